@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -35,8 +37,10 @@ import (
 	opentsdbhttpserver "github.com/VictoriaMetrics/VictoriaMetrics/lib/ingestserver/opentsdbhttp"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/procutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/pushgateway"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/pushmetrics"
 	"github.com/VictoriaMetrics/metrics"
 )
@@ -138,6 +142,9 @@ func main() {
 	}
 
 	promscrape.Init(remotewrite.Push)
+	pushgateway.Init(func(wr *prompbmarshal.WriteRequest) {
+		remotewrite.Push(nil, wr)
+	}, filepath.Join(remotewrite.GetTmpDataPath(), "pushgateway"))
 
 	if len(*httpListenAddr) > 0 {
 		go httpserver.Serve(*httpListenAddr, *useProxyProtocol, requestHandler)
@@ -157,6 +164,7 @@ func main() {
 	}
 
 	promscrape.Stop()
+	pushgateway.MustStop()
 
 	if len(*influxListenAddr) > 0 {
 		influxServer.MustStop()
@@ -250,6 +258,15 @@ func requestHandler(w http.ResponseWriter, r *http.Request) bool {
 		w.WriteHeader(statusCode)
 		return true
 	}
+	if strings.HasPrefix(path, "/prometheus/api/v1/pushgateway/") || strings.HasPrefix(path, "/api/v1/pushgateway/") {
+		pushgatewayRequests.Inc()
+		path = strings.TrimPrefix(path, "/prometheus")
+		if err := pushgateway.RequestHandler(w, r, strings.TrimPrefix(path, "/api/v1/pushgateway")); err != nil {
+			pushgatewayErrors.Inc()
+			httpserver.Errorf(w, r, "%s", err)
+		}
+		return true
+	}
 	if strings.HasPrefix(path, "datadog/") {
 		// Trim suffix from paths starting from /datadog/ in order to support legacy DataDog agent.
 		// See https://github.com/VictoriaMetrics/VictoriaMetrics/pull/2670
@@ -363,7 +380,8 @@ func requestHandler(w http.ResponseWriter, r *http.Request) bool {
 		promscrapeAPIV1TargetsRequests.Inc()
 		w.Header().Set("Content-Type", "application/json")
 		state := r.FormValue("state")
-		promscrape.WriteAPIV1Targets(w, state)
+		showScrapeHistory, _ := strconv.ParseBool(r.FormValue("scrape_history"))
+		promscrape.WriteAPIV1Targets(w, state, showScrapeHistory)
 		return true
 	case "/prometheus/target_response", "/target_response":
 		promscrapeTargetResponseRequests.Inc()
@@ -373,6 +391,14 @@ func requestHandler(w http.ResponseWriter, r *http.Request) bool {
 			return true
 		}
 		return true
+	case "/prometheus/target_debug", "/target_debug":
+		promscrapeTargetDebugRequests.Inc()
+		if err := promscrape.WriteTargetDebug(w, r); err != nil {
+			promscrapeTargetDebugErrors.Inc()
+			httpserver.Errorf(w, r, "%s", err)
+			return true
+		}
+		return true
 	case "/prometheus/config", "/config":
 		if !httpserver.CheckAuthFlag(w, r, *configAuthKey, "configAuthKey") {
 			return true
@@ -552,6 +578,9 @@ var (
 	prometheusimportRequests = metrics.NewCounter(`vmagent_http_requests_total{path="/api/v1/import/prometheus", protocol="prometheusimport"}`)
 	prometheusimportErrors   = metrics.NewCounter(`vmagent_http_request_errors_total{path="/api/v1/import/prometheus", protocol="prometheusimport"}`)
 
+	pushgatewayRequests = metrics.NewCounter(`vmagent_http_requests_total{path="/api/v1/pushgateway", protocol="pushgateway"}`)
+	pushgatewayErrors   = metrics.NewCounter(`vmagent_http_request_errors_total{path="/api/v1/pushgateway", protocol="pushgateway"}`)
+
 	nativeimportRequests = metrics.NewCounter(`vmagent_http_requests_total{path="/api/v1/import/native", protocol="nativeimport"}`)
 	nativeimportErrors   = metrics.NewCounter(`vmagent_http_request_errors_total{path="/api/v1/import/native", protocol="nativeimport"}`)
 
@@ -578,6 +607,8 @@ var (
 
 	promscrapeTargetResponseRequests = metrics.NewCounter(`vmagent_http_requests_total{path="/target_response"}`)
 	promscrapeTargetResponseErrors   = metrics.NewCounter(`vmagent_http_request_errors_total{path="/target_response"}`)
+	promscrapeTargetDebugRequests    = metrics.NewCounter(`vmagent_http_requests_total{path="/target_debug"}`)
+	promscrapeTargetDebugErrors      = metrics.NewCounter(`vmagent_http_request_errors_total{path="/target_debug"}`)
 
 	promscrapeConfigRequests       = metrics.NewCounter(`vmagent_http_requests_total{path="/config"}`)
 	promscrapeStatusConfigRequests = metrics.NewCounter(`vmagent_http_requests_total{path="/api/v1/status/config"}`)
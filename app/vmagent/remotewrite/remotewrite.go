@@ -92,6 +92,11 @@ func MultitenancyEnabled() bool {
 	return len(*remoteWriteMultitenantURLs) > 0
 }
 
+// GetTmpDataPath returns the path to -remoteWrite.tmpDataPath directory.
+func GetTmpDataPath() string {
+	return *tmpDataPath
+}
+
 // Contains the current relabelConfigs.
 var allRelabelConfigs atomic.Value
 
@@ -36,6 +36,16 @@ type Config struct {
 	// StaticConfigs contains list of static targets
 	StaticConfigs []StaticConfig `yaml:"static_configs,omitempty"`
 
+	// WebhookConfigs contains list of receivers for sending notifications
+	// to arbitrary HTTP endpoints
+	WebhookConfigs []WebhookConfig `yaml:"webhook_configs,omitempty"`
+	// SlackConfigs contains list of receivers for sending notifications
+	// to Slack-compatible incoming webhooks
+	SlackConfigs []SlackConfig `yaml:"slack_configs,omitempty"`
+	// PagerDutyConfigs contains list of receivers for sending notifications
+	// to PagerDuty Events API v2
+	PagerDutyConfigs []PagerDutyConfig `yaml:"pagerduty_configs,omitempty"`
+
 	// HTTPClientConfig contains HTTP configuration for Notifier clients
 	HTTPClientConfig promauth.HTTPClientConfig `yaml:",inline"`
 	// RelabelConfigs contains list of relabeling rules for entities discovered via SD
@@ -14,6 +14,7 @@ func TestConfigParseGood(t *testing.T) {
 	f("testdata/consul.good.yaml")
 	f("testdata/dns.good.yaml")
 	f("testdata/static.good.yaml")
+	f("testdata/receivers.good.yaml")
 }
 
 func TestConfigParseBad(t *testing.T) {
@@ -29,4 +30,8 @@ func TestConfigParseBad(t *testing.T) {
 
 	f("testdata/unknownFields.bad.yaml", "unknown field")
 	f("non-existing-file", "error reading")
+	f("testdata/receiverUnknownFields.bad.yaml", "unknown fields in slack_configs: unknown")
+	f("testdata/receiverMissingURL.bad.yaml", "missing `url`")
+	f("testdata/receiverBadTemplate.bad.yaml", "invalid `summary`")
+	f("testdata/receiverMissingRoutingKey.bad.yaml", "missing `routing_key`")
 }
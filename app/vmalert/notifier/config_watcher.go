@@ -182,6 +182,48 @@ func (cw *configWatcher) start() error {
 		cw.setTargets(TargetStatic, targets)
 	}
 
+	if len(cw.cfg.WebhookConfigs) > 0 {
+		var targets []Target
+		for i := range cw.cfg.WebhookConfigs {
+			notifier, err := NewWebhook(&cw.cfg.WebhookConfigs[i], cw.cfg.baseDir, cw.genFn)
+			if err != nil {
+				return fmt.Errorf("failed to init webhook notifier #%d: %s", i+1, err)
+			}
+			targets = append(targets, Target{
+				Notifier: notifier,
+			})
+		}
+		cw.setTargets(TargetWebhook, targets)
+	}
+
+	if len(cw.cfg.SlackConfigs) > 0 {
+		var targets []Target
+		for i := range cw.cfg.SlackConfigs {
+			notifier, err := NewSlack(&cw.cfg.SlackConfigs[i], cw.cfg.baseDir, cw.genFn)
+			if err != nil {
+				return fmt.Errorf("failed to init slack notifier #%d: %s", i+1, err)
+			}
+			targets = append(targets, Target{
+				Notifier: notifier,
+			})
+		}
+		cw.setTargets(TargetSlack, targets)
+	}
+
+	if len(cw.cfg.PagerDutyConfigs) > 0 {
+		var targets []Target
+		for i := range cw.cfg.PagerDutyConfigs {
+			notifier, err := NewPagerDuty(&cw.cfg.PagerDutyConfigs[i], cw.cfg.baseDir, cw.genFn)
+			if err != nil {
+				return fmt.Errorf("failed to init pagerduty notifier #%d: %s", i+1, err)
+			}
+			targets = append(targets, Target{
+				Notifier: notifier,
+			})
+		}
+		cw.setTargets(TargetPagerDuty, targets)
+	}
+
 	if len(cw.cfg.ConsulSDConfigs) > 0 {
 		err := cw.add(TargetConsul, *consul.SDCheckInterval, func() ([]*promutils.Labels, error) {
 			var labels []*promutils.Labels
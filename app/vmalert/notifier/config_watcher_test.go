@@ -318,3 +318,57 @@ func TestMergeHTTPClientConfigs(t *testing.T) {
 		t.Fatalf("expected BasicAuth tp be present")
 	}
 }
+
+func TestConfigWatcherReceivers(t *testing.T) {
+	urlFile, err := os.CreateTemp("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Remove(urlFile.Name()) }()
+	writeToFile(t, urlFile.Name(), "https://hooks.slack.com/services/T000/B000/XXXX\n")
+
+	f, err := os.CreateTemp("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+	writeToFile(t, f.Name(), fmt.Sprintf(`
+webhook_configs:
+  - url: http://localhost:8080/hook
+slack_configs:
+  - url_file: %s
+    channel: '#alerts'
+pagerduty_configs:
+  - routing_key: foo
+`, urlFile.Name()))
+
+	cw, err := newWatcher(f.Name(), nil)
+	if err != nil {
+		t.Fatalf("failed to start config watcher: %s", err)
+	}
+	defer cw.mustStop()
+	if len(cw.notifiers()) != 3 {
+		t.Fatalf("expected to have 3 notifiers; got %d", len(cw.notifiers()))
+	}
+	f2 := func(typ TargetType, expAddr string) {
+		t.Helper()
+		targets := cw.targets[typ]
+		if len(targets) != 1 {
+			t.Fatalf("expected to have 1 target of type %q; got %d", typ, len(targets))
+		}
+		if targets[0].Addr() != expAddr {
+			t.Fatalf("expected to get %q; got %q instead", expAddr, targets[0].Addr())
+		}
+	}
+	f2(TargetWebhook, "http://localhost:8080/hook")
+	f2(TargetSlack, "https://hooks.slack.com/<secret> #alerts")
+	f2(TargetPagerDuty, defaultPagerDutyURL)
+
+	writeToFile(t, f.Name(), `
+pagerduty_configs:
+  - routing_key_file: non-existing-file
+`)
+	if err := cw.reload(f.Name()); err == nil {
+		t.Fatalf("expected to get non-nil error for missing routing_key_file")
+	}
+}
@@ -169,6 +169,12 @@ const (
 	TargetConsul TargetType = "consulSD"
 	// TargetDNS is for targets discovered via DNS
 	TargetDNS TargetType = "DNSSD"
+	// TargetWebhook is for receivers configured via webhook_configs
+	TargetWebhook TargetType = "webhook"
+	// TargetSlack is for receivers configured via slack_configs
+	TargetSlack TargetType = "slack"
+	// TargetPagerDuty is for receivers configured via pagerduty_configs
+	TargetPagerDuty TargetType = "pagerduty"
 )
 
 // GetTargets returns list of static or discovered targets
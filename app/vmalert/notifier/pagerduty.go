@@ -0,0 +1,282 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+)
+
+const (
+	defaultPagerDutyURL      = "https://events.pagerduty.com/v2/enqueue"
+	defaultPagerDutySummary  = `{{ .Name }}{{ if .Annotations.summary }}: {{ .Annotations.summary }}{{ end }}`
+	defaultPagerDutySeverity = `{{ if .Labels.severity }}{{ .Labels.severity }}{{ else }}error{{ end }}`
+	defaultPagerDutySource   = "vmalert"
+
+	// pagerDutyMaxSummaryLen is the maximum length of the event summary accepted by PagerDuty
+	pagerDutyMaxSummaryLen = 1024
+)
+
+// PagerDutyConfig contains settings for sending notifications
+// to PagerDuty Events API v2.
+type PagerDutyConfig struct {
+	// URL is the address of PagerDuty Events API v2
+	URL string `yaml:"url,omitempty"`
+	// RoutingKey is the integration key of PagerDuty service
+	RoutingKey *promauth.Secret `yaml:"routing_key,omitempty"`
+	// RoutingKeyFile is a path to the file with the integration key
+	RoutingKeyFile string `yaml:"routing_key_file,omitempty"`
+
+	// Summary is a template for the event summary
+	Summary string `yaml:"summary,omitempty"`
+	// Severity is a template for the event severity.
+	// Must be evaluated to one of critical, error, warning or info.
+	Severity string `yaml:"severity,omitempty"`
+	// Source is a template for the event source
+	Source string `yaml:"source,omitempty"`
+	// Component is an optional template for the event component
+	Component string `yaml:"component,omitempty"`
+	// Group is an optional template for the event group
+	Group string `yaml:"group,omitempty"`
+	// Class is an optional template for the event class
+	Class string `yaml:"class,omitempty"`
+
+	ReceiverConfig `yaml:",inline"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (pc *PagerDutyConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type pagerDutyConfig PagerDutyConfig
+	if err := unmarshal((*pagerDutyConfig)(pc)); err != nil {
+		return err
+	}
+	if err := checkUnknownFields("pagerduty_configs", pc.XXX); err != nil {
+		return err
+	}
+	if pc.RoutingKey == nil && pc.RoutingKeyFile == "" {
+		return fmt.Errorf("missing `routing_key` or `routing_key_file` in pagerduty_configs")
+	}
+	if pc.RoutingKey != nil && pc.RoutingKeyFile != "" {
+		return fmt.Errorf("only one of `routing_key` or `routing_key_file` must be set in pagerduty_configs")
+	}
+	if pc.URL == "" {
+		pc.URL = defaultPagerDutyURL
+	}
+	if _, err := url.Parse(pc.URL); err != nil {
+		return fmt.Errorf("invalid `url` %q in pagerduty_configs: %w", pc.URL, err)
+	}
+	if pc.Summary == "" {
+		pc.Summary = defaultPagerDutySummary
+	}
+	if pc.Severity == "" {
+		pc.Severity = defaultPagerDutySeverity
+	}
+	if pc.Source == "" {
+		pc.Source = defaultPagerDutySource
+	}
+	for _, f := range []struct {
+		name string
+		text string
+	}{
+		{"summary", pc.Summary},
+		{"severity", pc.Severity},
+		{"source", pc.Source},
+		{"component", pc.Component},
+		{"group", pc.Group},
+		{"class", pc.Class},
+	} {
+		if err := validateTemplate(f.text); err != nil {
+			return fmt.Errorf("invalid `%s` in pagerduty_configs: %w", f.name, err)
+		}
+	}
+	return pc.ReceiverConfig.init()
+}
+
+// PagerDuty sends notifications to PagerDuty Events API v2
+type PagerDuty struct {
+	*receiver
+
+	routingKey string
+	cfg        *PagerDutyConfig
+}
+
+// NewPagerDuty is a constructor for PagerDuty
+func NewPagerDuty(cfg *PagerDutyConfig, baseDir string, fn AlertURLGenerator) (*PagerDuty, error) {
+	routingKey := cfg.RoutingKey.String()
+	if cfg.RoutingKeyFile != "" {
+		path := fs.GetFilepath(baseDir, cfg.RoutingKeyFile)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read PagerDuty routing key from `routing_key_file` %q: %w", path, err)
+		}
+		routingKey = strings.TrimSpace(string(data))
+	}
+	if routingKey == "" {
+		return nil, fmt.Errorf("routing key in pagerduty_configs cannot be empty")
+	}
+	pd := &PagerDuty{
+		routingKey: routingKey,
+		cfg:        cfg,
+	}
+	r, err := newReceiver(cfg.URL, cfg.URL, &cfg.ReceiverConfig, baseDir, fn, pd.buildPayloads)
+	if err != nil {
+		return nil, err
+	}
+	pd.receiver = r
+	return pd, nil
+}
+
+// pagerDutyEvent is the request body for PagerDuty Events API v2.
+// See https://developer.pagerduty.com/docs/ZG9jOjExMDI5NTgw-events-api-v2-overview
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	Client      string            `json:"client,omitempty"`
+	ClientURL   string            `json:"client_url,omitempty"`
+	Links       []pagerDutyLink   `json:"links,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     string                 `json:"timestamp,omitempty"`
+	Component     string                 `json:"component,omitempty"`
+	Group         string                 `json:"group,omitempty"`
+	Class         string                 `json:"class,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+type pagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
+
+// pagerDutyTplData is the data passed to PagerDuty templates.
+// Templates are executed for every alert.
+type pagerDutyTplData struct {
+	receiverAlert
+	ExternalURL    string
+	ExternalLabels map[string]string
+}
+
+// buildPayloads returns a separate event for every alert,
+// since PagerDuty deduplicates events by dedup_key.
+//
+// An alert, which cannot be converted to event, gets payload with non-nil err,
+// so it doesn't prevent sending the rest of alerts.
+func (pd *PagerDuty) buildPayloads(status string, alerts []receiverAlert) ([]receiverPayload, error) {
+	payloads := make([]receiverPayload, 0, len(alerts))
+	for _, ra := range alerts {
+		p := receiverPayload{
+			alerts: []receiverAlert{ra},
+		}
+		p.body, p.err = pd.marshalEvent(status, ra)
+		payloads = append(payloads, p)
+	}
+	return payloads, nil
+}
+
+func (pd *PagerDuty) marshalEvent(status string, ra receiverAlert) ([]byte, error) {
+	event, err := pd.newEvent(status, ra)
+	if err != nil {
+		return nil, fmt.Errorf("cannot build event for alert %q: %w", ra.Name, err)
+	}
+	b, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal PagerDuty event for alert %q: %w", ra.Name, err)
+	}
+	return b, nil
+}
+
+func (pd *PagerDuty) newEvent(status string, ra receiverAlert) (*pagerDutyEvent, error) {
+	event := &pagerDutyEvent{
+		RoutingKey:  pd.routingKey,
+		EventAction: "trigger",
+		DedupKey:    ra.Fingerprint,
+	}
+	if status == "resolved" {
+		// PagerDuty requires only dedup_key for resolving the incident.
+		event.EventAction = "resolve"
+		return event, nil
+	}
+
+	data := &pagerDutyTplData{
+		receiverAlert:  ra,
+		ExternalURL:    externalURL,
+		ExternalLabels: externalLabels,
+	}
+	var fields [6]string
+	for i, text := range []string{pd.cfg.Summary, pd.cfg.Severity, pd.cfg.Source, pd.cfg.Component, pd.cfg.Group, pd.cfg.Class} {
+		if text == "" {
+			continue
+		}
+		s, err := executeTemplate(text, data)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = strings.TrimSpace(s)
+	}
+	summary, severity, source := fields[0], fields[1], fields[2]
+	if len(summary) > pagerDutyMaxSummaryLen {
+		// Truncate the summary on rune boundary in order to keep it valid UTF-8.
+		n := pagerDutyMaxSummaryLen
+		for n > 0 && !utf8.RuneStart(summary[n]) {
+			n--
+		}
+		summary = summary[:n]
+	}
+	if !isValidPagerDutySeverity(severity) {
+		severity = "error"
+	}
+	if source == "" {
+		source = defaultPagerDutySource
+	}
+	event.Payload = &pagerDutyPayload{
+		Summary:   summary,
+		Source:    source,
+		Severity:  severity,
+		Component: fields[3],
+		Group:     fields[4],
+		Class:     fields[5],
+		CustomDetails: map[string]interface{}{
+			"labels":      ra.Labels,
+			"annotations": ra.Annotations,
+			// The value is passed as string, since JSON doesn't support Inf and NaN.
+			"value": strconv.FormatFloat(ra.Value, 'g', -1, 64),
+		},
+	}
+	if !ra.StartsAt.IsZero() {
+		event.Payload.Timestamp = ra.StartsAt.Format(time.RFC3339)
+	}
+	event.Client = "vmalert"
+	event.ClientURL = externalURL
+	if ra.GeneratorURL != "" {
+		event.Links = append(event.Links, pagerDutyLink{
+			Href: ra.GeneratorURL,
+			Text: "Alert source",
+		})
+	}
+	return event, nil
+}
+
+func isValidPagerDutySeverity(s string) bool {
+	switch s {
+	case "critical", "error", "warning", "info":
+		return true
+	default:
+		return false
+	}
+}
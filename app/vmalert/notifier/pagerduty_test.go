@@ -0,0 +1,189 @@
+package notifier
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+)
+
+func TestPagerDutyBuildPayloads(t *testing.T) {
+	cfg := &PagerDutyConfig{
+		URL:        defaultPagerDutyURL,
+		RoutingKey: promauth.NewSecret("key"),
+		Summary:    defaultPagerDutySummary,
+		Severity:   defaultPagerDutySeverity,
+		Source:     `{{ .Labels.instance }}`,
+		Component:  `{{ .Labels.job }}`,
+	}
+	if err := cfg.ReceiverConfig.init(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	pd, err := NewPagerDuty(cfg, "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer pd.Close()
+
+	alerts := []receiverAlert{
+		{
+			Name:         "InstanceDown",
+			Labels:       map[string]string{"alertname": "InstanceDown", "instance": "foo", "job": "bar", "severity": "critical"},
+			Annotations:  map[string]string{"summary": "foo is down"},
+			StartsAt:     time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			GeneratorURL: "http://vmalert/alert",
+			Fingerprint:  "00000000000000010000000000000002",
+		},
+		{
+			Name:        "DiskFull",
+			Labels:      map[string]string{"alertname": "DiskFull", "severity": "page"},
+			Annotations: map[string]string{"summary": strings.Repeat("a", 2000)},
+			Fingerprint: "00000000000000010000000000000003",
+		},
+	}
+	payloads, err := pd.buildPayloads("firing", alerts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(payloads) != 2 {
+		t.Fatalf("expected to get 2 payloads; got %d", len(payloads))
+	}
+	var events []pagerDutyEvent
+	for _, p := range payloads {
+		var e pagerDutyEvent
+		if err := json.Unmarshal(p.body, &e); err != nil {
+			t.Fatalf("cannot unmarshal PagerDuty event: %s", err)
+		}
+		events = append(events, e)
+	}
+
+	e := events[0]
+	if e.RoutingKey != "key" || e.EventAction != "trigger" || e.DedupKey != alerts[0].Fingerprint {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	if e.Payload == nil {
+		t.Fatalf("expected to get non-nil payload")
+	}
+	if e.Payload.Summary != "InstanceDown: foo is down" {
+		t.Fatalf("unexpected summary %q", e.Payload.Summary)
+	}
+	if e.Payload.Severity != "critical" {
+		t.Fatalf("unexpected severity %q", e.Payload.Severity)
+	}
+	if e.Payload.Source != "foo" || e.Payload.Component != "bar" {
+		t.Fatalf("unexpected source %q or component %q", e.Payload.Source, e.Payload.Component)
+	}
+	if e.Payload.Timestamp != "2023-01-01T00:00:00Z" {
+		t.Fatalf("unexpected timestamp %q", e.Payload.Timestamp)
+	}
+	if len(e.Links) != 1 || e.Links[0].Href != alerts[0].GeneratorURL {
+		t.Fatalf("unexpected links %+v", e.Links)
+	}
+
+	// invalid severity must fall back to error, empty source must fall back to default
+	e = events[1]
+	if e.Payload.Severity != "error" {
+		t.Fatalf("unexpected severity %q", e.Payload.Severity)
+	}
+	if e.Payload.Source != defaultPagerDutySource {
+		t.Fatalf("unexpected source %q", e.Payload.Source)
+	}
+	if len(e.Payload.Summary) != pagerDutyMaxSummaryLen {
+		t.Fatalf("expected summary to be truncated to %d; got %d", pagerDutyMaxSummaryLen, len(e.Payload.Summary))
+	}
+
+	// resolve events must contain only dedup_key
+	payloads, err = pd.buildPayloads("resolved", alerts[:1])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var resolved map[string]interface{}
+	if err := json.Unmarshal(payloads[0].body, &resolved); err != nil {
+		t.Fatalf("cannot unmarshal PagerDuty event: %s", err)
+	}
+	if resolved["event_action"] != "resolve" || resolved["dedup_key"] != alerts[0].Fingerprint {
+		t.Fatalf("unexpected resolve event %s", payloads[0].body)
+	}
+	if _, ok := resolved["payload"]; ok {
+		t.Fatalf("unexpected payload in resolve event %s", payloads[0].body)
+	}
+}
+
+func TestPagerDutyBuildPayloadsPerAlertFailure(t *testing.T) {
+	cfg := &PagerDutyConfig{
+		URL:        defaultPagerDutyURL,
+		RoutingKey: promauth.NewSecret("key"),
+		Summary:    `{{ .Annotations.summary }}`,
+		Severity:   defaultPagerDutySeverity,
+		Component:  `{{ if .Labels.broken }}{{ .Labels.broken.foo }}{{ end }}`,
+	}
+	if err := cfg.ReceiverConfig.init(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	pd, err := NewPagerDuty(cfg, "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer pd.Close()
+
+	alerts := []receiverAlert{
+		{
+			Name:        "Inf",
+			Labels:      map[string]string{"alertname": "Inf"},
+			Annotations: map[string]string{"summary": strings.Repeat("ж", pagerDutyMaxSummaryLen)},
+			Value:       math.Inf(1),
+			Fingerprint: "00000000000000010000000000000001",
+		},
+		{
+			Name:        "NaN",
+			Labels:      map[string]string{"alertname": "NaN"},
+			Value:       math.NaN(),
+			Fingerprint: "00000000000000010000000000000002",
+		},
+		{
+			Name:        "BrokenTemplate",
+			Labels:      map[string]string{"alertname": "BrokenTemplate", "broken": "true"},
+			Value:       1,
+			Fingerprint: "00000000000000010000000000000003",
+		},
+	}
+	payloads, err := pd.buildPayloads("firing", alerts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(payloads) != len(alerts) {
+		t.Fatalf("expected to get %d payloads; got %d", len(alerts), len(payloads))
+	}
+
+	f := func(p receiverPayload, valueExpected string) {
+		t.Helper()
+		if p.err != nil {
+			t.Fatalf("unexpected error: %s", p.err)
+		}
+		var e pagerDutyEvent
+		if err := json.Unmarshal(p.body, &e); err != nil {
+			t.Fatalf("cannot unmarshal PagerDuty event: %s", err)
+		}
+		if v := e.Payload.CustomDetails["value"]; v != valueExpected {
+			t.Fatalf("unexpected value; got %v; want %q", v, valueExpected)
+		}
+		if !utf8.ValidString(e.Payload.Summary) || len(e.Payload.Summary) > pagerDutyMaxSummaryLen {
+			t.Fatalf("unexpected summary %q", e.Payload.Summary)
+		}
+	}
+	f(payloads[0], "+Inf")
+	f(payloads[1], "NaN")
+
+	// template error must fail only the corresponding alert
+	p := payloads[2]
+	if p.err == nil {
+		t.Fatalf("expecting non-nil error for alert with broken template")
+	}
+	if len(p.alerts) != 1 || p.alerts[0].Name != "BrokenTemplate" {
+		t.Fatalf("unexpected alerts for failed payload: %v", p.alerts)
+	}
+}
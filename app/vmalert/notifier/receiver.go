@@ -0,0 +1,505 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	textTpl "text/template"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/templates"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmalert/utils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promrelabel"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+// ReceiverConfig contains common settings for receivers,
+// which send notifications directly to third-party services without Alertmanager.
+type ReceiverConfig struct {
+	// SendResolved defines whether to notify about resolved alerts.
+	// Default is true.
+	SendResolved *bool `yaml:"send_resolved,omitempty"`
+	// RepeatInterval defines how long to wait before sending
+	// a notification again for the alert, which is still firing.
+	RepeatInterval *promutils.Duration `yaml:"repeat_interval,omitempty"`
+	// The timeout used when sending notifications.
+	Timeout *promutils.Duration `yaml:"timeout,omitempty"`
+	// AlertRelabelConfigs contains list of relabeling rules for alert labels.
+	// Alerts with empty labels after relabeling are dropped.
+	AlertRelabelConfigs []promrelabel.RelabelConfig `yaml:"alert_relabel_configs,omitempty"`
+
+	// HTTPClientConfig contains HTTP configuration for the receiver
+	HTTPClientConfig promauth.HTTPClientConfig `yaml:",inline"`
+
+	// stores already parsed AlertRelabelConfigs object
+	parsedAlertRelabelConfigs *promrelabel.ParsedConfigs
+}
+
+const (
+	defaultRepeatInterval  = 4 * time.Hour
+	defaultReceiverTimeout = 10 * time.Second
+)
+
+func (rc *ReceiverConfig) init() error {
+	if rc.SendResolved == nil {
+		sendResolved := true
+		rc.SendResolved = &sendResolved
+	}
+	if rc.RepeatInterval.Duration() == 0 {
+		rc.RepeatInterval = promutils.NewDuration(defaultRepeatInterval)
+	}
+	if rc.Timeout.Duration() == 0 {
+		rc.Timeout = promutils.NewDuration(defaultReceiverTimeout)
+	}
+	arCfg, err := promrelabel.ParseRelabelConfigs(rc.AlertRelabelConfigs)
+	if err != nil {
+		return fmt.Errorf("failed to parse alert relabeling config: %w", err)
+	}
+	rc.parsedAlertRelabelConfigs = arCfg
+	return nil
+}
+
+// checkUnknownFields returns an error if xxx contains fields, which weren't recognized during parsing.
+func checkUnknownFields(section string, xxx map[string]interface{}) error {
+	if len(xxx) == 0 {
+		return nil
+	}
+	var keys []string
+	for k := range xxx {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return fmt.Errorf("unknown fields in %s: %s", section, strings.Join(keys, ", "))
+}
+
+// validateTemplate checks whether the given text is a valid template.
+func validateTemplate(text string) error {
+	_, err := parseTemplate(text)
+	return err
+}
+
+func parseTemplate(text string) (*textTpl.Template, error) {
+	tmpl, err := templates.Get()
+	if err != nil {
+		return nil, fmt.Errorf("error cloning template: %w", err)
+	}
+	// Clone() doesn't copy tpl Options, so we set them manually
+	tmpl = tmpl.Option("missingkey=zero")
+	tmpl, err = tmpl.Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template %q: %w", text, err)
+	}
+	return tmpl, nil
+}
+
+// executeTemplate executes the given template text for data.
+//
+// The template is parsed on every call, so the changes to templates
+// loaded via -rule.templates are applied without re-creating the receiver.
+func executeTemplate(text string, data interface{}) (string, error) {
+	tmpl, err := parseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	var bb bytes.Buffer
+	if err := tmpl.Execute(&bb, data); err != nil {
+		return "", fmt.Errorf("error evaluating template %q: %w", text, err)
+	}
+	return bb.String(), nil
+}
+
+// receiverAlert is an alert representation passed to receiver templates.
+type receiverAlert struct {
+	// Status is either "firing" or "resolved"
+	Status       string            `json:"status"`
+	Name         string            `json:"-"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	Value        float64           `json:"-"`
+	GeneratorURL string            `json:"generatorURL"`
+	// Fingerprint uniquely identifies the alert
+	Fingerprint string `json:"fingerprint"`
+
+	key alertKey
+}
+
+// receiverData is the data passed to receiver templates, which are executed for a list of alerts.
+type receiverData struct {
+	// Status is either "firing" or "resolved"
+	Status            string
+	Alerts            []receiverAlert
+	CommonLabels      map[string]string
+	CommonAnnotations map[string]string
+	ExternalURL       string
+	ExternalLabels    map[string]string
+}
+
+func newReceiverData(status string, alerts []receiverAlert) *receiverData {
+	rd := &receiverData{
+		Status:         status,
+		Alerts:         alerts,
+		ExternalURL:    externalURL,
+		ExternalLabels: externalLabels,
+	}
+	commonLabels := make([]map[string]string, len(alerts))
+	commonAnnotations := make([]map[string]string, len(alerts))
+	for i := range alerts {
+		commonLabels[i] = alerts[i].Labels
+		commonAnnotations[i] = alerts[i].Annotations
+	}
+	rd.CommonLabels = getCommonPairs(commonLabels)
+	rd.CommonAnnotations = getCommonPairs(commonAnnotations)
+	return rd
+}
+
+// getCommonPairs returns key-value pairs, which are present in all the given maps.
+func getCommonPairs(ms []map[string]string) map[string]string {
+	common := make(map[string]string)
+	if len(ms) == 0 {
+		return common
+	}
+	for k, v := range ms[0] {
+		common[k] = v
+	}
+	for _, m := range ms[1:] {
+		for k, v := range common {
+			if m[k] != v {
+				delete(common, k)
+			}
+		}
+	}
+	return common
+}
+
+// alertKey uniquely identifies the alert among all the groups
+type alertKey struct {
+	groupID uint64
+	id      uint64
+}
+
+// notificationState holds the notification history for a single alert.
+type notificationState struct {
+	// lastNotified is the last time the notification about the firing alert was successfully sent
+	lastNotified time.Time
+	// lastSeen is the last time the alert was passed to the receiver
+	lastSeen time.Time
+	// endsAt is the time when the alert is considered resolved
+	// if it isn't passed to the receiver again
+	endsAt time.Time
+	// alert is the last alert sent to the receiver.
+	// It is used for notifying about expired alerts.
+	alert receiverAlert
+	// resolving is set while the notification about resolved alert is in progress
+	resolving bool
+}
+
+// receiverPayload is a single request body sent to the receiver
+// together with alerts it contains.
+type receiverPayload struct {
+	body   []byte
+	alerts []receiverAlert
+
+	// err is set if the body couldn't be built for alerts.
+	// Such alerts are marked as failed without affecting the rest of payloads.
+	err error
+}
+
+// payloadBuilder builds request bodies for the given alerts with the same status.
+type payloadBuilder func(status string, alerts []receiverAlert) ([]receiverPayload, error)
+
+// minStateRetention is the minimum duration for keeping the notification state
+// for alerts, which are no longer passed to the receiver.
+const minStateRetention = 24 * time.Hour
+
+// receiver is a base implementation of Notifier for services, which
+// accept notifications via HTTP POST requests.
+//
+// Unlike Alertmanager, such services do not deduplicate notifications,
+// so receiver keeps track of the sent notifications in memory and sends them
+// only when alert becomes firing, when it is resolved and every repeatInterval
+// while it is still firing.
+type receiver struct {
+	addr    string
+	url     string
+	client  *http.Client
+	timeout time.Duration
+	authCfg *promauth.Config
+
+	argFunc AlertURLGenerator
+	// stores already parsed AlertRelabelConfigs object
+	relabelConfigs *promrelabel.ParsedConfigs
+	sendResolved   bool
+	repeatInterval time.Duration
+	buildPayloads  payloadBuilder
+
+	metrics *metrics
+
+	mu       sync.Mutex
+	notified map[alertKey]*notificationState
+}
+
+func newReceiver(addr, u string, rc *ReceiverConfig, baseDir string, fn AlertURLGenerator, pb payloadBuilder) (*receiver, error) {
+	ac, err := rc.HTTPClientConfig.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure auth: %w", err)
+	}
+	tr := &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: ac.NewTLSConfig(),
+	}
+	return &receiver{
+		addr:           addr,
+		url:            u,
+		client:         &http.Client{Transport: tr},
+		timeout:        rc.Timeout.Duration(),
+		authCfg:        ac,
+		argFunc:        fn,
+		relabelConfigs: rc.parsedAlertRelabelConfigs,
+		sendResolved:   rc.SendResolved == nil || *rc.SendResolved,
+		repeatInterval: rc.RepeatInterval.Duration(),
+		buildPayloads:  pb,
+		metrics:        newMetrics(addr),
+		notified:       make(map[alertKey]*notificationState),
+	}, nil
+}
+
+// Addr returns address where notifications are sent.
+func (r *receiver) Addr() string { return r.addr }
+
+// Close is a destructor method for receiver
+func (r *receiver) Close() {
+	r.metrics.alertsSent.Unregister()
+	r.metrics.alertsSendErrors.Unregister()
+}
+
+// Send sends notifications for the given alerts if needed.
+func (r *receiver) Send(ctx context.Context, alerts []Alert, headers map[string]string) error {
+	firing, resolved := r.selectAlerts(alerts, time.Now())
+	eg := new(utils.ErrGroup)
+	for _, g := range []struct {
+		status string
+		alerts []receiverAlert
+	}{
+		{status: "firing", alerts: firing},
+		{status: "resolved", alerts: resolved},
+	} {
+		if len(g.alerts) == 0 {
+			continue
+		}
+		r.metrics.alertsSent.Add(len(g.alerts))
+		payloads, err := r.buildPayloads(g.status, g.alerts)
+		if err != nil {
+			r.metrics.alertsSendErrors.Add(len(g.alerts))
+			r.markFailed(g.alerts)
+			eg.Add(fmt.Errorf("cannot build %s notification for %q: %w", g.status, r.addr, err))
+			continue
+		}
+		for _, p := range payloads {
+			if p.err != nil {
+				r.metrics.alertsSendErrors.Add(len(p.alerts))
+				r.markFailed(p.alerts)
+				eg.Add(fmt.Errorf("cannot build %s notification for %q: %w", g.status, r.addr, p.err))
+				continue
+			}
+			if err := r.send(ctx, p.body, headers); err != nil {
+				r.metrics.alertsSendErrors.Add(len(p.alerts))
+				r.markFailed(p.alerts)
+				eg.Add(err)
+				continue
+			}
+			r.markNotified(p.alerts, time.Now())
+		}
+	}
+	return eg.Err()
+}
+
+// selectAlerts returns firing and resolved alerts, which must be sent to the receiver at the given time.
+//
+// Besides the given alerts, it returns previously notified alerts with expired endsAt as resolved.
+// Such alerts are no longer sent by vmalert, for example, if the corresponding rule was removed.
+// This is the same behavior as in Alertmanager, which resolves alerts after their endsAt.
+func (r *receiver) selectAlerts(alerts []Alert, now time.Time) ([]receiverAlert, []receiverAlert) {
+	var firing, resolved []receiverAlert
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range alerts {
+		a := &alerts[i]
+		if a.State == StatePending {
+			continue
+		}
+		key := alertKey{groupID: a.GroupID, id: a.ID}
+		st := r.notified[key]
+		if a.State == StateInactive {
+			if st == nil || st.lastNotified.IsZero() {
+				// There is no need to notify about resolved alert,
+				// if there were no notifications about it.
+				delete(r.notified, key)
+				continue
+			}
+			if st.resolving {
+				continue
+			}
+			if !r.sendResolved {
+				delete(r.notified, key)
+				continue
+			}
+			if ra, ok := r.newReceiverAlert(a, key, "resolved"); ok {
+				st.resolving = true
+				resolved = append(resolved, ra)
+			}
+			continue
+		}
+		if st == nil {
+			st = &notificationState{}
+			r.notified[key] = st
+		}
+		st.lastSeen = now
+		st.endsAt = a.End
+		if !st.lastNotified.IsZero() && now.Sub(st.lastNotified) < r.repeatInterval {
+			continue
+		}
+		if ra, ok := r.newReceiverAlert(a, key, "firing"); ok {
+			firing = append(firing, ra)
+		}
+	}
+
+	retention := minStateRetention
+	if d := 2 * r.repeatInterval; d > retention {
+		retention = d
+	}
+	for key, st := range r.notified {
+		if !st.lastNotified.IsZero() && !st.resolving && !st.endsAt.IsZero() && now.After(st.endsAt) {
+			if !r.sendResolved {
+				delete(r.notified, key)
+				continue
+			}
+			ra := st.alert
+			ra.Status = "resolved"
+			ra.EndsAt = st.endsAt
+			st.resolving = true
+			resolved = append(resolved, ra)
+			continue
+		}
+		// Drop the state for alerts, which weren't notified and are no longer passed to the receiver.
+		if now.Sub(st.lastSeen) > retention {
+			delete(r.notified, key)
+		}
+	}
+	return firing, resolved
+}
+
+// markNotified updates the notification state for the successfully sent alerts.
+func (r *receiver) markNotified(alerts []receiverAlert, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, ra := range alerts {
+		if ra.Status == "resolved" {
+			delete(r.notified, ra.key)
+			continue
+		}
+		st := r.notified[ra.key]
+		if st == nil {
+			st = &notificationState{lastSeen: now}
+			r.notified[ra.key] = st
+		}
+		st.lastNotified = now
+		st.alert = ra
+	}
+}
+
+// markFailed updates the notification state for alerts, which couldn't be sent,
+// so the notifications about resolved alerts are retried on the next Send call.
+func (r *receiver) markFailed(alerts []receiverAlert) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, ra := range alerts {
+		if st := r.notified[ra.key]; st != nil {
+			st.resolving = false
+		}
+	}
+}
+
+// newReceiverAlert converts a to receiverAlert.
+//
+// It returns false if the alert has been dropped by alert relabeling.
+func (r *receiver) newReceiverAlert(a *Alert, key alertKey, status string) (receiverAlert, bool) {
+	labels := a.toPromLabels(r.relabelConfigs)
+	if len(labels) == 0 {
+		return receiverAlert{}, false
+	}
+	ls := make(map[string]string, len(labels))
+	for _, l := range labels {
+		ls[l.Name] = l.Value
+	}
+	ra := receiverAlert{
+		Status:      status,
+		Name:        a.Name,
+		Labels:      ls,
+		Annotations: a.Annotations,
+		StartsAt:    a.Start,
+		EndsAt:      a.End,
+		Value:       a.Value,
+		Fingerprint: fmt.Sprintf("%016x%016x", a.GroupID, a.ID),
+		key:         key,
+	}
+	if r.argFunc != nil {
+		ra.GeneratorURL = r.argFunc(*a)
+	}
+	return ra, true
+}
+
+func (r *receiver) send(ctx context.Context, body []byte, headers map[string]string) error {
+	req, err := http.NewRequest(http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+	req = req.WithContext(ctx)
+
+	if r.authCfg != nil {
+		r.authCfg.SetHeaders(req, true)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		// Do not expose r.url in the error message, since it may contain secrets.
+		return fmt.Errorf("failed to send request to %q: %w", r.addr, unwrapURLError(err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read response from %q: %w", r.addr, err)
+		}
+		return fmt.Errorf("invalid SC %d from %q; response body: %s", resp.StatusCode, r.addr, string(body))
+	}
+	return nil
+}
+
+// unwrapURLError returns the underlying error for *url.Error, since it contains the full request url.
+func unwrapURLError(err error) error {
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		return ue.Err
+	}
+	return err
+}
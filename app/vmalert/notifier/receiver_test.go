@@ -0,0 +1,262 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promrelabel"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+func TestReceiverSelectAlerts(t *testing.T) {
+	rc := &ReceiverConfig{
+		RepeatInterval: promutils.NewDuration(time.Hour),
+	}
+	if err := rc.init(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	r, err := newReceiver("foo", "http://localhost", rc, "", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer r.Close()
+
+	firing := Alert{GroupID: 1, ID: 1, Name: "foo", State: StateFiring, Labels: map[string]string{"alertname": "foo"}}
+	pending := Alert{GroupID: 1, ID: 2, Name: "bar", State: StatePending, Labels: map[string]string{"alertname": "bar"}}
+	resolved := firing
+	resolved.State = StateInactive
+
+	f := func(alerts []Alert, now time.Time, expFiring, expResolved int) {
+		t.Helper()
+		gotFiring, gotResolved := r.selectAlerts(alerts, now)
+		if len(gotFiring) != expFiring {
+			t.Fatalf("expected to get %d firing alerts; got %d", expFiring, len(gotFiring))
+		}
+		if len(gotResolved) != expResolved {
+			t.Fatalf("expected to get %d resolved alerts; got %d", expResolved, len(gotResolved))
+		}
+		r.markNotified(gotFiring, now)
+		r.markNotified(gotResolved, now)
+	}
+
+	now := time.Now()
+	// resolved alert without previous notifications must be skipped
+	f([]Alert{resolved}, now, 0, 0)
+	// pending alerts must be skipped
+	f([]Alert{firing, pending}, now, 1, 0)
+	// firing alert must not be re-sent until repeat interval passes
+	f([]Alert{firing}, now.Add(time.Minute), 0, 0)
+	f([]Alert{firing}, now.Add(time.Hour+time.Minute), 1, 0)
+	// resolved alert must be sent once
+	f([]Alert{resolved}, now.Add(2*time.Hour), 0, 1)
+	f([]Alert{resolved}, now.Add(2*time.Hour+time.Minute), 0, 0)
+	// alert firing again must be sent immediately
+	f([]Alert{firing}, now.Add(3*time.Hour), 1, 0)
+
+	// state for the alert, which disappeared without resolving, must be dropped
+	f(nil, now.Add(3*time.Hour+minStateRetention+time.Minute), 0, 0)
+	if len(r.notified) != 0 {
+		t.Fatalf("expected to have empty notification state; got %d entries", len(r.notified))
+	}
+}
+
+func TestReceiverSelectAlertsExpired(t *testing.T) {
+	rc := &ReceiverConfig{}
+	if err := rc.init(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	r, err := newReceiver("foo", "http://localhost", rc, "", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer r.Close()
+
+	now := time.Now()
+	firing := Alert{GroupID: 1, ID: 1, Name: "foo", State: StateFiring, Labels: map[string]string{"alertname": "foo"}, End: now.Add(time.Minute)}
+	gotFiring, _ := r.selectAlerts([]Alert{firing}, now)
+	r.markNotified(gotFiring, now)
+
+	// alert isn't expired yet
+	_, gotResolved := r.selectAlerts(nil, now.Add(30*time.Second))
+	if len(gotResolved) != 0 {
+		t.Fatalf("expected to get no resolved alerts; got %d", len(gotResolved))
+	}
+
+	// alert is no longer passed to the receiver, so it must be resolved after its End
+	_, gotResolved = r.selectAlerts(nil, now.Add(2*time.Minute))
+	if len(gotResolved) != 1 {
+		t.Fatalf("expected to get 1 resolved alert; got %d", len(gotResolved))
+	}
+	if gotResolved[0].Status != "resolved" || !gotResolved[0].EndsAt.Equal(firing.End) {
+		t.Fatalf("unexpected resolved alert %+v", gotResolved[0])
+	}
+	// resolved notification is in progress, so it mustn't be sent twice
+	_, gotResolved = r.selectAlerts(nil, now.Add(2*time.Minute))
+	if len(gotResolved) != 0 {
+		t.Fatalf("expected to get no resolved alerts; got %d", len(gotResolved))
+	}
+	// failed notification must be retried
+	r.markFailed([]receiverAlert{{key: alertKey{groupID: 1, id: 1}}})
+	_, gotResolved = r.selectAlerts(nil, now.Add(3*time.Minute))
+	if len(gotResolved) != 1 {
+		t.Fatalf("expected to get 1 resolved alert; got %d", len(gotResolved))
+	}
+	r.markNotified(gotResolved, now.Add(3*time.Minute))
+	if len(r.notified) != 0 {
+		t.Fatalf("expected to have empty notification state; got %d entries", len(r.notified))
+	}
+}
+
+func TestReceiverSelectAlertsNoResolved(t *testing.T) {
+	sendResolved := false
+	rc := &ReceiverConfig{
+		SendResolved: &sendResolved,
+	}
+	if err := rc.init(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	r, err := newReceiver("foo", "http://localhost", rc, "", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer r.Close()
+
+	firing := Alert{GroupID: 1, ID: 1, Name: "foo", State: StateFiring, Labels: map[string]string{"alertname": "foo"}}
+	resolved := firing
+	resolved.State = StateInactive
+
+	now := time.Now()
+	gotFiring, _ := r.selectAlerts([]Alert{firing}, now)
+	r.markNotified(gotFiring, now)
+	_, gotResolved := r.selectAlerts([]Alert{resolved}, now)
+	if len(gotResolved) != 0 {
+		t.Fatalf("expected to get no resolved alerts; got %d", len(gotResolved))
+	}
+	if len(r.notified) != 0 {
+		t.Fatalf("expected to have empty notification state; got %d entries", len(r.notified))
+	}
+}
+
+func TestReceiverRelabeling(t *testing.T) {
+	rc := &ReceiverConfig{
+		AlertRelabelConfigs: []promrelabel.RelabelConfig{
+			{
+				SourceLabels: []string{"team"},
+				Regex:        &promrelabel.MultiLineRegex{S: "infra"},
+				Action:       "keep",
+			},
+			{
+				Action: "labeldrop",
+				Regex:  &promrelabel.MultiLineRegex{S: "team"},
+			},
+		},
+	}
+	if err := rc.init(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	r, err := newReceiver("foo", "http://localhost", rc, "", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer r.Close()
+
+	alerts := []Alert{
+		{GroupID: 1, ID: 1, State: StateFiring, Labels: map[string]string{"alertname": "foo", "team": "infra"}},
+		{GroupID: 1, ID: 2, State: StateFiring, Labels: map[string]string{"alertname": "bar", "team": "dev"}},
+	}
+	firing, _ := r.selectAlerts(alerts, time.Now())
+	if len(firing) != 1 {
+		t.Fatalf("expected to get 1 firing alert; got %d", len(firing))
+	}
+	if len(firing[0].Labels) != 1 || firing[0].Labels["alertname"] != "foo" {
+		t.Fatalf("unexpected labels after relabeling: %v", firing[0].Labels)
+	}
+}
+
+func TestReceiverSend(t *testing.T) {
+	const headerKey, headerValue = "TenantID", "foo"
+	var requests []webhookMessage
+	failRequests := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(headerKey) != headerValue {
+			t.Errorf("expected header %q to be set to %q; got %q instead", headerKey, headerValue, r.Header.Get(headerKey))
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("unexpected Authorization header %q", r.Header.Get("Authorization"))
+		}
+		if failRequests {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var m webhookMessage
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			t.Errorf("cannot unmarshal webhook message: %s", err)
+		}
+		requests = append(requests, m)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	cfg := &WebhookConfig{
+		URL: srv.URL,
+		ReceiverConfig: ReceiverConfig{
+			HTTPClientConfig: promauth.HTTPClientConfig{
+				BearerToken: promauth.NewSecret("secret"),
+			},
+		},
+	}
+	if err := cfg.ReceiverConfig.init(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	wh, err := NewWebhook(cfg, "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer wh.Close()
+
+	firing := Alert{GroupID: 1, ID: 1, Name: "foo", State: StateFiring, Labels: map[string]string{"alertname": "foo"}}
+	resolved := firing
+	resolved.State = StateInactive
+	headers := map[string]string{headerKey: headerValue}
+
+	// failed notification must be retried on the next Send call
+	if err := wh.Send(context.Background(), []Alert{firing}, headers); err == nil {
+		t.Fatalf("expected to get non-nil error")
+	}
+	failRequests = false
+	checkErr(t, wh.Send(context.Background(), []Alert{firing}, headers))
+	checkErr(t, wh.Send(context.Background(), []Alert{firing}, headers))
+	checkErr(t, wh.Send(context.Background(), []Alert{resolved}, headers))
+	checkErr(t, wh.Send(context.Background(), []Alert{resolved}, headers))
+
+	if len(requests) != 2 {
+		t.Fatalf("expected to get 2 requests; got %d", len(requests))
+	}
+	if requests[0].Status != "firing" || requests[1].Status != "resolved" {
+		t.Fatalf("unexpected statuses %q and %q", requests[0].Status, requests[1].Status)
+	}
+}
+
+func TestGetCommonPairs(t *testing.T) {
+	f := func(ms []map[string]string, exp map[string]string) {
+		t.Helper()
+		got := getCommonPairs(ms)
+		if len(got) != len(exp) {
+			t.Fatalf("expected to get %v; got %v", exp, got)
+		}
+		for k, v := range exp {
+			if got[k] != v {
+				t.Fatalf("expected to get %v; got %v", exp, got)
+			}
+		}
+	}
+	f(nil, map[string]string{})
+	f([]map[string]string{{"a": "b"}}, map[string]string{"a": "b"})
+	f([]map[string]string{{"a": "b", "c": "d"}, {"a": "b", "c": "e"}}, map[string]string{"a": "b"})
+	f([]map[string]string{{"a": "b"}, {"c": "d"}}, map[string]string{})
+}
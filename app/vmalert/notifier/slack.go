@@ -0,0 +1,168 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+)
+
+const (
+	defaultSlackTitle = `[{{ .Status | toUpper }}{{ if eq .Status "firing" }}:{{ len .Alerts }}{{ end }}] ` +
+		`{{ if .CommonLabels.alertname }}{{ .CommonLabels.alertname }}{{ else }}{{ (index .Alerts 0).Name }}{{ end }}`
+	defaultSlackText = `{{ range .Alerts }}*{{ .Name }}*{{ if .Annotations.summary }}: {{ .Annotations.summary }}{{ end }}` +
+		`{{ if .Annotations.description }}{{ "\n" }}{{ .Annotations.description }}{{ end }}{{ "\n" }}{{ end }}`
+)
+
+// SlackConfig contains settings for sending notifications
+// to Slack-compatible incoming webhooks.
+type SlackConfig struct {
+	// URL is the incoming webhook URL.
+	// It is considered as a secret, since it allows posting messages to the channel.
+	URL *promauth.Secret `yaml:"url,omitempty"`
+	// URLFile is a path to the file with the incoming webhook URL.
+	URLFile string `yaml:"url_file,omitempty"`
+	// Channel overrides the default channel of the incoming webhook
+	Channel string `yaml:"channel,omitempty"`
+	// Username overrides the default username of the incoming webhook
+	Username string `yaml:"username,omitempty"`
+	// Title is a template for the message title
+	Title string `yaml:"title,omitempty"`
+	// Text is a template for the message text
+	Text string `yaml:"text,omitempty"`
+
+	ReceiverConfig `yaml:",inline"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (sc *SlackConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type slackConfig SlackConfig
+	if err := unmarshal((*slackConfig)(sc)); err != nil {
+		return err
+	}
+	if err := checkUnknownFields("slack_configs", sc.XXX); err != nil {
+		return err
+	}
+	if sc.URL == nil && sc.URLFile == "" {
+		return fmt.Errorf("missing `url` or `url_file` in slack_configs")
+	}
+	if sc.URL != nil && sc.URLFile != "" {
+		return fmt.Errorf("only one of `url` or `url_file` must be set in slack_configs")
+	}
+	if sc.Title == "" {
+		sc.Title = defaultSlackTitle
+	}
+	if sc.Text == "" {
+		sc.Text = defaultSlackText
+	}
+	if err := validateTemplate(sc.Title); err != nil {
+		return fmt.Errorf("invalid `title` in slack_configs: %w", err)
+	}
+	if err := validateTemplate(sc.Text); err != nil {
+		return fmt.Errorf("invalid `text` in slack_configs: %w", err)
+	}
+	return sc.ReceiverConfig.init()
+}
+
+// Slack sends notifications to Slack-compatible incoming webhooks
+type Slack struct {
+	*receiver
+
+	channel  string
+	username string
+	title    string
+	text     string
+}
+
+// NewSlack is a constructor for Slack
+func NewSlack(cfg *SlackConfig, baseDir string, fn AlertURLGenerator) (*Slack, error) {
+	webhookURL := cfg.URL.String()
+	if cfg.URLFile != "" {
+		path := fs.GetFilepath(baseDir, cfg.URLFile)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read Slack webhook url from `url_file` %q: %w", path, err)
+		}
+		webhookURL = strings.TrimSpace(string(data))
+	}
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		// Do not expose webhookURL in the error message, since it is a secret.
+		return nil, fmt.Errorf("cannot parse webhook url in slack_configs")
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("webhook url in slack_configs must contain scheme and host")
+	}
+	// Hide the secret part of the url in UI and metrics.
+	addr := fmt.Sprintf("%s://%s/<secret>", u.Scheme, u.Host)
+	if cfg.Channel != "" {
+		addr += " " + cfg.Channel
+	}
+
+	s := &Slack{
+		channel:  cfg.Channel,
+		username: cfg.Username,
+		title:    cfg.Title,
+		text:     cfg.Text,
+	}
+	r, err := newReceiver(addr, webhookURL, &cfg.ReceiverConfig, baseDir, fn, s.buildPayloads)
+	if err != nil {
+		return nil, err
+	}
+	s.receiver = r
+	return s, nil
+}
+
+// slackMessage is the request body for Slack incoming webhook.
+// See https://api.slack.com/messaging/webhooks
+type slackMessage struct {
+	Channel     string            `json:"channel,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	Fallback  string `json:"fallback"`
+	Color     string `json:"color"`
+	Title     string `json:"title"`
+	TitleLink string `json:"title_link,omitempty"`
+	Text      string `json:"text"`
+}
+
+func (s *Slack) buildPayloads(status string, alerts []receiverAlert) ([]receiverPayload, error) {
+	rd := newReceiverData(status, alerts)
+	title, err := executeTemplate(s.title, rd)
+	if err != nil {
+		return nil, err
+	}
+	text, err := executeTemplate(s.text, rd)
+	if err != nil {
+		return nil, err
+	}
+	color := "danger"
+	if status == "resolved" {
+		color = "good"
+	}
+	b, err := json.Marshal(&slackMessage{
+		Channel:  s.channel,
+		Username: s.username,
+		Attachments: []slackAttachment{{
+			Fallback:  title,
+			Color:     color,
+			Title:     title,
+			TitleLink: rd.ExternalURL,
+			Text:      text,
+		}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal Slack message: %w", err)
+	}
+	return []receiverPayload{{body: b, alerts: alerts}}, nil
+}
@@ -0,0 +1,73 @@
+package notifier
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+)
+
+func TestSlackBuildPayloads(t *testing.T) {
+	cfg := &SlackConfig{
+		URL:     promauth.NewSecret("https://hooks.slack.com/services/T000/B000/XXXX"),
+		Channel: "#alerts",
+		Title:   defaultSlackTitle,
+		Text:    defaultSlackText,
+	}
+	if err := cfg.ReceiverConfig.init(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	s, err := NewSlack(cfg, "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer s.Close()
+	if s.Addr() != "https://hooks.slack.com/<secret> #alerts" {
+		t.Fatalf("unexpected addr %q", s.Addr())
+	}
+
+	f := func(status string, alerts []receiverAlert, expTitle, expText, expColor string) {
+		t.Helper()
+		payloads, err := s.buildPayloads(status, alerts)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		var m slackMessage
+		if err := json.Unmarshal(payloads[0].body, &m); err != nil {
+			t.Fatalf("cannot unmarshal Slack message: %s", err)
+		}
+		if m.Channel != "#alerts" {
+			t.Fatalf("unexpected channel %q", m.Channel)
+		}
+		if len(m.Attachments) != 1 {
+			t.Fatalf("expected to get 1 attachment; got %d", len(m.Attachments))
+		}
+		a := m.Attachments[0]
+		if a.Title != expTitle {
+			t.Fatalf("unexpected title;\ngot\n%q\nwant\n%q", a.Title, expTitle)
+		}
+		if a.Text != expText {
+			t.Fatalf("unexpected text;\ngot\n%q\nwant\n%q", a.Text, expText)
+		}
+		if a.Color != expColor {
+			t.Fatalf("unexpected color %q; want %q", a.Color, expColor)
+		}
+	}
+
+	alerts := []receiverAlert{
+		{
+			Name:        "HighLatency",
+			Labels:      map[string]string{"alertname": "HighLatency", "instance": "foo"},
+			Annotations: map[string]string{"summary": "latency is high on foo"},
+		},
+		{
+			Name:        "HighLatency",
+			Labels:      map[string]string{"alertname": "HighLatency", "instance": "bar"},
+			Annotations: map[string]string{"summary": "latency is high on bar", "description": "p99 > 1s"},
+		},
+	}
+	f("firing", alerts, "[FIRING:2] HighLatency",
+		"*HighLatency*: latency is high on foo\n*HighLatency*: latency is high on bar\np99 > 1s\n", "danger")
+	f("resolved", alerts[:1], "[RESOLVED] HighLatency",
+		"*HighLatency*: latency is high on foo\n", "good")
+}
@@ -0,0 +1,103 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// WebhookConfig contains settings for sending notifications
+// to arbitrary HTTP endpoint.
+type WebhookConfig struct {
+	// URL is the address of the endpoint to send notifications to
+	URL string `yaml:"url"`
+	// BodyTemplate is an optional template for the request body.
+	// By default, the body is compatible with Alertmanager webhook payload.
+	BodyTemplate string `yaml:"body_template,omitempty"`
+
+	ReceiverConfig `yaml:",inline"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (wc *WebhookConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type webhookConfig WebhookConfig
+	if err := unmarshal((*webhookConfig)(wc)); err != nil {
+		return err
+	}
+	if err := checkUnknownFields("webhook_configs", wc.XXX); err != nil {
+		return err
+	}
+	if wc.URL == "" {
+		return fmt.Errorf("missing `url` in webhook_configs")
+	}
+	if _, err := url.Parse(wc.URL); err != nil {
+		return fmt.Errorf("invalid `url` %q in webhook_configs: %w", wc.URL, err)
+	}
+	if wc.BodyTemplate != "" {
+		if err := validateTemplate(wc.BodyTemplate); err != nil {
+			return fmt.Errorf("invalid `body_template` in webhook_configs: %w", err)
+		}
+	}
+	return wc.ReceiverConfig.init()
+}
+
+// Webhook sends notifications to arbitrary HTTP endpoint
+type Webhook struct {
+	*receiver
+
+	bodyTemplate string
+}
+
+// NewWebhook is a constructor for Webhook
+func NewWebhook(cfg *WebhookConfig, baseDir string, fn AlertURLGenerator) (*Webhook, error) {
+	wh := &Webhook{
+		bodyTemplate: cfg.BodyTemplate,
+	}
+	r, err := newReceiver(cfg.URL, cfg.URL, &cfg.ReceiverConfig, baseDir, fn, wh.buildPayloads)
+	if err != nil {
+		return nil, err
+	}
+	wh.receiver = r
+	return wh, nil
+}
+
+// webhookMessage is the default request body for Webhook.
+// It is compatible with Alertmanager webhook payload.
+// See https://prometheus.io/docs/alerting/latest/configuration/#webhook_config
+type webhookMessage struct {
+	Version           string            `json:"version"`
+	Status            string            `json:"status"`
+	Alerts            []receiverAlert   `json:"alerts"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+}
+
+func (wh *Webhook) buildPayloads(status string, alerts []receiverAlert) ([]receiverPayload, error) {
+	rd := newReceiverData(status, alerts)
+	var body []byte
+	if wh.bodyTemplate != "" {
+		s, err := executeTemplate(wh.bodyTemplate, rd)
+		if err != nil {
+			return nil, err
+		}
+		body = []byte(s)
+	} else {
+		b, err := json.Marshal(&webhookMessage{
+			Version:           "4",
+			Status:            rd.Status,
+			Alerts:            rd.Alerts,
+			CommonLabels:      rd.CommonLabels,
+			CommonAnnotations: rd.CommonAnnotations,
+			ExternalURL:       rd.ExternalURL,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot marshal webhook message: %w", err)
+		}
+		body = b
+	}
+	return []receiverPayload{{body: body, alerts: alerts}}, nil
+}
@@ -0,0 +1,58 @@
+package notifier
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestWebhookBuildPayloads(t *testing.T) {
+	f := func(bodyTemplate, expBody string) {
+		t.Helper()
+		wh := &Webhook{bodyTemplate: bodyTemplate}
+		alerts := []receiverAlert{
+			{
+				Status:      "firing",
+				Name:        "foo",
+				Labels:      map[string]string{"alertname": "foo", "job": "bar"},
+				Annotations: map[string]string{"summary": "foo is down"},
+				StartsAt:    time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+				Fingerprint: "00000000000000010000000000000002",
+			},
+		}
+		payloads, err := wh.buildPayloads("firing", alerts)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(payloads) != 1 {
+			t.Fatalf("expected to get 1 payload; got %d", len(payloads))
+		}
+		if string(payloads[0].body) != expBody {
+			t.Fatalf("unexpected body;\ngot\n%s\nwant\n%s", payloads[0].body, expBody)
+		}
+	}
+
+	f(`{"text": "{{ .Status }}: {{ range .Alerts }}{{ .Annotations.summary | jsonEscape }}{{ end }}"}`,
+		`{"text": "firing: "foo is down""}`)
+	f(`{{ .CommonLabels.job }}`, `bar`)
+	f(`{"text": {{ printf "%s: %d alerts" .Status (len .Alerts) | jsonEscape }}}`, `{"text": "firing: 1 alerts"}`)
+
+	// default body must be compatible with Alertmanager webhook payload
+	wh := &Webhook{}
+	payloads, err := wh.buildPayloads("resolved", []receiverAlert{{Status: "resolved", Labels: map[string]string{"alertname": "foo"}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(payloads[0].body, &m); err != nil {
+		t.Fatalf("cannot unmarshal default body: %s", err)
+	}
+	for _, key := range []string{"version", "status", "alerts", "commonLabels", "commonAnnotations", "externalURL"} {
+		if _, ok := m[key]; !ok {
+			t.Fatalf("missing %q in default body %s", key, payloads[0].body)
+		}
+	}
+	if m["status"] != "resolved" {
+		t.Fatalf("unexpected status %q", m["status"])
+	}
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/actions"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/fscommon"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/procutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/snapshot"
+)
+
+var (
+	daemon = flag.Bool("daemon", false, "Whether to run vmbackup in daemon mode. In this mode vmbackup makes backups via -snapshot.createURL every -backupInterval "+
+		"and maintains latest, hourly, daily, weekly and monthly backups at -dst. See https://docs.victoriametrics.com/vmbackup.html#daemon-mode")
+	backupInterval = flag.Duration("backupInterval", time.Hour, "Interval between backups in daemon mode")
+	runOnStart     = flag.Bool("runOnStart", false, "Whether to make backup immediately after the start in daemon mode. Otherwise the first backup is made at the start of the next -backupInterval")
+)
+
+var (
+	backupRunsTotal       = metrics.NewCounter(`vm_backup_runs_total`)
+	backupRunErrorsTotal  = metrics.NewCounter(`vm_backup_run_errors_total`)
+	retentionDeletedTotal = metrics.NewCounter(`vm_backup_retention_deleted_total`)
+)
+
+// backupDaemon makes backups on schedule in daemon mode.
+type backupDaemon struct {
+	mu     sync.Mutex
+	status daemonStatus
+}
+
+// daemonStatus is the status of backupDaemon returned from /api/v1/status.
+type daemonStatus struct {
+	InProgress          bool    `json:"in_progress"`
+	LastStartedAt       string  `json:"last_started_at,omitempty"`
+	LastFinishedAt      string  `json:"last_finished_at,omitempty"`
+	LastSuccessAt       string  `json:"last_success_at,omitempty"`
+	LastDurationSeconds float64 `json:"last_duration_seconds"`
+	LastError           string  `json:"last_error,omitempty"`
+	NextRunAt           string  `json:"next_run_at,omitempty"`
+
+	lastSuccess time.Time
+}
+
+func runDaemon() {
+	if len(*snapshotCreateURL) == 0 {
+		logger.Fatalf("-snapshot.createURL must be set in daemon mode")
+	}
+	if len(*snapshotName) > 0 {
+		logger.Fatalf("-snapshotName cannot be set in daemon mode, since snapshots are created automatically via -snapshot.createURL")
+	}
+	if len(*origin) > 0 {
+		logger.Fatalf("-origin cannot be set in daemon mode, since the latest backup is used as origin for other backups")
+	}
+	if *backupInterval < time.Minute {
+		logger.Fatalf("-backupInterval cannot be smaller than 1m; got %s", *backupInterval)
+	}
+	if _, err := newDstFS(); err != nil {
+		logger.Fatalf("%s", err)
+	}
+	kp, err := actions.NewKeyProvider()
+	if err != nil {
+		logger.Fatalf("cannot initialize encryption: %s", err)
+	}
+	if kp != nil && kp.KeyID() == "" {
+		logger.Fatalf("-encryptionKeyID must be set when -encryptionKeyringDir is used for backups")
+	}
+
+	d := &backupDaemon{}
+	metrics.NewGauge(`vm_backup_last_success_timestamp_seconds`, func() float64 {
+		t := d.lastSuccess()
+		if t.IsZero() {
+			return 0
+		}
+		return float64(t.Unix())
+	})
+	metrics.NewGauge(`vm_backup_last_success_age_seconds`, func() float64 {
+		t := d.lastSuccess()
+		if t.IsZero() {
+			return 0
+		}
+		return time.Since(t).Seconds()
+	})
+	metrics.NewGauge(`vm_backup_last_duration_seconds`, func() float64 {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return d.status.LastDurationSeconds
+	})
+	metrics.NewGauge(`vm_backup_in_progress`, func() float64 {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.status.InProgress {
+			return 1
+		}
+		return 0
+	})
+
+	go httpserver.Serve(*httpListenAddr, false, d.requestHandler)
+
+	stopCh := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.run(stopCh)
+	}()
+
+	sig := procutil.WaitForSigterm()
+	logger.Infof("received signal %s; waiting for the in-progress backup to finish", sig)
+	close(stopCh)
+	wg.Wait()
+	stopHTTPServer()
+}
+
+func (d *backupDaemon) lastSuccess() time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.status.lastSuccess
+}
+
+func (d *backupDaemon) run(stopCh <-chan struct{}) {
+	if *runOnStart {
+		d.runOnce()
+	}
+	for {
+		next := time.Now().Truncate(*backupInterval).Add(*backupInterval)
+		d.mu.Lock()
+		d.status.NextRunAt = next.Format(time.RFC3339)
+		d.mu.Unlock()
+
+		t := time.NewTimer(time.Until(next))
+		select {
+		case <-stopCh:
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		d.runOnce()
+	}
+}
+
+func (d *backupDaemon) runOnce() {
+	startTime := time.Now()
+	d.mu.Lock()
+	d.status.InProgress = true
+	d.status.LastStartedAt = startTime.Format(time.RFC3339)
+	d.mu.Unlock()
+
+	backupRunsTotal.Inc()
+	err := makeScheduledBackups(startTime)
+	if err != nil {
+		backupRunErrorsTotal.Inc()
+		logger.Errorf("cannot make scheduled backups: %s", err)
+	}
+
+	finishTime := time.Now()
+	d.mu.Lock()
+	d.status.InProgress = false
+	d.status.LastFinishedAt = finishTime.Format(time.RFC3339)
+	d.status.LastDurationSeconds = finishTime.Sub(startTime).Seconds()
+	d.status.LastError = ""
+	if err != nil {
+		d.status.LastError = err.Error()
+	} else {
+		d.status.LastSuccessAt = d.status.LastFinishedAt
+		d.status.lastSuccess = finishTime
+	}
+	d.mu.Unlock()
+}
+
+// makeScheduledBackups makes snapshot, backs it up to the latest backup,
+// copies the latest backup to tier backups and applies retention to them.
+func makeScheduledBackups(now time.Time) error {
+	name, err := snapshot.Create(*snapshotCreateURL)
+	if err != nil {
+		return fmt.Errorf("cannot create snapshot: %w", err)
+	}
+	err = backupSnapshot(name, now)
+	if errDelete := snapshot.Delete(getSnapshotDeleteURL(), name); errDelete != nil {
+		logger.Errorf("cannot delete snapshot %q: %s", name, errDelete)
+	}
+	if err != nil {
+		return err
+	}
+
+	root, err := newDstFS()
+	if err != nil {
+		return err
+	}
+	defer root.MustStop()
+	return applyRetention(root, getBackupTiers())
+}
+
+func getSnapshotDeleteURL() string {
+	if len(*snapshotDeleteURL) > 0 {
+		return *snapshotDeleteURL
+	}
+	return strings.Replace(*snapshotCreateURL, "/create", "/delete", 1)
+}
+
+func backupSnapshot(name string, now time.Time) error {
+	if err := snapshot.Validate(name); err != nil {
+		return fmt.Errorf("invalid snapshot name %q: %w", name, err)
+	}
+	srcFS, err := newSrcFS(name)
+	if err != nil {
+		return err
+	}
+	defer srcFS.MustStop()
+	kp, err := actions.NewKeyProvider()
+	if err != nil {
+		return fmt.Errorf("cannot initialize encryption: %w", err)
+	}
+
+	latestFS, err := actions.NewRemoteFS(backupPath(latestBackupName))
+	if err != nil {
+		return fmt.Errorf("cannot initialize latest backup fs: %w", err)
+	}
+	defer latestFS.MustStop()
+	a := &actions.Backup{
+		Concurrency: *concurrency,
+		Src:         srcFS,
+		Dst:         latestFS,
+		KeyProvider: kp,
+	}
+	if err := a.Run(); err != nil {
+		return fmt.Errorf("cannot make %s backup: %w", latestBackupName, err)
+	}
+
+	// Copy the latest backup to tier backups via server-side copying.
+	for _, tier := range getBackupTiers() {
+		if tier.disabled {
+			continue
+		}
+		backupName := tier.name + "/" + tier.backupName(now)
+		tierFS, err := actions.NewRemoteFS(backupPath(backupName))
+		if err != nil {
+			return fmt.Errorf("cannot initialize %s backup fs: %w", backupName, err)
+		}
+		a := &actions.Backup{
+			Concurrency: *concurrency,
+			Src:         srcFS,
+			Dst:         tierFS,
+			Origin:      latestFS,
+			KeyProvider: kp,
+		}
+		err = a.Run()
+		tierFS.MustStop()
+		if err != nil {
+			return fmt.Errorf("cannot make %s backup: %w", backupName, err)
+		}
+	}
+	return nil
+}
+
+// backupInfo is the backup info returned from /api/v1/backups.
+type backupInfo struct {
+	Name        string `json:"name"`
+	SizeBytes   uint64 `json:"size_bytes"`
+	CreatedAt   string `json:"created_at,omitempty"`
+	CompletedAt string `json:"completed_at,omitempty"`
+	Encrypted   bool   `json:"encrypted"`
+}
+
+func getBackupInfo(name string) (*backupInfo, error) {
+	fs, err := actions.NewRemoteFS(backupPath(name))
+	if err != nil {
+		return nil, err
+	}
+	defer fs.MustStop()
+	ok, err := fs.HasFile(fscommon.BackupCompleteFilename)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// Skip incomplete backups.
+		return nil, nil
+	}
+	bi := &backupInfo{
+		Name: name,
+	}
+	m, err := actions.ReadMetadata(fs)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil {
+		bi.CreatedAt = m.CreatedAt
+		bi.CompletedAt = m.CompletedAt
+		bi.Encrypted = m.Encryption != nil
+	}
+	parts, err := fs.ListParts()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list parts at %s: %w", fs, err)
+	}
+	for _, p := range parts {
+		bi.SizeBytes += p.Size
+	}
+	return bi, nil
+}
+
+func listBackups() ([]*backupInfo, error) {
+	root, err := newDstFS()
+	if err != nil {
+		return nil, err
+	}
+	defer root.MustStop()
+
+	names := []string{latestBackupName}
+	for _, tier := range getBackupTiers() {
+		tierNames, err := listTierBackups(root, tier.name)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range tierNames {
+			names = append(names, tier.name+"/"+name)
+		}
+	}
+	bis := make([]*backupInfo, 0, len(names))
+	for _, name := range names {
+		bi, err := getBackupInfo(name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot obtain info for %q backup: %w", name, err)
+		}
+		if bi != nil {
+			bis = append(bis, bi)
+		}
+	}
+	return bis, nil
+}
+
+// resolveBackupPath returns the full path for the backup with the given name.
+//
+// The name may be either relative to -dst or a full path to backup at any supported remote storage.
+func resolveBackupPath(name string) (string, error) {
+	path := name
+	if !strings.Contains(name, "://") {
+		if strings.Contains(name, "..") {
+			return "", fmt.Errorf("backup name cannot contain `..`; got %q", name)
+		}
+		path = backupPath(strings.Trim(name, "/"))
+	}
+	fs, err := actions.NewRemoteFS(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot parse backup path %q: %w", path, err)
+	}
+	defer fs.MustStop()
+	ok, err := fs.HasFile(fscommon.BackupCompleteFilename)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("cannot find complete backup at %q", path)
+	}
+	return path, nil
+}
+
+func (d *backupDaemon) requestHandler(w http.ResponseWriter, r *http.Request) bool {
+	switch r.URL.Path {
+	case "/api/v1/status":
+		d.mu.Lock()
+		data, err := json.Marshal(&d.status)
+		d.mu.Unlock()
+		if err != nil {
+			httpserver.Errorf(w, r, "cannot marshal status: %s", err)
+			return true
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+		return true
+	case "/api/v1/backups":
+		bis, err := listBackups()
+		if err != nil {
+			httpserver.Errorf(w, r, "cannot list backups: %s", err)
+			return true
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(bis)
+		return true
+	case "/api/v1/restore":
+		handleRestoreMark(w, r)
+		return true
+	default:
+		return false
+	}
+}
+
+func handleRestoreMark(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		rm, err := actions.ReadRestoreMark(*storageDataPath)
+		if err != nil {
+			httpserver.Errorf(w, r, "%s", err)
+			return
+		}
+		if rm == nil {
+			http.Error(w, "restore mark doesn't exist", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(rm)
+	case http.MethodPost:
+		var rm actions.RestoreMark
+		if err := json.NewDecoder(r.Body).Decode(&rm); err != nil {
+			http.Error(w, fmt.Sprintf("cannot parse request body: %s", err), http.StatusBadRequest)
+			return
+		}
+		if rm.Backup == "" {
+			http.Error(w, "missing `backup` in request body", http.StatusBadRequest)
+			return
+		}
+		path, err := resolveBackupPath(rm.Backup)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rm.Backup = path
+		if err := actions.WriteRestoreMark(*storageDataPath, &rm); err != nil {
+			httpserver.Errorf(w, r, "%s", err)
+			return
+		}
+		logger.Infof("created restore mark for the backup at %q", path)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&rm)
+	case http.MethodDelete:
+		if err := actions.DeleteRestoreMark(*storageDataPath); err != nil {
+			httpserver.Errorf(w, r, "%s", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, fmt.Sprintf("unsupported method %s", r.Method), http.StatusMethodNotAllowed)
+	}
+}
@@ -31,11 +31,17 @@ var (
 	snapshotDeleteURL = flag.String("snapshot.deleteURL", "", "VictoriaMetrics delete snapshot url. Optional. Will be generated from -snapshot.createURL if not provided. "+
 		"All created snapshots will be automatically deleted. Example: http://victoriametrics:8428/snapshot/delete")
 	dst = flag.String("dst", "", "Where to put the backup on the remote storage. "+
-		"Example: gs://bucket/path/to/backup, s3://bucket/path/to/backup, azblob://container/path/to/backup or fs:///path/to/local/backup/dir\n"+
+		"Example: gs://bucket/path/to/backup, s3://bucket/path/to/backup, azblob://container/path/to/backup, "+
+		"sftp://user@host/path/to/backup, webdav://host/path/to/backup or fs:///path/to/local/backup/dir\n"+
 		"-dst can point to the previous backup. In this case incremental backup is performed, i.e. only changed data is uploaded")
 	origin            = flag.String("origin", "", "Optional origin directory on the remote storage with old backup for server-side copying when performing full backup. This speeds up full backups")
 	concurrency       = flag.Int("concurrency", 10, "The number of concurrent workers. Higher concurrency may reduce backup duration")
 	maxBytesPerSecond = flagutil.NewBytes("maxBytesPerSecond", 0, "The maximum upload speed. There is no limit if it is set to 0")
+	verify            = flag.Bool("verify", false, "Whether to verify the integrity of the backup at -dst instead of making a new backup. "+
+		"vmbackup exits with non-zero code if the backup contains missing or corrupted parts. See https://docs.victoriametrics.com/vmbackup.html#backup-verification")
+	verifySkipChecksums = flag.Bool("verify.skipChecksums", false, "Whether to skip downloading the backup for verifying checksums of backed up parts when -verify is set. "+
+		"In this case only the list of parts and their sizes are verified")
+	verifyPartHeaders = flag.Bool("verify.partHeaders", false, "Whether to download and verify headers for storage and indexdb parts when -verify is set")
 )
 
 func main() {
@@ -49,6 +55,20 @@ func main() {
 	logger.Init()
 	pushmetrics.Init()
 
+	if *daemon {
+		runDaemon()
+		return
+	}
+
+	if *verify {
+		go httpserver.Serve(*httpListenAddr, false, nil)
+		if err := verifyBackup(); err != nil {
+			logger.Fatalf("cannot verify backup: %s", err)
+		}
+		stopHTTPServer()
+		return
+	}
+
 	// Storing snapshot delete function to be able to call it in case
 	// of error since logger.Fatal will exit the program without
 	// calling deferred functions.
@@ -104,6 +124,10 @@ func main() {
 		logger.Fatalf("cannot create backup: %s", err)
 	}
 
+	stopHTTPServer()
+}
+
+func stopHTTPServer() {
 	startTime := time.Now()
 	logger.Infof("gracefully shutting down http server for metrics at %q", *httpListenAddr)
 	if err := httpserver.Stop(*httpListenAddr); err != nil {
@@ -112,12 +136,35 @@ func main() {
 	logger.Infof("successfully shut down http server for metrics in %.3f seconds", time.Since(startTime).Seconds())
 }
 
+func verifyBackup() error {
+	fs, err := actions.NewRemoteFS(*dst)
+	if err != nil {
+		return fmt.Errorf("cannot parse `-dst`=%q: %w", *dst, err)
+	}
+	kp, err := actions.NewKeyProvider()
+	if err != nil {
+		return fmt.Errorf("cannot initialize encryption: %w", err)
+	}
+	a := &actions.Verify{
+		Concurrency:       *concurrency,
+		Src:               fs,
+		KeyProvider:       kp,
+		SkipChecksums:     *verifySkipChecksums,
+		VerifyPartHeaders: *verifyPartHeaders,
+	}
+	if err := a.Run(); err != nil {
+		return err
+	}
+	fs.MustStop()
+	return nil
+}
+
 func makeBackup() error {
 	if err := snapshot.Validate(*snapshotName); err != nil {
 		return fmt.Errorf("invalid -snapshotName=%q: %s", *snapshotName, err)
 	}
 
-	srcFS, err := newSrcFS()
+	srcFS, err := newSrcFS(*snapshotName)
 	if err != nil {
 		return err
 	}
@@ -129,11 +176,19 @@ func makeBackup() error {
 	if err != nil {
 		return err
 	}
+	kp, err := actions.NewKeyProvider()
+	if err != nil {
+		return fmt.Errorf("cannot initialize encryption: %w", err)
+	}
+	if kp != nil && kp.KeyID() == "" {
+		return fmt.Errorf("-encryptionKeyID must be set when -encryptionKeyringDir is used for backups")
+	}
 	a := &actions.Backup{
 		Concurrency: *concurrency,
 		Src:         srcFS,
 		Dst:         dstFS,
 		Origin:      originFS,
+		KeyProvider: kp,
 	}
 	if err := a.Run(); err != nil {
 		return err
@@ -154,8 +209,8 @@ See the docs at https://docs.victoriametrics.com/vmbackup.html .
 	flagutil.Usage(s)
 }
 
-func newSrcFS() (*fslocal.FS, error) {
-	snapshotPath := filepath.Join(*storageDataPath, "snapshots", *snapshotName)
+func newSrcFS(name string) (*fslocal.FS, error) {
+	snapshotPath := filepath.Join(*storageDataPath, "snapshots", name)
 
 	// Verify the snapshot exists.
 	f, err := os.Open(snapshotPath)
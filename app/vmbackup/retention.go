@@ -0,0 +1,180 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/actions"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/fscommon"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+)
+
+var (
+	disableHourly  = flag.Bool("disableHourly", false, "Whether to disable hourly backups in daemon mode. See https://docs.victoriametrics.com/vmbackup.html#daemon-mode")
+	disableDaily   = flag.Bool("disableDaily", false, "Whether to disable daily backups in daemon mode. See https://docs.victoriametrics.com/vmbackup.html#daemon-mode")
+	disableWeekly  = flag.Bool("disableWeekly", false, "Whether to disable weekly backups in daemon mode. See https://docs.victoriametrics.com/vmbackup.html#daemon-mode")
+	disableMonthly = flag.Bool("disableMonthly", false, "Whether to disable monthly backups in daemon mode. See https://docs.victoriametrics.com/vmbackup.html#daemon-mode")
+
+	keepLastHourly = flag.Int("keepLastHourly", -1, "Keep the last N hourly backups in daemon mode. All the hourly backups are kept if it is set to a negative value. "+
+		"All the hourly backups are deleted on the next retention cycle if it is set to 0")
+	keepLastDaily = flag.Int("keepLastDaily", -1, "Keep the last N daily backups in daemon mode. All the daily backups are kept if it is set to a negative value. "+
+		"All the daily backups are deleted on the next retention cycle if it is set to 0")
+	keepLastWeekly = flag.Int("keepLastWeekly", -1, "Keep the last N weekly backups in daemon mode. All the weekly backups are kept if it is set to a negative value. "+
+		"All the weekly backups are deleted on the next retention cycle if it is set to 0")
+	keepLastMonthly = flag.Int("keepLastMonthly", -1, "Keep the last N monthly backups in daemon mode. All the monthly backups are kept if it is set to a negative value. "+
+		"All the monthly backups are deleted on the next retention cycle if it is set to 0")
+)
+
+// latestBackupName is the name of the backup, which is updated on every run in daemon mode.
+const latestBackupName = "latest"
+
+// backupTier describes backups made with the given period in daemon mode.
+type backupTier struct {
+	// name is the name of the directory with tier backups at -dst.
+	name string
+
+	// disabled is set if the tier backups mustn't be made.
+	disabled bool
+
+	// keepLast is the number of the last backups to keep. All the backups are kept if it is negative.
+	keepLast int
+
+	// backupName returns the name of the tier backup for the given time.
+	backupName func(t time.Time) string
+}
+
+func getBackupTiers() []*backupTier {
+	return []*backupTier{
+		{
+			name:     "hourly",
+			disabled: *disableHourly,
+			keepLast: *keepLastHourly,
+			backupName: func(t time.Time) string {
+				return t.UTC().Format("2006-01-02:15")
+			},
+		},
+		{
+			name:     "daily",
+			disabled: *disableDaily,
+			keepLast: *keepLastDaily,
+			backupName: func(t time.Time) string {
+				return t.UTC().Format("2006-01-02")
+			},
+		},
+		{
+			name:     "weekly",
+			disabled: *disableWeekly,
+			keepLast: *keepLastWeekly,
+			backupName: func(t time.Time) string {
+				year, week := t.UTC().ISOWeek()
+				return fmt.Sprintf("%d-%02d", year, week)
+			},
+		},
+		{
+			name:     "monthly",
+			disabled: *disableMonthly,
+			keepLast: *keepLastMonthly,
+			backupName: func(t time.Time) string {
+				return t.UTC().Format("2006-01")
+			},
+		},
+	}
+}
+
+// backupPath returns the path for the backup with the given name relative to -dst.
+func backupPath(name string) string {
+	return strings.TrimSuffix(*dst, "/") + "/" + name
+}
+
+// listTierBackups returns sorted names of backups for the given tier at root.
+func listTierBackups(root common.RemoteFS, tier string) ([]string, error) {
+	prefix := tier + "/"
+	fis, err := root.ListFiles(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list %s backups at %s: %w", tier, root, err)
+	}
+	m := make(map[string]struct{})
+	for _, fi := range fis {
+		name := strings.TrimPrefix(fi.Path, prefix)
+		n := strings.IndexByte(name, '/')
+		if n <= 0 {
+			continue
+		}
+		m[name[:n]] = struct{}{}
+	}
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// getBackupsToDelete returns backups, which must be deleted in order to keep only keepLast backups.
+//
+// names must be sorted in ascending order. Backup names are sorted in chronological order.
+func getBackupsToDelete(names []string, keepLast int) []string {
+	if keepLast < 0 || len(names) <= keepLast {
+		return nil
+	}
+	return names[:len(names)-keepLast]
+}
+
+// applyRetention deletes backups, which are outside the configured retention for tiers.
+func applyRetention(root common.RemoteFS, tiers []*backupTier) error {
+	for _, tier := range tiers {
+		if tier.keepLast < 0 {
+			continue
+		}
+		names, err := listTierBackups(root, tier.name)
+		if err != nil {
+			return err
+		}
+		toDelete := getBackupsToDelete(names, tier.keepLast)
+		if len(toDelete) == 0 {
+			continue
+		}
+		logger.Infof("%s backups to delete %q", tier.name, toDelete)
+		for _, name := range toDelete {
+			if err := deleteBackup(backupPath(tier.name + "/" + name)); err != nil {
+				return err
+			}
+			retentionDeletedTotal.Inc()
+		}
+	}
+	return nil
+}
+
+// deleteBackup deletes the backup at the given path.
+func deleteBackup(path string) error {
+	fs, err := actions.NewRemoteFS(path)
+	if err != nil {
+		return fmt.Errorf("cannot parse backup path %q: %w", path, err)
+	}
+	defer fs.MustStop()
+
+	// Delete `backup complete` file at first, so the partially deleted backup isn't considered complete.
+	for _, filename := range []string{fscommon.BackupCompleteFilename, fscommon.BackupChecksumsFilename, fscommon.BackupMetadataFilename} {
+		if err := fs.DeleteFile(filename); err != nil {
+			return fmt.Errorf("cannot delete %q at %s: %w", filename, fs, err)
+		}
+	}
+	parts, err := fs.ListParts()
+	if err != nil {
+		return fmt.Errorf("cannot list parts at %s: %w", fs, err)
+	}
+	for _, p := range parts {
+		if err := fs.DeletePart(p); err != nil {
+			return fmt.Errorf("cannot delete %s at %s: %w", &p, fs, err)
+		}
+	}
+	if err := fs.RemoveEmptyDirs(); err != nil {
+		return fmt.Errorf("cannot remove empty directories at %s: %w", fs, err)
+	}
+	logger.Infof("deleted backup at %s", fs)
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetBackupsToDelete(t *testing.T) {
+	f := func(names []string, keepLast int, resultExpected []string) {
+		t.Helper()
+		result := getBackupsToDelete(names, keepLast)
+		if !reflect.DeepEqual(result, resultExpected) {
+			t.Fatalf("unexpected backups to delete for keepLast=%d; got %q; want %q", keepLast, result, resultExpected)
+		}
+	}
+	names := []string{"2023-01-29", "2023-01-30", "2023-01-31", "2023-02-01"}
+	f(nil, 3, nil)
+	f(names, -1, nil)
+	f(names, 4, nil)
+	f(names, 10, nil)
+	f(names, 2, []string{"2023-01-29", "2023-01-30"})
+	f(names, 0, names)
+}
+
+func TestBackupTierNames(t *testing.T) {
+	ts := time.Date(2023, time.January, 1, 7, 30, 0, 0, time.UTC)
+	m := make(map[string]string)
+	for _, tier := range getBackupTiers() {
+		m[tier.name] = tier.backupName(ts)
+	}
+	mExpected := map[string]string{
+		"hourly": "2023-01-01:07",
+		"daily":  "2023-01-01",
+		// 2023-01-01 belongs to the last ISO week of 2022.
+		"weekly":  "2022-52",
+		"monthly": "2023-01",
+	}
+	if !reflect.DeepEqual(m, mExpected) {
+		t.Fatalf("unexpected backup names; got %v; want %v", m, mExpected)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/barpool"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/blocks"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/vm"
+)
+
+type blocksProcessor struct {
+	// blocks client lists and reads
+	// TSDB blocks from object storage
+	cl *blocks.Client
+	// importer performs import requests
+	// for timeseries data read from blocks
+	im *vm.Importer
+	// cc stands for concurrency
+	// and defines number of concurrently
+	// running block readers
+	cc int
+}
+
+func (bp *blocksProcessor) run(silent, verbose bool) error {
+	bs, err := bp.cl.Explore()
+	if err != nil {
+		return fmt.Errorf("explore failed: %s", err)
+	}
+	if len(bs) < 1 {
+		return fmt.Errorf("found no blocks to import")
+	}
+	question := fmt.Sprintf("Found %d blocks to import. Continue?", len(bs))
+	if !silent && !prompt(question) {
+		return nil
+	}
+
+	bar := barpool.AddWithTemplate(fmt.Sprintf(barTpl, "Processing blocks"), len(bs))
+
+	if err := barpool.Start(); err != nil {
+		return err
+	}
+	defer barpool.Stop()
+
+	blocksCh := make(chan *blocks.Block)
+	errCh := make(chan error, bp.cc)
+	bp.im.ResetStats()
+
+	var wg sync.WaitGroup
+	wg.Add(bp.cc)
+	for i := 0; i < bp.cc; i++ {
+		go func() {
+			defer wg.Done()
+			for b := range blocksCh {
+				if err := bp.do(b); err != nil {
+					errCh <- fmt.Errorf("read failed for block %q: %s", b.Dir, err)
+					return
+				}
+				bar.Increment()
+			}
+		}()
+	}
+	// any error breaks the import
+	for _, b := range bs {
+		select {
+		case blocksErr := <-errCh:
+			close(blocksCh)
+			return fmt.Errorf("blocks error: %s", blocksErr)
+		case vmErr := <-bp.im.Errors():
+			close(blocksCh)
+			return fmt.Errorf("import process failed: %s", wrapErr(vmErr, verbose))
+		case blocksCh <- b:
+		}
+	}
+
+	close(blocksCh)
+	wg.Wait()
+	// wait for all buffers to flush
+	bp.im.Close()
+	close(errCh)
+	// drain import errors channel
+	for vmErr := range bp.im.Errors() {
+		if vmErr.Err != nil {
+			return fmt.Errorf("import process failed: %s", wrapErr(vmErr, verbose))
+		}
+	}
+	for err := range errCh {
+		return fmt.Errorf("import process failed: %s", err)
+	}
+
+	log.Println("Import finished!")
+	log.Print(bp.im.Stats())
+	return nil
+}
+
+func (bp *blocksProcessor) do(b *blocks.Block) error {
+	return bp.cl.Read(b, func(s *blocks.Series) error {
+		var name string
+		var lps []vm.LabelPair
+		s.Labels.Range(func(l labels.Label) {
+			if l.Name == labels.MetricName {
+				name = l.Value
+				return
+			}
+			lps = append(lps, vm.LabelPair{
+				Name:  l.Name,
+				Value: l.Value,
+			})
+		})
+		if name == "" {
+			return fmt.Errorf("failed to find `__name__` label in labelset for block %q", b.Dir)
+		}
+		ts := vm.TimeSeries{
+			Name:       name,
+			LabelPairs: lps,
+			Timestamps: s.Timestamps,
+			Values:     s.Values,
+		}
+		return bp.im.Input(&ts)
+	})
+}
@@ -0,0 +1,67 @@
+package blocks
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+)
+
+// aggrEncoding is the chunk encoding used by Thanos compactor for downsampled blocks.
+//
+// See https://github.com/thanos-io/thanos/blob/main/pkg/compact/downsample/aggr.go
+const aggrEncoding = chunkenc.Encoding(0xff)
+
+// aggrTypes contains names of aggregates stored in Thanos aggregate chunks
+// in the order they are stored in the chunk.
+var aggrTypes = []string{"count", "sum", "min", "max", "counter"}
+
+// parseAggrs returns indexes in aggrTypes for the given aggregate names.
+func parseAggrs(names []string) ([]int, error) {
+	var aggrs []int
+	for _, name := range names {
+		n := -1
+		for i, t := range aggrTypes {
+			if t == name {
+				n = i
+				break
+			}
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("unsupported aggregate %q; supported values: %s", name, strings.Join(aggrTypes, ", "))
+		}
+		aggrs = append(aggrs, n)
+	}
+	return aggrs, nil
+}
+
+// aggrChunk returns chunk for the aggregate with index n in aggrTypes
+// from Thanos aggregate chunk data.
+//
+// nil chunk is returned if the aggregate is missing in data.
+func aggrChunk(data []byte, n int) (chunkenc.Chunk, error) {
+	var chunkData []byte
+	for i := 0; i <= n; i++ {
+		size, n1 := binary.Uvarint(data)
+		if n1 <= 0 {
+			return nil, fmt.Errorf("cannot read size of %q aggregate", aggrTypes[i])
+		}
+		data = data[n1:]
+		if size == 0 {
+			// Missing aggregate
+			chunkData = nil
+			continue
+		}
+		// The aggregate contains encoding byte followed by size bytes of chunk data.
+		if uint64(len(data)) < size+1 {
+			return nil, fmt.Errorf("too short data for %q aggregate; got %d bytes; want %d bytes", aggrTypes[i], len(data), size+1)
+		}
+		chunkData = data[:size+1]
+		data = data[size+1:]
+	}
+	if chunkData == nil {
+		return nil, nil
+	}
+	return chunkenc.FromData(chunkenc.Encoding(chunkData[0]), chunkData[1:])
+}
@@ -0,0 +1,505 @@
+package blocks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/common"
+)
+
+// Config contains a list of params needed
+// for reading TSDB blocks from object storage
+type Config struct {
+	// Src is a path to the bucket with blocks in the form `scheme://bucket/path`
+	Src string
+	// Remote contains params for accessing Src
+	Remote RemoteConfig
+	// TmpDir is a directory for temporary files
+	TmpDir string
+	// Tenants is an optional list of tenants to import blocks for
+	Tenants []string
+	// TenantLabel is an optional label name to store the tenant of the block
+	TenantLabel string
+	// Resolutions is a list of Thanos downsampling resolutions to import blocks for, e.g. `raw`, `5m`, `1h`
+	Resolutions []string
+	// Aggregates is a list of aggregates to import from Thanos downsampled blocks
+	Aggregates []string
+
+	Filter Filter
+}
+
+// Filter contains configuration for filtering
+// the timeseries
+type Filter struct {
+	TimeMin    string
+	TimeMax    string
+	Label      string
+	LabelValue string
+}
+
+// Meta is a TSDB block meta with Thanos extensions.
+type Meta struct {
+	tsdb.BlockMeta
+
+	Thanos ThanosMeta `json:"thanos"`
+}
+
+// ThanosMeta contains Thanos-specific block meta.
+//
+// See https://github.com/thanos-io/thanos/blob/main/pkg/block/metadata/meta.go
+type ThanosMeta struct {
+	// Labels are external labels of the block
+	Labels     map[string]string `json:"labels"`
+	Downsample struct {
+		// Resolution is the downsampling resolution in milliseconds; 0 for raw blocks
+		Resolution int64 `json:"resolution"`
+	} `json:"downsample"`
+}
+
+// Block is a TSDB block stored in object storage.
+type Block struct {
+	// Tenant is the tenant prefix for the block; empty for Prometheus and Thanos blocks
+	Tenant string
+	// Dir is the block path relative to the bucket root
+	Dir  string
+	Meta Meta
+
+	meta         common.FileInfo
+	index        common.FileInfo
+	tombstones   *common.FileInfo
+	segments     []common.FileInfo
+	deletionMark bool
+}
+
+// Series is a single time series read from a block.
+type Series struct {
+	Labels     labels.Labels
+	Timestamps []int64
+	Values     []float64
+}
+
+// Client reads TSDB blocks from object storage
+type Client struct {
+	fs          common.RemoteFS
+	tmpDir      string
+	tenants     map[string]struct{}
+	tenantLabel string
+	resolutions map[int64]struct{}
+	aggrs       []int
+	filter      filter
+}
+
+type filter struct {
+	min, max   int64
+	label      string
+	labelValue string
+}
+
+func (f filter) inRange(min, max int64) bool {
+	fmin, fmax := f.min, f.max
+	if fmin == 0 {
+		fmin = min
+	}
+	if fmax == 0 {
+		fmax = max
+	}
+	return min <= fmax && fmin <= max
+}
+
+// NewClient creates and validates new Client
+// with given Config
+func NewClient(cfg Config) (*Client, error) {
+	min, max, err := parseTime(cfg.Filter.TimeMin, cfg.Filter.TimeMax)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse time in filter: %s", err)
+	}
+	c := &Client{
+		tmpDir:      cfg.TmpDir,
+		tenantLabel: cfg.TenantLabel,
+		resolutions: make(map[int64]struct{}),
+		filter: filter{
+			min:        min,
+			max:        max,
+			label:      cfg.Filter.Label,
+			labelValue: cfg.Filter.LabelValue,
+		},
+	}
+	if c.filter.label != "" {
+		if _, err := labels.NewMatcher(labels.MatchRegexp, c.filter.label, c.filter.labelValue); err != nil {
+			return nil, fmt.Errorf("cannot parse label filter: %s", err)
+		}
+	}
+	if len(cfg.Tenants) > 0 {
+		c.tenants = make(map[string]struct{}, len(cfg.Tenants))
+		for _, t := range cfg.Tenants {
+			c.tenants[t] = struct{}{}
+		}
+	}
+	for _, r := range cfg.Resolutions {
+		if r == "raw" {
+			c.resolutions[0] = struct{}{}
+			continue
+		}
+		d, err := time.ParseDuration(r)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse resolution %q; it must be `raw` or duration such as `5m` or `1h`", r)
+		}
+		c.resolutions[d.Milliseconds()] = struct{}{}
+	}
+	if len(c.resolutions) == 0 {
+		return nil, fmt.Errorf("at least a single resolution must be set")
+	}
+	c.aggrs, err = parseAggrs(cfg.Aggregates)
+	if err != nil {
+		return nil, err
+	}
+	fs, err := newRemoteFS(cfg.Src, cfg.Remote)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %q: %s", cfg.Src, err)
+	}
+	c.fs = fs
+	return c, nil
+}
+
+// MustStop stops c.
+func (c *Client) MustStop() {
+	c.fs.MustStop()
+}
+
+// Explore lists all the blocks in the bucket and returns blocks
+// matching configured tenants, resolutions and time filter.
+//
+// Blocks are expected to be stored at `<ULID>/` for Prometheus and Thanos
+// or at `<tenant>/<ULID>/` for Cortex and Mimir.
+// Blocks marked for deletion are skipped, since their data is already
+// contained in other blocks produced by compaction.
+// Explore doesn't take into account label filters.
+func (c *Client) Explore() ([]*Block, error) {
+	files, err := c.fs.ListFiles("")
+	if err != nil {
+		return nil, fmt.Errorf("cannot list files at %s: %s", c.fs, err)
+	}
+	blocks := make(map[string]*Block)
+	getBlock := func(dir string) *Block {
+		b := blocks[dir]
+		if b == nil {
+			b = &Block{Dir: dir}
+			blocks[dir] = b
+		}
+		return b
+	}
+	var dirs []string
+	for _, f := range files {
+		dir, name := path.Split(f.Path)
+		if dir == "" {
+			continue
+		}
+		dir = dir[:len(dir)-1]
+		switch name {
+		case "meta.json":
+			getBlock(dir).meta = f
+			dirs = append(dirs, dir)
+		case "index":
+			getBlock(dir).index = f
+		case "tombstones":
+			fi := f
+			getBlock(dir).tombstones = &fi
+		case "deletion-mark.json":
+			getBlock(dir).deletionMark = true
+		default:
+			blockDir, chunksDir := path.Split(dir)
+			if chunksDir == "chunks" && blockDir != "" {
+				if _, err := strconv.ParseUint(name, 10, 64); err == nil {
+					b := getBlock(blockDir[:len(blockDir)-1])
+					b.segments = append(b.segments, f)
+				}
+			}
+		}
+	}
+
+	s := &Stats{
+		Filtered: c.filter.min != 0 || c.filter.max != 0 || c.filter.label != "",
+	}
+	tenants := make(map[string]struct{})
+	var blocksToImport []*Block
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		b := getBlock(dir)
+		tenant, ulid := path.Split(dir)
+		b.Tenant = strings.TrimSuffix(tenant, "/")
+		if c.tenants != nil {
+			if _, ok := c.tenants[b.Tenant]; !ok {
+				continue
+			}
+		}
+		data, err := c.fs.ReadFileRange(b.meta.Path, 0, b.meta.Size)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read meta.json for block %q: %s", dir, err)
+		}
+		if err := json.Unmarshal(data, &b.Meta); err != nil {
+			return nil, fmt.Errorf("cannot parse meta.json for block %q: %s", dir, err)
+		}
+		if b.Meta.ULID.String() != ulid {
+			// Not a TSDB block
+			continue
+		}
+		s.Blocks++
+		tenants[b.Tenant] = struct{}{}
+		if b.deletionMark {
+			s.SkippedDeletedBlocks++
+			continue
+		}
+		if _, ok := c.resolutions[b.Meta.Thanos.Downsample.Resolution]; !ok {
+			s.SkippedDownsampledBlocks++
+			continue
+		}
+		if !c.filter.inRange(b.Meta.MinTime, b.Meta.MaxTime) {
+			s.SkippedBlocks++
+			continue
+		}
+		if b.index.Path == "" {
+			return nil, fmt.Errorf("missing index file for block %q", dir)
+		}
+		sort.Slice(b.segments, func(i, j int) bool {
+			return b.segments[i].Path < b.segments[j].Path
+		})
+		if s.MinTime == 0 || b.Meta.MinTime < s.MinTime {
+			s.MinTime = b.Meta.MinTime
+		}
+		if s.MaxTime == 0 || b.Meta.MaxTime > s.MaxTime {
+			s.MaxTime = b.Meta.MaxTime
+		}
+		s.Samples += b.Meta.Stats.NumSamples
+		s.Series += b.Meta.Stats.NumSeries
+		blocksToImport = append(blocksToImport, b)
+	}
+	s.Tenants = len(tenants)
+	fmt.Println(s)
+	return blocksToImport, nil
+}
+
+// Read reads series from the given block according to configured
+// time and label filters and calls f for every read series.
+//
+// The index file of the block is downloaded to a temporary directory,
+// while chunks are streamed from object storage.
+//
+// Every aggregate of downsampled Thanos blocks is passed to f as a separate series
+// with `:<aggregate>` suffix in the metric name.
+func (c *Client) Read(b *Block, f func(s *Series) error) error {
+	tmpDir, err := os.MkdirTemp(c.tmpDir, "vmctl-block-")
+	if err != nil {
+		return fmt.Errorf("cannot create temporary directory: %s", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	indexPath := filepath.Join(tmpDir, "index")
+	if err := downloadFile(c.fs, b.index.Path, b.index.Size, indexPath); err != nil {
+		return fmt.Errorf("cannot download index: %s", err)
+	}
+	ir, err := index.NewFileReader(indexPath)
+	if err != nil {
+		return fmt.Errorf("cannot open index: %s", err)
+	}
+	defer func() { _ = ir.Close() }()
+
+	tr := tombstones.Reader(tombstones.NewMemTombstones())
+	if b.tombstones != nil {
+		if err := downloadFile(c.fs, b.tombstones.Path, b.tombstones.Size, filepath.Join(tmpDir, "tombstones")); err != nil {
+			return fmt.Errorf("cannot download tombstones: %s", err)
+		}
+		tr, _, err = tombstones.ReadTombstones(tmpDir)
+		if err != nil {
+			return fmt.Errorf("cannot read tombstones: %s", err)
+		}
+	}
+	defer func() { _ = tr.Close() }()
+
+	var p index.Postings
+	if c.filter.label != "" {
+		m := labels.MustNewMatcher(labels.MatchRegexp, c.filter.label, c.filter.labelValue)
+		p, err = tsdb.PostingsForMatchers(ir, m)
+	} else {
+		p, err = ir.Postings(index.AllPostingsKey())
+	}
+	if err != nil {
+		return fmt.Errorf("cannot select series: %s", err)
+	}
+
+	minTime, maxTime := b.Meta.MinTime, b.Meta.MaxTime
+	if c.filter.min != 0 {
+		minTime = c.filter.min
+	}
+	if c.filter.max != 0 {
+		maxTime = c.filter.max
+	}
+	segments := make([]*segmentReader, len(b.segments))
+	for i, s := range b.segments {
+		segments[i] = &segmentReader{
+			fs:   c.fs,
+			path: s.Path,
+			size: s.Size,
+		}
+	}
+	extraLabels := c.extraLabels(b)
+	downsampled := b.Meta.Thanos.Downsample.Resolution > 0
+
+	var builder labels.ScratchBuilder
+	var chks []chunks.Meta
+	var it chunkenc.Iterator
+	for p.Next() {
+		ref := p.At()
+		if err := ir.Series(ref, &builder, &chks); err != nil {
+			return fmt.Errorf("cannot read series: %s", err)
+		}
+		deleted, err := tr.Get(ref)
+		if err != nil {
+			return fmt.Errorf("cannot read tombstones for series: %s", err)
+		}
+		lb := labels.NewBuilder(builder.Labels())
+		for _, l := range extraLabels {
+			if lb.Get(l.Name) == "" {
+				lb.Set(l.Name, l.Value)
+			}
+		}
+		var series []*Series
+		if downsampled {
+			series = make([]*Series, len(c.aggrs))
+			name := lb.Get(labels.MetricName)
+			for i, n := range c.aggrs {
+				lb.Set(labels.MetricName, name+":"+aggrTypes[n])
+				series[i] = &Series{Labels: lb.Labels()}
+			}
+		} else {
+			series = []*Series{{Labels: lb.Labels()}}
+		}
+		for _, chk := range chks {
+			if !chk.OverlapsClosedInterval(minTime, maxTime) {
+				continue
+			}
+			sgmIndex, offset := chunks.BlockChunkRef(chk.Ref).Unpack()
+			if sgmIndex >= len(segments) {
+				return fmt.Errorf("missing chunk segment #%d", sgmIndex+1)
+			}
+			enc, data, err := segments[sgmIndex].chunk(uint64(offset))
+			if err != nil {
+				return err
+			}
+			if !downsampled {
+				if enc != chunkenc.EncXOR {
+					// Skip unsupported chunks such as native histograms
+					continue
+				}
+				chunk, err := chunkenc.FromData(enc, data)
+				if err != nil {
+					return err
+				}
+				it = chunk.Iterator(it)
+				if err := series[0].appendSamples(it, minTime, maxTime, deleted); err != nil {
+					return err
+				}
+				continue
+			}
+			if enc != aggrEncoding {
+				return fmt.Errorf("unexpected chunk encoding %d in downsampled block; want %d", enc, aggrEncoding)
+			}
+			for i, n := range c.aggrs {
+				chunk, err := aggrChunk(data, n)
+				if err != nil {
+					return fmt.Errorf("cannot read Thanos aggregate chunk: %s", err)
+				}
+				if chunk == nil {
+					continue
+				}
+				it = chunk.Iterator(it)
+				if err := series[i].appendSamples(it, minTime, maxTime, deleted); err != nil {
+					return err
+				}
+			}
+		}
+		for _, s := range series {
+			if len(s.Timestamps) == 0 {
+				continue
+			}
+			if err := f(s); err != nil {
+				return err
+			}
+		}
+	}
+	return p.Err()
+}
+
+// extraLabels returns labels, which must be added to all the series from b.
+func (c *Client) extraLabels(b *Block) labels.Labels {
+	lb := labels.NewBuilder(labels.EmptyLabels())
+	for k, v := range b.Meta.Thanos.Labels {
+		if strings.HasPrefix(k, "__") {
+			// Skip internal labels such as `__org_id__` set by Mimir
+			continue
+		}
+		lb.Set(k, v)
+	}
+	if c.tenantLabel != "" {
+		lb.Set(c.tenantLabel, b.Tenant)
+	}
+	return lb.Labels()
+}
+
+func (s *Series) appendSamples(it chunkenc.Iterator, minTime, maxTime int64, deleted tombstones.Intervals) error {
+	for it.Next() == chunkenc.ValFloat {
+		t, v := it.At()
+		if t < minTime || t > maxTime || isDeleted(t, deleted) {
+			continue
+		}
+		if n := len(s.Timestamps); n > 0 && t <= s.Timestamps[n-1] {
+			// Skip duplicate samples. For example, Thanos counter aggregate
+			// repeats the last raw sample with the timestamp of the last aggregated sample.
+			continue
+		}
+		s.Timestamps = append(s.Timestamps, t)
+		s.Values = append(s.Values, v)
+	}
+	return it.Err()
+}
+
+func isDeleted(t int64, deleted tombstones.Intervals) bool {
+	for _, d := range deleted {
+		if d.InBounds(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTime(start, end string) (int64, int64, error) {
+	var s, e int64
+	if start != "" {
+		v, err := time.Parse(time.RFC3339, start)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to parse %q: %s", start, err)
+		}
+		s = v.UnixMilli()
+	}
+	if end != "" {
+		v, err := time.Parse(time.RFC3339, end)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to parse %q: %s", end, err)
+		}
+		e = v.UnixMilli()
+	}
+	return s, e, nil
+}
@@ -0,0 +1,385 @@
+package blocks
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+)
+
+type testSeries struct {
+	labels labels.Labels
+	chunks []chunkenc.Chunk
+}
+
+// writeBlock writes TSDB block with the given series to dir
+func writeBlock(t *testing.T, dir, ulid string, thanos map[string]interface{}, series []testSeries) {
+	t.Helper()
+	blockDir := filepath.Join(dir, ulid)
+	cw, err := chunks.NewWriter(filepath.Join(blockDir, "chunks"))
+	if err != nil {
+		t.Fatalf("cannot create chunks writer: %s", err)
+	}
+	iw, err := index.NewWriter(context.Background(), filepath.Join(blockDir, "index"))
+	if err != nil {
+		t.Fatalf("cannot create index writer: %s", err)
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return labels.Compare(series[i].labels, series[j].labels) < 0
+	})
+	symbols := make(map[string]struct{})
+	for _, s := range series {
+		s.labels.Range(func(l labels.Label) {
+			symbols[l.Name] = struct{}{}
+			symbols[l.Value] = struct{}{}
+		})
+	}
+	var sortedSymbols []string
+	for s := range symbols {
+		sortedSymbols = append(sortedSymbols, s)
+	}
+	sort.Strings(sortedSymbols)
+	for _, s := range sortedSymbols {
+		if err := iw.AddSymbol(s); err != nil {
+			t.Fatalf("cannot add symbol: %s", err)
+		}
+	}
+	minTime, maxTime := int64(1<<62), int64(-1<<62)
+	for i, s := range series {
+		var metas []chunks.Meta
+		for _, c := range s.chunks {
+			m := chunks.Meta{Chunk: c, MinTime: 1 << 62, MaxTime: -1 << 62}
+			it := iteratorForChunk(c)
+			for it.Next() != chunkenc.ValNone {
+				ts, _ := it.At()
+				if ts < m.MinTime {
+					m.MinTime = ts
+				}
+				if ts > m.MaxTime {
+					m.MaxTime = ts
+				}
+			}
+			if m.MinTime < minTime {
+				minTime = m.MinTime
+			}
+			if m.MaxTime > maxTime {
+				maxTime = m.MaxTime
+			}
+			metas = append(metas, m)
+		}
+		if err := cw.WriteChunks(metas...); err != nil {
+			t.Fatalf("cannot write chunks: %s", err)
+		}
+		if err := iw.AddSeries(storage.SeriesRef(i+1), s.labels, metas...); err != nil {
+			t.Fatalf("cannot add series: %s", err)
+		}
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatalf("cannot close chunks writer: %s", err)
+	}
+	if err := iw.Close(); err != nil {
+		t.Fatalf("cannot close index writer: %s", err)
+	}
+	meta := map[string]interface{}{
+		"ulid":    ulid,
+		"minTime": minTime,
+		"maxTime": maxTime + 1,
+		"version": 1,
+		"stats": map[string]interface{}{
+			"numSeries": len(series),
+		},
+	}
+	if thanos != nil {
+		meta["thanos"] = thanos
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		t.Fatalf("cannot marshal meta: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(blockDir, "meta.json"), data, 0644); err != nil {
+		t.Fatalf("cannot write meta: %s", err)
+	}
+}
+
+// iteratorForChunk returns iterator for c, which may be Thanos aggregate chunk.
+func iteratorForChunk(c chunkenc.Chunk) chunkenc.Iterator {
+	if ac, ok := c.(aggrTestChunk); ok {
+		for n := range aggrTypes {
+			chunk, err := aggrChunk(ac, n)
+			if err == nil && chunk != nil {
+				return chunk.Iterator(nil)
+			}
+		}
+	}
+	return c.Iterator(nil)
+}
+
+func newXORChunk(t *testing.T, timestamps []int64, values []float64) chunkenc.Chunk {
+	t.Helper()
+	c := chunkenc.NewXORChunk()
+	app, err := c.Appender()
+	if err != nil {
+		t.Fatalf("cannot create appender: %s", err)
+	}
+	for i, ts := range timestamps {
+		app.Append(ts, values[i])
+	}
+	return c
+}
+
+// aggrTestChunk is Thanos aggregate chunk.
+type aggrTestChunk []byte
+
+func newAggrChunk(aggrs [5]chunkenc.Chunk) aggrTestChunk {
+	var b []byte
+	for _, c := range aggrs {
+		if c == nil {
+			b = binary.AppendUvarint(b, 0)
+			continue
+		}
+		b = binary.AppendUvarint(b, uint64(len(c.Bytes())))
+		b = append(b, byte(c.Encoding()))
+		b = append(b, c.Bytes()...)
+	}
+	return b
+}
+
+func (c aggrTestChunk) Bytes() []byte               { return c }
+func (c aggrTestChunk) Encoding() chunkenc.Encoding { return aggrEncoding }
+func (c aggrTestChunk) Appender() (chunkenc.Appender, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+func (c aggrTestChunk) Iterator(chunkenc.Iterator) chunkenc.Iterator {
+	return chunkenc.NewNopIterator()
+}
+func (c aggrTestChunk) NumSamples() int { return 0 }
+func (c aggrTestChunk) Compact()        {}
+
+func TestAggrChunk(t *testing.T) {
+	sum := newXORChunk(t, []int64{1, 2}, []float64{10, 20})
+	counter := newXORChunk(t, []int64{1, 2, 2}, []float64{5, 7, 8})
+	data := newAggrChunk([5]chunkenc.Chunk{nil, sum, nil, nil, counter})
+
+	f := func(n int, timestampsExpected []int64, valuesExpected []float64) {
+		t.Helper()
+		c, err := aggrChunk(data, n)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if timestampsExpected == nil {
+			if c != nil {
+				t.Fatalf("expecting missing %q aggregate", aggrTypes[n])
+			}
+			return
+		}
+		var timestamps []int64
+		var values []float64
+		it := c.Iterator(nil)
+		for it.Next() != chunkenc.ValNone {
+			ts, v := it.At()
+			timestamps = append(timestamps, ts)
+			values = append(values, v)
+		}
+		if !reflect.DeepEqual(timestamps, timestampsExpected) || !reflect.DeepEqual(values, valuesExpected) {
+			t.Fatalf("unexpected %q aggregate; got %v %v; want %v %v", aggrTypes[n], timestamps, values, timestampsExpected, valuesExpected)
+		}
+	}
+	f(0, nil, nil)
+	f(1, []int64{1, 2}, []float64{10, 20})
+	f(2, nil, nil)
+	f(4, []int64{1, 2, 2}, []float64{5, 7, 8})
+
+	// Truncated data
+	if _, err := aggrChunk(data[:len(data)-3], 4); err == nil {
+		t.Fatalf("expecting non-nil error for truncated chunk")
+	}
+	if _, err := parseAggrs([]string{"sum", "avg"}); err == nil {
+		t.Fatalf("expecting non-nil error for unsupported aggregate")
+	}
+}
+
+func TestClient(t *testing.T) {
+	bucket := t.TempDir()
+
+	// Mimir-like block with tenant prefix and external labels
+	writeBlock(t, filepath.Join(bucket, "tenant-a"), "01GZYXJ2Q7B3VJ8E0S3XGQ4K5M", map[string]interface{}{
+		"labels": map[string]string{"cluster": "c1", "__org_id__": "tenant-a"},
+	}, []testSeries{
+		{
+			labels: labels.FromStrings("__name__", "foo", "job", "a"),
+			chunks: []chunkenc.Chunk{
+				newXORChunk(t, []int64{1000, 2000}, []float64{1, 2}),
+				newXORChunk(t, []int64{3000, 4000}, []float64{3, 4}),
+			},
+		},
+		{
+			labels: labels.FromStrings("__name__", "bar", "job", "a", "cluster", "own"),
+			chunks: []chunkenc.Chunk{
+				newXORChunk(t, []int64{1000, 2000}, []float64{10, 20}),
+			},
+		},
+	})
+	// Block marked for deletion
+	writeBlock(t, filepath.Join(bucket, "tenant-b"), "01GZYXJ2Q7B3VJ8E0S3XGQ4K5N", nil, []testSeries{
+		{
+			labels: labels.FromStrings("__name__", "foo"),
+			chunks: []chunkenc.Chunk{newXORChunk(t, []int64{1000}, []float64{1})},
+		},
+	})
+	if err := os.WriteFile(filepath.Join(bucket, "tenant-b", "01GZYXJ2Q7B3VJ8E0S3XGQ4K5N", "deletion-mark.json"), []byte("{}"), 0644); err != nil {
+		t.Fatalf("cannot write deletion mark: %s", err)
+	}
+	// Thanos downsampled block
+	writeBlock(t, filepath.Join(bucket, "tenant-c"), "01GZYXJ2Q7B3VJ8E0S3XGQ4K5P", map[string]interface{}{
+		"downsample": map[string]interface{}{"resolution": 300000},
+	}, []testSeries{
+		{
+			labels: labels.FromStrings("__name__", "foo"),
+			chunks: []chunkenc.Chunk{newAggrChunk([5]chunkenc.Chunk{
+				newXORChunk(t, []int64{300000, 600000}, []float64{30, 30}),
+				newXORChunk(t, []int64{300000, 600000}, []float64{60, 90}),
+				nil,
+				newXORChunk(t, []int64{300000, 600000}, []float64{5, 6}),
+				newXORChunk(t, []int64{300000, 600000, 600000}, []float64{100, 200, 201}),
+			})},
+		},
+	})
+
+	type result map[string][]int64
+
+	f := func(cfg Config, blocksExpected int, resultExpected result) {
+		t.Helper()
+		cfg.Src = "fs://" + bucket
+		cfg.TmpDir = t.TempDir()
+		if cfg.Resolutions == nil {
+			cfg.Resolutions = []string{"raw"}
+		}
+		c, err := NewClient(cfg)
+		if err != nil {
+			t.Fatalf("cannot create client: %s", err)
+		}
+		defer c.MustStop()
+		blocks, err := c.Explore()
+		if err != nil {
+			t.Fatalf("cannot explore blocks: %s", err)
+		}
+		if len(blocks) != blocksExpected {
+			t.Fatalf("unexpected number of blocks; got %d; want %d", len(blocks), blocksExpected)
+		}
+		res := make(result)
+		for _, b := range blocks {
+			err := c.Read(b, func(s *Series) error {
+				if len(s.Timestamps) != len(s.Values) {
+					return fmt.Errorf("timestamps and values mismatch for %s", s.Labels)
+				}
+				res[s.Labels.String()] = s.Timestamps
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("cannot read block %q: %s", b.Dir, err)
+			}
+		}
+		if !reflect.DeepEqual(res, resultExpected) {
+			t.Fatalf("unexpected result;\ngot\n%v\nwant\n%v", res, resultExpected)
+		}
+	}
+
+	// All raw blocks
+	f(Config{}, 1, result{
+		`{__name__="foo", cluster="c1", job="a"}`:  {1000, 2000, 3000, 4000},
+		`{__name__="bar", cluster="own", job="a"}`: {1000, 2000},
+	})
+
+	// Time and label filters with tenant label
+	f(Config{
+		TenantLabel: "tenant",
+		Filter: Filter{
+			TimeMin:    "1970-01-01T00:00:02Z",
+			TimeMax:    "1970-01-01T00:00:03Z",
+			Label:      "__name__",
+			LabelValue: "fo.*",
+		},
+	}, 1, result{
+		`{__name__="foo", cluster="c1", job="a", tenant="tenant-a"}`: {2000, 3000},
+	})
+
+	// Time filter excluding all the blocks
+	f(Config{
+		Filter: Filter{TimeMin: "2020-01-01T00:00:00Z"},
+	}, 0, result{})
+
+	// Downsampled blocks only
+	f(Config{
+		Resolutions: []string{"5m"},
+		Aggregates:  []string{"sum", "min", "counter"},
+	}, 1, result{
+		`{__name__="foo:sum"}`:     {300000, 600000},
+		`{__name__="foo:counter"}`: {300000, 600000},
+	})
+
+	// Tenant filter
+	f(Config{
+		Tenants:     []string{"tenant-b", "tenant-c"},
+		Resolutions: []string{"raw", "5m"},
+		Aggregates:  []string{"max"},
+	}, 1, result{
+		`{__name__="foo:max"}`: {300000, 600000},
+	})
+}
+
+func TestSegmentReader(t *testing.T) {
+	dir := t.TempDir()
+	writeBlock(t, dir, "01GZYXJ2Q7B3VJ8E0S3XGQ4K5M", nil, []testSeries{
+		{
+			labels: labels.FromStrings("__name__", "foo"),
+			chunks: []chunkenc.Chunk{newXORChunk(t, []int64{1, 2}, []float64{1, 2})},
+		},
+	})
+	fs, err := newRemoteFS("fs://"+dir, RemoteConfig{})
+	if err != nil {
+		t.Fatalf("cannot create fs: %s", err)
+	}
+	path := "01GZYXJ2Q7B3VJ8E0S3XGQ4K5M/chunks/000001"
+	data, err := os.ReadFile(filepath.Join(dir, path))
+	if err != nil {
+		t.Fatalf("cannot read segment: %s", err)
+	}
+	sr := &segmentReader{
+		fs:   fs,
+		path: path,
+		size: uint64(len(data)),
+	}
+	enc, _, err := sr.chunk(chunks.SegmentHeaderSize)
+	if err != nil {
+		t.Fatalf("cannot read chunk: %s", err)
+	}
+	if enc != chunkenc.EncXOR {
+		t.Fatalf("unexpected chunk encoding; got %s; want %s", enc, chunkenc.EncXOR)
+	}
+
+	// Corrupted checksum
+	data[len(data)-1]++
+	if err := os.WriteFile(filepath.Join(dir, path), data, 0644); err != nil {
+		t.Fatalf("cannot write segment: %s", err)
+	}
+	sr.buf = nil
+	if _, _, err := sr.chunk(chunks.SegmentHeaderSize); err == nil {
+		t.Fatalf("expecting non-nil error for corrupted chunk")
+	}
+
+	// Out of range offset
+	if _, _, err := sr.chunk(uint64(len(data))); err == nil {
+		t.Fatalf("expecting non-nil error for out of range chunk")
+	}
+}
@@ -0,0 +1,209 @@
+package blocks
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/azremote"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/fsremote"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/gcsremote"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/s3remote"
+)
+
+// RemoteConfig contains params for accessing object storage with TSDB blocks.
+type RemoteConfig struct {
+	// CredsFilePath is a path to file with GCS or S3 credentials.
+	CredsFilePath string
+	// ConfigFilePath is a path to file with S3 configs.
+	ConfigFilePath string
+	// ConfigProfile is a profile name for S3 configs.
+	ConfigProfile string
+	// CustomS3Endpoint is a custom S3 endpoint for S3-compatible storages.
+	CustomS3Endpoint string
+	// S3ForcePathStyle prefixes S3 endpoint with bucket name when set to false.
+	S3ForcePathStyle bool
+}
+
+// newRemoteFS returns RemoteFS for the given src in the form `scheme://bucket/path`.
+//
+// Unlike vmbackup, the path inside the bucket may be empty,
+// since Thanos and Mimir usually store blocks at the bucket root.
+func newRemoteFS(src string, cfg RemoteConfig) (common.RemoteFS, error) {
+	n := strings.Index(src, "://")
+	if n < 0 {
+		return nil, fmt.Errorf("missing scheme in %q. Supported schemes: `gs://`, `s3://`, `azblob://`, `fs://`", src)
+	}
+	scheme := src[:n]
+	path := src[n+len("://"):]
+	if scheme == "fs" {
+		if !filepath.IsAbs(path) {
+			return nil, fmt.Errorf("dir must be absolute; got %q", path)
+		}
+		return &fsremote.FS{
+			Dir: filepath.Clean(path),
+		}, nil
+	}
+	bucket, dir, _ := strings.Cut(path, "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("missing bucket name in %q", src)
+	}
+	// Init() methods below normalize empty dir to "/", which doesn't match
+	// object names at the bucket root. So the dir is restored after Init() call.
+	isRoot := strings.Trim(dir, "/") == ""
+	switch scheme {
+	case "gcs", "gs":
+		fs := &gcsremote.FS{
+			CredsFilePath: cfg.CredsFilePath,
+			Bucket:        bucket,
+			Dir:           dir,
+		}
+		if err := fs.Init(); err != nil {
+			return nil, fmt.Errorf("cannot initialize connection to gcs: %w", err)
+		}
+		if isRoot {
+			fs.Dir = ""
+		}
+		return fs, nil
+	case "azblob":
+		fs := &azremote.FS{
+			Container: bucket,
+			Dir:       dir,
+		}
+		if err := fs.Init(); err != nil {
+			return nil, fmt.Errorf("cannot initialize connection to AZBlob: %w", err)
+		}
+		if isRoot {
+			fs.Dir = ""
+		}
+		return fs, nil
+	case "s3":
+		fs := &s3remote.FS{
+			CredsFilePath:    cfg.CredsFilePath,
+			ConfigFilePath:   cfg.ConfigFilePath,
+			CustomEndpoint:   cfg.CustomS3Endpoint,
+			S3ForcePathStyle: cfg.S3ForcePathStyle,
+			ProfileName:      cfg.ConfigProfile,
+			Bucket:           bucket,
+			Dir:              dir,
+		}
+		if err := fs.Init(); err != nil {
+			return nil, fmt.Errorf("cannot initialize connection to s3: %w", err)
+		}
+		if isRoot {
+			fs.Dir = ""
+		}
+		return fs, nil
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q", scheme)
+	}
+}
+
+// downloadFile downloads the file with the given size from fs to localPath.
+func downloadFile(fs common.RemoteFS, path string, size uint64, localPath string) error {
+	f, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("cannot create %q: %w", localPath, err)
+	}
+	for offset := uint64(0); offset < size; {
+		n := size - offset
+		if n > downloadChunkSize {
+			n = downloadChunkSize
+		}
+		data, err := fs.ReadFileRange(path, offset, n)
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("cannot write to %q: %w", localPath, err)
+		}
+		offset += n
+	}
+	return f.Close()
+}
+
+const downloadChunkSize = 64 << 20
+
+// segmentReadWindow is the minimum number of bytes to read from chunk segment at once.
+//
+// Series in the block index are ordered in the same way as their chunks in segments,
+// so chunks are read sequentially from every segment with rare forward jumps
+// for series skipped by filters.
+const segmentReadWindow = 16 << 20
+
+// segmentReader reads chunks from chunk segment file stored at RemoteFS.
+type segmentReader struct {
+	fs   common.RemoteFS
+	path string
+	size uint64
+
+	// buf contains segment data starting at bufOffset.
+	buf       []byte
+	bufOffset uint64
+}
+
+// readRange returns size bytes from the segment starting at offset.
+//
+// The returned data is valid until the next call to readRange.
+func (sr *segmentReader) readRange(offset, size uint64) ([]byte, error) {
+	if offset+size > sr.size {
+		return nil, fmt.Errorf("cannot read %d bytes at offset %d from %q with size %d bytes", size, offset, sr.path, sr.size)
+	}
+	if offset >= sr.bufOffset && offset+size <= sr.bufOffset+uint64(len(sr.buf)) {
+		start := offset - sr.bufOffset
+		return sr.buf[start : start+size], nil
+	}
+	n := size
+	if n < segmentReadWindow {
+		n = segmentReadWindow
+	}
+	if offset+n > sr.size {
+		n = sr.size - offset
+	}
+	data, err := sr.fs.ReadFileRange(sr.path, offset, n)
+	if err != nil {
+		return nil, err
+	}
+	sr.buf = data
+	sr.bufOffset = offset
+	return sr.buf[:size], nil
+}
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// chunk returns chunk encoding and chunk data stored at the given offset.
+//
+// See https://github.com/prometheus/prometheus/blob/main/tsdb/docs/format/chunks.md
+func (sr *segmentReader) chunk(offset uint64) (chunkenc.Encoding, []byte, error) {
+	n := uint64(chunks.MaxChunkLengthFieldSize)
+	if offset+n > sr.size {
+		n = sr.size - offset
+	}
+	b, err := sr.readRange(offset, n)
+	if err != nil {
+		return 0, nil, err
+	}
+	dataLen, n1 := binary.Uvarint(b)
+	if n1 <= 0 {
+		return 0, nil, fmt.Errorf("cannot read chunk length at offset %d in %q", offset, sr.path)
+	}
+	b, err = sr.readRange(offset, uint64(n1)+chunks.ChunkEncodingSize+dataLen+crc32.Size)
+	if err != nil {
+		return 0, nil, err
+	}
+	b = b[n1:]
+	sumStart := len(b) - crc32.Size
+	if crc32.Checksum(b[:sumStart], castagnoliTable) != binary.BigEndian.Uint32(b[sumStart:]) {
+		return 0, nil, fmt.Errorf("checksum mismatch for chunk at offset %d in %q", offset, sr.path)
+	}
+	return chunkenc.Encoding(b[0]), b[chunks.ChunkEncodingSize:sumStart], nil
+}
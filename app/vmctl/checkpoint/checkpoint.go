@@ -0,0 +1,162 @@
+package checkpoint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Unit is a single migration unit - time series matching Match
+// on the time range from Start to End.
+type Unit struct {
+	Tenant string `json:"tenant,omitempty"`
+	Match  string `json:"match"`
+	Start  string `json:"start"`
+	End    string `json:"end"`
+}
+
+// String returns user-readable representation of u.
+func (u Unit) String() string {
+	s := fmt.Sprintf("match[]=%s, start=%s, end=%s", u.Match, u.Start, u.End)
+	if u.Tenant != "" {
+		s += fmt.Sprintf(", tenant=%s", u.Tenant)
+	}
+	return s
+}
+
+// record is a single line in the checkpoint file.
+type record struct {
+	Unit
+	// Invalid is set for units, which failed verification after the migration.
+	Invalid bool `json:"invalid,omitempty"`
+}
+
+// Checkpoint tracks completed migration units in a file,
+// so the interrupted migration could be resumed by skipping already completed units.
+//
+// Every completed unit is appended to the file as a separate JSON line,
+// so the file remains consistent if vmctl is interrupted at any moment.
+//
+// nil Checkpoint is valid - it doesn't track anything.
+type Checkpoint struct {
+	path string
+
+	mu   sync.Mutex
+	f    *os.File
+	done map[Unit]struct{}
+}
+
+// Open opens checkpoint file at the given path.
+//
+// The file is created if it is missing.
+func Open(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("cannot read checkpoint file: %w", err)
+	}
+	// Every record is written with the trailing newline, so the last line without newline
+	// is an incomplete record left after vmctl was killed while writing it.
+	// It is dropped from the file.
+	validLen := bytes.LastIndexByte(data, '\n') + 1
+	done := make(map[Unit]struct{})
+	for i, line := range bytes.Split(data[:validLen], []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var r record
+		if err := json.Unmarshal(line, &r); err != nil {
+			return nil, fmt.Errorf("cannot parse line #%d in checkpoint file %q: %w", i+1, path, err)
+		}
+		if r.Invalid {
+			delete(done, r.Unit)
+		} else {
+			done[r.Unit] = struct{}{}
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open checkpoint file: %w", err)
+	}
+	if validLen < len(data) {
+		if err := f.Truncate(int64(validLen)); err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("cannot drop incomplete last line from checkpoint file %q: %w", path, err)
+		}
+	}
+	return &Checkpoint{
+		path: path,
+		f:    f,
+		done: done,
+	}, nil
+}
+
+// Len returns the number of completed units in c.
+func (c *Checkpoint) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.done)
+}
+
+// IsDone returns true if u is already completed.
+func (c *Checkpoint) IsDone(u Unit) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.done[u]
+	return ok
+}
+
+// MarkDone registers u as completed.
+//
+// The unit is persisted to the checkpoint file before returning.
+func (c *Checkpoint) MarkDone(u Unit) error {
+	return c.append(record{Unit: u})
+}
+
+// MarkInvalid unregisters u from completed units,
+// so it is migrated again on the next run.
+func (c *Checkpoint) MarkInvalid(u Unit) error {
+	return c.append(record{Unit: u, Invalid: true})
+}
+
+func (c *Checkpoint) append(r record) error {
+	if c == nil {
+		return nil
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("cannot marshal checkpoint record: %w", err)
+	}
+	line = append(line, '\n')
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.f.Write(line); err != nil {
+		return fmt.Errorf("cannot write to checkpoint file %q: %w", c.path, err)
+	}
+	if err := c.f.Sync(); err != nil {
+		return fmt.Errorf("cannot sync checkpoint file %q: %w", c.path, err)
+	}
+	if r.Invalid {
+		delete(c.done, r.Unit)
+	} else {
+		c.done[r.Unit] = struct{}{}
+	}
+	return nil
+}
+
+// Close closes the checkpoint file.
+func (c *Checkpoint) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.f.Close()
+}
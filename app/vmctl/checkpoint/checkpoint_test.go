@@ -0,0 +1,99 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+
+	u1 := Unit{Match: `{__name__="foo"}`, Start: "2023-01-01T00:00:00Z", End: "2023-01-02T00:00:00Z"}
+	u2 := Unit{Match: `{__name__="foo"}`, Start: "2023-01-02T00:00:00Z", End: "2023-01-03T00:00:00Z"}
+	u3 := Unit{Tenant: "1:0", Match: `{__name__="foo"}`, Start: "2023-01-01T00:00:00Z", End: "2023-01-02T00:00:00Z"}
+
+	mustOpen := func() *Checkpoint {
+		t.Helper()
+		c, err := Open(path)
+		if err != nil {
+			t.Fatalf("cannot open checkpoint: %s", err)
+		}
+		return c
+	}
+	checkDone := func(c *Checkpoint, units ...Unit) {
+		t.Helper()
+		for _, u := range units {
+			if !c.IsDone(u) {
+				t.Fatalf("expecting unit %s to be done", u)
+			}
+		}
+		if n := c.Len(); n != len(units) {
+			t.Fatalf("unexpected number of done units; got %d; want %d", n, len(units))
+		}
+	}
+
+	// Empty checkpoint
+	c := mustOpen()
+	checkDone(c)
+	if err := c.MarkDone(u1); err != nil {
+		t.Fatalf("cannot mark unit as done: %s", err)
+	}
+	if err := c.MarkDone(u3); err != nil {
+		t.Fatalf("cannot mark unit as done: %s", err)
+	}
+	checkDone(c, u1, u3)
+	if c.IsDone(u2) {
+		t.Fatalf("unit %s mustn't be done", u2)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("cannot close checkpoint: %s", err)
+	}
+
+	// Re-open the checkpoint and invalidate a unit
+	c = mustOpen()
+	checkDone(c, u1, u3)
+	if err := c.MarkInvalid(u1); err != nil {
+		t.Fatalf("cannot mark unit as invalid: %s", err)
+	}
+	checkDone(c, u3)
+	if err := c.Close(); err != nil {
+		t.Fatalf("cannot close checkpoint: %s", err)
+	}
+
+	// Incomplete last line must be ignored
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("cannot open checkpoint file: %s", err)
+	}
+	if _, err := f.WriteString(`{"match":"{__name__=\"bar\"}","sta`); err != nil {
+		t.Fatalf("cannot write to checkpoint file: %s", err)
+	}
+	_ = f.Close()
+	c = mustOpen()
+	checkDone(c, u3)
+	if err := c.MarkDone(u2); err != nil {
+		t.Fatalf("cannot mark unit as done: %s", err)
+	}
+	_ = c.Close()
+	c = mustOpen()
+	checkDone(c, u2, u3)
+	_ = c.Close()
+
+	// Corrupted line in the middle of the file
+	if err := os.WriteFile(path, []byte("foobar\n{}\n"), 0644); err != nil {
+		t.Fatalf("cannot write checkpoint file: %s", err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Fatalf("expecting non-nil error for corrupted checkpoint file")
+	}
+
+	// nil checkpoint doesn't track anything
+	var cNil *Checkpoint
+	if err := cNil.MarkDone(u1); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if cNil.IsDone(u1) {
+		t.Fatalf("nil checkpoint mustn't contain done units")
+	}
+}
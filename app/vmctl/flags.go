@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/urfave/cli/v2"
@@ -319,6 +320,174 @@ var (
 	}
 )
 
+const (
+	blocksSrc              = "blocks-src"
+	blocksConcurrency      = "blocks-concurrency"
+	blocksTmpDir           = "blocks-tmp-dir"
+	blocksTenant           = "blocks-tenant"
+	blocksTenantLabel      = "blocks-tenant-label"
+	blocksResolution       = "blocks-resolution"
+	blocksDownsampledAggr  = "blocks-downsampled-aggr"
+	blocksFilterTimeStart  = "blocks-filter-time-start"
+	blocksFilterTimeEnd    = "blocks-filter-time-end"
+	blocksFilterLabel      = "blocks-filter-label"
+	blocksFilterLabelValue = "blocks-filter-label-value"
+	blocksCredsFilePath    = "blocks-creds-file-path"
+	blocksConfigFilePath   = "blocks-config-file-path"
+	blocksConfigProfile    = "blocks-config-profile"
+	blocksCustomS3Endpoint = "blocks-custom-s3-endpoint"
+	blocksS3ForcePathStyle = "blocks-s3-force-path-style"
+)
+
+var (
+	blocksFlags = []cli.Flag{
+		&cli.StringFlag{
+			Name: blocksSrc,
+			Usage: "Path to the bucket with Prometheus, Thanos, Cortex or Mimir TSDB blocks. " +
+				"Supported schemes: 's3://bucket/path', 'gs://bucket/path', 'azblob://container/path', 'fs:///local/path'. \n" +
+				"Blocks are looked up at '<ULID>/' and '<tenant>/<ULID>/' paths relative to the given path.",
+			Required: true,
+		},
+		&cli.IntFlag{
+			Name:  blocksConcurrency,
+			Usage: "Number of concurrently running block readers",
+			Value: 1,
+		},
+		&cli.StringFlag{
+			Name:  blocksTmpDir,
+			Usage: "Directory for temporary files. Index file of every block is downloaded there while the block is imported",
+			Value: os.TempDir(),
+		},
+		&cli.StringSliceFlag{
+			Name:  blocksTenant,
+			Usage: "Tenants to import blocks for. Tenant is the first path element for Cortex and Mimir blocks. By default, blocks for all the tenants are imported",
+		},
+		&cli.StringFlag{
+			Name:  blocksTenantLabel,
+			Usage: "Optional label name to add to imported series with the tenant of the block as value",
+		},
+		&cli.StringSliceFlag{
+			Name: blocksResolution,
+			Usage: "Thanos downsampling resolutions to import blocks for. Supported values: 'raw', '5m', '1h'. \n" +
+				"Thanos keeps raw and downsampled blocks for the same time ranges, so import only the resolutions you need",
+			Value: cli.NewStringSlice("raw"),
+		},
+		&cli.StringSliceFlag{
+			Name: blocksDownsampledAggr,
+			Usage: "Aggregates to import from Thanos downsampled blocks. Supported values: 'count', 'sum', 'min', 'max', 'counter'. \n" +
+				"Every aggregate is imported as a separate series with ':<aggregate>' suffix in the metric name",
+			Value: cli.NewStringSlice("count", "sum", "min", "max", "counter"),
+		},
+		&cli.StringFlag{
+			Name:  blocksFilterTimeStart,
+			Usage: "The time filter in RFC3339 format to select samples with timestamp equal or higher than provided value. E.g. '2020-01-01T20:07:00Z'",
+		},
+		&cli.StringFlag{
+			Name:  blocksFilterTimeEnd,
+			Usage: "The time filter in RFC3339 format to select samples with timestamp equal or lower than provided value. E.g. '2020-01-01T20:07:00Z'",
+		},
+		&cli.StringFlag{
+			Name:  blocksFilterLabel,
+			Usage: "Prometheus label name to filter timeseries by. E.g. '__name__' will filter timeseries by name.",
+		},
+		&cli.StringFlag{
+			Name:  blocksFilterLabelValue,
+			Usage: fmt.Sprintf("Prometheus regular expression to filter label from %q flag.", blocksFilterLabel),
+			Value: ".*",
+		},
+		&cli.StringFlag{
+			Name: blocksCredsFilePath,
+			Usage: "Path to file with GCS or S3 credentials. Credentials are loaded from default locations if not set. \n" +
+				"See https://cloud.google.com/iam/docs/creating-managing-service-account-keys and https://docs.aws.amazon.com/general/latest/gr/aws-security-credentials.html",
+		},
+		&cli.StringFlag{
+			Name:  blocksConfigFilePath,
+			Usage: "Path to file with S3 configs. Configs are loaded from default location if not set",
+		},
+		&cli.StringFlag{
+			Name:  blocksConfigProfile,
+			Usage: "Profile name for S3 configs. If not set, the value of the environment variable AWS_PROFILE or AWS_DEFAULT_PROFILE is used",
+		},
+		&cli.StringFlag{
+			Name:  blocksCustomS3Endpoint,
+			Usage: "Custom S3 endpoint for use with S3-compatible storages (e.g. MinIO). S3 is used if not set",
+		},
+		&cli.BoolFlag{
+			Name:  blocksS3ForcePathStyle,
+			Usage: "Prefixing endpoint with bucket name when set false, true by default.",
+			Value: true,
+		},
+	}
+)
+
+const (
+	whisperPath            = "whisper-path"
+	whisperConcurrency     = "whisper-concurrency"
+	whisperFilterTimeStart = "whisper-filter-time-start"
+	whisperFilterTimeEnd   = "whisper-filter-time-end"
+	whisperFilterName      = "whisper-filter-name"
+)
+
+var (
+	whisperFlags = []cli.Flag{
+		&cli.StringFlag{
+			Name: whisperPath,
+			Usage: "Path to the directory with Graphite whisper files, e.g. '/var/lib/graphite/whisper'. \n" +
+				"Metric names are built from file paths relative to this directory. Tagged series are read from '_tagged' subdirectory.",
+			Required: true,
+		},
+		&cli.IntFlag{
+			Name:  whisperConcurrency,
+			Usage: "Number of concurrently running whisper file readers",
+			Value: 1,
+		},
+		&cli.StringFlag{
+			Name:  whisperFilterTimeStart,
+			Usage: "The time filter in RFC3339 format to select samples with timestamp equal or higher than provided value. E.g. '2020-01-01T20:07:00Z'",
+		},
+		&cli.StringFlag{
+			Name:  whisperFilterTimeEnd,
+			Usage: "The time filter in RFC3339 format to select samples with timestamp equal or lower than provided value. E.g. '2020-01-01T20:07:00Z'",
+		},
+		&cli.StringFlag{
+			Name: whisperFilterName,
+			Usage: "Regular expression to filter Graphite metric names by. E.g. 'servers\\..+\\.cpu\\..+'. \n" +
+				"Tagged series are matched in the 'name;tag1=value1;tag2=value2' form. By default, all the files are imported.",
+		},
+	}
+)
+
+const (
+	checkpointFile  = "checkpoint-file"
+	verifyMigration = "verify"
+	verifyDstAddr   = "verify-dst-addr"
+)
+
+var (
+	// checkpointFlags are used in vm-native and remote-read modes
+	checkpointFlags = []cli.Flag{
+		&cli.StringFlag{
+			Name: checkpointFile,
+			Usage: "Optional path to the file for tracking the migration progress. \n" +
+				"Every completed (series filter, time range) request is registered in this file. Re-running the same command with the same file \n" +
+				"skips already completed requests, so interrupted migration is resumed from the point where it stopped. \n" +
+				"Do not share the file between migrations with distinct source or destination.",
+		},
+		&cli.BoolFlag{
+			Name: verifyMigration,
+			Usage: "Whether to compare the number of series and samples between the source and the destination for every migrated time range after the migration. \n" +
+				fmt.Sprintf("Time ranges with mismatched numbers are removed from --%s, so they are migrated again on the next run.", checkpointFile),
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name: verifyDstAddr,
+			Usage: fmt.Sprintf("Optional VictoriaMetrics address for querying the destination during --%s. \n", verifyMigration) +
+				"By default, the destination address is used. It must be set to vmselect address if the destination is VictoriaMetrics cluster, \n" +
+				"e.g. 'http://vmselect:8481/select/0/prometheus' or 'http://vmselect:8481/' in cluster-to-cluster migration mode.",
+		},
+	}
+)
+
 const (
 	vmNativeFilterMatch     = "vm-native-filter-match"
 	vmNativeFilterTimeStart = "vm-native-filter-time-start"
@@ -327,6 +496,7 @@ const (
 
 	vmNativeDisableHTTPKeepAlive = "vm-native-disable-http-keep-alive"
 	vmNativeDisableRetries       = "vm-native-disable-retries"
+	vmNativeMergePolicy          = "vm-native-merge-policy"
 
 	vmNativeSrcAddr        = "vm-native-src-addr"
 	vmNativeSrcUser        = "vm-native-src-user"
@@ -450,6 +620,12 @@ var (
 			Usage: "Defines whether to disable retries with backoff policy for migration process",
 			Value: false,
 		},
+		&cli.StringFlag{
+			Name: vmNativeMergePolicy,
+			Usage: "Optional policy for merging the imported samples with the existing samples with the same timestamps at destination. " +
+				"Supported values: 'keep_existing' - skip the imported samples if the destination already contains samples with the same timestamps. " +
+				"This makes re-running the migration idempotent. See https://docs.victoriametrics.com/#how-to-import-data-in-native-format",
+		},
 	}
 )
 
@@ -14,6 +14,8 @@ import (
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/auth"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/backoff"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/blocks"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/checkpoint"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/native"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/remoteread"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/terminal"
@@ -23,6 +25,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/opentsdb"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/prometheus"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/vm"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/whisper"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/buildinfo"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/native/stream"
@@ -120,7 +123,7 @@ func main() {
 			{
 				Name:  "remote-read",
 				Usage: "Migrate time series via Prometheus remote-read protocol",
-				Flags: mergeFlags(globalFlags, remoteReadFlags, vmFlags),
+				Flags: mergeFlags(globalFlags, remoteReadFlags, checkpointFlags, vmFlags),
 				Action: func(c *cli.Context) error {
 					rr, err := remoteread.NewClient(remoteread.Config{
 						Addr:               c.String(remoteReadSrcAddr),
@@ -144,6 +147,12 @@ func main() {
 						return fmt.Errorf("failed to create VM importer: %s", err)
 					}
 
+					cp, err := openCheckpoint(c)
+					if err != nil {
+						return err
+					}
+					defer func() { _ = cp.Close() }()
+
 					rmp := remoteReadProcessor{
 						src: rr,
 						dst: importer,
@@ -151,9 +160,27 @@ func main() {
 							timeStart: c.Timestamp(remoteReadFilterTimeStart),
 							timeEnd:   c.Timestamp(remoteReadFilterTimeEnd),
 							chunk:     c.String(remoteReadStepInterval),
+							match:     remoteReadMatch(c.String(remoteReadFilterLabel), c.String(remoteReadFilterLabelValue)),
 						},
+						cp: cp,
 						cc: c.Int(remoteReadConcurrency),
 					}
+					if c.Bool(verifyMigration) {
+						verifyAddr := c.String(verifyDstAddr)
+						if verifyAddr == "" {
+							verifyAddr = c.String(vmAddr)
+						}
+						authCfg, err := auth.Generate(auth.WithBasicAuth(c.String(vmUser), c.String(vmPassword)))
+						if err != nil {
+							return fmt.Errorf("error initilize auth config for verification: %s", err)
+						}
+						rmp.verifier = &native.Client{
+							AuthCfg:    authCfg,
+							Addr:       strings.Trim(verifyAddr, "/"),
+							HTTPClient: http.DefaultClient,
+						}
+						rmp.tenant = c.String(vmAccountID)
+					}
 					return rmp.run(ctx, isNonInteractive(c), c.Bool(globalVerbose))
 				},
 			},
@@ -191,10 +218,90 @@ func main() {
 					return pp.run(isNonInteractive(c), c.Bool(globalVerbose))
 				},
 			},
+			{
+				Name:  "blocks",
+				Usage: "Migrate time series from Prometheus, Thanos, Cortex or Mimir TSDB blocks stored in object storage",
+				Flags: mergeFlags(globalFlags, blocksFlags, vmFlags),
+				Action: func(c *cli.Context) error {
+					fmt.Println("TSDB blocks import mode")
+
+					vmCfg := initConfigVM(c)
+					importer, err = vm.NewImporter(ctx, vmCfg)
+					if err != nil {
+						return fmt.Errorf("failed to create VM importer: %s", err)
+					}
+
+					bCfg := blocks.Config{
+						Src: c.String(blocksSrc),
+						Remote: blocks.RemoteConfig{
+							CredsFilePath:    c.String(blocksCredsFilePath),
+							ConfigFilePath:   c.String(blocksConfigFilePath),
+							ConfigProfile:    c.String(blocksConfigProfile),
+							CustomS3Endpoint: c.String(blocksCustomS3Endpoint),
+							S3ForcePathStyle: c.Bool(blocksS3ForcePathStyle),
+						},
+						TmpDir:      c.String(blocksTmpDir),
+						Tenants:     c.StringSlice(blocksTenant),
+						TenantLabel: c.String(blocksTenantLabel),
+						Resolutions: c.StringSlice(blocksResolution),
+						Aggregates:  c.StringSlice(blocksDownsampledAggr),
+						Filter: blocks.Filter{
+							TimeMin:    c.String(blocksFilterTimeStart),
+							TimeMax:    c.String(blocksFilterTimeEnd),
+							Label:      c.String(blocksFilterLabel),
+							LabelValue: c.String(blocksFilterLabelValue),
+						},
+					}
+					cl, err := blocks.NewClient(bCfg)
+					if err != nil {
+						return fmt.Errorf("failed to create blocks client: %s", err)
+					}
+					defer cl.MustStop()
+					bp := blocksProcessor{
+						cl: cl,
+						im: importer,
+						cc: c.Int(blocksConcurrency),
+					}
+					return bp.run(isNonInteractive(c), c.Bool(globalVerbose))
+				},
+			},
+			{
+				Name:  "whisper",
+				Usage: "Migrate time series from Graphite whisper files",
+				Flags: mergeFlags(globalFlags, whisperFlags, vmFlags),
+				Action: func(c *cli.Context) error {
+					fmt.Println("Graphite whisper import mode")
+
+					vmCfg := initConfigVM(c)
+					importer, err = vm.NewImporter(ctx, vmCfg)
+					if err != nil {
+						return fmt.Errorf("failed to create VM importer: %s", err)
+					}
+
+					wCfg := whisper.Config{
+						Path: c.String(whisperPath),
+						Filter: whisper.Filter{
+							TimeMin: c.String(whisperFilterTimeStart),
+							TimeMax: c.String(whisperFilterTimeEnd),
+							Name:    c.String(whisperFilterName),
+						},
+					}
+					cl, err := whisper.NewClient(wCfg)
+					if err != nil {
+						return fmt.Errorf("failed to create whisper client: %s", err)
+					}
+					wp := whisperProcessor{
+						cl: cl,
+						im: importer,
+						cc: c.Int(whisperConcurrency),
+					}
+					return wp.run(isNonInteractive(c), c.Bool(globalVerbose))
+				},
+			},
 			{
 				Name:  "vm-native",
 				Usage: "Migrate time series between VictoriaMetrics installations via native binary format",
-				Flags: mergeFlags(globalFlags, vmNativeFlags),
+				Flags: mergeFlags(globalFlags, vmNativeFlags, checkpointFlags),
 				Action: func(c *cli.Context) error {
 					fmt.Println("VictoriaMetrics Native import mode")
 
@@ -250,6 +357,24 @@ func main() {
 						backoff:        backoff.New(),
 						cc:             c.Int(vmConcurrency),
 						disableRetries: c.Bool(vmNativeDisableRetries),
+						mergePolicy:    c.String(vmNativeMergePolicy),
+					}
+					p.cp, err = openCheckpoint(c)
+					if err != nil {
+						return err
+					}
+					defer func() { _ = p.cp.Close() }()
+
+					if c.Bool(verifyMigration) {
+						verifyAddr := dstAddr
+						if c.String(verifyDstAddr) != "" {
+							verifyAddr = strings.Trim(c.String(verifyDstAddr), "/")
+						}
+						p.verifier = &native.Client{
+							AuthCfg:    dstAuthConfig,
+							Addr:       verifyAddr,
+							HTTPClient: dstHTTPClient,
+						}
 					}
 					return p.run(ctx, isNonInteractive(c))
 				},
@@ -325,6 +450,24 @@ func initConfigVM(c *cli.Context) vm.Config {
 	}
 }
 
+// openCheckpoint opens the checkpoint file if it is configured via the corresponding flag.
+//
+// It returns nil checkpoint if the flag isn't set.
+func openCheckpoint(c *cli.Context) (*checkpoint.Checkpoint, error) {
+	path := c.String(checkpointFile)
+	if path == "" {
+		return nil, nil
+	}
+	cp, err := checkpoint.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open --%s=%q: %s", checkpointFile, path, err)
+	}
+	if n := cp.Len(); n > 0 {
+		log.Printf("Loaded %d completed requests from --%s=%q", n, checkpointFile, path)
+	}
+	return cp, nil
+}
+
 func isNonInteractive(c *cli.Context) bool {
 	isTerminal := terminal.IsTerminal(int(os.Stdout.Fd()))
 	return c.Bool(globalSilent) || !isTerminal
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/auth"
 )
@@ -13,6 +15,8 @@ import (
 const (
 	nativeTenantsAddr     = "admin/tenants"
 	nativeMetricNamesAddr = "api/v1/label/__name__/values"
+	nativeQueryAddr       = "api/v1/query"
+	nativeForceFlushAddr  = "internal/force_flush"
 )
 
 // Client is an HTTP client for exporting and importing
@@ -150,6 +154,108 @@ func (c *Client) GetSourceTenants(ctx context.Context, f Filter) ([]string, erro
 	return r.Tenants, nil
 }
 
+// Stats contains the number of series and samples
+type Stats struct {
+	Series  uint64
+	Samples uint64
+}
+
+// GetStats returns the number of series and samples matching the given match selector
+// on the time range (start, end] according to the data stored at c.
+func (c *Client) GetStats(ctx context.Context, tenantID, match string, start, end time.Time) (*Stats, error) {
+	window := end.Sub(start).Milliseconds()
+	if window <= 0 {
+		return &Stats{}, nil
+	}
+	series, err := c.queryScalar(ctx, tenantID, fmt.Sprintf("count(last_over_time(%s[%dms]))", match, window), end)
+	if err != nil {
+		return nil, fmt.Errorf("cannot obtain series count: %w", err)
+	}
+	samples, err := c.queryScalar(ctx, tenantID, fmt.Sprintf("sum(count_over_time(%s[%dms]))", match, window), end)
+	if err != nil {
+		return nil, fmt.Errorf("cannot obtain samples count: %w", err)
+	}
+	return &Stats{
+		Series:  uint64(series),
+		Samples: uint64(samples),
+	}, nil
+}
+
+// ForceFlush makes the recently ingested data at c searchable immediately.
+//
+// It must be called before GetStats if the data was ingested during the last few seconds.
+func (c *Client) ForceFlush(ctx context.Context) error {
+	url := fmt.Sprintf("%s/%s", c.Addr, nativeForceFlushAddr)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("cannot create request to %q: %s", url, err)
+	}
+	resp, err := c.do(req, http.StatusOK)
+	if err != nil {
+		return fmt.Errorf("cannot force flush data at %q: %s", url, err)
+	}
+	if err := resp.Body.Close(); err != nil {
+		return fmt.Errorf("cannot close force flush response body: %s", err)
+	}
+	return nil
+}
+
+// queryScalar executes instant query, which returns a single value, at the given time.
+//
+// Zero is returned if the query returns no results.
+func (c *Client) queryScalar(ctx context.Context, tenantID, query string, t time.Time) (float64, error) {
+	url := fmt.Sprintf("%s/%s", c.Addr, nativeQueryAddr)
+	if tenantID != "" {
+		url = fmt.Sprintf("%s/select/%s/prometheus/%s", c.Addr, tenantID, nativeQueryAddr)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("cannot create request to %q: %s", url, err)
+	}
+
+	params := req.URL.Query()
+	params.Set("query", query)
+	params.Set("time", strconv.FormatFloat(float64(t.UnixMilli())/1e3, 'f', 3, 64))
+	// disable cache, since the data may be updated by the migration
+	params.Set("nocache", "1")
+	req.URL.RawQuery = params.Encode()
+
+	resp, err := c.do(req, http.StatusOK)
+	if err != nil {
+		return 0, fmt.Errorf("query %q failed: %s", query, err)
+	}
+
+	var r struct {
+		Data struct {
+			Result []struct {
+				Value [2]interface{} `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return 0, fmt.Errorf("cannot decode query response: %s", err)
+	}
+	if err := resp.Body.Close(); err != nil {
+		return 0, fmt.Errorf("cannot close query response body: %s", err)
+	}
+
+	if len(r.Data.Result) == 0 {
+		return 0, nil
+	}
+	if len(r.Data.Result) > 1 {
+		return 0, fmt.Errorf("unexpected number of results for query %q; got %d; want 1", query, len(r.Data.Result))
+	}
+	s, ok := r.Data.Result[0].Value[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected value in response for query %q: %v", query, r.Data.Result[0].Value[1])
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse value %q in response for query %q: %s", s, query, err)
+	}
+	return v, nil
+}
+
 func (c *Client) do(req *http.Request, expSC int) (*http.Response, error) {
 	if c.AuthCfg != nil {
 		c.AuthCfg.SetHeaders(req, true)
@@ -0,0 +1,90 @@
+package native
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientGetStats(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.FormValue("time") != "1672531200.000" {
+			t.Errorf("unexpected time query arg: %q", r.FormValue("time"))
+		}
+		if r.FormValue("nocache") != "1" {
+			t.Errorf("missing nocache query arg")
+		}
+		switch r.FormValue("query") {
+		case `count(last_over_time({__name__="foo"}[3600000ms]))`:
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1672531200,"3"]}]}}`))
+		case `sum(count_over_time({__name__="foo"}[3600000ms]))`:
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1672531200,"360"]}]}}`))
+		default:
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{
+		Addr:       srv.URL,
+		HTTPClient: http.DefaultClient,
+	}
+	end := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	start := end.Add(-time.Hour)
+
+	f := func(tenantID, match string, statsExpected Stats, pathExpected string) {
+		t.Helper()
+		paths = paths[:0]
+		stats, err := c.GetStats(context.Background(), tenantID, match, start, end)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if *stats != statsExpected {
+			t.Fatalf("unexpected stats; got %+v; want %+v", *stats, statsExpected)
+		}
+		for _, path := range paths {
+			if path != pathExpected {
+				t.Fatalf("unexpected request path; got %q; want %q", path, pathExpected)
+			}
+		}
+	}
+
+	f("", `{__name__="foo"}`, Stats{Series: 3, Samples: 360}, "/api/v1/query")
+	f("1:0", `{__name__="foo"}`, Stats{Series: 3, Samples: 360}, "/select/1:0/prometheus/api/v1/query")
+
+	// No data
+	f("", `{__name__="bar"}`, Stats{}, "/api/v1/query")
+}
+
+func TestClientForceFlush(t *testing.T) {
+	flushCalls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/internal/force_flush" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		flushCalls++
+	}))
+	defer srv.Close()
+
+	c := &Client{
+		Addr:       srv.URL,
+		HTTPClient: http.DefaultClient,
+	}
+	if err := c.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if flushCalls != 1 {
+		t.Fatalf("unexpected number of force flush calls; got %d; want 1", flushCalls)
+	}
+
+	// The address without force flush support
+	c.Addr = srv.URL + "/select/0/prometheus"
+	if err := c.ForceFlush(context.Background()); err == nil {
+		t.Fatalf("expecting non-nil error")
+	}
+}
@@ -317,3 +317,17 @@ func TestSteamRemoteRead(t *testing.T) {
 		})
 	}
 }
+
+func TestRemoteReadMatch(t *testing.T) {
+	f := func(label, value, matchExpected string) {
+		t.Helper()
+		match := remoteReadMatch(label, value)
+		if match != matchExpected {
+			t.Fatalf("unexpected match for %s=~%q; got %s; want %s", label, value, match, matchExpected)
+		}
+	}
+	f("__name__", "foo.+", `{__name__=~"foo.+"}`)
+	f("job", "node|vmagent", `{job=~"node|vmagent"}`)
+	f("__name__", ".*", `{__name__=~".*",__name__!=""}`)
+	f("job", "node|", `{job=~"node|",__name__!=""}`)
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"regexp"
 	"sync"
 	"time"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/barpool"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/checkpoint"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/native"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/remoteread"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/stepper"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/vm"
@@ -20,6 +23,14 @@ type remoteReadProcessor struct {
 	dst *vm.Importer
 	src *remoteread.Client
 
+	// cp tracks completed time ranges if set
+	cp *checkpoint.Checkpoint
+	// verifier queries the destination for verifying
+	// the migrated time ranges if set
+	verifier *native.Client
+	// tenant is the destination tenant for the verifier
+	tenant string
+
 	cc int
 }
 
@@ -27,6 +38,9 @@ type remoteReadFilter struct {
 	timeStart *time.Time
 	timeEnd   *time.Time
 	chunk     string
+	// match is a series selector equivalent to the remote read label filter.
+	// It is used for tracking checkpoints and for verification.
+	match string
 }
 
 func (rrp *remoteReadProcessor) run(ctx context.Context, silent, verbose bool) error {
@@ -44,15 +58,24 @@ func (rrp *remoteReadProcessor) run(ctx context.Context, silent, verbose bool) e
 		return fmt.Errorf("failed to create date ranges for the given time filters: %v", err)
 	}
 
-	question := fmt.Sprintf("Selected time range %q - %q will be split into %d ranges according to %q step. Continue?",
+	question := fmt.Sprintf("Selected time range %q - %q will be split into %d ranges according to %q step.",
 		rrp.filter.timeStart.String(), rrp.filter.timeEnd.String(), len(ranges), rrp.filter.chunk)
-	if !silent && !prompt(question) {
+	var pending [][]time.Time
+	for _, r := range ranges {
+		if !rrp.cp.IsDone(rrp.unit(r)) {
+			pending = append(pending, r)
+		}
+	}
+	if skipped := len(ranges) - len(pending); skipped > 0 {
+		question += fmt.Sprintf(" %d ranges were already migrated according to the checkpoint file and will be skipped.", skipped)
+	}
+	if !silent && !prompt(question+" Continue?") {
 		return nil
 	}
 
 	var bar *pb.ProgressBar
 	if !silent {
-		bar = barpool.AddWithTemplate(fmt.Sprintf(barTpl, "Processing ranges"), len(ranges))
+		bar = barpool.AddWithTemplate(fmt.Sprintf(barTpl, "Processing ranges"), len(pending))
 		if err := barpool.Start(); err != nil {
 			return err
 		}
@@ -65,7 +88,7 @@ func (rrp *remoteReadProcessor) run(ctx context.Context, silent, verbose bool) e
 		log.Print(rrp.dst.Stats())
 	}()
 
-	rangeC := make(chan *remoteread.Filter)
+	rangeC := make(chan []time.Time)
 	errCh := make(chan error)
 
 	var wg sync.WaitGroup
@@ -85,16 +108,13 @@ func (rrp *remoteReadProcessor) run(ctx context.Context, silent, verbose bool) e
 		}()
 	}
 
-	for _, r := range ranges {
+	for _, r := range pending {
 		select {
 		case infErr := <-errCh:
 			return fmt.Errorf("remote read error: %s", infErr)
 		case vmErr := <-rrp.dst.Errors():
 			return fmt.Errorf("import process failed: %s", wrapErr(vmErr, verbose))
-		case rangeC <- &remoteread.Filter{
-			StartTimestampMs: r[0].UnixMilli(),
-			EndTimestampMs:   r[1].UnixMilli(),
-		}:
+		case rangeC <- r:
 		}
 	}
 
@@ -112,16 +132,126 @@ func (rrp *remoteReadProcessor) run(ctx context.Context, silent, verbose bool) e
 		return fmt.Errorf("import process failed: %s", err)
 	}
 
+	if rrp.verifier != nil {
+		return rrp.verify(ctx, ranges)
+	}
 	return nil
 }
 
-func (rrp *remoteReadProcessor) do(ctx context.Context, filter *remoteread.Filter) error {
-	return rrp.src.Read(ctx, filter, func(series *vm.TimeSeries) error {
-		if err := rrp.dst.Input(series); err != nil {
-			return fmt.Errorf(
-				"failed to read data for time range start: %d, end: %d, %s",
+func (rrp *remoteReadProcessor) do(ctx context.Context, r []time.Time) error {
+	filter := &remoteread.Filter{
+		StartTimestampMs: r[0].UnixMilli(),
+		EndTimestampMs:   r[1].UnixMilli(),
+	}
+	if rrp.cp == nil {
+		return rrp.src.Read(ctx, filter, func(series *vm.TimeSeries) error {
+			if err := rrp.dst.Input(series); err != nil {
+				return fmt.Errorf(
+					"failed to read data for time range start: %d, end: %d, %s",
+					filter.StartTimestampMs, filter.EndTimestampMs, err)
+			}
+			return nil
+		})
+	}
+
+	// The time range can be registered in the checkpoint file only after all its data
+	// is delivered to the destination, so import it synchronously in batches
+	// and mark the time range as done after the last batch is imported.
+	var batch []*vm.TimeSeries
+	var samples int
+	importBatch := func() error {
+		if err := rrp.dst.ImportWithRetries(ctx, batch); err != nil {
+			return fmt.Errorf("failed to import data for time range start: %d, end: %d, %s",
 				filter.StartTimestampMs, filter.EndTimestampMs, err)
 		}
+		batch = batch[:0]
+		samples = 0
 		return nil
+	}
+	err := rrp.src.Read(ctx, filter, func(ts *vm.TimeSeries) error {
+		batch = append(batch, ts)
+		samples += len(ts.Values)
+		if samples < rrp.dst.BatchSize() {
+			return nil
+		}
+		return importBatch()
 	})
+	if err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		if err := importBatch(); err != nil {
+			return err
+		}
+	}
+	return rrp.cp.MarkDone(rrp.unit(r))
+}
+
+// unit returns checkpoint unit for the given time range.
+func (rrp *remoteReadProcessor) unit(r []time.Time) checkpoint.Unit {
+	return checkpoint.Unit{
+		Match: rrp.filter.match,
+		Start: r[0].Format(time.RFC3339),
+		End:   r[1].Format(time.RFC3339),
+	}
+}
+
+// verify compares the number of series and samples for every time range
+// between the remote read source and the destination.
+func (rrp *remoteReadProcessor) verify(ctx context.Context, ranges [][]time.Time) error {
+	if err := rrp.verifier.ForceFlush(ctx); err != nil {
+		// The verifier may point to vmselect, which doesn't support force flush.
+		// Recently imported data becomes searchable in a few seconds after the import then.
+		log.Printf("cannot make the migrated data searchable before verification: %s; "+
+			"the verification may fail for the data imported during the last few seconds", err)
+	}
+	log.Printf("Verifying %d ranges...", len(ranges))
+	var mismatches int
+	for _, r := range ranges {
+		filter := &remoteread.Filter{
+			StartTimestampMs: r[0].UnixMilli(),
+			EndTimestampMs:   r[1].UnixMilli(),
+		}
+		var src native.Stats
+		err := rrp.src.Read(ctx, filter, func(ts *vm.TimeSeries) error {
+			if len(ts.Values) > 0 {
+				src.Series++
+				src.Samples += uint64(len(ts.Values))
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("cannot read source data for verification: %s", err)
+		}
+		// remote read returns samples on the time range [start, end),
+		// while the destination is queried on the time range (start, end],
+		// so shift the destination time range by a millisecond.
+		start := r[0].Add(-time.Millisecond)
+		end := r[1].Add(-time.Millisecond)
+		dst, err := rrp.verifier.GetStats(ctx, rrp.tenant, rrp.filter.match, start, end)
+		if err != nil {
+			return fmt.Errorf("cannot obtain destination stats for verification: %s", err)
+		}
+		if *dst == src {
+			continue
+		}
+		mismatches++
+		u := rrp.unit(r)
+		log.Printf("verification failed for %s: source has %d series and %d samples, while destination has %d series and %d samples",
+			u, src.Series, src.Samples, dst.Series, dst.Samples)
+		if err := rrp.cp.MarkInvalid(u); err != nil {
+			return err
+		}
+	}
+	return verificationResult(mismatches, len(ranges), rrp.cp != nil)
+}
+
+// remoteReadMatch returns series selector equivalent to remote read label filter label=~value.
+func remoteReadMatch(label, value string) string {
+	match := fmt.Sprintf("%s=~%q", label, value)
+	if re, err := regexp.Compile("^(?:" + value + ")$"); err == nil && re.MatchString("") {
+		// VictoriaMetrics requires at least a single filter, which doesn't match empty label value
+		match += `,__name__!=""`
+	}
+	return "{" + match + "}"
 }
@@ -2,7 +2,9 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"strings"
 
@@ -51,3 +53,17 @@ func wrapErr(vmErr *vm.ImportError, verbose bool) error {
 	return fmt.Errorf("%s\n\tImporting batch failed for timestamps range %d - %d %s\n%s",
 		vmErr.Err, minTS, maxTS, verboseMsg, errTS)
 }
+
+// verificationResult returns an error if some of the verified requests have mismatched
+// number of series or samples between the source and the destination.
+func verificationResult(mismatches, total int, hasCheckpoint bool) error {
+	if mismatches == 0 {
+		log.Printf("Verification passed for %d requests", total)
+		return nil
+	}
+	msg := fmt.Sprintf("verification failed for %d out of %d requests; see the log above for details", mismatches, total)
+	if hasCheckpoint {
+		msg += fmt.Sprintf("; failed requests are removed from --%s, so they are migrated again on the next run with the same flags", checkpointFile)
+	}
+	return errors.New(msg)
+}
@@ -78,6 +78,10 @@ type Importer struct {
 
 	s       *stats
 	backoff *backoff.Backoff
+
+	batchSize          int
+	significantFigures int
+	roundDigits        int
 }
 
 // ResetStats resets im stats.
@@ -147,6 +151,9 @@ func NewImporter(ctx context.Context, cfg Config) (*Importer, error) {
 	if cfg.BatchSize < 1 {
 		cfg.BatchSize = 1e5
 	}
+	im.batchSize = cfg.BatchSize
+	im.significantFigures = cfg.SignificantFigures
+	im.roundDigits = cfg.RoundDigits
 
 	im.wg.Add(int(cfg.Concurrency))
 	for i := 0; i < int(cfg.Concurrency); i++ {
@@ -269,6 +276,31 @@ func (im *Importer) startWorker(ctx context.Context, bar *pb.ProgressBar, batchS
 	}
 }
 
+// ImportWithRetries synchronously imports the given series bypassing the input buffers.
+// The series are split into batches according to the configured batch size.
+// Every batch is retried on errors.
+//
+// It is used when the caller must be sure the series are delivered before going further,
+// e.g. before registering the migration progress in the checkpoint file.
+func (im *Importer) ImportWithRetries(ctx context.Context, series []*TimeSeries) error {
+	var batch []*TimeSeries
+	var dataPoints int
+	for _, ts := range series {
+		ts = roundTimeseriesValue(ts, im.significantFigures, im.roundDigits)
+		batch = append(batch, ts)
+		dataPoints += len(ts.Values)
+		if dataPoints < im.batchSize {
+			continue
+		}
+		if err := im.flush(ctx, batch); err != nil {
+			return err
+		}
+		dataPoints = 0
+		batch = batch[:0]
+	}
+	return im.flush(ctx, batch)
+}
+
 func (im *Importer) flush(ctx context.Context, b []*TimeSeries) error {
 	retryableFunc := func() error { return im.Import(b) }
 	attempts, err := im.backoff.Retry(ctx, retryableFunc)
@@ -281,6 +313,11 @@ func (im *Importer) flush(ctx context.Context, b []*TimeSeries) error {
 	return nil
 }
 
+// BatchSize returns the maximum number of samples in a single import request.
+func (im *Importer) BatchSize() int {
+	return im.batchSize
+}
+
 // Ping sends a ping to im.addr.
 func (im *Importer) Ping() error {
 	url := fmt.Sprintf("%s/health", im.addr)
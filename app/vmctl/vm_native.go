@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/backoff"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/checkpoint"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/limiter"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/native"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/stepper"
@@ -27,11 +30,18 @@ type vmNativeProcessor struct {
 	src     *native.Client
 	backoff *backoff.Backoff
 
+	// cp tracks completed requests if set
+	cp *checkpoint.Checkpoint
+	// verifier queries the destination for verifying
+	// the migrated data if set
+	verifier *native.Client
+
 	s              *stats
 	rateLimit      int64
 	interCluster   bool
 	cc             int
 	disableRetries bool
+	mergePolicy    string
 }
 
 const (
@@ -164,6 +174,13 @@ func (p *vmNativeProcessor) runBackfilling(ctx context.Context, tenantID string,
 	if err != nil {
 		return fmt.Errorf("failed to add labels to import path: %s", err)
 	}
+	if p.mergePolicy != "" {
+		separator := "?"
+		if strings.Contains(importAddr, "?") {
+			separator = "&"
+		}
+		importAddr += fmt.Sprintf("%smerge_policy=%s", separator, url.QueryEscape(p.mergePolicy))
+	}
 	dstURL := fmt.Sprintf("%s/%s", p.dst.Addr, importAddr)
 
 	if p.interCluster {
@@ -210,15 +227,40 @@ func (p *vmNativeProcessor) runBackfilling(ctx context.Context, tenantID string,
 		log.Print(foundSeriesMsg)
 	}
 
-	processingMsg := fmt.Sprintf("Requests to make: %d", len(metrics)*len(ranges))
+	var filters, pending []native.Filter
+	for _, s := range metrics {
+
+		match, err := buildMatchWithFilter(p.filter.Match, s)
+		if err != nil {
+			logger.Errorf("failed to build export filters: %s", err)
+			continue
+		}
+
+		for _, times := range ranges {
+			f := native.Filter{
+				Match:     match,
+				TimeStart: times[0].Format(time.RFC3339),
+				TimeEnd:   times[1].Format(time.RFC3339),
+			}
+			filters = append(filters, f)
+			if !p.cp.IsDone(nativeUnit(tenantID, f)) {
+				pending = append(pending, f)
+			}
+		}
+	}
+
+	processingMsg := fmt.Sprintf("Requests to make: %d", len(pending))
 	if len(ranges) > 1 {
 		processingMsg = fmt.Sprintf("Selected time range will be split into %d ranges according to %q step. %s", len(ranges), p.filter.Chunk, processingMsg)
 	}
+	if skipped := len(filters) - len(pending); skipped > 0 {
+		processingMsg += fmt.Sprintf(". Skipped requests completed according to the checkpoint file: %d", skipped)
+	}
 	log.Print(processingMsg)
 
 	var bar *pb.ProgressBar
 	if !silent {
-		bar = pb.ProgressBarTemplate(fmt.Sprintf(nativeWithBackoffTpl, barPrefix)).New(len(metrics) * len(ranges))
+		bar = pb.ProgressBarTemplate(fmt.Sprintf(nativeWithBackoffTpl, barPrefix)).New(len(pending))
 		if p.disableRetries {
 			bar = pb.ProgressBarTemplate(nativeSingleProcessTpl).New(0)
 		}
@@ -249,31 +291,88 @@ func (p *vmNativeProcessor) runBackfilling(ctx context.Context, tenantID string,
 						return
 					}
 				}
+				if err := p.cp.MarkDone(nativeUnit(tenantID, f)); err != nil {
+					errCh <- err
+					return
+				}
 			}
 		}()
 	}
 
 	// any error breaks the import
-	for _, s := range metrics {
-
-		match, err := buildMatchWithFilter(p.filter.Match, s)
-		if err != nil {
-			logger.Errorf("failed to build export filters: %s", err)
-			continue
+	for _, f := range pending {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("context canceled")
+		case infErr := <-errCh:
+			return fmt.Errorf("native error: %s", infErr)
+		case filterCh <- f:
 		}
+	}
 
-		for _, times := range ranges {
-			select {
-			case <-ctx.Done():
-				return fmt.Errorf("context canceled")
-			case infErr := <-errCh:
-				return fmt.Errorf("native error: %s", infErr)
-			case filterCh <- native.Filter{
-				Match:     match,
-				TimeStart: times[0].Format(time.RFC3339),
-				TimeEnd:   times[1].Format(time.RFC3339),
-			}:
+	close(filterCh)
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		return fmt.Errorf("import process failed: %s", err)
+	}
+
+	if p.verifier != nil {
+		return p.verify(ctx, tenantID, filters)
+	}
+	return nil
+}
+
+// nativeUnit returns checkpoint unit for the given export filter.
+func nativeUnit(tenantID string, f native.Filter) checkpoint.Unit {
+	return checkpoint.Unit{
+		Tenant: tenantID,
+		Match:  f.Match,
+		Start:  f.TimeStart,
+		End:    f.TimeEnd,
+	}
+}
+
+// verify compares the number of series and samples for every export filter
+// between the source and the destination.
+func (p *vmNativeProcessor) verify(ctx context.Context, tenantID string, filters []native.Filter) error {
+	if err := p.verifier.ForceFlush(ctx); err != nil {
+		// The verifier may point to vmselect, which doesn't support force flush.
+		// Recently imported data becomes searchable in a few seconds after the import then.
+		log.Printf("cannot make the migrated data searchable before verification: %s; "+
+			"the verification may fail for the data imported during the last few seconds", err)
+	}
+	log.Printf("Verifying %d requests...", len(filters))
+	filterCh := make(chan native.Filter)
+	errCh := make(chan error, p.cc)
+	var mismatches uint64
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.cc; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range filterCh {
+				ok, err := p.verifyFilter(ctx, tenantID, f)
+				if err != nil {
+					errCh <- err
+					return
+				}
+				if !ok {
+					atomic.AddUint64(&mismatches, 1)
+				}
 			}
+		}()
+	}
+
+	for _, f := range filters {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("context canceled")
+		case err := <-errCh:
+			return fmt.Errorf("verification failed: %s", err)
+		case filterCh <- f:
 		}
 	}
 
@@ -282,10 +381,35 @@ func (p *vmNativeProcessor) runBackfilling(ctx context.Context, tenantID string,
 	close(errCh)
 
 	for err := range errCh {
-		return fmt.Errorf("import process failed: %s", err)
+		return fmt.Errorf("verification failed: %s", err)
 	}
+	return verificationResult(int(mismatches), len(filters), p.cp != nil)
+}
 
-	return nil
+func (p *vmNativeProcessor) verifyFilter(ctx context.Context, tenantID string, f native.Filter) (bool, error) {
+	start, err := time.Parse(time.RFC3339, f.TimeStart)
+	if err != nil {
+		return false, err
+	}
+	end, err := time.Parse(time.RFC3339, f.TimeEnd)
+	if err != nil {
+		return false, err
+	}
+	src, err := p.src.GetStats(ctx, tenantID, f.Match, start, end)
+	if err != nil {
+		return false, fmt.Errorf("cannot obtain source stats: %s", err)
+	}
+	dst, err := p.verifier.GetStats(ctx, tenantID, f.Match, start, end)
+	if err != nil {
+		return false, fmt.Errorf("cannot obtain destination stats: %s", err)
+	}
+	if *src == *dst {
+		return true, nil
+	}
+	u := nativeUnit(tenantID, f)
+	log.Printf("verification failed for %s: source has %d series and %d samples, while destination has %d series and %d samples",
+		u, src.Series, src.Samples, dst.Series, dst.Samples)
+	return false, p.cp.MarkInvalid(u)
 }
 
 // stats represents client statistic
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/barpool"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/vm"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmctl/whisper"
+)
+
+type whisperProcessor struct {
+	// whisper client walks the whisper directory
+	// and reads whisper files
+	cl *whisper.Client
+	// importer performs import requests
+	// for timeseries data read from whisper files
+	im *vm.Importer
+	// cc stands for concurrency
+	// and defines number of concurrently
+	// running whisper file readers
+	cc int
+}
+
+func (wp *whisperProcessor) run(silent, verbose bool) error {
+	files, err := wp.cl.Explore()
+	if err != nil {
+		return fmt.Errorf("explore failed: %s", err)
+	}
+	if len(files) < 1 {
+		return fmt.Errorf("found no whisper files to import")
+	}
+	question := fmt.Sprintf("Found %d whisper files to import. Continue?", len(files))
+	if !silent && !prompt(question) {
+		return nil
+	}
+
+	bar := barpool.AddWithTemplate(fmt.Sprintf(barTpl, "Processing files"), len(files))
+
+	if err := barpool.Start(); err != nil {
+		return err
+	}
+	defer barpool.Stop()
+
+	filesCh := make(chan string)
+	errCh := make(chan error, wp.cc)
+	wp.im.ResetStats()
+
+	var wg sync.WaitGroup
+	wg.Add(wp.cc)
+	for i := 0; i < wp.cc; i++ {
+		go func() {
+			defer wg.Done()
+			for path := range filesCh {
+				if err := wp.do(path); err != nil {
+					errCh <- fmt.Errorf("read failed for file %q: %s", path, err)
+					return
+				}
+				bar.Increment()
+			}
+		}()
+	}
+	// any error breaks the import
+	for _, path := range files {
+		select {
+		case whisperErr := <-errCh:
+			close(filesCh)
+			return fmt.Errorf("whisper error: %s", whisperErr)
+		case vmErr := <-wp.im.Errors():
+			close(filesCh)
+			return fmt.Errorf("import process failed: %s", wrapErr(vmErr, verbose))
+		case filesCh <- path:
+		}
+	}
+
+	close(filesCh)
+	wg.Wait()
+	// wait for all buffers to flush
+	wp.im.Close()
+	close(errCh)
+	// drain import errors channel
+	for vmErr := range wp.im.Errors() {
+		if vmErr.Err != nil {
+			return fmt.Errorf("import process failed: %s", wrapErr(vmErr, verbose))
+		}
+	}
+	for err := range errCh {
+		return fmt.Errorf("import process failed: %s", err)
+	}
+
+	log.Println("Import finished!")
+	log.Print(wp.im.Stats())
+	return nil
+}
+
+func (wp *whisperProcessor) do(path string) error {
+	s, err := wp.cl.Read(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %s", err)
+	}
+	if len(s.Timestamps) == 0 {
+		// Nothing to import
+		return nil
+	}
+	labels := make([]vm.LabelPair, 0, len(s.Tags))
+	for _, tag := range s.Tags {
+		labels = append(labels, vm.LabelPair{
+			Name:  tag.Key,
+			Value: tag.Value,
+		})
+	}
+	ts := vm.TimeSeries{
+		Name:       s.Name,
+		LabelPairs: labels,
+		Timestamps: s.Timestamps,
+		Values:     s.Values,
+	}
+	return wp.im.Input(&ts)
+}
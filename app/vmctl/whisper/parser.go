@@ -0,0 +1,153 @@
+package whisper
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// See https://graphite.readthedocs.io/en/latest/whisper.html#database-format
+const (
+	metadataSize    = 16
+	archiveInfoSize = 12
+	pointSize       = 12
+)
+
+// Point is a single whisper data point.
+type Point struct {
+	// Timestamp in seconds
+	Timestamp int64
+	Value     float64
+}
+
+// Archive is a single whisper archive.
+type Archive struct {
+	SecondsPerPoint uint32
+	Points          uint32
+
+	// points contains valid points for the archive sorted by timestamp.
+	points []Point
+}
+
+// Retention returns the archive retention in seconds.
+func (a *Archive) Retention() int64 {
+	return int64(a.SecondsPerPoint) * int64(a.Points)
+}
+
+// File is a parsed whisper file.
+type File struct {
+	AggregationMethod uint32
+	MaxRetention      uint32
+	XFilesFactor      float32
+
+	// Archives are sorted by resolution, starting from the highest one.
+	Archives []Archive
+}
+
+// Parse parses whisper file contents from data.
+func Parse(data []byte) (*File, error) {
+	if len(data) < metadataSize {
+		return nil, fmt.Errorf("too short whisper file; got %d bytes; want at least %d bytes", len(data), metadataSize)
+	}
+	f := &File{
+		AggregationMethod: binary.BigEndian.Uint32(data),
+		MaxRetention:      binary.BigEndian.Uint32(data[4:]),
+		XFilesFactor:      math.Float32frombits(binary.BigEndian.Uint32(data[8:])),
+	}
+	archivesCount := int(binary.BigEndian.Uint32(data[12:]))
+	if archivesCount == 0 {
+		return nil, fmt.Errorf("whisper file must contain at least a single archive")
+	}
+	headerSize := metadataSize + archivesCount*archiveInfoSize
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("too short whisper file for %d archives; got %d bytes; want at least %d bytes", archivesCount, len(data), headerSize)
+	}
+	for i := 0; i < archivesCount; i++ {
+		info := data[metadataSize+i*archiveInfoSize:]
+		offset := int(binary.BigEndian.Uint32(info))
+		a := Archive{
+			SecondsPerPoint: binary.BigEndian.Uint32(info[4:]),
+			Points:          binary.BigEndian.Uint32(info[8:]),
+		}
+		if a.SecondsPerPoint == 0 {
+			return nil, fmt.Errorf("archive #%d has zero secondsPerPoint", i)
+		}
+		end := offset + int(a.Points)*pointSize
+		if offset < headerSize || end > len(data) {
+			return nil, fmt.Errorf("archive #%d at offset %d with %d points exceeds whisper file size of %d bytes", i, offset, a.Points, len(data))
+		}
+		if i > 0 && a.SecondsPerPoint <= f.Archives[i-1].SecondsPerPoint {
+			return nil, fmt.Errorf("archive #%d must have lower resolution than the previous archive; got secondsPerPoint=%d; previous secondsPerPoint=%d",
+				i, a.SecondsPerPoint, f.Archives[i-1].SecondsPerPoint)
+		}
+		a.points = parseArchivePoints(data[offset:end], a.SecondsPerPoint, a.Retention())
+		f.Archives = append(f.Archives, a)
+	}
+	return f, nil
+}
+
+// parseArchivePoints returns valid points from the archive ring buffer in data.
+//
+// Whisper doesn't clear outdated slots in the ring buffer, so only points
+// within the archive retention relative to the newest point are returned.
+func parseArchivePoints(data []byte, secondsPerPoint uint32, retention int64) []Point {
+	var points []Point
+	maxTimestamp := int64(0)
+	for len(data) >= pointSize {
+		ts := int64(binary.BigEndian.Uint32(data))
+		v := math.Float64frombits(binary.BigEndian.Uint64(data[4:]))
+		data = data[pointSize:]
+		if ts == 0 || ts%int64(secondsPerPoint) != 0 {
+			// Empty or corrupted slot
+			continue
+		}
+		if ts > maxTimestamp {
+			maxTimestamp = ts
+		}
+		points = append(points, Point{
+			Timestamp: ts,
+			Value:     v,
+		})
+	}
+	minTimestamp := maxTimestamp - retention
+	dst := points[:0]
+	for _, p := range points {
+		if p.Timestamp > minTimestamp {
+			dst = append(dst, p)
+		}
+	}
+	points = dst
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp < points[j].Timestamp
+	})
+	return points
+}
+
+// Points returns all the points stored in f sorted by timestamp.
+//
+// Every time range is covered by points from the archive with the highest resolution,
+// which contains data for this time range.
+func (f *File) Points() []Point {
+	var parts [][]Point
+	pointsCount := 0
+	cutoff := int64(math.MaxInt64)
+	for _, a := range f.Archives {
+		points := a.points
+		n := sort.Search(len(points), func(i int) bool {
+			return points[i].Timestamp >= cutoff
+		})
+		points = points[:n]
+		if len(points) == 0 {
+			continue
+		}
+		parts = append(parts, points)
+		pointsCount += len(points)
+		cutoff = points[0].Timestamp
+	}
+	result := make([]Point, 0, pointsCount)
+	for i := len(parts) - 1; i >= 0; i-- {
+		result = append(result, parts[i]...)
+	}
+	return result
+}
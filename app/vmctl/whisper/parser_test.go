@@ -0,0 +1,193 @@
+package whisper
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+type testArchive struct {
+	secondsPerPoint uint32
+	points          uint32
+	// data contains points to write into the archive ring buffer.
+	data []Point
+}
+
+// newTestWhisperFile returns whisper file contents for the given archives.
+//
+// Points are written into the archive ring buffers in the same way as whisper does.
+func newTestWhisperFile(archives []testArchive) []byte {
+	var data []byte
+	data = binary.BigEndian.AppendUint32(data, 1)
+	maxRetention := uint32(0)
+	for _, a := range archives {
+		if r := a.secondsPerPoint * a.points; r > maxRetention {
+			maxRetention = r
+		}
+	}
+	data = binary.BigEndian.AppendUint32(data, maxRetention)
+	data = binary.BigEndian.AppendUint32(data, math.Float32bits(0.5))
+	data = binary.BigEndian.AppendUint32(data, uint32(len(archives)))
+	offset := uint32(metadataSize + len(archives)*archiveInfoSize)
+	for _, a := range archives {
+		data = binary.BigEndian.AppendUint32(data, offset)
+		data = binary.BigEndian.AppendUint32(data, a.secondsPerPoint)
+		data = binary.BigEndian.AppendUint32(data, a.points)
+		offset += a.points * pointSize
+	}
+	for _, a := range archives {
+		buf := make([]byte, a.points*pointSize)
+		if len(a.data) > 0 {
+			baseTimestamp := a.data[0].Timestamp
+			for _, p := range a.data {
+				slot := ((p.Timestamp - baseTimestamp) / int64(a.secondsPerPoint)) % int64(a.points)
+				b := buf[slot*pointSize:]
+				binary.BigEndian.PutUint32(b, uint32(p.Timestamp))
+				binary.BigEndian.PutUint64(b[4:], math.Float64bits(p.Value))
+			}
+		}
+		data = append(data, buf...)
+	}
+	return data
+}
+
+func TestParseSuccess(t *testing.T) {
+	f := func(archives []testArchive, pointsExpected []Point) {
+		t.Helper()
+		data := newTestWhisperFile(archives)
+		wf, err := Parse(data)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(wf.Archives) != len(archives) {
+			t.Fatalf("unexpected number of archives; got %d; want %d", len(wf.Archives), len(archives))
+		}
+		for i, a := range wf.Archives {
+			if a.SecondsPerPoint != archives[i].secondsPerPoint || a.Points != archives[i].points {
+				t.Fatalf("unexpected archive #%d; got secondsPerPoint=%d, points=%d; want secondsPerPoint=%d, points=%d",
+					i, a.SecondsPerPoint, a.Points, archives[i].secondsPerPoint, archives[i].points)
+			}
+		}
+		if wf.XFilesFactor != 0.5 {
+			t.Fatalf("unexpected xFilesFactor; got %v; want 0.5", wf.XFilesFactor)
+		}
+		points := wf.Points()
+		if len(points) == 0 && len(pointsExpected) == 0 {
+			return
+		}
+		if !reflect.DeepEqual(points, pointsExpected) {
+			t.Fatalf("unexpected points\ngot\n%v\nwant\n%v", points, pointsExpected)
+		}
+	}
+
+	// Empty archive
+	f([]testArchive{{
+		secondsPerPoint: 10,
+		points:          5,
+	}}, nil)
+
+	// Single archive without ring buffer wrapping
+	f([]testArchive{{
+		secondsPerPoint: 10,
+		points:          5,
+		data:            []Point{{100, 1}, {110, 2}, {130, 4}},
+	}}, []Point{{100, 1}, {110, 2}, {130, 4}})
+
+	// Single archive with ring buffer wrapping
+	f([]testArchive{{
+		secondsPerPoint: 10,
+		points:          3,
+		data:            []Point{{100, 1}, {110, 2}, {120, 3}, {130, 4}, {140, 5}},
+	}}, []Point{{120, 3}, {130, 4}, {140, 5}})
+
+	// Outdated points in the ring buffer must be skipped
+	f([]testArchive{{
+		secondsPerPoint: 10,
+		points:          3,
+		data:            []Point{{100, 1}, {110, 2}, {120, 3}, {160, 7}},
+	}}, []Point{{160, 7}})
+
+	// Multiple archives - the highest resolution archive is used for every time range
+	f([]testArchive{
+		{
+			secondsPerPoint: 10,
+			points:          3,
+			data:            []Point{{180, 1}, {190, 2}, {200, 3}},
+		},
+		{
+			secondsPerPoint: 60,
+			points:          5,
+			data:            []Point{{60, 10}, {120, 20}, {180, 30}},
+		},
+		{
+			secondsPerPoint: 120,
+			points:          5,
+			data:            []Point{{120, 100}},
+		},
+	}, []Point{{60, 10}, {120, 20}, {180, 1}, {190, 2}, {200, 3}})
+
+	// Multiple archives with empty high resolution archive
+	f([]testArchive{
+		{
+			secondsPerPoint: 10,
+			points:          3,
+		},
+		{
+			secondsPerPoint: 60,
+			points:          5,
+			data:            []Point{{60, 10}, {120, 20}},
+		},
+	}, []Point{{60, 10}, {120, 20}})
+}
+
+func TestParseFailure(t *testing.T) {
+	f := func(data []byte) {
+		t.Helper()
+		wf, err := Parse(data)
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+		if wf != nil {
+			t.Fatalf("expecting nil file")
+		}
+	}
+
+	valid := newTestWhisperFile([]testArchive{
+		{
+			secondsPerPoint: 10,
+			points:          3,
+		},
+		{
+			secondsPerPoint: 60,
+			points:          3,
+		},
+	})
+
+	// Too short metadata
+	f(nil)
+	f(valid[:metadataSize-1])
+
+	// Missing archive info
+	f(valid[:metadataSize+archiveInfoSize])
+
+	// Truncated archive data
+	f(valid[:len(valid)-1])
+
+	// Zero archives
+	data := append([]byte{}, valid...)
+	binary.BigEndian.PutUint32(data[12:], 0)
+	f(data)
+
+	// Invalid archives order
+	f(newTestWhisperFile([]testArchive{
+		{
+			secondsPerPoint: 60,
+			points:          3,
+		},
+		{
+			secondsPerPoint: 10,
+			points:          3,
+		},
+	}))
+}
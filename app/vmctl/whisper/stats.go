@@ -0,0 +1,21 @@
+package whisper
+
+import (
+	"fmt"
+)
+
+// Stats represents data migration stats.
+type Stats struct {
+	Files        int
+	SkippedFiles int
+	Bytes        int64
+}
+
+// String returns string representation for s.
+func (s Stats) String() string {
+	return fmt.Sprintf("Whisper files stats:\n"+
+		"  files found: %d;\n"+
+		"  files skipped by name filter: %d;\n"+
+		"  size of files to import: %d bytes.",
+		s.Files, s.SkippedFiles, s.Bytes)
+}
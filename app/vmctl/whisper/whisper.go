@@ -0,0 +1,194 @@
+package whisper
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/graphite"
+)
+
+// Config contains a list of params needed
+// for reading whisper files
+type Config struct {
+	// Path to the directory with whisper files
+	Path string
+
+	Filter Filter
+}
+
+// Filter contains configuration for filtering
+// the timeseries
+type Filter struct {
+	TimeMin string
+	TimeMax string
+	// Name is a regular expression for Graphite metric names to import
+	Name string
+}
+
+// Client reads whisper files from the given directory
+type Client struct {
+	path   string
+	filter filter
+}
+
+type filter struct {
+	min, max int64
+	name     *regexp.Regexp
+}
+
+func (f filter) inRange(ts int64) bool {
+	if f.min != 0 && ts < f.min {
+		return false
+	}
+	if f.max != 0 && ts > f.max {
+		return false
+	}
+	return true
+}
+
+// Series represents time series read from a single whisper file.
+type Series struct {
+	Name       string
+	Tags       []graphite.Tag
+	Timestamps []int64
+	Values     []float64
+}
+
+// NewClient creates and validates new Client
+// with given Config
+func NewClient(cfg Config) (*Client, error) {
+	fi, err := os.Stat(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open whisper directory: %s", err)
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%q must point to a directory with whisper files", cfg.Path)
+	}
+	min, max, err := parseTime(cfg.Filter.TimeMin, cfg.Filter.TimeMax)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse time in filter: %s", err)
+	}
+	c := &Client{
+		path: cfg.Path,
+		filter: filter{
+			min: min,
+			max: max,
+		},
+	}
+	if cfg.Filter.Name != "" {
+		re, err := regexp.Compile("^(?:" + cfg.Filter.Name + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse name filter %q: %s", cfg.Filter.Name, err)
+		}
+		c.filter.name = re
+	}
+	return c, nil
+}
+
+// Explore walks the whisper directory and returns paths
+// to whisper files matching the name filter.
+func (c *Client) Explore() ([]string, error) {
+	s := &Stats{}
+	var files []string
+	err := filepath.WalkDir(c.path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".wsp") {
+			return nil
+		}
+		s.Files++
+		relPath, err := filepath.Rel(c.path, path)
+		if err != nil {
+			return err
+		}
+		if c.filter.name != nil && !c.filter.name.MatchString(metricName(relPath)) {
+			s.SkippedFiles++
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		s.Bytes += fi.Size()
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %q: %s", c.path, err)
+	}
+	fmt.Println(s)
+	return files, nil
+}
+
+// Read reads the whisper file at the given path according to configured time filter.
+func (c *Client) Read(path string) (*Series, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	relPath, err := filepath.Rel(c.path, path)
+	if err != nil {
+		return nil, err
+	}
+	var r graphite.Row
+	if _, err := r.UnmarshalMetricAndTags(metricName(relPath), nil); err != nil {
+		return nil, fmt.Errorf("cannot parse metric name: %s", err)
+	}
+	s := &Series{
+		Name: r.Metric,
+		Tags: r.Tags,
+	}
+	for _, p := range f.Points() {
+		ts := p.Timestamp * 1e3
+		if !c.filter.inRange(ts) {
+			continue
+		}
+		s.Timestamps = append(s.Timestamps, ts)
+		s.Values = append(s.Values, p.Value)
+	}
+	return s, nil
+}
+
+// metricName returns Graphite metric name for the whisper file
+// at the given relPath inside the whisper directory.
+//
+// Tagged series are stored by carbon at `_tagged/<hash>/<hash>/<name;tag=value>.wsp`
+// with dots in the name replaced by `_DOT_`.
+// See https://graphite.readthedocs.io/en/latest/tags.html#carbon
+func metricName(relPath string) string {
+	path := strings.TrimSuffix(filepath.ToSlash(relPath), ".wsp")
+	if strings.HasPrefix(path, "_tagged/") {
+		n := strings.LastIndexByte(path, '/')
+		return strings.ReplaceAll(path[n+1:], "_DOT_", ".")
+	}
+	return strings.ReplaceAll(path, "/", ".")
+}
+
+func parseTime(start, end string) (int64, int64, error) {
+	var s, e int64
+	if start != "" {
+		v, err := time.Parse(time.RFC3339, start)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to parse %q: %s", start, err)
+		}
+		s = v.UnixMilli()
+	}
+	if end != "" {
+		v, err := time.Parse(time.RFC3339, end)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to parse %q: %s", end, err)
+		}
+		e = v.UnixMilli()
+	}
+	return s, e, nil
+}
@@ -0,0 +1,120 @@
+package whisper
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/graphite"
+)
+
+func TestMetricName(t *testing.T) {
+	f := func(relPath, nameExpected string) {
+		t.Helper()
+		name := metricName(relPath)
+		if name != nameExpected {
+			t.Fatalf("unexpected metric name for %q; got %q; want %q", relPath, name, nameExpected)
+		}
+	}
+	f("foo.wsp", "foo")
+	f("servers/host-1/cpu/user.wsp", "servers.host-1.cpu.user")
+	f("_tagged/2b0/9c1/cpu_DOT_user;host=host-1_DOT_example_DOT_com;dc=eu.wsp", "cpu.user;host=host-1.example.com;dc=eu")
+}
+
+func TestClientRead(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(relPath string, archives []testArchive) {
+		t.Helper()
+		path := filepath.Join(dir, relPath)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("cannot create dir: %s", err)
+		}
+		if err := os.WriteFile(path, newTestWhisperFile(archives), 0644); err != nil {
+			t.Fatalf("cannot write file: %s", err)
+		}
+	}
+	archives := []testArchive{{
+		secondsPerPoint: 60,
+		points:          10,
+		data:            []Point{{1577836800, 1}, {1577836860, 2}, {1577836920, 3}},
+	}}
+	writeFile("servers/host1/cpu.wsp", archives)
+	writeFile("servers/host2/cpu.wsp", archives)
+	writeFile("servers/host2/mem.wsp", archives)
+	writeFile("servers/README", nil)
+	writeFile("_tagged/abc/def/disk_DOT_used;host=host1;dc=eu.wsp", archives)
+
+	f := func(filter Filter, seriesExpected []Series) {
+		t.Helper()
+		c, err := NewClient(Config{
+			Path:   dir,
+			Filter: filter,
+		})
+		if err != nil {
+			t.Fatalf("cannot create client: %s", err)
+		}
+		files, err := c.Explore()
+		if err != nil {
+			t.Fatalf("explore failed: %s", err)
+		}
+		var result []Series
+		for _, path := range files {
+			s, err := c.Read(path)
+			if err != nil {
+				t.Fatalf("cannot read %q: %s", path, err)
+			}
+			result = append(result, *s)
+		}
+		if !reflect.DeepEqual(result, seriesExpected) {
+			t.Fatalf("unexpected series\ngot\n%+v\nwant\n%+v", result, seriesExpected)
+		}
+	}
+
+	timestamps := []int64{1577836800000, 1577836860000, 1577836920000}
+	values := []float64{1, 2, 3}
+
+	// Name filter
+	f(Filter{
+		Name: `servers\.host2\..+`,
+	}, []Series{
+		{
+			Name:       "servers.host2.cpu",
+			Timestamps: timestamps,
+			Values:     values,
+		},
+		{
+			Name:       "servers.host2.mem",
+			Timestamps: timestamps,
+			Values:     values,
+		},
+	})
+
+	// Tagged series
+	f(Filter{
+		Name: `disk\.used;.*`,
+	}, []Series{
+		{
+			Name: "disk.used",
+			Tags: []graphite.Tag{
+				{Key: "host", Value: "host1"},
+				{Key: "dc", Value: "eu"},
+			},
+			Timestamps: timestamps,
+			Values:     values,
+		},
+	})
+
+	// Time filter
+	f(Filter{
+		TimeMin: "2020-01-01T00:01:00Z",
+		TimeMax: "2020-01-01T00:01:30Z",
+		Name:    `servers\.host1\.cpu`,
+	}, []Series{
+		{
+			Name:       "servers.host1.cpu",
+			Timestamps: timestamps[1:2],
+			Values:     values[1:2],
+		},
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -34,6 +35,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/pushgateway"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
 	"github.com/VictoriaMetrics/metrics"
 )
@@ -60,7 +62,10 @@ var (
 		"at -opentsdbHTTPListenAddr . See https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt")
 	configAuthKey          = flag.String("configAuthKey", "", "Authorization key for accessing /config page. It must be passed via authKey query arg")
 	maxLabelsPerTimeseries = flag.Int("maxLabelsPerTimeseries", 30, "The maximum number of labels accepted per time series. Superfluous labels are dropped. In this case the vm_metrics_with_dropped_labels_total metric at /metrics page is incremented")
-	maxLabelValueLen       = flag.Int("maxLabelValueLen", 16*1024, "The maximum length of label values in the accepted time series. Longer label values are truncated. In this case the vm_too_long_label_values_total metric at /metrics page is incremented")
+	pushgatewayDataPath    = flag.String("pushgateway.dataPath", "victoria-metrics-pushgateway-data", "Path to directory for persisting the last pushed metrics "+
+		"received via Pushgateway-compatible API at /api/v1/pushgateway/metrics/job/... . "+
+		"See https://docs.victoriametrics.com/#how-to-use-pushgateway-compatible-api")
+	maxLabelValueLen = flag.Int("maxLabelValueLen", 16*1024, "The maximum length of label values in the accepted time series. Longer label values are truncated. In this case the vm_too_long_label_values_total metric at /metrics page is incremented")
 )
 
 var (
@@ -97,11 +102,13 @@ func Init() {
 	promscrape.Init(func(at *auth.Token, wr *prompbmarshal.WriteRequest) {
 		prompush.Push(wr)
 	})
+	pushgateway.Init(prompush.Push, *pushgatewayDataPath)
 }
 
 // Stop stops vminsert.
 func Stop() {
 	promscrape.Stop()
+	pushgateway.MustStop()
 	if len(*graphiteListenAddr) > 0 {
 		graphiteServer.MustStop()
 	}
@@ -133,6 +140,15 @@ func RequestHandler(w http.ResponseWriter, r *http.Request) bool {
 		staticServer.ServeHTTP(w, r)
 		return true
 	}
+	if strings.HasPrefix(path, "/prometheus/api/v1/pushgateway/") || strings.HasPrefix(path, "/api/v1/pushgateway/") {
+		pushgatewayRequests.Inc()
+		path = strings.TrimPrefix(path, "/prometheus")
+		if err := pushgateway.RequestHandler(w, r, strings.TrimPrefix(path, "/api/v1/pushgateway")); err != nil {
+			pushgatewayErrors.Inc()
+			httpserver.Errorf(w, r, "%s", err)
+		}
+		return true
+	}
 	if strings.HasPrefix(path, "/prometheus/api/v1/import/prometheus") || strings.HasPrefix(path, "/api/v1/import/prometheus") {
 		prometheusimportRequests.Inc()
 		if err := prometheusimport.InsertHandler(r); err != nil {
@@ -257,7 +273,8 @@ func RequestHandler(w http.ResponseWriter, r *http.Request) bool {
 		promscrapeAPIV1TargetsRequests.Inc()
 		w.Header().Set("Content-Type", "application/json")
 		state := r.FormValue("state")
-		promscrape.WriteAPIV1Targets(w, state)
+		showScrapeHistory, _ := strconv.ParseBool(r.FormValue("scrape_history"))
+		promscrape.WriteAPIV1Targets(w, state, showScrapeHistory)
 		return true
 	case "/prometheus/target_response", "/target_response":
 		promscrapeTargetResponseRequests.Inc()
@@ -267,6 +284,14 @@ func RequestHandler(w http.ResponseWriter, r *http.Request) bool {
 			return true
 		}
 		return true
+	case "/prometheus/target_debug", "/target_debug":
+		promscrapeTargetDebugRequests.Inc()
+		if err := promscrape.WriteTargetDebug(w, r); err != nil {
+			promscrapeTargetDebugErrors.Inc()
+			httpserver.Errorf(w, r, "%s", err)
+			return true
+		}
+		return true
 	case "/prometheus/config", "/config":
 		if !httpserver.CheckAuthFlag(w, r, *configAuthKey, "configAuthKey") {
 			return true
@@ -328,6 +353,9 @@ var (
 	prometheusimportRequests = metrics.NewCounter(`vm_http_requests_total{path="/api/v1/import/prometheus", protocol="prometheusimport"}`)
 	prometheusimportErrors   = metrics.NewCounter(`vm_http_request_errors_total{path="/api/v1/import/prometheus", protocol="prometheusimport"}`)
 
+	pushgatewayRequests = metrics.NewCounter(`vm_http_requests_total{path="/api/v1/pushgateway", protocol="pushgateway"}`)
+	pushgatewayErrors   = metrics.NewCounter(`vm_http_request_errors_total{path="/api/v1/pushgateway", protocol="pushgateway"}`)
+
 	nativeimportRequests = metrics.NewCounter(`vm_http_requests_total{path="/api/v1/import/native", protocol="nativeimport"}`)
 	nativeimportErrors   = metrics.NewCounter(`vm_http_request_errors_total{path="/api/v1/import/native", protocol="nativeimport"}`)
 
@@ -351,6 +379,8 @@ var (
 
 	promscrapeTargetResponseRequests = metrics.NewCounter(`vm_http_requests_total{path="/target_response"}`)
 	promscrapeTargetResponseErrors   = metrics.NewCounter(`vm_http_request_errors_total{path="/target_response"}`)
+	promscrapeTargetDebugRequests    = metrics.NewCounter(`vm_http_requests_total{path="/target_debug"}`)
+	promscrapeTargetDebugErrors      = metrics.NewCounter(`vm_http_request_errors_total{path="/target_debug"}`)
 
 	promscrapeConfigRequests       = metrics.NewCounter(`vm_http_requests_total{path="/config"}`)
 	promscrapeStatusConfigRequests = metrics.NewCounter(`vm_http_requests_total{path="/api/v1/status/config"}`)
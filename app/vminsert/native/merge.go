@@ -0,0 +1,172 @@
+package native
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// mergePolicy defines how the imported samples are merged with the existing samples for the same series.
+type mergePolicy int
+
+const (
+	// mergePolicyNone stores all the imported samples as is.
+	mergePolicyNone mergePolicy = iota
+
+	// mergePolicyKeepExisting skips the imported samples with timestamps, which already exist for the series.
+	mergePolicyKeepExisting
+)
+
+// getMergePolicy returns merge policy from `merge_policy` query arg at req.
+func getMergePolicy(req *http.Request) (mergePolicy, error) {
+	s := req.FormValue("merge_policy")
+	switch s {
+	case "":
+		return mergePolicyNone, nil
+	case "keep_existing":
+		return mergePolicyKeepExisting, nil
+	case "replace":
+		return mergePolicyNone, fmt.Errorf("merge_policy=replace isn't supported, since the storage cannot overwrite the existing samples; " +
+			"use merge_policy=keep_existing or delete the series before the import")
+	default:
+		return mergePolicyNone, fmt.Errorf("unsupported merge_policy=%q; supported values: keep_existing", s)
+	}
+}
+
+// flushPendingSamples makes the recently added samples visible for skipExistingSamples.
+//
+// The storage buffers the added samples for a few seconds before making them searchable,
+// so without the flush the samples from the import, which finished right before the current import,
+// could be missed by skipExistingSamples.
+func flushPendingSamples() {
+	vmstorage.WG.Add(1)
+	vmstorage.Storage.DebugFlush()
+	vmstorage.WG.Done()
+}
+
+// mergeSearchTimeout is the maximum duration in seconds for searching the existing samples for a single imported block.
+const mergeSearchTimeout = 60
+
+// skipExistingSamples returns the imported samples for the series with the given labels,
+// which have no existing samples with the same timestamps in the storage.
+//
+// Only the existing samples on the time range of the imported samples are taken into account.
+// The samples, which aren't searchable yet, aren't taken into account, so flushPendingSamples
+// must be called before the import. Samples, which are concurrently added by other requests, may be missed.
+func skipExistingSamples(labels []prompb.Label, timestamps []int64, values []float64) ([]int64, []float64, error) {
+	if len(timestamps) == 0 {
+		return timestamps, values, nil
+	}
+	tfs, err := newSeriesTagFilters(labels)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Timestamps in native blocks are sorted.
+	tr := storage.TimeRange{
+		MinTimestamp: timestamps[0],
+		MaxTimestamp: timestamps[len(timestamps)-1],
+	}
+	existingTimestamps, err := searchExistingTimestamps(labels, tfs, tr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(existingTimestamps) == 0 {
+		return timestamps, values, nil
+	}
+	dstTimestamps := timestamps[:0]
+	dstValues := values[:0]
+	for i, ts := range timestamps {
+		if _, ok := existingTimestamps[ts]; ok {
+			continue
+		}
+		dstTimestamps = append(dstTimestamps, ts)
+		dstValues = append(dstValues, values[i])
+	}
+	mergeSkippedRows.Add(len(timestamps) - len(dstTimestamps))
+	return dstTimestamps, dstValues, nil
+}
+
+// newSeriesTagFilters returns tag filters matching the series with the given labels.
+//
+// The returned filters also match series with additional labels.
+func newSeriesTagFilters(labels []prompb.Label) (*storage.TagFilters, error) {
+	tfs := storage.NewTagFilters()
+	for _, label := range labels {
+		if len(label.Value) == 0 {
+			continue
+		}
+		var key []byte
+		if !isMetricGroup(label.Name) {
+			key = label.Name
+		}
+		if err := tfs.Add(key, label.Value, false, false); err != nil {
+			return nil, fmt.Errorf("cannot add filter for label %q: %w", label.Name, err)
+		}
+	}
+	return tfs, nil
+}
+
+func isMetricGroup(name []byte) bool {
+	return len(name) == 0 || string(name) == "__name__"
+}
+
+// searchExistingTimestamps returns timestamps for the existing samples on tr for the series with the given labels.
+//
+// tfs must contain tag filters returned from newSeriesTagFilters for labels.
+// Series with additional labels are ignored.
+func searchExistingTimestamps(labels []prompb.Label, tfs *storage.TagFilters, tr storage.TimeRange) (map[int64]struct{}, error) {
+	var metricGroup string
+	tagsCount := 0
+	for _, label := range labels {
+		if len(label.Value) == 0 {
+			continue
+		}
+		if isMetricGroup(label.Name) {
+			metricGroup = string(label.Value)
+		} else {
+			tagsCount++
+		}
+	}
+	existingTimestamps := make(map[int64]struct{})
+
+	vmstorage.WG.Add(1)
+	defer vmstorage.WG.Done()
+
+	var sr storage.Search
+	deadline := fasttime.UnixTimestamp() + mergeSearchTimeout
+	sr.Init(nil, vmstorage.Storage, []*storage.TagFilters{tfs}, tr, math.MaxInt32, deadline)
+	defer sr.MustClose()
+	var mn storage.MetricName
+	var b storage.Block
+	var tmpTimestamps []int64
+	var tmpValues []float64
+	for sr.NextMetricBlock() {
+		if err := mn.Unmarshal(sr.MetricBlockRef.MetricName); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal metricName: %w", err)
+		}
+		if string(mn.MetricGroup) != metricGroup || len(mn.Tags) != tagsCount {
+			// The series contains additional labels.
+			continue
+		}
+		sr.MetricBlockRef.BlockRef.MustReadBlock(&b)
+		if err := b.UnmarshalData(); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal block for %s: %w", &mn, err)
+		}
+		tmpTimestamps, tmpValues = b.AppendRowsWithTimeRangeFilter(tmpTimestamps[:0], tmpValues[:0], tr)
+		for _, ts := range tmpTimestamps {
+			existingTimestamps[ts] = struct{}{}
+		}
+	}
+	if err := sr.Error(); err != nil {
+		return nil, fmt.Errorf("cannot search for existing samples: %w", err)
+	}
+	return existingTimestamps, nil
+}
+
+var mergeSkippedRows = metrics.NewCounter(`vm_native_import_merge_skipped_rows_total`)
@@ -0,0 +1,196 @@
+package native
+
+import (
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+)
+
+func TestGetMergePolicySuccess(t *testing.T) {
+	f := func(s string, mpExpected mergePolicy) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, "http://localhost/api/v1/import/native?"+s, nil)
+		if err != nil {
+			t.Fatalf("cannot create request: %s", err)
+		}
+		mp, err := getMergePolicy(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if mp != mpExpected {
+			t.Fatalf("unexpected merge policy; got %d; want %d", mp, mpExpected)
+		}
+	}
+	f("", mergePolicyNone)
+	f("merge_policy=", mergePolicyNone)
+	f("merge_policy=keep_existing", mergePolicyKeepExisting)
+}
+
+func TestGetMergePolicyFailure(t *testing.T) {
+	f := func(s, errExpected string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, "http://localhost/api/v1/import/native?"+s, nil)
+		if err != nil {
+			t.Fatalf("cannot create request: %s", err)
+		}
+		_, err = getMergePolicy(req)
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+		if !strings.Contains(err.Error(), errExpected) {
+			t.Fatalf("unexpected error; got %q; want it to contain %q", err, errExpected)
+		}
+	}
+	// The storage cannot overwrite the existing samples.
+	f("merge_policy=replace", "merge_policy=replace isn't supported")
+
+	// Invalid merge policy
+	f("merge_policy=foobar", `unsupported merge_policy="foobar"`)
+	f("merge_policy=KEEP_EXISTING", `unsupported merge_policy="KEEP_EXISTING"`)
+}
+
+func TestSkipExistingSamples(t *testing.T) {
+	path := "TestSkipExistingSamples"
+	vmstorage.Storage = storage.MustOpenStorage(path, 0, 0, 0)
+	defer func() {
+		vmstorage.Storage.MustClose()
+		vmstorage.Storage = nil
+		_ = os.RemoveAll(path)
+	}()
+
+	newLabels := func(kvs ...string) []prompb.Label {
+		var labels []prompb.Label
+		for i := 0; i < len(kvs); i += 2 {
+			labels = append(labels, prompb.Label{
+				Name:  []byte(kvs[i]),
+				Value: []byte(kvs[i+1]),
+			})
+		}
+		return labels
+	}
+
+	start := time.Now().UnixNano()/1e6 - 3600*1000
+	var mrs []storage.MetricRow
+	addSamples := func(labels []prompb.Label, offsets ...int64) {
+		metricNameRaw := storage.MarshalMetricNameRaw(nil, labels)
+		for _, offset := range offsets {
+			mrs = append(mrs, storage.MetricRow{
+				MetricNameRaw: metricNameRaw,
+				Timestamp:     start + offset,
+				Value:         float64(offset),
+			})
+		}
+	}
+	addSamples(newLabels("__name__", "foo", "job", "a"), 1000, 2000, 3000)
+	// Series with additional labels mustn't be taken into account.
+	addSamples(newLabels("__name__", "foo", "job", "a", "instance", "x"), 4000, 5000)
+	addSamples(newLabels("job", "a"), 6000)
+	// Series with other label values mustn't be taken into account.
+	addSamples(newLabels("__name__", "foo", "job", "b"), 7000)
+	if err := vmstorage.Storage.AddRows(mrs, 64); err != nil {
+		t.Fatalf("cannot add rows: %s", err)
+	}
+	vmstorage.Storage.DebugFlush()
+
+	f := func(labels []prompb.Label, offsets, offsetsExpected []int64) {
+		t.Helper()
+		var timestamps []int64
+		var values []float64
+		for _, offset := range offsets {
+			timestamps = append(timestamps, start+offset)
+			values = append(values, -float64(offset))
+		}
+		var timestampsExpected []int64
+		var valuesExpected []float64
+		for _, offset := range offsetsExpected {
+			timestampsExpected = append(timestampsExpected, start+offset)
+			valuesExpected = append(valuesExpected, -float64(offset))
+		}
+		timestamps, values, err := skipExistingSamples(labels, timestamps, values)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(timestamps) == 0 && len(timestampsExpected) == 0 {
+			return
+		}
+		if !reflect.DeepEqual(timestamps, timestampsExpected) {
+			t.Fatalf("unexpected timestamps; got %v; want %v", timestamps, timestampsExpected)
+		}
+		if !reflect.DeepEqual(values, valuesExpected) {
+			t.Fatalf("unexpected values; got %v; want %v", values, valuesExpected)
+		}
+	}
+
+	// No imported samples
+	f(newLabels("__name__", "foo", "job", "a"), nil, nil)
+
+	// Missing series
+	f(newLabels("__name__", "bar"), []int64{1000, 2000}, []int64{1000, 2000})
+
+	// All the imported samples already exist
+	f(newLabels("__name__", "foo", "job", "a"), []int64{1000, 2000, 3000}, nil)
+
+	// Some of the imported samples already exist
+	f(newLabels("__name__", "foo", "job", "a"), []int64{500, 1000, 1500, 3000, 3500}, []int64{500, 1500, 3500})
+
+	// The existing samples with additional labels are ignored
+	f(newLabels("__name__", "foo", "job", "a"), []int64{3000, 4000, 5000, 6000, 7000}, []int64{4000, 5000, 6000, 7000})
+	f(newLabels("__name__", "foo", "job", "a", "instance", "x"), []int64{3000, 4000, 5000}, []int64{3000})
+
+	// Series without metric name
+	f(newLabels("job", "a"), []int64{3000, 6000}, []int64{3000})
+
+	// Empty label values are ignored
+	f(newLabels("__name__", "foo", "job", "a", "instance", ""), []int64{2000, 2500}, []int64{2500})
+}
+
+func TestSkipExistingSamplesReimport(t *testing.T) {
+	path := "TestSkipExistingSamplesReimport"
+	vmstorage.Storage = storage.MustOpenStorage(path, 0, 0, 0)
+	defer func() {
+		vmstorage.Storage.MustClose()
+		vmstorage.Storage = nil
+		_ = os.RemoveAll(path)
+	}()
+
+	labels := []prompb.Label{
+		{
+			Name:  []byte("__name__"),
+			Value: []byte("foo"),
+		},
+	}
+	start := time.Now().UnixNano()/1e6 - 3600*1000
+	var timestamps []int64
+	var values []float64
+	var mrs []storage.MetricRow
+	metricNameRaw := storage.MarshalMetricNameRaw(nil, labels)
+	for i := int64(0); i < 10; i++ {
+		timestamps = append(timestamps, start+i*1000)
+		values = append(values, float64(i))
+		mrs = append(mrs, storage.MetricRow{
+			MetricNameRaw: metricNameRaw,
+			Timestamp:     start + i*1000,
+			Value:         float64(i),
+		})
+	}
+
+	// The first import isn't flushed yet, so the re-import right after it must flush the pending samples.
+	if err := vmstorage.Storage.AddRows(mrs, 64); err != nil {
+		t.Fatalf("cannot add rows: %s", err)
+	}
+	flushPendingSamples()
+	timestamps, values, err := skipExistingSamples(labels, timestamps, values)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(timestamps) != 0 || len(values) != 0 {
+		t.Fatalf("expecting all the re-imported samples to be skipped; got timestamps %v", timestamps)
+	}
+}
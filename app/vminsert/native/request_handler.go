@@ -1,6 +1,7 @@
 package native
 
 import (
+	"fmt"
 	"net/http"
 	"sync"
 
@@ -26,13 +27,20 @@ func InsertHandler(req *http.Request) error {
 	if err != nil {
 		return err
 	}
+	mp, err := getMergePolicy(req)
+	if err != nil {
+		return err
+	}
+	if mp != mergePolicyNone {
+		flushPendingSamples()
+	}
 	isGzip := req.Header.Get("Content-Encoding") == "gzip"
 	return stream.Parse(req.Body, isGzip, func(block *stream.Block) error {
-		return insertRows(block, extraLabels)
+		return insertRows(block, extraLabels, mp)
 	})
 }
 
-func insertRows(block *stream.Block, extraLabels []prompbmarshal.Label) error {
+func insertRows(block *stream.Block, extraLabels []prompbmarshal.Label, mp mergePolicy) error {
 	ctx := getPushCtx()
 	defer putPushCtx(ctx)
 
@@ -70,6 +78,13 @@ func insertRows(block *stream.Block, extraLabels []prompbmarshal.Label) error {
 	if len(timestamps) != len(values) {
 		logger.Panicf("BUG: len(timestamps)=%d must match len(values)=%d", len(timestamps), len(values))
 	}
+	if mp != mergePolicyNone {
+		var err error
+		timestamps, values, err = skipExistingSamples(ic.Labels, timestamps, values)
+		if err != nil {
+			return fmt.Errorf("cannot skip the existing samples: %w", err)
+		}
+	}
 	for j, value := range values {
 		timestamp := timestamps[j]
 		if err := ic.WriteDataPoint(ctx.metricNameBuf, nil, timestamp, value); err != nil {
@@ -20,13 +20,17 @@ import (
 var (
 	httpListenAddr = flag.String("httpListenAddr", ":8421", "TCP address for exporting metrics at /metrics page")
 	src            = flag.String("src", "", "Source path with backup on the remote storage. "+
-		"Example: gs://bucket/path/to/backup, s3://bucket/path/to/backup, azblob://container/path/to/backup or fs:///path/to/local/backup")
+		"Example: gs://bucket/path/to/backup, s3://bucket/path/to/backup, azblob://container/path/to/backup, "+
+		"sftp://user@host/path/to/backup, webdav://host/path/to/backup or fs:///path/to/local/backup")
 	storageDataPath = flag.String("storageDataPath", "victoria-metrics-data", "Destination path where backup must be restored. "+
 		"VictoriaMetrics must be stopped when restoring from backup. -storageDataPath dir can be non-empty. In this case the contents of -storageDataPath dir "+
 		"is synchronized with -src contents, i.e. it works like 'rsync --delete'")
 	concurrency             = flag.Int("concurrency", 10, "The number of concurrent workers. Higher concurrency may reduce restore duration")
 	maxBytesPerSecond       = flagutil.NewBytes("maxBytesPerSecond", 0, "The maximum download speed. There is no limit if it is set to 0")
 	skipBackupCompleteCheck = flag.Bool("skipBackupCompleteCheck", false, "Whether to skip checking for 'backup complete' file in -src. This may be useful for restoring from old backups, which were created without 'backup complete' file")
+	useRestoreMark          = flag.Bool("useRestoreMark", false, "Whether to restore from the backup specified in the restore mark at -storageDataPath instead of -src. "+
+		"vmrestore exits without restoring if the restore mark is missing. The restore mark is deleted after successful restore. "+
+		"See https://docs.victoriametrics.com/vmrestore.html#restore-mark")
 )
 
 func main() {
@@ -40,6 +44,48 @@ func main() {
 
 	go httpserver.Serve(*httpListenAddr, false, nil)
 
+	if *useRestoreMark {
+		if len(*src) > 0 {
+			logger.Fatalf("-src cannot be set when -useRestoreMark is set")
+		}
+		rm, err := actions.ReadRestoreMark(*storageDataPath)
+		if err != nil {
+			logger.Fatalf("%s", err)
+		}
+		if rm == nil {
+			logger.Infof("restore mark is missing at -storageDataPath=%q; nothing to restore", *storageDataPath)
+			stopHTTPServer()
+			return
+		}
+		logger.Infof("restoring from the backup at %q specified in the restore mark", rm.Backup)
+		if err := flag.Set("src", rm.Backup); err != nil {
+			logger.Fatalf("cannot set -src flag: %s", err)
+		}
+	}
+
+	tr, err := getRestoreTimeRange()
+	if err != nil {
+		logger.Fatalf("cannot parse -restore.timeRange: %s", err)
+	}
+	if len(*restoreExportMatch) > 0 && len(*restoreExportPath) == 0 {
+		logger.Fatalf("-restore.exportPath must be set when -restore.exportMatch is set")
+	}
+	if len(*restoreExportMatch) == 0 && len(*restoreExportPath) > 0 {
+		logger.Fatalf("-restore.exportMatch must be set when -restore.exportPath is set")
+	}
+	tfss, err := parseExportMatch(*restoreExportMatch)
+	if err != nil {
+		logger.Fatalf("cannot parse -restore.exportMatch: %s", err)
+	}
+	if len(tfss) > 0 {
+		if *useRestoreMark {
+			logger.Fatalf("-restore.exportMatch cannot be set when -useRestoreMark is set")
+		}
+		if err := prepareExportDataPath(*storageDataPath); err != nil {
+			logger.Fatalf("%s", err)
+		}
+	}
+
 	srcFS, err := newSrcFS()
 	if err != nil {
 		logger.Fatalf("%s", err)
@@ -48,18 +94,40 @@ func main() {
 	if err != nil {
 		logger.Fatalf("%s", err)
 	}
+	kp, err := actions.NewKeyProvider()
+	if err != nil {
+		logger.Fatalf("cannot initialize encryption: %s", err)
+	}
 	a := &actions.Restore{
 		Concurrency:             *concurrency,
 		Src:                     srcFS,
 		Dst:                     dstFS,
 		SkipBackupCompleteCheck: *skipBackupCompleteCheck,
+		KeyProvider:             kp,
+	}
+	if len(*restoreTimeRange) > 0 {
+		a.PartFilter = newPartitionFilter(tr)
 	}
 	if err := a.Run(); err != nil {
 		logger.Fatalf("cannot restore from backup: %s", err)
 	}
 	srcFS.MustStop()
 	dstFS.MustStop()
+	if *useRestoreMark {
+		if err := actions.DeleteRestoreMark(*storageDataPath); err != nil {
+			logger.Fatalf("%s", err)
+		}
+	}
+	if len(tfss) > 0 {
+		if err := exportNative(*storageDataPath, tfss, tr, *restoreExportPath); err != nil {
+			logger.Fatalf("cannot export series from the restored data: %s", err)
+		}
+	}
+
+	stopHTTPServer()
+}
 
+func stopHTTPServer() {
 	startTime := time.Now()
 	logger.Infof("gracefully shutting down http server for metrics at %q", *httpListenAddr)
 	if err := httpserver.Stop(*httpListenAddr); err != nil {
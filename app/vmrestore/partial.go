@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/backupnames"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+	"github.com/VictoriaMetrics/metricsql"
+)
+
+var (
+	restoreTimeRange = flag.String("restore.timeRange", "", "Optional time range in the form 'start,end' for restoring only the monthly partitions overlapping it. "+
+		"For example, -restore.timeRange=2023-01,2023-03 restores data for January, February and March 2023. "+
+		"See https://docs.victoriametrics.com/Single-server-VictoriaMetrics.html#timestamp-formats for supported time formats. "+
+		"The end in YYYY, YYYY-MM or YYYY-MM-DD format covers the whole year, month or day. "+
+		"Partitions outside the time range are deleted from -storageDataPath. See https://docs.victoriametrics.com/vmrestore.html#partial-restore")
+	restoreExportMatch = flagutil.NewArrayString("restore.exportMatch", "Optional series selector for exporting the matching series from the restored data at -storageDataPath "+
+		"to -restore.exportPath in native format. The exported file can be imported into the live VictoriaMetrics via /api/v1/import/native. "+
+		"-storageDataPath must be missing or empty in this case, so vmrestore creates it for the restored data. "+
+		"See https://docs.victoriametrics.com/vmrestore.html#partial-restore")
+	restoreExportPath = flag.String("restore.exportPath", "", "Path to the file for exporting the series matching -restore.exportMatch in native format")
+)
+
+// getRestoreTimeRange returns the time range from -restore.timeRange.
+//
+// The returned time range covers all the data if -restore.timeRange isn't set.
+func getRestoreTimeRange() (storage.TimeRange, error) {
+	if len(*restoreTimeRange) == 0 {
+		return storage.TimeRange{
+			MinTimestamp: 0,
+			MaxTimestamp: math.MaxInt64,
+		}, nil
+	}
+	return parseTimeRange(*restoreTimeRange)
+}
+
+func parseTimeRange(s string) (storage.TimeRange, error) {
+	n := strings.IndexByte(s, ',')
+	if n < 0 {
+		return storage.TimeRange{}, fmt.Errorf("missing ',' between start and end in %q", s)
+	}
+	start, err := promutils.ParseTime(s[:n])
+	if err != nil {
+		return storage.TimeRange{}, fmt.Errorf("cannot parse start time %q: %w", s[:n], err)
+	}
+	end, err := parseEndTime(s[n+1:])
+	if err != nil {
+		return storage.TimeRange{}, fmt.Errorf("cannot parse end time %q: %w", s[n+1:], err)
+	}
+	tr := storage.TimeRange{
+		MinTimestamp: int64(start * 1e3),
+		MaxTimestamp: end,
+	}
+	if tr.MinTimestamp > tr.MaxTimestamp {
+		return storage.TimeRange{}, fmt.Errorf("start time %q cannot exceed end time %q", s[:n], s[n+1:])
+	}
+	return tr, nil
+}
+
+// parseEndTime parses the end of time range from s and returns it in milliseconds.
+//
+// The end in YYYY, YYYY-MM or YYYY-MM-DD format is inclusive, i.e. it points to the last millisecond of the given year, month or day.
+func parseEndTime(s string) (int64, error) {
+	for _, p := range []struct {
+		layout string
+		years  int
+		months int
+		days   int
+	}{
+		{"2006", 1, 0, 0},
+		{"2006-01", 0, 1, 0},
+		{"2006-01-02", 0, 0, 1},
+	} {
+		if t, err := time.Parse(p.layout, s); err == nil {
+			return t.AddDate(p.years, p.months, p.days).UnixMilli() - 1, nil
+		}
+	}
+	end, err := promutils.ParseTime(s)
+	if err != nil {
+		return 0, err
+	}
+	return int64(end * 1e3), nil
+}
+
+// newPartitionFilter returns filter for backup parts, which accepts only the partitions overlapping tr.
+//
+// Parts outside data/small and data/big partitions such as indexdb are always accepted,
+// since they are shared among all the partitions.
+func newPartitionFilter(tr storage.TimeRange) func(p common.Part) bool {
+	return func(p common.Part) bool {
+		partitionName, ok := getPartitionName(p.Path)
+		if !ok {
+			return true
+		}
+		t, err := time.Parse("2006_01", partitionName)
+		if err != nil {
+			// Unknown directory - restore it as is.
+			return true
+		}
+		minTimestamp := t.UnixMilli()
+		maxTimestamp := t.AddDate(0, 1, 0).UnixMilli() - 1
+		return minTimestamp <= tr.MaxTimestamp && maxTimestamp >= tr.MinTimestamp
+	}
+}
+
+// getPartitionName returns partition name for the given path in the backup.
+func getPartitionName(path string) (string, bool) {
+	a := strings.SplitN(path, "/", 4)
+	if len(a) < 4 || a[0] != "data" || (a[1] != "small" && a[1] != "big") {
+		return "", false
+	}
+	return a[2], true
+}
+
+// prepareExportDataPath prepares dataPath for restoring the backup before exporting series from it.
+//
+// The contents of dataPath is replaced with the backup contents, so dataPath must be missing or empty,
+// or it must be created by the previous vmrestore run with -restore.exportMatch.
+func prepareExportDataPath(dataPath string) error {
+	markPath := filepath.Join(dataPath, backupnames.RestoreExportDataFilename)
+	if fs.IsPathExist(markPath) {
+		return nil
+	}
+	if fs.IsPathExist(dataPath) && !fs.IsEmptyDir(dataPath) {
+		return fmt.Errorf("-storageDataPath=%q must be missing or empty when -restore.exportMatch is set, since its contents is replaced with the restored backup", dataPath)
+	}
+	fs.MustMkdirIfNotExist(dataPath)
+	fs.MustWriteSync(markPath, nil)
+	return nil
+}
+
+// parseExportMatch parses series selectors from -restore.exportMatch.
+func parseExportMatch(matches []string) ([]*storage.TagFilters, error) {
+	var tfss []*storage.TagFilters
+	for _, match := range matches {
+		expr, err := metricsql.Parse(match)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse series selector %q: %w", match, err)
+		}
+		me, ok := expr.(*metricsql.MetricExpr)
+		if !ok || len(me.LabelFilters) == 0 {
+			return nil, fmt.Errorf("expecting series selector; got %q", match)
+		}
+		tfs := storage.NewTagFilters()
+		for _, lf := range me.LabelFilters {
+			var key []byte
+			if lf.Label != "__name__" {
+				key = []byte(lf.Label)
+			}
+			if err := tfs.Add(key, []byte(lf.Value), lf.IsNegative, lf.IsRegexp); err != nil {
+				return nil, fmt.Errorf("cannot parse label filter %s in %q: %w", lf.AppendString(nil), match, err)
+			}
+		}
+		tfss = append(tfss, tfs)
+	}
+	return tfss, nil
+}
+
+// exportNative exports series matching tfss on the time range tr from the storage at dataPath to dstPath in native format.
+//
+// The exported file can be imported via /api/v1/import/native.
+func exportNative(dataPath string, tfss []*storage.TagFilters, tr storage.TimeRange, dstPath string) error {
+	startTime := time.Now()
+	logger.Infof("exporting series matching %s on the time range %s from %q to %q", tfss, &tr, dataPath, dstPath)
+
+	// Use the maximum retention, so the restored data isn't deleted.
+	strg := storage.MustOpenStorage(dataPath, 0, 0, 0)
+	defer strg.MustClose()
+
+	tmpPath := dstPath + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("cannot create %q: %w", tmpPath, err)
+	}
+	defer func() {
+		if f != nil {
+			fs.MustClose(f)
+			_ = os.Remove(tmpPath)
+		}
+	}()
+	bw := bufio.NewWriterSize(f, 1024*1024)
+
+	// Marshal tr
+	buf := make([]byte, 0, 16)
+	buf = encoding.MarshalInt64(buf, tr.MinTimestamp)
+	buf = encoding.MarshalInt64(buf, tr.MaxTimestamp)
+	if _, err := bw.Write(buf); err != nil {
+		return fmt.Errorf("cannot write to %q: %w", tmpPath, err)
+	}
+
+	// Marshal native blocks.
+	var sr storage.Search
+	sr.Init(nil, strg, tfss, tr, math.MaxInt32, math.MaxUint64)
+	defer sr.MustClose()
+	var mn storage.MetricName
+	var b storage.Block
+	var tmp []byte
+	blocks := 0
+	samples := 0
+	for sr.NextMetricBlock() {
+		if err := mn.Unmarshal(sr.MetricBlockRef.MetricName); err != nil {
+			return fmt.Errorf("cannot unmarshal metricName for block #%d: %w", blocks+1, err)
+		}
+		br := sr.MetricBlockRef.BlockRef
+		br.MustReadBlock(&b)
+
+		buf = buf[:0]
+		tmp = mn.Marshal(tmp[:0])
+		buf = encoding.MarshalUint32(buf, uint32(len(tmp)))
+		buf = append(buf, tmp...)
+		tmp = b.MarshalPortable(tmp[:0])
+		buf = encoding.MarshalUint32(buf, uint32(len(tmp)))
+		buf = append(buf, tmp...)
+		if _, err := bw.Write(buf); err != nil {
+			return fmt.Errorf("cannot write to %q: %w", tmpPath, err)
+		}
+		blocks++
+		samples += br.RowsCount()
+	}
+	if err := sr.Error(); err != nil {
+		return fmt.Errorf("search error after reading %d data blocks: %w", blocks, err)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("cannot write to %q: %w", tmpPath, err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("cannot sync %q: %w", tmpPath, err)
+	}
+	err = f.Close()
+	f = nil
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("cannot close %q: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, dstPath); err != nil {
+		return fmt.Errorf("cannot rename %q to %q: %w", tmpPath, dstPath, err)
+	}
+	logger.Infof("exported %d blocks with %d samples to %q in %.3f seconds", blocks, samples, dstPath, time.Since(startTime).Seconds())
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+)
+
+func TestParseTimeRangeSuccess(t *testing.T) {
+	f := func(s string, minTimestampExpected, maxTimestampExpected int64) {
+		t.Helper()
+		tr, err := parseTimeRange(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if tr.MinTimestamp != minTimestampExpected || tr.MaxTimestamp != maxTimestampExpected {
+			t.Fatalf("unexpected time range for %q; got [%d..%d]; want [%d..%d]", s, tr.MinTimestamp, tr.MaxTimestamp, minTimestampExpected, maxTimestampExpected)
+		}
+	}
+	// The end in YYYY, YYYY-MM or YYYY-MM-DD format is inclusive
+	f("2023-01,2023-03", 1672531200000, 1680307199999)
+	f("2023,2023", 1672531200000, 1704067199999)
+	f("2023-01-01,2023-01-01", 1672531200000, 1672617599999)
+	f("2023-01-02T00:00:00Z,2023-01-02T00:00:00Z", 1672617600000, 1672617600000)
+	f("1672531200,1672617600.5", 1672531200000, 1672617600500)
+}
+
+func TestParseTimeRangeFailure(t *testing.T) {
+	f := func(s string) {
+		t.Helper()
+		_, err := parseTimeRange(s)
+		if err == nil {
+			t.Fatalf("expecting non-nil error for %q", s)
+		}
+	}
+	f("")
+	f("2023-01")
+	f("foo,2023-01")
+	f("2023-01,bar")
+	f("2023-03,2023-01")
+}
+
+func TestPartitionFilter(t *testing.T) {
+	// [2023-01-15T00:00:00Z..2023-02-01T00:00:00Z]
+	tr := storage.TimeRange{
+		MinTimestamp: 1673740800000,
+		MaxTimestamp: 1675209600000,
+	}
+	filter := newPartitionFilter(tr)
+	f := func(path string, resultExpected bool) {
+		t.Helper()
+		p := common.Part{
+			Path: path,
+		}
+		if result := filter(p); result != resultExpected {
+			t.Fatalf("unexpected result for %q; got %v; want %v", path, result, resultExpected)
+		}
+	}
+	f("data/small/2023_01/17417B3D6A5C7F6D/values.bin", true)
+	f("data/big/2023_02/17417B3D6A5C7F6E/values.bin", true)
+	f("data/small/2022_12/17417B3D6A5C7F6F/values.bin", false)
+	f("data/big/2023_03/17417B3D6A5C7F70/index.bin", false)
+	f("indexdb/17417B3D6A5C7F6C/17417B3D6A5C7F71/items.bin", true)
+	f("metadata/minTimestampForCompositeIndex", true)
+	f("data/small/foo/bar/baz", true)
+}
+
+func TestPrepareExportDataPath(t *testing.T) {
+	path := "TestPrepareExportDataPath"
+	defer func() {
+		_ = os.RemoveAll(path)
+	}()
+
+	// Missing dir is created
+	if err := prepareExportDataPath(path); err != nil {
+		t.Fatalf("unexpected error for missing dir: %s", err)
+	}
+	// The dir created by vmrestore can be re-used
+	if err := os.MkdirAll(filepath.Join(path, "data"), 0755); err != nil {
+		t.Fatalf("cannot create dir: %s", err)
+	}
+	if err := prepareExportDataPath(path); err != nil {
+		t.Fatalf("unexpected error for the dir created by vmrestore: %s", err)
+	}
+	// Non-empty dir not created by vmrestore must be rejected
+	if err := os.Remove(filepath.Join(path, "restore-export-data")); err != nil {
+		t.Fatalf("cannot remove file: %s", err)
+	}
+	if err := prepareExportDataPath(path); err == nil {
+		t.Fatalf("expecting non-nil error for non-empty dir")
+	}
+}
+
+func TestParseExportMatch(t *testing.T) {
+	f := func(matches []string, errExpected bool) {
+		t.Helper()
+		tfss, err := parseExportMatch(matches)
+		if errExpected {
+			if err == nil {
+				t.Fatalf("expecting non-nil error for %q", matches)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %s", matches, err)
+		}
+		if len(tfss) != len(matches) {
+			t.Fatalf("unexpected number of tag filters; got %d; want %d", len(tfss), len(matches))
+		}
+	}
+	f(nil, false)
+	f([]string{`foo`}, false)
+	f([]string{`{job="foo",instance=~"bar.+"}`, `baz{a!="b"}`}, false)
+	f([]string{`rate(foo[5m])`}, true)
+	f([]string{`{`}, true)
+}
@@ -196,6 +196,45 @@ func TestExecExprSuccess(t *testing.T) {
 		},
 	})
 	f("aggregate(group(),'avg')", []*series{})
+	f(`aggregateSeriesLists(
+		group(
+			time('foo',30),
+			time('bar',30)
+		),
+		group(
+			time('x',30),
+			time('y',30)
+		),
+		"max"
+	)`, []*series{
+		{
+			Timestamps: []int64{120000, 150000, 180000},
+			Values:     []float64{120, 150, 180},
+			Name:       "maxSeries(foo,x)",
+			Tags:       map[string]string{"name": "maxSeries(foo,x)", "aggregatedBy": "max"},
+		},
+		{
+			Timestamps: []int64{120000, 150000, 180000},
+			Values:     []float64{120, 150, 180},
+			Name:       "maxSeries(bar,y)",
+			Tags:       map[string]string{"name": "maxSeries(bar,y)", "aggregatedBy": "max"},
+		},
+	})
+	f(`aggregateSeriesLists(
+		removeBelowValue(time('foo',30),150),
+		time('bar',30),
+		"avgSeries",
+		1
+	)`, []*series{
+		{
+			Timestamps:     []int64{120000, 150000, 180000},
+			Values:         []float64{nan, 150, 180},
+			Name:           "avgSeries(removeBelowValue(foo,150),bar)",
+			Tags:           map[string]string{"name": "avgSeries(bar,removeBelowValue(foo,150))", "aggregatedBy": "avg"},
+			pathExpression: "avgSeries(bar,removeBelowValue(foo,150))",
+		},
+	})
+	f(`aggregateSeriesLists(group(),group(),"sum")`, []*series{})
 	f(`aggregateLine(
 		group(
 			time("foo", 10),
@@ -534,6 +573,25 @@ func TestExecExprSuccess(t *testing.T) {
 			Tags:       map[string]string{"name": "b", "areaBetween": "1"},
 		},
 	})
+	f(`pct(
+		group(
+			time("foo", 30),
+			time("bar", 30),
+		)
+	)`, []*series{
+		{
+			Timestamps: []int64{120000, 150000, 180000},
+			Values:     []float64{50, 50, 50},
+			Name:       "asPercent(foo,sumSeries(bar,foo))",
+			Tags:       map[string]string{"name": "asPercent(foo,sumSeries(bar,foo))"},
+		},
+		{
+			Timestamps: []int64{120000, 150000, 180000},
+			Values:     []float64{50, 50, 50},
+			Name:       "asPercent(bar,sumSeries(bar,foo))",
+			Tags:       map[string]string{"name": "asPercent(bar,sumSeries(bar,foo))"},
+		},
+	})
 	f(`asPercent(
 		group(
 			time("foo", 30),
@@ -1026,6 +1084,30 @@ func TestExecExprSuccess(t *testing.T) {
 			Tags:       map[string]string{"name": "diffSeries(foo,bar)", "aggregatedBy": "diff"},
 		},
 	})
+	f(`diffSeriesLists(
+		group(
+			time('foo',30),
+			time('bar',30)
+		),
+		group(
+			time('x',30),
+			constantLine(100)
+		)
+	)`, []*series{
+		{
+			Timestamps: []int64{120000, 150000, 180000},
+			Values:     []float64{0, 0, 0},
+			Name:       "diffSeries(foo,x)",
+			Tags:       map[string]string{"name": "diffSeries(foo,x)", "aggregatedBy": "diff"},
+		},
+		{
+			Timestamps:     []int64{120000, 150000, 180000},
+			Values:         []float64{20, 50, 180},
+			Name:           "diffSeries(bar,100)",
+			Tags:           map[string]string{"name": "diffSeries(bar,constantLine(100))", "aggregatedBy": "diff"},
+			pathExpression: "diffSeries(bar,constantLine(100))",
+		},
+	})
 	f(`divideSeries(
 		group(
 			time('foo',30),
@@ -1984,6 +2066,29 @@ func TestExecExprSuccess(t *testing.T) {
 			Tags:       map[string]string{"name": "multiplySeries(bar,foo)", "aggregatedBy": "multiply"},
 		},
 	})
+	f(`multiplySeriesLists(
+		group(
+			time('foo',30),
+			time('bar',30)
+		),
+		group(
+			time('x',30),
+			time('y',30)
+		)
+	)`, []*series{
+		{
+			Timestamps: []int64{120000, 150000, 180000},
+			Values:     []float64{14400, 22500, 32400},
+			Name:       "multiplySeries(foo,x)",
+			Tags:       map[string]string{"name": "multiplySeries(foo,x)", "aggregatedBy": "multiply"},
+		},
+		{
+			Timestamps: []int64{120000, 150000, 180000},
+			Values:     []float64{14400, 22500, 32400},
+			Name:       "multiplySeries(bar,y)",
+			Tags:       map[string]string{"name": "multiplySeries(bar,y)", "aggregatedBy": "multiply"},
+		},
+	})
 	f(`multiplySeriesWithWildcards(
 		group(
 			time('foo.bar',30),
@@ -2137,6 +2242,35 @@ func TestExecExprSuccess(t *testing.T) {
 		},
 	})
 	f(`removeEmptySeries(removeBelowValue(time('a'),150),1)`, []*series{})
+	f(`removeZeroSeries(
+		group(
+			time('a'),
+			constantLine(0),
+			removeBelowValue(time('b',30),180)
+		)
+	)`, []*series{
+		{
+			Timestamps: []int64{120000, 180000},
+			Values:     []float64{120, 180},
+			Name:       "a",
+			Tags:       map[string]string{"name": "a"},
+		},
+		{
+			Timestamps: []int64{120000, 150000, 180000, 210000},
+			Values:     []float64{nan, nan, 180, 210},
+			Name:       "removeBelowValue(b,180)",
+			Tags:       map[string]string{"name": "b"},
+		},
+	})
+	f(`removeZeroSeries(removeBelowValue(time('b',30),200),0.5)`, []*series{})
+	f(`removeZeroSeries(offset(time('a',30),-150),xFilesFactor=0.5)`, []*series{
+		{
+			Timestamps: []int64{120000, 150000, 180000, 210000},
+			Values:     []float64{-30, 0, 30, 60},
+			Name:       "offset(a,-150)",
+			Tags:       map[string]string{"name": "a", "offset": "-150"},
+		},
+	})
 	f(`round(time('a',17),-1)`, []*series{
 		{
 			Timestamps:     []int64{120000, 137000, 154000, 171000, 188000, 205000},
@@ -2175,6 +2309,30 @@ func TestExecExprSuccess(t *testing.T) {
 			Tags:       map[string]string{"name": "foo", "xFilesFactor": "0.5"},
 		},
 	})
+	f(`sumSeriesLists(
+		group(
+			time('foo',30),
+			time('bar',30)
+		),
+		group(
+			time('x',30),
+			time('a',30)
+		)
+	)`, []*series{
+		{
+			Timestamps: []int64{120000, 150000, 180000},
+			Values:     []float64{240, 300, 360},
+			Name:       "sumSeries(foo,x)",
+			Tags:       map[string]string{"name": "sumSeries(foo,x)", "aggregatedBy": "sum"},
+		},
+		{
+			Timestamps:     []int64{120000, 150000, 180000},
+			Values:         []float64{240, 300, 360},
+			Name:           "sumSeries(bar,a)",
+			Tags:           map[string]string{"name": "sumSeries(a,bar)", "aggregatedBy": "sum"},
+			pathExpression: "sumSeries(a,bar)",
+		},
+	})
 	f(`sumSeriesWithWildcards(
 		group(
 			time('foo.bar',30),
@@ -2469,6 +2627,42 @@ func TestExecExprSuccess(t *testing.T) {
 			Tags:       map[string]string{"name": "percentileOfSeries(a,90)"},
 		},
 	})
+	f(`toLowerCase(time('Foo.BAR'))`, []*series{
+		{
+			Timestamps:     []int64{120000, 180000},
+			Values:         []float64{120, 180},
+			Name:           "foo.bar",
+			Tags:           map[string]string{"name": "Foo.BAR"},
+			pathExpression: "Foo.BAR",
+		},
+	})
+	f(`lower(time('FOO.BAR'),0,-1,100)`, []*series{
+		{
+			Timestamps:     []int64{120000, 180000},
+			Values:         []float64{120, 180},
+			Name:           "fOO.BAr",
+			Tags:           map[string]string{"name": "FOO.BAR"},
+			pathExpression: "FOO.BAR",
+		},
+	})
+	f(`toUpperCase(time('foo.bar'))`, []*series{
+		{
+			Timestamps:     []int64{120000, 180000},
+			Values:         []float64{120, 180},
+			Name:           "FOO.BAR",
+			Tags:           map[string]string{"name": "foo.bar"},
+			pathExpression: "foo.bar",
+		},
+	})
+	f(`upper(time('foo.bar'),1,-2)`, []*series{
+		{
+			Timestamps:     []int64{120000, 180000},
+			Values:         []float64{120, 180},
+			Name:           "fOo.bAr",
+			Tags:           map[string]string{"name": "foo.bar"},
+			pathExpression: "foo.bar",
+		},
+	})
 	f(`transformNull(time('foo.bar',35),-1,time('foo.bar',30))`, []*series{
 		{
 			Timestamps:     []int64{120000, 150000, 180000},
@@ -3362,6 +3556,29 @@ func TestExecExprFailure(t *testing.T) {
 	f("aggregate(time('a'), 'sum', 'bar')")
 	f("aggregate(1,'sum')")
 
+	f("aggregateSeriesLists()")
+	f("aggregateSeriesLists(time('a'))")
+	f("aggregateSeriesLists(time('a'),time('b'))")
+	f("aggregateSeriesLists(time('a'),time('b'),'non-existing-func')")
+	f("aggregateSeriesLists(time('a'),time('b'),'sum','bar')")
+	f("aggregateSeriesLists(time('a'),group(time('b'),time('c')),'sum')")
+	f("aggregateSeriesLists(1,time('b'),'sum')")
+
+	f("sumSeriesLists()")
+	f("sumSeriesLists(time('a'))")
+	f("sumSeriesLists(time('a'),time('b'),time('c'))")
+	f("diffSeriesLists(time('a'),group(time('b'),time('c')))")
+	f("multiplySeriesLists(time('a'),bar)")
+
+	f("removeZeroSeries()")
+	f("removeZeroSeries(time('a'),'bar')")
+	f("removeZeroSeries(time('a'),1,2)")
+
+	f("toLowerCase()")
+	f("toLowerCase(1)")
+	f("toLowerCase(time('a'),'bar')")
+	f("toUpperCase(time('a'),1,'bar')")
+
 	f("aggregateLine()")
 	f("aggregateLine(123)")
 	f("aggregateLine(time('a'), bar)")
@@ -0,0 +1,221 @@
+package graphite
+
+import (
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/graphiteql"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/netstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/searchutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+)
+
+// TestExecExprGraphiteWebFixtures verifies functions over series stored in the storage
+// against the fixtures from graphite-web test suite - see https://github.com/graphite-project/graphite-web/blob/master/webapp/tests/test_functions.py
+func TestExecExprGraphiteWebFixtures(t *testing.T) {
+	path := "TestExecExprGraphiteWebFixtures"
+	vmstorage.Storage = storage.MustOpenStorage(path, 0, 0, 0)
+	netstorage.InitTmpBlocksDir(path)
+	defer func() {
+		vmstorage.Storage.MustClose()
+		vmstorage.Storage = nil
+		_ = os.RemoveAll(path)
+	}()
+
+	const step = 60e3
+	start := (time.Now().UnixNano()/1e6/3600e3 - 1) * 3600e3
+	nan := math.NaN()
+	fixtures := map[string][]float64{
+		"collectd.test-db1.load.value": {1, 2, 3, 4, 5},
+		"collectd.test-db2.load.value": {5, 4, 3, 2, 1},
+		"collectd.test-db3.load.value": {1, nan, 3, nan, 5},
+		"collectd.test-db4.load.value": {0, 0, 0, 0, 0},
+		"collectd.TEST-DB5.Load.Value": {1, 1, 1, 1, 1},
+	}
+	var mrs []storage.MetricRow
+	for name, values := range fixtures {
+		metricNameRaw := storage.MarshalMetricNameRaw(nil, []prompb.Label{{
+			Name:  []byte("__name__"),
+			Value: []byte(name),
+		}})
+		for i, v := range values {
+			if math.IsNaN(v) {
+				// graphite-web None values are missing samples.
+				continue
+			}
+			mrs = append(mrs, storage.MetricRow{
+				MetricNameRaw: metricNameRaw,
+				Timestamp:     start + int64(i)*step,
+				Value:         v,
+			})
+		}
+	}
+	if err := vmstorage.Storage.AddRows(mrs, 64); err != nil {
+		t.Fatalf("cannot add rows: %s", err)
+	}
+	vmstorage.Storage.DebugFlush()
+
+	timestamps := []int64{start, start + step, start + 2*step, start + 3*step, start + 4*step}
+	ec := &evalConfig{
+		startTime:   start,
+		endTime:     start + 5*step,
+		storageStep: step,
+		deadline:    searchutils.NewDeadline(time.Now(), time.Minute, ""),
+		currentTime: time.Unix(start/1e3, 0),
+	}
+	f := func(query string, expectedSeries []*series) {
+		t.Helper()
+		ecCopy := *ec
+		nextSeries, err := execExpr(&ecCopy, query)
+		if err != nil {
+			t.Fatalf("unexpected error in execExpr(%q): %s", query, err)
+		}
+		ss, err := fetchAllSeries(nextSeries)
+		if err != nil {
+			t.Fatalf("cannot fetch all series: %s", err)
+		}
+		expr, err := graphiteql.Parse(query)
+		if err != nil {
+			t.Fatalf("cannot parse query %q: %s", query, err)
+		}
+		for _, s := range expectedSeries {
+			s.Timestamps = timestamps
+		}
+		if err := compareSeries(ss, expectedSeries, expr); err != nil {
+			t.Fatalf("series mismatch for query %q: %s\ngot series\n%s\nexpected series\n%s", query, err, printSeriess(ss), printSeriess(expectedSeries))
+		}
+	}
+
+	// Series from metric expressions are paired in the order of sorted paths.
+	f(`sumSeriesLists(collectd.test-db{1,2}.load.value,collectd.test-db{3,4}.load.value)`, []*series{
+		{
+			Values:         []float64{2, 2, 6, 4, 10},
+			Name:           "sumSeries(collectd.test-db1.load.value,collectd.test-db3.load.value)",
+			Tags:           map[string]string{"name": "sumSeries(collectd.test-db{1,2}.load.value,collectd.test-db{3,4}.load.value)", "aggregatedBy": "sum"},
+			pathExpression: "sumSeries(collectd.test-db{1,2}.load.value,collectd.test-db{3,4}.load.value)",
+		},
+		{
+			Values:         []float64{5, 4, 3, 2, 1},
+			Name:           "sumSeries(collectd.test-db2.load.value,collectd.test-db4.load.value)",
+			Tags:           map[string]string{"name": "sumSeries(collectd.test-db{1,2}.load.value,collectd.test-db{3,4}.load.value)", "aggregatedBy": "sum"},
+			pathExpression: "sumSeries(collectd.test-db{1,2}.load.value,collectd.test-db{3,4}.load.value)",
+		},
+	})
+	f(`diffSeriesLists(collectd.test-db{1,2}.load.value,collectd.test-db{3,4}.load.value)`, []*series{
+		{
+			Values:         []float64{0, 2, 0, 4, 0},
+			Name:           "diffSeries(collectd.test-db1.load.value,collectd.test-db3.load.value)",
+			Tags:           map[string]string{"name": "diffSeries(collectd.test-db{1,2}.load.value,collectd.test-db{3,4}.load.value)", "aggregatedBy": "diff"},
+			pathExpression: "diffSeries(collectd.test-db{1,2}.load.value,collectd.test-db{3,4}.load.value)",
+		},
+		{
+			Values:         []float64{5, 4, 3, 2, 1},
+			Name:           "diffSeries(collectd.test-db2.load.value,collectd.test-db4.load.value)",
+			Tags:           map[string]string{"name": "diffSeries(collectd.test-db{1,2}.load.value,collectd.test-db{3,4}.load.value)", "aggregatedBy": "diff"},
+			pathExpression: "diffSeries(collectd.test-db{1,2}.load.value,collectd.test-db{3,4}.load.value)",
+		},
+	})
+	f(`multiplySeriesLists(collectd.test-db{1,2}.load.value,collectd.test-db{2,3}.load.value)`, []*series{
+		{
+			Values:         []float64{5, 8, 9, 8, 5},
+			Name:           "multiplySeries(collectd.test-db1.load.value,collectd.test-db2.load.value)",
+			Tags:           map[string]string{"name": "multiplySeries(collectd.test-db{1,2}.load.value,collectd.test-db{2,3}.load.value)", "aggregatedBy": "multiply"},
+			pathExpression: "multiplySeries(collectd.test-db{1,2}.load.value,collectd.test-db{2,3}.load.value)",
+		},
+		{
+			Values:         []float64{5, 4, 9, 2, 5},
+			Name:           "multiplySeries(collectd.test-db2.load.value,collectd.test-db3.load.value)",
+			Tags:           map[string]string{"name": "multiplySeries(collectd.test-db{1,2}.load.value,collectd.test-db{2,3}.load.value)", "aggregatedBy": "multiply"},
+			pathExpression: "multiplySeries(collectd.test-db{1,2}.load.value,collectd.test-db{2,3}.load.value)",
+		},
+	})
+	f(`aggregateSeriesLists(collectd.test-db{1,2}.load.value,collectd.test-db{3,4}.load.value,'avg')`, []*series{
+		{
+			Values:         []float64{1, 2, 3, 4, 5},
+			Name:           "avgSeries(collectd.test-db1.load.value,collectd.test-db3.load.value)",
+			Tags:           map[string]string{"name": "avgSeries(collectd.test-db{1,2}.load.value,collectd.test-db{3,4}.load.value)", "aggregatedBy": "avg"},
+			pathExpression: "avgSeries(collectd.test-db{1,2}.load.value,collectd.test-db{3,4}.load.value)",
+		},
+		{
+			Values:         []float64{2.5, 2, 1.5, 1, 0.5},
+			Name:           "avgSeries(collectd.test-db2.load.value,collectd.test-db4.load.value)",
+			Tags:           map[string]string{"name": "avgSeries(collectd.test-db{1,2}.load.value,collectd.test-db{3,4}.load.value)", "aggregatedBy": "avg"},
+			pathExpression: "avgSeries(collectd.test-db{1,2}.load.value,collectd.test-db{3,4}.load.value)",
+		},
+	})
+
+	// Series with only zero values must be removed.
+	f(`removeZeroSeries(collectd.test-db*.load.value)`, []*series{
+		{
+			Values:         []float64{1, 2, 3, 4, 5},
+			Name:           "collectd.test-db1.load.value",
+			Tags:           map[string]string{"name": "collectd.test-db1.load.value"},
+			pathExpression: "collectd.test-db*.load.value",
+		},
+		{
+			Values:         []float64{5, 4, 3, 2, 1},
+			Name:           "collectd.test-db2.load.value",
+			Tags:           map[string]string{"name": "collectd.test-db2.load.value"},
+			pathExpression: "collectd.test-db*.load.value",
+		},
+		{
+			Values:         []float64{1, nan, 3, nan, 5},
+			Name:           "collectd.test-db3.load.value",
+			Tags:           map[string]string{"name": "collectd.test-db3.load.value"},
+			pathExpression: "collectd.test-db*.load.value",
+		},
+	})
+	// Series with less than xFilesFactor non-zero values must be removed.
+	f(`removeZeroSeries(collectd.test-db*.load.value,1)`, []*series{
+		{
+			Values:         []float64{1, 2, 3, 4, 5},
+			Name:           "collectd.test-db1.load.value",
+			Tags:           map[string]string{"name": "collectd.test-db1.load.value"},
+			pathExpression: "collectd.test-db*.load.value",
+		},
+		{
+			Values:         []float64{5, 4, 3, 2, 1},
+			Name:           "collectd.test-db2.load.value",
+			Tags:           map[string]string{"name": "collectd.test-db2.load.value"},
+			pathExpression: "collectd.test-db*.load.value",
+		},
+	})
+
+	// Only series names are changed, while tags remain the same.
+	f(`toUpperCase(collectd.test-db1.load.value)`, []*series{
+		{
+			Values:         []float64{1, 2, 3, 4, 5},
+			Name:           "COLLECTD.TEST-DB1.LOAD.VALUE",
+			Tags:           map[string]string{"name": "collectd.test-db1.load.value"},
+			pathExpression: "collectd.test-db1.load.value",
+		},
+	})
+	f(`toUpperCase(collectd.test-db1.load.value,0,9,-1)`, []*series{
+		{
+			Values:         []float64{1, 2, 3, 4, 5},
+			Name:           "Collectd.Test-db1.load.valuE",
+			Tags:           map[string]string{"name": "collectd.test-db1.load.value"},
+			pathExpression: "collectd.test-db1.load.value",
+		},
+	})
+	f(`toLowerCase(collectd.TEST-DB5.Load.Value)`, []*series{
+		{
+			Values:         []float64{1, 1, 1, 1, 1},
+			Name:           "collectd.test-db5.load.value",
+			Tags:           map[string]string{"name": "collectd.TEST-DB5.Load.Value"},
+			pathExpression: "collectd.TEST-DB5.Load.Value",
+		},
+	})
+	f(`toLowerCase(collectd.TEST-DB5.Load.Value,9,-5)`, []*series{
+		{
+			Values:         []float64{1, 1, 1, 1, 1},
+			Name:           "collectd.tEST-DB5.Load.value",
+			Tags:           map[string]string{"name": "collectd.TEST-DB5.Load.Value"},
+			pathExpression: "collectd.TEST-DB5.Load.Value",
+		},
+	})
+}
@@ -27,8 +27,18 @@ var (
 func RenderHandler(startTime time.Time, w http.ResponseWriter, r *http.Request) error {
 	deadline := searchutils.GetDeadlineForQuery(r, startTime)
 	format := r.FormValue("format")
-	if format != "json" {
-		return fmt.Errorf("unsupported format=%q; supported values: json", format)
+	switch format {
+	case "json", "csv", "raw", "pickle", "msgpack", "dygraph", "rickshaw":
+	default:
+		return fmt.Errorf("unsupported format=%q; supported values: json, csv, raw, pickle, msgpack, dygraph, rickshaw", format)
+	}
+	loc := time.UTC
+	if tz := r.FormValue("tz"); len(tz) > 0 {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			return fmt.Errorf("cannot load timezone tz=%q: %w", tz, err)
+		}
+		loc = l
 	}
 	xFilesFactor := float64(0)
 	if xff := r.FormValue("xFilesFactor"); len(xff) > 0 {
@@ -135,11 +145,50 @@ func RenderHandler(startTime time.Time, w http.ResponseWriter, r *http.Request)
 	}
 	f := nextSeriesGroup(nextSeriess, nil)
 	jsonp := r.FormValue("jsonp")
-	contentType := getContentType(jsonp)
-	w.Header().Set("Content-Type", contentType)
+	if format == "json" {
+		w.Header().Set("Content-Type", getContentType(jsonp))
+		bw := bufferedwriter.Get(w)
+		defer bufferedwriter.Put(bw)
+		WriteRenderJSONResponse(bw, f, jsonp)
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		renderDuration.UpdateDuration(startTime)
+		return nil
+	}
+
+	ss, err := fetchAllSeries(f)
+	if err != nil {
+		return err
+	}
 	bw := bufferedwriter.Get(w)
 	defer bufferedwriter.Put(bw)
-	WriteRenderJSONResponse(bw, f, jsonp)
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		WriteRenderCSVResponse(bw, ss, loc)
+	case "raw":
+		w.Header().Set("Content-Type", "text/plain")
+		WriteRenderRawResponse(bw, ss)
+	case "dygraph":
+		w.Header().Set("Content-Type", getContentType(jsonp))
+		WriteRenderDygraphResponse(bw, ss, jsonp)
+	case "rickshaw":
+		w.Header().Set("Content-Type", getContentType(jsonp))
+		WriteRenderRickshawResponse(bw, ss, jsonp)
+	case "pickle", "msgpack":
+		sis := make([]*seriesInfo, len(ss))
+		for i, s := range ss {
+			sis[i] = newSeriesInfo(s, xFilesFactor)
+		}
+		if format == "pickle" {
+			w.Header().Set("Content-Type", "application/pickle")
+			_, _ = bw.Write(marshalPickle(nil, sis))
+		} else {
+			w.Header().Set("Content-Type", "application/x-msgpack")
+			_, _ = bw.Write(marshalMsgpack(nil, sis))
+		}
+	}
 	if err := bw.Flush(); err != nil {
 		return err
 	}
@@ -0,0 +1,356 @@
+package graphite
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// getSeriesTimeRange returns start, end and step in seconds for s in the same way as graphite-web does.
+//
+// end is exclusive, i.e. it equals to start + len(s.Values)*step.
+func getSeriesTimeRange(s *series) (int64, int64, int64) {
+	step := s.step / 1e3
+	if step <= 0 && len(s.Timestamps) > 1 {
+		step = (s.Timestamps[1] - s.Timestamps[0]) / 1e3
+	}
+	if step <= 0 {
+		step = 1
+	}
+	start := int64(0)
+	if len(s.Timestamps) > 0 {
+		start = s.Timestamps[0] / 1e3
+	}
+	end := start + int64(len(s.Values))*step
+	return start, end, step
+}
+
+// appendPythonFloat appends v to dst in the format used by Python's repr(float).
+func appendPythonFloat(dst []byte, v float64) []byte {
+	switch {
+	case math.IsNaN(v):
+		return append(dst, "nan"...)
+	case math.IsInf(v, 1):
+		return append(dst, "inf"...)
+	case math.IsInf(v, -1):
+		return append(dst, "-inf"...)
+	}
+	s := strconv.FormatFloat(v, 'e', -1, 64)
+	n := strings.IndexByte(s, 'e')
+	exp, _ := strconv.Atoi(s[n+1:])
+	if exp < -4 || exp >= 16 {
+		// Python uses scientific notation for such numbers, e.g. 1e+16 or 1.5e-05
+		return append(dst, s...)
+	}
+	dstLen := len(dst)
+	dst = strconv.AppendFloat(dst, v, 'f', -1, 64)
+	if strings.IndexByte(string(dst[dstLen:]), '.') < 0 {
+		dst = append(dst, ".0"...)
+	}
+	return dst
+}
+
+// pythonFloat returns Python's repr(v) for non-NaN v and None for NaN v.
+func pythonFloat(v float64) string {
+	if math.IsNaN(v) {
+		return "None"
+	}
+	return string(appendPythonFloat(nil, v))
+}
+
+// jsonPythonFloat returns v in the format used by json.dumps() in Python.
+//
+// NaN is returned as null, since it represents a missing value in Graphite.
+func jsonPythonFloat(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "null"
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	}
+	return string(appendPythonFloat(nil, v))
+}
+
+// jsonPythonString returns s in the format used by json.dumps() in Python, i.e. with escaped non-ASCII chars.
+func jsonPythonString(s string) string {
+	dst := make([]byte, 0, len(s)+2)
+	dst = append(dst, '"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			dst = append(dst, `\"`...)
+		case '\\':
+			dst = append(dst, `\\`...)
+		case '\n':
+			dst = append(dst, `\n`...)
+		case '\r':
+			dst = append(dst, `\r`...)
+		case '\t':
+			dst = append(dst, `\t`...)
+		case '\b':
+			dst = append(dst, `\b`...)
+		case '\f':
+			dst = append(dst, `\f`...)
+		default:
+			if r >= 0x20 && r < 0x7f {
+				dst = append(dst, byte(r))
+				continue
+			}
+			if r >= 0x10000 {
+				// Encode the char as UTF-16 surrogate pair like Python does.
+				r -= 0x10000
+				dst = appendJSONUnicodeEscape(dst, 0xd800+(r>>10)&0x3ff)
+				dst = appendJSONUnicodeEscape(dst, 0xdc00+r&0x3ff)
+				continue
+			}
+			dst = appendJSONUnicodeEscape(dst, r)
+		}
+	}
+	dst = append(dst, '"')
+	return string(dst)
+}
+
+func appendJSONUnicodeEscape(dst []byte, r rune) []byte {
+	const hex = "0123456789abcdef"
+	return append(dst, '\\', 'u', hex[(r>>12)&0xf], hex[(r>>8)&0xf], hex[(r>>4)&0xf], hex[r&0xf])
+}
+
+// csvQuote quotes s in the same way as csv.writer from Python does for the default excel dialect.
+func csvQuote(s string) string {
+	if !strings.ContainsAny(s, ",\"\r\n") {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// seriesInfo contains the same fields as TimeSeries.getInfo() in graphite-web.
+//
+// It is used for pickle and msgpack responses.
+type seriesInfo struct {
+	name              string
+	start             int64
+	end               int64
+	step              int64
+	values            []float64
+	pathExpression    string
+	valuesPerPoint    int64
+	consolidationFunc string
+	xFilesFactor      float64
+}
+
+func newSeriesInfo(s *series, xFilesFactor float64) *seriesInfo {
+	start, end, step := getSeriesTimeRange(s)
+	if s.xFilesFactor > 0 {
+		xFilesFactor = s.xFilesFactor
+	}
+	pathExpression := s.pathExpression
+	if pathExpression == "" {
+		pathExpression = s.Name
+	}
+	return &seriesInfo{
+		name:              s.Name,
+		start:             start,
+		end:               end,
+		step:              step,
+		values:            s.Values,
+		pathExpression:    pathExpression,
+		valuesPerPoint:    1,
+		consolidationFunc: "average",
+		xFilesFactor:      xFilesFactor,
+	}
+}
+
+// marshalPickle marshals sis into pickle protocol 2, which can be loaded by both Python 2 and Python 3.
+//
+// The result is compatible with format=pickle response from graphite-web.
+func marshalPickle(dst []byte, sis []*seriesInfo) []byte {
+	dst = append(dst, 0x80, 2)  // PROTO 2
+	dst = append(dst, ']', '(') // EMPTY_LIST, MARK
+	for _, si := range sis {
+		dst = append(dst, '}', '(') // EMPTY_DICT, MARK
+		dst = appendPickleString(dst, "name")
+		dst = appendPickleString(dst, si.name)
+		dst = appendPickleString(dst, "start")
+		dst = appendPickleInt(dst, si.start)
+		dst = appendPickleString(dst, "end")
+		dst = appendPickleInt(dst, si.end)
+		dst = appendPickleString(dst, "step")
+		dst = appendPickleInt(dst, si.step)
+		dst = appendPickleString(dst, "values")
+		dst = append(dst, ']', '(') // EMPTY_LIST, MARK
+		for _, v := range si.values {
+			if math.IsNaN(v) {
+				dst = append(dst, 'N') // NONE
+			} else {
+				dst = appendPickleFloat(dst, v)
+			}
+		}
+		dst = append(dst, 'e') // APPENDS
+		dst = appendPickleString(dst, "pathExpression")
+		dst = appendPickleString(dst, si.pathExpression)
+		dst = appendPickleString(dst, "valuesPerPoint")
+		dst = appendPickleInt(dst, si.valuesPerPoint)
+		dst = appendPickleString(dst, "consolidationFunc")
+		dst = appendPickleString(dst, si.consolidationFunc)
+		dst = appendPickleString(dst, "xFilesFactor")
+		dst = appendPickleXFilesFactor(dst, si.xFilesFactor)
+		dst = append(dst, 'u') // SETITEMS
+	}
+	dst = append(dst, 'e', '.') // APPENDS, STOP
+	return dst
+}
+
+func appendPickleString(dst []byte, s string) []byte {
+	dst = append(dst, 'X') // BINUNICODE
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(s)))
+	return append(dst, s...)
+}
+
+func appendPickleInt(dst []byte, n int64) []byte {
+	if n >= math.MinInt32 && n <= math.MaxInt32 {
+		dst = append(dst, 'J') // BININT
+		return binary.LittleEndian.AppendUint32(dst, uint32(int32(n)))
+	}
+	dst = append(dst, 0x8a, 8) // LONG1 with 8 bytes
+	return binary.LittleEndian.AppendUint64(dst, uint64(n))
+}
+
+func appendPickleFloat(dst []byte, v float64) []byte {
+	dst = append(dst, 'G') // BINFLOAT
+	return binary.BigEndian.AppendUint64(dst, math.Float64bits(v))
+}
+
+func appendPickleXFilesFactor(dst []byte, xFilesFactor float64) []byte {
+	if xFilesFactor == 0 {
+		// graphite-web uses integer 0 as the default xFilesFactor.
+		return appendPickleInt(dst, 0)
+	}
+	return appendPickleFloat(dst, xFilesFactor)
+}
+
+// marshalMsgpack marshals sis into msgpack in the same way as msgpack.dump(use_bin_type=True) from Python does.
+//
+// The result is compatible with format=msgpack response from graphite-web.
+func marshalMsgpack(dst []byte, sis []*seriesInfo) []byte {
+	dst = appendMsgpackArrayHeader(dst, len(sis))
+	for _, si := range sis {
+		dst = appendMsgpackMapHeader(dst, 9)
+		dst = appendMsgpackString(dst, "name")
+		dst = appendMsgpackString(dst, si.name)
+		dst = appendMsgpackString(dst, "start")
+		dst = appendMsgpackInt(dst, si.start)
+		dst = appendMsgpackString(dst, "end")
+		dst = appendMsgpackInt(dst, si.end)
+		dst = appendMsgpackString(dst, "step")
+		dst = appendMsgpackInt(dst, si.step)
+		dst = appendMsgpackString(dst, "values")
+		dst = appendMsgpackArrayHeader(dst, len(si.values))
+		for _, v := range si.values {
+			if math.IsNaN(v) {
+				dst = append(dst, 0xc0) // nil
+			} else {
+				dst = appendMsgpackFloat(dst, v)
+			}
+		}
+		dst = appendMsgpackString(dst, "pathExpression")
+		dst = appendMsgpackString(dst, si.pathExpression)
+		dst = appendMsgpackString(dst, "valuesPerPoint")
+		dst = appendMsgpackInt(dst, si.valuesPerPoint)
+		dst = appendMsgpackString(dst, "consolidationFunc")
+		dst = appendMsgpackString(dst, si.consolidationFunc)
+		dst = appendMsgpackString(dst, "xFilesFactor")
+		if si.xFilesFactor == 0 {
+			// graphite-web uses integer 0 as the default xFilesFactor.
+			dst = appendMsgpackInt(dst, 0)
+		} else {
+			dst = appendMsgpackFloat(dst, si.xFilesFactor)
+		}
+	}
+	return dst
+}
+
+func appendMsgpackArrayHeader(dst []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(dst, 0x90|byte(n))
+	case n < 1<<16:
+		dst = append(dst, 0xdc)
+		return binary.BigEndian.AppendUint16(dst, uint16(n))
+	default:
+		dst = append(dst, 0xdd)
+		return binary.BigEndian.AppendUint32(dst, uint32(n))
+	}
+}
+
+func appendMsgpackMapHeader(dst []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(dst, 0x80|byte(n))
+	case n < 1<<16:
+		dst = append(dst, 0xde)
+		return binary.BigEndian.AppendUint16(dst, uint16(n))
+	default:
+		dst = append(dst, 0xdf)
+		return binary.BigEndian.AppendUint32(dst, uint32(n))
+	}
+}
+
+func appendMsgpackString(dst []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		dst = append(dst, 0xa0|byte(n))
+	case n < 1<<8:
+		dst = append(dst, 0xd9, byte(n))
+	case n < 1<<16:
+		dst = append(dst, 0xda)
+		dst = binary.BigEndian.AppendUint16(dst, uint16(n))
+	default:
+		dst = append(dst, 0xdb)
+		dst = binary.BigEndian.AppendUint32(dst, uint32(n))
+	}
+	return append(dst, s...)
+}
+
+func appendMsgpackInt(dst []byte, n int64) []byte {
+	if n >= 0 {
+		switch {
+		case n < 128:
+			return append(dst, byte(n))
+		case n < 1<<8:
+			return append(dst, 0xcc, byte(n))
+		case n < 1<<16:
+			dst = append(dst, 0xcd)
+			return binary.BigEndian.AppendUint16(dst, uint16(n))
+		case n < 1<<32:
+			dst = append(dst, 0xce)
+			return binary.BigEndian.AppendUint32(dst, uint32(n))
+		default:
+			dst = append(dst, 0xcf)
+			return binary.BigEndian.AppendUint64(dst, uint64(n))
+		}
+	}
+	switch {
+	case n >= -32:
+		return append(dst, byte(n))
+	case n >= math.MinInt8:
+		return append(dst, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		dst = append(dst, 0xd1)
+		return binary.BigEndian.AppendUint16(dst, uint16(n))
+	case n >= math.MinInt32:
+		dst = append(dst, 0xd2)
+		return binary.BigEndian.AppendUint32(dst, uint32(n))
+	default:
+		dst = append(dst, 0xd3)
+		return binary.BigEndian.AppendUint64(dst, uint64(n))
+	}
+}
+
+func appendMsgpackFloat(dst []byte, v float64) []byte {
+	dst = append(dst, 0xcb)
+	return binary.BigEndian.AppendUint64(dst, math.Float64bits(v))
+}
@@ -0,0 +1,199 @@
+package graphite
+
+import (
+	"encoding/hex"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAppendPythonFloat(t *testing.T) {
+	f := func(v float64, resultExpected string) {
+		t.Helper()
+		result := string(appendPythonFloat(nil, v))
+		if result != resultExpected {
+			t.Fatalf("unexpected result for %v; got %q; want %q", v, result, resultExpected)
+		}
+	}
+	f(0, "0.0")
+	f(math.Copysign(0, -1), "-0.0")
+	f(1, "1.0")
+	f(-12.5, "-12.5")
+	f(0.1, "0.1")
+	f(0.0001, "0.0001")
+	f(0.00001, "1e-05")
+	f(1.5e-7, "1.5e-07")
+	f(123456, "123456.0")
+	f(1e15+0.5, "1000000000000000.5")
+	f(1e16, "1e+16")
+	f(1.2345678901234568e+17, "1.2345678901234568e+17")
+	f(math.Inf(1), "inf")
+	f(math.Inf(-1), "-inf")
+	f(math.NaN(), "nan")
+}
+
+func TestJSONPythonString(t *testing.T) {
+	f := func(s, resultExpected string) {
+		t.Helper()
+		result := jsonPythonString(s)
+		if result != resultExpected {
+			t.Fatalf("unexpected result for %q; got %s; want %s", s, result, resultExpected)
+		}
+	}
+	f("", `""`)
+	f("foo.bar", `"foo.bar"`)
+	f("a\"b\\c\nd\te\x01", `"a\"b\\c\nd\te\u0001"`)
+	f("Προμηθεύς", `"\u03a0\u03c1\u03bf\u03bc\u03b7\u03b8\u03b5\u03cd\u03c2"`)
+	f("😀", `"\ud83d\ude00"`)
+}
+
+func TestCSVQuote(t *testing.T) {
+	f := func(s, resultExpected string) {
+		t.Helper()
+		result := csvQuote(s)
+		if result != resultExpected {
+			t.Fatalf("unexpected result for %q; got %s; want %s", s, result, resultExpected)
+		}
+	}
+	f("", "")
+	f("foo.bar", "foo.bar")
+	f("sumSeries(foo.bar,baz)", `"sumSeries(foo.bar,baz)"`)
+	f(`alias(foo,"a b")`, `"alias(foo,""a b"")"`)
+}
+
+func newTestSeriesForRender() []*series {
+	nan := math.NaN()
+	return []*series{
+		{
+			Name:       "foo.bar",
+			Timestamps: []int64{1600000000000, 1600000060000, 1600000120000},
+			Values:     []float64{1, nan, 2.5},
+			step:       60000,
+		},
+		{
+			Name:           "sumSeries(a.*,b)",
+			Timestamps:     []int64{1600000000000, 1600000060000, 1600000120000},
+			Values:         []float64{math.Inf(1), 0.1, 1e20},
+			pathExpression: "sumSeries(a.*,b)",
+			step:           60000,
+		},
+	}
+}
+
+func TestRenderCSVResponse(t *testing.T) {
+	result := RenderCSVResponse(newTestSeriesForRender(), time.UTC)
+	resultExpected := "foo.bar,2020-09-13 12:26:40,1.0\r\n" +
+		"foo.bar,2020-09-13 12:27:40,\r\n" +
+		"foo.bar,2020-09-13 12:28:40,2.5\r\n" +
+		"\"sumSeries(a.*,b)\",2020-09-13 12:26:40,inf\r\n" +
+		"\"sumSeries(a.*,b)\",2020-09-13 12:27:40,0.1\r\n" +
+		"\"sumSeries(a.*,b)\",2020-09-13 12:28:40,1e+20\r\n"
+	if result != resultExpected {
+		t.Fatalf("unexpected result;\ngot\n%q\nwant\n%q", result, resultExpected)
+	}
+}
+
+func TestRenderRawResponse(t *testing.T) {
+	result := RenderRawResponse(newTestSeriesForRender())
+	resultExpected := "foo.bar,1600000000,1600000180,60|1.0,None,2.5\n" +
+		"sumSeries(a.*,b),1600000000,1600000180,60|inf,0.1,1e+20\n"
+	if result != resultExpected {
+		t.Fatalf("unexpected result;\ngot\n%q\nwant\n%q", result, resultExpected)
+	}
+}
+
+func TestRenderDygraphResponse(t *testing.T) {
+	f := func(ss []*series, jsonp, resultExpected string) {
+		t.Helper()
+		result := RenderDygraphResponse(ss, jsonp)
+		if result != resultExpected {
+			t.Fatalf("unexpected result;\ngot\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+	f(nil, "", `{}`)
+	f(newTestSeriesForRender(), "", `{"labels" : ["Time", "foo.bar", "sumSeries(a.*,b)"], "data" : `+
+		`[[1600000000000, 1.0, Infinity], [1600000060000, null, 0.1], [1600000120000, 2.5, 1e+20]]}`)
+	f(newTestSeriesForRender()[:1], "cb", `cb({"labels" : ["Time", "foo.bar"], "data" : `+
+		`[[1600000000000, 1.0], [1600000060000, null], [1600000120000, 2.5]]})`)
+}
+
+func TestRenderRickshawResponse(t *testing.T) {
+	f := func(ss []*series, jsonp, resultExpected string) {
+		t.Helper()
+		result := RenderRickshawResponse(ss, jsonp)
+		if result != resultExpected {
+			t.Fatalf("unexpected result;\ngot\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+	f(nil, "", `[]`)
+	f(newTestSeriesForRender()[:1], "", `[{"target": "foo.bar", "datapoints": `+
+		`[{"x": 1600000000, "y": 1.0}, {"x": 1600000060, "y": null}, {"x": 1600000120, "y": 2.5}]}]`)
+	f(newTestSeriesForRender(), "cb", `cb([{"target": "foo.bar", "datapoints": `+
+		`[{"x": 1600000000, "y": 1.0}, {"x": 1600000060, "y": null}, {"x": 1600000120, "y": 2.5}]}, `+
+		`{"target": "sumSeries(a.*,b)", "datapoints": `+
+		`[{"x": 1600000000, "y": Infinity}, {"x": 1600000060, "y": 0.1}, {"x": 1600000120, "y": 1e+20}]}])`)
+}
+
+func TestMarshalPickle(t *testing.T) {
+	ss := newTestSeriesForRender()[:1]
+	sis := []*seriesInfo{newSeriesInfo(ss[0], 0)}
+	result := hex.EncodeToString(marshalPickle(nil, sis))
+	// The expected result can be verified with the following Python code:
+	//
+	//   pickle.loads(bytes.fromhex(resultExpected))
+	resultExpected := "8002" + "5d28" + "7d28" +
+		"58040000006e616d65" + "5807000000666f6f2e626172" +
+		"58050000007374617274" + "4a00105e5f" +
+		"5803000000656e64" + "4ab4105e5f" +
+		"580400000073746570" + "4a3c000000" +
+		"580600000076616c756573" + "5d28" + "473ff0000000000000" + "4e" + "474004000000000000" + "65" +
+		hex.EncodeToString(appendPickleString(nil, "pathExpression")) + "5807000000666f6f2e626172" +
+		hex.EncodeToString(appendPickleString(nil, "valuesPerPoint")) + "4a01000000" +
+		hex.EncodeToString(appendPickleString(nil, "consolidationFunc")) + hex.EncodeToString(appendPickleString(nil, "average")) +
+		hex.EncodeToString(appendPickleString(nil, "xFilesFactor")) + "4a00000000" +
+		"75" + "652e"
+	if result != resultExpected {
+		t.Fatalf("unexpected result;\ngot\n%s\nwant\n%s", result, resultExpected)
+	}
+}
+
+func TestMarshalMsgpack(t *testing.T) {
+	ss := newTestSeriesForRender()[:1]
+	sis := []*seriesInfo{newSeriesInfo(ss[0], 0.5)}
+	result := hex.EncodeToString(marshalMsgpack(nil, sis))
+	// The expected result can be verified with the following Python code:
+	//
+	//   msgpack.loads(bytes.fromhex(resultExpected))
+	resultExpected := "91" + "89" +
+		"a46e616d65" + "a7666f6f2e626172" +
+		"a57374617274" + "ce5f5e1000" +
+		"a3656e64" + "ce5f5e10b4" +
+		"a473746570" + "3c" +
+		"a676616c756573" + "93" + "cb3ff0000000000000" + "c0" + "cb4004000000000000" +
+		"ae7061746845787072657373696f6e" + "a7666f6f2e626172" +
+		"ae76616c756573506572506f696e74" + "01" +
+		"b1636f6e736f6c69646174696f6e46756e63" + "a761766572616765" +
+		"ac7846696c6573466163746f72" + "cb3fe0000000000000"
+	if result != resultExpected {
+		t.Fatalf("unexpected result;\ngot\n%s\nwant\n%s", result, resultExpected)
+	}
+
+	// Verify integer encoding
+	f := func(n int64, resultExpected string) {
+		t.Helper()
+		result := hex.EncodeToString(appendMsgpackInt(nil, n))
+		if result != resultExpected {
+			t.Fatalf("unexpected result for %d; got %s; want %s", n, result, resultExpected)
+		}
+	}
+	f(0, "00")
+	f(127, "7f")
+	f(128, "cc80")
+	f(65535, "cdffff")
+	f(1<<32, "cf0000000100000000")
+	f(-1, "ff")
+	f(-32, "e0")
+	f(-33, "d0df")
+	f(-129, "d1ff7f")
+	f(-(1 << 31), "d280000000")
+}
@@ -8,196 +8,551 @@ package graphite
 import (
 	"math"
 	"sort"
+	"time"
 )
 
 // RenderJSONResponse generates response for /render?format=json .See https://graphite.readthedocs.io/en/stable/render_api.html#json
 
-//line app/vmselect/graphite/render_response.qtpl:10
+//line app/vmselect/graphite/render_response.qtpl:11
 import (
 	qtio422016 "io"
 
 	qt422016 "github.com/valyala/quicktemplate"
 )
 
-//line app/vmselect/graphite/render_response.qtpl:10
+//line app/vmselect/graphite/render_response.qtpl:11
 var (
 	_ = qtio422016.Copy
 	_ = qt422016.AcquireByteBuffer
 )
 
-//line app/vmselect/graphite/render_response.qtpl:10
-func StreamRenderJSONResponse(qw422016 *qt422016.Writer, nextSeries nextSeriesFunc, jsonp string) {
 //line app/vmselect/graphite/render_response.qtpl:11
+func StreamRenderJSONResponse(qw422016 *qt422016.Writer, nextSeries nextSeriesFunc, jsonp string) {
+//line app/vmselect/graphite/render_response.qtpl:12
 	if jsonp != "" {
-//line app/vmselect/graphite/render_response.qtpl:11
+//line app/vmselect/graphite/render_response.qtpl:12
 		qw422016.N().S(jsonp)
-//line app/vmselect/graphite/render_response.qtpl:11
+//line app/vmselect/graphite/render_response.qtpl:12
 		qw422016.N().S(`(`)
-//line app/vmselect/graphite/render_response.qtpl:11
-	}
 //line app/vmselect/graphite/render_response.qtpl:12
+	}
+//line app/vmselect/graphite/render_response.qtpl:13
 	ss, err := fetchAllSeries(nextSeries)
 
-//line app/vmselect/graphite/render_response.qtpl:13
+//line app/vmselect/graphite/render_response.qtpl:14
 	if err != nil {
-//line app/vmselect/graphite/render_response.qtpl:13
+//line app/vmselect/graphite/render_response.qtpl:14
 		qw422016.N().S(`{"error":`)
-//line app/vmselect/graphite/render_response.qtpl:15
+//line app/vmselect/graphite/render_response.qtpl:16
 		qw422016.N().Q(err.Error())
-//line app/vmselect/graphite/render_response.qtpl:15
+//line app/vmselect/graphite/render_response.qtpl:16
 		qw422016.N().S(`}`)
-//line app/vmselect/graphite/render_response.qtpl:17
-		return
 //line app/vmselect/graphite/render_response.qtpl:18
-	}
+		return
 //line app/vmselect/graphite/render_response.qtpl:19
+	}
+//line app/vmselect/graphite/render_response.qtpl:20
 	sort.Slice(ss, func(i, j int) bool { return ss[i].Name < ss[j].Name })
 
-//line app/vmselect/graphite/render_response.qtpl:19
+//line app/vmselect/graphite/render_response.qtpl:20
 	qw422016.N().S(`[`)
-//line app/vmselect/graphite/render_response.qtpl:21
-	for i, s := range ss {
 //line app/vmselect/graphite/render_response.qtpl:22
-		streamrenderSeriesJSON(qw422016, s)
+	for i, s := range ss {
 //line app/vmselect/graphite/render_response.qtpl:23
+		streamrenderSeriesJSON(qw422016, s)
+//line app/vmselect/graphite/render_response.qtpl:24
 		if i+1 < len(ss) {
-//line app/vmselect/graphite/render_response.qtpl:23
+//line app/vmselect/graphite/render_response.qtpl:24
 			qw422016.N().S(`,`)
-//line app/vmselect/graphite/render_response.qtpl:23
-		}
 //line app/vmselect/graphite/render_response.qtpl:24
+		}
+//line app/vmselect/graphite/render_response.qtpl:25
 	}
-//line app/vmselect/graphite/render_response.qtpl:24
+//line app/vmselect/graphite/render_response.qtpl:25
 	qw422016.N().S(`]`)
-//line app/vmselect/graphite/render_response.qtpl:26
+//line app/vmselect/graphite/render_response.qtpl:27
 	if jsonp != "" {
-//line app/vmselect/graphite/render_response.qtpl:26
+//line app/vmselect/graphite/render_response.qtpl:27
 		qw422016.N().S(`)`)
-//line app/vmselect/graphite/render_response.qtpl:26
-	}
 //line app/vmselect/graphite/render_response.qtpl:27
+	}
+//line app/vmselect/graphite/render_response.qtpl:28
 }
 
-//line app/vmselect/graphite/render_response.qtpl:27
+//line app/vmselect/graphite/render_response.qtpl:28
 func WriteRenderJSONResponse(qq422016 qtio422016.Writer, nextSeries nextSeriesFunc, jsonp string) {
-//line app/vmselect/graphite/render_response.qtpl:27
+//line app/vmselect/graphite/render_response.qtpl:28
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vmselect/graphite/render_response.qtpl:27
+//line app/vmselect/graphite/render_response.qtpl:28
 	StreamRenderJSONResponse(qw422016, nextSeries, jsonp)
-//line app/vmselect/graphite/render_response.qtpl:27
+//line app/vmselect/graphite/render_response.qtpl:28
 	qt422016.ReleaseWriter(qw422016)
-//line app/vmselect/graphite/render_response.qtpl:27
+//line app/vmselect/graphite/render_response.qtpl:28
 }
 
-//line app/vmselect/graphite/render_response.qtpl:27
+//line app/vmselect/graphite/render_response.qtpl:28
 func RenderJSONResponse(nextSeries nextSeriesFunc, jsonp string) string {
-//line app/vmselect/graphite/render_response.qtpl:27
+//line app/vmselect/graphite/render_response.qtpl:28
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vmselect/graphite/render_response.qtpl:27
+//line app/vmselect/graphite/render_response.qtpl:28
 	WriteRenderJSONResponse(qb422016, nextSeries, jsonp)
-//line app/vmselect/graphite/render_response.qtpl:27
+//line app/vmselect/graphite/render_response.qtpl:28
 	qs422016 := string(qb422016.B)
-//line app/vmselect/graphite/render_response.qtpl:27
+//line app/vmselect/graphite/render_response.qtpl:28
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vmselect/graphite/render_response.qtpl:27
+//line app/vmselect/graphite/render_response.qtpl:28
 	return qs422016
-//line app/vmselect/graphite/render_response.qtpl:27
+//line app/vmselect/graphite/render_response.qtpl:28
 }
 
-//line app/vmselect/graphite/render_response.qtpl:29
+//line app/vmselect/graphite/render_response.qtpl:30
 func streamrenderSeriesJSON(qw422016 *qt422016.Writer, s *series) {
-//line app/vmselect/graphite/render_response.qtpl:29
+//line app/vmselect/graphite/render_response.qtpl:30
 	qw422016.N().S(`{"target":`)
-//line app/vmselect/graphite/render_response.qtpl:31
+//line app/vmselect/graphite/render_response.qtpl:32
 	qw422016.N().Q(s.Name)
-//line app/vmselect/graphite/render_response.qtpl:31
+//line app/vmselect/graphite/render_response.qtpl:32
 	qw422016.N().S(`,"tags":{`)
-//line app/vmselect/graphite/render_response.qtpl:34
+//line app/vmselect/graphite/render_response.qtpl:35
 	tagKeys := make([]string, 0, len(s.Tags))
 	for k := range s.Tags {
 		tagKeys = append(tagKeys, k)
 	}
 	sort.Strings(tagKeys)
 
-//line app/vmselect/graphite/render_response.qtpl:40
-	for i, k := range tagKeys {
 //line app/vmselect/graphite/render_response.qtpl:41
+	for i, k := range tagKeys {
+//line app/vmselect/graphite/render_response.qtpl:42
 		v := s.Tags[k]
 
-//line app/vmselect/graphite/render_response.qtpl:42
+//line app/vmselect/graphite/render_response.qtpl:43
 		qw422016.N().Q(k)
-//line app/vmselect/graphite/render_response.qtpl:42
+//line app/vmselect/graphite/render_response.qtpl:43
 		qw422016.N().S(`:`)
-//line app/vmselect/graphite/render_response.qtpl:42
-		qw422016.N().Q(v)
 //line app/vmselect/graphite/render_response.qtpl:43
+		qw422016.N().Q(v)
+//line app/vmselect/graphite/render_response.qtpl:44
 		if i+1 < len(tagKeys) {
-//line app/vmselect/graphite/render_response.qtpl:43
+//line app/vmselect/graphite/render_response.qtpl:44
 			qw422016.N().S(`,`)
-//line app/vmselect/graphite/render_response.qtpl:43
-		}
 //line app/vmselect/graphite/render_response.qtpl:44
+		}
+//line app/vmselect/graphite/render_response.qtpl:45
 	}
-//line app/vmselect/graphite/render_response.qtpl:44
+//line app/vmselect/graphite/render_response.qtpl:45
 	qw422016.N().S(`},"datapoints":[`)
-//line app/vmselect/graphite/render_response.qtpl:47
+//line app/vmselect/graphite/render_response.qtpl:48
 	timestamps := s.Timestamps
 
-//line app/vmselect/graphite/render_response.qtpl:48
+//line app/vmselect/graphite/render_response.qtpl:49
 	for i, v := range s.Values {
-//line app/vmselect/graphite/render_response.qtpl:48
+//line app/vmselect/graphite/render_response.qtpl:49
 		qw422016.N().S(`[`)
-//line app/vmselect/graphite/render_response.qtpl:50
+//line app/vmselect/graphite/render_response.qtpl:51
 		if math.IsNaN(v) {
-//line app/vmselect/graphite/render_response.qtpl:50
+//line app/vmselect/graphite/render_response.qtpl:51
 			qw422016.N().S(`null`)
-//line app/vmselect/graphite/render_response.qtpl:50
+//line app/vmselect/graphite/render_response.qtpl:51
 		} else {
-//line app/vmselect/graphite/render_response.qtpl:50
+//line app/vmselect/graphite/render_response.qtpl:51
 			qw422016.N().F(v)
-//line app/vmselect/graphite/render_response.qtpl:50
+//line app/vmselect/graphite/render_response.qtpl:51
 		}
-//line app/vmselect/graphite/render_response.qtpl:50
-		qw422016.N().S(`,`)
 //line app/vmselect/graphite/render_response.qtpl:51
+		qw422016.N().S(`,`)
+//line app/vmselect/graphite/render_response.qtpl:52
 		qw422016.N().DL(timestamps[i] / 1e3)
-//line app/vmselect/graphite/render_response.qtpl:51
+//line app/vmselect/graphite/render_response.qtpl:52
 		qw422016.N().S(`]`)
-//line app/vmselect/graphite/render_response.qtpl:53
+//line app/vmselect/graphite/render_response.qtpl:54
 		if i+1 < len(timestamps) {
-//line app/vmselect/graphite/render_response.qtpl:53
+//line app/vmselect/graphite/render_response.qtpl:54
 			qw422016.N().S(`,`)
-//line app/vmselect/graphite/render_response.qtpl:53
-		}
 //line app/vmselect/graphite/render_response.qtpl:54
+		}
+//line app/vmselect/graphite/render_response.qtpl:55
 	}
-//line app/vmselect/graphite/render_response.qtpl:54
+//line app/vmselect/graphite/render_response.qtpl:55
 	qw422016.N().S(`]}`)
-//line app/vmselect/graphite/render_response.qtpl:57
+//line app/vmselect/graphite/render_response.qtpl:58
 }
 
-//line app/vmselect/graphite/render_response.qtpl:57
+//line app/vmselect/graphite/render_response.qtpl:58
 func writerenderSeriesJSON(qq422016 qtio422016.Writer, s *series) {
-//line app/vmselect/graphite/render_response.qtpl:57
+//line app/vmselect/graphite/render_response.qtpl:58
 	qw422016 := qt422016.AcquireWriter(qq422016)
-//line app/vmselect/graphite/render_response.qtpl:57
+//line app/vmselect/graphite/render_response.qtpl:58
 	streamrenderSeriesJSON(qw422016, s)
-//line app/vmselect/graphite/render_response.qtpl:57
+//line app/vmselect/graphite/render_response.qtpl:58
 	qt422016.ReleaseWriter(qw422016)
-//line app/vmselect/graphite/render_response.qtpl:57
+//line app/vmselect/graphite/render_response.qtpl:58
 }
 
-//line app/vmselect/graphite/render_response.qtpl:57
+//line app/vmselect/graphite/render_response.qtpl:58
 func renderSeriesJSON(s *series) string {
-//line app/vmselect/graphite/render_response.qtpl:57
+//line app/vmselect/graphite/render_response.qtpl:58
 	qb422016 := qt422016.AcquireByteBuffer()
-//line app/vmselect/graphite/render_response.qtpl:57
+//line app/vmselect/graphite/render_response.qtpl:58
 	writerenderSeriesJSON(qb422016, s)
-//line app/vmselect/graphite/render_response.qtpl:57
+//line app/vmselect/graphite/render_response.qtpl:58
+	qs422016 := string(qb422016.B)
+//line app/vmselect/graphite/render_response.qtpl:58
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vmselect/graphite/render_response.qtpl:58
+	return qs422016
+//line app/vmselect/graphite/render_response.qtpl:58
+}
+
+// RenderCSVResponse generates response for /render?format=csv .The response is compatible with graphite-web.
+
+//line app/vmselect/graphite/render_response.qtpl:62
+func StreamRenderCSVResponse(qw422016 *qt422016.Writer, ss []*series, loc *time.Location) {
+//line app/vmselect/graphite/render_response.qtpl:63
+	for _, s := range ss {
+//line app/vmselect/graphite/render_response.qtpl:65
+		name := csvQuote(s.Name)
+		start, _, step := getSeriesTimeRange(s)
+
+//line app/vmselect/graphite/render_response.qtpl:68
+		for i, v := range s.Values {
+//line app/vmselect/graphite/render_response.qtpl:69
+			qw422016.N().S(name)
+//line app/vmselect/graphite/render_response.qtpl:69
+			qw422016.N().S(`,`)
+//line app/vmselect/graphite/render_response.qtpl:69
+			qw422016.N().S(time.Unix(start+int64(i)*step, 0).In(loc).Format("2006-01-02 15:04:05"))
+//line app/vmselect/graphite/render_response.qtpl:69
+			qw422016.N().S(`,`)
+//line app/vmselect/graphite/render_response.qtpl:70
+			if !math.IsNaN(v) {
+//line app/vmselect/graphite/render_response.qtpl:70
+				qw422016.N().Z(appendPythonFloat(nil, v))
+//line app/vmselect/graphite/render_response.qtpl:70
+			}
+//line app/vmselect/graphite/render_response.qtpl:71
+			qw422016.N().S("\r\n")
+//line app/vmselect/graphite/render_response.qtpl:72
+		}
+//line app/vmselect/graphite/render_response.qtpl:73
+	}
+//line app/vmselect/graphite/render_response.qtpl:74
+}
+
+//line app/vmselect/graphite/render_response.qtpl:74
+func WriteRenderCSVResponse(qq422016 qtio422016.Writer, ss []*series, loc *time.Location) {
+//line app/vmselect/graphite/render_response.qtpl:74
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vmselect/graphite/render_response.qtpl:74
+	StreamRenderCSVResponse(qw422016, ss, loc)
+//line app/vmselect/graphite/render_response.qtpl:74
+	qt422016.ReleaseWriter(qw422016)
+//line app/vmselect/graphite/render_response.qtpl:74
+}
+
+//line app/vmselect/graphite/render_response.qtpl:74
+func RenderCSVResponse(ss []*series, loc *time.Location) string {
+//line app/vmselect/graphite/render_response.qtpl:74
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vmselect/graphite/render_response.qtpl:74
+	WriteRenderCSVResponse(qb422016, ss, loc)
+//line app/vmselect/graphite/render_response.qtpl:74
+	qs422016 := string(qb422016.B)
+//line app/vmselect/graphite/render_response.qtpl:74
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vmselect/graphite/render_response.qtpl:74
+	return qs422016
+//line app/vmselect/graphite/render_response.qtpl:74
+}
+
+// RenderRawResponse generates response for /render?format=raw .The response is compatible with graphite-web.
+
+//line app/vmselect/graphite/render_response.qtpl:78
+func StreamRenderRawResponse(qw422016 *qt422016.Writer, ss []*series) {
+//line app/vmselect/graphite/render_response.qtpl:79
+	for _, s := range ss {
+//line app/vmselect/graphite/render_response.qtpl:80
+		start, end, step := getSeriesTimeRange(s)
+
+//line app/vmselect/graphite/render_response.qtpl:81
+		qw422016.N().S(s.Name)
+//line app/vmselect/graphite/render_response.qtpl:81
+		qw422016.N().S(`,`)
+//line app/vmselect/graphite/render_response.qtpl:81
+		qw422016.N().DL(start)
+//line app/vmselect/graphite/render_response.qtpl:81
+		qw422016.N().S(`,`)
+//line app/vmselect/graphite/render_response.qtpl:81
+		qw422016.N().DL(end)
+//line app/vmselect/graphite/render_response.qtpl:81
+		qw422016.N().S(`,`)
+//line app/vmselect/graphite/render_response.qtpl:81
+		qw422016.N().DL(step)
+//line app/vmselect/graphite/render_response.qtpl:81
+		qw422016.N().S(`|`)
+//line app/vmselect/graphite/render_response.qtpl:82
+		for i, v := range s.Values {
+//line app/vmselect/graphite/render_response.qtpl:83
+			qw422016.N().S(pythonFloat(v))
+//line app/vmselect/graphite/render_response.qtpl:84
+			if i+1 < len(s.Values) {
+//line app/vmselect/graphite/render_response.qtpl:84
+				qw422016.N().S(`,`)
+//line app/vmselect/graphite/render_response.qtpl:84
+			}
+//line app/vmselect/graphite/render_response.qtpl:85
+		}
+//line app/vmselect/graphite/render_response.qtpl:86
+		qw422016.N().S(`
+`)
+//line app/vmselect/graphite/render_response.qtpl:87
+	}
+//line app/vmselect/graphite/render_response.qtpl:88
+}
+
+//line app/vmselect/graphite/render_response.qtpl:88
+func WriteRenderRawResponse(qq422016 qtio422016.Writer, ss []*series) {
+//line app/vmselect/graphite/render_response.qtpl:88
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vmselect/graphite/render_response.qtpl:88
+	StreamRenderRawResponse(qw422016, ss)
+//line app/vmselect/graphite/render_response.qtpl:88
+	qt422016.ReleaseWriter(qw422016)
+//line app/vmselect/graphite/render_response.qtpl:88
+}
+
+//line app/vmselect/graphite/render_response.qtpl:88
+func RenderRawResponse(ss []*series) string {
+//line app/vmselect/graphite/render_response.qtpl:88
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vmselect/graphite/render_response.qtpl:88
+	WriteRenderRawResponse(qb422016, ss)
+//line app/vmselect/graphite/render_response.qtpl:88
+	qs422016 := string(qb422016.B)
+//line app/vmselect/graphite/render_response.qtpl:88
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vmselect/graphite/render_response.qtpl:88
+	return qs422016
+//line app/vmselect/graphite/render_response.qtpl:88
+}
+
+// RenderDygraphResponse generates response for /render?format=dygraph .The response is compatible with graphite-web.
+
+//line app/vmselect/graphite/render_response.qtpl:92
+func StreamRenderDygraphResponse(qw422016 *qt422016.Writer, ss []*series, jsonp string) {
+//line app/vmselect/graphite/render_response.qtpl:93
+	if jsonp != "" {
+//line app/vmselect/graphite/render_response.qtpl:93
+		qw422016.N().S(jsonp)
+//line app/vmselect/graphite/render_response.qtpl:93
+		qw422016.N().S(`(`)
+//line app/vmselect/graphite/render_response.qtpl:93
+	}
+//line app/vmselect/graphite/render_response.qtpl:94
+	if len(ss) == 0 {
+//line app/vmselect/graphite/render_response.qtpl:94
+		qw422016.N().S(`{}`)
+//line app/vmselect/graphite/render_response.qtpl:96
+	} else {
+//line app/vmselect/graphite/render_response.qtpl:97
+		start, _, step := getSeriesTimeRange(ss[0])
+
+//line app/vmselect/graphite/render_response.qtpl:97
+		qw422016.N().S(`{"labels" :`)
+//line app/vmselect/graphite/render_response.qtpl:98
+		qw422016.N().S(` `)
+//line app/vmselect/graphite/render_response.qtpl:98
+		qw422016.N().S(`["Time"`)
+//line app/vmselect/graphite/render_response.qtpl:99
+		for _, s := range ss {
+//line app/vmselect/graphite/render_response.qtpl:99
+			qw422016.N().S(`,`)
+//line app/vmselect/graphite/render_response.qtpl:100
+			qw422016.N().S(` `)
+//line app/vmselect/graphite/render_response.qtpl:100
+			qw422016.N().S(jsonPythonString(s.Name))
+//line app/vmselect/graphite/render_response.qtpl:101
+		}
+//line app/vmselect/graphite/render_response.qtpl:101
+		qw422016.N().S(`], "data" :`)
+//line app/vmselect/graphite/render_response.qtpl:102
+		qw422016.N().S(` `)
+//line app/vmselect/graphite/render_response.qtpl:102
+		qw422016.N().S(`[`)
+//line app/vmselect/graphite/render_response.qtpl:103
+		for i := range ss[0].Values {
+//line app/vmselect/graphite/render_response.qtpl:103
+			qw422016.N().S(`[`)
+//line app/vmselect/graphite/render_response.qtpl:104
+			qw422016.N().DL(start + int64(i)*step)
+//line app/vmselect/graphite/render_response.qtpl:104
+			qw422016.N().S(`000`)
+//line app/vmselect/graphite/render_response.qtpl:105
+			for _, s := range ss {
+//line app/vmselect/graphite/render_response.qtpl:106
+				if i < len(s.Values) {
+//line app/vmselect/graphite/render_response.qtpl:106
+					qw422016.N().S(`,`)
+//line app/vmselect/graphite/render_response.qtpl:106
+					qw422016.N().S(` `)
+//line app/vmselect/graphite/render_response.qtpl:106
+					qw422016.N().S(jsonPythonFloat(s.Values[i]))
+//line app/vmselect/graphite/render_response.qtpl:106
+				}
+//line app/vmselect/graphite/render_response.qtpl:107
+			}
+//line app/vmselect/graphite/render_response.qtpl:107
+			qw422016.N().S(`]`)
+//line app/vmselect/graphite/render_response.qtpl:109
+			if i+1 < len(ss[0].Values) {
+//line app/vmselect/graphite/render_response.qtpl:109
+				qw422016.N().S(`,`)
+//line app/vmselect/graphite/render_response.qtpl:109
+				qw422016.N().S(` `)
+//line app/vmselect/graphite/render_response.qtpl:109
+			}
+//line app/vmselect/graphite/render_response.qtpl:110
+		}
+//line app/vmselect/graphite/render_response.qtpl:110
+		qw422016.N().S(`]}`)
+//line app/vmselect/graphite/render_response.qtpl:112
+	}
+//line app/vmselect/graphite/render_response.qtpl:113
+	if jsonp != "" {
+//line app/vmselect/graphite/render_response.qtpl:113
+		qw422016.N().S(`)`)
+//line app/vmselect/graphite/render_response.qtpl:113
+	}
+//line app/vmselect/graphite/render_response.qtpl:114
+}
+
+//line app/vmselect/graphite/render_response.qtpl:114
+func WriteRenderDygraphResponse(qq422016 qtio422016.Writer, ss []*series, jsonp string) {
+//line app/vmselect/graphite/render_response.qtpl:114
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vmselect/graphite/render_response.qtpl:114
+	StreamRenderDygraphResponse(qw422016, ss, jsonp)
+//line app/vmselect/graphite/render_response.qtpl:114
+	qt422016.ReleaseWriter(qw422016)
+//line app/vmselect/graphite/render_response.qtpl:114
+}
+
+//line app/vmselect/graphite/render_response.qtpl:114
+func RenderDygraphResponse(ss []*series, jsonp string) string {
+//line app/vmselect/graphite/render_response.qtpl:114
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vmselect/graphite/render_response.qtpl:114
+	WriteRenderDygraphResponse(qb422016, ss, jsonp)
+//line app/vmselect/graphite/render_response.qtpl:114
+	qs422016 := string(qb422016.B)
+//line app/vmselect/graphite/render_response.qtpl:114
+	qt422016.ReleaseByteBuffer(qb422016)
+//line app/vmselect/graphite/render_response.qtpl:114
+	return qs422016
+//line app/vmselect/graphite/render_response.qtpl:114
+}
+
+// RenderRickshawResponse generates response for /render?format=rickshaw .The response is compatible with graphite-web.
+
+//line app/vmselect/graphite/render_response.qtpl:118
+func StreamRenderRickshawResponse(qw422016 *qt422016.Writer, ss []*series, jsonp string) {
+//line app/vmselect/graphite/render_response.qtpl:119
+	if jsonp != "" {
+//line app/vmselect/graphite/render_response.qtpl:119
+		qw422016.N().S(jsonp)
+//line app/vmselect/graphite/render_response.qtpl:119
+		qw422016.N().S(`(`)
+//line app/vmselect/graphite/render_response.qtpl:119
+	}
+//line app/vmselect/graphite/render_response.qtpl:119
+	qw422016.N().S(`[`)
+//line app/vmselect/graphite/render_response.qtpl:121
+	for i, s := range ss {
+//line app/vmselect/graphite/render_response.qtpl:122
+		start, _, step := getSeriesTimeRange(s)
+
+//line app/vmselect/graphite/render_response.qtpl:122
+		qw422016.N().S(`{"target":`)
+//line app/vmselect/graphite/render_response.qtpl:123
+		qw422016.N().S(` `)
+//line app/vmselect/graphite/render_response.qtpl:123
+		qw422016.N().S(jsonPythonString(s.Name))
+//line app/vmselect/graphite/render_response.qtpl:123
+		qw422016.N().S(`, "datapoints":`)
+//line app/vmselect/graphite/render_response.qtpl:123
+		qw422016.N().S(` `)
+//line app/vmselect/graphite/render_response.qtpl:123
+		qw422016.N().S(`[`)
+//line app/vmselect/graphite/render_response.qtpl:124
+		for j, v := range s.Values {
+//line app/vmselect/graphite/render_response.qtpl:124
+			qw422016.N().S(`{"x":`)
+//line app/vmselect/graphite/render_response.qtpl:125
+			qw422016.N().S(` `)
+//line app/vmselect/graphite/render_response.qtpl:125
+			qw422016.N().DL(start + int64(j)*step)
+//line app/vmselect/graphite/render_response.qtpl:125
+			qw422016.N().S(`, "y":`)
+//line app/vmselect/graphite/render_response.qtpl:125
+			qw422016.N().S(` `)
+//line app/vmselect/graphite/render_response.qtpl:125
+			qw422016.N().S(jsonPythonFloat(v))
+//line app/vmselect/graphite/render_response.qtpl:125
+			qw422016.N().S(`}`)
+//line app/vmselect/graphite/render_response.qtpl:126
+			if j+1 < len(s.Values) {
+//line app/vmselect/graphite/render_response.qtpl:126
+				qw422016.N().S(`,`)
+//line app/vmselect/graphite/render_response.qtpl:126
+				qw422016.N().S(` `)
+//line app/vmselect/graphite/render_response.qtpl:126
+			}
+//line app/vmselect/graphite/render_response.qtpl:127
+		}
+//line app/vmselect/graphite/render_response.qtpl:127
+		qw422016.N().S(`]}`)
+//line app/vmselect/graphite/render_response.qtpl:129
+		if i+1 < len(ss) {
+//line app/vmselect/graphite/render_response.qtpl:129
+			qw422016.N().S(`,`)
+//line app/vmselect/graphite/render_response.qtpl:129
+			qw422016.N().S(` `)
+//line app/vmselect/graphite/render_response.qtpl:129
+		}
+//line app/vmselect/graphite/render_response.qtpl:130
+	}
+//line app/vmselect/graphite/render_response.qtpl:130
+	qw422016.N().S(`]`)
+//line app/vmselect/graphite/render_response.qtpl:132
+	if jsonp != "" {
+//line app/vmselect/graphite/render_response.qtpl:132
+		qw422016.N().S(`)`)
+//line app/vmselect/graphite/render_response.qtpl:132
+	}
+//line app/vmselect/graphite/render_response.qtpl:133
+}
+
+//line app/vmselect/graphite/render_response.qtpl:133
+func WriteRenderRickshawResponse(qq422016 qtio422016.Writer, ss []*series, jsonp string) {
+//line app/vmselect/graphite/render_response.qtpl:133
+	qw422016 := qt422016.AcquireWriter(qq422016)
+//line app/vmselect/graphite/render_response.qtpl:133
+	StreamRenderRickshawResponse(qw422016, ss, jsonp)
+//line app/vmselect/graphite/render_response.qtpl:133
+	qt422016.ReleaseWriter(qw422016)
+//line app/vmselect/graphite/render_response.qtpl:133
+}
+
+//line app/vmselect/graphite/render_response.qtpl:133
+func RenderRickshawResponse(ss []*series, jsonp string) string {
+//line app/vmselect/graphite/render_response.qtpl:133
+	qb422016 := qt422016.AcquireByteBuffer()
+//line app/vmselect/graphite/render_response.qtpl:133
+	WriteRenderRickshawResponse(qb422016, ss, jsonp)
+//line app/vmselect/graphite/render_response.qtpl:133
 	qs422016 := string(qb422016.B)
-//line app/vmselect/graphite/render_response.qtpl:57
+//line app/vmselect/graphite/render_response.qtpl:133
 	qt422016.ReleaseByteBuffer(qb422016)
-//line app/vmselect/graphite/render_response.qtpl:57
+//line app/vmselect/graphite/render_response.qtpl:133
 	return qs422016
-//line app/vmselect/graphite/render_response.qtpl:57
+//line app/vmselect/graphite/render_response.qtpl:133
 }
@@ -12,6 +12,7 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+	"unicode"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/graphiteql"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/cgroup"
@@ -36,6 +37,7 @@ func init() {
 		"add":                         transformAdd,
 		"aggregate":                   transformAggregate,
 		"aggregateLine":               transformAggregateLine,
+		"aggregateSeriesLists":        transformAggregateSeriesLists,
 		"aggregateWithWildcards":      transformAggregateWithWildcards,
 		"alias":                       transformAlias,
 		"aliasByMetric":               transformAliasByMetric,
@@ -66,6 +68,7 @@ func init() {
 		"delay":                       transformDelay,
 		"derivative":                  transformDerivative,
 		"diffSeries":                  transformDiffSeries,
+		"diffSeriesLists":             transformDiffSeriesLists,
 		"divideSeries":                transformDivideSeries,
 		"divideSeriesLists":           transformDivideSeriesLists,
 		"drawAsInfinite":              transformDrawAsInfinite,
@@ -103,6 +106,7 @@ func init() {
 		"log":                         transformLogarithm,
 		"logarithm":                   transformLogarithm,
 		"logit":                       transformLogit,
+		"lower":                       transformToLowerCase,
 		"lowest":                      transformLowest,
 		"lowestAverage":               transformLowestAverage,
 		"lowestCurrent":               transformLowestCurrent,
@@ -125,12 +129,14 @@ func init() {
 		"movingSum":                   transformMovingSum,
 		"movingWindow":                transformMovingWindow,
 		"multiplySeries":              transformMultiplySeries,
+		"multiplySeriesLists":         transformMultiplySeriesLists,
 		"multiplySeriesWithWildcards": transformMultiplySeriesWithWildcards,
 		"nPercentile":                 transformNPercentile,
 		"nonNegativeDerivative":       transformNonNegativeDerivative,
 		"offset":                      transformOffset,
 		"offsetToZero":                transformOffsetToZero,
 		"perSecond":                   transformPerSecond,
+		"pct":                         transformAsPercent,
 		"percentileOfSeries":          transformPercentileOfSeries,
 		// It looks like pie* functions aren't needed for Graphite render API
 		//		"pieAverage":                  transformTODO,
@@ -149,6 +155,7 @@ func init() {
 		"removeBelowValue":        transformRemoveBelowValue,
 		"removeBetweenPercentile": transformRemoveBetweenPercentile,
 		"removeEmptySeries":       transformRemoveEmptySeries,
+		"removeZeroSeries":        transformRemoveZeroSeries,
 		"round":                   transformRoundFunction,
 		"roundFunction":           transformRoundFunction,
 		"scale":                   transformScale,
@@ -172,6 +179,7 @@ func init() {
 		"substr":                  transformSubstr,
 		"sum":                     transformSumSeries,
 		"sumSeries":               transformSumSeries,
+		"sumSeriesLists":          transformSumSeriesLists,
 		"sumSeriesWithWildcards":  transformSumSeriesWithWildcards,
 		"summarize":               transformSummarize,
 		"threshold":               transformThreshold,
@@ -180,8 +188,11 @@ func init() {
 		"timeShift":               transformTimeShift,
 		"timeSlice":               transformTimeSlice,
 		"timeStack":               transformTimeStack,
+		"toLowerCase":             transformToLowerCase,
+		"toUpperCase":             transformToUpperCase,
 		"transformNull":           transformTransformNull,
 		"unique":                  transformUnique,
+		"upper":                   transformToUpperCase,
 		"useSeriesAbove":          transformUseSeriesAbove,
 		"verticalLine":            transformVerticalLine,
 		"weightedAverage":         transformWeightedAverage,
@@ -322,6 +333,97 @@ func aggregateSeries(ec *evalConfig, expr graphiteql.Expr, nextSeries nextSeries
 	return singleSeriesFunc(s), nil
 }
 
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.aggregateSeriesLists
+func transformAggregateSeriesLists(ec *evalConfig, fe *graphiteql.FuncExpr) (nextSeriesFunc, error) {
+	args := fe.Args
+	if len(args) != 3 && len(args) != 4 {
+		return nil, fmt.Errorf("unexpected number of args; got %d; want 3 or 4", len(args))
+	}
+	funcName, err := getString(args, "func", 2)
+	if err != nil {
+		return nil, err
+	}
+	funcName = strings.TrimSuffix(funcName, "Series")
+	xFilesFactor, err := getOptionalNumber(args, "xFilesFactor", 3, ec.xFilesFactor)
+	if err != nil {
+		return nil, err
+	}
+	return aggregateSeriesLists(ec, fe, funcName, xFilesFactor)
+}
+
+// aggregateSeriesLists aggregates pairs of series from seriesListFirstPos and seriesListSecondPos args with the given funcName.
+//
+// Output series are named `<funcName>Series(<first>,<second>)` like in graphite-web.
+func aggregateSeriesLists(ec *evalConfig, fe *graphiteql.FuncExpr, funcName string, xFilesFactor float64) (nextSeriesFunc, error) {
+	args := fe.Args
+	if _, err := getAggrFunc(funcName); err != nil {
+		return nil, err
+	}
+	ssFirst, err := fetchSeriesListForPairing(ec, args, "seriesListFirstPos", 0)
+	if err != nil {
+		return nil, err
+	}
+	ssSecond, err := fetchSeriesListForPairing(ec, args, "seriesListSecondPos", 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(ssFirst) != len(ssSecond) {
+		return nil, fmt.Errorf("seriesListFirstPos and seriesListSecondPos must have equal number of series; got %d vs %d series", len(ssFirst), len(ssSecond))
+	}
+	ssDst := make([]*series, 0, len(ssFirst))
+	for i, sFirst := range ssFirst {
+		sSecond := ssSecond[i]
+		name := fmt.Sprintf("%sSeries(%s,%s)", funcName, sFirst.Name, sSecond.Name)
+		nextSeries, err := aggregateSeries(ec, fe, multiSeriesFunc([]*series{sFirst, sSecond}), funcName, xFilesFactor)
+		if err != nil {
+			return nil, err
+		}
+		ss, err := fetchAllSeries(nextSeries)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range ss {
+			// graphite-web updates only the name of the aggregated series.
+			s.Name = name
+			ssDst = append(ssDst, s)
+		}
+	}
+	return multiSeriesFunc(ssDst), nil
+}
+
+// fetchSeriesListForPairing returns all the series for the arg with the given name and index.
+//
+// Series for metric expressions are sorted by name, since graphite-web pairs series in the order of sorted paths,
+// while the storage returns them in random order.
+func fetchSeriesListForPairing(ec *evalConfig, args []*graphiteql.ArgExpr, name string, index int) ([]*series, error) {
+	arg, err := getArg(args, name, index)
+	if err != nil {
+		return nil, err
+	}
+	nextSeries, err := evalSeriesList(ec, args, name, index)
+	if err != nil {
+		return nil, err
+	}
+	ss, err := fetchAllSeries(nextSeries)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := arg.Expr.(*graphiteql.MetricExpr); ok {
+		sort.Slice(ss, func(i, j int) bool {
+			return ss[i].Name < ss[j].Name
+		})
+	}
+	return ss, nil
+}
+
+func aggregateSeriesListsGeneric(ec *evalConfig, fe *graphiteql.FuncExpr, funcName string) (nextSeriesFunc, error) {
+	args := fe.Args
+	if len(args) != 2 {
+		return nil, fmt.Errorf("unexpected number of args; got %d; want 2", len(args))
+	}
+	return aggregateSeriesLists(ec, fe, funcName, ec.xFilesFactor)
+}
+
 func aggregateSeriesGeneric(ec *evalConfig, fe *graphiteql.FuncExpr, funcName string) (nextSeriesFunc, error) {
 	nextSeries, err := groupSeriesLists(ec, fe.Args, fe)
 	if err != nil {
@@ -1265,6 +1367,11 @@ func transformDiffSeries(ec *evalConfig, fe *graphiteql.FuncExpr) (nextSeriesFun
 	return aggregateSeriesGeneric(ec, fe, "diff")
 }
 
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.diffSeriesLists
+func transformDiffSeriesLists(ec *evalConfig, fe *graphiteql.FuncExpr) (nextSeriesFunc, error) {
+	return aggregateSeriesListsGeneric(ec, fe, "diff")
+}
+
 // See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.divideSeries
 func transformDivideSeries(ec *evalConfig, fe *graphiteql.FuncExpr) (nextSeriesFunc, error) {
 	args := fe.Args
@@ -2755,6 +2862,11 @@ func transformMultiplySeries(ec *evalConfig, fe *graphiteql.FuncExpr) (nextSerie
 	return aggregateSeriesGeneric(ec, fe, "multiply")
 }
 
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.multiplySeriesLists
+func transformMultiplySeriesLists(ec *evalConfig, fe *graphiteql.FuncExpr) (nextSeriesFunc, error) {
+	return aggregateSeriesListsGeneric(ec, fe, "multiply")
+}
+
 // See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.multiplySeriesWithWildcards
 func transformMultiplySeriesWithWildcards(ec *evalConfig, fe *graphiteql.FuncExpr) (nextSeriesFunc, error) {
 	return aggregateSeriesWithWildcardsGeneric(ec, fe, "multiply")
@@ -3096,6 +3208,40 @@ func transformRemoveEmptySeries(ec *evalConfig, fe *graphiteql.FuncExpr) (nextSe
 	return f, nil
 }
 
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.removeZeroSeries
+func transformRemoveZeroSeries(ec *evalConfig, fe *graphiteql.FuncExpr) (nextSeriesFunc, error) {
+	args := fe.Args
+	if len(args) < 1 || len(args) > 2 {
+		return nil, fmt.Errorf("unexpected number of args; got %d; want 1 or 2", len(args))
+	}
+	xFilesFactor, err := getOptionalNumber(args, "xFilesFactor", 1, ec.xFilesFactor)
+	if err != nil {
+		return nil, err
+	}
+	nextSeries, err := evalSeriesList(ec, args, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	f := nextSeriesConcurrentWrapper(nextSeries, func(s *series) (*series, error) {
+		xff := s.xFilesFactor
+		if xff == 0 {
+			xff = xFilesFactor
+		}
+		n := 0
+		for _, v := range s.Values {
+			if !math.IsNaN(v) && v != 0 {
+				n++
+			}
+		}
+		if n == 0 || float64(n)/float64(len(s.Values)) < xff {
+			return nil, nil
+		}
+		s.expr = fe
+		return s, nil
+	})
+	return f, nil
+}
+
 // See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.roundFunction
 func transformRoundFunction(ec *evalConfig, fe *graphiteql.FuncExpr) (nextSeriesFunc, error) {
 	args := fe.Args
@@ -4224,6 +4370,58 @@ func transformTimeStack(ec *evalConfig, fe *graphiteql.FuncExpr) (nextSeriesFunc
 	return nextSeriesGroup(allSeries, fe), nil
 }
 
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.toLowerCase
+func transformToLowerCase(ec *evalConfig, fe *graphiteql.FuncExpr) (nextSeriesFunc, error) {
+	return changeSeriesNameCase(ec, fe, unicode.ToLower, strings.ToLower)
+}
+
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.toUpperCase
+func transformToUpperCase(ec *evalConfig, fe *graphiteql.FuncExpr) (nextSeriesFunc, error) {
+	return changeSeriesNameCase(ec, fe, unicode.ToUpper, strings.ToUpper)
+}
+
+// changeSeriesNameCase changes the case of series names with the given functions.
+//
+// If positions are passed after seriesList arg, then only letters at these positions are changed.
+// Negative positions are counted from the end of the name like in graphite-web.
+func changeSeriesNameCase(ec *evalConfig, fe *graphiteql.FuncExpr, runeFunc func(r rune) rune, stringFunc func(s string) string) (nextSeriesFunc, error) {
+	args := fe.Args
+	if len(args) < 1 {
+		return nil, fmt.Errorf("unexpected number of args; got %d; want at least 1", len(args))
+	}
+	var positions []int
+	for i := range args[1:] {
+		n, err := getNumber(args, "pos", i+1)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, int(n))
+	}
+	nextSeries, err := evalSeriesList(ec, args, "seriesList", 0)
+	if err != nil {
+		return nil, err
+	}
+	f := nextSeriesConcurrentWrapper(nextSeries, func(s *series) (*series, error) {
+		if len(positions) == 0 {
+			s.Name = stringFunc(s.Name)
+		} else {
+			name := []rune(s.Name)
+			for _, pos := range positions {
+				if pos < 0 {
+					pos += len(name)
+				}
+				if pos >= 0 && pos < len(name) {
+					name[pos] = runeFunc(name[pos])
+				}
+			}
+			s.Name = string(name)
+		}
+		s.expr = fe
+		return s, nil
+	})
+	return f, nil
+}
+
 // https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.timeSlice
 func transformTimeSlice(ec *evalConfig, fe *graphiteql.FuncExpr) (nextSeriesFunc, error) {
 	args := fe.Args
@@ -4548,6 +4746,11 @@ func transformSumSeries(ec *evalConfig, fe *graphiteql.FuncExpr) (nextSeriesFunc
 	return aggregateSeriesGeneric(ec, fe, "sum")
 }
 
+// See https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.sumSeriesLists
+func transformSumSeriesLists(ec *evalConfig, fe *graphiteql.FuncExpr) (nextSeriesFunc, error) {
+	return aggregateSeriesListsGeneric(ec, fe, "sum")
+}
+
 // https://graphite.readthedocs.io/en/stable/functions.html#graphite.render.functions.substr
 func transformSubstr(ec *evalConfig, fe *graphiteql.FuncExpr) (nextSeriesFunc, error) {
 	args := fe.Args
@@ -5207,6 +5410,9 @@ func getTimeFromArgExpr(originT int64, currentT time.Time, expr *graphiteql.ArgE
 }
 
 // Returns is_not_none, factor and offset of linear regression function by least squares method.
+//
+// linearRegressionAnalysis isn't registered at transformFuncs, since graphite-web exposes it only as a helper
+// for linearRegression function instead of a render function.
 // https://en.wikipedia.org/wiki/Linear_least_squares
 // https://github.com/graphite-project/graphite-web/blob/master/webapp/graphite/render/functions.py#L4158
 func linearRegressionAnalysis(s *series, step float64) (bool, float64, float64) {
@@ -15,6 +15,8 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/netstorage"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/prometheus"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/promql"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/querystats"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/resultcache"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/searchutils"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmstorage"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/cgroup"
@@ -62,6 +64,8 @@ func Init() {
 	fs.RemoveDirContents(tmpDirPath)
 	netstorage.InitTmpBlocksDir(tmpDirPath)
 	promql.InitRollupResultCache(*vmstorage.DataPath + "/cache/rollupResult")
+	resultcache.Init()
+	querystats.Init()
 
 	concurrencyLimitCh = make(chan struct{}, *maxConcurrentRequests)
 	initVMAlertProxy()
@@ -70,6 +74,8 @@ func Init() {
 // Stop stops vmselect
 func Stop() {
 	promql.StopRollupResultCache()
+	resultcache.MustStop()
+	querystats.MustStop()
 }
 
 var concurrencyLimitCh chan struct{}
@@ -136,6 +142,7 @@ func RequestHandler(w http.ResponseWriter, r *http.Request) bool {
 			return true
 		}
 	}
+	r = r.WithContext(searchutils.WithConcurrencySlot(r.Context(), concurrencyLimitCh))
 
 	if *logSlowQueryDuration > 0 {
 		actualStartTime := time.Now()
@@ -289,6 +296,42 @@ func RequestHandler(w http.ResponseWriter, r *http.Request) bool {
 			return true
 		}
 		return true
+	case "/api/v1/query_cost":
+		queryCostRequests.Inc()
+		httpserver.EnableCORS(w, r)
+		if err := prometheus.QueryCostHandler(qt, startTime, w, r); err != nil {
+			queryCostErrors.Inc()
+			sendPrometheusError(w, r, err)
+			return true
+		}
+		return true
+	case "/api/v1/analyze_query":
+		analyzeQueryRequests.Inc()
+		httpserver.EnableCORS(w, r)
+		if err := prometheus.AnalyzeQueryHandler(qt, startTime, w, r); err != nil {
+			analyzeQueryErrors.Inc()
+			sendPrometheusError(w, r, err)
+			return true
+		}
+		return true
+	case "/api/v1/format_query":
+		formatQueryRequests.Inc()
+		httpserver.EnableCORS(w, r)
+		if err := prometheus.FormatQueryHandler(w, r); err != nil {
+			formatQueryErrors.Inc()
+			sendPrometheusError(w, r, err)
+			return true
+		}
+		return true
+	case "/api/v1/parse_query":
+		parseQueryRequests.Inc()
+		httpserver.EnableCORS(w, r)
+		if err := prometheus.ParseQueryHandler(w, r); err != nil {
+			parseQueryErrors.Inc()
+			sendPrometheusError(w, r, err)
+			return true
+		}
+		return true
 	case "/api/v1/series":
 		seriesRequests.Inc()
 		httpserver.EnableCORS(w, r)
@@ -362,6 +405,14 @@ func RequestHandler(w http.ResponseWriter, r *http.Request) bool {
 			return true
 		}
 		return true
+	case "/api/v1/read":
+		remoteReadRequests.Inc()
+		if err := prometheus.RemoteReadHandler(startTime, w, r); err != nil {
+			remoteReadErrors.Inc()
+			httpserver.Errorf(w, r, "%s", err)
+			return true
+		}
+		return true
 	case "/federate":
 		federateRequests.Inc()
 		if err := prometheus.FederateHandler(startTime, w, r); err != nil {
@@ -575,6 +626,18 @@ var (
 	queryRangeRequests = metrics.NewCounter(`vm_http_requests_total{path="/api/v1/query_range"}`)
 	queryRangeErrors   = metrics.NewCounter(`vm_http_request_errors_total{path="/api/v1/query_range"}`)
 
+	queryCostRequests = metrics.NewCounter(`vm_http_requests_total{path="/api/v1/query_cost"}`)
+	queryCostErrors   = metrics.NewCounter(`vm_http_request_errors_total{path="/api/v1/query_cost"}`)
+
+	analyzeQueryRequests = metrics.NewCounter(`vm_http_requests_total{path="/api/v1/analyze_query"}`)
+	analyzeQueryErrors   = metrics.NewCounter(`vm_http_request_errors_total{path="/api/v1/analyze_query"}`)
+
+	formatQueryRequests = metrics.NewCounter(`vm_http_requests_total{path="/api/v1/format_query"}`)
+	formatQueryErrors   = metrics.NewCounter(`vm_http_request_errors_total{path="/api/v1/format_query"}`)
+
+	parseQueryRequests = metrics.NewCounter(`vm_http_requests_total{path="/api/v1/parse_query"}`)
+	parseQueryErrors   = metrics.NewCounter(`vm_http_request_errors_total{path="/api/v1/parse_query"}`)
+
 	seriesRequests = metrics.NewCounter(`vm_http_requests_total{path="/api/v1/series"}`)
 	seriesErrors   = metrics.NewCounter(`vm_http_request_errors_total{path="/api/v1/series"}`)
 
@@ -604,6 +667,9 @@ var (
 	exportNativeRequests = metrics.NewCounter(`vm_http_requests_total{path="/api/v1/export/native"}`)
 	exportNativeErrors   = metrics.NewCounter(`vm_http_request_errors_total{path="/api/v1/export/native"}`)
 
+	remoteReadRequests = metrics.NewCounter(`vm_http_requests_total{path="/api/v1/read"}`)
+	remoteReadErrors   = metrics.NewCounter(`vm_http_request_errors_total{path="/api/v1/read"}`)
+
 	federateRequests = metrics.NewCounter(`vm_http_requests_total{path="/federate"}`)
 	federateErrors   = metrics.NewCounter(`vm_http_request_errors_total{path="/federate"}`)
 
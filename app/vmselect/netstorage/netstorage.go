@@ -55,6 +55,14 @@ type Results struct {
 	packedTimeseries []packedTimeseries
 	sr               *storage.Search
 	tbf              *tmpBlocksFile
+
+	// bytesRead is the size of compressed data blocks selected for rss.
+	bytesRead int64
+}
+
+// BytesRead returns the size of compressed data blocks selected for rss.
+func (rss *Results) BytesRead() int64 {
+	return rss.bytesRead
 }
 
 // Len returns the number of results in rss.
@@ -245,24 +253,7 @@ func (rss *Results) runParallel(qt *querytracer.Tracer, f func(rs *Result, worke
 	maxWorkers := MaxWorkers()
 	if maxWorkers == 1 || tswsLen == 1 {
 		// It is faster to process time series in the current goroutine.
-		tsw := getTimeseriesWork()
-		tmpResult := getTmpResult()
-		rowsProcessedTotal := 0
-		var err error
-		for i := range rss.packedTimeseries {
-			initTimeseriesWork(tsw, &rss.packedTimeseries[i])
-			err = tsw.do(&tmpResult.rs, 0)
-			rowsReadPerSeries.Update(float64(tsw.rowsProcessed))
-			rowsProcessedTotal += tsw.rowsProcessed
-			if err != nil {
-				break
-			}
-			tsw.reset()
-		}
-		putTmpResult(tmpResult)
-		putTimeseriesWork(tsw)
-
-		return rowsProcessedTotal, err
+		return rss.runSequential(f)
 	}
 
 	// Slow path - spin up multiple local workers for parallel data processing.
@@ -326,6 +317,85 @@ func (rss *Results) runParallel(qt *querytracer.Tracer, f func(rs *Result, worke
 	return rowsProcessedTotal, firstErr
 }
 
+// runSequential calls f for every series in rss in the current goroutine in the order of rss.packedTimeseries.
+func (rss *Results) runSequential(f func(rs *Result, workerID uint) error) (int, error) {
+	var mustStop uint32
+	tsw := getTimeseriesWork()
+	tmpResult := getTmpResult()
+	rowsProcessedTotal := 0
+	var err error
+	for i := range rss.packedTimeseries {
+		tsw.rss = rss
+		tsw.pts = &rss.packedTimeseries[i]
+		tsw.f = f
+		tsw.mustStop = &mustStop
+		err = tsw.do(&tmpResult.rs, 0)
+		rowsReadPerSeries.Update(float64(tsw.rowsProcessed))
+		rowsProcessedTotal += tsw.rowsProcessed
+		if err != nil {
+			break
+		}
+		tsw.reset()
+	}
+	putTmpResult(tmpResult)
+	putTimeseriesWork(tsw)
+	return rowsProcessedTotal, err
+}
+
+// RunSorted calls f sequentially for every series in rss in the order defined by less for series names.
+//
+// Only metric names for series in rss are kept in memory during sorting, while series samples are unpacked right before calling f.
+// Data processing is immediately stopped if f returns non-nil error.
+//
+// rss becomes unusable after the call to RunSorted.
+func (rss *Results) RunSorted(qt *querytracer.Tracer, less func(a, b *storage.MetricName) bool, f func(rs *Result) error) error {
+	qt = qt.NewChild("sorted process of fetched data")
+	defer rss.mustClose()
+
+	mns := make([]storage.MetricName, len(rss.packedTimeseries))
+	for i := range rss.packedTimeseries {
+		pts := &rss.packedTimeseries[i]
+		if err := mns[i].Unmarshal(bytesutil.ToUnsafeBytes(pts.metricName)); err != nil {
+			return fmt.Errorf("cannot unmarshal metricName %q: %w", pts.metricName, err)
+		}
+	}
+	sort.Sort(&sortedTimeseries{
+		pts:  rss.packedTimeseries,
+		mns:  mns,
+		less: less,
+	})
+	qt.Printf("sort %d series", len(mns))
+	mns = nil
+
+	rowsProcessedTotal, err := rss.runSequential(func(rs *Result, workerID uint) error {
+		return f(rs)
+	})
+	seriesProcessedTotal := len(rss.packedTimeseries)
+	rss.packedTimeseries = rss.packedTimeseries[:0]
+
+	rowsReadPerQuery.Update(float64(rowsProcessedTotal))
+	seriesReadPerQuery.Update(float64(seriesProcessedTotal))
+
+	qt.Donef("series=%d, samples=%d", seriesProcessedTotal, rowsProcessedTotal)
+
+	return err
+}
+
+type sortedTimeseries struct {
+	pts  []packedTimeseries
+	mns  []storage.MetricName
+	less func(a, b *storage.MetricName) bool
+}
+
+func (st *sortedTimeseries) Len() int { return len(st.pts) }
+func (st *sortedTimeseries) Less(i, j int) bool {
+	return st.less(&st.mns[i], &st.mns[j])
+}
+func (st *sortedTimeseries) Swap(i, j int) {
+	st.pts[i], st.pts[j] = st.pts[j], st.pts[i]
+	st.mns[i], st.mns[j] = st.mns[j], st.mns[i]
+}
+
 var (
 	rowsReadPerSeries  = metrics.NewHistogram(`vm_rows_read_per_series`)
 	rowsReadPerQuery   = metrics.NewHistogram(`vm_rows_read_per_query`)
@@ -955,6 +1025,30 @@ func SeriesCount(qt *querytracer.Tracer, deadline searchutils.Deadline) (uint64,
 	return n, nil
 }
 
+// SeriesCountPerDay returns the number of series matching sq per each day on sq time range.
+func SeriesCountPerDay(qt *querytracer.Tracer, sq *storage.SearchQuery, deadline searchutils.Deadline) ([]int, error) {
+	qt = qt.NewChild("get series count per day: %s", sq)
+	defer qt.Done()
+	if deadline.Exceeded() {
+		return nil, fmt.Errorf("timeout exceeded before starting the query processing: %s", deadline.String())
+	}
+	tr := sq.GetTimeRange()
+	tfss, err := setupTfss(qt, tr, sq.TagFilterss, sq.MaxMetrics, deadline)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := vmstorage.GetSeriesCountPerDay(qt, tfss, tr, sq.MaxMetrics, deadline.Deadline())
+	if err != nil {
+		return nil, fmt.Errorf("error during series count per day request: %w", err)
+	}
+	return counts, nil
+}
+
+// SampleDensity returns the estimated number of samples per series per second.
+func SampleDensity() float64 {
+	return vmstorage.GetSampleDensity()
+}
+
 func getStorageSearch() *storage.Search {
 	v := ssPool.Get()
 	if v == nil {
@@ -1148,6 +1242,7 @@ func ProcessSearchQuery(qt *querytracer.Tracer, sq *storage.SearchQuery, deadlin
 	orderedMetricNames := make([]string, 0, maxSeriesCount)
 	blocksRead := 0
 	samples := 0
+	bytesRead := int64(0)
 	tbf := getTmpBlocksFile()
 	var buf []byte
 	for sr.NextMetricBlock() {
@@ -1159,6 +1254,7 @@ func ProcessSearchQuery(qt *querytracer.Tracer, sq *storage.SearchQuery, deadlin
 		}
 		br := sr.MetricBlockRef.BlockRef
 		samples += br.RowsCount()
+		bytesRead += int64(br.SizeBytes())
 		if *maxSamplesPerQuery > 0 && samples > *maxSamplesPerQuery {
 			putTmpBlocksFile(tbf)
 			putStorageSearch(sr)
@@ -1217,6 +1313,7 @@ func ProcessSearchQuery(qt *querytracer.Tracer, sq *storage.SearchQuery, deadlin
 	rss.packedTimeseries = pts
 	rss.sr = sr
 	rss.tbf = tbf
+	rss.bytesRead = bytesRead
 	return &rss, nil
 }
 
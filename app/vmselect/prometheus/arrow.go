@@ -0,0 +1,433 @@
+package prometheus
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+)
+
+// This file implements the minimal subset of Apache Arrow IPC streaming format
+// needed for streaming time series in columnar form.
+//
+// The stream consists of the schema message followed by record batch messages with the following columns:
+//
+//   - metric - JSON-encoded series labels in the same form as `metric` field in JSON responses
+//     (dictionary-encoded utf8 with int32 indexes)
+//   - timestamp - sample timestamp in milliseconds (timestamp[ms, tz=UTC])
+//   - value - sample value (float64)
+//
+// Every record batch contains samples for a single series. Record batches for every series are preceded
+// by dictionary batch, which replaces the `metric` dictionary with a single entry for the series labels,
+// so the labels are sent once per series and the stream can be written series-by-series without buffering the whole response.
+//
+// See https://arrow.apache.org/docs/format/Columnar.html#ipc-streaming-format
+// and https://arrow.apache.org/docs/format/Columnar.html#dictionary-messages
+
+// arrowStreamContentType is the content type for Apache Arrow IPC streaming format.
+const arrowStreamContentType = "application/vnd.apache.arrow.stream"
+
+// maxArrowRowsPerBatch is the maximum number of rows per Arrow record batch.
+//
+// Series with bigger number of samples are split into multiple record batches.
+const maxArrowRowsPerBatch = 64 * 1024
+
+// arrowMetricDictionaryID is the id of the dictionary for `metric` column.
+const arrowMetricDictionaryID = 0
+
+// Constants from Arrow flatbuffers schema.
+//
+// See https://github.com/apache/arrow/blob/main/format/Schema.fbs and https://github.com/apache/arrow/blob/main/format/Message.fbs
+const (
+	arrowMetadataVersionV5 = 4
+
+	arrowMessageHeaderSchema          = 1
+	arrowMessageHeaderDictionaryBatch = 2
+	arrowMessageHeaderRecordBatch     = 3
+
+	arrowTypeFloatingPoint = 3
+	arrowTypeUtf8          = 5
+	arrowTypeTimestamp     = 10
+
+	arrowPrecisionDouble   = 2
+	arrowTimeUnitMillisecs = 1
+)
+
+// appendArrowSchemaMessage appends Arrow schema message for the streamed time series to dst.
+func appendArrowSchemaMessage(dst []byte) []byte {
+	var b fbBuilder
+
+	// Field order must match the order of columns in appendArrowRecordBatchMessage.
+	metricName := b.createString("metric")
+	b.startTable(0)
+	metricType := b.endTable()
+	b.startTable(2)
+	b.addInt32(0, 32)
+	// Signed
+	b.addUint8(1, 1)
+	indexType := b.endTable()
+	b.startTable(2)
+	b.addInt64(0, arrowMetricDictionaryID)
+	b.addUOffset(1, indexType)
+	metricDictionary := b.endTable()
+	metricField := b.createField(metricName, arrowTypeUtf8, metricType, metricDictionary)
+
+	timestampName := b.createString("timestamp")
+	tz := b.createString("UTC")
+	b.startTable(2)
+	b.addInt16(0, arrowTimeUnitMillisecs)
+	b.addUOffset(1, tz)
+	timestampType := b.endTable()
+	timestampField := b.createField(timestampName, arrowTypeTimestamp, timestampType, 0)
+
+	valueName := b.createString("value")
+	b.startTable(1)
+	b.addInt16(0, arrowPrecisionDouble)
+	valueType := b.endTable()
+	valueField := b.createField(valueName, arrowTypeFloatingPoint, valueType, 0)
+
+	fields := b.createUOffsetVector([]uint32{metricField, timestampField, valueField})
+	b.startTable(4)
+	// Little endian
+	b.addInt16(0, 0)
+	b.addUOffset(1, fields)
+	schema := b.endTable()
+
+	metadata := b.finishMessage(arrowMessageHeaderSchema, schema, 0)
+	return appendArrowMessage(dst, metadata)
+}
+
+// appendArrowRecordBatchMessages appends Arrow messages for the given series to dst.
+//
+// The dictionary batch with the series labels is followed by record batches with the series samples.
+// The series is split into multiple record batches if it contains too many samples.
+// The appended messages must be written to the stream without interleaving with messages for other series.
+func appendArrowRecordBatchMessages(dst []byte, mn *storage.MetricName, timestamps []int64, values []float64) []byte {
+	bb := bbPool.Get()
+	writemetricNameObject(bb, mn)
+	dst = appendArrowDictionaryBatchMessage(dst, bb.B)
+	bbPool.Put(bb)
+
+	for len(timestamps) > 0 {
+		n := len(timestamps)
+		if n > maxArrowRowsPerBatch {
+			n = maxArrowRowsPerBatch
+		}
+		dst = appendArrowRecordBatchMessage(dst, timestamps[:n], values[:n])
+		timestamps = timestamps[n:]
+		values = values[n:]
+	}
+	return dst
+}
+
+// appendArrowDictionaryBatchMessage appends Arrow dictionary batch message, which replaces `metric` dictionary with the single metric entry.
+func appendArrowDictionaryBatchMessage(dst, metric []byte) []byte {
+	var b fbBuilder
+	bodyLen, recordBatch := b.createRecordBatch(1, 1, []int{0, 4 * 2, len(metric)})
+	b.startTable(3)
+	b.addInt64(0, arrowMetricDictionaryID)
+	b.addUOffset(1, recordBatch)
+	dictionaryBatch := b.endTable()
+	metadata := b.finishMessage(arrowMessageHeaderDictionaryBatch, dictionaryBatch, bodyLen)
+
+	dst = appendArrowMessage(dst, metadata)
+
+	// Write the body.
+	bodyStart := len(dst)
+	dst = binary.LittleEndian.AppendUint32(dst, 0)
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(metric)))
+	dst = append(dst, metric...)
+	return padArrowBody(dst, bodyStart)
+}
+
+func appendArrowRecordBatchMessage(dst []byte, timestamps []int64, values []float64) []byte {
+	rows := len(timestamps)
+
+	// Every column consists of validity bitmap followed by data buffer.
+	// Validity bitmaps are empty, since columns have no nulls.
+	var b fbBuilder
+	bodyLen, recordBatch := b.createRecordBatch(rows, 3, []int{0, 4 * rows, 0, 8 * rows, 0, 8 * rows})
+	metadata := b.finishMessage(arrowMessageHeaderRecordBatch, recordBatch, bodyLen)
+
+	dst = appendArrowMessage(dst, metadata)
+
+	// Write the body.
+	bodyStart := len(dst)
+	// metric indexes refer to the single entry in the dictionary
+	for i := 0; i < rows; i++ {
+		dst = binary.LittleEndian.AppendUint32(dst, 0)
+	}
+	dst = padArrowBody(dst, bodyStart)
+	for _, ts := range timestamps {
+		dst = binary.LittleEndian.AppendUint64(dst, uint64(ts))
+	}
+	for _, v := range values {
+		dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(v))
+	}
+	return dst
+}
+
+// appendArrowEndOfStream appends Arrow end-of-stream marker to dst.
+func appendArrowEndOfStream(dst []byte) []byte {
+	return append(dst, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0)
+}
+
+type arrowBuffer struct {
+	offset int
+	length int
+}
+
+// appendArrowMessage appends encapsulated Arrow IPC message with the given flatbuffers metadata to dst.
+//
+// The message body must be appended by the caller.
+func appendArrowMessage(dst, metadata []byte) []byte {
+	// Continuation marker followed by metadata size.
+	dst = append(dst, 0xff, 0xff, 0xff, 0xff)
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(metadata)))
+	// The metadata is already padded to 8 bytes by fbBuilder.finishMessage.
+	return append(dst, metadata...)
+}
+
+func padArrowSize(n int) int {
+	return (n + 7) &^ 7
+}
+
+func padArrowBody(dst []byte, bodyStart int) []byte {
+	for (len(dst)-bodyStart)%8 != 0 {
+		dst = append(dst, 0)
+	}
+	return dst
+}
+
+// fbBuilder is a minimal flatbuffers builder sufficient for encoding Arrow IPC metadata.
+//
+// Like the official flatbuffers builder, it builds the buffer from the end to the start,
+// so objects must be created before the objects referring to them.
+// All the offsets are measured from the end of the buffer.
+//
+// See https://flatbuffers.dev/md__internals.html
+type fbBuilder struct {
+	// buf[head:] contains the built data.
+	buf  []byte
+	head int
+
+	// vtable contains field offsets for the table being built.
+	vtable      []uint32
+	objectStart uint32
+}
+
+func (b *fbBuilder) offset() uint32 {
+	return uint32(len(b.buf) - b.head)
+}
+
+func (b *fbBuilder) grow(n int) {
+	if b.head >= n {
+		return
+	}
+	size := len(b.buf) - b.head
+	buf := make([]byte, 2*len(b.buf)+n+64)
+	head := len(buf) - size
+	copy(buf[head:], b.buf[b.head:])
+	b.buf = buf
+	b.head = head
+}
+
+func (b *fbBuilder) pad(n int) {
+	b.grow(n)
+	for i := 0; i < n; i++ {
+		b.head--
+		b.buf[b.head] = 0
+	}
+}
+
+// prep aligns the buffer to size after writing additionalBytes.
+func (b *fbBuilder) prep(size, additionalBytes int) {
+	alignSize := (-(int(b.offset()) + additionalBytes)) & (size - 1)
+	b.pad(alignSize)
+}
+
+func (b *fbBuilder) putUint8(v uint8) {
+	b.grow(1)
+	b.head--
+	b.buf[b.head] = v
+}
+
+func (b *fbBuilder) putUint16(v uint16) {
+	b.grow(2)
+	b.head -= 2
+	binary.LittleEndian.PutUint16(b.buf[b.head:], v)
+}
+
+func (b *fbBuilder) putUint32(v uint32) {
+	b.grow(4)
+	b.head -= 4
+	binary.LittleEndian.PutUint32(b.buf[b.head:], v)
+}
+
+func (b *fbBuilder) putUint64(v uint64) {
+	b.grow(8)
+	b.head -= 8
+	binary.LittleEndian.PutUint64(b.buf[b.head:], v)
+}
+
+func (b *fbBuilder) prependUOffset(off uint32) {
+	b.prep(4, 0)
+	b.putUint32(b.offset() - off + 4)
+}
+
+func (b *fbBuilder) createString(s string) uint32 {
+	b.prep(4, len(s)+1)
+	b.putUint8(0)
+	b.grow(len(s))
+	b.head -= len(s)
+	copy(b.buf[b.head:], s)
+	b.putUint32(uint32(len(s)))
+	return b.offset()
+}
+
+func (b *fbBuilder) startVector(elemSize, n, alignment int) {
+	b.prep(4, elemSize*n)
+	b.prep(alignment, elemSize*n)
+}
+
+func (b *fbBuilder) endVector(n int) uint32 {
+	b.putUint32(uint32(n))
+	return b.offset()
+}
+
+func (b *fbBuilder) createUOffsetVector(offsets []uint32) uint32 {
+	b.startVector(4, len(offsets), 4)
+	for i := len(offsets) - 1; i >= 0; i-- {
+		b.prependUOffset(offsets[i])
+	}
+	return b.endVector(len(offsets))
+}
+
+// startTable starts the table with the given number of fields.
+func (b *fbBuilder) startTable(numFields int) {
+	b.vtable = append(b.vtable[:0], make([]uint32, numFields)...)
+	b.objectStart = b.offset()
+}
+
+func (b *fbBuilder) addInt16(slot int, v int16) {
+	b.prep(2, 0)
+	b.putUint16(uint16(v))
+	b.vtable[slot] = b.offset()
+}
+
+func (b *fbBuilder) addUint8(slot int, v uint8) {
+	b.putUint8(v)
+	b.vtable[slot] = b.offset()
+}
+
+func (b *fbBuilder) addInt32(slot int, v int32) {
+	b.prep(4, 0)
+	b.putUint32(uint32(v))
+	b.vtable[slot] = b.offset()
+}
+
+func (b *fbBuilder) addInt64(slot int, v int64) {
+	b.prep(8, 0)
+	b.putUint64(uint64(v))
+	b.vtable[slot] = b.offset()
+}
+
+func (b *fbBuilder) addUOffset(slot int, off uint32) {
+	b.prependUOffset(off)
+	b.vtable[slot] = b.offset()
+}
+
+// endTable finishes the table started with startTable and returns its offset.
+func (b *fbBuilder) endTable() uint32 {
+	// Placeholder for the offset to vtable.
+	b.prep(4, 0)
+	b.putUint32(0)
+	objectOffset := b.offset()
+
+	n := len(b.vtable)
+	for n > 0 && b.vtable[n-1] == 0 {
+		n--
+	}
+	for i := n - 1; i >= 0; i-- {
+		fieldOffset := uint16(0)
+		if b.vtable[i] != 0 {
+			fieldOffset = uint16(objectOffset - b.vtable[i])
+		}
+		b.putUint16(fieldOffset)
+	}
+	b.putUint16(uint16(objectOffset - b.objectStart))
+	b.putUint16(uint16((n + 2) * 2))
+	vtableOffset := b.offset()
+
+	// The table refers to vtable via signed offset from the table start.
+	binary.LittleEndian.PutUint32(b.buf[len(b.buf)-int(objectOffset):], vtableOffset-objectOffset)
+	b.vtable = b.vtable[:0]
+	return objectOffset
+}
+
+// createField creates Arrow Field table.
+//
+// dictionary must contain non-zero offset to DictionaryEncoding table for dictionary-encoded field.
+func (b *fbBuilder) createField(name uint32, typeType uint8, typ, dictionary uint32) uint32 {
+	children := b.createUOffsetVector(nil)
+	b.startTable(6)
+	b.addUOffset(0, name)
+	b.addUOffset(3, typ)
+	if dictionary != 0 {
+		b.addUOffset(4, dictionary)
+	}
+	b.addUOffset(5, children)
+	// Not nullable
+	b.addUint8(1, 0)
+	b.addUint8(2, typeType)
+	return b.endTable()
+}
+
+// createRecordBatch creates Arrow RecordBatch table for the given number of rows and columns without nulls.
+//
+// bufferSizes must contain sizes for all the column buffers in the order they are written to the message body.
+// It returns the body length and the offset of the created table.
+func (b *fbBuilder) createRecordBatch(rows, columns int, bufferSizes []int) (int, uint32) {
+	var buffers []arrowBuffer
+	bodyLen := 0
+	for _, size := range bufferSizes {
+		buffers = append(buffers, arrowBuffer{
+			offset: bodyLen,
+			length: size,
+		})
+		bodyLen += padArrowSize(size)
+	}
+
+	b.startVector(16, columns, 8)
+	for i := 0; i < columns; i++ {
+		// FieldNode{length: rows, null_count: 0}
+		b.putUint64(0)
+		b.putUint64(uint64(rows))
+	}
+	nodes := b.endVector(columns)
+	b.startVector(16, len(buffers), 8)
+	for i := len(buffers) - 1; i >= 0; i-- {
+		b.putUint64(uint64(buffers[i].length))
+		b.putUint64(uint64(buffers[i].offset))
+	}
+	bufs := b.endVector(len(buffers))
+	b.startTable(3)
+	b.addInt64(0, int64(rows))
+	b.addUOffset(1, nodes)
+	b.addUOffset(2, bufs)
+	return bodyLen, b.endTable()
+}
+
+// finishMessage creates Arrow Message table with the given header and returns the resulting flatbuffer padded to 8 bytes.
+func (b *fbBuilder) finishMessage(headerType uint8, header uint32, bodyLen int) []byte {
+	b.startTable(4)
+	b.addInt64(3, int64(bodyLen))
+	b.addUOffset(2, header)
+	b.addInt16(0, arrowMetadataVersionV5)
+	b.addUint8(1, headerType)
+	message := b.endTable()
+
+	b.prep(8, 4)
+	b.prependUOffset(message)
+	return b.buf[b.head:]
+}
@@ -0,0 +1,340 @@
+package prometheus
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+)
+
+func TestArrowStream(t *testing.T) {
+	f := func(series []arrowTestSeries, rowsPerBatchExpected []int) {
+		t.Helper()
+		data := appendArrowSchemaMessage(nil)
+		for _, s := range series {
+			mn := storage.GetMetricName()
+			mn.MetricGroup = []byte(s.name)
+			for k, v := range s.labels {
+				mn.AddTag(k, v)
+			}
+			data = appendArrowRecordBatchMessages(data, mn, s.timestamps, s.values)
+			storage.PutMetricName(mn)
+		}
+		data = appendArrowEndOfStream(data)
+
+		// Read schema
+		msg, body, tail := readArrowMessage(t, data)
+		if ht := msg.uint8Field(1); ht != arrowMessageHeaderSchema {
+			t.Fatalf("unexpected header type for the first message; got %d; want %d", ht, arrowMessageHeaderSchema)
+		}
+		if v := msg.int16Field(0); v != arrowMetadataVersionV5 {
+			t.Fatalf("unexpected metadata version; got %d; want %d", v, arrowMetadataVersionV5)
+		}
+		if len(body) != 0 {
+			t.Fatalf("unexpected non-empty body for schema message")
+		}
+		schema := msg.tableField(2)
+		fields := schema.vectorField(1)
+		if len(fields) != 3 {
+			t.Fatalf("unexpected number of fields; got %d; want 3", len(fields))
+		}
+		fieldsExpected := []struct {
+			name     string
+			typeType uint8
+		}{
+			{"metric", arrowTypeUtf8},
+			{"timestamp", arrowTypeTimestamp},
+			{"value", arrowTypeFloatingPoint},
+		}
+		for i, fe := range fieldsExpected {
+			field := fields[i]
+			if name := field.stringField(0); name != fe.name {
+				t.Fatalf("unexpected name for field #%d; got %q; want %q", i, name, fe.name)
+			}
+			if tt := field.uint8Field(2); tt != fe.typeType {
+				t.Fatalf("unexpected type for field %q; got %d; want %d", fe.name, tt, fe.typeType)
+			}
+			if children := field.vectorField(5); len(children) != 0 {
+				t.Fatalf("unexpected children for field %q", fe.name)
+			}
+		}
+		dictionary := fields[0].tableField(4)
+		if id := dictionary.int64Field(0); id != arrowMetricDictionaryID {
+			t.Fatalf("unexpected dictionary id for metric field; got %d; want %d", id, arrowMetricDictionaryID)
+		}
+		indexType := dictionary.tableField(1)
+		if bitWidth := indexType.int32Field(0); bitWidth != 32 {
+			t.Fatalf("unexpected bit width for metric index; got %d; want 32", bitWidth)
+		}
+		if signed := indexType.uint8Field(1); signed != 1 {
+			t.Fatalf("expecting signed metric index")
+		}
+		tsType := fields[1].tableField(3)
+		if unit := tsType.int16Field(0); unit != arrowTimeUnitMillisecs {
+			t.Fatalf("unexpected timestamp unit; got %d; want %d", unit, arrowTimeUnitMillisecs)
+		}
+		if tz := tsType.stringField(1); tz != "UTC" {
+			t.Fatalf("unexpected timezone; got %q; want %q", tz, "UTC")
+		}
+		if precision := fields[2].tableField(3).int16Field(0); precision != arrowPrecisionDouble {
+			t.Fatalf("unexpected value precision; got %d; want %d", precision, arrowPrecisionDouble)
+		}
+
+		// Read dictionary batches followed by record batches
+		var rowsPerBatch []int
+		var result []arrowTestSeries
+		for {
+			if len(tail) == 8 && binary.LittleEndian.Uint32(tail[4:]) == 0 {
+				// End of stream
+				break
+			}
+			msg, body, tail = readArrowMessage(t, tail)
+			switch ht := msg.uint8Field(1); ht {
+			case arrowMessageHeaderDictionaryBatch:
+				db := msg.tableField(2)
+				if id := db.int64Field(0); id != arrowMetricDictionaryID {
+					t.Fatalf("unexpected dictionary id; got %d; want %d", id, arrowMetricDictionaryID)
+				}
+				if db.uint8Field(2) != 0 {
+					t.Fatalf("unexpected delta dictionary batch")
+				}
+				rb := db.tableField(1)
+				getBuffer := readArrowRecordBatch(t, rb, body, 1, 1, 3)
+				offsets := getBuffer(1)
+				metricData := getBuffer(2)
+				start := binary.LittleEndian.Uint32(offsets)
+				end := binary.LittleEndian.Uint32(offsets[4:])
+				var labels map[string]string
+				if err := json.Unmarshal(metricData[start:end], &labels); err != nil {
+					t.Fatalf("cannot parse metric %q: %s", metricData[start:end], err)
+				}
+				var s arrowTestSeries
+				s.name = labels["__name__"]
+				delete(labels, "__name__")
+				if len(labels) > 0 {
+					s.labels = labels
+				}
+				result = append(result, s)
+			case arrowMessageHeaderRecordBatch:
+				if len(result) == 0 {
+					t.Fatalf("missing dictionary batch before the record batch")
+				}
+				rb := msg.tableField(2)
+				rows := int(rb.int64Field(0))
+				rowsPerBatch = append(rowsPerBatch, rows)
+				getBuffer := readArrowRecordBatch(t, rb, body, rows, 3, 6)
+				indexes := getBuffer(1)
+				timestamps := getBuffer(3)
+				values := getBuffer(5)
+				s := &result[len(result)-1]
+				for i := 0; i < rows; i++ {
+					if idx := binary.LittleEndian.Uint32(indexes[4*i:]); idx != 0 {
+						t.Fatalf("unexpected metric index; got %d; want 0", idx)
+					}
+					s.timestamps = append(s.timestamps, int64(binary.LittleEndian.Uint64(timestamps[8*i:])))
+					s.values = append(s.values, math.Float64frombits(binary.LittleEndian.Uint64(values[8*i:])))
+				}
+			default:
+				t.Fatalf("unexpected header type: %d", ht)
+			}
+		}
+		if !reflect.DeepEqual(rowsPerBatch, rowsPerBatchExpected) {
+			t.Fatalf("unexpected rows per batch; got %v; want %v", rowsPerBatch, rowsPerBatchExpected)
+		}
+		if !reflect.DeepEqual(result, series) {
+			t.Fatalf("unexpected series\ngot\n%+v\nwant\n%+v", result, series)
+		}
+	}
+
+	// Empty stream
+	f(nil, nil)
+
+	// Multiple series
+	f([]arrowTestSeries{
+		{
+			name:       "foo",
+			labels:     map[string]string{"job": "x", "instance": "y"},
+			timestamps: []int64{1000, 2000, 3000},
+			values:     []float64{1, 2.5, -3},
+		},
+		{
+			name:       "bar",
+			timestamps: []int64{1000},
+			values:     []float64{42},
+		},
+	}, []int{3, 1})
+
+	// Series with samples exceeding maxArrowRowsPerBatch
+	n := maxArrowRowsPerBatch + 10
+	s := arrowTestSeries{
+		name: "foo",
+	}
+	for i := 0; i < n; i++ {
+		s.timestamps = append(s.timestamps, int64(i)*1000)
+		s.values = append(s.values, float64(i))
+	}
+	f([]arrowTestSeries{s}, []int{maxArrowRowsPerBatch, 10})
+}
+
+// readArrowRecordBatch verifies the layout of rb and returns a function for obtaining body buffers by index.
+func readArrowRecordBatch(t *testing.T, rb *fbTable, body []byte, rows, columns, buffersCount int) func(i int) []byte {
+	t.Helper()
+	nodes := rb.structVectorField(1)
+	if len(nodes) != columns {
+		t.Fatalf("unexpected number of field nodes; got %d; want %d", len(nodes), columns)
+	}
+	for _, node := range nodes {
+		if node[0] != int64(rows) || node[1] != 0 {
+			t.Fatalf("unexpected field node; got %v; want [%d 0]", node, rows)
+		}
+	}
+	buffers := rb.structVectorField(2)
+	if len(buffers) != buffersCount {
+		t.Fatalf("unexpected number of buffers; got %d; want %d", len(buffers), buffersCount)
+	}
+	return func(i int) []byte {
+		b := buffers[i]
+		if b[0]%8 != 0 {
+			t.Fatalf("buffer #%d isn't aligned to 8 bytes; offset=%d", i, b[0])
+		}
+		return body[b[0] : b[0]+b[1]]
+	}
+}
+
+type arrowTestSeries struct {
+	name       string
+	labels     map[string]string
+	timestamps []int64
+	values     []float64
+}
+
+// readArrowMessage reads encapsulated Arrow message from data.
+func readArrowMessage(t *testing.T, data []byte) (*fbTable, []byte, []byte) {
+	t.Helper()
+	if len(data) < 8 {
+		t.Fatalf("too short message; got %d bytes", len(data))
+	}
+	if marker := binary.LittleEndian.Uint32(data); marker != 0xffffffff {
+		t.Fatalf("unexpected continuation marker: %x", marker)
+	}
+	metadataLen := int(binary.LittleEndian.Uint32(data[4:]))
+	if metadataLen%8 != 0 {
+		t.Fatalf("metadata length must be aligned to 8 bytes; got %d", metadataLen)
+	}
+	data = data[8:]
+	metadata := data[:metadataLen]
+	data = data[metadataLen:]
+	msg := &fbTable{
+		buf: metadata,
+		pos: int(binary.LittleEndian.Uint32(metadata)),
+	}
+	bodyLen := int(msg.int64Field(3))
+	if bodyLen%8 != 0 {
+		t.Fatalf("body length must be aligned to 8 bytes; got %d", bodyLen)
+	}
+	return msg, data[:bodyLen], data[bodyLen:]
+}
+
+// fbTable is a minimal flatbuffers table reader for verifying fbBuilder output.
+type fbTable struct {
+	buf []byte
+	pos int
+}
+
+func (t *fbTable) fieldPos(id int) int {
+	vtablePos := t.pos - int(int32(binary.LittleEndian.Uint32(t.buf[t.pos:])))
+	vtableLen := int(binary.LittleEndian.Uint16(t.buf[vtablePos:]))
+	if 4+2*id >= vtableLen {
+		return 0
+	}
+	off := int(binary.LittleEndian.Uint16(t.buf[vtablePos+4+2*id:]))
+	if off == 0 {
+		return 0
+	}
+	return t.pos + off
+}
+
+func (t *fbTable) uint8Field(id int) uint8 {
+	pos := t.fieldPos(id)
+	if pos == 0 {
+		return 0
+	}
+	return t.buf[pos]
+}
+
+func (t *fbTable) int16Field(id int) int16 {
+	pos := t.fieldPos(id)
+	if pos == 0 {
+		return 0
+	}
+	return int16(binary.LittleEndian.Uint16(t.buf[pos:]))
+}
+
+func (t *fbTable) int32Field(id int) int32 {
+	pos := t.fieldPos(id)
+	if pos == 0 {
+		return 0
+	}
+	return int32(binary.LittleEndian.Uint32(t.buf[pos:]))
+}
+
+func (t *fbTable) int64Field(id int) int64 {
+	pos := t.fieldPos(id)
+	if pos == 0 {
+		return 0
+	}
+	if pos%8 != 0 {
+		panic("BUG: unaligned int64 field")
+	}
+	return int64(binary.LittleEndian.Uint64(t.buf[pos:]))
+}
+
+func (t *fbTable) indirect(pos int) int {
+	return pos + int(binary.LittleEndian.Uint32(t.buf[pos:]))
+}
+
+func (t *fbTable) tableField(id int) *fbTable {
+	return &fbTable{
+		buf: t.buf,
+		pos: t.indirect(t.fieldPos(id)),
+	}
+}
+
+func (t *fbTable) stringField(id int) string {
+	pos := t.indirect(t.fieldPos(id))
+	n := int(binary.LittleEndian.Uint32(t.buf[pos:]))
+	return string(t.buf[pos+4 : pos+4+n])
+}
+
+func (t *fbTable) vectorField(id int) []*fbTable {
+	pos := t.indirect(t.fieldPos(id))
+	n := int(binary.LittleEndian.Uint32(t.buf[pos:]))
+	var a []*fbTable
+	for i := 0; i < n; i++ {
+		a = append(a, &fbTable{
+			buf: t.buf,
+			pos: t.indirect(pos + 4 + 4*i),
+		})
+	}
+	return a
+}
+
+func (t *fbTable) structVectorField(id int) [][2]int64 {
+	pos := t.indirect(t.fieldPos(id))
+	n := int(binary.LittleEndian.Uint32(t.buf[pos:]))
+	if (pos+4)%8 != 0 {
+		panic("BUG: unaligned struct vector")
+	}
+	var a [][2]int64
+	for i := 0; i < n; i++ {
+		p := pos + 4 + 16*i
+		a = append(a, [2]int64{
+			int64(binary.LittleEndian.Uint64(t.buf[p:])),
+			int64(binary.LittleEndian.Uint64(t.buf[p+8:])),
+		})
+	}
+	return a
+}
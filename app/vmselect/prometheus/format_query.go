@@ -0,0 +1,326 @@
+package prometheus
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/bufferedwriter"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/httpserver"
+	"github.com/VictoriaMetrics/metricsql"
+)
+
+// FormatQueryHandler implements /api/v1/format_query .
+//
+// See https://prometheus.io/docs/prometheus/latest/querying/api/#formatting-query-expressions
+func FormatQueryHandler(w http.ResponseWriter, r *http.Request) error {
+	e, err := parseQueryArg(r)
+	if err != nil {
+		return err
+	}
+	s := prettifyExpr(e)
+	w.Header().Set("Content-Type", "application/json")
+	bw := bufferedwriter.Get(w)
+	defer bufferedwriter.Put(bw)
+	WriteFormatQueryResponse(bw, s)
+	return bw.Flush()
+}
+
+// ParseQueryHandler implements /api/v1/parse_query .
+//
+// It returns AST for the given MetricsQL query in JSON.
+func ParseQueryHandler(w http.ResponseWriter, r *http.Request) error {
+	e, err := parseQueryArg(r)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	bw := bufferedwriter.Get(w)
+	defer bufferedwriter.Put(bw)
+	WriteParseQueryResponse(bw, e)
+	return bw.Flush()
+}
+
+func parseQueryArg(r *http.Request) (metricsql.Expr, error) {
+	query := r.FormValue("query")
+	if len(query) == 0 {
+		return nil, fmt.Errorf("missing `query` arg")
+	}
+	if len(query) > maxQueryLen.IntN() {
+		return nil, fmt.Errorf("too long query; got %d bytes; mustn't exceed `-search.maxQueryLen=%d` bytes", len(query), maxQueryLen.N)
+	}
+	e, err := metricsql.Parse(query)
+	if err != nil {
+		return nil, &httpserver.ErrorWithStatusCode{
+			Err:        fmt.Errorf("cannot parse query %q: %w", query, err),
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+	return e, nil
+}
+
+// maxPrettifiedLineLen is the maximum line length for prettified queries.
+//
+// Longer lines are split into multiple lines.
+const maxPrettifiedLineLen = 80
+
+// prettifyExpr returns prettified representation of e.
+//
+// Expressions, which fit maxPrettifiedLineLen, are written in a single line.
+// Longer expressions are split into multiple lines with two-space indentation.
+func prettifyExpr(e metricsql.Expr) string {
+	dst := appendPrettifiedExpr(nil, e, 0, false)
+	return string(dst)
+}
+
+func appendPrettifiedExpr(dst []byte, e metricsql.Expr, indent int, needParens bool) []byte {
+	dstLen := len(dst)
+
+	// Try writing e in a single line.
+	dst = appendIndent(dst, indent)
+	if needParens {
+		dst = append(dst, '(')
+	}
+	dst = e.AppendString(dst)
+	if needParens {
+		dst = append(dst, ')')
+	}
+	if len(dst)-dstLen <= maxPrettifiedLineLen {
+		return dst
+	}
+
+	// The line is too long. Split it into multiple lines.
+	dst = dst[:dstLen]
+	switch t := e.(type) {
+	case *metricsql.BinaryOpExpr:
+		// Split `a op b` into:
+		//
+		//   a
+		//     op
+		//   b
+		if needParens {
+			dst = appendIndent(dst, indent)
+			dst = append(dst, "(\n"...)
+			indent++
+		}
+		_, leftNeedParens := t.Left.(*metricsql.BinaryOpExpr)
+		dst = appendPrettifiedExpr(dst, t.Left, indent, leftNeedParens)
+		dst = append(dst, '\n')
+		dst = appendIndent(dst, indent+1)
+		dst = appendBinaryOpWithModifiers(dst, t)
+		dst = append(dst, '\n')
+		_, rightNeedParens := t.Right.(*metricsql.BinaryOpExpr)
+		dst = appendPrettifiedExpr(dst, t.Right, indent, rightNeedParens)
+		if needParens {
+			indent--
+			dst = append(dst, '\n')
+			dst = appendIndent(dst, indent)
+			dst = append(dst, ')')
+		}
+	case *metricsql.AggrFuncExpr:
+		// Split `aggr(arg1, ..., argN) modifiers` into:
+		//
+		//   aggr(
+		//     arg1,
+		//     ...
+		//     argN
+		//   ) modifiers
+		dst = appendIndent(dst, indent)
+		dst = appendFuncName(dst, t.Name)
+		dst = appendPrettifiedFuncArgs(dst, indent, t.Args)
+		if t.Modifier.Op != "" {
+			dst = append(dst, ' ')
+			dst = t.Modifier.AppendString(dst)
+		}
+		if t.Limit > 0 {
+			dst = append(dst, fmt.Sprintf(" limit %d", t.Limit)...)
+		}
+	case *metricsql.FuncExpr:
+		// Split `f(arg1, ..., argN)` into:
+		//
+		//   f(
+		//     arg1,
+		//     ...
+		//     argN
+		//   )
+		dst = appendIndent(dst, indent)
+		dst = appendFuncName(dst, t.Name)
+		dst = appendPrettifiedFuncArgs(dst, indent, t.Args)
+		if t.KeepMetricNames {
+			dst = append(dst, " keep_metric_names"...)
+		}
+	case *metricsql.RollupExpr:
+		// Split `q[window:step] offset off @ at` into:
+		//
+		//   (
+		//     q
+		//   )[window:step] offset off @ at
+		//
+		// Parens are omitted if q doesn't need them.
+		if rollupExprNeedsParens(t.Expr) {
+			dst = appendIndent(dst, indent)
+			dst = append(dst, "(\n"...)
+			dst = appendPrettifiedExpr(dst, t.Expr, indent+1, false)
+			dst = append(dst, '\n')
+			dst = appendIndent(dst, indent)
+			dst = append(dst, ')')
+		} else {
+			dst = appendPrettifiedExpr(dst, t.Expr, indent, false)
+		}
+		dst = appendRollupModifiers(dst, t)
+	case *metricsql.MetricExpr:
+		// Split `metric{filter1, ..., filterN}` into:
+		//
+		//   metric{
+		//     filter1,
+		//     ...
+		//     filterN
+		//   }
+		lfs := t.LabelFilters
+		var name []byte
+		if len(lfs) > 0 && lfs[0].Label == "__name__" && !lfs[0].IsNegative && !lfs[0].IsRegexp {
+			me := &metricsql.MetricExpr{
+				LabelFilters: lfs[:1],
+			}
+			name = me.AppendString(nil)
+			lfs = lfs[1:]
+		}
+		dst = appendIndent(dst, indent)
+		if len(lfs) == 0 {
+			dst = append(dst, name...)
+			break
+		}
+		dst = append(dst, name...)
+		dst = append(dst, "{\n"...)
+		for i := range lfs {
+			dst = appendIndent(dst, indent+1)
+			dst = lfs[i].AppendString(dst)
+			if i+1 < len(lfs) {
+				dst = append(dst, ',')
+			}
+			dst = append(dst, '\n')
+		}
+		dst = appendIndent(dst, indent)
+		dst = append(dst, '}')
+	default:
+		// Other expressions such as strings and numbers cannot be split.
+		dst = appendIndent(dst, indent)
+		dst = t.AppendString(dst)
+	}
+	return dst
+}
+
+func appendPrettifiedFuncArgs(dst []byte, indent int, args []metricsql.Expr) []byte {
+	dst = append(dst, "(\n"...)
+	for i, arg := range args {
+		dst = appendPrettifiedExpr(dst, arg, indent+1, false)
+		if i+1 < len(args) {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, '\n')
+	}
+	dst = appendIndent(dst, indent)
+	dst = append(dst, ')')
+	return dst
+}
+
+func appendBinaryOpWithModifiers(dst []byte, be *metricsql.BinaryOpExpr) []byte {
+	dst = append(dst, be.Op...)
+	if be.Bool {
+		dst = append(dst, " bool"...)
+	}
+	if be.GroupModifier.Op != "" {
+		dst = append(dst, ' ')
+		dst = be.GroupModifier.AppendString(dst)
+	}
+	if be.JoinModifier.Op != "" {
+		dst = append(dst, ' ')
+		dst = be.JoinModifier.AppendString(dst)
+	}
+	return dst
+}
+
+func appendRollupModifiers(dst []byte, re *metricsql.RollupExpr) []byte {
+	if re.Window != nil || re.InheritStep || re.Step != nil {
+		dst = append(dst, '[')
+		dst = re.Window.AppendString(dst)
+		if re.Step != nil {
+			dst = append(dst, ':')
+			dst = re.Step.AppendString(dst)
+		} else if re.InheritStep {
+			dst = append(dst, ':')
+		}
+		dst = append(dst, ']')
+	}
+	if re.Offset != nil {
+		dst = append(dst, " offset "...)
+		dst = re.Offset.AppendString(dst)
+	}
+	if re.At != nil {
+		dst = append(dst, " @ "...)
+		_, needAtParens := re.At.(*metricsql.BinaryOpExpr)
+		if needAtParens {
+			dst = append(dst, '(')
+		}
+		dst = re.At.AppendString(dst)
+		if needAtParens {
+			dst = append(dst, ')')
+		}
+	}
+	return dst
+}
+
+// rollupExprNeedsParens returns true if e must be put in parens when used inside RollupExpr.
+//
+// This must be in sync with metricsql.RollupExpr.AppendString.
+func rollupExprNeedsParens(e metricsql.Expr) bool {
+	switch t := e.(type) {
+	case *metricsql.RollupExpr, *metricsql.BinaryOpExpr:
+		return true
+	case *metricsql.AggrFuncExpr:
+		return t.Modifier.Op != ""
+	default:
+		return false
+	}
+}
+
+// appendFuncName appends properly escaped function name to dst.
+func appendFuncName(dst []byte, name string) []byte {
+	// Obtain the escaped name from metricsql, since it doesn't export the escaping function.
+	fe := &metricsql.FuncExpr{
+		Name: name,
+	}
+	s := string(fe.AppendString(nil))
+	return append(dst, strings.TrimSuffix(s, "()")...)
+}
+
+func appendIndent(dst []byte, indent int) []byte {
+	for i := 0; i < indent; i++ {
+		dst = append(dst, "  "...)
+	}
+	return dst
+}
+
+// getFuncType returns the type of the function with the given name.
+func getFuncType(name string) string {
+	if metricsql.IsRollupFunc(name) {
+		return "rollup"
+	}
+	if metricsql.IsTransformFunc(name) {
+		return "transform"
+	}
+	return "unknown"
+}
+
+func getLabelFilterOp(lf *metricsql.LabelFilter) string {
+	if lf.IsNegative {
+		if lf.IsRegexp {
+			return "!~"
+		}
+		return "!="
+	}
+	if lf.IsRegexp {
+		return "=~"
+	}
+	return "="
+}
@@ -0,0 +1,153 @@
+package prometheus
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/VictoriaMetrics/metricsql"
+)
+
+func TestPrettifyExpr(t *testing.T) {
+	f := func(q, resultExpected string) {
+		t.Helper()
+		e, err := metricsql.Parse(q)
+		if err != nil {
+			t.Fatalf("cannot parse %q: %s", q, err)
+		}
+		result := prettifyExpr(e)
+		if result != resultExpected {
+			t.Fatalf("unexpected result for %q\ngot\n%s\nwant\n%s", q, result, resultExpected)
+		}
+
+		// Verify the prettified query is parsed into the same expression.
+		e2, err := metricsql.Parse(result)
+		if err != nil {
+			t.Fatalf("cannot parse prettified query %q: %s", result, err)
+		}
+		s1 := string(e.AppendString(nil))
+		s2 := string(e2.AppendString(nil))
+		if s1 != s2 {
+			t.Fatalf("prettified query %q doesn't match the original query %q", s2, s1)
+		}
+	}
+
+	// Short queries are written in a single line.
+	f(`foo`, `foo`)
+	f(`sum(rate(foo{bar="baz"}[5m])) by (job)`, `sum(rate(foo{bar="baz"}[5m])) by (job)`)
+	f(`a+b*c`, `a + (b * c)`)
+
+	// Long function calls.
+	f(`sum(rate(http_requests_total{job="api-server",instance=~"10.0.0.1:8080|10.0.0.2:8080"}[5m])) by (job, instance) limit 10`,
+		`sum(
+  rate(
+    http_requests_total{
+      job="api-server",
+      instance=~"10.0.0.1:8080|10.0.0.2:8080"
+    }[5m]
+  )
+) by (job, instance) limit 10`)
+	f(`histogram_quantile(0.99, sum(rate(http_request_duration_seconds_bucket{job="api-server"}[5m])) by (le))`,
+		`histogram_quantile(
+  0.99,
+  sum(rate(http_request_duration_seconds_bucket{job="api-server"}[5m])) by (le)
+)`)
+
+	// Long binary operations.
+	f(`sum(rate(http_requests_total{job="api-server",code=~"5.."}[5m])) / sum(rate(http_requests_total{job="api-server"}[5m])) > bool 0.1`,
+		`(
+  sum(rate(http_requests_total{job="api-server", code=~"5.."}[5m]))
+    /
+  sum(rate(http_requests_total{job="api-server"}[5m]))
+)
+  > bool
+0.1`)
+	f(`node_filesystem_avail_bytes{mountpoint="/",fstype!="rootfs"} / on(instance, device) group_left(job) node_filesystem_size_bytes`,
+		`node_filesystem_avail_bytes{mountpoint="/", fstype!="rootfs"}
+  / on (instance, device) group_left (job)
+node_filesystem_size_bytes`)
+
+	// Long selectors.
+	f(`http_requests_total{job="api-server",instance="10.0.0.1:8080",handler="/api/v1/query",method="GET",code="200"}`,
+		`http_requests_total{
+  job="api-server",
+  instance="10.0.0.1:8080",
+  handler="/api/v1/query",
+  method="GET",
+  code="200"
+}`)
+
+	// Long rollups and subqueries.
+	f(`max_over_time(rate(http_requests_total{job="api-server",instance="10.0.0.1:8080",handler="/api/v1/query"}[5m])[1d:1m] offset 1h)`,
+		`max_over_time(
+  rate(
+    http_requests_total{
+      job="api-server",
+      instance="10.0.0.1:8080",
+      handler="/api/v1/query"
+    }[5m]
+  )[1d:1m] offset 1h
+)`)
+	f(`(sum(rate(http_requests_total{job="api-server",instance="10.0.0.1:8080"}[5m])) by (handler))[1h:1m]`,
+		`(
+  sum(
+    rate(http_requests_total{job="api-server", instance="10.0.0.1:8080"}[5m])
+  ) by (handler)
+)[1h:1m]`)
+
+	// WITH templates are expanded.
+	f(`WITH (f(x) = rate(x[5m])) f(foo)`, `rate(foo[5m])`)
+}
+
+func TestParseQueryResponse(t *testing.T) {
+	f := func(q string) {
+		t.Helper()
+		e, err := metricsql.Parse(q)
+		if err != nil {
+			t.Fatalf("cannot parse %q: %s", q, err)
+		}
+		var bb bytes.Buffer
+		WriteParseQueryResponse(&bb, e)
+		var resp struct {
+			Status string                 `json:"status"`
+			Data   map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(bb.Bytes(), &resp); err != nil {
+			t.Fatalf("cannot parse response %q: %s", bb.String(), err)
+		}
+		if resp.Status != "success" {
+			t.Fatalf("unexpected status; got %q; want %q", resp.Status, "success")
+		}
+		if expr := resp.Data["expr"]; expr != nil && expr != string(e.AppendString(nil)) {
+			t.Fatalf("unexpected expr; got %q; want %q", expr, e.AppendString(nil))
+		}
+	}
+	f(`foo`)
+	f(`foo{bar="baz",x!~"y.+"}[5m] offset 1h @ end()`)
+	f(`sum(rate(foo[5m])) by (job) limit 3`)
+	f(`max_over_time(rate(foo[5m])[1d:1m])`)
+	f(`abs(foo) keep_metric_names`)
+	f(`a / on(x) group_left(y) b > bool 1`)
+	f(`label_set(time(), "foo", "bar")`)
+	f(`NaN`)
+	f(`-Inf`)
+
+	// Verify the response structure.
+	e, err := metricsql.Parse(`sum(rate(foo{job="x"}[5m])) by (job) / 2`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var bb bytes.Buffer
+	WriteParseQueryResponse(&bb, e)
+	respExpected := `{"status":"success","data":{"type":"binaryOpExpr","expr":"sum(rate(foo{job=\"x\"}[5m])) by (job) / 2","op":"/","bool":false,` +
+		`"groupModifier":{"op":"","args":[]},"joinModifier":{"op":"","args":[]},` +
+		`"left":{"type":"aggrFuncExpr","expr":"sum(rate(foo{job=\"x\"}[5m])) by (job)","name":"sum","funcType":"aggregate","modifier":{"op":"by","args":["job"]},"limit":0,` +
+		`"args":[{"type":"funcExpr","expr":"rate(foo{job=\"x\"}[5m])","name":"rate","funcType":"rollup","keepMetricNames":false,` +
+		`"args":[{"type":"rollupExpr","expr":"foo{job=\"x\"}[5m]","arg":{"type":"metricExpr","expr":"foo{job=\"x\"}",` +
+		`"labelFilters":[{"label":"__name__","op":"=","value":"foo"},{"label":"job","op":"=","value":"x"}]},` +
+		`"window":"5m","step":"","inheritStep":false,"offset":"","subquery":false}]}]},` +
+		`"right":{"type":"numberExpr","value":"2"}}}`
+	if resp := bb.String(); resp != respExpected {
+		t.Fatalf("unexpected response\ngot\n%s\nwant\n%s", resp, respExpected)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/netstorage"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/promql"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/querystats"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/resultcache"
 	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/searchutils"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
@@ -295,6 +296,17 @@ func exportHandler(qt *querytracer.Tracer, w http.ResponseWriter, cp *commonPara
 			WriteExportPrometheusLine(bb, xb)
 			return sw.maybeFlushBuffer(bb)
 		}
+	} else if format == "arrow" {
+		contentType = arrowStreamContentType
+		bb := bbPool.Get()
+		bb.B = appendArrowSchemaMessage(bb.B[:0])
+		_, _ = bw.Write(bb.B)
+		bbPool.Put(bb)
+		writeLineFunc = func(xb *exportBlock, workerID uint) error {
+			bb := sw.getBuffer(workerID)
+			bb.B = appendArrowRecordBatchMessages(bb.B, xb.mn, xb.timestamps, xb.values)
+			return sw.maybeFlushBuffer(bb)
+		}
 	} else if format == "promapi" {
 		WriteExportPromAPIHeader(bw)
 		firstLineOnce := uint32(0)
@@ -416,6 +428,9 @@ func exportHandler(qt *querytracer.Tracer, w http.ResponseWriter, cp *commonPara
 	if format == "promapi" {
 		WriteExportPromAPIFooter(bw, qt)
 	}
+	if format == "arrow" {
+		_, _ = bw.Write(appendArrowEndOfStream(nil))
+	}
 	return bw.Flush()
 }
 
@@ -469,6 +484,14 @@ var deleteDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/
 func LabelValuesHandler(qt *querytracer.Tracer, startTime time.Time, labelName string, w http.ResponseWriter, r *http.Request) error {
 	defer labelValuesDuration.UpdateDuration(startTime)
 
+	return withResponseCache(resultcache.Series, qt, startTime, w, r, func(w http.ResponseWriter) error {
+		return labelValuesHandler(qt, startTime, labelName, w, r)
+	})
+}
+
+var labelValuesDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/label/{}/values"}`)
+
+func labelValuesHandler(qt *querytracer.Tracer, startTime time.Time, labelName string, w http.ResponseWriter, r *http.Request) error {
 	cp, err := getCommonParamsWithDefaultDuration(r, startTime, false)
 	if err != nil {
 		return err
@@ -493,8 +516,6 @@ func LabelValuesHandler(qt *querytracer.Tracer, startTime time.Time, labelName s
 	return nil
 }
 
-var labelValuesDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/label/{}/values"}`)
-
 const secsPerDay = 3600 * 24
 
 // TSDBStatusHandler processes /api/v1/status/tsdb request.
@@ -566,6 +587,14 @@ var tsdbStatusDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/
 func LabelsHandler(qt *querytracer.Tracer, startTime time.Time, w http.ResponseWriter, r *http.Request) error {
 	defer labelsDuration.UpdateDuration(startTime)
 
+	return withResponseCache(resultcache.Series, qt, startTime, w, r, func(w http.ResponseWriter) error {
+		return labelsHandler(qt, startTime, w, r)
+	})
+}
+
+var labelsDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/labels"}`)
+
+func labelsHandler(qt *querytracer.Tracer, startTime time.Time, w http.ResponseWriter, r *http.Request) error {
 	cp, err := getCommonParamsWithDefaultDuration(r, startTime, false)
 	if err != nil {
 		return err
@@ -590,8 +619,6 @@ func LabelsHandler(qt *querytracer.Tracer, startTime time.Time, w http.ResponseW
 	return nil
 }
 
-var labelsDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/labels"}`)
-
 // SeriesCountHandler processes /api/v1/series/count request.
 func SeriesCountHandler(startTime time.Time, w http.ResponseWriter, r *http.Request) error {
 	defer seriesCountDuration.UpdateDuration(startTime)
@@ -619,6 +646,14 @@ var seriesCountDuration = metrics.NewSummary(`vm_request_duration_seconds{path="
 func SeriesHandler(qt *querytracer.Tracer, startTime time.Time, w http.ResponseWriter, r *http.Request) error {
 	defer seriesDuration.UpdateDuration(startTime)
 
+	return withResponseCache(resultcache.Series, qt, startTime, w, r, func(w http.ResponseWriter) error {
+		return seriesHandler(qt, startTime, w, r)
+	})
+}
+
+var seriesDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/series"}`)
+
+func seriesHandler(qt *querytracer.Tracer, startTime time.Time, w http.ResponseWriter, r *http.Request) error {
 	// Do not set start to searchutils.minTimeMsecs by default as Prometheus does,
 	// since this leads to fetching and scanning all the data from the storage,
 	// which can take a lot of time for big storages.
@@ -658,14 +693,20 @@ func SeriesHandler(qt *querytracer.Tracer, startTime time.Time, w http.ResponseW
 	return nil
 }
 
-var seriesDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/series"}`)
-
 // QueryHandler processes /api/v1/query request.
 //
 // See https://prometheus.io/docs/prometheus/latest/querying/api/#instant-queries
 func QueryHandler(qt *querytracer.Tracer, startTime time.Time, w http.ResponseWriter, r *http.Request) error {
 	defer queryDuration.UpdateDuration(startTime)
 
+	return withResponseCache(resultcache.InstantQuery, qt, startTime, w, r, func(w http.ResponseWriter) error {
+		return queryHandler(qt, startTime, w, r)
+	})
+}
+
+var queryDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/query"}`)
+
+func queryHandler(qt *querytracer.Tracer, startTime time.Time, w http.ResponseWriter, r *http.Request) error {
 	ct := startTime.UnixNano() / 1e6
 	deadline := searchutils.GetDeadlineForQuery(r, startTime)
 	mayCache := !searchutils.GetBool(r, "nocache")
@@ -771,7 +812,8 @@ func QueryHandler(qt *querytracer.Tracer, startTime time.Time, w http.ResponseWr
 			return httpserver.GetRequestURI(r)
 		},
 
-		QueryStats: qs,
+		QueryStats:      qs,
+		ConcurrencySlot: searchutils.GetConcurrencySlot(r),
 	}
 	result, err := promql.Exec(qt, ec, query, true)
 	if err != nil {
@@ -804,8 +846,6 @@ func QueryHandler(qt *querytracer.Tracer, startTime time.Time, w http.ResponseWr
 	return nil
 }
 
-var queryDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/query"}`)
-
 // QueryRangeHandler processes /api/v1/query_range request.
 //
 // See https://prometheus.io/docs/prometheus/latest/querying/api/#range-queries
@@ -879,7 +919,8 @@ func queryRangeHandler(qt *querytracer.Tracer, startTime time.Time, w http.Respo
 			return httpserver.GetRequestURI(r)
 		},
 
-		QueryStats: qs,
+		QueryStats:      qs,
+		ConcurrencySlot: searchutils.GetConcurrencySlot(r),
 	}
 	result, err := promql.Exec(qt, ec, query, false)
 	if err != nil {
@@ -899,6 +940,11 @@ func queryRangeHandler(qt *querytracer.Tracer, startTime time.Time, w http.Respo
 	// See https://github.com/VictoriaMetrics/VictoriaMetrics/issues/153
 	result = removeEmptyValuesAndTimeseries(result)
 
+	if r.FormValue("format") == "arrow" {
+		qt.Donef("start=%d, end=%d, step=%d, query=%q: series=%d", start, end, step, query, len(result))
+		return writeQueryRangeArrowResponse(w, result)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	bw := bufferedwriter.Get(w)
 	defer bufferedwriter.Put(bw)
@@ -912,6 +958,37 @@ func queryRangeHandler(qt *querytracer.Tracer, startTime time.Time, w http.Respo
 	return nil
 }
 
+// writeQueryRangeArrowResponse writes result to w in Apache Arrow IPC streaming format.
+//
+// The result is already calculated in memory, so series are written one-by-one
+// and are released after being written in order to reduce memory usage while sending the response.
+func writeQueryRangeArrowResponse(w http.ResponseWriter, result []netstorage.Result) error {
+	w.Header().Set("Content-Type", arrowStreamContentType)
+	bw := bufferedwriter.Get(w)
+	defer bufferedwriter.Put(bw)
+	bb := bbPool.Get()
+	defer bbPool.Put(bb)
+	bb.B = appendArrowSchemaMessage(bb.B[:0])
+	for i := range result {
+		rs := &result[i]
+		bb.B = appendArrowRecordBatchMessages(bb.B, &rs.MetricName, rs.Timestamps, rs.Values)
+		// Allow GC reclaiming memory occupied by the written series.
+		*rs = netstorage.Result{}
+		if _, err := bw.Write(bb.B); err != nil {
+			return fmt.Errorf("cannot send query range response to remote client: %w", err)
+		}
+		bb.B = bb.B[:0]
+	}
+	bb.B = appendArrowEndOfStream(bb.B)
+	if _, err := bw.Write(bb.B); err != nil {
+		return fmt.Errorf("cannot send query range response to remote client: %w", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("cannot send query range response to remote client: %w", err)
+	}
+	return nil
+}
+
 func removeEmptyValuesAndTimeseries(tss []netstorage.Result) []netstorage.Result {
 	dst := tss[:0]
 	for i := range tss {
@@ -955,6 +1032,131 @@ func removeEmptyValuesAndTimeseries(tss []netstorage.Result) []netstorage.Result
 
 var queryRangeDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/query_range"}`)
 
+// QueryCostHandler processes /api/v1/query_cost request.
+//
+// It returns the estimated cost for the query without executing it.
+// The query is estimated as range query if `start` arg is set. Otherwise it is estimated as instant query at `time`.
+func QueryCostHandler(qt *querytracer.Tracer, startTime time.Time, w http.ResponseWriter, r *http.Request) error {
+	defer queryCostDuration.UpdateDuration(startTime)
+
+	query, ec, err := getQueryAnalysisParams(r, startTime)
+	if err != nil {
+		return err
+	}
+	qc, err := promql.EstimateQueryCost(qt, ec, query)
+	if err != nil {
+		return fmt.Errorf("cannot estimate cost for query=%q on the time range (start=%d, end=%d, step=%d): %w", query, ec.Start, ec.End, ec.Step, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	bw := bufferedwriter.Get(w)
+	defer bufferedwriter.Put(bw)
+	WriteQueryCostResponse(bw, qc, qt)
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("cannot send query cost response to remote client: %w", err)
+	}
+	return nil
+}
+
+// AnalyzeQueryHandler processes /api/v1/analyze_query request.
+//
+// It returns hints about possible issues in the query together with the suggested rewrites.
+// The query isn't executed, but the series metadata for the selected time range is read from the storage.
+func AnalyzeQueryHandler(qt *querytracer.Tracer, startTime time.Time, w http.ResponseWriter, r *http.Request) error {
+	defer analyzeQueryDuration.UpdateDuration(startTime)
+
+	query, ec, err := getQueryAnalysisParams(r, startTime)
+	if err != nil {
+		return err
+	}
+	qa, err := promql.AnalyzeQuery(qt, ec, query)
+	if err != nil {
+		return fmt.Errorf("cannot analyze query=%q on the time range (start=%d, end=%d, step=%d): %w", query, ec.Start, ec.End, ec.Step, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	bw := bufferedwriter.Get(w)
+	defer bufferedwriter.Put(bw)
+	WriteAnalyzeQueryResponse(bw, query, qa, qt)
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("cannot send query analysis response to remote client: %w", err)
+	}
+	return nil
+}
+
+var analyzeQueryDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/analyze_query"}`)
+
+// getQueryAnalysisParams returns the query and EvalConfig for query analysis handlers such as /api/v1/query_cost and /api/v1/analyze_query .
+//
+// The time range is obtained from `start`, `end` and `step` args for range queries and from `time` arg for instant queries.
+func getQueryAnalysisParams(r *http.Request, startTime time.Time) (string, *promql.EvalConfig, error) {
+	ct := startTime.UnixNano() / 1e6
+	deadline := searchutils.GetDeadlineForQuery(r, startTime)
+	query := r.FormValue("query")
+	if len(query) == 0 {
+		return "", nil, fmt.Errorf("missing `query` arg")
+	}
+	if len(query) > maxQueryLen.IntN() {
+		return "", nil, fmt.Errorf("too long query; got %d bytes; mustn't exceed `-search.maxQueryLen=%d` bytes", len(query), maxQueryLen.N)
+	}
+	lookbackDelta, err := getMaxLookback(r)
+	if err != nil {
+		return "", nil, err
+	}
+	var start, end, step int64
+	if len(r.FormValue("start")) > 0 {
+		start, err = searchutils.GetTime(r, "start", ct-defaultStep)
+		if err != nil {
+			return "", nil, err
+		}
+		end, err = searchutils.GetTime(r, "end", ct)
+		if err != nil {
+			return "", nil, err
+		}
+		step, err = searchutils.GetDuration(r, "step", defaultStep)
+		if err != nil {
+			return "", nil, err
+		}
+		if start > end {
+			end = start + defaultStep
+		}
+		if err := promql.ValidateMaxPointsPerSeries(start, end, step, *maxPointsPerTimeseries); err != nil {
+			return "", nil, fmt.Errorf("%w; (see -search.maxPointsPerTimeseries command-line flag)", err)
+		}
+	} else {
+		start, err = searchutils.GetTime(r, "time", ct)
+		if err != nil {
+			return "", nil, err
+		}
+		end = start
+		step, err = searchutils.GetDuration(r, "step", lookbackDelta)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	if step <= 0 {
+		step = defaultStep
+	}
+	etfs, err := searchutils.GetExtraTagFilters(r)
+	if err != nil {
+		return "", nil, err
+	}
+	ec := &promql.EvalConfig{
+		Start:               start,
+		End:                 end,
+		Step:                step,
+		MaxPointsPerSeries:  *maxPointsPerTimeseries,
+		MaxSeries:           *maxUniqueTimeseries,
+		QuotedRemoteAddr:    httpserver.GetQuotedRemoteAddr(r),
+		Deadline:            deadline,
+		LookbackDelta:       lookbackDelta,
+		EnforcedTagFilterss: etfs,
+	}
+	return query, ec, nil
+}
+
+var queryCostDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/query_cost"}`)
+
 var nan = math.NaN()
 
 // adjustLastPoints substitutes the last point values on the time range (start..end]
@@ -0,0 +1,316 @@
+package prometheus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/bufferedwriter"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/netstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/searchutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+)
+
+// maxRemoteReadRequestSize is the maximum size of a single remote read request.
+const maxRemoteReadRequestSize = 32 * 1024 * 1024
+
+// maxSamplesPerChunk is the maximum number of samples per XOR chunk returned in STREAMED_XOR_CHUNKS response.
+//
+// This is the same value as Prometheus uses for chunks in its TSDB.
+const maxSamplesPerChunk = 120
+
+// RemoteReadHandler implements Prometheus remote read API at /api/v1/read .
+//
+// Both SAMPLES and STREAMED_XOR_CHUNKS response types are supported.
+// See https://prometheus.io/docs/prometheus/latest/querying/remote_read_api/
+func RemoteReadHandler(startTime time.Time, w http.ResponseWriter, r *http.Request) error {
+	defer remoteReadDuration.UpdateDuration(startTime)
+
+	req, err := readRemoteReadRequest(r)
+	if err != nil {
+		return err
+	}
+	deadline := searchutils.GetDeadlineForQuery(r, startTime)
+	for _, rt := range req.AcceptedResponseTypes {
+		switch rt {
+		case prompb.ReadRequest_STREAMED_XOR_CHUNKS:
+			return remoteReadStreamedXORChunks(w, req, deadline)
+		case prompb.ReadRequest_SAMPLES:
+			return remoteReadSamples(w, req, deadline)
+		}
+	}
+	if len(req.AcceptedResponseTypes) == 0 {
+		// Old clients do not set accepted response types and expect SAMPLES response.
+		return remoteReadSamples(w, req, deadline)
+	}
+	return fmt.Errorf("unsupported accepted_response_types=%s; supported types: %s, %s",
+		req.AcceptedResponseTypes, prompb.ReadRequest_SAMPLES, prompb.ReadRequest_STREAMED_XOR_CHUNKS)
+}
+
+var remoteReadDuration = metrics.NewSummary(`vm_request_duration_seconds{path="/api/v1/read"}`)
+
+func readRemoteReadRequest(r *http.Request) (*prompb.ReadRequest, error) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxRemoteReadRequestSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read remote read request: %w", err)
+	}
+	if len(data) > maxRemoteReadRequestSize {
+		return nil, fmt.Errorf("too big remote read request; mustn't exceed %d bytes", maxRemoteReadRequestSize)
+	}
+	reqBuf, err := snappy.Decode(nil, data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decompress snappy-encoded remote read request: %w", err)
+	}
+	var req prompb.ReadRequest
+	if err := req.Unmarshal(reqBuf); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal remote read request: %w", err)
+	}
+	return &req, nil
+}
+
+func remoteReadSamples(w http.ResponseWriter, req *prompb.ReadRequest, deadline searchutils.Deadline) error {
+	resp := &prompb.ReadResponse{
+		Results: make([]*prompb.QueryResult, len(req.Queries)),
+	}
+	for i, q := range req.Queries {
+		var tss []*prompb.TimeSeries
+		var tssLock sync.Mutex
+		err := remoteReadQuery(q, deadline, func(labels []prompb.Label, timestamps []int64, values []float64) error {
+			samples := make([]prompb.Sample, len(timestamps))
+			for k, ts := range timestamps {
+				samples[k] = prompb.Sample{
+					Value:     values[k],
+					Timestamp: ts,
+				}
+			}
+			ts := &prompb.TimeSeries{
+				Labels:  labels,
+				Samples: samples,
+			}
+			tssLock.Lock()
+			tss = append(tss, ts)
+			tssLock.Unlock()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// Prometheus expects series sorted by labels, since it merges them with other series sets.
+		sort.Slice(tss, func(i, j int) bool {
+			return lessLabels(tss[i].Labels, tss[j].Labels)
+		})
+		resp.Results[i] = &prompb.QueryResult{
+			Timeseries: tss,
+		}
+	}
+	data, err := resp.Marshal()
+	if err != nil {
+		return fmt.Errorf("cannot marshal remote read response: %w", err)
+	}
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Header().Set("Content-Encoding", "snappy")
+	_, err = w.Write(snappy.Encode(nil, data))
+	return err
+}
+
+// remoteReadStreamedXORChunks sends every series in a separate frame.
+//
+// Series for every query are sent in the order sorted by labels, since Prometheus and Thanos merge
+// the returned series sets with other sets in the same order as series are sorted in TSDB blocks.
+// Only metric names for the matching series are kept in memory during sorting,
+// while series samples are read from the storage and sent one by one in the sorted order.
+func remoteReadStreamedXORChunks(w http.ResponseWriter, req *prompb.ReadRequest, deadline searchutils.Deadline) error {
+	w.Header().Set("Content-Type", "application/x-streamed-protobuf; proto=prometheus.ChunkedReadResponse")
+	bw := bufferedwriter.Get(w)
+	defer bufferedwriter.Put(bw)
+	var buf []byte
+	for i, q := range req.Queries {
+		rss, err := searchRemoteReadQuery(q, deadline)
+		if err != nil {
+			return err
+		}
+		err = rss.RunSorted(nil, lessMetricNames, func(r *netstorage.Result) error {
+			labels := metricNameToLabels(&r.MetricName)
+			chunks, err := encodeXORChunks(r.Timestamps, r.Values)
+			if err != nil {
+				return fmt.Errorf("cannot encode chunks for series %v: %w", labels, err)
+			}
+			cs := &prompb.ChunkedSeries{
+				Labels: labels,
+				Chunks: chunks,
+			}
+			buf, err = writeChunkedSeries(bw, buf, cs, int64(i))
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("error when reading data for query #%d: %w", i, err)
+		}
+	}
+	return bw.Flush()
+}
+
+// writeChunkedSeries writes cs in a separate frame for the query with the given queryIndex to w.
+//
+// buf is used as a temporary buffer. It is returned for re-use in subsequent calls.
+func writeChunkedSeries(w io.Writer, buf []byte, cs *prompb.ChunkedSeries, queryIndex int64) ([]byte, error) {
+	resp := &prompb.ChunkedReadResponse{
+		ChunkedSeries: []*prompb.ChunkedSeries{cs},
+		QueryIndex:    queryIndex,
+	}
+	data, err := resp.Marshal()
+	if err != nil {
+		return buf, fmt.Errorf("cannot marshal chunked remote read response: %w", err)
+	}
+	buf = appendChunkedFrame(buf[:0], data)
+	if _, err := w.Write(buf); err != nil {
+		return buf, fmt.Errorf("cannot send chunked remote read response to remote client: %w", err)
+	}
+	return buf, nil
+}
+
+// appendChunkedFrame appends a frame with data to dst in the format expected by Prometheus remote read clients.
+//
+// The frame consists of uvarint-encoded data size, big-endian CRC32 Castagnoli checksum for data and data itself.
+func appendChunkedFrame(dst, data []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(data)))
+	dst = binary.BigEndian.AppendUint32(dst, crc32.Checksum(data, castagnoliTable))
+	return append(dst, data...)
+}
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+func encodeXORChunks(timestamps []int64, values []float64) ([]prompb.Chunk, error) {
+	chunks := make([]prompb.Chunk, 0, (len(timestamps)+maxSamplesPerChunk-1)/maxSamplesPerChunk)
+	for len(timestamps) > 0 {
+		n := maxSamplesPerChunk
+		if n > len(timestamps) {
+			n = len(timestamps)
+		}
+		c := chunkenc.NewXORChunk()
+		app, err := c.Appender()
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < n; i++ {
+			app.Append(timestamps[i], values[i])
+		}
+		chunks = append(chunks, prompb.Chunk{
+			MinTimeMs: timestamps[0],
+			MaxTimeMs: timestamps[n-1],
+			Type:      prompb.Chunk_XOR,
+			Data:      c.Bytes(),
+		})
+		timestamps = timestamps[n:]
+		values = values[n:]
+	}
+	return chunks, nil
+}
+
+// remoteReadQuery calls f for every series matching q.
+//
+// f may be called concurrently from multiple goroutines. The timestamps and values passed to f
+// are valid only until f returns, while labels may be retained.
+//
+// The number of returned samples is limited by -search.maxSamplesPerQuery.
+func remoteReadQuery(q *prompb.Query, deadline searchutils.Deadline, f func(labels []prompb.Label, timestamps []int64, values []float64) error) error {
+	rss, err := searchRemoteReadQuery(q, deadline)
+	if err != nil {
+		return err
+	}
+	err = rss.RunParallel(nil, func(r *netstorage.Result, workerID uint) error {
+		if len(r.Timestamps) == 0 {
+			return nil
+		}
+		return f(metricNameToLabels(&r.MetricName), r.Timestamps, r.Values)
+	})
+	if err != nil {
+		return fmt.Errorf("error when reading data for %s: %w", q, err)
+	}
+	return nil
+}
+
+// searchRemoteReadQuery returns series matching q.
+func searchRemoteReadQuery(q *prompb.Query, deadline searchutils.Deadline) (*netstorage.Results, error) {
+	tfs, err := getTagFiltersFromMatchers(q.Matchers)
+	if err != nil {
+		return nil, err
+	}
+	sq := storage.NewSearchQuery(q.StartTimestampMs, q.EndTimestampMs, [][]storage.TagFilter{tfs}, *maxUniqueTimeseries)
+	rss, err := netstorage.ProcessSearchQuery(nil, sq, deadline)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch data for %q: %w", sq, err)
+	}
+	return rss, nil
+}
+
+func metricNameToLabels(mn *storage.MetricName) []prompb.Label {
+	labels := make([]prompb.Label, 0, len(mn.Tags)+1)
+	if len(mn.MetricGroup) > 0 {
+		labels = append(labels, prompb.Label{
+			Name:  "__name__",
+			Value: string(mn.MetricGroup),
+		})
+	}
+	for _, tag := range mn.Tags {
+		labels = append(labels, prompb.Label{
+			Name:  string(tag.Key),
+			Value: string(tag.Value),
+		})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+	return labels
+}
+
+// lessMetricNames returns true if labels for a are less than labels for b.
+func lessMetricNames(a, b *storage.MetricName) bool {
+	return lessLabels(metricNameToLabels(a), metricNameToLabels(b))
+}
+
+func lessLabels(a, b []prompb.Label) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].Name != b[i].Name {
+			return a[i].Name < b[i].Name
+		}
+		if a[i].Value != b[i].Value {
+			return a[i].Value < b[i].Value
+		}
+	}
+	return len(a) < len(b)
+}
+
+func getTagFiltersFromMatchers(matchers []*prompb.LabelMatcher) ([]storage.TagFilter, error) {
+	tfs := make([]storage.TagFilter, 0, len(matchers))
+	for _, m := range matchers {
+		var tf storage.TagFilter
+		if m.Name != "__name__" {
+			// Empty key is required for metric name filter in storage.Search.
+			tf.Key = []byte(m.Name)
+		}
+		tf.Value = []byte(m.Value)
+		switch m.Type {
+		case prompb.LabelMatcher_EQ:
+		case prompb.LabelMatcher_NEQ:
+			tf.IsNegative = true
+		case prompb.LabelMatcher_RE:
+			tf.IsRegexp = true
+		case prompb.LabelMatcher_NRE:
+			tf.IsNegative = true
+			tf.IsRegexp = true
+		default:
+			return nil, fmt.Errorf("unsupported label matcher type %s for label %q", m.Type, m.Name)
+		}
+		tfs = append(tfs, tf)
+	}
+	return tfs, nil
+}
@@ -0,0 +1,342 @@
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/netstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/searchutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmstorage"
+	vmprompb "github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage/remote"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+)
+
+func TestGetTagFiltersFromMatchers(t *testing.T) {
+	f := func(matchers []*prompb.LabelMatcher, resultExpected string) {
+		t.Helper()
+		tfs, err := getTagFiltersFromMatchers(matchers)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		a := make([]string, len(tfs))
+		for i := range tfs {
+			a[i] = tfs[i].String()
+		}
+		result := "[" + strings.Join(a, " ") + "]"
+		if result != resultExpected {
+			t.Fatalf("unexpected tag filters;\ngot\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+	f(nil, "[]")
+	f([]*prompb.LabelMatcher{
+		{Type: prompb.LabelMatcher_EQ, Name: "__name__", Value: "foo"},
+		{Type: prompb.LabelMatcher_NEQ, Name: "job", Value: "bar"},
+		{Type: prompb.LabelMatcher_RE, Name: "instance", Value: "a.+"},
+		{Type: prompb.LabelMatcher_NRE, Name: "env", Value: "dev|test"},
+	}, `[__name__="foo" job!="bar" instance=~"a.+" env!~"dev|test"]`)
+
+	// invalid matcher type
+	if _, err := getTagFiltersFromMatchers([]*prompb.LabelMatcher{{Type: 123, Name: "foo", Value: "bar"}}); err == nil {
+		t.Fatalf("expecting non-nil error for invalid matcher type")
+	}
+}
+
+func TestMetricNameToLabels(t *testing.T) {
+	var mn storage.MetricName
+	mn.MetricGroup = []byte("foo")
+	mn.AddTag("job", "bar")
+	mn.AddTag("a", "b")
+	labels := metricNameToLabels(&mn)
+	labelsExpected := []prompb.Label{
+		{Name: "__name__", Value: "foo"},
+		{Name: "a", Value: "b"},
+		{Name: "job", Value: "bar"},
+	}
+	if !reflect.DeepEqual(labels, labelsExpected) {
+		t.Fatalf("unexpected labels;\ngot\n%v\nwant\n%v", labels, labelsExpected)
+	}
+
+	// __name__ label must be skipped for series without metric name
+	mn.MetricGroup = nil
+	labels = metricNameToLabels(&mn)
+	labelsExpected = []prompb.Label{
+		{Name: "a", Value: "b"},
+		{Name: "job", Value: "bar"},
+	}
+	if !reflect.DeepEqual(labels, labelsExpected) {
+		t.Fatalf("unexpected labels;\ngot\n%v\nwant\n%v", labels, labelsExpected)
+	}
+}
+
+func TestEncodeXORChunks(t *testing.T) {
+	f := func(samplesCount, chunksCountExpected int) {
+		t.Helper()
+		timestamps := make([]int64, samplesCount)
+		values := make([]float64, samplesCount)
+		for i := range timestamps {
+			timestamps[i] = int64(i) * 15000
+			values[i] = float64(i) * 1.5
+		}
+		chunks, err := encodeXORChunks(timestamps, values)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(chunks) != chunksCountExpected {
+			t.Fatalf("unexpected number of chunks; got %d; want %d", len(chunks), chunksCountExpected)
+		}
+		var timestampsGot []int64
+		var valuesGot []float64
+		for _, chunk := range chunks {
+			if chunk.Type != prompb.Chunk_XOR {
+				t.Fatalf("unexpected chunk type: %s", chunk.Type)
+			}
+			c, err := chunkenc.FromData(chunkenc.EncXOR, chunk.Data)
+			if err != nil {
+				t.Fatalf("cannot decode chunk: %s", err)
+			}
+			it := c.Iterator(nil)
+			n := 0
+			for it.Next() == chunkenc.ValFloat {
+				ts, v := it.At()
+				if ts < chunk.MinTimeMs || ts > chunk.MaxTimeMs {
+					t.Fatalf("timestamp %d is outside chunk time range [%d ... %d]", ts, chunk.MinTimeMs, chunk.MaxTimeMs)
+				}
+				timestampsGot = append(timestampsGot, ts)
+				valuesGot = append(valuesGot, v)
+				n++
+			}
+			if err := it.Err(); err != nil {
+				t.Fatalf("unexpected error when iterating chunk: %s", err)
+			}
+			if n > maxSamplesPerChunk {
+				t.Fatalf("too many samples in chunk; got %d; mustn't exceed %d", n, maxSamplesPerChunk)
+			}
+		}
+		if len(timestampsGot) != samplesCount {
+			t.Fatalf("unexpected number of decoded samples; got %d; want %d", len(timestampsGot), samplesCount)
+		}
+		if samplesCount > 0 && (!reflect.DeepEqual(timestampsGot, timestamps) || !reflect.DeepEqual(valuesGot, values)) {
+			t.Fatalf("unexpected decoded samples;\ngot\n%v\n%v\nwant\n%v\n%v", timestampsGot, valuesGot, timestamps, values)
+		}
+	}
+	f(0, 0)
+	f(1, 1)
+	f(maxSamplesPerChunk, 1)
+	f(maxSamplesPerChunk+1, 2)
+	f(3*maxSamplesPerChunk+7, 4)
+}
+
+func TestAppendChunkedFrame(t *testing.T) {
+	responses := []*prompb.ChunkedReadResponse{
+		{
+			ChunkedSeries: []*prompb.ChunkedSeries{{
+				Labels: []prompb.Label{{Name: "__name__", Value: "foo"}},
+				Chunks: []prompb.Chunk{{MinTimeMs: 1, MaxTimeMs: 2, Type: prompb.Chunk_XOR, Data: []byte("abc")}},
+			}},
+			QueryIndex: 0,
+		},
+		{
+			ChunkedSeries: []*prompb.ChunkedSeries{{
+				Labels: []prompb.Label{{Name: "__name__", Value: "bar"}},
+			}},
+			QueryIndex: 1,
+		},
+	}
+	var buf []byte
+	for _, resp := range responses {
+		data, err := resp.Marshal()
+		if err != nil {
+			t.Fatalf("cannot marshal response: %s", err)
+		}
+		buf = appendChunkedFrame(buf, data)
+	}
+
+	cr := remote.NewChunkedReader(bytes.NewReader(buf), remote.DefaultChunkedReadLimit, nil)
+	for i, respExpected := range responses {
+		var resp prompb.ChunkedReadResponse
+		if err := cr.NextProto(&resp); err != nil {
+			t.Fatalf("cannot read response #%d: %s", i, err)
+		}
+		if resp.String() != respExpected.String() {
+			t.Fatalf("unexpected response #%d;\ngot\n%s\nwant\n%s", i, resp.String(), respExpected.String())
+		}
+	}
+}
+
+func TestRemoteReadStreamedXORChunks(t *testing.T) {
+	path := "TestRemoteReadStreamedXORChunks"
+	vmstorage.Storage = storage.MustOpenStorage(path, 0, 0, 0)
+	netstorage.InitTmpBlocksDir(path + "/tmp")
+	defer func() {
+		vmstorage.Storage.MustClose()
+		vmstorage.Storage = nil
+		_ = os.RemoveAll(path)
+	}()
+
+	end := time.Now().UnixNano() / 1e6
+	var mrs []storage.MetricRow
+	for i := 0; i < 100; i++ {
+		labels := []vmprompb.Label{
+			{Name: []byte("__name__"), Value: []byte("foo")},
+			{Name: []byte("instance"), Value: []byte(fmt.Sprintf("host-%d", i))},
+		}
+		metricNameRaw := storage.MarshalMetricNameRaw(nil, labels)
+		for j := 0; j < 10; j++ {
+			mrs = append(mrs, storage.MetricRow{
+				MetricNameRaw: metricNameRaw,
+				Timestamp:     end - int64(j)*15000,
+				Value:         float64(j),
+			})
+		}
+	}
+	if err := vmstorage.Storage.AddRows(mrs, 64); err != nil {
+		t.Fatalf("cannot add rows: %s", err)
+	}
+	vmstorage.Storage.DebugFlush()
+
+	req := &prompb.ReadRequest{
+		Queries: []*prompb.Query{{
+			StartTimestampMs: end - 3600*1000,
+			EndTimestampMs:   end,
+			Matchers:         []*prompb.LabelMatcher{{Type: prompb.LabelMatcher_EQ, Name: "__name__", Value: "foo"}},
+		}},
+	}
+	w := httptest.NewRecorder()
+	if err := remoteReadStreamedXORChunks(w, req, searchutils.NewDeadline(time.Now(), time.Minute, "")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cr := remote.NewChunkedReader(w.Body, remote.DefaultChunkedReadLimit, nil)
+	var prevLabels []prompb.Label
+	series := 0
+	for {
+		var resp prompb.ChunkedReadResponse
+		err := cr.NextProto(&resp)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("cannot read response #%d: %s", series, err)
+		}
+		for _, cs := range resp.ChunkedSeries {
+			if prevLabels != nil && !lessLabels(prevLabels, cs.Labels) {
+				t.Fatalf("series aren't sorted; %s is sent after %s", labelsToString(cs.Labels), labelsToString(prevLabels))
+			}
+			prevLabels = cs.Labels
+			series++
+		}
+	}
+	if series != 100 {
+		t.Fatalf("unexpected number of series; got %d; want 100", series)
+	}
+}
+
+func TestLessMetricNames(t *testing.T) {
+	newMetricName := func(labels ...string) storage.MetricName {
+		var mn storage.MetricName
+		for i := 0; i < len(labels); i += 2 {
+			if labels[i] == "__name__" {
+				mn.MetricGroup = []byte(labels[i+1])
+				continue
+			}
+			mn.AddTag(labels[i], labels[i+1])
+		}
+		return mn
+	}
+	// Series are passed in the order they are read from the storage.
+	mns := []storage.MetricName{
+		newMetricName("__name__", "foo", "job", "b"),
+		newMetricName("__name__", "bar"),
+		newMetricName("__name__", "foo", "job", "a", "instance", "x"),
+		newMetricName("__name__", "foo", "job", "a"),
+		newMetricName("__name__", "bar", "job", "a"),
+		newMetricName("job", "a", "Zone", "z"),
+	}
+	namesExpected := []string{
+		`{Zone="z", job="a"}`,
+		`{__name__="bar"}`,
+		`{__name__="bar", job="a"}`,
+		`{__name__="foo", instance="x", job="a"}`,
+		`{__name__="foo", job="a"}`,
+		`{__name__="foo", job="b"}`,
+	}
+	sort.Slice(mns, func(i, j int) bool {
+		return lessMetricNames(&mns[i], &mns[j])
+	})
+	for i, nameExpected := range namesExpected {
+		name := labelsToString(metricNameToLabels(&mns[i]))
+		if name != nameExpected {
+			t.Fatalf("unexpected series #%d; got %s; want %s", i, name, nameExpected)
+		}
+	}
+}
+
+func TestWriteChunkedSeries(t *testing.T) {
+	newSeries := func(labels ...string) *prompb.ChunkedSeries {
+		cs := &prompb.ChunkedSeries{
+			Chunks: []prompb.Chunk{{MinTimeMs: 1, MaxTimeMs: 2, Type: prompb.Chunk_XOR, Data: []byte("abc")}},
+		}
+		for i := 0; i < len(labels); i += 2 {
+			cs.Labels = append(cs.Labels, prompb.Label{Name: labels[i], Value: labels[i+1]})
+		}
+		return cs
+	}
+	css := []*prompb.ChunkedSeries{
+		newSeries("__name__", "bar"),
+		newSeries("__name__", "foo", "job", "a"),
+	}
+
+	var bb bytes.Buffer
+	var buf []byte
+	for _, cs := range css {
+		var err error
+		buf, err = writeChunkedSeries(&bb, buf, cs, 3)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(buf) == 0 {
+			t.Fatalf("expecting non-empty buf for re-use")
+		}
+	}
+
+	cr := remote.NewChunkedReader(&bb, remote.DefaultChunkedReadLimit, nil)
+	for i, cs := range css {
+		var resp prompb.ChunkedReadResponse
+		if err := cr.NextProto(&resp); err != nil {
+			t.Fatalf("cannot read response #%d: %s", i, err)
+		}
+		if resp.QueryIndex != 3 {
+			t.Fatalf("unexpected query index for response #%d; got %d; want 3", i, resp.QueryIndex)
+		}
+		if len(resp.ChunkedSeries) != 1 {
+			t.Fatalf("unexpected number of series in response #%d; got %d; want 1", i, len(resp.ChunkedSeries))
+		}
+		name := labelsToString(resp.ChunkedSeries[0].Labels)
+		if nameExpected := labelsToString(cs.Labels); name != nameExpected {
+			t.Fatalf("unexpected series in response #%d; got %s; want %s", i, name, nameExpected)
+		}
+	}
+	var resp prompb.ChunkedReadResponse
+	if err := cr.NextProto(&resp); err != io.EOF {
+		t.Fatalf("expecting io.EOF after the last response; got %v", err)
+	}
+}
+
+func labelsToString(labels []prompb.Label) string {
+	a := make([]string, len(labels))
+	for i, label := range labels {
+		a[i] = fmt.Sprintf("%s=%q", label.Name, label.Value)
+	}
+	return "{" + strings.Join(a, ", ") + "}"
+}
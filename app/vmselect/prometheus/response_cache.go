@@ -0,0 +1,144 @@
+package prometheus
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/promql"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/resultcache"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/searchutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querytracer"
+)
+
+// withResponseCache serves the response for r from c if possible.
+//
+// Otherwise it calls f and stores the response written by f in c.
+func withResponseCache(c *resultcache.Cache, qt *querytracer.Tracer, startTime time.Time, w http.ResponseWriter, r *http.Request,
+	f func(w http.ResponseWriter) error) error {
+	if !c.Enabled() || promql.IsCacheDisabled() || qt.Enabled() || searchutils.GetBool(r, "nocache") {
+		return f(w)
+	}
+	key, err := getResponseCacheKey(r, startTime, c.TTL())
+	if err != nil {
+		// Let f return the proper error for invalid args.
+		return f(w)
+	}
+	body, contentType, ok := c.Get(nil, key)
+	if ok {
+		w.Header().Set("Content-Type", contentType)
+		_, err := w.Write(body)
+		return err
+	}
+	cw := &cachingResponseWriter{
+		ResponseWriter: w,
+		maxSize:        c.MaxEntrySize(),
+	}
+	if err := f(cw); err != nil {
+		return err
+	}
+	if !cw.skip {
+		c.Set(key, w.Header().Get("Content-Type"), cw.buf)
+	}
+	return nil
+}
+
+// getResponseCacheKey returns cache key for r.
+//
+// The `time` arg is included in the key as is, since it defines timestamps in the response.
+// The missing `time` arg defaults to the current time, which is rounded down to ttl, so requests without `time` arg
+// sent within ttl share the response evaluated at the time of the first such request. This response may be stale by up to ttl.
+// The `start` and `end` args are rounded down to ttl, so requests sent within ttl share the same key.
+// All the other args, including extra_label and extra_filters, are included in the key as is.
+func getResponseCacheKey(r *http.Request, startTime time.Time, ttl time.Duration) ([]byte, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	ttlMsecs := getTTLMsecs(ttl)
+	ct := startTime.UnixNano() / 1e6
+	var dst []byte
+	dst = encoding.MarshalBytes(dst, []byte(r.URL.Path))
+	for _, argKey := range []string{"time", "start", "end"} {
+		hasArg := len(r.Form[argKey]) > 0
+		if argKey == "start" && !hasArg {
+			// The default start depends on end, which is already included in the key.
+			dst = encoding.MarshalInt64(dst, 0)
+			continue
+		}
+		t, err := searchutils.GetTime(r, argKey, ct)
+		if err != nil {
+			return nil, err
+		}
+		if argKey == "time" {
+			// Responses for the missing time arg mustn't be shared with responses for the explicitly set time arg,
+			// since they are evaluated at different timestamps.
+			if hasArg {
+				dst = append(dst, 1)
+			} else {
+				dst = append(dst, 0)
+				t -= t % ttlMsecs
+			}
+		} else {
+			t -= t % ttlMsecs
+		}
+		dst = encoding.MarshalInt64(dst, t)
+	}
+	argKeys := make([]string, 0, len(r.Form))
+	for argKey := range r.Form {
+		switch argKey {
+		case "time", "start", "end", "nocache", "trace", "_":
+			// These args are either handled above or do not influence the response.
+			continue
+		}
+		argKeys = append(argKeys, argKey)
+	}
+	sort.Strings(argKeys)
+	for _, argKey := range argKeys {
+		dst = encoding.MarshalBytes(dst, []byte(argKey))
+		values := r.Form[argKey]
+		dst = encoding.MarshalVarUint64(dst, uint64(len(values)))
+		for _, v := range values {
+			dst = encoding.MarshalBytes(dst, []byte(v))
+		}
+	}
+	return dst, nil
+}
+
+func getTTLMsecs(ttl time.Duration) int64 {
+	ttlMsecs := ttl.Milliseconds()
+	if ttlMsecs <= 0 {
+		ttlMsecs = 1
+	}
+	return ttlMsecs
+}
+
+// cachingResponseWriter collects the response written to http.ResponseWriter, so it could be cached.
+type cachingResponseWriter struct {
+	http.ResponseWriter
+
+	maxSize int
+	buf     []byte
+
+	// skip is set if the response mustn't be cached.
+	skip bool
+}
+
+func (cw *cachingResponseWriter) WriteHeader(statusCode int) {
+	if statusCode != http.StatusOK {
+		cw.skip = true
+	}
+	cw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (cw *cachingResponseWriter) Write(p []byte) (int, error) {
+	if !cw.skip {
+		if len(cw.buf)+len(p) > cw.maxSize {
+			cw.skip = true
+			cw.buf = nil
+		} else {
+			cw.buf = append(cw.buf, p...)
+		}
+	}
+	return cw.ResponseWriter.Write(p)
+}
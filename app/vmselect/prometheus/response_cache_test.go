@@ -0,0 +1,168 @@
+package prometheus
+
+import (
+	"bytes"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/resultcache"
+)
+
+func TestGetResponseCacheKey(t *testing.T) {
+	startTime := time.Unix(1600000000, 0)
+	ttl := 30 * time.Second
+	getKey := func(uri string) []byte {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodGet, uri, nil)
+		key, err := getResponseCacheKey(r, startTime, ttl)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return key
+	}
+	f := func(uri1, uri2 string, equalExpected bool) {
+		t.Helper()
+		key1 := getKey(uri1)
+		key2 := getKey(uri2)
+		if bytes.Equal(key1, key2) != equalExpected {
+			t.Fatalf("unexpected keys equality for %q and %q; got %v; want %v", uri1, uri2, !equalExpected, equalExpected)
+		}
+	}
+
+	// The time arg must be taken into account as is, since it defines timestamps in the response
+	f("/api/v1/query?query=up&time=1600000020", "/api/v1/query?query=up&time=1600000020.000", true)
+	f("/api/v1/query?query=up&time=1600000020", "/api/v1/query?query=up&time=1600000049", false)
+	f("/api/v1/query?query=up", "/api/v1/query?query=up&time=1600000000", false)
+	f("/api/v1/query?query=up", "/api/v1/query?query=up&time=1599999990", false)
+
+	// start and end args within ttl share the same key
+	f("/api/v1/series?match[]=up&start=1600000001&end=1600000002", "/api/v1/series?match[]=up&start=1600000003&end=1600000004", true)
+	f("/api/v1/series?match[]=up&start=1599990000", "/api/v1/series?match[]=up&start=1600000000", false)
+
+	// Args order and cache-unrelated args do not matter
+	f("/api/v1/query?query=up&step=1m&nocache=1&_=123", "/api/v1/query?step=1m&query=up", true)
+
+	// Other args must be taken into account
+	f("/api/v1/query?query=up", "/api/v1/query?query=down", false)
+	f("/api/v1/query?query=up", "/api/v1/query?query=up&step=1m", false)
+	f("/api/v1/query?query=up", "/api/v1/query?query=up&extra_label=tenant=1", false)
+	f("/api/v1/query?query=up&extra_label=tenant=1", "/api/v1/query?query=up&extra_label=tenant=2", false)
+	f("/api/v1/query?query=up&extra_filters[]={a=\"b\"}", "/api/v1/query?query=up", false)
+	f("/api/v1/labels", "/api/v1/label/job/values", false)
+	f("/api/v1/series?match[]=a&match[]=b", "/api/v1/series?match[]=ab", false)
+
+	// Invalid time arg
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up&time=foo", nil)
+	if _, err := getResponseCacheKey(r, startTime, ttl); err == nil {
+		t.Fatalf("expecting non-nil error for invalid time arg")
+	}
+}
+
+func TestGetResponseCacheKeyMissingTime(t *testing.T) {
+	ttl := 30 * time.Second
+	getKey := func(startTime time.Time) []byte {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up", nil)
+		key, err := getResponseCacheKey(r, startTime, ttl)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(r.Form["time"]) > 0 {
+			t.Fatalf("the time arg mustn't be set in the request; got %q", r.Form["time"])
+		}
+		return key
+	}
+
+	// Requests without time arg within ttl share the same key
+	key := getKey(time.Unix(1600000020, 0))
+	if !bytes.Equal(key, getKey(time.Unix(1600000049, 0))) {
+		t.Fatalf("expecting the same key for requests within ttl")
+	}
+	if bytes.Equal(key, getKey(time.Unix(1600000050, 0))) {
+		t.Fatalf("expecting distinct keys for requests outside ttl")
+	}
+}
+
+func TestWithResponseCache(t *testing.T) {
+	if err := flag.Set("search.instantQueryCacheTTL", "1m"); err != nil {
+		t.Fatalf("cannot set -search.instantQueryCacheTTL: %s", err)
+	}
+	resultcache.Init()
+	defer func() {
+		resultcache.MustStop()
+		_ = flag.Set("search.instantQueryCacheTTL", "0")
+		_ = flag.Set("search.disableCache", "false")
+	}()
+
+	calls := 0
+	f := func(uri string, callsExpected int) {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodGet, uri, nil)
+		w := httptest.NewRecorder()
+		err := withResponseCache(resultcache.InstantQuery, nil, time.Now(), w, r, func(w http.ResponseWriter) error {
+			calls++
+			w.Header().Set("Content-Type", "application/json")
+			_, err := w.Write([]byte(`{"status":"success"}`))
+			return err
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if calls != callsExpected {
+			t.Fatalf("unexpected number of calls for %q; got %d; want %d", uri, calls, callsExpected)
+		}
+		if body := w.Body.String(); body != `{"status":"success"}` {
+			t.Fatalf("unexpected response body: %q", body)
+		}
+	}
+	f("/api/v1/query?query=up&time=1600000000", 1)
+	// the response must be served from the cache
+	f("/api/v1/query?query=up&time=1600000000", 1)
+	// another time must result in cache miss
+	f("/api/v1/query?query=up&time=1600000001", 2)
+	// nocache=1 must bypass the cache
+	f("/api/v1/query?query=up&time=1600000000&nocache=1", 3)
+	// -search.disableCache must bypass the cache
+	if err := flag.Set("search.disableCache", "true"); err != nil {
+		t.Fatalf("cannot set -search.disableCache: %s", err)
+	}
+	f("/api/v1/query?query=up&time=1600000000", 4)
+}
+
+func TestCachingResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	cw := &cachingResponseWriter{
+		ResponseWriter: rec,
+		maxSize:        10,
+	}
+	if _, err := cw.Write([]byte("foo")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := cw.Write([]byte("bar")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if cw.skip || string(cw.buf) != "foobar" {
+		t.Fatalf("unexpected state; skip=%v, buf=%q", cw.skip, cw.buf)
+	}
+	if _, err := cw.Write([]byte("bazbaz")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !cw.skip || cw.buf != nil {
+		t.Fatalf("the response exceeding maxSize mustn't be cached; skip=%v, buf=%q", cw.skip, cw.buf)
+	}
+	if rec.Body.String() != "foobarbazbaz" {
+		t.Fatalf("unexpected response body: %q", rec.Body.String())
+	}
+
+	cw = &cachingResponseWriter{
+		ResponseWriter: httptest.NewRecorder(),
+		maxSize:        10,
+	}
+	cw.WriteHeader(http.StatusBadRequest)
+	if !cw.skip {
+		t.Fatalf("non-200 response mustn't be cached")
+	}
+}
@@ -0,0 +1,564 @@
+package promql
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/netstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/searchutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querytracer"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+	"github.com/VictoriaMetrics/metricsql"
+)
+
+// QueryAnalysis contains the results of query analysis.
+type QueryAnalysis struct {
+	// Hints contains warnings about possible issues in the query.
+	Hints []QueryHint
+
+	// SuggestedQuery is the query with all the safe rewrites from Hints applied.
+	//
+	// It is empty if there are no safe rewrites.
+	SuggestedQuery string
+}
+
+// QueryHint is a warning about possible issue in the query.
+type QueryHint struct {
+	// Type is the hint type such as `counterFuncOverGauge`.
+	Type string
+
+	// Expr is the part of the query the hint refers to.
+	Expr string
+
+	// Message is human-readable description of the issue.
+	Message string
+
+	// Suggestion is an optional suggested rewrite for Expr.
+	Suggestion string
+}
+
+// counterFuncs contains rollup functions, which expect counters as input.
+var counterFuncs = map[string]string{
+	// The value is the function, which can be used for gauges instead.
+	"increase":            "delta",
+	"increase_prometheus": "delta_prometheus",
+	"increase_pure":       "delta",
+	"irate":               "ideriv",
+	"rate":                "deriv",
+	"resets":              "changes",
+}
+
+// histogramFuncs contains functions, which expect histogram buckets as the last arg.
+var histogramFuncs = map[string]bool{
+	"histogram_avg":       true,
+	"histogram_quantile":  true,
+	"histogram_quantiles": true,
+	"histogram_share":     true,
+	"histogram_stddev":    true,
+	"histogram_stdvar":    true,
+}
+
+// counterSuffixes contains the conventional suffixes for counter names.
+var counterSuffixes = []string{"_total", "_count", "_sum", "_bucket"}
+
+// maxLookbehindWindowHint is the lookbehind window, which triggers `largeLookbehindWindow` hint.
+const maxLookbehindWindowHint = 24 * 3600 * 1000
+
+// maxSeriesForGaugeDetection is the maximum number of series to inspect when detecting whether the metric is a gauge.
+const maxSeriesForGaugeDetection = 100
+
+// maxSeriesForGaugeSampling is the maximum number of series names to fetch from the index for selecting
+// up to maxSeriesForGaugeDetection series to inspect, when the selector matches more than maxSeriesForGaugeDetection series.
+const maxSeriesForGaugeSampling = 10000
+
+// gaugeDetectionWindow is the time range for raw samples to inspect when detecting whether the metric is a gauge.
+const gaugeDetectionWindow = 15 * 60 * 1000
+
+// AnalyzeQuery analyzes q for common anti-patterns and returns hints with suggested rewrites.
+//
+// Series metadata is obtained from the storage for the time range of ec.
+func AnalyzeQuery(qt *querytracer.Tracer, ec *EvalConfig, q string) (*QueryAnalysis, error) {
+	qt = qt.NewChild("analyze query %q", q)
+	defer qt.Done()
+	ec.validate()
+	e, err := metricsql.Parse(q)
+	if err != nil {
+		return nil, err
+	}
+	qa := &queryAnalyzer{
+		qt:             qt,
+		ec:             ec,
+		checkedMetrics: make(map[string]bool),
+		checkedSelects: make(map[string]bool),
+	}
+	// Analyze the copy of e, since safe rewrites are applied to it in place.
+	eCopy := metricsql.Clone(e)
+	if err := qa.analyzeExpr(eCopy); err != nil {
+		return nil, err
+	}
+	result := &QueryAnalysis{
+		Hints: qa.hints,
+	}
+	if qa.rewritten {
+		result.SuggestedQuery = string(eCopy.AppendString(nil))
+	}
+	qt.Printf("found %d hints", len(result.Hints))
+	return result, nil
+}
+
+type queryAnalyzer struct {
+	qt *querytracer.Tracer
+	ec *EvalConfig
+
+	hints []QueryHint
+
+	// rewritten is set if safe rewrites have been applied to the analyzed expression.
+	rewritten bool
+
+	// checkedMetrics contains metric names, which were already checked for being gauges.
+	checkedMetrics map[string]bool
+
+	// checkedSelects contains series selectors, which were already checked for matching series.
+	checkedSelects map[string]bool
+}
+
+func (qa *queryAnalyzer) addHint(typ string, e metricsql.Expr, suggestion, format string, args ...interface{}) {
+	qa.hints = append(qa.hints, QueryHint{
+		Type:       typ,
+		Expr:       string(e.AppendString(nil)),
+		Message:    fmt.Sprintf(format, args...),
+		Suggestion: suggestion,
+	})
+}
+
+func (qa *queryAnalyzer) analyzeExpr(e metricsql.Expr) error {
+	switch t := e.(type) {
+	case *metricsql.MetricExpr:
+		return qa.analyzeMetricExpr(t)
+	case *metricsql.RollupExpr:
+		if t.Window != nil && !t.ForSubquery() {
+			if window := t.Window.Duration(qa.ec.Step); window > maxLookbehindWindowHint {
+				qa.addHint("largeLookbehindWindow", t, "",
+					"the lookbehind window %s exceeds %s; every calculated point needs to scan all the raw samples on this window, "+
+						"so the query may be slow; consider using smaller window, a subquery with bigger step or recording rules",
+					t.Window.AppendString(nil), time.Duration(maxLookbehindWindowHint)*time.Millisecond)
+			}
+		}
+		if err := qa.analyzeExpr(t.Expr); err != nil {
+			return err
+		}
+		if t.At != nil {
+			return qa.analyzeExpr(t.At)
+		}
+		return nil
+	case *metricsql.FuncExpr:
+		if err := qa.analyzeFuncExpr(t); err != nil {
+			return err
+		}
+		return qa.analyzeExprs(t.Args)
+	case *metricsql.AggrFuncExpr:
+		return qa.analyzeExprs(t.Args)
+	case *metricsql.BinaryOpExpr:
+		return qa.analyzeExprs([]metricsql.Expr{t.Left, t.Right})
+	default:
+		return nil
+	}
+}
+
+func (qa *queryAnalyzer) analyzeExprs(es []metricsql.Expr) error {
+	for _, e := range es {
+		if err := qa.analyzeExpr(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (qa *queryAnalyzer) analyzeFuncExpr(fe *metricsql.FuncExpr) error {
+	name := strings.ToLower(fe.Name)
+	if histogramFuncs[name] && len(fe.Args) > 0 {
+		qa.analyzeHistogramArg(fe, fe.Args[len(fe.Args)-1])
+	}
+	gaugeFunc, ok := counterFuncs[name]
+	if !ok {
+		return nil
+	}
+	argIdx := metricsql.GetRollupArgIdx(fe)
+	if argIdx < 0 || argIdx >= len(fe.Args) {
+		return nil
+	}
+	re := getRollupExprArg(fe.Args[argIdx])
+	if re.ForSubquery() {
+		if ae, ok := re.Expr.(*metricsql.AggrFuncExpr); ok {
+			qa.analyzeCounterFuncOverAggregate(fe, re, ae)
+		}
+		return nil
+	}
+	me, ok := re.Expr.(*metricsql.MetricExpr)
+	if !ok {
+		return nil
+	}
+	return qa.analyzeCounterFuncOverSelector(fe, gaugeFunc, me)
+}
+
+// analyzeHistogramArg checks whether the aggregate function over histogram buckets preserves the bucket label.
+func (qa *queryAnalyzer) analyzeHistogramArg(fe *metricsql.FuncExpr, arg metricsql.Expr) {
+	ae, ok := arg.(*metricsql.AggrFuncExpr)
+	if !ok {
+		return
+	}
+	mod := &ae.Modifier
+	switch strings.ToLower(mod.Op) {
+	case "":
+		suggestion := *ae
+		suggestion.Modifier = metricsql.ModifierExpr{
+			Op:   "by",
+			Args: []string{"le"},
+		}
+		qa.addHint("histogramWithoutLe", ae, string(suggestion.AppendString(nil)),
+			"%s() without `by (le)` drops `le` label from histogram buckets, so %s() cannot calculate the result; add `by (le)` to %s()",
+			ae.Name, fe.Name, ae.Name)
+		*mod = suggestion.Modifier
+		qa.rewritten = true
+	case "by":
+		for _, arg := range mod.Args {
+			if arg == "le" || arg == "vmrange" {
+				return
+			}
+		}
+		suggestion := *ae
+		suggestion.Modifier = metricsql.ModifierExpr{
+			Op:   mod.Op,
+			Args: append(append([]string{}, mod.Args...), "le"),
+		}
+		qa.addHint("histogramWithoutLe", ae, string(suggestion.AppendString(nil)),
+			"%s() by (%s) drops `le` label from histogram buckets, so %s() cannot calculate the result; add `le` to the `by` list",
+			ae.Name, strings.Join(mod.Args, ", "), fe.Name)
+		*mod = suggestion.Modifier
+		qa.rewritten = true
+	case "without":
+		var args []string
+		for _, arg := range mod.Args {
+			if arg != "le" && arg != "vmrange" {
+				args = append(args, arg)
+			}
+		}
+		if len(args) == len(mod.Args) {
+			return
+		}
+		// Keep `without` even if the list becomes empty, since `without ()` preserves all the labels,
+		// while the aggregate function without modifier drops all the labels including the bucket label.
+		suggestion := *ae
+		suggestion.Modifier = metricsql.ModifierExpr{
+			Op:   mod.Op,
+			Args: args,
+		}
+		qa.addHint("histogramWithoutLe", ae, string(suggestion.AppendString(nil)),
+			"%s() without (%s) drops the bucket label from histogram buckets, so %s() cannot calculate the result; remove the bucket label from the `without` list",
+			ae.Name, strings.Join(mod.Args, ", "), fe.Name)
+		*mod = suggestion.Modifier
+		qa.rewritten = true
+	}
+}
+
+// analyzeCounterFuncOverAggregate checks for `rate(sum(x)[d:])` anti-pattern.
+func (qa *queryAnalyzer) analyzeCounterFuncOverAggregate(fe *metricsql.FuncExpr, re *metricsql.RollupExpr, ae *metricsql.AggrFuncExpr) {
+	suggestion := ""
+	if len(ae.Args) == 1 {
+		if me, ok := ae.Args[0].(*metricsql.MetricExpr); ok && re.Window != nil {
+			// Suggest `sum(rate(x[d]))` instead of `rate(sum(x)[d:])`.
+			reNew := &metricsql.RollupExpr{
+				Expr:   me,
+				Window: re.Window,
+				Offset: re.Offset,
+				At:     re.At,
+			}
+			feNew := &metricsql.FuncExpr{
+				Name: fe.Name,
+				Args: []metricsql.Expr{reNew},
+			}
+			aeNew := *ae
+			aeNew.Args = []metricsql.Expr{feNew}
+			suggestion = string(aeNew.AppendString(nil))
+		}
+	}
+	qa.addHint("counterFuncOverAggregate", fe, suggestion,
+		"%s() over %s() may return unexpected results, since counter resets in individual series are hidden by the aggregation; "+
+			"apply %s() to individual series before the aggregation", fe.Name, ae.Name, fe.Name)
+}
+
+// analyzeCounterFuncOverSelector checks whether counter function such as rate() is applied to gauges.
+func (qa *queryAnalyzer) analyzeCounterFuncOverSelector(fe *metricsql.FuncExpr, gaugeFunc string, me *metricsql.MetricExpr) error {
+	metricName := getMetricNameFromFilters(me.LabelFilters)
+	if metricName == "" || qa.checkedMetrics[metricName] {
+		return nil
+	}
+	qa.checkedMetrics[metricName] = true
+	for _, suffix := range counterSuffixes {
+		if strings.HasSuffix(metricName, suffix) {
+			return nil
+		}
+	}
+	suggestion := *fe
+	suggestion.Name = gaugeFunc
+	isGauge, samples, err := qa.isGauge(me)
+	if err != nil {
+		return err
+	}
+	if isGauge {
+		qa.addHint("counterFuncOverGauge", fe, string(suggestion.AppendString(nil)),
+			"%s() expects counter, while %q looks like a gauge, since its values decrease frequently; use %s() for gauges",
+			fe.Name, metricName, gaugeFunc)
+		return nil
+	}
+	if samples == 0 {
+		// There is no data for checking the metric type. Fall back to name-based check.
+		qa.addHint("counterFuncOverGauge", fe, "",
+			"%s() expects counter, while the name %q doesn't end with any of the conventional counter suffixes %s; "+
+				"make sure the metric is a counter, otherwise use %s() for gauges",
+			fe.Name, metricName, strings.Join(counterSuffixes, ", "), gaugeFunc)
+	}
+	return nil
+}
+
+// isGauge returns true if the series matching me look like gauges according to their raw samples.
+//
+// Up to maxSeriesForGaugeDetection series are inspected. It also returns the number of inspected samples.
+func (qa *queryAnalyzer) isGauge(me *metricsql.MetricExpr) (bool, int, error) {
+	ec := qa.ec
+	end := ec.End
+	start := end - gaugeDetectionWindow
+	tfs := searchutils.ToTagFilters(me.LabelFilters)
+	tfss := searchutils.JoinTagFilterss([][]storage.TagFilter{tfs}, ec.EnforcedTagFilterss)
+	sq := storage.NewSearchQuery(start, end, tfss, maxSeriesForGaugeDetection)
+	rss, err := netstorage.ProcessSearchQuery(qa.qt, sq, ec.Deadline)
+	if err != nil {
+		if !errors.Is(err, storage.ErrTooManyTimeseries) {
+			return false, 0, &UserReadableError{
+				Err: err,
+			}
+		}
+		// The selector matches too many series. Inspect a bounded sample of them.
+		tfssSample, err := qa.sampleSeries(me, sq)
+		if err != nil {
+			return false, 0, err
+		}
+		if len(tfssSample) == 0 {
+			return false, 0, nil
+		}
+		sq = storage.NewSearchQuery(start, end, tfssSample, maxSeriesForGaugeDetection)
+		rss, err = netstorage.ProcessSearchQuery(qa.qt, sq, ec.Deadline)
+		if err != nil {
+			return false, 0, &UserReadableError{
+				Err: err,
+			}
+		}
+	}
+	var deltas, decreases uint64
+	var samples uint64
+	err = rss.RunParallel(qa.qt, func(rs *netstorage.Result, workerID uint) error {
+		var d, dec uint64
+		values := rs.Values
+		for i := 1; i < len(values); i++ {
+			if values[i] == values[i-1] {
+				continue
+			}
+			d++
+			if values[i] < values[i-1] {
+				dec++
+			}
+		}
+		atomic.AddUint64(&deltas, d)
+		atomic.AddUint64(&decreases, dec)
+		atomic.AddUint64(&samples, uint64(len(values)))
+		return nil
+	})
+	if err != nil {
+		return false, 0, &UserReadableError{
+			Err: err,
+		}
+	}
+	// Counters may decrease only on resets, which are rare.
+	isGauge := deltas >= 10 && decreases*10 > deltas
+	qa.qt.Printf("gauge detection for %s: samples=%d, deltas=%d, decreases=%d, isGauge=%v", me.AppendString(nil), samples, deltas, decreases, isGauge)
+	return isGauge, int(samples), nil
+}
+
+// sampleSeries returns tag filters for up to maxSeriesForGaugeDetection series evenly selected from series matching sq.
+//
+// Nil is returned if sq matches more than maxSeriesForGaugeSampling series.
+func (qa *queryAnalyzer) sampleSeries(me *metricsql.MetricExpr, sq *storage.SearchQuery) ([][]storage.TagFilter, error) {
+	sqNames := *sq
+	sqNames.MaxMetrics = maxSeriesForGaugeSampling
+	metricNames, err := netstorage.SearchMetricNames(qa.qt, &sqNames, qa.ec.Deadline)
+	if err != nil {
+		if errors.Is(err, storage.ErrTooManyTimeseries) {
+			qa.qt.Printf("skip gauge detection for %s: %s", me.AppendString(nil), err)
+			return nil, nil
+		}
+		return nil, &UserReadableError{
+			Err: err,
+		}
+	}
+	n := len(metricNames)
+	if n > maxSeriesForGaugeDetection {
+		n = maxSeriesForGaugeDetection
+	}
+	tfss := make([][]storage.TagFilter, 0, n)
+	var mn storage.MetricName
+	for i := 0; i < n; i++ {
+		// metricNames are sorted, so select them with the same step in order to cover various label values.
+		metricName := metricNames[i*len(metricNames)/n]
+		if err := mn.UnmarshalString(metricName); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal metric name: %w", err)
+		}
+		tfs := make([]storage.TagFilter, 0, len(mn.Tags)+1)
+		tfs = append(tfs, storage.TagFilter{
+			Value: append([]byte{}, mn.MetricGroup...),
+		})
+		for _, tag := range mn.Tags {
+			tfs = append(tfs, storage.TagFilter{
+				Key:   append([]byte{}, tag.Key...),
+				Value: append([]byte{}, tag.Value...),
+			})
+		}
+		tfss = append(tfss, tfs)
+	}
+	qa.qt.Printf("sample %d out of %d series for gauge detection for %s", len(tfss), len(metricNames), me.AppendString(nil))
+	return tfss, nil
+}
+
+func (qa *queryAnalyzer) analyzeMetricExpr(me *metricsql.MetricExpr) error {
+	if me.IsEmpty() {
+		return nil
+	}
+	if err := qa.checkMatchingSeries(me); err != nil {
+		return err
+	}
+	lfs := me.LabelFilters
+	for i := range lfs {
+		lf := &lfs[i]
+		if !lf.IsRegexp {
+			continue
+		}
+		lfNew, ok := simplifyRegexpFilter(lf)
+		if !ok {
+			continue
+		}
+		suggestion := ""
+		if lfNew != nil {
+			suggestion = string(lfNew.AppendString(nil))
+		}
+		lfExpr := &metricsql.MetricExpr{
+			LabelFilters: []metricsql.LabelFilter{*lf},
+		}
+		if lfNew == nil {
+			qa.addHint("redundantRegexpFilter", lfExpr, suggestion,
+				"the filter %s matches any value, including empty value, so it can be removed", lf.AppendString(nil))
+		} else {
+			qa.addHint("regexpFilterCanBeSimplified", lfExpr, suggestion,
+				"the filter %s can be replaced with faster and more readable filter %s", lf.AppendString(nil), suggestion)
+		}
+	}
+
+	// Apply the rewrites.
+	var lfsNew []metricsql.LabelFilter
+	changed := false
+	for i := range lfs {
+		lf := &lfs[i]
+		if !lf.IsRegexp {
+			lfsNew = append(lfsNew, *lf)
+			continue
+		}
+		lfNew, ok := simplifyRegexpFilter(lf)
+		if !ok {
+			lfsNew = append(lfsNew, *lf)
+			continue
+		}
+		changed = true
+		if lfNew != nil {
+			lfsNew = append(lfsNew, *lfNew)
+		}
+	}
+	if !changed || len(lfsNew) == 0 {
+		// Do not leave empty selector, since it matches nothing.
+		return nil
+	}
+	me.LabelFilters = lfsNew
+	qa.rewritten = true
+	return nil
+}
+
+// checkMatchingSeries adds `noMatchingSeries` hint if me doesn't match any series on the selected time range.
+func (qa *queryAnalyzer) checkMatchingSeries(me *metricsql.MetricExpr) error {
+	selector := string(me.AppendString(nil))
+	if qa.checkedSelects[selector] {
+		return nil
+	}
+	qa.checkedSelects[selector] = true
+	ec := qa.ec
+	start := ec.Start - maxSilenceInterval
+	if start < 0 {
+		start = 0
+	}
+	// Limit the number of days to check, since it is enough for detecting typos in selectors.
+	if d := ec.End - maxDaysForCostEstimation*msecsPerDay; d > start {
+		start = d
+	}
+	tfs := searchutils.ToTagFilters(me.LabelFilters)
+	tfss := searchutils.JoinTagFilterss([][]storage.TagFilter{tfs}, ec.EnforcedTagFilterss)
+	sq := storage.NewSearchQuery(start, ec.End, tfss, ec.MaxSeries)
+	counts, err := netstorage.SeriesCountPerDay(qa.qt, sq, ec.Deadline)
+	if err != nil {
+		return &UserReadableError{
+			Err: err,
+		}
+	}
+	for _, n := range counts {
+		if n > 0 {
+			return nil
+		}
+	}
+	qa.addHint("noMatchingSeries", me, "",
+		"the selector doesn't match any series on the selected time range; check it for typos in metric name and label filters")
+	return nil
+}
+
+// simplifyRegexpFilter returns simplified filter for the given regexp filter lf.
+//
+// It returns nil filter if lf can be removed. It returns false if lf cannot be simplified.
+func simplifyRegexpFilter(lf *metricsql.LabelFilter) (*metricsql.LabelFilter, bool) {
+	switch lf.Value {
+	case ".*":
+		if lf.IsNegative || lf.Label == "__name__" {
+			return nil, false
+		}
+		return nil, true
+	case ".+":
+		return &metricsql.LabelFilter{
+			Label:      lf.Label,
+			IsNegative: !lf.IsNegative,
+		}, true
+	}
+	if lf.Value == "" || regexp.QuoteMeta(lf.Value) != lf.Value {
+		return nil, false
+	}
+	return &metricsql.LabelFilter{
+		Label:      lf.Label,
+		Value:      lf.Value,
+		IsNegative: lf.IsNegative,
+	}, true
+}
+
+func getMetricNameFromFilters(lfs []metricsql.LabelFilter) string {
+	for _, lf := range lfs {
+		if lf.Label == "__name__" && !lf.IsNegative && !lf.IsRegexp {
+			return lf.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,167 @@
+package promql
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/netstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/searchutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+	"github.com/VictoriaMetrics/metricsql"
+)
+
+func TestSimplifyRegexpFilter(t *testing.T) {
+	f := func(q, resultExpected string, okExpected bool) {
+		t.Helper()
+		e, err := metricsql.Parse(q)
+		if err != nil {
+			t.Fatalf("cannot parse %q: %s", q, err)
+		}
+		me := e.(*metricsql.MetricExpr)
+		lf := &me.LabelFilters[len(me.LabelFilters)-1]
+		lfNew, ok := simplifyRegexpFilter(lf)
+		if ok != okExpected {
+			t.Fatalf("unexpected ok for %q; got %v; want %v", q, ok, okExpected)
+		}
+		result := ""
+		if lfNew != nil {
+			result = string(lfNew.AppendString(nil))
+		}
+		if result != resultExpected {
+			t.Fatalf("unexpected result for %q; got %q; want %q", q, result, resultExpected)
+		}
+	}
+
+	// Literal values
+	f(`foo{bar=~"baz"}`, `bar="baz"`, true)
+	f(`foo{bar!~"baz"}`, `bar!="baz"`, true)
+	f(`foo{bar=~"baz_1-2"}`, `bar="baz_1-2"`, true)
+
+	// Match-all regexps
+	f(`foo{bar=~".*"}`, ``, true)
+	f(`foo{bar=~".+"}`, `bar!=""`, true)
+	f(`foo{bar!~".+"}`, `bar=""`, true)
+	f(`foo{bar!~".*"}`, ``, false)
+	f(`{__name__=~".*"}`, ``, false)
+
+	// Non-trivial regexps
+	f(`foo{bar=~"a|b"}`, ``, false)
+	f(`foo{bar=~"a.+"}`, ``, false)
+	f(`foo{bar=~""}`, ``, false)
+	f(`foo{bar=~"1.2.3.4"}`, ``, false)
+}
+
+func TestAnalyzeHistogramArg(t *testing.T) {
+	f := func(q, suggestionExpected string) {
+		t.Helper()
+		e, err := metricsql.Parse(q)
+		if err != nil {
+			t.Fatalf("cannot parse %q: %s", q, err)
+		}
+		fe := e.(*metricsql.FuncExpr)
+		qa := &queryAnalyzer{}
+		qa.analyzeHistogramArg(fe, fe.Args[len(fe.Args)-1])
+		if suggestionExpected == "" {
+			if len(qa.hints) > 0 || qa.rewritten {
+				t.Fatalf("unexpected hints for %q: %+v", q, qa.hints)
+			}
+			return
+		}
+		if len(qa.hints) != 1 {
+			t.Fatalf("unexpected number of hints for %q; got %d; want 1", q, len(qa.hints))
+		}
+		h := qa.hints[0]
+		if h.Type != "histogramWithoutLe" {
+			t.Fatalf("unexpected hint type for %q; got %q; want %q", q, h.Type, "histogramWithoutLe")
+		}
+		if h.Suggestion != suggestionExpected {
+			t.Fatalf("unexpected suggestion for %q; got %q; want %q", q, h.Suggestion, suggestionExpected)
+		}
+		if !qa.rewritten {
+			t.Fatalf("expecting rewritten query for %q", q)
+		}
+	}
+
+	f(`histogram_quantile(0.9, sum(rate(foo_bucket[5m])))`, `sum(rate(foo_bucket[5m])) by (le)`)
+	f(`histogram_quantile(0.9, sum(rate(foo_bucket[5m])) by (job))`, `sum(rate(foo_bucket[5m])) by (job, le)`)
+	f(`histogram_quantile(0.9, sum(rate(foo_bucket[5m])) without (le, job))`, `sum(rate(foo_bucket[5m])) without (job)`)
+	f(`histogram_quantile(0.9, sum(rate(foo_bucket[5m])) without (le))`, `sum(rate(foo_bucket[5m])) without ()`)
+	f(`histogram_share(10, max(foo_bucket))`, `max(foo_bucket) by (le)`)
+
+	// The bucket label is preserved
+	f(`histogram_quantile(0.9, sum(rate(foo_bucket[5m])) by (job, le))`, ``)
+	f(`histogram_quantile(0.9, sum(rate(foo_bucket[5m])) by (vmrange))`, ``)
+	f(`histogram_quantile(0.9, sum(rate(foo_bucket[5m])) without (job))`, ``)
+	f(`histogram_quantile(0.9, rate(foo_bucket[5m]))`, ``)
+}
+
+func TestQueryAnalyzerIsGauge(t *testing.T) {
+	path := "TestQueryAnalyzerIsGauge"
+	vmstorage.Storage = storage.MustOpenStorage(path, 0, 0, 0)
+	netstorage.InitTmpBlocksDir(path + "/tmp")
+	defer func() {
+		vmstorage.Storage.MustClose()
+		vmstorage.Storage = nil
+		_ = os.RemoveAll(path)
+	}()
+
+	// Register more series than maxSeriesForGaugeDetection, so the gauge detection must sample them.
+	end := time.Now().UnixNano() / 1e6
+	var mrs []storage.MetricRow
+	for i := 0; i < 3*maxSeriesForGaugeDetection; i++ {
+		labels := []prompb.Label{
+			{Name: []byte("__name__"), Value: []byte("temperature")},
+			{Name: []byte("instance"), Value: []byte(fmt.Sprintf("host-%d", i))},
+		}
+		metricNameRaw := storage.MarshalMetricNameRaw(nil, labels)
+		for j := 0; j < 20; j++ {
+			// Values go up and down like gauges do.
+			v := float64(j % 2)
+			mrs = append(mrs, storage.MetricRow{
+				MetricNameRaw: metricNameRaw,
+				Timestamp:     end - int64(j+1)*15000,
+				Value:         v,
+			})
+		}
+	}
+	if err := vmstorage.Storage.AddRows(mrs, 64); err != nil {
+		t.Fatalf("cannot add rows: %s", err)
+	}
+	vmstorage.Storage.DebugFlush()
+
+	f := func(q string, isGaugeExpected bool, samplesExpected int) {
+		t.Helper()
+		qa := &queryAnalyzer{
+			ec: &EvalConfig{
+				Start:    end - 3600*1000,
+				End:      end,
+				Step:     60 * 1000,
+				Deadline: searchutils.NewDeadline(time.Now(), time.Minute, ""),
+			},
+		}
+		e, err := metricsql.Parse(q)
+		if err != nil {
+			t.Fatalf("cannot parse %q: %s", q, err)
+		}
+		isGauge, samples, err := qa.isGauge(e.(*metricsql.MetricExpr))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if isGauge != isGaugeExpected {
+			t.Fatalf("unexpected isGauge for %q; got %v; want %v", q, isGauge, isGaugeExpected)
+		}
+		if samples != samplesExpected {
+			t.Fatalf("unexpected number of inspected samples for %q; got %d; want %d", q, samples, samplesExpected)
+		}
+	}
+	// The selector matches less than maxSeriesForGaugeDetection series
+	f(`temperature{instance=~"host-[0-9]"}`, true, 10*20)
+	// The selector matches more than maxSeriesForGaugeDetection series, so only a sample of them is inspected
+	f(`temperature`, true, maxSeriesForGaugeDetection*20)
+	// The selector matches no series
+	f(`missing_metric`, false, 0)
+}
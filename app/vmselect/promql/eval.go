@@ -138,6 +138,11 @@ type EvalConfig struct {
 	// Otherwise the stats isn't collected.
 	QueryStats *QueryStats
 
+	// ConcurrencySlot is the slot for concurrently executed requests held by the currently executed query.
+	//
+	// It is released while the query waits in the queue of expensive queries. It may be nil.
+	ConcurrencySlot *searchutils.ConcurrencySlot
+
 	timestamps     []int64
 	timestampsOnce sync.Once
 }
@@ -157,6 +162,7 @@ func copyEvalConfig(src *EvalConfig) *EvalConfig {
 	ec.EnforcedTagFilterss = src.EnforcedTagFilterss
 	ec.GetRequestURI = src.GetRequestURI
 	ec.QueryStats = src.QueryStats
+	ec.ConcurrencySlot = src.ConcurrencySlot
 
 	// do not copy src.timestamps - they must be generated again.
 	return &ec
@@ -164,6 +170,25 @@ func copyEvalConfig(src *EvalConfig) *EvalConfig {
 
 // QueryStats contains various stats for the query.
 type QueryStats struct {
+	// Atomic fields must go at the top of the structure in order to properly align by 8 bytes on 32-bit archs.
+
+	// SamplesScanned contains the number of raw samples scanned during the query evaluation.
+	SamplesScanned int64
+
+	// BytesRead contains the size of compressed data blocks read from storage during the query evaluation.
+	BytesRead int64
+
+	// EstimatedMemoryBytes contains the maximum estimated memory size needed for a single rollup during the query evaluation.
+	//
+	// The estimate is calculated before the rollup evaluation, so it may differ from the actual memory usage.
+	EstimatedMemoryBytes int64
+
+	// CacheHits contains the number of full or partial hits in rollup result cache during the query evaluation.
+	CacheHits int64
+
+	// CacheMisses contains the number of misses in rollup result cache during the query evaluation.
+	CacheMisses int64
+
 	// SeriesFetched contains the number of series fetched from storage during the query evaluation.
 	SeriesFetched int
 }
@@ -174,6 +199,42 @@ func (qs *QueryStats) addSeriesFetched(n int) {
 	}
 }
 
+func (qs *QueryStats) addSamplesScanned(n uint64) {
+	if qs != nil {
+		atomic.AddInt64(&qs.SamplesScanned, int64(n))
+	}
+}
+
+func (qs *QueryStats) addBytesRead(n int64) {
+	if qs != nil {
+		atomic.AddInt64(&qs.BytesRead, n)
+	}
+}
+
+func (qs *QueryStats) updateEstimatedMemoryBytes(n int64) {
+	if qs == nil {
+		return
+	}
+	for {
+		v := atomic.LoadInt64(&qs.EstimatedMemoryBytes)
+		if n <= v || atomic.CompareAndSwapInt64(&qs.EstimatedMemoryBytes, v, n) {
+			return
+		}
+	}
+}
+
+func (qs *QueryStats) addCacheHit() {
+	if qs != nil {
+		atomic.AddInt64(&qs.CacheHits, 1)
+	}
+}
+
+func (qs *QueryStats) addCacheMiss() {
+	if qs != nil {
+		atomic.AddInt64(&qs.CacheMisses, 1)
+	}
+}
+
 func (ec *EvalConfig) validate() {
 	if ec.Start > ec.End {
 		logger.Panicf("BUG: start cannot exceed end; got %d vs %d", ec.Start, ec.End)
@@ -926,6 +987,7 @@ func evalRollupFuncWithSubquery(qt *querytracer.Tracer, ec *EvalConfig, funcName
 	}
 
 	var samplesScannedTotal uint64
+	setMaxParallelChunks(rcs, len(tssSQ))
 	keepMetricNames := getKeepMetricNames(expr)
 	tsw := getTimeseriesByWorkerID()
 	seriesByWorkerID := tsw.byWorkerID
@@ -953,12 +1015,27 @@ func evalRollupFuncWithSubquery(qt *querytracer.Tracer, ec *EvalConfig, funcName
 	putTimeseriesByWorkerID(tsw)
 
 	rowsScannedPerQuery.Update(float64(samplesScannedTotal))
+	ec.QueryStats.addSamplesScanned(samplesScannedTotal)
 	qt.Printf("rollup %s() over %d series returned by subquery: series=%d, samplesScanned=%d", funcName, len(tssSQ), len(tss), samplesScannedTotal)
 	return tss, nil
 }
 
 var rowsScannedPerQuery = metrics.NewHistogram(`vm_rows_scanned_per_query`)
 
+// setMaxParallelChunks allows splitting rollup calculations for a single series into time range chunks,
+// which are processed in parallel, when the number of series is smaller than the number of available workers.
+//
+// This allows utilizing all the CPU cores for heavy rollups over long time ranges for a small number of series.
+func setMaxParallelChunks(rcs []*rollupConfig, seriesCount int) {
+	if seriesCount <= 0 {
+		return
+	}
+	n := netstorage.MaxWorkers() / seriesCount
+	for _, rc := range rcs {
+		rc.maxParallelChunks = n
+	}
+}
+
 func getKeepMetricNames(expr metricsql.Expr) bool {
 	if ae, ok := expr.(*metricsql.AggrFuncExpr); ok {
 		// Extract rollupFunc(...) from aggrFunc(rollupFunc(...)).
@@ -1058,12 +1135,15 @@ func evalRollupFuncWithMetricExpr(qt *querytracer.Tracer, ec *EvalConfig, funcNa
 	if start > ec.End {
 		// The result is fully cached.
 		rollupResultCacheFullHits.Inc()
+		ec.QueryStats.addCacheHit()
 		return tssCached, nil
 	}
 	if start > ec.Start {
 		rollupResultCachePartialHits.Inc()
+		ec.QueryStats.addCacheHit()
 	} else {
 		rollupResultCacheMiss.Inc()
+		ec.QueryStats.addCacheMiss()
 	}
 
 	// Obtain rollup configs before fetching data from db,
@@ -1097,6 +1177,7 @@ func evalRollupFuncWithMetricExpr(qt *querytracer.Tracer, ec *EvalConfig, funcNa
 		return tss, nil
 	}
 	ec.QueryStats.addSeriesFetched(rssLen)
+	ec.QueryStats.addBytesRead(rss.BytesRead())
 
 	// Verify timeseries fit available memory after the rollup.
 	// Take into account points from tssCached.
@@ -1122,6 +1203,7 @@ func evalRollupFuncWithMetricExpr(qt *querytracer.Tracer, ec *EvalConfig, funcNa
 	}
 	rollupPoints := mulNoOverflow(pointsPerTimeseries, int64(timeseriesLen*len(rcs)))
 	rollupMemorySize = sumNoOverflow(mulNoOverflow(int64(rssLen), 1000), mulNoOverflow(rollupPoints, 16))
+	ec.QueryStats.updateEstimatedMemoryBytes(rollupMemorySize)
 	if maxMemory := int64(logQueryMemoryUsage.N); maxMemory > 0 && rollupMemorySize > maxMemory {
 		requestURI := ec.GetRequestURI()
 		logger.Warnf("remoteAddr=%s, requestURI=%s: the %s requires %d bytes of memory for processing; "+
@@ -1154,12 +1236,13 @@ func evalRollupFuncWithMetricExpr(qt *querytracer.Tracer, ec *EvalConfig, funcNa
 	defer rml.Put(uint64(rollupMemorySize))
 
 	// Evaluate rollup
+	setMaxParallelChunks(rcs, rssLen)
 	keepMetricNames := getKeepMetricNames(expr)
 	var tss []*timeseries
 	if iafc != nil {
-		tss, err = evalRollupWithIncrementalAggregate(qt, funcName, keepMetricNames, iafc, rss, rcs, preFunc, sharedTimestamps)
+		tss, err = evalRollupWithIncrementalAggregate(qt, ec.QueryStats, funcName, keepMetricNames, iafc, rss, rcs, preFunc, sharedTimestamps)
 	} else {
-		tss, err = evalRollupNoIncrementalAggregate(qt, funcName, keepMetricNames, rss, rcs, preFunc, sharedTimestamps)
+		tss, err = evalRollupNoIncrementalAggregate(qt, ec.QueryStats, funcName, keepMetricNames, rss, rcs, preFunc, sharedTimestamps)
 	}
 	if err != nil {
 		return nil, &UserReadableError{
@@ -1183,7 +1266,7 @@ func getRollupMemoryLimiter() *memoryLimiter {
 	return &rollupMemoryLimiter
 }
 
-func evalRollupWithIncrementalAggregate(qt *querytracer.Tracer, funcName string, keepMetricNames bool,
+func evalRollupWithIncrementalAggregate(qt *querytracer.Tracer, qs *QueryStats, funcName string, keepMetricNames bool,
 	iafc *incrementalAggrFuncContext, rss *netstorage.Results, rcs []*rollupConfig,
 	preFunc func(values []float64, timestamps []int64), sharedTimestamps []int64) ([]*timeseries, error) {
 	qt = qt.NewChild("rollup %s() with incremental aggregation %s() over %d series; rollupConfigs=%s", funcName, iafc.ae.Name, rss.Len(), rcs)
@@ -1219,11 +1302,12 @@ func evalRollupWithIncrementalAggregate(qt *querytracer.Tracer, funcName string,
 	}
 	tss := iafc.finalizeTimeseries()
 	rowsScannedPerQuery.Update(float64(samplesScannedTotal))
+	qs.addSamplesScanned(samplesScannedTotal)
 	qt.Printf("series after aggregation with %s(): %d; samplesScanned=%d", iafc.ae.Name, len(tss), samplesScannedTotal)
 	return tss, nil
 }
 
-func evalRollupNoIncrementalAggregate(qt *querytracer.Tracer, funcName string, keepMetricNames bool, rss *netstorage.Results, rcs []*rollupConfig,
+func evalRollupNoIncrementalAggregate(qt *querytracer.Tracer, qs *QueryStats, funcName string, keepMetricNames bool, rss *netstorage.Results, rcs []*rollupConfig,
 	preFunc func(values []float64, timestamps []int64), sharedTimestamps []int64) ([]*timeseries, error) {
 	qt = qt.NewChild("rollup %s() over %d series; rollupConfigs=%s", funcName, rss.Len(), rcs)
 	defer qt.Done()
@@ -1259,6 +1343,7 @@ func evalRollupNoIncrementalAggregate(qt *querytracer.Tracer, funcName string, k
 	putTimeseriesByWorkerID(tsw)
 
 	rowsScannedPerQuery.Update(float64(samplesScannedTotal))
+	qs.addSamplesScanned(samplesScannedTotal)
 	qt.Printf("samplesScanned=%d", samplesScannedTotal)
 	return tss, nil
 }
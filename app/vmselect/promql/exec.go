@@ -48,8 +48,27 @@ func (ure *UserReadableError) Error() string {
 // Exec executes q for the given ec.
 func Exec(qt *querytracer.Tracer, ec *EvalConfig, q string, isFirstPointOnly bool) ([]netstorage.Result, error) {
 	if querystats.Enabled() {
+		if ec.QueryStats == nil {
+			ec.QueryStats = &QueryStats{}
+		}
 		startTime := time.Now()
-		defer querystats.RegisterQuery(q, ec.End-ec.Start, startTime)
+		defer func() {
+			qs := ec.QueryStats
+			qd := &querystats.QueryDetails{
+				Query:                q,
+				Start:                ec.Start,
+				End:                  ec.End,
+				Step:                 ec.Step,
+				Client:               ec.QuotedRemoteAddr,
+				SeriesFetched:        qs.SeriesFetched,
+				SamplesScanned:       atomic.LoadInt64(&qs.SamplesScanned),
+				BytesRead:            atomic.LoadInt64(&qs.BytesRead),
+				EstimatedMemoryBytes: atomic.LoadInt64(&qs.EstimatedMemoryBytes),
+				CacheHits:            atomic.LoadInt64(&qs.CacheHits),
+				CacheMisses:          atomic.LoadInt64(&qs.CacheMisses),
+			}
+			querystats.RegisterQuery(qd, startTime)
+		}()
 	}
 
 	ec.validate()
@@ -59,6 +78,11 @@ func Exec(qt *querytracer.Tracer, ec *EvalConfig, q string, isFirstPointOnly boo
 		return nil, err
 	}
 
+	release, err := admitQuery(qt, ec, e)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 	qid := activeQueriesV.Add(ec, q)
 	rv, err := evalExpr(qt, ec, e)
 	activeQueriesV.Remove(qid)
@@ -0,0 +1,326 @@
+package promql
+
+import (
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/netstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/searchutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querytracer"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/timerpool"
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/VictoriaMetrics/metricsql"
+)
+
+var (
+	maxEstimatedSamplesPerQuery = flag.Int64("search.maxEstimatedSamplesPerQuery", 0, "Queries with the estimated number of raw samples to read exceeding this value "+
+		"are rejected before the execution. Zero disables the limit. See https://docs.victoriametrics.com/#query-cost-estimation")
+	expensiveQueryEstimatedSamples = flag.Int64("search.expensiveQueryEstimatedSamples", 0, "Queries with the estimated number of raw samples to read exceeding this value "+
+		"are considered expensive. The number of concurrently executed expensive queries is limited by -search.maxConcurrentExpensiveQueries . "+
+		"Zero disables the limit. See https://docs.victoriametrics.com/#query-cost-estimation")
+	maxConcurrentExpensiveQueries = flag.Int("search.maxConcurrentExpensiveQueries", 1, "The maximum number of concurrently executed expensive queries. "+
+		"Other expensive queries wait in the queue until the query deadline. See -search.expensiveQueryEstimatedSamples")
+)
+
+// maxDaysForCostEstimation is the maximum number of days to query the index for during query cost estimation.
+//
+// The cost for longer time ranges is extrapolated from the cost for the last maxDaysForCostEstimation days
+// in order to keep the estimation cheap.
+const maxDaysForCostEstimation = 31
+
+// defaultSampleDensity is the number of samples per series per second, which is used when the storage has no enough data
+// for estimating the real density. It corresponds to 15s scrape interval.
+const defaultSampleDensity = 1.0 / 15
+
+const msecsPerDay = 24 * 3600 * 1000
+
+// QueryCost is the estimated cost for query execution.
+type QueryCost struct {
+	// Series is the estimated number of series selected by the query.
+	Series int
+
+	// Samples is the estimated number of raw samples to read for the query.
+	Samples int64
+
+	// Selectors contains the estimated costs for series selectors in the query.
+	Selectors []SelectorCost
+}
+
+// SelectorCost is the estimated cost for a single series selector.
+type SelectorCost struct {
+	// Selector is the series selector.
+	Selector string
+
+	// Start and End is the time range in milliseconds to select raw samples for.
+	Start int64
+	End   int64
+
+	// Series is the maximum number of matching series per day on the selected time range.
+	Series int
+
+	// Samples is the estimated number of raw samples to read.
+	Samples int64
+}
+
+// EstimateQueryCost estimates the cost for executing q with the given ec.
+//
+// The estimation is based on the number of matching series per day obtained from the index
+// and on the sample density obtained from the ingestion rate.
+func EstimateQueryCost(qt *querytracer.Tracer, ec *EvalConfig, q string) (*QueryCost, error) {
+	ec.validate()
+	e, err := parsePromQLWithCache(q)
+	if err != nil {
+		return nil, err
+	}
+	return estimateExprCost(qt, ec, e)
+}
+
+func estimateExprCost(qt *querytracer.Tracer, ec *EvalConfig, e metricsql.Expr) (*QueryCost, error) {
+	qt = qt.NewChild("estimate query cost")
+	density := netstorage.SampleDensity()
+	if density <= 0 {
+		density = defaultSampleDensity
+	}
+	qc := &QueryCost{}
+	if err := qc.addExpr(qt, ec, e, density); err != nil {
+		qt.Done()
+		return nil, err
+	}
+	qt.Donef("series=%d, samples=%d, sampleDensity=%.3f samples/s", qc.Series, qc.Samples, density)
+	return qc, nil
+}
+
+func (qc *QueryCost) addExpr(qt *querytracer.Tracer, ec *EvalConfig, e metricsql.Expr, density float64) error {
+	switch t := e.(type) {
+	case *metricsql.MetricExpr:
+		return qc.addSelector(qt, ec, t, 0, density)
+	case *metricsql.RollupExpr:
+		if t.Offset != nil {
+			offset := t.Offset.Duration(ec.Step)
+			ecNew := copyEvalConfig(ec)
+			ecNew.Start -= offset
+			ecNew.End -= offset
+			ec = ecNew
+		}
+		window := t.Window.Duration(ec.Step)
+		if me, ok := t.Expr.(*metricsql.MetricExpr); ok && !t.ForSubquery() {
+			return qc.addSelector(qt, ec, me, window, density)
+		}
+		// Subquery. Use the same time range as evalRollupFuncWithSubquery uses.
+		step := t.Step.Duration(ec.Step)
+		if step <= 0 {
+			step = ec.Step
+		}
+		ecSQ := copyEvalConfig(ec)
+		ecSQ.Start -= window + maxSilenceInterval + step
+		ecSQ.End += step
+		ecSQ.Step = step
+		return qc.addExpr(qt, ecSQ, t.Expr, density)
+	case *metricsql.FuncExpr:
+		return qc.addExprs(qt, ec, t.Args, density)
+	case *metricsql.AggrFuncExpr:
+		return qc.addExprs(qt, ec, t.Args, density)
+	case *metricsql.BinaryOpExpr:
+		return qc.addExprs(qt, ec, []metricsql.Expr{t.Left, t.Right}, density)
+	default:
+		// Other expressions such as numbers and strings do not read samples from the storage.
+		return nil
+	}
+}
+
+func (qc *QueryCost) addExprs(qt *querytracer.Tracer, ec *EvalConfig, es []metricsql.Expr, density float64) error {
+	for _, e := range es {
+		if err := qc.addExpr(qt, ec, e, density); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (qc *QueryCost) addSelector(qt *querytracer.Tracer, ec *EvalConfig, me *metricsql.MetricExpr, window int64, density float64) error {
+	if me.IsEmpty() {
+		return nil
+	}
+	// Use the same time range as evalRollupFuncWithMetricExpr uses.
+	minTimestamp := ec.Start - maxSilenceInterval
+	if window > ec.Step {
+		minTimestamp -= window
+	} else {
+		minTimestamp -= ec.Step
+	}
+	if minTimestamp < 0 {
+		minTimestamp = 0
+	}
+	maxTimestamp := ec.End
+	if maxTimestamp < minTimestamp {
+		return nil
+	}
+
+	// Limit the number of days to query the index for.
+	searchMinTimestamp := minTimestamp
+	if d := maxTimestamp - maxDaysForCostEstimation*msecsPerDay; d > searchMinTimestamp {
+		searchMinTimestamp = d
+	}
+	tfs := searchutils.ToTagFilters(me.LabelFilters)
+	tfss := searchutils.JoinTagFilterss([][]storage.TagFilter{tfs}, ec.EnforcedTagFilterss)
+	sq := storage.NewSearchQuery(searchMinTimestamp, maxTimestamp, tfss, ec.MaxSeries)
+	counts, err := netstorage.SeriesCountPerDay(qt, sq, ec.Deadline)
+	if err != nil {
+		return &UserReadableError{
+			Err: err,
+		}
+	}
+	series, samples := estimateSamples(counts, searchMinTimestamp, minTimestamp, maxTimestamp, density)
+	sc := SelectorCost{
+		Selector: string(me.AppendString(nil)),
+		Start:    minTimestamp,
+		End:      maxTimestamp,
+		Series:   series,
+		Samples:  samples,
+	}
+	qt.Printf("selector %s on timeRange=[%d..%d]: series=%d, samples=%d", sc.Selector, sc.Start, sc.End, sc.Series, sc.Samples)
+	qc.Selectors = append(qc.Selectors, sc)
+	qc.Series += sc.Series
+	qc.Samples += sc.Samples
+	return nil
+}
+
+// estimateSamples returns the maximum number of series per day and the estimated number of samples on the time range [minTimestamp ... maxTimestamp]
+// from the given counts of series per day starting from the day for searchMinTimestamp.
+//
+// The number of samples is extrapolated to the whole time range if searchMinTimestamp exceeds minTimestamp.
+func estimateSamples(counts []int, searchMinTimestamp, minTimestamp, maxTimestamp int64, density float64) (int, int64) {
+	series := 0
+	samples := float64(0)
+	minDate := searchMinTimestamp / msecsPerDay
+	for i, n := range counts {
+		dayStart := (minDate + int64(i)) * msecsPerDay
+		dayEnd := dayStart + msecsPerDay
+		if dayStart < searchMinTimestamp {
+			dayStart = searchMinTimestamp
+		}
+		if dayEnd > maxTimestamp {
+			dayEnd = maxTimestamp
+		}
+		if dayEnd > dayStart {
+			samples += float64(n) * density * float64(dayEnd-dayStart) / 1e3
+		}
+		if n > series {
+			series = n
+		}
+	}
+	if searchMinTimestamp > minTimestamp && maxTimestamp > searchMinTimestamp {
+		samples *= float64(maxTimestamp-minTimestamp) / float64(maxTimestamp-searchMinTimestamp)
+	}
+	return series, int64(samples)
+}
+
+// admitQuery applies admission control to e according to its estimated cost.
+//
+// The cost is estimated only if -search.maxEstimatedSamplesPerQuery or -search.expensiveQueryEstimatedSamples is set
+// or if qt is enabled, so the estimated cost is visible in query trace.
+// The query, which cost cannot be estimated, is considered expensive.
+//
+// The returned func must be called after the query execution.
+func admitQuery(qt *querytracer.Tracer, ec *EvalConfig, e metricsql.Expr) (func(), error) {
+	if *maxEstimatedSamplesPerQuery <= 0 && *expensiveQueryEstimatedSamples <= 0 && !qt.Enabled() {
+		// Fast path - nothing to do.
+		return func() {}, nil
+	}
+	qc, err := estimateExprCost(qt, ec, e)
+	if err != nil {
+		queryCostEstimationErrors.Inc()
+		if *expensiveQueryEstimatedSamples <= 0 {
+			costEstimationErrorLogger.Warnf("cannot estimate the cost for query %q; executing it without cost limits: %s", e.AppendString(nil), err)
+			return func() {}, nil
+		}
+		costEstimationErrorLogger.Warnf("cannot estimate the cost for query %q; executing it as expensive query: %s", e.AppendString(nil), err)
+		qt.Printf("cannot estimate the query cost; consider the query expensive: %s", err)
+		return waitForExpensiveQuerySlot(qt, ec, -1)
+	}
+	if *maxEstimatedSamplesPerQuery > 0 && qc.Samples > *maxEstimatedSamplesPerQuery {
+		expensiveQueriesRejected.Inc()
+		return nil, &UserReadableError{
+			Err: fmt.Errorf("cannot execute the query, since the estimated number of samples to read is %d, which exceeds -search.maxEstimatedSamplesPerQuery=%d; "+
+				"either narrow down the time range or reduce the number of series matching the query", qc.Samples, *maxEstimatedSamplesPerQuery),
+		}
+	}
+	if *expensiveQueryEstimatedSamples <= 0 || qc.Samples <= *expensiveQueryEstimatedSamples {
+		return func() {}, nil
+	}
+	return waitForExpensiveQuerySlot(qt, ec, qc.Samples)
+}
+
+// waitForExpensiveQuerySlot waits until the expensive query with the given estimated number of samples can be executed.
+//
+// samples is negative if the query cost cannot be estimated.
+// ec.ConcurrencySlot is released while waiting in the queue, so the expensive query doesn't block cheap queries.
+func waitForExpensiveQuerySlot(qt *querytracer.Tracer, ec *EvalConfig, samples int64) (func(), error) {
+	ch := getExpensiveQueriesCh()
+	select {
+	case ch <- struct{}{}:
+		return releaseExpensiveQuery, nil
+	default:
+	}
+	expensiveQueriesQueued.Inc()
+	startTime := time.Now()
+	d := time.Duration(int64(ec.Deadline.Deadline())-int64(fasttime.UnixTimestamp())) * time.Second
+	t := timerpool.Get(d)
+	defer timerpool.Put(t)
+	ec.ConcurrencySlot.Release()
+	defer ec.ConcurrencySlot.Acquire()
+	select {
+	case ch <- struct{}{}:
+		qt.Printf("wait in the queue of expensive queries for %.3f seconds", time.Since(startTime).Seconds())
+		return releaseExpensiveQuery, nil
+	case <-t.C:
+		expensiveQueriesTimeouts.Inc()
+		samplesStr := "unknown"
+		if samples >= 0 {
+			samplesStr = fmt.Sprintf("%d", samples)
+		}
+		return nil, &UserReadableError{
+			Err: fmt.Errorf("cannot execute the expensive query with the estimated number of samples to read %s during %.3f seconds, "+
+				"since -search.maxConcurrentExpensiveQueries=%d concurrent expensive queries are executed; "+
+				"see https://docs.victoriametrics.com/#query-cost-estimation", samplesStr, time.Since(startTime).Seconds(), cap(ch)),
+		}
+	}
+}
+
+func releaseExpensiveQuery() {
+	<-getExpensiveQueriesCh()
+}
+
+func getExpensiveQueriesCh() chan struct{} {
+	expensiveQueriesChOnce.Do(func() {
+		n := *maxConcurrentExpensiveQueries
+		if n <= 0 {
+			n = 1
+		}
+		expensiveQueriesCh = make(chan struct{}, n)
+	})
+	return expensiveQueriesCh
+}
+
+var (
+	expensiveQueriesCh     chan struct{}
+	expensiveQueriesChOnce sync.Once
+)
+
+var costEstimationErrorLogger = logger.WithThrottler("queryCostEstimation", 5*time.Second)
+
+var (
+	queryCostEstimationErrors = metrics.NewCounter(`vm_query_cost_estimation_errors_total`)
+	expensiveQueriesRejected  = metrics.NewCounter(`vm_expensive_queries_rejected_total`)
+	expensiveQueriesQueued    = metrics.NewCounter(`vm_expensive_queries_queued_total`)
+	expensiveQueriesTimeouts  = metrics.NewCounter(`vm_expensive_queries_queue_timeouts_total`)
+
+	_ = metrics.NewGauge(`vm_expensive_queries_current`, func() float64 {
+		return float64(len(getExpensiveQueriesCh()))
+	})
+)
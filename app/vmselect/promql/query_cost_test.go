@@ -0,0 +1,226 @@
+package promql
+
+import (
+	"flag"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/searchutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmstorage"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompb"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/querytracer"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+)
+
+func TestEstimateSamples(t *testing.T) {
+	f := func(counts []int, searchMinTimestamp, minTimestamp, maxTimestamp int64, density float64, seriesExpected int, samplesExpected int64) {
+		t.Helper()
+		series, samples := estimateSamples(counts, searchMinTimestamp, minTimestamp, maxTimestamp, density)
+		if series != seriesExpected {
+			t.Fatalf("unexpected series; got %d; want %d", series, seriesExpected)
+		}
+		if samples != samplesExpected {
+			t.Fatalf("unexpected samples; got %d; want %d", samples, samplesExpected)
+		}
+	}
+
+	// No series
+	f(nil, 0, 0, msecsPerDay, 1, 0, 0)
+	f([]int{0, 0}, 0, 0, msecsPerDay, 1, 0, 0)
+
+	// A single day
+	f([]int{10}, 3600*1000, 3600*1000, 2*3600*1000, 0.1, 10, 3600)
+
+	// Time range spanning two days
+	f([]int{10, 20}, msecsPerDay-1800*1000, msecsPerDay-1800*1000, msecsPerDay+1800*1000, 1, 20, 10*1800+20*1800)
+
+	// Extrapolation to the whole time range
+	f([]int{10}, 3600*1000, 0, 2*3600*1000, 1, 10, 2*10*3600)
+}
+
+func TestAdmitQueryFastPath(t *testing.T) {
+	ec := &EvalConfig{
+		Start: 1000,
+		End:   2000,
+		Step:  100,
+	}
+	e, err := parsePromQLWithCache("sum(rate(foo[5m]))")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// The cost mustn't be estimated when admission control is disabled.
+	release, err := admitQuery(nil, ec, e)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	release()
+
+}
+
+func TestAdmitQueryTracing(t *testing.T) {
+	path := "TestAdmitQueryTracing"
+	vmstorage.Storage = storage.MustOpenStorage(path, 0, 0, 0)
+	defer func() {
+		vmstorage.Storage.MustClose()
+		vmstorage.Storage = nil
+		_ = os.RemoveAll(path)
+	}()
+
+	ec := &EvalConfig{
+		Start:    1000,
+		End:      2000,
+		Step:     100,
+		Deadline: searchutils.NewDeadline(time.Now(), time.Minute, ""),
+	}
+	e, err := parsePromQLWithCache("sum(rate(foo[5m]))")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// The cost must be estimated when tracing is enabled even if admission control is disabled.
+	qt := querytracer.New(true, "test")
+	release, err := admitQuery(qt, ec, e)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	release()
+	qt.Done()
+	if s := qt.String(); !strings.Contains(s, "estimate query cost") {
+		t.Fatalf("missing query cost estimation in the trace:\n%s", s)
+	}
+}
+
+func TestAdmitQueryEstimationError(t *testing.T) {
+	path := "TestAdmitQueryEstimationError"
+	vmstorage.Storage = storage.MustOpenStorage(path, 0, 0, 0)
+	if err := flag.Set("search.maxEstimatedSamplesPerQuery", "1"); err != nil {
+		t.Fatalf("cannot set -search.maxEstimatedSamplesPerQuery: %s", err)
+	}
+	defer func() {
+		_ = flag.Set("search.maxEstimatedSamplesPerQuery", "0")
+		vmstorage.Storage.MustClose()
+		vmstorage.Storage = nil
+		_ = os.RemoveAll(path)
+	}()
+
+	// The query must be admitted if its cost cannot be estimated, e.g. because of the exceeded deadline,
+	// and the limit on concurrent expensive queries isn't set.
+	ec := &EvalConfig{
+		Start:    1000,
+		End:      2000,
+		Step:     100,
+		Deadline: searchutils.NewDeadline(time.Now().Add(-time.Hour), time.Second, ""),
+	}
+	e, err := parsePromQLWithCache("sum(rate(foo[5m]))")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	release, err := admitQuery(nil, ec, e)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	release()
+
+	// The query must be considered expensive if its cost cannot be estimated
+	// and the limit on concurrent expensive queries is set.
+	if err := flag.Set("search.expensiveQueryEstimatedSamples", "1"); err != nil {
+		t.Fatalf("cannot set -search.expensiveQueryEstimatedSamples: %s", err)
+	}
+	defer func() {
+		_ = flag.Set("search.expensiveQueryEstimatedSamples", "0")
+	}()
+	ch := getExpensiveQueriesCh()
+	for i := 0; i < cap(ch); i++ {
+		ch <- struct{}{}
+	}
+	defer func() {
+		for i := 0; i < cap(ch); i++ {
+			<-ch
+		}
+	}()
+	if _, err := admitQuery(nil, ec, e); err == nil {
+		t.Fatalf("expecting non-nil error for expensive query exceeding the deadline in the queue")
+	}
+}
+
+func TestAdmitQueryReleasesConcurrencySlot(t *testing.T) {
+	path := "TestAdmitQueryReleasesConcurrencySlot"
+	vmstorage.Storage = storage.MustOpenStorage(path, 0, 0, 0)
+	if err := flag.Set("search.expensiveQueryEstimatedSamples", "1"); err != nil {
+		t.Fatalf("cannot set -search.expensiveQueryEstimatedSamples: %s", err)
+	}
+	defer func() {
+		_ = flag.Set("search.expensiveQueryEstimatedSamples", "0")
+		vmstorage.Storage.MustClose()
+		vmstorage.Storage = nil
+		_ = os.RemoveAll(path)
+	}()
+
+	now := time.Now().UnixNano() / 1e6
+	mrs := []storage.MetricRow{{
+		MetricNameRaw: storage.MarshalMetricNameRaw(nil, []prompb.Label{{
+			Name:  []byte("__name__"),
+			Value: []byte("foo"),
+		}}),
+		Timestamp: now - 3600*1000,
+		Value:     1,
+	}}
+	if err := vmstorage.Storage.AddRows(mrs, 64); err != nil {
+		t.Fatalf("cannot add rows: %s", err)
+	}
+	vmstorage.Storage.DebugFlush()
+
+	// The current request holds the only slot for concurrently executed requests.
+	concurrencyLimitCh := make(chan struct{}, 1)
+	concurrencyLimitCh <- struct{}{}
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/api/v1/query_range", nil)
+	if err != nil {
+		t.Fatalf("cannot create request: %s", err)
+	}
+	req = req.WithContext(searchutils.WithConcurrencySlot(req.Context(), concurrencyLimitCh))
+	ec := &EvalConfig{
+		Start:           now - 2*3600*1000,
+		End:             now,
+		Step:            60 * 1000,
+		Deadline:        searchutils.NewDeadline(time.Now(), time.Minute, ""),
+		ConcurrencySlot: searchutils.GetConcurrencySlot(req),
+	}
+	e, err := parsePromQLWithCache("rate(foo[5m])")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Occupy all the slots for expensive queries, so the query waits in the queue.
+	ch := getExpensiveQueriesCh()
+	for i := 0; i < cap(ch); i++ {
+		ch <- struct{}{}
+	}
+	resultCh := make(chan error, 1)
+	go func() {
+		release, err := admitQuery(nil, ec, e)
+		if err == nil {
+			release()
+		}
+		resultCh <- err
+	}()
+
+	// Another request must be able to obtain the slot released by the queued expensive query.
+	select {
+	case concurrencyLimitCh <- struct{}{}:
+		<-concurrencyLimitCh
+	case <-time.After(10 * time.Second):
+		t.Fatalf("the expensive query in the queue must release the slot for concurrently executed requests")
+	}
+	for i := 0; i < cap(ch); i++ {
+		<-ch
+	}
+	if err := <-resultCh; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// The slot must be acquired again after leaving the queue.
+	if n := len(concurrencyLimitCh); n != 1 {
+		t.Fatalf("the slot for concurrently executed requests must be acquired again; got %d acquired slots", n)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"math"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/decimal"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
@@ -18,6 +19,10 @@ var minStalenessInterval = flag.Duration("search.minStalenessInterval", 0, "The
 	"This flag could be useful for removing gaps on graphs generated from time series with irregular intervals between samples. "+
 	"See also '-search.maxStalenessInterval'")
 
+var minSamplesPerRollupChunk = flag.Int("search.minSamplesPerRollupChunk", 100000, "The minimum number of raw samples to scan per time range chunk "+
+	"when calculating rollups and subqueries over a single time series in parallel on multiple CPU cores. This speeds up heavy queries "+
+	"over long time ranges, which select a small number of time series. Zero disables splitting rollup calculations into time range chunks")
+
 var rollupFuncs = map[string]newRollupFunc{
 	"absent_over_time":        newRollupFuncOneArg(rollupAbsent),
 	"aggr_over_time":          newRollupFuncTwoArgs(rollupFake),
@@ -536,6 +541,11 @@ type rollupConfig struct {
 	//
 	// If zero, then it is considered that Func scans all the samples passed to it.
 	samplesScannedPerCall int
+
+	// The maximum number of chunks to split Timestamps into for parallel rollup calculations on a single series.
+	//
+	// Zero or one disables parallel calculations.
+	maxParallelChunks int
 }
 
 func (rc *rollupConfig) getTimestamps() []int64 {
@@ -676,8 +686,84 @@ func (rc *rollupConfig) doInternal(dstValues []float64, tsm *timeseriesMap, valu
 			window = rc.LookbackDelta
 		}
 	}
+	samplesScanned := uint64(len(values))
+	if tsm == nil {
+		if chunks := rc.getParallelChunks(len(values), scrapeInterval, window); chunks > 1 {
+			dstValues, n := rc.doParallelChunks(dstValues, values, timestamps, window, maxPrevInterval, chunks)
+			return dstValues, samplesScanned + n
+		}
+	}
+	dstValues, n := rc.doRange(dstValues, tsm, values, timestamps, window, maxPrevInterval, 0, rc.Timestamps)
+	return dstValues, samplesScanned + n
+}
+
+// getParallelChunks returns the number of chunks to split rc.Timestamps into for parallel rollup calculations
+// over the given number of raw samples with the given scrapeInterval and window.
+func (rc *rollupConfig) getParallelChunks(samples int, scrapeInterval, window int64) int {
+	if rc.maxParallelChunks <= 1 || *minSamplesPerRollupChunk <= 0 || len(rc.Timestamps) < 2 || samples == 0 {
+		return 1
+	}
+	// Estimate the number of samples scanned per rollup point.
+	samplesPerPoint := int64(rc.samplesScannedPerCall)
+	if samplesPerPoint <= 0 {
+		if scrapeInterval <= 0 {
+			scrapeInterval = 1
+		}
+		samplesPerPoint = window/scrapeInterval + 1
+		if samplesPerPoint > int64(samples) {
+			samplesPerPoint = int64(samples)
+		}
+	}
+	chunks := int64(len(rc.Timestamps)) * samplesPerPoint / int64(*minSamplesPerRollupChunk)
+	if chunks > int64(rc.maxParallelChunks) {
+		chunks = int64(rc.maxParallelChunks)
+	}
+	if chunks > int64(len(rc.Timestamps)) {
+		chunks = int64(len(rc.Timestamps))
+	}
+	if chunks < 1 {
+		chunks = 1
+	}
+	return int(chunks)
+}
+
+// doParallelChunks splits rc.Timestamps into the given number of chunks and calculates rollups for them in parallel.
+//
+// Every chunk is calculated over the whole values and timestamps, so the results are identical to the results of sequential calculations.
+func (rc *rollupConfig) doParallelChunks(dstValues []float64, values []float64, timestamps []int64, window, maxPrevInterval int64, chunks int) ([]float64, uint64) {
+	dstLen := len(dstValues)
+	pointsLen := len(rc.Timestamps)
+	dstValues = decimal.ExtendFloat64sCapacity(dstValues, pointsLen)
+	dst := dstValues[dstLen : dstLen+pointsLen]
+	pointsPerChunk := (pointsLen + chunks - 1) / chunks
+	var samplesScannedTotal uint64
+	var wg sync.WaitGroup
+	for start := 0; start < pointsLen; start += pointsPerChunk {
+		end := start + pointsPerChunk
+		if end > pointsLen {
+			end = pointsLen
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			_, n := rc.doRange(dst[start:start:end], nil, values, timestamps, window, maxPrevInterval, start, rc.Timestamps[start:end])
+			atomic.AddUint64(&samplesScannedTotal, n)
+		}(start, end)
+	}
+	wg.Wait()
+	parallelRollupChunks.Add(chunks)
+	return dstValues[:dstLen+pointsLen], samplesScannedTotal
+}
+
+var parallelRollupChunks = metrics.NewCounter(`vm_rollup_parallel_chunks_total`)
+
+// doRange calculates rollups for the given tEnds, which start at idxStart index in rc.Timestamps, and appends them to dstValues.
+//
+// It returns the number of samples scanned by rc.Func calls.
+func (rc *rollupConfig) doRange(dstValues []float64, tsm *timeseriesMap, values []float64, timestamps []int64, window, maxPrevInterval int64,
+	idxStart int, tEnds []int64) ([]float64, uint64) {
 	rfa := getRollupFuncArg()
-	rfa.idx = 0
+	rfa.idx = idxStart
 	rfa.window = window
 	rfa.tsm = tsm
 
@@ -686,9 +772,9 @@ func (rc *rollupConfig) doInternal(dstValues []float64, tsm *timeseriesMap, valu
 	ni := 0
 	nj := 0
 	f := rc.Func
-	samplesScanned := uint64(len(values))
+	samplesScanned := uint64(0)
 	samplesScannedPerCall := uint64(rc.samplesScannedPerCall)
-	for _, tEnd := range rc.Timestamps {
+	for _, tEnd := range tEnds {
 		tStart := tEnd - window
 		ni = seekFirstTimestampIdxAfter(timestamps[i:], tStart, ni)
 		i += ni
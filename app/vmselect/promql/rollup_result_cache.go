@@ -9,6 +9,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/VictoriaMetrics/VictoriaMetrics/app/vmselect/resultcache"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/bytesutil"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
@@ -99,6 +100,11 @@ var (
 	rollupResultCacheSizeOnce sync.Once
 )
 
+// IsCacheDisabled returns true if response caching is disabled via -search.disableCache command-line flag.
+func IsCacheDisabled() bool {
+	return *disableCache
+}
+
 // InitRollupResultCache initializes the rollupResult cache
 //
 // if cachePath is empty, then the cache isn't stored to persistent disk.
@@ -200,6 +206,7 @@ func ResetRollupResultCache() {
 	rollupResultCacheResets.Inc()
 	atomic.AddUint64(&rollupResultCacheKeyPrefix, 1)
 	logger.Infof("rollupResult cache has been cleared")
+	resultcache.ResetAll()
 }
 
 func (rrc *rollupResultCache) Get(qt *querytracer.Tracer, ec *EvalConfig, expr metricsql.Expr, window int64) (tss []*timeseries, newStart int64) {
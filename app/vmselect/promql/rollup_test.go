@@ -1455,3 +1455,115 @@ func TestRollupDelta(t *testing.T) {
 	f(1, nan, nan, nil, 0)
 	f(100, nan, nan, nil, 0)
 }
+
+func TestRollupParallelChunks(t *testing.T) {
+	defer func(n int) {
+		*minSamplesPerRollupChunk = n
+	}(*minSamplesPerRollupChunk)
+	*minSamplesPerRollupChunk = 100
+
+	// Generate a counter with gaps, resets and jitter.
+	const srcValuesCount = 1e4
+	srcValues := make([]float64, 0, srcValuesCount)
+	srcTimestamps := make([]int64, 0, srcValuesCount)
+	v := float64(0)
+	ts := int64(1000)
+	for i := 0; i < srcValuesCount; i++ {
+		ts += 10000 + int64(i%7)*100
+		if i%1000 == 500 {
+			// gap
+			ts += 20 * 60 * 1000
+		}
+		v += float64(i % 13)
+		if i%2500 == 0 {
+			// counter reset
+			v = 0
+		}
+		srcValues = append(srcValues, v)
+		srcTimestamps = append(srcTimestamps, ts)
+	}
+
+	f := func(name string, rf rollupFunc, window, step int64) {
+		t.Helper()
+		rc := rollupConfig{
+			Func:               rf,
+			Start:              0,
+			End:                ts + 3600*1000,
+			Step:               step,
+			Window:             window,
+			MaxPointsPerSeries: 1e6,
+			MayAdjustWindow:    true,
+		}
+		rc.Timestamps = rc.getTimestamps()
+		valuesExpected, samplesScannedExpected := rc.Do(nil, srcValues, srcTimestamps)
+
+		for _, chunks := range []int{2, 3, 16, 1e6} {
+			rc.maxParallelChunks = chunks
+			values, samplesScanned := rc.Do([]float64{1, 2}, srcValues, srcTimestamps)
+			rc.maxParallelChunks = 0
+			if samplesScanned != samplesScannedExpected {
+				t.Fatalf("%s, window=%d, step=%d, chunks=%d: unexpected samplesScanned; got %d; want %d", name, window, step, chunks, samplesScanned, samplesScannedExpected)
+			}
+			if len(values) < 2 || values[0] != 1 || values[1] != 2 {
+				t.Fatalf("%s, window=%d, step=%d, chunks=%d: dst prefix must be preserved; got %v", name, window, step, chunks, values[:2])
+			}
+			values = values[2:]
+			if len(values) != len(valuesExpected) {
+				t.Fatalf("%s, window=%d, step=%d, chunks=%d: unexpected len(values); got %d; want %d", name, window, step, chunks, len(values), len(valuesExpected))
+			}
+			for i, v := range values {
+				vExpected := valuesExpected[i]
+				if math.Float64bits(v) != math.Float64bits(vExpected) && !(math.IsNaN(v) && math.IsNaN(vExpected)) {
+					t.Fatalf("%s, window=%d, step=%d, chunks=%d: unexpected value at index %d; got %v; want %v", name, window, step, chunks, i, v, vExpected)
+				}
+			}
+		}
+	}
+
+	phis := make([]*timeseries, 0)
+	rfs := map[string]rollupFunc{
+		"default_rollup":   rollupDefault,
+		"first_over_time":  rollupFirst,
+		"last_over_time":   rollupLast,
+		"count_over_time":  rollupCount,
+		"max_over_time":    rollupMax,
+		"avg_over_time":    rollupAvg,
+		"stddev_over_time": rollupStddev,
+		"delta":            rollupDelta,
+		"ideriv":           rollupIderiv,
+		"deriv":            rollupDerivSlow,
+		"changes":          rollupChanges,
+		"lag":              rollupLag,
+		"distinct":         rollupDistinct,
+		"mad_over_time":    rollupMAD,
+		"mode_over_time":   rollupModeOverTime,
+	}
+	for _, window := range []int64{0, 5 * 60 * 1000, 3600 * 1000} {
+		for _, step := range []int64{15 * 1000, 60 * 1000} {
+			for name, rf := range rfs {
+				f(name, rf, window, step)
+			}
+
+			// Verify rollup funcs, which depend on rfa.idx.
+			rc := rollupConfig{
+				Start:              0,
+				End:                ts + 3600*1000,
+				Step:               step,
+				MaxPointsPerSeries: 1e6,
+			}
+			points := len(rc.getTimestamps())
+			phiValues := make([]float64, points)
+			for i := range phiValues {
+				phiValues[i] = float64(i%10) / 10
+			}
+			phis = append(phis[:0], &timeseries{
+				Values: phiValues,
+			})
+			rf, err := newRollupQuantile([]interface{}{phis, &metricsql.RollupExpr{Expr: &metricsql.MetricExpr{}}})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			f("quantile_over_time", rf, window, step)
+		}
+	}
+}
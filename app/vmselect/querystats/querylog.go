@@ -0,0 +1,232 @@
+package querystats
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+var (
+	queryLogPath = flag.String("search.queryLog.path", "", "Path to file for logging queries in JSON lines format together with the resources they used. "+
+		"Only queries with the duration exceeding -search.queryLog.minQueryDuration are logged. The log is also used for restoring "+
+		"query stats at /api/v1/status/top_queries after restart. See https://docs.victoriametrics.com/#query-log")
+	queryLogMinQueryDuration = flag.Duration("search.queryLog.minQueryDuration", time.Second, "The minimum duration for queries to log to -search.queryLog.path")
+	queryLogMaxFileSize      = flagutil.NewBytes("search.queryLog.maxFileSize", 100*1024*1024, "The maximum size of -search.queryLog.path file. "+
+		"The file is rotated when it reaches this size. See also -search.queryLog.maxFiles")
+	queryLogMaxFiles = flag.Int("search.queryLog.maxFiles", 5, "The maximum number of query log files to keep, including the current -search.queryLog.path file. "+
+		"Rotated files are named <path>.1, <path>.2, etc.")
+)
+
+// qlWriter is set in Init and is reset in MustStop, so it is read without synchronization while serving queries.
+var qlWriter *queryLogWriter
+
+// queryLogEntry is a single line in the query log.
+type queryLogEntry struct {
+	Timestamp            string  `json:"ts"`
+	Query                string  `json:"query"`
+	Start                int64   `json:"start"`
+	End                  int64   `json:"end"`
+	Step                 int64   `json:"step"`
+	DurationSeconds      float64 `json:"durationSeconds"`
+	Client               string  `json:"client"`
+	SeriesFetched        int     `json:"seriesFetched"`
+	SamplesScanned       int64   `json:"samplesScanned"`
+	BytesRead            int64   `json:"bytesRead"`
+	EstimatedMemoryBytes int64   `json:"estimatedMemoryBytes"`
+	CacheHits            int64   `json:"cacheHits"`
+	CacheMisses          int64   `json:"cacheMisses"`
+	CacheHitRatio        float64 `json:"cacheHitRatio"`
+}
+
+// queryLogWriter writes query log entries to the file at path and rotates it when it becomes too big.
+type queryLogWriter struct {
+	path string
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+func newQueryLogWriter(path string) *queryLogWriter {
+	logger.Infof("enabled query log at -search.queryLog.path=%q with -search.queryLog.minQueryDuration=%s, -search.queryLog.maxFileSize=%d, -search.queryLog.maxFiles=%d",
+		path, *queryLogMinQueryDuration, queryLogMaxFileSize.N, *queryLogMaxFiles)
+	return &queryLogWriter{
+		path: path,
+	}
+}
+
+func (qlw *queryLogWriter) writeEntry(qd *QueryDetails, registerTime time.Time, duration time.Duration) {
+	e := &queryLogEntry{
+		Timestamp:            registerTime.UTC().Format(time.RFC3339Nano),
+		Query:                qd.Query,
+		Start:                qd.Start,
+		End:                  qd.End,
+		Step:                 qd.Step,
+		DurationSeconds:      duration.Seconds(),
+		Client:               qd.Client,
+		SeriesFetched:        qd.SeriesFetched,
+		SamplesScanned:       qd.SamplesScanned,
+		BytesRead:            qd.BytesRead,
+		EstimatedMemoryBytes: qd.EstimatedMemoryBytes,
+		CacheHits:            qd.CacheHits,
+		CacheMisses:          qd.CacheMisses,
+	}
+	if n := qd.CacheHits + qd.CacheMisses; n > 0 {
+		e.CacheHitRatio = float64(qd.CacheHits) / float64(n)
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		logger.Panicf("BUG: cannot marshal query log entry: %s", err)
+	}
+	line = append(line, '\n')
+
+	qlw.mu.Lock()
+	defer qlw.mu.Unlock()
+	if err := qlw.writeLocked(line); err != nil {
+		queryLogErrors.Inc()
+		queryLogErrorLogger.Errorf("cannot write entry to query log: %s", err)
+		return
+	}
+	queryLogEntries.Inc()
+}
+
+func (qlw *queryLogWriter) writeLocked(line []byte) error {
+	if qlw.f != nil && qlw.size > 0 && qlw.size+int64(len(line)) > queryLogMaxFileSize.N {
+		if err := qlw.rotateLocked(); err != nil {
+			return err
+		}
+	}
+	if qlw.f == nil {
+		f, err := os.OpenFile(qlw.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("cannot open %q: %w", qlw.path, err)
+		}
+		fi, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			return fmt.Errorf("cannot stat %q: %w", qlw.path, err)
+		}
+		qlw.f = f
+		qlw.size = fi.Size()
+	}
+	n, err := qlw.f.Write(line)
+	qlw.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("cannot write to %q: %w", qlw.path, err)
+	}
+	return nil
+}
+
+// rotateLocked renames path to path.1, path.1 to path.2, etc. and drops files exceeding -search.queryLog.maxFiles.
+func (qlw *queryLogWriter) rotateLocked() error {
+	if err := qlw.f.Close(); err != nil {
+		return fmt.Errorf("cannot close %q: %w", qlw.path, err)
+	}
+	qlw.f = nil
+	qlw.size = 0
+
+	maxFiles := *queryLogMaxFiles
+	if maxFiles < 1 {
+		maxFiles = 1
+	}
+	oldest := rotatedQueryLogPath(qlw.path, maxFiles-1)
+	if err := os.Remove(oldest); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot remove %q: %w", oldest, err)
+	}
+	for i := maxFiles - 2; i >= 0; i-- {
+		src := rotatedQueryLogPath(qlw.path, i)
+		dst := rotatedQueryLogPath(qlw.path, i+1)
+		if err := os.Rename(src, dst); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot rename %q to %q: %w", src, dst, err)
+		}
+	}
+	return nil
+}
+
+func rotatedQueryLogPath(path string, n int) string {
+	if n == 0 {
+		return path
+	}
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+func (qlw *queryLogWriter) mustClose() {
+	qlw.mu.Lock()
+	defer qlw.mu.Unlock()
+	if qlw.f == nil {
+		return
+	}
+	if err := qlw.f.Close(); err != nil {
+		logger.Errorf("cannot close %q: %s", qlw.path, err)
+	}
+	qlw.f = nil
+	qlw.size = 0
+}
+
+// restoreQueryStats loads query stats from the current and rotated query log files into qst.
+//
+// Files are read from the oldest to the newest, so qst ends up with the last logged queries.
+func (qlw *queryLogWriter) restoreQueryStats(qst *queryStatsTracker) {
+	if *lastQueriesCount <= 0 {
+		return
+	}
+	maxFiles := *queryLogMaxFiles
+	if maxFiles < 1 {
+		maxFiles = 1
+	}
+	for i := maxFiles - 1; i >= 0; i-- {
+		restoreQueryStatsFromFile(qst, rotatedQueryLogPath(qlw.path, i))
+	}
+}
+
+func restoreQueryStatsFromFile(qst *queryStatsTracker, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Errorf("cannot restore query stats from %q: %s", path, err)
+		}
+		return
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	restored := 0
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 16*1024*1024)
+	for sc.Scan() {
+		var e queryLogEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			// Skip broken lines, which may appear after unclean shutdown.
+			continue
+		}
+		registerTime, err := time.Parse(time.RFC3339Nano, e.Timestamp)
+		if err != nil {
+			continue
+		}
+		duration := time.Duration(e.DurationSeconds * float64(time.Second))
+		if duration < *minQueryDuration {
+			continue
+		}
+		qst.addRecord(e.Query, e.End-e.Start, registerTime, duration, e.SamplesScanned, e.EstimatedMemoryBytes)
+		restored++
+	}
+	if err := sc.Err(); err != nil {
+		logger.Errorf("cannot read query log %q: %s", path, err)
+	}
+	logger.Infof("restored %d query stats entries from %q", restored, path)
+}
+
+var (
+	queryLogEntries = metrics.NewCounter(`vm_query_log_entries_total`)
+	queryLogErrors  = metrics.NewCounter(`vm_query_log_errors_total`)
+
+	queryLogErrorLogger = logger.WithThrottler("queryLog", 5*time.Second)
+)
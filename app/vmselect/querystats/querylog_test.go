@@ -0,0 +1,260 @@
+package querystats
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryLogWriterWriteEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query.log")
+	qlw := newQueryLogWriter(path)
+	defer qlw.mustClose()
+
+	qd := &QueryDetails{
+		Query:                "sum(rate(foo[5m]))",
+		Start:                1000,
+		End:                  61000,
+		Step:                 15000,
+		Client:               `"1.2.3.4:5678"`,
+		SeriesFetched:        10,
+		SamplesScanned:       1234,
+		BytesRead:            4567,
+		EstimatedMemoryBytes: 8910,
+		CacheHits:            1,
+		CacheMisses:          3,
+	}
+	registerTime := time.Unix(1600000000, 0)
+	qlw.writeEntry(qd, registerTime, 1500*time.Millisecond)
+
+	entries := mustReadQueryLog(t, path)
+	if len(entries) != 1 {
+		t.Fatalf("unexpected number of entries; got %d; want 1", len(entries))
+	}
+	e := entries[0]
+	eExpected := queryLogEntry{
+		Timestamp:            "2020-09-13T12:26:40Z",
+		Query:                "sum(rate(foo[5m]))",
+		Start:                1000,
+		End:                  61000,
+		Step:                 15000,
+		DurationSeconds:      1.5,
+		Client:               `"1.2.3.4:5678"`,
+		SeriesFetched:        10,
+		SamplesScanned:       1234,
+		BytesRead:            4567,
+		EstimatedMemoryBytes: 8910,
+		CacheHits:            1,
+		CacheMisses:          3,
+		CacheHitRatio:        0.25,
+	}
+	if e != eExpected {
+		t.Fatalf("unexpected entry\ngot\n%+v\nwant\n%+v", e, eExpected)
+	}
+}
+
+func TestQueryLogWriterRotate(t *testing.T) {
+	defer func(n int64, maxFiles int) {
+		queryLogMaxFileSize.N = n
+		*queryLogMaxFiles = maxFiles
+	}(queryLogMaxFileSize.N, *queryLogMaxFiles)
+	queryLogMaxFileSize.N = 300
+	*queryLogMaxFiles = 3
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "query.log")
+	qlw := newQueryLogWriter(path)
+	defer qlw.mustClose()
+
+	registerTime := time.Unix(1600000000, 0)
+	for i := 0; i < 20; i++ {
+		qd := &QueryDetails{
+			Query: fmt.Sprintf("query_%d", i),
+		}
+		qlw.writeEntry(qd, registerTime, time.Second)
+	}
+	qlw.mustClose()
+
+	names, err := filepath.Glob(path + "*")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(names) != 3 {
+		t.Fatalf("unexpected number of query log files; got %d; want 3; files: %q", len(names), names)
+	}
+	for _, name := range names {
+		fi, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("cannot stat %q: %s", name, err)
+		}
+		if fi.Size() > queryLogMaxFileSize.N {
+			t.Fatalf("too big file %q; got %d bytes; want no more than %d bytes", name, fi.Size(), queryLogMaxFileSize.N)
+		}
+	}
+
+	// The last entry must be in the current file, while the previous entries must be in rotated files.
+	entries := mustReadQueryLog(t, path)
+	if len(entries) == 0 {
+		t.Fatalf("missing entries in %q", path)
+	}
+	if q := entries[len(entries)-1].Query; q != "query_19" {
+		t.Fatalf("unexpected last query in %q; got %q; want %q", path, q, "query_19")
+	}
+	rotatedEntries := mustReadQueryLog(t, path+".1")
+	if q := rotatedEntries[len(rotatedEntries)-1].Query; q != fmt.Sprintf("query_%d", 19-len(entries)) {
+		t.Fatalf("unexpected last query in %q; got %q; want %q", path+".1", q, fmt.Sprintf("query_%d", 19-len(entries)))
+	}
+}
+
+func TestQueryLogRestoreQueryStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query.log")
+	qlw := newQueryLogWriter(path)
+	registerTime := time.Now()
+	for i := 0; i < 3; i++ {
+		qd := &QueryDetails{
+			Query:                "foo",
+			End:                  60000,
+			SamplesScanned:       100,
+			EstimatedMemoryBytes: int64(1000 * (i + 1)),
+		}
+		qlw.writeEntry(qd, registerTime, time.Second)
+	}
+	qd := &QueryDetails{
+		Query:                "bar",
+		End:                  60000,
+		SamplesScanned:       200,
+		EstimatedMemoryBytes: 500,
+	}
+	qlw.writeEntry(qd, registerTime, time.Second)
+	qlw.mustClose()
+
+	// Emulate partially written line after unclean shutdown.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("cannot open %q: %s", path, err)
+	}
+	if _, err := f.WriteString(`{"ts":"2020-`); err != nil {
+		t.Fatalf("cannot write to %q: %s", path, err)
+	}
+	_ = f.Close()
+
+	qst := &queryStatsTracker{
+		a: make([]queryStatRecord, 10),
+	}
+	qlw.restoreQueryStats(qst)
+
+	var bb strings.Builder
+	qst.writeJSONQueryStats(&bb, 10, time.Hour)
+	var resp struct {
+		TopBySumSamplesScanned []struct {
+			Query             string `json:"query"`
+			TimeRangeSeconds  int64  `json:"timeRangeSeconds"`
+			SumSamplesScanned int64  `json:"sumSamplesScanned"`
+			Count             int    `json:"count"`
+		} `json:"topBySumSamplesScanned"`
+		TopByMaxMemoryBytes []struct {
+			Query          string `json:"query"`
+			MaxMemoryBytes int64  `json:"maxMemoryBytes"`
+			Count          int    `json:"count"`
+		} `json:"topByMaxMemoryBytes"`
+	}
+	if err := json.Unmarshal([]byte(bb.String()), &resp); err != nil {
+		t.Fatalf("cannot parse query stats response %q: %s", bb.String(), err)
+	}
+
+	a := resp.TopBySumSamplesScanned
+	if len(a) != 2 {
+		t.Fatalf("unexpected number of entries in topBySumSamplesScanned; got %d; want 2", len(a))
+	}
+	if a[0].Query != "foo" || a[0].TimeRangeSeconds != 60 || a[0].SumSamplesScanned != 300 || a[0].Count != 3 {
+		t.Fatalf("unexpected first entry in topBySumSamplesScanned: %+v", a[0])
+	}
+	if a[1].Query != "bar" || a[1].SumSamplesScanned != 200 || a[1].Count != 1 {
+		t.Fatalf("unexpected second entry in topBySumSamplesScanned: %+v", a[1])
+	}
+
+	b := resp.TopByMaxMemoryBytes
+	if len(b) != 2 {
+		t.Fatalf("unexpected number of entries in topByMaxMemoryBytes; got %d; want 2", len(b))
+	}
+	if b[0].Query != "foo" || b[0].MaxMemoryBytes != 3000 || b[0].Count != 3 {
+		t.Fatalf("unexpected first entry in topByMaxMemoryBytes: %+v", b[0])
+	}
+	if b[1].Query != "bar" || b[1].MaxMemoryBytes != 500 || b[1].Count != 1 {
+		t.Fatalf("unexpected second entry in topByMaxMemoryBytes: %+v", b[1])
+	}
+}
+
+func TestQueryLogRestoreQueryStatsRotated(t *testing.T) {
+	defer func(n int64, maxFiles int) {
+		queryLogMaxFileSize.N = n
+		*queryLogMaxFiles = maxFiles
+	}(queryLogMaxFileSize.N, *queryLogMaxFiles)
+	queryLogMaxFileSize.N = 300
+	*queryLogMaxFiles = 3
+
+	path := filepath.Join(t.TempDir(), "query.log")
+	qlw := newQueryLogWriter(path)
+	registerTime := time.Now()
+	for i := 0; i < 20; i++ {
+		qd := &QueryDetails{
+			Query:          "foo",
+			SamplesScanned: 1,
+		}
+		qlw.writeEntry(qd, registerTime, time.Second)
+	}
+	qlw.mustClose()
+
+	entriesExpected := len(mustReadQueryLog(t, path)) + len(mustReadQueryLog(t, path+".1")) + len(mustReadQueryLog(t, path+".2"))
+	if entriesExpected >= 20 {
+		t.Fatalf("expecting dropped entries after rotation; got %d entries", entriesExpected)
+	}
+
+	qst := &queryStatsTracker{
+		a: make([]queryStatRecord, 100),
+	}
+	qlw.restoreQueryStats(qst)
+	var bb strings.Builder
+	qst.writeJSONQueryStats(&bb, 10, time.Hour)
+	var resp struct {
+		TopByCount []struct {
+			Query string `json:"query"`
+			Count int    `json:"count"`
+		} `json:"topByCount"`
+	}
+	if err := json.Unmarshal([]byte(bb.String()), &resp); err != nil {
+		t.Fatalf("cannot parse query stats response %q: %s", bb.String(), err)
+	}
+	if len(resp.TopByCount) != 1 || resp.TopByCount[0].Count != entriesExpected {
+		t.Fatalf("unexpected topByCount; got %+v; want %d entries for foo", resp.TopByCount, entriesExpected)
+	}
+}
+
+func mustReadQueryLog(t *testing.T, path string) []queryLogEntry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("cannot open %q: %s", path, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	var entries []queryLogEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e queryLogEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("cannot parse query log line %q: %s", sc.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("cannot read %q: %s", path, err)
+	}
+	return entries
+}
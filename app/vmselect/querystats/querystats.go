@@ -22,17 +22,75 @@ var (
 	initOnce  sync.Once
 )
 
-// Enabled returns true of query stats tracking is enabled.
+// Init initializes query stats tracking and the query log at -search.queryLog.path if it is enabled.
+//
+// Init must be called before serving queries. MustStop must be called when the query log is no longer needed.
+func Init() {
+	initOnce.Do(initQueryStats)
+	if *queryLogPath == "" {
+		return
+	}
+	qlWriter = newQueryLogWriter(*queryLogPath)
+	// Restore query stats from the query log, so they survive restarts.
+	qlWriter.restoreQueryStats(qsTracker)
+}
+
+// Enabled returns true of query stats tracking or query logging is enabled.
 func Enabled() bool {
-	return *lastQueriesCount > 0
+	return *lastQueriesCount > 0 || *queryLogPath != ""
 }
 
-// RegisterQuery registers the query on the given timeRangeMsecs, which has been started at startTime.
+// QueryDetails contains details about the executed query.
+type QueryDetails struct {
+	// Query is the query text.
+	Query string
+
+	// Start, End and Step are the query time range and step in milliseconds.
+	Start int64
+	End   int64
+	Step  int64
+
+	// Client identifies the client, which sent the query.
+	Client string
+
+	// SeriesFetched is the number of series fetched from storage.
+	SeriesFetched int
+
+	// SamplesScanned is the number of raw samples scanned.
+	SamplesScanned int64
+
+	// BytesRead is the size of data blocks read from storage.
+	BytesRead int64
+
+	// EstimatedMemoryBytes is the maximum estimated memory size needed for a single rollup during the query evaluation.
+	//
+	// It isn't measured, so it may differ from the actual memory usage.
+	EstimatedMemoryBytes int64
+
+	// CacheHits and CacheMisses are the number of hits and misses in rollup result cache.
+	CacheHits   int64
+	CacheMisses int64
+}
+
+// RegisterQuery registers the query with the given qd, which has been started at startTime.
 //
 // RegisterQuery must be called when the query is finished.
-func RegisterQuery(query string, timeRangeMsecs int64, startTime time.Time) {
+func RegisterQuery(qd *QueryDetails, startTime time.Time) {
 	initOnce.Do(initQueryStats)
-	qsTracker.registerQuery(query, timeRangeMsecs, startTime)
+	registerTime := time.Now()
+	duration := registerTime.Sub(startTime)
+	if qlWriter != nil && duration >= *queryLogMinQueryDuration {
+		qlWriter.writeEntry(qd, registerTime, duration)
+	}
+	qsTracker.registerQuery(qd, registerTime, duration)
+}
+
+// MustStop closes the query log at -search.queryLog.path if it is enabled.
+func MustStop() {
+	if qlWriter != nil {
+		qlWriter.mustClose()
+		qlWriter = nil
+	}
 }
 
 // WriteJSONQueryStats writes query stats to given writer in json format.
@@ -49,10 +107,12 @@ type queryStatsTracker struct {
 }
 
 type queryStatRecord struct {
-	query         string
-	timeRangeSecs int64
-	registerTime  time.Time
-	duration      time.Duration
+	query          string
+	timeRangeSecs  int64
+	registerTime   time.Time
+	duration       time.Duration
+	samplesScanned int64
+	memoryBytes    int64
 }
 
 type queryStatKey struct {
@@ -101,16 +161,33 @@ func (qst *queryStatsTracker) writeJSONQueryStats(w io.Writer, topN int, maxLife
 			fmt.Fprintf(w, `,`)
 		}
 	}
+	fmt.Fprintf(w, `],"topBySumSamplesScanned":[`)
+	topBySumSamplesScanned := qst.getTopBySumSamplesScanned(topN, maxLifetime)
+	for i, r := range topBySumSamplesScanned {
+		fmt.Fprintf(w, `{"query":%q,"timeRangeSeconds":%d,"sumSamplesScanned":%d,"count":%d}`, r.query, r.timeRangeSecs, r.value, r.count)
+		if i+1 < len(topBySumSamplesScanned) {
+			fmt.Fprintf(w, `,`)
+		}
+	}
+	fmt.Fprintf(w, `],"topByMaxMemoryBytes":[`)
+	topByMaxMemoryBytes := qst.getTopByMaxMemoryBytes(topN, maxLifetime)
+	for i, r := range topByMaxMemoryBytes {
+		fmt.Fprintf(w, `{"query":%q,"timeRangeSeconds":%d,"maxMemoryBytes":%d,"count":%d}`, r.query, r.timeRangeSecs, r.value, r.count)
+		if i+1 < len(topByMaxMemoryBytes) {
+			fmt.Fprintf(w, `,`)
+		}
+	}
 	fmt.Fprintf(w, `]}`)
 }
 
-func (qst *queryStatsTracker) registerQuery(query string, timeRangeMsecs int64, startTime time.Time) {
-	registerTime := time.Now()
-	duration := registerTime.Sub(startTime)
-	if duration < *minQueryDuration {
+func (qst *queryStatsTracker) registerQuery(qd *QueryDetails, registerTime time.Time, duration time.Duration) {
+	if *lastQueriesCount <= 0 || duration < *minQueryDuration {
 		return
 	}
+	qst.addRecord(qd.Query, qd.End-qd.Start, registerTime, duration, qd.SamplesScanned, qd.EstimatedMemoryBytes)
+}
 
+func (qst *queryStatsTracker) addRecord(query string, timeRangeMsecs int64, registerTime time.Time, duration time.Duration, samplesScanned, memoryBytes int64) {
 	qst.mu.Lock()
 	defer qst.mu.Unlock()
 
@@ -125,6 +202,8 @@ func (qst *queryStatsTracker) registerQuery(query string, timeRangeMsecs int64,
 	r.timeRangeSecs = timeRangeMsecs / 1000
 	r.registerTime = registerTime
 	r.duration = duration
+	r.samplesScanned = samplesScanned
+	r.memoryBytes = memoryBytes
 }
 
 func (r *queryStatRecord) matches(currentTime time.Time, maxLifetime time.Duration) bool {
@@ -256,3 +335,68 @@ func (qst *queryStatsTracker) getTopBySumDuration(topN int, maxLifetime time.Dur
 	}
 	return a
 }
+
+func (qst *queryStatsTracker) getTopBySumSamplesScanned(topN int, maxLifetime time.Duration) []queryStatByValue {
+	return qst.getTopByValue(topN, maxLifetime, func(r *queryStatRecord) int64 {
+		return r.samplesScanned
+	}, func(prev, v int64) int64 {
+		return prev + v
+	})
+}
+
+func (qst *queryStatsTracker) getTopByMaxMemoryBytes(topN int, maxLifetime time.Duration) []queryStatByValue {
+	return qst.getTopByValue(topN, maxLifetime, func(r *queryStatRecord) int64 {
+		return r.memoryBytes
+	}, func(prev, v int64) int64 {
+		if v > prev {
+			return v
+		}
+		return prev
+	})
+}
+
+// getTopByValue returns topN queries with the biggest values obtained via getValue and merged via mergeValues.
+func (qst *queryStatsTracker) getTopByValue(topN int, maxLifetime time.Duration, getValue func(r *queryStatRecord) int64, mergeValues func(prev, v int64) int64) []queryStatByValue {
+	currentTime := time.Now()
+	qst.mu.Lock()
+	type countValue struct {
+		count int
+		value int64
+	}
+	m := make(map[queryStatKey]countValue)
+	for i := range qst.a {
+		r := &qst.a[i]
+		if r.matches(currentTime, maxLifetime) {
+			k := r.key()
+			kv := m[k]
+			kv.count++
+			kv.value = mergeValues(kv.value, getValue(r))
+			m[k] = kv
+		}
+	}
+	qst.mu.Unlock()
+
+	var a []queryStatByValue
+	for k, kv := range m {
+		a = append(a, queryStatByValue{
+			query:         k.query,
+			timeRangeSecs: k.timeRangeSecs,
+			value:         kv.value,
+			count:         kv.count,
+		})
+	}
+	sort.Slice(a, func(i, j int) bool {
+		return a[i].value > a[j].value
+	})
+	if len(a) > topN {
+		a = a[:topN]
+	}
+	return a
+}
+
+type queryStatByValue struct {
+	query         string
+	timeRangeSecs int64
+	value         int64
+	count         int
+}
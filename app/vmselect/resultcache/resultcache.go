@@ -0,0 +1,216 @@
+package resultcache
+
+import (
+	"flag"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/encoding"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/memory"
+	"github.com/VictoriaMetrics/fastcache"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+var (
+	instantQueryCacheTTL = flag.Duration("search.instantQueryCacheTTL", 0, "TTL for cached responses of instant queries at /api/v1/query . "+
+		"Identical queries without time arg sent within the same interval aligned to this value share the response evaluated at the time of the first query, so the response may be stale by up to this value. "+
+		"Zero disables the cache. See https://docs.victoriametrics.com/#instant-query-and-series-cache")
+	seriesCacheTTL = flag.Duration("search.seriesCacheTTL", 0, "TTL for cached responses of /api/v1/series, /api/v1/labels and /api/v1/label/.../values . "+
+		"The start and end args are rounded down to this value when looking up the cache. "+
+		"Zero disables the cache. See https://docs.victoriametrics.com/#instant-query-and-series-cache")
+	cacheSizeBytes = flagutil.NewBytes("search.resultCacheSizeBytes", 0, "The maximum size in bytes for each of the caches enabled via -search.instantQueryCacheTTL "+
+		"and -search.seriesCacheTTL. By default 1/32 of allowed memory is used. See also -memory.allowedPercent")
+)
+
+// InstantQuery caches responses for /api/v1/query.
+var InstantQuery = newCache("vmselect/instantQueryResult", instantQueryCacheTTL)
+
+// Series caches responses for /api/v1/series, /api/v1/labels and /api/v1/label/.../values.
+var Series = newCache("vmselect/seriesResult", seriesCacheTTL)
+
+var caches = []*Cache{InstantQuery, Series}
+
+// Init initializes caches with non-zero TTL.
+//
+// MustStop must be called when the caches are no longer needed.
+func Init() {
+	maxBytes := cacheSizeBytes.IntN()
+	if maxBytes <= 0 {
+		maxBytes = memory.Allowed() / 32
+	}
+	for _, c := range caches {
+		if *c.ttl > 0 {
+			c.init(maxBytes)
+		}
+	}
+}
+
+// MustStop releases memory occupied by caches.
+func MustStop() {
+	for _, c := range caches {
+		c.stop()
+	}
+}
+
+// ResetAll resets all the caches.
+//
+// It must be called when previously cached responses may become outdated, e.g. after series deletion.
+func ResetAll() {
+	for _, c := range caches {
+		c.Reset()
+	}
+}
+
+// Cache is a memory-bounded cache for responses with a short TTL.
+type Cache struct {
+	name string
+	ttl  *time.Duration
+
+	// generation is incremented on every Reset call.
+	//
+	// It is included in every key, so entries from previous generations are never returned
+	// and are eventually evicted by newer entries.
+	generation uint64
+
+	c            atomic.Value
+	maxEntrySize int
+
+	requests *metrics.Counter
+	misses   *metrics.Counter
+	resets   *metrics.Counter
+}
+
+func newCache(name string, ttl *time.Duration) *Cache {
+	return &Cache{
+		name:     name,
+		ttl:      ttl,
+		requests: metrics.NewCounter(fmt.Sprintf(`vm_cache_requests_total{type=%q}`, name)),
+		misses:   metrics.NewCounter(fmt.Sprintf(`vm_cache_misses_total{type=%q}`, name)),
+		resets:   metrics.NewCounter(fmt.Sprintf(`vm_cache_resets_total{type=%q}`, name)),
+	}
+}
+
+func (c *Cache) init(maxBytes int) {
+	fc := fastcache.New(maxBytes)
+	c.c.Store(fc)
+	// Do not cache too big responses, since they may evict many smaller entries.
+	c.maxEntrySize = maxBytes / 16
+
+	// Use metrics.GetOrCreateGauge instead of metrics.NewGauge,
+	// so init could be called multiple times in tests.
+	fcs := func() *fastcache.Stats {
+		var cs fastcache.Stats
+		if fc := c.getFastcache(); fc != nil {
+			fc.UpdateStats(&cs)
+		}
+		return &cs
+	}
+	metrics.GetOrCreateGauge(fmt.Sprintf(`vm_cache_entries{type=%q}`, c.name), func() float64 {
+		return float64(fcs().EntriesCount)
+	})
+	metrics.GetOrCreateGauge(fmt.Sprintf(`vm_cache_size_bytes{type=%q}`, c.name), func() float64 {
+		return float64(fcs().BytesSize)
+	})
+	metrics.GetOrCreateGauge(fmt.Sprintf(`vm_cache_size_max_bytes{type=%q}`, c.name), func() float64 {
+		return float64(fcs().MaxBytesSize)
+	})
+}
+
+func (c *Cache) stop() {
+	fc := c.getFastcache()
+	if fc == nil {
+		return
+	}
+	c.c.Store((*fastcache.Cache)(nil))
+	fc.Reset()
+}
+
+func (c *Cache) getFastcache() *fastcache.Cache {
+	v := c.c.Load()
+	if v == nil {
+		return nil
+	}
+	return v.(*fastcache.Cache)
+}
+
+// Enabled returns true if c is enabled.
+func (c *Cache) Enabled() bool {
+	return c.getFastcache() != nil
+}
+
+// TTL returns TTL for c entries.
+func (c *Cache) TTL() time.Duration {
+	return *c.ttl
+}
+
+// MaxEntrySize returns the maximum size of the response, which can be stored in c.
+func (c *Cache) MaxEntrySize() int {
+	return c.maxEntrySize
+}
+
+// Reset resets c.
+func (c *Cache) Reset() {
+	if !c.Enabled() {
+		return
+	}
+	c.resets.Inc()
+	atomic.AddUint64(&c.generation, 1)
+	logger.Infof("%s cache has been cleared", c.name)
+}
+
+// Get returns response body and content type for the given key.
+//
+// The body is appended to dst. false is returned if the key is missing in c or if the entry is expired.
+func (c *Cache) Get(dst []byte, key []byte) ([]byte, string, bool) {
+	fc := c.getFastcache()
+	if fc == nil {
+		return dst, "", false
+	}
+	c.requests.Inc()
+	v := fc.GetBig(nil, c.marshalKey(key))
+	if len(v) < 8 {
+		c.misses.Inc()
+		return dst, "", false
+	}
+	deadline := encoding.UnmarshalUint64(v)
+	if fasttime.UnixTimestamp() >= deadline {
+		c.misses.Inc()
+		return dst, "", false
+	}
+	tail, contentType, err := encoding.UnmarshalBytes(v[8:])
+	if err != nil {
+		logger.Panicf("BUG: cannot unmarshal content type from %s cache entry: %s", c.name, err)
+	}
+	return append(dst, tail...), string(contentType), true
+}
+
+// Set stores the response body with the given contentType under the given key in c.
+func (c *Cache) Set(key []byte, contentType string, body []byte) {
+	ttlSecs := uint64(c.TTL().Seconds())
+	if ttlSecs == 0 {
+		ttlSecs = 1
+	}
+	c.setWithDeadline(key, contentType, body, fasttime.UnixTimestamp()+ttlSecs)
+}
+
+func (c *Cache) setWithDeadline(key []byte, contentType string, body []byte, deadline uint64) {
+	fc := c.getFastcache()
+	if fc == nil {
+		return
+	}
+	v := make([]byte, 0, 8+len(contentType)+len(body)+10)
+	v = encoding.MarshalUint64(v, deadline)
+	v = encoding.MarshalBytes(v, []byte(contentType))
+	v = append(v, body...)
+	fc.SetBig(c.marshalKey(key), v)
+}
+
+func (c *Cache) marshalKey(key []byte) []byte {
+	dst := make([]byte, 0, 8+len(key))
+	dst = encoding.MarshalUint64(dst, atomic.LoadUint64(&c.generation))
+	return append(dst, key...)
+}
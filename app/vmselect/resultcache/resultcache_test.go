@@ -0,0 +1,68 @@
+package resultcache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fasttime"
+)
+
+func TestCacheDisabled(t *testing.T) {
+	ttl := time.Duration(0)
+	c := newCache("test_disabled", &ttl)
+	if c.Enabled() {
+		t.Fatalf("cache mustn't be enabled before init")
+	}
+	c.Set([]byte("foo"), "application/json", []byte("bar"))
+	if _, _, ok := c.Get(nil, []byte("foo")); ok {
+		t.Fatalf("unexpected entry found in disabled cache")
+	}
+	c.Reset()
+	c.stop()
+}
+
+func TestCacheGetSet(t *testing.T) {
+	ttl := time.Minute
+	c := newCache("test_get_set", &ttl)
+	c.init(1024 * 1024)
+	defer c.stop()
+
+	if !c.Enabled() {
+		t.Fatalf("cache must be enabled after init")
+	}
+	if c.TTL() != ttl {
+		t.Fatalf("unexpected ttl; got %s; want %s", c.TTL(), ttl)
+	}
+	if _, _, ok := c.Get(nil, []byte("foo")); ok {
+		t.Fatalf("unexpected entry found in empty cache")
+	}
+
+	c.Set([]byte("foo"), "application/json", []byte(`{"status":"success"}`))
+	body, contentType, ok := c.Get([]byte("prefix"), []byte("foo"))
+	if !ok {
+		t.Fatalf("cannot find the stored entry")
+	}
+	if string(body) != `prefix{"status":"success"}` {
+		t.Fatalf("unexpected body: %q", body)
+	}
+	if contentType != "application/json" {
+		t.Fatalf("unexpected content type: %q", contentType)
+	}
+
+	// Expired entries mustn't be returned
+	c.setWithDeadline([]byte("expired"), "application/json", []byte("bar"), fasttime.UnixTimestamp()-1)
+	if _, _, ok := c.Get(nil, []byte("expired")); ok {
+		t.Fatalf("unexpected expired entry returned")
+	}
+
+	// Entries mustn't be returned after the reset
+	c.Reset()
+	if _, _, ok := c.Get(nil, []byte("foo")); ok {
+		t.Fatalf("unexpected entry returned after cache reset")
+	}
+	c.Set([]byte("foo"), "text/plain", []byte("baz"))
+	body, contentType, ok = c.Get(nil, []byte("foo"))
+	if !ok || string(body) != "baz" || contentType != "text/plain" {
+		t.Fatalf("unexpected entry after cache reset; ok=%v, body=%q, contentType=%q", ok, body, contentType)
+	}
+}
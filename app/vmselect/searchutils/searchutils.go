@@ -1,6 +1,7 @@
 package searchutils
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"math"
@@ -203,6 +204,50 @@ func (d *Deadline) String() string {
 	return msg
 }
 
+// ConcurrencySlot is a slot in the limiter for concurrently executed requests, which is held by the current request.
+//
+// The slot can be temporarily released while the request waits for other resources.
+type ConcurrencySlot struct {
+	ch chan struct{}
+}
+
+// Release releases cs, so other requests can be executed.
+//
+// It does nothing if cs is nil.
+func (cs *ConcurrencySlot) Release() {
+	if cs == nil {
+		return
+	}
+	<-cs.ch
+}
+
+// Acquire acquires cs again after Release call.
+//
+// It does nothing if cs is nil.
+func (cs *ConcurrencySlot) Acquire() {
+	if cs == nil {
+		return
+	}
+	cs.ch <- struct{}{}
+}
+
+type concurrencySlotKey struct{}
+
+// WithConcurrencySlot returns ctx for the request holding the slot at the limiter ch for concurrently executed requests.
+func WithConcurrencySlot(ctx context.Context, ch chan struct{}) context.Context {
+	return context.WithValue(ctx, concurrencySlotKey{}, &ConcurrencySlot{
+		ch: ch,
+	})
+}
+
+// GetConcurrencySlot returns the slot held by r.
+//
+// It returns nil if r holds no slot.
+func GetConcurrencySlot(r *http.Request) *ConcurrencySlot {
+	cs, _ := r.Context().Value(concurrencySlotKey{}).(*ConcurrencySlot)
+	return cs
+}
+
 // GetExtraTagFilters returns additional label filters from request.
 //
 // Label filters can be present in extra_label and extra_filters[] query args.
@@ -231,6 +231,19 @@ func GetSeriesCount(deadline uint64) (uint64, error) {
 	return n, err
 }
 
+// GetSeriesCountPerDay returns the number of series matching tfss per each day on the given tr.
+func GetSeriesCountPerDay(qt *querytracer.Tracer, tfss []*storage.TagFilters, tr storage.TimeRange, maxMetrics int, deadline uint64) ([]int, error) {
+	WG.Add(1)
+	counts, err := Storage.GetSeriesCountPerDay(qt, tfss, tr, maxMetrics, deadline)
+	WG.Done()
+	return counts, err
+}
+
+// GetSampleDensity returns the estimated number of samples per series per second.
+func GetSampleDensity() float64 {
+	return Storage.GetSampleDensity()
+}
+
 // Stop stops the vmstorage
 func Stop() {
 	logger.Infof("gracefully closing the storage at %s", *DataPath)
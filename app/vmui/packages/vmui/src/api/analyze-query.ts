@@ -0,0 +1,11 @@
+import { TimeParams } from "../types";
+
+export interface QueryHint {
+  type: string;
+  expr: string;
+  message: string;
+  suggestion: string;
+}
+
+export const getAnalyzeQueryUrl = (server: string, query: string, period: TimeParams): string =>
+  `${server}/api/v1/analyze_query?query=${encodeURIComponent(query)}&start=${period.start}&end=${period.end}&step=${period.step}`;
@@ -0,0 +1,47 @@
+import { useEffect, useState } from "preact/compat";
+import { useAppState } from "../../../state/common/StateContext";
+import { useQueryState } from "../../../state/query/QueryStateContext";
+import { useTimeState } from "../../../state/time/TimeStateContext";
+import { getAnalyzeQueryUrl, QueryHint } from "../../../api/analyze-query";
+
+export interface QueryAnalysis {
+  query: string;
+  suggestedQuery: string;
+  hints: QueryHint[];
+}
+
+export const useAnalyzeQuery = (hideQuery: number[]) => {
+  const { serverUrl } = useAppState();
+  const { query } = useQueryState();
+  const { period } = useTimeState();
+
+  const [analyses, setAnalyses] = useState<QueryAnalysis[]>([]);
+
+  const fetchData = async (controller: AbortController) => {
+    const queries = query.filter((q, i) => q.trim() && !hideQuery.includes(i));
+    const result: QueryAnalysis[] = [];
+    for (const q of queries) {
+      try {
+        const response = await fetch(getAnalyzeQueryUrl(serverUrl, q, period), { signal: controller.signal });
+        if (!response.ok) continue;
+        const resp = await response.json();
+        if (resp?.data?.hints?.length) {
+          result.push(resp.data);
+        }
+      } catch (e) {
+        // Query analysis is optional, so errors are ignored.
+        if (e instanceof Error && e.name === "AbortError") return;
+      }
+    }
+    setAnalyses(result);
+  };
+
+  useEffect(() => {
+    if (!serverUrl) return;
+    const controller = new AbortController();
+    fetchData(controller);
+    return () => controller.abort();
+  }, [serverUrl, query, period, hideQuery]);
+
+  return { analyses };
+};
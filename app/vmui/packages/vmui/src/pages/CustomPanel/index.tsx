@@ -16,6 +16,7 @@ import { useCustomPanelState } from "../../state/customPanel/CustomPanelStateCon
 import { useQueryState } from "../../state/query/QueryStateContext";
 import { useTimeDispatch, useTimeState } from "../../state/time/TimeStateContext";
 import { useSetQueryParams } from "./hooks/useSetQueryParams";
+import { useAnalyzeQuery } from "./hooks/useAnalyzeQuery";
 import "./style.scss";
 import Alert from "../../components/Main/Alert/Alert";
 import TableView from "../../components/Views/TableView/TableView";
@@ -66,6 +67,8 @@ const CustomPanel: FC = () => {
     showAllSeries
   });
 
+  const { analyses } = useAnalyzeQuery(hideQuery);
+
   const setYaxisLimits = (limits: AxisRange) => {
     graphDispatch({ type: "SET_YAXIS_LIMITS", payload: limits });
   };
@@ -132,6 +135,27 @@ const CustomPanel: FC = () => {
       {isLoading && <Spinner />}
       {!hideError && error && <Alert variant="error">{error}</Alert>}
       {!liveData?.length && (displayType !== "chart") && <Alert variant="info"><InstantQueryTip/></Alert>}
+      {!!analyses.length && <Alert variant="info">
+        <div className="vm-custom-panel__hints">
+          {analyses.map((analysis) => (
+            <div
+              className="vm-custom-panel__hints-query"
+              key={analysis.query}
+            >
+              <code>{analysis.query}</code>
+              <ul>
+                {analysis.hints.map((hint, i) => (
+                  <li key={`${hint.type}_${i}`}>
+                    {hint.message}
+                    {hint.suggestion && <span>: <code>{hint.suggestion}</code></span>}
+                  </li>
+                ))}
+              </ul>
+              {analysis.suggestedQuery && <p>Suggested query: <code>{analysis.suggestedQuery}</code></p>}
+            </div>
+          ))}
+        </div>
+      </Alert>}
       {warning && <Alert variant="warning">
         <div
           className={classNames({
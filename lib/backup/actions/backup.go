@@ -1,6 +1,7 @@
 package actions
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/encryption"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/fscommon"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/fslocal"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/fsnil"
@@ -47,6 +49,11 @@ type Backup struct {
 	// Origin is optional origin for speeding up full backup if Dst points
 	// to empty dir.
 	Origin common.OriginFS
+
+	// KeyProvider is optional provider of keys for client-side encryption of the backup.
+	//
+	// The backup isn't encrypted if KeyProvider is nil.
+	KeyProvider encryption.KeyProvider
 }
 
 // BackupMetadata contains metadata about the backup.
@@ -54,6 +61,9 @@ type Backup struct {
 type BackupMetadata struct {
 	CreatedAt   string `json:"created_at"`
 	CompletedAt string `json:"completed_at"`
+
+	// Encryption contains encryption params for encrypted backups.
+	Encryption *encryption.Metadata `json:"encryption,omitempty"`
 }
 
 // Run runs b with the provided settings.
@@ -73,10 +83,40 @@ func (b *Backup) Run() error {
 	if err := dst.DeleteFile(fscommon.BackupCompleteFilename); err != nil {
 		return fmt.Errorf("cannot delete `backup complete` file at %s: %w", dst, err)
 	}
-	if err := runBackup(src, dst, origin, concurrency); err != nil {
+	prevMetadata, err := ReadMetadata(dst)
+	if err != nil {
+		return err
+	}
+	em, dst, origin, resetDst, err := prepareEncryption(b.KeyProvider, prevMetadata, dst, origin)
+	if err != nil {
+		return err
+	}
+	if em != nil || (prevMetadata != nil && prevMetadata.Encryption != nil) {
+		// Store encryption params before uploading the data,
+		// so interrupted backup can be resumed or restored with the correct params.
+		if err := storeMetadata(src, dst, em, false); err != nil {
+			return fmt.Errorf("cannot store backup metadata: %w", err)
+		}
+	}
+	prevChecksums, err := readChecksums(dst)
+	if err != nil {
+		return err
+	}
+	originChecksums := newPartChecksums()
+	if originRemote, ok := origin.(common.RemoteFS); ok {
+		originChecksums, err = readChecksums(originRemote)
+		if err != nil {
+			return err
+		}
+	}
+	checksums, err := runBackup(src, dst, origin, concurrency, resetDst, prevChecksums, originChecksums)
+	if err != nil {
+		return err
+	}
+	if err := storeChecksums(dst, checksums); err != nil {
 		return err
 	}
-	if err := storeMetadata(src, dst); err != nil {
+	if err := storeMetadata(src, dst, em, true); err != nil {
 		return fmt.Errorf("cannot store backup metadata: %w", err)
 	}
 	if err := dst.CreateFile(fscommon.BackupCompleteFilename, []byte{}); err != nil {
@@ -86,7 +126,7 @@ func (b *Backup) Run() error {
 	return nil
 }
 
-func storeMetadata(src *fslocal.FS, dst common.RemoteFS) error {
+func storeMetadata(src *fslocal.FS, dst common.RemoteFS, em *encryption.Metadata, isComplete bool) error {
 	snapshotName := filepath.Base(src.Dir)
 	snapshotTime, err := snapshot.Time(snapshotName)
 	if err != nil {
@@ -94,8 +134,11 @@ func storeMetadata(src *fslocal.FS, dst common.RemoteFS) error {
 	}
 
 	d := BackupMetadata{
-		CreatedAt:   snapshotTime.Format(time.RFC3339),
-		CompletedAt: time.Now().Format(time.RFC3339),
+		CreatedAt:  snapshotTime.Format(time.RFC3339),
+		Encryption: em,
+	}
+	if isComplete {
+		d.CompletedAt = time.Now().Format(time.RFC3339)
 	}
 
 	metadata, err := json.Marshal(d)
@@ -110,31 +153,165 @@ func storeMetadata(src *fslocal.FS, dst common.RemoteFS) error {
 	return nil
 }
 
-func runBackup(src *fslocal.FS, dst common.RemoteFS, origin common.OriginFS, concurrency int) error {
+// ReadMetadata reads backup metadata from fs.
+//
+// nil is returned if fs has no backup metadata.
+func ReadMetadata(fs common.RemoteFS) (*BackupMetadata, error) {
+	data, err := readFile(fs, fscommon.BackupMetadataFilename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read backup metadata: %w", err)
+	}
+	if data == nil {
+		return nil, nil
+	}
+	var m BackupMetadata
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("cannot parse backup metadata at %s: %w", fs, err)
+	}
+	return &m, nil
+}
+
+// readFile reads the file at filePath from fs.
+//
+// nil is returned if the file is missing.
+func readFile(fs common.RemoteFS, filePath string) ([]byte, error) {
+	fis, err := fs.ListFiles(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list files at %s: %w", fs, err)
+	}
+	for _, fi := range fis {
+		if fi.Path != filePath {
+			continue
+		}
+		data, err := fs.ReadFileRange(fi.Path, 0, fi.Size)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %q at %s: %w", fi.Path, fs, err)
+		}
+		if data == nil {
+			data = []byte{}
+		}
+		return data, nil
+	}
+	return nil, nil
+}
+
+// prepareEncryption returns encryption params for the backup at dst and dst with origin wrapped into encryption.FS if needed.
+//
+// The data key of the existing backup at dst is re-used, so unchanged parts aren't re-uploaded.
+// The data key of the origin is used for full backups, so parts can be copied from the origin at server side.
+// resetDst is set to true if the existing parts at dst must be re-uploaded because of the changed encryption.
+func prepareEncryption(kp encryption.KeyProvider, prevMetadata *BackupMetadata, dst common.RemoteFS, origin common.OriginFS) (*encryption.Metadata, common.RemoteFS, common.OriginFS, bool, error) {
+	var prevEncryption *encryption.Metadata
+	if prevMetadata != nil {
+		prevEncryption = prevMetadata.Encryption
+	}
+	var originEncryption *encryption.Metadata
+	originRemote, isRemoteOrigin := origin.(common.RemoteFS)
+	if isRemoteOrigin {
+		originMetadata, err := ReadMetadata(originRemote)
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+		if originMetadata != nil {
+			originEncryption = originMetadata.Encryption
+		}
+	}
+
+	if kp == nil {
+		if originEncryption != nil {
+			logger.Infof("disabling server-side copying from origin %s, since it is encrypted, while the backup at %s isn't encrypted", origin, dst)
+			origin = &fsnil.FS{}
+		}
+		if prevEncryption != nil {
+			logger.Infof("the existing backup at %s is encrypted, while the new backup isn't encrypted; all the parts will be re-uploaded", dst)
+			return nil, dst, origin, true, nil
+		}
+		return nil, dst, origin, false, nil
+	}
+
+	var dataKey []byte
+	frameSize := encryption.DefaultFrameSize
+	resetDst := false
+	if prevEncryption != nil {
+		k, err := prevEncryption.DataKey(kp)
+		if err != nil {
+			return nil, nil, nil, false, fmt.Errorf("cannot obtain data key for the existing backup at %s: %w; "+
+				"use the key the backup was encrypted with or an empty destination", dst, err)
+		}
+		dataKey = k
+		frameSize = prevEncryption.FrameSize
+	} else {
+		// The existing backup at dst, if any, isn't encrypted.
+		resetDst = true
+		if originEncryption != nil {
+			if k, err := originEncryption.DataKey(kp); err == nil {
+				dataKey = k
+				frameSize = originEncryption.FrameSize
+			}
+		}
+		if dataKey == nil {
+			k, err := encryption.NewDataKey()
+			if err != nil {
+				return nil, nil, nil, false, err
+			}
+			dataKey = k
+		}
+	}
+	if isRemoteOrigin {
+		canCopy := false
+		if originEncryption != nil && originEncryption.FrameSize == frameSize {
+			k, err := originEncryption.DataKey(kp)
+			canCopy = err == nil && bytes.Equal(k, dataKey)
+		}
+		if canCopy {
+			origin = encryption.NewFS(originRemote, dataKey, frameSize)
+		} else {
+			logger.Infof("disabling server-side copying from origin %s, since it isn't encrypted with the same data key as the backup at %s", origin, dst)
+			origin = &fsnil.FS{}
+		}
+	}
+	em, err := encryption.NewMetadata(kp, dataKey, frameSize)
+	if err != nil {
+		return nil, nil, nil, false, fmt.Errorf("cannot wrap data key: %w", err)
+	}
+	return em, encryption.NewFS(dst, dataKey, frameSize), origin, resetDst, nil
+}
+
+// runBackup performs the backup from src to dst and returns checksums for all the parts in the backup.
+//
+// Checksums for parts, which weren't uploaded from src, are taken from prevChecksums and originChecksums.
+func runBackup(src *fslocal.FS, dst common.RemoteFS, origin common.OriginFS, concurrency int, resetDst bool, prevChecksums, originChecksums *partChecksums) (*partChecksums, error) {
 	startTime := time.Now()
 
 	logger.Infof("starting backup from %s to %s using origin %s", src, dst, origin)
 
 	srcParts, err := src.ListParts()
 	if err != nil {
-		return fmt.Errorf("cannot list src parts: %w", err)
+		return nil, fmt.Errorf("cannot list src parts: %w", err)
 	}
 	logger.Infof("obtained %d parts from src %s", len(srcParts), src)
 
 	dstParts, err := dst.ListParts()
 	if err != nil {
-		return fmt.Errorf("cannot list dst parts: %w", err)
+		return nil, fmt.Errorf("cannot list dst parts: %w", err)
 	}
 	logger.Infof("obtained %d parts from dst %s", len(dstParts), dst)
 
 	originParts, err := origin.ListParts()
 	if err != nil {
-		return fmt.Errorf("cannot list origin parts: %w", err)
+		return nil, fmt.Errorf("cannot list origin parts: %w", err)
 	}
 	logger.Infof("obtained %d parts from origin %s", len(originParts), origin)
 
 	backupSize := getPartsSize(srcParts)
 
+	if resetDst && len(dstParts) > 0 {
+		logger.Infof("re-uploading all the %d parts at dst %s because of the changed encryption", len(dstParts), dst)
+		// Mark all the dst parts as broken, so they are deleted and uploaded again.
+		for i := range dstParts {
+			dstParts[i].ActualSize = dstParts[i].Size + 1
+		}
+	}
 	partsToDelete := common.PartsDifference(dstParts, srcParts)
 	deleteSize := getPartsSize(partsToDelete)
 	if len(partsToDelete) > 0 {
@@ -152,13 +329,16 @@ func runBackup(src *fslocal.FS, dst common.RemoteFS, origin common.OriginFS, con
 			logger.Infof("deleted %d out of %d parts from dst %s in %s", n, len(partsToDelete), dst, elapsed)
 		})
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if err := dst.RemoveEmptyDirs(); err != nil {
-			return fmt.Errorf("cannot remove empty directories at dst %s: %w", dst, err)
+			return nil, fmt.Errorf("cannot remove empty directories at dst %s: %w", dst, err)
 		}
 	}
 
+	checksums := newPartChecksums()
+	checksums.CopyFrom(prevChecksums, common.PartsIntersect(dstParts, srcParts))
+
 	partsToCopy := common.PartsDifference(srcParts, dstParts)
 	originCopyParts := common.PartsIntersect(originParts, partsToCopy)
 	copySize := getPartsSize(originCopyParts)
@@ -170,6 +350,9 @@ func runBackup(src *fslocal.FS, dst common.RemoteFS, origin common.OriginFS, con
 			if err := dst.CopyPart(origin, p); err != nil {
 				return fmt.Errorf("cannot copy %s from origin %s to dst %s: %w", &p, origin, dst, err)
 			}
+			if checksum, ok := originChecksums.Get(p); ok {
+				checksums.Set(p, checksum)
+			}
 			atomic.AddUint64(&copiedParts, 1)
 			return nil
 		}, func(elapsed time.Duration) {
@@ -177,7 +360,7 @@ func runBackup(src *fslocal.FS, dst common.RemoteFS, origin common.OriginFS, con
 			logger.Infof("server-side copied %d out of %d parts from origin %s to dst %s in %s", n, len(originCopyParts), origin, dst, elapsed)
 		})
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
@@ -192,8 +375,9 @@ func runBackup(src *fslocal.FS, dst common.RemoteFS, origin common.OriginFS, con
 			if err != nil {
 				return fmt.Errorf("cannot create reader for %s from src %s: %w", &p, src, err)
 			}
+			hr := newHashReader(rc)
 			sr := &statReader{
-				r:         rc,
+				r:         hr,
 				bytesRead: &bytesUploaded,
 			}
 			if err := dst.UploadPart(p, sr); err != nil {
@@ -202,6 +386,7 @@ func runBackup(src *fslocal.FS, dst common.RemoteFS, origin common.OriginFS, con
 			if err = rc.Close(); err != nil {
 				return fmt.Errorf("cannot close reader for %s from src %s: %w", &p, src, err)
 			}
+			checksums.Set(p, hr.Sum64())
 			return nil
 		}, func(elapsed time.Duration) {
 			n := atomic.LoadUint64(&bytesUploaded)
@@ -210,14 +395,19 @@ func runBackup(src *fslocal.FS, dst common.RemoteFS, origin common.OriginFS, con
 		atomic.AddUint64(&bytesUploadedTotal, bytesUploaded)
 		bytesUploadedTotalMetric.Set(bytesUploadedTotal)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
+	if n := len(srcParts) - checksums.Len(); n > 0 {
+		logger.Infof("%d out of %d parts at dst %s have no checksums, since they were backed up by older vmbackup version; "+
+			"make a full backup to an empty dst in order to obtain checksums for all the parts", n, len(srcParts), dst)
+	}
+
 	logger.Infof("backup from src %s to dst %s with origin %s is complete; backed up %d bytes in %.3f seconds; deleted %d bytes; server-side copied %d bytes; uploaded %d bytes",
 		src, dst, origin, backupSize, time.Since(startTime).Seconds(), deleteSize, copySize, uploadSize)
 
-	return nil
+	return checksums, nil
 }
 
 type statReader struct {
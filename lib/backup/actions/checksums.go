@@ -0,0 +1,137 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/fscommon"
+)
+
+// partChecksums contains xxhash checksums for the contents of backed up parts.
+//
+// Checksums are calculated for unencrypted part contents.
+type partChecksums struct {
+	mu sync.Mutex
+	m  map[string]uint64
+}
+
+func newPartChecksums() *partChecksums {
+	return &partChecksums{
+		m: make(map[string]uint64),
+	}
+}
+
+func checksumKey(p common.Part) string {
+	return strings.TrimPrefix(p.RemotePath(""), "/")
+}
+
+// Get returns the checksum for p.
+func (pc *partChecksums) Get(p common.Part) (uint64, bool) {
+	pc.mu.Lock()
+	checksum, ok := pc.m[checksumKey(p)]
+	pc.mu.Unlock()
+	return checksum, ok
+}
+
+// Set sets the checksum for p.
+func (pc *partChecksums) Set(p common.Part, checksum uint64) {
+	pc.mu.Lock()
+	pc.m[checksumKey(p)] = checksum
+	pc.mu.Unlock()
+}
+
+// CopyFrom copies checksums for parts from src to pc.
+//
+// Parts without checksums in src are skipped.
+func (pc *partChecksums) CopyFrom(src *partChecksums, parts []common.Part) {
+	for _, p := range parts {
+		if checksum, ok := src.Get(p); ok {
+			pc.Set(p, checksum)
+		}
+	}
+}
+
+// Len returns the number of checksums in pc.
+func (pc *partChecksums) Len() int {
+	pc.mu.Lock()
+	n := len(pc.m)
+	pc.mu.Unlock()
+	return n
+}
+
+// readChecksums reads part checksums from fs.
+//
+// Empty checksums are returned if fs contains no checksums. This is the case for backups made by older vmbackup versions.
+func readChecksums(fs common.RemoteFS) (*partChecksums, error) {
+	data, err := readFile(fs, fscommon.BackupChecksumsFilename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read backup checksums: %w", err)
+	}
+	pc := newPartChecksums()
+	if data == nil {
+		return pc, nil
+	}
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("cannot parse backup checksums at %s: %w", fs, err)
+	}
+	for k, v := range m {
+		checksum, err := strconv.ParseUint(v, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse checksum for %q at %s: %w", k, fs, err)
+		}
+		pc.m[k] = checksum
+	}
+	return pc, nil
+}
+
+// storeChecksums stores pc to dst.
+func storeChecksums(dst common.RemoteFS, pc *partChecksums) error {
+	pc.mu.Lock()
+	m := make(map[string]string, len(pc.m))
+	for k, checksum := range pc.m {
+		m[k] = fmt.Sprintf("%016x", checksum)
+	}
+	pc.mu.Unlock()
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("cannot marshal backup checksums: %w", err)
+	}
+	if err := dst.CreateFile(fscommon.BackupChecksumsFilename, data); err != nil {
+		return fmt.Errorf("cannot create backup checksums file at %s: %w", dst, err)
+	}
+	return nil
+}
+
+// hashReader calculates the checksum for the data read from r.
+type hashReader struct {
+	r io.Reader
+	h hash.Hash64
+}
+
+func newHashReader(r io.Reader) *hashReader {
+	return &hashReader{
+		r: r,
+		h: xxhash.New(),
+	}
+}
+
+func (hr *hashReader) Read(p []byte) (int, error) {
+	n, err := hr.r.Read(p)
+	_, _ = hr.h.Write(p[:n])
+	return n, err
+}
+
+// Sum64 returns the checksum for the data read so far.
+func (hr *hashReader) Sum64() uint64 {
+	return hr.h.Sum64()
+}
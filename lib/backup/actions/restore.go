@@ -10,6 +10,7 @@ import (
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/backupnames"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/encryption"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/fscommon"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/fslocal"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
@@ -38,6 +39,17 @@ type Restore struct {
 	//
 	// This may be needed for restoring from old backups with missing `backup complete` file.
 	SkipBackupCompleteCheck bool
+
+	// KeyProvider is optional provider of keys for decrypting encrypted backups.
+	//
+	// It must be set if Src contains encrypted backup.
+	KeyProvider encryption.KeyProvider
+
+	// PartFilter is optional filter for parts to restore.
+	//
+	// Only parts with PartFilter returning true are restored from Src.
+	// Other parts are deleted from Dst, i.e. Dst contains only the filtered data from Src after the restore.
+	PartFilter func(p common.Part) bool
 }
 
 // Run runs r with the provided settings.
@@ -67,6 +79,11 @@ func (r *Restore) Run() error {
 		}
 	}
 
+	src, err := newDecryptFS(src, r.KeyProvider)
+	if err != nil {
+		return err
+	}
+
 	logger.Infof("starting restore from %s to %s", src, dst)
 
 	logger.Infof("obtaining list of parts at %s", src)
@@ -80,6 +97,11 @@ func (r *Restore) Run() error {
 		return fmt.Errorf("cannot list dst parts: %w", err)
 	}
 
+	if r.PartFilter != nil {
+		srcParts = filterParts(srcParts, r.PartFilter)
+		logger.Infof("selected %d parts at %s for the restore", len(srcParts), src)
+	}
+
 	backupSize := getPartsSize(srcParts)
 
 	// Validate srcParts. They must cover the whole files.
@@ -193,6 +215,16 @@ func (r *Restore) Run() error {
 	return removeRestoreLock(r.Dst.Dir)
 }
 
+func filterParts(parts []common.Part, f func(p common.Part) bool) []common.Part {
+	var dst []common.Part
+	for _, p := range parts {
+		if f(p) {
+			dst = append(dst, p)
+		}
+	}
+	return dst
+}
+
 type statWriter struct {
 	w            io.Writer
 	bytesWritten *uint64
@@ -220,3 +252,24 @@ func removeRestoreLock(dstDir string) error {
 	}
 	return nil
 }
+
+// newDecryptFS returns fs, which transparently decrypts the backup at src if it is encrypted.
+//
+// src is returned as is if the backup isn't encrypted.
+func newDecryptFS(src common.RemoteFS, kp encryption.KeyProvider) (common.RemoteFS, error) {
+	m, err := ReadMetadata(src)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil || m.Encryption == nil {
+		return src, nil
+	}
+	if kp == nil {
+		return nil, fmt.Errorf("the backup at %s is encrypted with the key %q; pass -encryptionKeyFile or -encryptionKeyringDir command-line flag with this key", src, m.Encryption.KeyID)
+	}
+	dataKey, err := m.Encryption.DataKey(kp)
+	if err != nil {
+		return nil, fmt.Errorf("cannot obtain data key for the backup at %s: %w", src, err)
+	}
+	return encryption.NewFS(src, dataKey, m.Encryption.FrameSize), nil
+}
@@ -0,0 +1,60 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/backupnames"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+)
+
+// RestoreMark contains the backup to restore on the next vmrestore run.
+type RestoreMark struct {
+	// Backup is the full path to the backup at remote storage.
+	Backup string `json:"backup"`
+}
+
+// ReadRestoreMark reads restore mark from storageDataPath.
+//
+// nil is returned if the restore mark is missing.
+func ReadRestoreMark(storageDataPath string) (*RestoreMark, error) {
+	path := filepath.Join(storageDataPath, backupnames.RestoreMarkFilename)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot read restore mark: %w", err)
+	}
+	var rm RestoreMark
+	if err := json.Unmarshal(data, &rm); err != nil {
+		return nil, fmt.Errorf("cannot parse restore mark at %q: %w", path, err)
+	}
+	if rm.Backup == "" {
+		return nil, fmt.Errorf("missing backup in restore mark at %q", path)
+	}
+	return &rm, nil
+}
+
+// WriteRestoreMark writes rm to storageDataPath.
+func WriteRestoreMark(storageDataPath string, rm *RestoreMark) error {
+	data, err := json.Marshal(rm)
+	if err != nil {
+		return fmt.Errorf("cannot marshal restore mark: %w", err)
+	}
+	fs.MustMkdirIfNotExist(storageDataPath)
+	path := filepath.Join(storageDataPath, backupnames.RestoreMarkFilename)
+	fs.MustWriteAtomic(path, data, true)
+	return nil
+}
+
+// DeleteRestoreMark deletes restore mark from storageDataPath.
+func DeleteRestoreMark(storageDataPath string) error {
+	path := filepath.Join(storageDataPath, backupnames.RestoreMarkFilename)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot delete restore mark: %w", err)
+	}
+	return nil
+}
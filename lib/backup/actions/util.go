@@ -3,6 +3,9 @@ package actions
 import (
 	"flag"
 	"fmt"
+	"net"
+	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -10,9 +13,12 @@ import (
 
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/azremote"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/encryption"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/fsremote"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/gcsremote"
 	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/s3remote"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/sftpremote"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/webdavremote"
 )
 
 var (
@@ -27,8 +33,42 @@ var (
 	s3StorageClass   = flag.String("s3StorageClass", "", "The Storage Class applied to objects uploaded to AWS S3. Supported values are: GLACIER, "+
 		"DEEP_ARCHIVE, GLACIER_IR, INTELLIGENT_TIERING, ONEZONE_IA, OUTPOSTS, REDUCED_REDUNDANCY, STANDARD, STANDARD_IA.\n"+
 		"See https://docs.aws.amazon.com/AmazonS3/latest/userguide/storage-class-intro.html/")
+	encryptionKeyFile = flag.String("encryptionKeyFile", "", "Optional path to file with 32-byte key for client-side encryption of backups. "+
+		"The key may be stored in raw, hex or base64 form. See also -encryptionKeyringDir")
+	encryptionKeyringDir = flag.String("encryptionKeyringDir", "", "Optional path to directory with keys for client-side encryption of backups. "+
+		"File names are used as key ids. The key for new backups is selected via -encryptionKeyID, while all the keys in the directory "+
+		"can be used for decrypting existing backups. This allows rotating keys. See also -encryptionKeyFile")
+	encryptionKeyID = flag.String("encryptionKeyID", "", "The id of the key from -encryptionKeyringDir to use for encrypting backups")
+
+	sftpPasswordFile          = flag.String("sftp.passwordFile", "", "Optional path to file with the password for the user from sftp:// url")
+	sftpPrivateKeyFile        = flag.String("sftp.privateKeyFile", "", "Optional path to file with the private key for the user from sftp:// url")
+	sftpKnownHostsFile        = flag.String("sftp.knownHostsFile", "", "Path to known_hosts file for verifying SFTP server host key. ~/.ssh/known_hosts is used if not set")
+	sftpInsecureIgnoreHostKey = flag.Bool("sftp.insecureIgnoreHostKey", false, "Whether to skip verification of SFTP server host key. This is insecure, so use it only for testing")
+	webdavPasswordFile        = flag.String("webdav.passwordFile", "", "Optional path to file with the password for basic auth with the user from webdav:// or webdavs:// url")
+	webdavTLSCAFile           = flag.String("webdav.tlsCAFile", "", "Optional path to file with CA certificates for verifying WebDAV server certificate for webdavs:// url. "+
+		"System CA certificates are used if not set")
+	webdavTLSInsecureSkipVerify = flag.Bool("webdav.tlsInsecureSkipVerify", false, "Whether to skip verification of WebDAV server certificate for webdavs:// url")
 )
 
+// NewKeyProvider returns encryption key provider configured via -encryptionKey* command-line flags.
+//
+// nil is returned if encryption isn't configured.
+func NewKeyProvider() (encryption.KeyProvider, error) {
+	if *encryptionKeyFile != "" && *encryptionKeyringDir != "" {
+		return nil, fmt.Errorf("-encryptionKeyFile and -encryptionKeyringDir cannot be set simultaneously")
+	}
+	if *encryptionKeyFile != "" {
+		return encryption.NewFileKeyProvider(*encryptionKeyFile)
+	}
+	if *encryptionKeyringDir != "" {
+		return encryption.NewKeyringKeyProvider(*encryptionKeyringDir, *encryptionKeyID)
+	}
+	if *encryptionKeyID != "" {
+		return nil, fmt.Errorf("-encryptionKeyID requires -encryptionKeyringDir")
+	}
+	return nil, nil
+}
+
 func runParallel(concurrency int, parts []common.Part, f func(p common.Part) error, progress func(elapsed time.Duration)) error {
 	var err error
 	runWithProgress(progress, func() {
@@ -188,7 +228,7 @@ func NewRemoteFS(path string) (common.RemoteFS, error) {
 	}
 	n := strings.Index(path, "://")
 	if n < 0 {
-		return nil, fmt.Errorf("Missing scheme in path %q. Supported schemes: `gs://`, `s3://`, `azblob://`, `fs://`", path)
+		return nil, fmt.Errorf("Missing scheme in path %q. Supported schemes: `gs://`, `s3://`, `azblob://`, `fs://`, `sftp://`, `webdav://`, `webdavs://`", path)
 	}
 	scheme := path[:n]
 	dir := path[n+len("://"):]
@@ -253,6 +293,63 @@ func NewRemoteFS(path string) (common.RemoteFS, error) {
 			return nil, fmt.Errorf("cannot initialize connection to s3: %w", err)
 		}
 		return fs, nil
+	case "sftp":
+		u, err := url.Parse(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse sftp url %q: %w", path, err)
+		}
+		if u.Path == "" {
+			return nil, fmt.Errorf("missing directory on the SFTP server %q", u.Host)
+		}
+		addr := u.Host
+		if u.Port() == "" {
+			addr = net.JoinHostPort(u.Hostname(), "22")
+		}
+		knownHostsFile := *sftpKnownHostsFile
+		if knownHostsFile == "" && !*sftpInsecureIgnoreHostKey {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("cannot determine home directory for locating known_hosts file; pass -sftp.knownHostsFile command-line flag: %w", err)
+			}
+			knownHostsFile = filepath.Join(homeDir, ".ssh", "known_hosts")
+		}
+		fs := &sftpremote.FS{
+			Addr:                  addr,
+			User:                  u.User.Username(),
+			Dir:                   u.Path,
+			PasswordFile:          *sftpPasswordFile,
+			PrivateKeyFile:        *sftpPrivateKeyFile,
+			KnownHostsFile:        knownHostsFile,
+			InsecureIgnoreHostKey: *sftpInsecureIgnoreHostKey,
+		}
+		if err := fs.Init(); err != nil {
+			return nil, fmt.Errorf("cannot initialize connection to sftp: %w", err)
+		}
+		return fs, nil
+	case "webdav", "webdavs":
+		u, err := url.Parse(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse webdav url %q: %w", path, err)
+		}
+		if u.Path == "" {
+			return nil, fmt.Errorf("missing directory on the WebDAV server %q", u.Host)
+		}
+		serverURL := "http://" + u.Host
+		if scheme == "webdavs" {
+			serverURL = "https://" + u.Host
+		}
+		fs := &webdavremote.FS{
+			URL:                   serverURL,
+			Dir:                   u.Path,
+			Username:              u.User.Username(),
+			PasswordFile:          *webdavPasswordFile,
+			TLSCAFile:             *webdavTLSCAFile,
+			TLSInsecureSkipVerify: *webdavTLSInsecureSkipVerify,
+		}
+		if err := fs.Init(); err != nil {
+			return nil, fmt.Errorf("cannot initialize connection to webdav: %w", err)
+		}
+		return fs, nil
 	default:
 		return nil, fmt.Errorf("unsupported scheme %q", scheme)
 	}
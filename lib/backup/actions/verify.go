@@ -0,0 +1,258 @@
+package actions
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/encryption"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/fscommon"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/mergeset"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/storage"
+)
+
+// Verify verifies the integrity of the backup according to the provided settings.
+type Verify struct {
+	// Concurrency is the number of concurrent workers during the verification.
+	// Concurrency=1 is used by default.
+	Concurrency int
+
+	// Src is the backup to verify.
+	Src common.RemoteFS
+
+	// KeyProvider is optional provider of keys for decrypting encrypted backups.
+	//
+	// It must be set if Src contains encrypted backup.
+	KeyProvider encryption.KeyProvider
+
+	// SkipChecksums may be set in order to skip downloading parts for checksums verification.
+	SkipChecksums bool
+
+	// VerifyPartHeaders may be set in order to download and verify headers for storage and indexdb parts in the backup.
+	VerifyPartHeaders bool
+}
+
+// verifyIssues collects issues found during the verification.
+type verifyIssues struct {
+	mu     sync.Mutex
+	issues []string
+}
+
+func (vi *verifyIssues) Addf(format string, args ...interface{}) {
+	issue := fmt.Sprintf(format, args...)
+	logger.Errorf("%s", issue)
+	vi.mu.Lock()
+	vi.issues = append(vi.issues, issue)
+	vi.mu.Unlock()
+}
+
+func (vi *verifyIssues) Len() int {
+	vi.mu.Lock()
+	n := len(vi.issues)
+	vi.mu.Unlock()
+	return n
+}
+
+// Run runs v with the provided settings.
+//
+// An error is returned if the backup contains missing or corrupted parts.
+func (v *Verify) Run() error {
+	startTime := time.Now()
+	concurrency := v.Concurrency
+	src := v.Src
+	var vi verifyIssues
+
+	ok, err := src.HasFile(fscommon.BackupCompleteFilename)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		vi.Addf("cannot find %s file in %s; this means either incomplete backup or old backup", fscommon.BackupCompleteFilename, src)
+	}
+	checksums, err := readChecksums(src)
+	if err != nil {
+		return err
+	}
+	src, err = newDecryptFS(src, v.KeyProvider)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("starting verification of the backup at %s", src)
+
+	parts, err := src.ListParts()
+	if err != nil {
+		return fmt.Errorf("cannot list parts at %s: %w", src, err)
+	}
+	logger.Infof("obtained %d parts from %s", len(parts), src)
+	backupSize := getPartsSize(parts)
+
+	verifyPartsLayout(&vi, parts)
+
+	partsByKey := make(map[string]bool, len(parts))
+	var checksumParts []common.Part
+	for _, p := range parts {
+		partsByKey[checksumKey(p)] = true
+		if p.ActualSize != p.Size {
+			vi.Addf("invalid size for %s; got %d; want %d", &p, p.ActualSize, p.Size)
+			continue
+		}
+		if _, ok := checksums.Get(p); ok {
+			checksumParts = append(checksumParts, p)
+		}
+	}
+	for k := range checksums.m {
+		if !partsByKey[k] {
+			vi.Addf("missing part %q at %s", k, src)
+		}
+	}
+	if n := len(parts) - len(checksumParts); n > 0 && checksums.Len() == 0 {
+		logger.Infof("the backup at %s has no checksums, since it was created by older vmbackup version; skipping checksums verification", src)
+	} else if n > 0 {
+		logger.Infof("%d out of %d parts at %s have no checksums; skipping checksums verification for them", n, len(parts), src)
+	}
+
+	verifiedSize := uint64(0)
+	if !v.SkipChecksums && len(checksumParts) > 0 {
+		logger.Infof("verifying checksums for %d parts at %s", len(checksumParts), src)
+		checksumsSize := getPartsSize(checksumParts)
+		bytesVerified := uint64(0)
+		err = runParallel(concurrency, checksumParts, func(p common.Part) error {
+			h := xxhash.New()
+			sw := &statWriter{
+				w:            h,
+				bytesWritten: &bytesVerified,
+			}
+			if err := src.DownloadPart(p, sw); err != nil {
+				vi.Addf("cannot download %s from %s: %s", &p, src, err)
+				return nil
+			}
+			checksumExpected, _ := checksums.Get(p)
+			if checksum := h.Sum64(); checksum != checksumExpected {
+				vi.Addf("checksum mismatch for %s at %s; got %016x; want %016x", &p, src, checksum, checksumExpected)
+			}
+			return nil
+		}, func(elapsed time.Duration) {
+			n := atomic.LoadUint64(&bytesVerified)
+			logger.Infof("verified checksums for %d out of %d bytes at %s in %s", n, checksumsSize, src, elapsed)
+		})
+		if err != nil {
+			return err
+		}
+		verifiedSize = checksumsSize
+	}
+
+	if v.VerifyPartHeaders {
+		if err := verifyPartHeaders(&vi, src, parts, concurrency); err != nil {
+			return err
+		}
+	}
+
+	if n := vi.Len(); n > 0 {
+		return fmt.Errorf("found %d issues in the backup at %s; see the log above for details", n, src)
+	}
+	logger.Infof("successfully verified the backup at %s in %.3f seconds; backup size: %d bytes; verified checksums for %d bytes",
+		src, time.Since(startTime).Seconds(), backupSize, verifiedSize)
+	return nil
+}
+
+// verifyPartsLayout verifies that parts cover the whole files without gaps and overlaps.
+func verifyPartsLayout(vi *verifyIssues, parts []common.Part) {
+	common.SortParts(parts)
+	for len(parts) > 0 {
+		n := 1
+		for n < len(parts) && parts[n].Path == parts[0].Path {
+			n++
+		}
+		fileParts := parts[:n]
+		parts = parts[n:]
+
+		offset := uint64(0)
+		fileSize := fileParts[0].FileSize
+		for i := range fileParts {
+			p := &fileParts[i]
+			if p.FileSize != fileSize {
+				vi.Addf("inconsistent file size for %s; want %d", p, fileSize)
+			}
+			if p.Offset < offset {
+				vi.Addf("there is an overlap in %d bytes at %s", offset-p.Offset, p)
+			}
+			if p.Offset > offset {
+				vi.Addf("there is a gap in %d bytes before %s", p.Offset-offset, p)
+			}
+			offset = p.Offset + p.Size
+		}
+		if offset != fileSize {
+			vi.Addf("invalid size for %q; got %d; want %d", fileParts[0].Path, offset, fileSize)
+		}
+	}
+}
+
+// verifyPartHeaders downloads and verifies headers for storage and indexdb parts in the backup.
+func verifyPartHeaders(vi *verifyIssues, src common.RemoteFS, parts []common.Part, concurrency int) error {
+	filesPerDir := make(map[string]map[string][]common.Part)
+	for _, p := range parts {
+		dir, name := path.Split(p.Path)
+		files := filesPerDir[dir]
+		if files == nil {
+			files = make(map[string][]common.Part)
+			filesPerDir[dir] = files
+		}
+		files[name] = append(files[name], p)
+	}
+
+	perPath := make(map[string][]common.Part)
+	for dir, files := range filesPerDir {
+		// Part directories contain metaindex.bin file.
+		metaindexParts, ok := files["metaindex.bin"]
+		if !ok {
+			continue
+		}
+		if !strings.HasPrefix(dir, "data/") && !strings.HasPrefix(dir, "indexdb/") {
+			continue
+		}
+		perPath[dir] = metaindexParts
+	}
+	logger.Infof("verifying headers for %d storage parts at %s", len(perPath), src)
+
+	verifiedParts := uint64(0)
+	return runParallelPerPath(concurrency, perPath, func(metaindexParts []common.Part) error {
+		dir := path.Dir(metaindexParts[0].Path) + "/"
+		files := filesPerDir[dir]
+		fileSizes := make(map[string]uint64, len(files))
+		for name, fileParts := range files {
+			fileSizes[name] = fileParts[0].FileSize
+		}
+		readFile := func(name string) ([]byte, error) {
+			var bb bytes.Buffer
+			for _, p := range files[name] {
+				if err := src.DownloadPart(p, &bb); err != nil {
+					return nil, fmt.Errorf("cannot download %s: %w", &p, err)
+				}
+			}
+			return bb.Bytes(), nil
+		}
+		var err error
+		if strings.HasPrefix(dir, "indexdb/") {
+			err = mergeset.VerifyPartHeaders(fileSizes, readFile)
+		} else {
+			err = storage.VerifyPartHeaders(fileSizes, readFile)
+		}
+		if err != nil {
+			vi.Addf("invalid part %q at %s: %s", dir, src, err)
+		}
+		atomic.AddUint64(&verifiedParts, 1)
+		return nil
+	}, func(elapsed time.Duration) {
+		n := atomic.LoadUint64(&verifiedParts)
+		logger.Infof("verified headers for %d out of %d storage parts at %s in %s", n, len(perPath), src, elapsed)
+	})
+}
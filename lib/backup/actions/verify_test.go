@@ -0,0 +1,57 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/common"
+)
+
+func TestVerifyPartsLayout(t *testing.T) {
+	f := func(parts []common.Part, issuesExpected int) {
+		t.Helper()
+		var vi verifyIssues
+		verifyPartsLayout(&vi, parts)
+		if n := vi.Len(); n != issuesExpected {
+			t.Fatalf("unexpected number of issues; got %d; want %d; issues: %q", n, issuesExpected, vi.issues)
+		}
+	}
+	newPart := func(path string, fileSize, offset, size uint64) common.Part {
+		return common.Part{
+			Path:       path,
+			FileSize:   fileSize,
+			Offset:     offset,
+			Size:       size,
+			ActualSize: size,
+		}
+	}
+
+	f(nil, 0)
+	f([]common.Part{newPart("foo", 10, 0, 10)}, 0)
+	f([]common.Part{newPart("foo", 0, 0, 0)}, 0)
+	f([]common.Part{
+		newPart("foo", 10, 5, 5),
+		newPart("bar", 3, 0, 3),
+		newPart("foo", 10, 0, 5),
+	}, 0)
+
+	// missing tail
+	f([]common.Part{newPart("foo", 10, 0, 5)}, 1)
+
+	// gap
+	f([]common.Part{
+		newPart("foo", 10, 0, 3),
+		newPart("foo", 10, 5, 5),
+	}, 1)
+
+	// overlap
+	f([]common.Part{
+		newPart("foo", 10, 0, 6),
+		newPart("foo", 10, 5, 5),
+	}, 1)
+
+	// inconsistent file size
+	f([]common.Part{
+		newPart("foo", 10, 0, 5),
+		newPart("foo", 11, 5, 5),
+	}, 1)
+}
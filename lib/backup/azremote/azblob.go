@@ -234,8 +234,8 @@ func (fs *FS) DownloadPart(p common.Part, w io.Writer) error {
 	if err != nil {
 		return fmt.Errorf("cannot download %q from at %s (remote path %q): %w", p.Path, fs, bc.URL(), err)
 	}
-	if uint64(n) != p.Size {
-		return fmt.Errorf("wrong data size downloaded from %q at %s; got %d bytes; want %d bytes", p.Path, fs, n, p.Size)
+	if uint64(n) != p.RemoteSize() {
+		return fmt.Errorf("wrong data size downloaded from %q at %s; got %d bytes; want %d bytes", p.Path, fs, n, p.RemoteSize())
 	}
 	return nil
 }
@@ -328,3 +328,62 @@ func (fs *FS) HasFile(filePath string) (bool, error) {
 
 	return true, nil
 }
+
+// ListFiles returns all the files at fs with paths starting with prefix.
+func (fs *FS) ListFiles(prefix string) ([]common.FileInfo, error) {
+	dir := fs.Dir
+	ctx := context.Background()
+
+	fullPrefix := dir + prefix
+	opts := &azblob.ListBlobsFlatOptions{
+		Prefix: &fullPrefix,
+	}
+
+	pager := fs.client.NewListBlobsFlatPager(opts)
+	var fis []common.FileInfo
+	for pager.More() {
+		resp, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list blobs at %s (remote path %q): %w", fs, fs.Container, err)
+		}
+		for _, v := range resp.Segment.BlobItems {
+			file := *v.Name
+			if !strings.HasPrefix(file, dir) {
+				return nil, fmt.Errorf("unexpected prefix for AZBlob key %q; want %q", file, dir)
+			}
+			fis = append(fis, common.FileInfo{
+				Path: file[len(dir):],
+				Size: uint64(*v.Properties.ContentLength),
+			})
+		}
+	}
+	return fis, nil
+}
+
+// ReadFileRange reads size bytes from filePath at fs starting from the given offset.
+func (fs *FS) ReadFileRange(filePath string, offset, size uint64) ([]byte, error) {
+	path := fs.Dir + filePath
+	bc := fs.clientForPath(path)
+
+	ctx := context.Background()
+	r, err := bc.DownloadStream(ctx, &blob.DownloadStreamOptions{
+		Range: blob.HTTPRange{
+			Offset: int64(offset),
+			Count:  int64(size),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot open reader for %q at %s (remote path %q): %w", filePath, fs, bc.URL(), err)
+	}
+
+	body := r.NewRetryReader(ctx, &azblob.RetryReaderOptions{})
+	data := make([]byte, size)
+	_, err = io.ReadFull(body, data)
+	if err1 := body.Close(); err1 != nil && err == nil {
+		err = err1
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %d bytes at offset %d from %q at %s (remote path %q): %w", size, offset, filePath, fs, bc.URL(), err)
+	}
+	return data, nil
+}
@@ -6,4 +6,15 @@ const (
 	// This file is created at the beginning of the restore process and is deleted at the end of the restore process.
 	// If this file exists, then it is unsafe to read the storage data, since it can be incomplete.
 	RestoreInProgressFilename = "restore-in-progress"
+
+	// RestoreMarkFilename is the filename for "restore mark" file
+	//
+	// This file contains the backup to restore on the next vmrestore run with -useRestoreMark command-line flag.
+	RestoreMarkFilename = "restore-mark.json"
+
+	// RestoreExportDataFilename is the filename for "export data" file
+	//
+	// This file is created by vmrestore at the directory it creates for restoring the backup before exporting series from it.
+	// vmrestore refuses exporting series via non-empty directory without this file, since its contents is replaced with the backup.
+	RestoreExportDataFilename = "restore-export-data"
 )
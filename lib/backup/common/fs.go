@@ -53,4 +53,21 @@ type RemoteFS interface {
 
 	// HasFile returns true if filePath exists at RemoteFS.
 	HasFile(filePath string) (bool, error)
+
+	// ListFiles returns all the files at RemoteFS with paths starting with prefix.
+	//
+	// Returned paths are relative to RemoteFS root.
+	ListFiles(prefix string) ([]FileInfo, error)
+
+	// ReadFileRange reads size bytes from filePath at RemoteFS starting from the given offset.
+	ReadFileRange(filePath string, offset, size uint64) ([]byte, error)
+}
+
+// FileInfo describes a file stored at RemoteFS.
+type FileInfo struct {
+	// Path is the file path relative to RemoteFS root.
+	Path string
+
+	// Size is the file size in bytes.
+	Size uint64
 }
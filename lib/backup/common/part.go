@@ -31,6 +31,19 @@ type Part struct {
 	// The part is considered broken if it isn't equal to Size.
 	// Such a part must be removed from remote storage.
 	ActualSize uint64
+
+	// EncryptedSize is the size of the encrypted part at remote storage.
+	//
+	// It is zero for unencrypted parts.
+	EncryptedSize uint64
+}
+
+// RemoteSize returns the size of the part p at remote storage.
+func (p *Part) RemoteSize() uint64 {
+	if p.EncryptedSize > 0 {
+		return p.EncryptedSize
+	}
+	return p.Size
 }
 
 func (p *Part) key() string {
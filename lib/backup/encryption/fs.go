@@ -0,0 +1,94 @@
+package encryption
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/common"
+)
+
+// FS encrypts parts uploaded to the underlying RemoteFS and decrypts parts downloaded from it.
+//
+// Parts returned by FS contain plaintext sizes, so encrypted backups are compared
+// with local files in the same way as unencrypted backups. This preserves incremental backups
+// and server-side copying of parts between FS instances with the same data key.
+//
+// Files created via CreateFile are stored unencrypted.
+type FS struct {
+	common.RemoteFS
+
+	dataKey   []byte
+	frameSize int
+}
+
+// NewFS returns FS, which encrypts parts stored at fs with dataKey.
+func NewFS(fs common.RemoteFS, dataKey []byte, frameSize int) *FS {
+	return &FS{
+		RemoteFS:  fs,
+		dataKey:   dataKey,
+		frameSize: frameSize,
+	}
+}
+
+func (fs *FS) encryptedPart(p common.Part) common.Part {
+	p.EncryptedSize = EncryptedSize(p.Size, fs.frameSize)
+	return p
+}
+
+// ListParts returns all the parts for fs.
+//
+// ActualSize for correctly encrypted parts is set to the plaintext size.
+// ActualSize for other parts, such as unencrypted parts left by the previous backups,
+// is set to the value, which never matches the plaintext size, so these parts are uploaded again.
+func (fs *FS) ListParts() ([]common.Part, error) {
+	parts, err := fs.RemoteFS.ListParts()
+	if err != nil {
+		return nil, err
+	}
+	for i := range parts {
+		p := fs.encryptedPart(parts[i])
+		if p.ActualSize == p.EncryptedSize {
+			p.ActualSize = p.Size
+		} else {
+			p.ActualSize = p.Size + 1
+		}
+		parts[i] = p
+	}
+	return parts, nil
+}
+
+// DeletePart deletes part p from fs.
+func (fs *FS) DeletePart(p common.Part) error {
+	return fs.RemoteFS.DeletePart(fs.encryptedPart(p))
+}
+
+// CopyPart copies p from srcFS to fs.
+//
+// srcFS must be encrypted with the same data key as fs.
+func (fs *FS) CopyPart(srcFS common.OriginFS, p common.Part) error {
+	if src, ok := srcFS.(*FS); ok {
+		srcFS = src.RemoteFS
+	}
+	return fs.RemoteFS.CopyPart(srcFS, fs.encryptedPart(p))
+}
+
+// DownloadPart downloads and decrypts part p from fs to w.
+func (fs *FS) DownloadPart(p common.Part, w io.Writer) error {
+	dw := NewDecryptWriter(w, p, fs.dataKey, fs.frameSize)
+	if err := fs.RemoteFS.DownloadPart(fs.encryptedPart(p), dw); err != nil {
+		return err
+	}
+	if err := dw.Finish(); err != nil {
+		return fmt.Errorf("cannot decrypt %s at %s: %w", &p, fs, err)
+	}
+	return nil
+}
+
+// UploadPart encrypts and uploads part p from r to fs.
+func (fs *FS) UploadPart(p common.Part, r io.Reader) error {
+	er, err := NewEncryptReader(r, p, fs.dataKey, fs.frameSize)
+	if err != nil {
+		return err
+	}
+	return fs.RemoteFS.UploadPart(fs.encryptedPart(p), er)
+}
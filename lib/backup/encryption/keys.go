@@ -0,0 +1,235 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// KeySize is the size of keys used for encryption.
+const KeySize = 32
+
+// KeyProvider wraps and unwraps data keys used for encrypting backup parts.
+//
+// Data keys are stored in backup metadata only in wrapped form.
+type KeyProvider interface {
+	// KeyID returns the id of the key, which is used for wrapping new data keys.
+	KeyID() string
+
+	// WrapKey encrypts dataKey with the key returned by KeyID.
+	WrapKey(dataKey []byte) ([]byte, error)
+
+	// UnwrapKey decrypts wrappedKey with the key identified by keyID.
+	UnwrapKey(keyID string, wrappedKey []byte) ([]byte, error)
+}
+
+// localKeyProvider is KeyProvider with keys stored in local files.
+type localKeyProvider struct {
+	keys  map[string][]byte
+	keyID string
+}
+
+// NewFileKeyProvider returns KeyProvider with a single key read from the file at path.
+//
+// The key id is derived from the key contents, so backups made with another key are detected.
+func NewFileKeyProvider(path string) (KeyProvider, error) {
+	key, err := readKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(key)
+	keyID := "sha256:" + hex.EncodeToString(h[:8])
+	return &localKeyProvider{
+		keys:  map[string][]byte{keyID: key},
+		keyID: keyID,
+	}, nil
+}
+
+// NewKeyringKeyProvider returns KeyProvider with keys read from files in dir.
+//
+// File names are used as key ids. The key with keyID is used for wrapping new data keys,
+// while all the keys in dir may be used for unwrapping data keys of existing backups.
+// This allows rotating keys without re-uploading backups.
+//
+// keyID may be empty if the KeyProvider is used only for restoring backups.
+func NewKeyringKeyProvider(dir, keyID string) (KeyProvider, error) {
+	des, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read keyring directory: %w", err)
+	}
+	keys := make(map[string][]byte)
+	for _, de := range des {
+		if !de.Type().IsRegular() || strings.HasPrefix(de.Name(), ".") {
+			continue
+		}
+		key, err := readKeyFile(filepath.Join(dir, de.Name()))
+		if err != nil {
+			return nil, err
+		}
+		keys[de.Name()] = key
+	}
+	if keyID != "" {
+		if _, ok := keys[keyID]; !ok {
+			return nil, fmt.Errorf("cannot find key %q in keyring directory %q", keyID, dir)
+		}
+	}
+	return &localKeyProvider{
+		keys:  keys,
+		keyID: keyID,
+	}, nil
+}
+
+// readKeyFile reads the key from the file at path.
+//
+// The file may contain raw 32-byte key, or the key in hex or base64 encoding.
+func readKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read key file: %w", err)
+	}
+	if len(data) == KeySize {
+		return data, nil
+	}
+	s := string(bytes.TrimSpace(data))
+	if key, err := hex.DecodeString(s); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(s); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("key file %q must contain %d-byte key in raw, hex or base64 form", path, KeySize)
+}
+
+// KeyID implements KeyProvider interface.
+func (kp *localKeyProvider) KeyID() string {
+	return kp.keyID
+}
+
+// WrapKey implements KeyProvider interface.
+func (kp *localKeyProvider) WrapKey(dataKey []byte) ([]byte, error) {
+	if kp.keyID == "" {
+		return nil, fmt.Errorf("missing key id for wrapping data key")
+	}
+	aead, err := newAEAD(kp.keys[kp.keyID])
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("cannot generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, dataKey, wrapKeyAAD), nil
+}
+
+// UnwrapKey implements KeyProvider interface.
+func (kp *localKeyProvider) UnwrapKey(keyID string, wrappedKey []byte) ([]byte, error) {
+	key, ok := kp.keys[keyID]
+	if !ok {
+		ids := make([]string, 0, len(kp.keys))
+		for id := range kp.keys {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		return nil, fmt.Errorf("missing key %q; available keys: %q", keyID, ids)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrappedKey) < aead.NonceSize() {
+		return nil, fmt.Errorf("too short wrapped key; got %d bytes", len(wrappedKey))
+	}
+	nonce := wrappedKey[:aead.NonceSize()]
+	dataKey, err := aead.Open(nil, nonce, wrappedKey[len(nonce):], wrapKeyAAD)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unwrap data key with key %q: %w", keyID, err)
+	}
+	return dataKey, nil
+}
+
+var wrapKeyAAD = []byte("vmbackup data key")
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create AES cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create AES-GCM cipher: %w", err)
+	}
+	return aead, nil
+}
+
+// Metadata contains encryption params for the backup.
+//
+// It is stored in backup metadata.
+type Metadata struct {
+	// Algorithm is the encryption algorithm for backup parts.
+	Algorithm string `json:"algorithm"`
+
+	// KeyID is the id of the key used for wrapping the data key.
+	KeyID string `json:"key_id"`
+
+	// WrappedKey is the data key wrapped with the key identified by KeyID.
+	WrappedKey []byte `json:"wrapped_key"`
+
+	// FrameSize is the size of plaintext frames encrypted independently in every part.
+	FrameSize int `json:"frame_size"`
+}
+
+// Algorithm is the only supported encryption algorithm for backup parts.
+const Algorithm = "AES-256-GCM"
+
+// DefaultFrameSize is the default size of plaintext frames in encrypted parts.
+const DefaultFrameSize = 64 * 1024
+
+// NewDataKey returns new random data key.
+func NewDataKey() ([]byte, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("cannot generate data key: %w", err)
+	}
+	return dataKey, nil
+}
+
+// NewMetadata returns Metadata for dataKey wrapped with the current key from kp.
+func NewMetadata(kp KeyProvider, dataKey []byte, frameSize int) (*Metadata, error) {
+	wrappedKey, err := kp.WrapKey(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Metadata{
+		Algorithm:  Algorithm,
+		KeyID:      kp.KeyID(),
+		WrappedKey: wrappedKey,
+		FrameSize:  frameSize,
+	}, nil
+}
+
+// DataKey returns unwrapped data key from m.
+func (m *Metadata) DataKey(kp KeyProvider) ([]byte, error) {
+	if m.Algorithm != Algorithm {
+		return nil, fmt.Errorf("unsupported encryption algorithm %q; supported algorithm: %q", m.Algorithm, Algorithm)
+	}
+	if m.FrameSize <= 0 {
+		return nil, fmt.Errorf("invalid frame size: %d", m.FrameSize)
+	}
+	dataKey, err := kp.UnwrapKey(m.KeyID, m.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(dataKey) != KeySize {
+		return nil, fmt.Errorf("unexpected data key size; got %d bytes; want %d bytes", len(dataKey), KeySize)
+	}
+	return dataKey, nil
+}
@@ -0,0 +1,221 @@
+package encryption
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/common"
+)
+
+// Encrypted part layout:
+//
+//	magic (4 bytes) | version (1 byte) | salt (32 bytes) | frame_0 | ... | frame_N
+//
+// Every frame contains up to frameSize bytes of plaintext sealed with AES-256-GCM.
+// The key for the part is derived from the data key and the random salt,
+// so frames are sealed with counter-based nonces without the risk of nonce reuse.
+// The part name and the frame number are authenticated with every frame,
+// so frames cannot be reordered or moved between parts.
+const (
+	partMagic      = "VMBE"
+	partVersion    = 1
+	saltSize       = 32
+	partHeaderSize = len(partMagic) + 1 + saltSize
+	tagSize        = 16
+)
+
+// EncryptedSize returns the size of the encrypted part with the given plaintext size.
+func EncryptedSize(size uint64, frameSize int) uint64 {
+	frames := (size + uint64(frameSize) - 1) / uint64(frameSize)
+	return uint64(partHeaderSize) + size + frames*tagSize
+}
+
+func newPartAEAD(dataKey, salt []byte) (cipher.AEAD, error) {
+	h := hmac.New(sha256.New, dataKey)
+	_, _ = h.Write(salt)
+	return newAEAD(h.Sum(nil))
+}
+
+func partAAD(p common.Part) []byte {
+	return []byte(p.RemotePath(""))
+}
+
+func frameNonce(dst []byte, frame uint64) []byte {
+	for i := range dst {
+		dst[i] = 0
+	}
+	binary.BigEndian.PutUint64(dst[len(dst)-8:], frame)
+	return dst
+}
+
+func frameAAD(dst, partAAD []byte, frame uint64) []byte {
+	dst = append(dst[:0], partAAD...)
+	return binary.BigEndian.AppendUint64(dst, frame)
+}
+
+// encryptReader encrypts p.Size bytes read from r.
+type encryptReader struct {
+	r         io.Reader
+	aead      cipher.AEAD
+	partAAD   []byte
+	frameSize int
+	remaining uint64
+
+	frame uint64
+	nonce []byte
+	aad   []byte
+	plain []byte
+
+	// buf contains encrypted data, which wasn't read yet.
+	buf []byte
+}
+
+// NewEncryptReader returns reader, which returns encrypted contents of the part p read from r.
+func NewEncryptReader(r io.Reader, p common.Part, dataKey []byte, frameSize int) (io.Reader, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("cannot generate salt: %w", err)
+	}
+	aead, err := newPartAEAD(dataKey, salt)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 0, partHeaderSize)
+	header = append(header, partMagic...)
+	header = append(header, partVersion)
+	header = append(header, salt...)
+	return &encryptReader{
+		r:         r,
+		aead:      aead,
+		partAAD:   partAAD(p),
+		frameSize: frameSize,
+		remaining: p.Size,
+		nonce:     make([]byte, aead.NonceSize()),
+		plain:     make([]byte, frameSize),
+		buf:       header,
+	}, nil
+}
+
+// Read implements io.Reader interface.
+func (er *encryptReader) Read(p []byte) (int, error) {
+	if len(er.buf) == 0 {
+		if er.remaining == 0 {
+			return 0, io.EOF
+		}
+		n := uint64(er.frameSize)
+		if n > er.remaining {
+			n = er.remaining
+		}
+		plain := er.plain[:n]
+		if _, err := io.ReadFull(er.r, plain); err != nil {
+			return 0, fmt.Errorf("cannot read frame #%d: %w", er.frame, err)
+		}
+		nonce := frameNonce(er.nonce, er.frame)
+		er.aad = frameAAD(er.aad, er.partAAD, er.frame)
+		er.buf = er.aead.Seal(er.buf[:0], nonce, plain, er.aad)
+		er.remaining -= n
+		er.frame++
+	}
+	n := copy(p, er.buf)
+	er.buf = er.buf[n:]
+	return n, nil
+}
+
+// DecryptWriter decrypts the part written to it.
+//
+// Finish must be called after the whole part is written.
+type DecryptWriter struct {
+	w         io.Writer
+	dataKey   []byte
+	partAAD   []byte
+	frameSize int
+	remaining uint64
+
+	aead  cipher.AEAD
+	frame uint64
+	nonce []byte
+	aad   []byte
+	plain []byte
+
+	// buf contains encrypted data, which wasn't decrypted yet.
+	buf []byte
+}
+
+// NewDecryptWriter returns writer, which writes decrypted contents of the part p to w.
+func NewDecryptWriter(w io.Writer, p common.Part, dataKey []byte, frameSize int) *DecryptWriter {
+	return &DecryptWriter{
+		w:         w,
+		dataKey:   dataKey,
+		partAAD:   partAAD(p),
+		frameSize: frameSize,
+		remaining: p.Size,
+	}
+}
+
+// Write implements io.Writer interface.
+func (dw *DecryptWriter) Write(p []byte) (int, error) {
+	dw.buf = append(dw.buf, p...)
+	if dw.aead == nil {
+		if len(dw.buf) < partHeaderSize {
+			return len(p), nil
+		}
+		if string(dw.buf[:len(partMagic)]) != partMagic {
+			return 0, fmt.Errorf("unexpected header for encrypted part; the part may be unencrypted")
+		}
+		if v := dw.buf[len(partMagic)]; v != partVersion {
+			return 0, fmt.Errorf("unsupported version of encrypted part: %d; want %d", v, partVersion)
+		}
+		aead, err := newPartAEAD(dw.dataKey, dw.buf[len(partMagic)+1:partHeaderSize])
+		if err != nil {
+			return 0, err
+		}
+		dw.aead = aead
+		dw.nonce = make([]byte, aead.NonceSize())
+		dw.buf = append(dw.buf[:0], dw.buf[partHeaderSize:]...)
+	}
+	offset := 0
+	for dw.remaining > 0 {
+		n := uint64(dw.frameSize)
+		if n > dw.remaining {
+			n = dw.remaining
+		}
+		frameLen := int(n) + tagSize
+		if len(dw.buf)-offset < frameLen {
+			break
+		}
+		nonce := frameNonce(dw.nonce, dw.frame)
+		dw.aad = frameAAD(dw.aad, dw.partAAD, dw.frame)
+		plain, err := dw.aead.Open(dw.plain[:0], nonce, dw.buf[offset:offset+frameLen], dw.aad)
+		if err != nil {
+			return 0, fmt.Errorf("cannot decrypt frame #%d: %w", dw.frame, err)
+		}
+		dw.plain = plain
+		if _, err := dw.w.Write(plain); err != nil {
+			return 0, err
+		}
+		offset += frameLen
+		dw.remaining -= n
+		dw.frame++
+	}
+	dw.buf = append(dw.buf[:0], dw.buf[offset:]...)
+	if dw.remaining == 0 && len(dw.buf) > 0 {
+		return 0, fmt.Errorf("unexpected %d bytes after the end of encrypted part", len(dw.buf))
+	}
+	return len(p), nil
+}
+
+// Finish verifies that the whole part has been decrypted.
+func (dw *DecryptWriter) Finish() error {
+	if dw.aead == nil {
+		return fmt.Errorf("missing header for encrypted part")
+	}
+	if dw.remaining > 0 {
+		return fmt.Errorf("encrypted part is truncated; %d bytes of plaintext are missing", dw.remaining)
+	}
+	return nil
+}
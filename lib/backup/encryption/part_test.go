@@ -0,0 +1,315 @@
+package encryption
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/fsremote"
+)
+
+func newTestDataKey(t *testing.T) []byte {
+	t.Helper()
+	dataKey, err := NewDataKey()
+	if err != nil {
+		t.Fatalf("cannot create data key: %s", err)
+	}
+	return dataKey
+}
+
+func encryptPart(t *testing.T, p common.Part, data, dataKey []byte, frameSize int) []byte {
+	t.Helper()
+	er, err := NewEncryptReader(bytes.NewReader(data), p, dataKey, frameSize)
+	if err != nil {
+		t.Fatalf("cannot create encrypt reader: %s", err)
+	}
+	encrypted, err := io.ReadAll(er)
+	if err != nil {
+		t.Fatalf("cannot encrypt part: %s", err)
+	}
+	if uint64(len(encrypted)) != EncryptedSize(p.Size, frameSize) {
+		t.Fatalf("unexpected encrypted size; got %d; want %d", len(encrypted), EncryptedSize(p.Size, frameSize))
+	}
+	return encrypted
+}
+
+func decryptPart(p common.Part, encrypted, dataKey []byte, frameSize int, chunkSize int) ([]byte, error) {
+	var bb bytes.Buffer
+	dw := NewDecryptWriter(&bb, p, dataKey, frameSize)
+	for len(encrypted) > 0 {
+		n := chunkSize
+		if n > len(encrypted) {
+			n = len(encrypted)
+		}
+		if _, err := dw.Write(encrypted[:n]); err != nil {
+			return nil, err
+		}
+		encrypted = encrypted[n:]
+	}
+	if err := dw.Finish(); err != nil {
+		return nil, err
+	}
+	return bb.Bytes(), nil
+}
+
+func TestEncryptDecryptPart(t *testing.T) {
+	dataKey := newTestDataKey(t)
+	f := func(size uint64, frameSize, chunkSize int) {
+		t.Helper()
+		data := make([]byte, size)
+		r := rand.New(rand.NewSource(int64(size)))
+		_, _ = r.Read(data)
+		p := common.Part{
+			Path:     "data/small/2023_01/part/values.bin",
+			FileSize: size,
+			Size:     size,
+		}
+		encrypted := encryptPart(t, p, data, dataKey, frameSize)
+		result, err := decryptPart(p, encrypted, dataKey, frameSize, chunkSize)
+		if err != nil {
+			t.Fatalf("cannot decrypt part with size=%d, frameSize=%d, chunkSize=%d: %s", size, frameSize, chunkSize, err)
+		}
+		if !bytes.Equal(result, data) {
+			t.Fatalf("unexpected decrypted data for size=%d, frameSize=%d, chunkSize=%d", size, frameSize, chunkSize)
+		}
+	}
+	f(0, 16, 1)
+	f(1, 16, 1)
+	f(16, 16, 3)
+	f(17, 16, 100)
+	f(1000, 16, 7)
+	f(1000, 64, 1000)
+	f(100000, DefaultFrameSize, 4096)
+}
+
+func TestDecryptPartFailure(t *testing.T) {
+	dataKey := newTestDataKey(t)
+	const frameSize = 16
+	data := []byte(strings.Repeat("foobar", 10))
+	p := common.Part{
+		Path:     "data/small/2023_01/part/values.bin",
+		FileSize: uint64(len(data)),
+		Size:     uint64(len(data)),
+	}
+	encrypted := encryptPart(t, p, data, dataKey, frameSize)
+	f := func(p common.Part, encrypted, dataKey []byte) {
+		t.Helper()
+		if _, err := decryptPart(p, encrypted, dataKey, frameSize, len(encrypted)+1); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+
+	// wrong key
+	f(p, encrypted, newTestDataKey(t))
+
+	// tampered data
+	tampered := append([]byte{}, encrypted...)
+	tampered[len(tampered)-20] ^= 1
+	f(p, tampered, dataKey)
+
+	// truncated data
+	f(p, encrypted[:len(encrypted)-1], dataKey)
+	f(p, encrypted[:partHeaderSize+frameSize+tagSize], dataKey)
+	f(p, encrypted[:partHeaderSize-1], dataKey)
+
+	// trailing data
+	f(p, append(append([]byte{}, encrypted...), 'x'), dataKey)
+
+	// part moved to another path
+	pMoved := p
+	pMoved.Path = "data/small/2023_01/part/timestamps.bin"
+	f(pMoved, encrypted, dataKey)
+
+	// unencrypted data
+	f(p, data, dataKey)
+}
+
+func TestKeyProviders(t *testing.T) {
+	dir := t.TempDir()
+	dataKey := newTestDataKey(t)
+	writeKey := func(name, contents string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatalf("cannot write key file: %s", err)
+		}
+		return path
+	}
+	keyPath := writeKey("key1", strings.Repeat("ab", KeySize)+"\n")
+	writeKey("key2", "cXdlcnR5dWlvcGFzZGZnaGprbHp4Y3Zibm0xMjM0NTY=")
+
+	kp, err := NewFileKeyProvider(keyPath)
+	if err != nil {
+		t.Fatalf("cannot create file key provider: %s", err)
+	}
+	m, err := NewMetadata(kp, dataKey, DefaultFrameSize)
+	if err != nil {
+		t.Fatalf("cannot create metadata: %s", err)
+	}
+	result, err := m.DataKey(kp)
+	if err != nil {
+		t.Fatalf("cannot obtain data key: %s", err)
+	}
+	if !bytes.Equal(result, dataKey) {
+		t.Fatalf("unexpected data key")
+	}
+
+	// Rotate the key via keyring: the data key wrapped with key1 is re-wrapped with key2.
+	kr, err := NewKeyringKeyProvider(dir, "key2")
+	if err != nil {
+		t.Fatalf("cannot create keyring key provider: %s", err)
+	}
+	m1 := &Metadata{
+		Algorithm:  m.Algorithm,
+		KeyID:      "key1",
+		WrappedKey: m.WrappedKey,
+		FrameSize:  m.FrameSize,
+	}
+	result, err = m1.DataKey(kr)
+	if err != nil {
+		t.Fatalf("cannot obtain data key from keyring: %s", err)
+	}
+	m2, err := NewMetadata(kr, result, m1.FrameSize)
+	if err != nil {
+		t.Fatalf("cannot re-wrap data key: %s", err)
+	}
+	if m2.KeyID != "key2" {
+		t.Fatalf("unexpected key id; got %q; want %q", m2.KeyID, "key2")
+	}
+	result, err = m2.DataKey(kr)
+	if err != nil {
+		t.Fatalf("cannot obtain re-wrapped data key: %s", err)
+	}
+	if !bytes.Equal(result, dataKey) {
+		t.Fatalf("unexpected re-wrapped data key")
+	}
+
+	// The original key cannot unwrap data key wrapped with another key.
+	if _, err := m2.DataKey(kp); err == nil {
+		t.Fatalf("expecting non-nil error when unwrapping data key with wrong key")
+	}
+
+	// Missing key id in keyring.
+	if _, err := NewKeyringKeyProvider(dir, "missing"); err == nil {
+		t.Fatalf("expecting non-nil error for missing key id")
+	}
+
+	// Invalid key file.
+	if _, err := NewFileKeyProvider(writeKey("invalid", "foobar")); err == nil {
+		t.Fatalf("expecting non-nil error for invalid key file")
+	}
+}
+
+func TestFS(t *testing.T) {
+	dataKey := newTestDataKey(t)
+	const frameSize = 100
+	data := []byte(strings.Repeat("foobarbaz", 100))
+	p := common.Part{
+		Path:     "data/small/2023_01/part/values.bin",
+		FileSize: uint64(len(data)),
+		Size:     uint64(len(data)),
+	}
+
+	remote := &fsremote.FS{Dir: t.TempDir()}
+	fs := NewFS(remote, dataKey, frameSize)
+	if err := fs.UploadPart(p, bytes.NewReader(data)); err != nil {
+		t.Fatalf("cannot upload part: %s", err)
+	}
+
+	// The part must be stored encrypted.
+	rawParts, err := remote.ListParts()
+	if err != nil {
+		t.Fatalf("cannot list raw parts: %s", err)
+	}
+	if len(rawParts) != 1 || rawParts[0].ActualSize != EncryptedSize(p.Size, frameSize) {
+		t.Fatalf("unexpected raw parts: %+v", rawParts)
+	}
+
+	// Encrypted parts must be listed with plaintext sizes.
+	parts, err := fs.ListParts()
+	if err != nil {
+		t.Fatalf("cannot list parts: %s", err)
+	}
+	if len(parts) != 1 || parts[0].ActualSize != p.Size || parts[0].Size != p.Size {
+		t.Fatalf("unexpected parts: %+v", parts)
+	}
+
+	// Server-side copy must preserve the encrypted part.
+	remoteCopy := &fsremote.FS{Dir: t.TempDir()}
+	fsCopy := NewFS(remoteCopy, dataKey, frameSize)
+	if err := fsCopy.CopyPart(fs, p); err != nil {
+		t.Fatalf("cannot copy part: %s", err)
+	}
+
+	var bb bytes.Buffer
+	if err := fsCopy.DownloadPart(p, &bb); err != nil {
+		t.Fatalf("cannot download part: %s", err)
+	}
+	if !bytes.Equal(bb.Bytes(), data) {
+		t.Fatalf("unexpected downloaded data")
+	}
+}
+
+func TestFSListPartsMixed(t *testing.T) {
+	dataKey := newTestDataKey(t)
+	const frameSize = 100
+	data := []byte(strings.Repeat("foobarbaz", 100))
+	pEncrypted := common.Part{
+		Path:     "data/small/2023_01/part/values.bin",
+		FileSize: uint64(len(data)),
+		Size:     uint64(len(data)),
+	}
+	pPlain := common.Part{
+		Path:     "data/small/2023_01/part/timestamps.bin",
+		FileSize: uint64(len(data)),
+		Size:     uint64(len(data)),
+	}
+
+	remote := &fsremote.FS{Dir: t.TempDir()}
+	fs := NewFS(remote, dataKey, frameSize)
+	if err := fs.UploadPart(pEncrypted, bytes.NewReader(data)); err != nil {
+		t.Fatalf("cannot upload encrypted part: %s", err)
+	}
+	// The unencrypted part left by the previous backup without encryption.
+	if err := remote.UploadPart(pPlain, bytes.NewReader(data)); err != nil {
+		t.Fatalf("cannot upload unencrypted part: %s", err)
+	}
+
+	parts, err := fs.ListParts()
+	if err != nil {
+		t.Fatalf("cannot list parts: %s", err)
+	}
+	if len(parts) != 2 {
+		t.Fatalf("unexpected number of parts; got %d; want 2", len(parts))
+	}
+	for _, p := range parts {
+		switch p.Path {
+		case pEncrypted.Path:
+			if p.ActualSize != p.Size {
+				t.Fatalf("unexpected ActualSize for encrypted part; got %d; want %d", p.ActualSize, p.Size)
+			}
+		case pPlain.Path:
+			if p.ActualSize == p.Size {
+				t.Fatalf("ActualSize for unencrypted part mustn't match its size %d", p.Size)
+			}
+		default:
+			t.Fatalf("unexpected part %+v", p)
+		}
+	}
+
+	// Only the unencrypted part must be uploaded again.
+	var srcParts []common.Part
+	for _, p := range []common.Part{pEncrypted, pPlain} {
+		p.ActualSize = p.Size
+		srcParts = append(srcParts, p)
+	}
+	partsToDelete := common.PartsDifference(parts, srcParts)
+	if len(partsToDelete) != 1 || partsToDelete[0].Path != pPlain.Path {
+		t.Fatalf("unexpected parts to delete: %+v", partsToDelete)
+	}
+}
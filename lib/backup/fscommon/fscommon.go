@@ -106,7 +106,8 @@ func appendFilesInternal(dst []string, d *os.File) ([]string, error) {
 }
 
 func isSpecialFile(name string) bool {
-	return name == "flock.lock" || name == backupnames.RestoreInProgressFilename
+	return name == "flock.lock" || name == backupnames.RestoreInProgressFilename || name == backupnames.RestoreMarkFilename ||
+		name == backupnames.RestoreExportDataFilename
 }
 
 // RemoveEmptyDirs recursively removes empty directories under the given dir.
@@ -238,3 +239,6 @@ const BackupCompleteFilename = "backup_complete.ignore"
 
 // BackupMetadataFilename is a filename, which contains metadata for the backup.
 const BackupMetadataFilename = "backup_metadata.ignore"
+
+// BackupChecksumsFilename is a filename, which contains checksums for the backed up parts.
+const BackupChecksumsFilename = "backup_checksums.ignore"
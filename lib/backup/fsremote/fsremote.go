@@ -130,9 +130,9 @@ func (fs *FS) CopyPart(srcFS common.OriginFS, p common.Part) error {
 		_ = os.RemoveAll(dstPath)
 		return err
 	}
-	if uint64(n) != p.Size {
+	if uint64(n) != p.RemoteSize() {
 		_ = os.RemoveAll(dstPath)
-		return fmt.Errorf("unexpected number of bytes copied from %q to %q; got %d bytes; want %d bytes", srcPath, dstPath, n, p.Size)
+		return fmt.Errorf("unexpected number of bytes copied from %q to %q; got %d bytes; want %d bytes", srcPath, dstPath, n, p.RemoteSize())
 	}
 	return nil
 }
@@ -151,8 +151,8 @@ func (fs *FS) DownloadPart(p common.Part, w io.Writer) error {
 	if err != nil {
 		return fmt.Errorf("cannot download data from %q: %w", path, err)
 	}
-	if uint64(n) != p.Size {
-		return fmt.Errorf("wrong data size downloaded from %q; got %d bytes; want %d bytes", path, n, p.Size)
+	if uint64(n) != p.RemoteSize() {
+		return fmt.Errorf("wrong data size downloaded from %q; got %d bytes; want %d bytes", path, n, p.RemoteSize())
 	}
 	return nil
 }
@@ -178,9 +178,9 @@ func (fs *FS) UploadPart(p common.Part, r io.Reader) error {
 		_ = os.RemoveAll(path)
 		return fmt.Errorf("cannot upload data to %q: %w", path, err)
 	}
-	if uint64(n) != p.Size {
+	if uint64(n) != p.RemoteSize() {
 		_ = os.RemoveAll(path)
-		return fmt.Errorf("wrong data size uploaded to %q; got %d bytes; want %d bytes", path, n, p.Size)
+		return fmt.Errorf("wrong data size uploaded to %q; got %d bytes; want %d bytes", path, n, p.RemoteSize())
 	}
 	return nil
 }
@@ -238,3 +238,53 @@ func (fs *FS) HasFile(filePath string) (bool, error) {
 	}
 	return true, nil
 }
+
+// ListFiles returns all the files at fs with paths starting with prefix.
+func (fs *FS) ListFiles(prefix string) ([]common.FileInfo, error) {
+	dir := fs.Dir
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	files, err := fscommon.AppendFiles(nil, dir)
+	if err != nil {
+		return nil, err
+	}
+	var fis []common.FileInfo
+	dir += string(filepath.Separator)
+	for _, file := range files {
+		if !strings.HasPrefix(file, dir) {
+			logger.Panicf("BUG: unexpected prefix for file %q; want %q", file, dir)
+		}
+		path := filepath.ToSlash(file[len(dir):])
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		fi, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot stat %q: %w", file, err)
+		}
+		fis = append(fis, common.FileInfo{
+			Path: path,
+			Size: uint64(fi.Size()),
+		})
+	}
+	return fis, nil
+}
+
+// ReadFileRange reads size bytes from filePath at fs starting from the given offset.
+func (fs *FS) ReadFileRange(filePath string, offset, size uint64) ([]byte, error) {
+	path := filepath.Join(fs.Dir, filePath)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %q: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+	data := make([]byte, size)
+	if _, err := f.ReadAt(data, int64(offset)); err != nil {
+		return nil, fmt.Errorf("cannot read %d bytes at offset %d from %q: %w", size, offset, path, err)
+	}
+	return data, nil
+}
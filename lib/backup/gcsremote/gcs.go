@@ -160,8 +160,8 @@ func (fs *FS) CopyPart(srcFS common.OriginFS, p common.Part) error {
 	if err != nil {
 		return fmt.Errorf("cannot copy %q from %s to %s: %w", p.Path, src, fs, err)
 	}
-	if uint64(attr.Size) != p.Size {
-		return fmt.Errorf("unexpected %q size after copying from %s to %s; got %d bytes; want %d bytes", p.Path, src, fs, attr.Size, p.Size)
+	if uint64(attr.Size) != p.RemoteSize() {
+		return fmt.Errorf("unexpected %q size after copying from %s to %s; got %d bytes; want %d bytes", p.Path, src, fs, attr.Size, p.RemoteSize())
 	}
 	return nil
 }
@@ -181,8 +181,8 @@ func (fs *FS) DownloadPart(p common.Part, w io.Writer) error {
 	if err != nil {
 		return fmt.Errorf("cannot download %q from at %s (remote path %q): %w", p.Path, fs, o.ObjectName(), err)
 	}
-	if uint64(n) != p.Size {
-		return fmt.Errorf("wrong data size downloaded from %q at %s; got %d bytes; want %d bytes", p.Path, fs, n, p.Size)
+	if uint64(n) != p.RemoteSize() {
+		return fmt.Errorf("wrong data size downloaded from %q at %s; got %d bytes; want %d bytes", p.Path, fs, n, p.RemoteSize())
 	}
 	return nil
 }
@@ -199,8 +199,8 @@ func (fs *FS) UploadPart(p common.Part, r io.Reader) error {
 	if err != nil {
 		return fmt.Errorf("cannot upload data to %q at %s (remote path %q): %w", p.Path, fs, o.ObjectName(), err)
 	}
-	if uint64(n) != p.Size {
-		return fmt.Errorf("wrong data size uploaded to %q at %s; got %d bytes; want %d bytes", p.Path, fs, n, p.Size)
+	if uint64(n) != p.RemoteSize() {
+		return fmt.Errorf("wrong data size uploaded to %q at %s; got %d bytes; want %d bytes", p.Path, fs, n, p.RemoteSize())
 	}
 	return nil
 }
@@ -262,3 +262,54 @@ func (fs *FS) HasFile(filePath string) (bool, error) {
 	}
 	return true, nil
 }
+
+// ListFiles returns all the files at fs with paths starting with prefix.
+func (fs *FS) ListFiles(prefix string) ([]common.FileInfo, error) {
+	dir := fs.Dir
+	ctx := context.Background()
+	q := &storage.Query{
+		Prefix: dir + prefix,
+	}
+	if err := q.SetAttrSelection(selectAttrs); err != nil {
+		return nil, fmt.Errorf("error in SetAttrSelection: %w", err)
+	}
+	it := fs.bkt.Objects(ctx, q)
+	var fis []common.FileInfo
+	for {
+		attr, err := it.Next()
+		if err == iterator.Done {
+			return fis, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error when iterating objects at %q: %w", dir, err)
+		}
+		file := attr.Name
+		if !strings.HasPrefix(file, dir) {
+			return nil, fmt.Errorf("unexpected prefix for gcs key %q; want %q", file, dir)
+		}
+		fis = append(fis, common.FileInfo{
+			Path: file[len(dir):],
+			Size: uint64(attr.Size),
+		})
+	}
+}
+
+// ReadFileRange reads size bytes from filePath at fs starting from the given offset.
+func (fs *FS) ReadFileRange(filePath string, offset, size uint64) ([]byte, error) {
+	path := fs.Dir + filePath
+	o := fs.bkt.Object(path)
+	ctx := context.Background()
+	r, err := o.NewRangeReader(ctx, int64(offset), int64(size))
+	if err != nil {
+		return nil, fmt.Errorf("cannot open reader for %q at %s (remote path %q): %w", filePath, fs, o.ObjectName(), err)
+	}
+	data := make([]byte, size)
+	_, err = io.ReadFull(r, data)
+	if err1 := r.Close(); err1 != nil && err == nil {
+		err = err1
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %d bytes at offset %d from %q at %s (remote path %q): %w", size, offset, filePath, fs, o.ObjectName(), err)
+	}
+	return data, nil
+}
@@ -254,8 +254,8 @@ func (fs *FS) DownloadPart(p common.Part, w io.Writer) error {
 	if err != nil {
 		return fmt.Errorf("cannot download %q from at %s (remote path %q): %w", p.Path, fs, path, err)
 	}
-	if uint64(n) != p.Size {
-		return fmt.Errorf("wrong data size downloaded from %q at %s; got %d bytes; want %d bytes", p.Path, fs, n, p.Size)
+	if uint64(n) != p.RemoteSize() {
+		return fmt.Errorf("wrong data size downloaded from %q at %s; got %d bytes; want %d bytes", p.Path, fs, n, p.RemoteSize())
 	}
 	return nil
 }
@@ -277,8 +277,8 @@ func (fs *FS) UploadPart(p common.Part, r io.Reader) error {
 	if err != nil {
 		return fmt.Errorf("cannot upoad data to %q at %s (remote path %q): %w", p.Path, fs, path, err)
 	}
-	if uint64(sr.size) != p.Size {
-		return fmt.Errorf("wrong data size uploaded to %q at %s; got %d bytes; want %d bytes", p.Path, fs, sr.size, p.Size)
+	if uint64(sr.size) != p.RemoteSize() {
+		return fmt.Errorf("wrong data size uploaded to %q at %s; got %d bytes; want %d bytes", p.Path, fs, sr.size, p.RemoteSize())
 	}
 	return nil
 }
@@ -356,6 +356,59 @@ func (fs *FS) HasFile(filePath string) (bool, error) {
 	return true, nil
 }
 
+// ListFiles returns all the files at fs with paths starting with prefix.
+func (fs *FS) ListFiles(prefix string) ([]common.FileInfo, error) {
+	dir := fs.Dir
+	paginator := s3.NewListObjectsV2Paginator(fs.s3, &s3.ListObjectsV2Input{
+		Bucket: aws.String(fs.Bucket),
+		Prefix: aws.String(dir + prefix),
+	})
+	var fis []common.FileInfo
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("unexpected pagination error: %w", err)
+		}
+		for _, o := range page.Contents {
+			file := *o.Key
+			if !strings.HasPrefix(file, dir) {
+				return nil, fmt.Errorf("unexpected prefix for s3 key %q; want %q", file, dir)
+			}
+			fis = append(fis, common.FileInfo{
+				Path: file[len(dir):],
+				Size: uint64(o.Size),
+			})
+		}
+	}
+	return fis, nil
+}
+
+// ReadFileRange reads size bytes from filePath at fs starting from the given offset.
+func (fs *FS) ReadFileRange(filePath string, offset, size uint64) ([]byte, error) {
+	if size == 0 {
+		return nil, nil
+	}
+	path := fs.Dir + filePath
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(fs.Bucket),
+		Key:    aws.String(path),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+size-1)),
+	}
+	o, err := fs.s3.GetObject(context.Background(), input)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %q at %s (remote path %q): %w", filePath, fs, path, err)
+	}
+	data := make([]byte, size)
+	_, err = io.ReadFull(o.Body, data)
+	if err1 := o.Body.Close(); err1 != nil && err == nil {
+		err = err1
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %d bytes at offset %d from %q at %s (remote path %q): %w", size, offset, filePath, fs, path, err)
+	}
+	return data, nil
+}
+
 func (fs *FS) path(p common.Part) string {
 	return p.RemotePath(fs.Dir)
 }
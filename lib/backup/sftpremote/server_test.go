@@ -0,0 +1,218 @@
+package sftpremote
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testServer is in-process SFTP server for tests.
+//
+// It serves local files. Tests must use paths under root directory.
+type testServer struct {
+	root     string
+	ln       net.Listener
+	password string
+	hostKey  ssh.Signer
+
+	// clientKeyFile is the path to the file with the private key, which is accepted by the server.
+	clientKeyFile string
+
+	// knownHostsFile is the path to known_hosts file with the server host key.
+	knownHostsFile string
+
+	// dropConnAfterBytes is the number of bytes received from the client after which the connection is dropped.
+	//
+	// The connection isn't dropped if it is zero.
+	dropConnAfterBytes int64
+
+	conns int64
+
+	wg sync.WaitGroup
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "root")
+	if err := os.MkdirAll(root, 0700); err != nil {
+		t.Fatalf("cannot create root dir: %s", err)
+	}
+
+	hostKey := newTestSigner(t)
+	clientKey, clientKeyPEM := newTestKey(t)
+	clientKeyFile := filepath.Join(tmpDir, "id_ecdsa")
+	if err := os.WriteFile(clientKeyFile, clientKeyPEM, 0600); err != nil {
+		t.Fatalf("cannot write client key: %s", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %s", err)
+	}
+	knownHostsFile := filepath.Join(tmpDir, "known_hosts")
+	line := knownhosts.Line([]string{ln.Addr().String()}, hostKey.PublicKey())
+	if err := os.WriteFile(knownHostsFile, []byte(line+"\n"), 0600); err != nil {
+		t.Fatalf("cannot write known hosts: %s", err)
+	}
+
+	s := &testServer{
+		root:           root,
+		ln:             ln,
+		password:       "secret",
+		hostKey:        hostKey,
+		clientKeyFile:  clientKeyFile,
+		knownHostsFile: knownHostsFile,
+	}
+	clientPublicKey := clientKey.PublicKey().Marshal()
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == "test" && string(password) == s.password {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("invalid password for %q", conn.User())
+		},
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "test" && string(key.Marshal()) == string(clientPublicKey) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("invalid public key for %q", conn.User())
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.serveConn(conn, config)
+			}()
+		}
+	}()
+	t.Cleanup(s.stop)
+	return s
+}
+
+func newTestKey(t *testing.T) (ssh.Signer, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate key: %s", err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("cannot marshal key: %s", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "EC PRIVATE KEY",
+		Bytes: der,
+	})
+	signer, err := ssh.ParsePrivateKey(keyPEM)
+	if err != nil {
+		t.Fatalf("cannot parse key: %s", err)
+	}
+	return signer, keyPEM
+}
+
+func newTestSigner(t *testing.T) ssh.Signer {
+	signer, _ := newTestKey(t)
+	return signer
+}
+
+func (s *testServer) addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *testServer) stop() {
+	_ = s.ln.Close()
+	s.wg.Wait()
+}
+
+func (s *testServer) serveConn(conn net.Conn, config *ssh.ServerConfig) {
+	sconn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	atomic.AddInt64(&s.conns, 1)
+	go ssh.DiscardRequests(reqs)
+	for newCh := range chans {
+		if newCh.ChannelType() != "session" {
+			_ = newCh.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		ch, chReqs, err := newCh.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range chReqs {
+				ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+				_ = req.Reply(ok, nil)
+				if ok {
+					go s.serveSFTP(ch, sconn)
+				}
+			}
+		}()
+	}
+}
+
+func (s *testServer) serveSFTP(ch ssh.Channel, conn ssh.Conn) {
+	rwc := &droppingChannel{
+		Channel: ch,
+		s:       s,
+		conn:    conn,
+	}
+	srv, err := sftp.NewServer(rwc)
+	if err != nil {
+		_ = ch.Close()
+		return
+	}
+	_ = srv.Serve()
+	_ = srv.Close()
+}
+
+// droppingChannel drops the connection after receiving testServer.dropConnAfterBytes bytes.
+type droppingChannel struct {
+	ssh.Channel
+	s    *testServer
+	conn ssh.Conn
+	n    int64
+}
+
+func (dc *droppingChannel) Read(p []byte) (int, error) {
+	n, err := dc.Channel.Read(p)
+	dc.n += int64(n)
+	if limit := atomic.LoadInt64(&dc.s.dropConnAfterBytes); limit > 0 && dc.n > limit {
+		atomic.StoreInt64(&dc.s.dropConnAfterBytes, 0)
+		_ = dc.conn.Close()
+		return 0, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// localPath returns local path for the given path relative to the server root.
+func (s *testServer) localPath(p string) string {
+	return filepath.Join(s.root, filepath.FromSlash(p))
+}
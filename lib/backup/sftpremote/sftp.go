@@ -0,0 +1,718 @@
+package sftpremote
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/fscommon"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+)
+
+// OpenSSH extensions. See https://github.com/openssh/openssh-portable/blob/master/PROTOCOL
+const (
+	extPosixRename = "posix-rename@openssh.com"
+	extHardlink    = "hardlink@openssh.com"
+)
+
+// uploadChunkSize is the size of data chunk, which is re-sent to the server after re-connect during the upload.
+const uploadChunkSize = 4 * 1024 * 1024
+
+// maxRetries is the maximum number of retries for the operation after connection errors.
+const maxRetries = 5
+
+// FS represents filesystem for backups at SFTP server.
+//
+// Init must be called before calling other FS methods.
+type FS struct {
+	// Addr is the address of SFTP server in the form host:port.
+	Addr string
+
+	// User is the user for authenticating at SFTP server.
+	User string
+
+	// Dir is the directory at SFTP server to write to.
+	Dir string
+
+	// PasswordFile is an optional path to file with the password for User.
+	PasswordFile string
+
+	// PrivateKeyFile is an optional path to file with the private key for User.
+	PrivateKeyFile string
+
+	// KnownHostsFile is the path to file with known host keys in OpenSSH format.
+	//
+	// It is used for verifying SFTP server host key.
+	KnownHostsFile string
+
+	// InsecureIgnoreHostKey disables verification of SFTP server host key.
+	InsecureIgnoreHostKey bool
+
+	config *ssh.ClientConfig
+
+	mu sync.Mutex
+	c  *client
+
+	// createdDirs contains directories, which are known to exist at SFTP server.
+	createdDirs sync.Map
+}
+
+// Init initializes fs.
+//
+// The returned fs must be stopped when no long needed with MustStop call.
+func (fs *FS) Init() error {
+	if fs.config != nil {
+		logger.Panicf("BUG: Init is already called")
+	}
+	if fs.User == "" {
+		return fmt.Errorf("missing user for SFTP server %q", fs.Addr)
+	}
+	fs.Dir = path.Clean("/" + fs.Dir)
+
+	var auth []ssh.AuthMethod
+	if fs.PrivateKeyFile != "" {
+		data, err := os.ReadFile(fs.PrivateKeyFile)
+		if err != nil {
+			return fmt.Errorf("cannot read private key: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			return fmt.Errorf("cannot parse private key from %q: %w", fs.PrivateKeyFile, err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if fs.PasswordFile != "" {
+		data, err := os.ReadFile(fs.PasswordFile)
+		if err != nil {
+			return fmt.Errorf("cannot read password: %w", err)
+		}
+		auth = append(auth, ssh.Password(strings.TrimRight(string(data), "\r\n")))
+	}
+	if len(auth) == 0 {
+		return fmt.Errorf("missing credentials for SFTP server %q; pass either password or private key", fs.Addr)
+	}
+
+	var hostKeyCallback ssh.HostKeyCallback
+	switch {
+	case fs.InsecureIgnoreHostKey:
+		hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	case fs.KnownHostsFile != "":
+		cb, err := knownhosts.New(fs.KnownHostsFile)
+		if err != nil {
+			return fmt.Errorf("cannot read known hosts: %w", err)
+		}
+		hostKeyCallback = cb
+	default:
+		return fmt.Errorf("missing known hosts file for verifying SFTP server %q host key", fs.Addr)
+	}
+
+	fs.config = &ssh.ClientConfig{
+		User:            fs.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         30 * time.Second,
+	}
+	if _, err := fs.getClient(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// MustStop stops fs.
+func (fs *FS) MustStop() {
+	fs.mu.Lock()
+	if fs.c != nil {
+		fs.c.close()
+		fs.c = nil
+	}
+	fs.mu.Unlock()
+}
+
+// String returns human-readable description for fs.
+func (fs *FS) String() string {
+	return fmt.Sprintf("SFTP{addr: %q, user: %q, dir: %q}", fs.Addr, fs.User, fs.Dir)
+}
+
+// getClient returns the client connected to SFTP server.
+//
+// It re-connects to the server if the previous connection is broken.
+func (fs *FS) getClient() (*client, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.c != nil && !fs.c.isBroken() {
+		return fs.c, nil
+	}
+	if fs.c != nil {
+		fs.c.close()
+		fs.c = nil
+	}
+	conn, err := ssh.Dial("tcp", fs.Addr, fs.config)
+	if err != nil {
+		return nil, &connError{err: fmt.Errorf("cannot connect to %q: %w", fs.Addr, err)}
+	}
+	sc, err := sftp.NewClient(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, &connError{err: fmt.Errorf("cannot start SFTP session at %q: %w", fs.Addr, err)}
+	}
+	c := &client{
+		Client: sc,
+		conn:   conn,
+		doneCh: make(chan struct{}),
+	}
+	go func() {
+		_ = sc.Wait()
+		close(c.doneCh)
+	}()
+	fs.c = c
+	return c, nil
+}
+
+// client is a connection to SFTP server.
+type client struct {
+	*sftp.Client
+
+	conn *ssh.Client
+
+	// doneCh is closed when the connection is broken.
+	doneCh chan struct{}
+}
+
+func (c *client) isBroken() bool {
+	select {
+	case <-c.doneCh:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *client) close() {
+	_ = c.Client.Close()
+	_ = c.conn.Close()
+}
+
+// hasExtension returns true if the server supports the given extension.
+func (c *client) hasExtension(name string) bool {
+	_, ok := c.HasExtension(name)
+	return ok
+}
+
+// rename renames oldPath to newPath. newPath is overwritten if it exists.
+func (c *client) rename(oldPath, newPath string) error {
+	if c.hasExtension(extPosixRename) {
+		return c.PosixRename(oldPath, newPath)
+	}
+	// SFTP protocol version 3 doesn't allow overwriting the existing file during the rename.
+	if err := c.Remove(newPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return c.Rename(oldPath, newPath)
+}
+
+// connError is returned when the connection to SFTP server cannot be established.
+type connError struct {
+	err error
+}
+
+func (ce *connError) Error() string {
+	return ce.err.Error()
+}
+
+func (ce *connError) Unwrap() error {
+	return ce.err
+}
+
+// isConnError returns true if err is caused by broken connection to SFTP server for c.
+func isConnError(c *client, err error) bool {
+	var ce *connError
+	if errors.As(err, &ce) || errors.Is(err, sftp.ErrSSHFxConnectionLost) {
+		return true
+	}
+	return c != nil && c.isBroken()
+}
+
+// withRetries calls f with the client connected to SFTP server.
+//
+// f is called again after re-connecting to the server if it returns connection error.
+func (fs *FS) withRetries(f func(c *client) error) error {
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		c, err := fs.getClient()
+		if err == nil {
+			err = f(c)
+		}
+		if err == nil || !isConnError(c, err) || attempt >= maxRetries {
+			return err
+		}
+		logger.Warnf("%s; retrying in %s", err, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// walk calls f for all the regular files under dir.
+//
+// f is called with the file path relative to fs.Dir.
+func (fs *FS) walk(c *client, dir string, f func(filePath string, size uint64)) error {
+	fis, err := c.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("cannot read directory %q: %w", dir, err)
+	}
+	for _, fi := range fis {
+		p := path.Join(dir, fi.Name())
+		if fi.IsDir() {
+			if err := fs.walk(c, p, f); err != nil {
+				return err
+			}
+			continue
+		}
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+		f(fs.relPath(p), uint64(fi.Size()))
+	}
+	return nil
+}
+
+// listFiles returns all the files under fs.Dir.
+func (fs *FS) listFiles() ([]common.FileInfo, error) {
+	var fis []common.FileInfo
+	err := fs.withRetries(func(c *client) error {
+		fis = fis[:0]
+		if _, err := c.Stat(fs.Dir); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				// Return empty list for non-existing directory.
+				// The directory will be created later.
+				return nil
+			}
+			return fmt.Errorf("cannot stat %q: %w", fs.Dir, err)
+		}
+		return fs.walk(c, fs.Dir, func(filePath string, size uint64) {
+			fis = append(fis, common.FileInfo{
+				Path: filePath,
+				Size: size,
+			})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fis, nil
+}
+
+// ListParts returns all the parts for fs.
+func (fs *FS) ListParts() ([]common.Part, error) {
+	fis, err := fs.listFiles()
+	if err != nil {
+		return nil, err
+	}
+	var parts []common.Part
+	for _, fi := range fis {
+		if fscommon.IgnorePath(fi.Path) || isTmpPath(fi.Path) {
+			continue
+		}
+		var p common.Part
+		if !p.ParseFromRemotePath(fi.Path) {
+			logger.Infof("skipping unknown file %s", fi.Path)
+			continue
+		}
+		p.ActualSize = fi.Size
+		parts = append(parts, p)
+	}
+	return parts, nil
+}
+
+// DeletePart deletes part p from fs.
+func (fs *FS) DeletePart(p common.Part) error {
+	filePath := fs.path(p)
+	err := fs.withRetries(func(c *client) error {
+		return c.Remove(filePath)
+	})
+	if err != nil {
+		return fmt.Errorf("cannot remove %q: %w", filePath, err)
+	}
+	return nil
+}
+
+// RemoveEmptyDirs recursively removes empty directories in fs.
+func (fs *FS) RemoveEmptyDirs() error {
+	err := fs.withRetries(func(c *client) error {
+		_, err := fs.removeEmptyDirs(c, fs.Dir)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	})
+	fs.createdDirs.Range(func(k, _ interface{}) bool {
+		fs.createdDirs.Delete(k)
+		return true
+	})
+	return err
+}
+
+func (fs *FS) removeEmptyDirs(c *client, dir string) (bool, error) {
+	fis, err := c.ReadDir(dir)
+	if err != nil {
+		return false, fmt.Errorf("cannot read directory %q: %w", dir, err)
+	}
+	dirEntries := 0
+	for _, fi := range fis {
+		if !fi.IsDir() {
+			dirEntries++
+			continue
+		}
+		ok, err := fs.removeEmptyDirs(c, path.Join(dir, fi.Name()))
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			dirEntries++
+		}
+	}
+	if dirEntries > 0 {
+		return false, nil
+	}
+	if err := c.RemoveDirectory(dir); err != nil {
+		return false, fmt.Errorf("cannot remove empty directory %q: %w", dir, err)
+	}
+	return true, nil
+}
+
+// CopyPart copies p from srcFS to fs.
+//
+// srcFS must have *FS type. Hard link is created for p if both fs and srcFS are located at the same SFTP server,
+// which supports hard links. Otherwise p is downloaded from srcFS and uploaded to fs.
+func (fs *FS) CopyPart(srcFS common.OriginFS, p common.Part) error {
+	src, ok := srcFS.(*FS)
+	if !ok {
+		return fmt.Errorf("cannot perform server-side copying from %s to %s: both of them must be SFTP", srcFS, fs)
+	}
+	if src.Addr == fs.Addr && src.User == fs.User {
+		srcPath := src.path(p)
+		dstPath := fs.path(p)
+		if err := fs.mkdirAll(path.Dir(dstPath)); err != nil {
+			return err
+		}
+		linked := false
+		err := fs.withRetries(func(c *client) error {
+			if !c.hasExtension(extHardlink) {
+				return nil
+			}
+			if err := c.Remove(dstPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("cannot remove %q: %w", dstPath, err)
+			}
+			if err := c.Link(srcPath, dstPath); err != nil {
+				return fmt.Errorf("cannot create hard link from %q to %q: %w", srcPath, dstPath, err)
+			}
+			linked = true
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if linked {
+			return nil
+		}
+	}
+
+	// Copy the part via the client.
+	pr, pw := io.Pipe()
+	doneCh := make(chan error, 1)
+	go func() {
+		err := src.DownloadPart(p, pw)
+		pw.CloseWithError(err)
+		doneCh <- err
+	}()
+	err := fs.UploadPart(p, pr)
+	pr.CloseWithError(io.ErrClosedPipe)
+	if errDownload := <-doneCh; errDownload != nil && err == nil {
+		err = errDownload
+	}
+	if err != nil {
+		return fmt.Errorf("cannot copy %s from %s to %s: %w", &p, src, fs, err)
+	}
+	return nil
+}
+
+// DownloadPart downloads part p from fs to w.
+func (fs *FS) DownloadPart(p common.Part, w io.Writer) error {
+	filePath := fs.path(p)
+	size := p.RemoteSize()
+	offset := uint64(0)
+	err := fs.withRetries(func(c *client) error {
+		f, err := c.Open(filePath)
+		if err != nil {
+			return fmt.Errorf("cannot open %q: %w", filePath, err)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		// Resume the download from the last offset after re-connect.
+		if _, err := f.Seek(int64(offset), io.SeekStart); err != nil {
+			return fmt.Errorf("cannot seek to offset %d at %q: %w", offset, filePath, err)
+		}
+		n, err := io.CopyN(w, f, int64(size-offset))
+		offset += uint64(n)
+		if err == io.EOF {
+			return fmt.Errorf("unexpected end of file; got %d bytes; want %d bytes", offset, size)
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("cannot download %q after downloading %d bytes: %w", filePath, offset, err)
+	}
+	return nil
+}
+
+// UploadPart uploads part p from r to fs.
+//
+// The upload is resumed from the last written chunk after connection errors.
+func (fs *FS) UploadPart(p common.Part, r io.Reader) error {
+	filePath := fs.path(p)
+	n, err := fs.upload(filePath, r)
+	if err != nil {
+		return err
+	}
+	if n != p.RemoteSize() {
+		_ = fs.DeleteFile(fs.relPath(filePath))
+		return fmt.Errorf("wrong data size uploaded to %q; got %d bytes; want %d bytes", filePath, n, p.RemoteSize())
+	}
+	return nil
+}
+
+// upload uploads data from r to filePath at fs and returns the number of uploaded bytes.
+//
+// The data is uploaded to temporary file at first and then the temporary file is atomically renamed to filePath.
+func (fs *FS) upload(filePath string, r io.Reader) (uint64, error) {
+	if err := fs.mkdirAll(path.Dir(filePath)); err != nil {
+		return 0, err
+	}
+	tmpPath := filePath + tmpSuffix
+	var fileClient *client
+	var f *sftp.File
+	created := false
+	offset := uint64(0)
+	writeChunk := func(c *client, chunk []byte) error {
+		if c != fileClient {
+			// Open the file after re-connect. Do not truncate it in order to keep the previously written data.
+			flags := os.O_WRONLY | os.O_CREATE
+			if offset == 0 {
+				flags |= os.O_TRUNC
+			}
+			fNew, err := c.OpenFile(tmpPath, flags)
+			if err != nil {
+				return fmt.Errorf("cannot open %q: %w", tmpPath, err)
+			}
+			fileClient = c
+			f = fNew
+			created = true
+		}
+		if _, err := f.WriteAt(chunk, int64(offset)); err != nil {
+			return fmt.Errorf("cannot write %d bytes to %q at offset %d: %w", len(chunk), tmpPath, offset, err)
+		}
+		return nil
+	}
+
+	buf := make([]byte, uploadChunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			chunk := buf[:n]
+			if err := fs.withRetries(func(c *client) error { return writeChunk(c, chunk) }); err != nil {
+				fs.removeTmpFile(tmpPath)
+				return 0, err
+			}
+			offset += uint64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			fs.removeTmpFile(tmpPath)
+			return 0, fmt.Errorf("cannot read data for uploading to %q: %w", filePath, err)
+		}
+	}
+
+	err := fs.withRetries(func(c *client) error {
+		if !created {
+			// Create an empty file.
+			if err := writeChunk(c, nil); err != nil {
+				return err
+			}
+		}
+		if c == fileClient {
+			if err := f.Close(); err != nil {
+				return fmt.Errorf("cannot close %q: %w", tmpPath, err)
+			}
+			fileClient = nil
+		}
+		if err := c.rename(tmpPath, filePath); err != nil {
+			return fmt.Errorf("cannot rename %q to %q: %w", tmpPath, filePath, err)
+		}
+		return nil
+	})
+	if err != nil {
+		fs.removeTmpFile(tmpPath)
+		return 0, err
+	}
+	return offset, nil
+}
+
+func (fs *FS) removeTmpFile(tmpPath string) {
+	_ = fs.withRetries(func(c *client) error {
+		return c.Remove(tmpPath)
+	})
+}
+
+// mkdirAll creates dir with all the parent directories at SFTP server.
+func (fs *FS) mkdirAll(dir string) error {
+	if _, ok := fs.createdDirs.Load(dir); ok {
+		return nil
+	}
+	if dir == "/" || dir == "." {
+		return nil
+	}
+	if err := fs.mkdirAll(path.Dir(dir)); err != nil {
+		return err
+	}
+	err := fs.withRetries(func(c *client) error {
+		fi, err := c.Stat(dir)
+		if err == nil {
+			if !fi.IsDir() {
+				return fmt.Errorf("%q isn't a directory", dir)
+			}
+			return nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("cannot stat %q: %w", dir, err)
+		}
+		if err := c.Mkdir(dir); err != nil {
+			// The directory may be created by concurrent goroutine.
+			if fi, errStat := c.Stat(dir); errStat == nil && fi.IsDir() {
+				return nil
+			}
+			return fmt.Errorf("cannot create directory %q: %w", dir, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fs.createdDirs.Store(dir, struct{}{})
+	return nil
+}
+
+func (fs *FS) path(p common.Part) string {
+	return p.RemotePath(fs.Dir)
+}
+
+func (fs *FS) filePath(filePath string) string {
+	return path.Join(fs.Dir, filePath)
+}
+
+// relPath returns filePath relative to fs.Dir.
+func (fs *FS) relPath(filePath string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(filePath, fs.Dir), "/")
+}
+
+// tmpSuffix is the suffix for temporary files, which are created during the upload.
+const tmpSuffix = ".tmp"
+
+func isTmpPath(filePath string) bool {
+	return strings.HasSuffix(filePath, tmpSuffix)
+}
+
+// DeleteFile deletes filePath from fs if it exists.
+func (fs *FS) DeleteFile(filePath string) error {
+	p := fs.filePath(filePath)
+	err := fs.withRetries(func(c *client) error {
+		return c.Remove(p)
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("cannot delete %q: %w", p, err)
+	}
+	return nil
+}
+
+// CreateFile creates filePath at fs and puts data into it.
+//
+// The file is overwritten if it already exists.
+func (fs *FS) CreateFile(filePath string, data []byte) error {
+	p := fs.filePath(filePath)
+	n, err := fs.upload(p, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("cannot create %q: %w", p, err)
+	}
+	if n != uint64(len(data)) {
+		return fmt.Errorf("wrong data size uploaded to %q; got %d bytes; want %d bytes", p, n, len(data))
+	}
+	return nil
+}
+
+// HasFile returns true if filePath exists at fs.
+func (fs *FS) HasFile(filePath string) (bool, error) {
+	p := fs.filePath(filePath)
+	var fi os.FileInfo
+	err := fs.withRetries(func(c *client) error {
+		var err error
+		fi, err = c.Stat(p)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("cannot stat %q: %w", p, err)
+	}
+	if fi.IsDir() {
+		return false, fmt.Errorf("%q is directory, while file is needed", p)
+	}
+	return true, nil
+}
+
+// ListFiles returns all the files at fs with paths starting with prefix.
+func (fs *FS) ListFiles(prefix string) ([]common.FileInfo, error) {
+	fis, err := fs.listFiles()
+	if err != nil {
+		return nil, err
+	}
+	var result []common.FileInfo
+	for _, fi := range fis {
+		if strings.HasPrefix(fi.Path, prefix) && !isTmpPath(fi.Path) {
+			result = append(result, fi)
+		}
+	}
+	return result, nil
+}
+
+// ReadFileRange reads size bytes from filePath at fs starting from the given offset.
+func (fs *FS) ReadFileRange(filePath string, offset, size uint64) ([]byte, error) {
+	p := fs.filePath(filePath)
+	data := make([]byte, size)
+	err := fs.withRetries(func(c *client) error {
+		f, err := c.Open(p)
+		if err != nil {
+			return err
+		}
+		_, err = f.ReadAt(data, int64(offset))
+		_ = f.Close()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %d bytes at offset %d from %q: %w", size, offset, p, err)
+	}
+	return data, nil
+}
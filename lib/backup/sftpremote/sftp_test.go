@@ -0,0 +1,257 @@
+package sftpremote
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/common"
+)
+
+func newTestFS(t *testing.T, s *testServer, dir string) *FS {
+	t.Helper()
+	fs := &FS{
+		Addr:           s.addr(),
+		User:           "test",
+		Dir:            s.localPath(dir),
+		PrivateKeyFile: s.clientKeyFile,
+		KnownHostsFile: s.knownHostsFile,
+	}
+	if err := fs.Init(); err != nil {
+		t.Fatalf("cannot initialize fs: %s", err)
+	}
+	t.Cleanup(fs.MustStop)
+	return fs
+}
+
+func newTestPart(path string, size int) (common.Part, []byte) {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	p := common.Part{
+		Path:     path,
+		FileSize: uint64(size),
+		Size:     uint64(size),
+	}
+	return p, data
+}
+
+func TestFSAuth(t *testing.T) {
+	s := newTestServer(t)
+
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte(s.password+"\n"), 0600); err != nil {
+		t.Fatalf("cannot write password file: %s", err)
+	}
+	fs := &FS{
+		Addr:           s.addr(),
+		User:           "test",
+		Dir:            s.localPath("backup"),
+		PasswordFile:   passwordFile,
+		KnownHostsFile: s.knownHostsFile,
+	}
+	if err := fs.Init(); err != nil {
+		t.Fatalf("cannot initialize fs with password auth: %s", err)
+	}
+	fs.MustStop()
+
+	// Invalid password
+	if err := os.WriteFile(passwordFile, []byte("invalid"), 0600); err != nil {
+		t.Fatalf("cannot write password file: %s", err)
+	}
+	fs = &FS{
+		Addr:           s.addr(),
+		User:           "test",
+		Dir:            s.localPath("backup"),
+		PasswordFile:   passwordFile,
+		KnownHostsFile: s.knownHostsFile,
+	}
+	if err := fs.Init(); err == nil {
+		fs.MustStop()
+		t.Fatalf("expecting non-nil error for invalid password")
+	}
+
+	// Unknown host key
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(knownHostsFile, nil, 0600); err != nil {
+		t.Fatalf("cannot write known hosts file: %s", err)
+	}
+	fs = &FS{
+		Addr:           s.addr(),
+		User:           "test",
+		Dir:            s.localPath("backup"),
+		PrivateKeyFile: s.clientKeyFile,
+		KnownHostsFile: knownHostsFile,
+	}
+	if err := fs.Init(); err == nil {
+		fs.MustStop()
+		t.Fatalf("expecting non-nil error for unknown host key")
+	}
+}
+
+func TestFSParts(t *testing.T) {
+	s := newTestServer(t)
+	fs := newTestFS(t, s, "/backup")
+
+	parts, err := fs.ListParts()
+	if err != nil {
+		t.Fatalf("cannot list parts: %s", err)
+	}
+	if len(parts) != 0 {
+		t.Fatalf("unexpected parts in empty fs: %v", parts)
+	}
+
+	p, data := newTestPart("data/small/part/values.bin", 100*1024)
+	if err := fs.UploadPart(p, bytes.NewReader(data)); err != nil {
+		t.Fatalf("cannot upload part: %s", err)
+	}
+	parts, err = fs.ListParts()
+	if err != nil {
+		t.Fatalf("cannot list parts: %s", err)
+	}
+	if len(parts) != 1 || parts[0].Path != p.Path || parts[0].ActualSize != p.Size {
+		t.Fatalf("unexpected parts: %v", parts)
+	}
+
+	var bb bytes.Buffer
+	if err := fs.DownloadPart(p, &bb); err != nil {
+		t.Fatalf("cannot download part: %s", err)
+	}
+	if !bytes.Equal(bb.Bytes(), data) {
+		t.Fatalf("unexpected data downloaded")
+	}
+
+	// Upload the part with invalid size
+	pInvalid, _ := newTestPart("data/small/part/index.bin", 10)
+	if err := fs.UploadPart(pInvalid, bytes.NewReader(data)); err == nil {
+		t.Fatalf("expecting non-nil error for part with invalid size")
+	}
+
+	if err := fs.DeletePart(p); err != nil {
+		t.Fatalf("cannot delete part: %s", err)
+	}
+	if err := fs.RemoveEmptyDirs(); err != nil {
+		t.Fatalf("cannot remove empty dirs: %s", err)
+	}
+	if _, err := os.Stat(s.localPath("backup")); !os.IsNotExist(err) {
+		t.Fatalf("expecting empty dirs to be removed; got %v", err)
+	}
+}
+
+func TestFSFiles(t *testing.T) {
+	s := newTestServer(t)
+	fs := newTestFS(t, s, "/backup")
+
+	ok, err := fs.HasFile("backup_complete.ignore")
+	if err != nil {
+		t.Fatalf("cannot check for file: %s", err)
+	}
+	if ok {
+		t.Fatalf("unexpected file in empty fs")
+	}
+	if err := fs.CreateFile("backup_complete.ignore", []byte("foobar")); err != nil {
+		t.Fatalf("cannot create file: %s", err)
+	}
+	if err := fs.CreateFile("backup_complete.ignore", []byte("foo")); err != nil {
+		t.Fatalf("cannot overwrite file: %s", err)
+	}
+	ok, err = fs.HasFile("backup_complete.ignore")
+	if err != nil {
+		t.Fatalf("cannot check for file: %s", err)
+	}
+	if !ok {
+		t.Fatalf("missing created file")
+	}
+	data, err := fs.ReadFileRange("backup_complete.ignore", 1, 2)
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err)
+	}
+	if string(data) != "oo" {
+		t.Fatalf("unexpected data read; got %q; want %q", data, "oo")
+	}
+
+	fis, err := fs.ListFiles("backup_")
+	if err != nil {
+		t.Fatalf("cannot list files: %s", err)
+	}
+	if len(fis) != 1 || fis[0].Path != "backup_complete.ignore" || fis[0].Size != 3 {
+		t.Fatalf("unexpected files: %v", fis)
+	}
+
+	if err := fs.DeleteFile("backup_complete.ignore"); err != nil {
+		t.Fatalf("cannot delete file: %s", err)
+	}
+	if err := fs.DeleteFile("backup_complete.ignore"); err != nil {
+		t.Fatalf("cannot delete missing file: %s", err)
+	}
+}
+
+func TestFSUploadResume(t *testing.T) {
+	s := newTestServer(t)
+	fs := newTestFS(t, s, "/backup")
+
+	// Drop the connection in the middle of the upload.
+	atomic.StoreInt64(&s.dropConnAfterBytes, uploadChunkSize)
+	p, data := newTestPart("data/big/part/values.bin", 3*uploadChunkSize/2)
+	if err := fs.UploadPart(p, bytes.NewReader(data)); err != nil {
+		t.Fatalf("cannot upload part: %s", err)
+	}
+	if n := atomic.LoadInt64(&s.conns); n != 2 {
+		t.Fatalf("unexpected number of connections; got %d; want 2", n)
+	}
+	var bb bytes.Buffer
+	if err := fs.DownloadPart(p, &bb); err != nil {
+		t.Fatalf("cannot download part: %s", err)
+	}
+	if !bytes.Equal(bb.Bytes(), data) {
+		t.Fatalf("unexpected data downloaded after resumed upload")
+	}
+}
+
+func TestFSCopyPart(t *testing.T) {
+	f := func(sameServer, hardlinkExpected bool) {
+		t.Helper()
+		s := newTestServer(t)
+		sDst := s
+		if !sameServer {
+			sDst = newTestServer(t)
+		}
+		src := newTestFS(t, s, "backup/src")
+		dst := newTestFS(t, sDst, "backup/dst")
+
+		p, data := newTestPart("data/small/part/values.bin", 1000)
+		if err := src.UploadPart(p, bytes.NewReader(data)); err != nil {
+			t.Fatalf("cannot upload part: %s", err)
+		}
+		if err := dst.CopyPart(src, p); err != nil {
+			t.Fatalf("cannot copy part: %s", err)
+		}
+		srcFI, err := os.Stat(src.path(p))
+		if err != nil {
+			t.Fatalf("cannot stat source part: %s", err)
+		}
+		dstFI, err := os.Stat(dst.path(p))
+		if err != nil {
+			t.Fatalf("cannot stat copied part: %s", err)
+		}
+		if hardlink := os.SameFile(srcFI, dstFI); hardlink != hardlinkExpected {
+			t.Fatalf("unexpected hardlink for the copied part; got %v; want %v", hardlink, hardlinkExpected)
+		}
+		var bb bytes.Buffer
+		if err := dst.DownloadPart(p, &bb); err != nil {
+			t.Fatalf("cannot download part: %s", err)
+		}
+		if !bytes.Equal(bb.Bytes(), data) {
+			t.Fatalf("unexpected data for the copied part")
+		}
+	}
+
+	// Server-side copy via hard link
+	f(true, true)
+
+	// Copy via the client
+	f(false, false)
+}
@@ -0,0 +1,797 @@
+package webdavremote
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/studio-b12/gowebdav"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/common"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/fscommon"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+)
+
+// chunkSize is the maximum size of a single chunk for the part stored at WebDAV server.
+//
+// Every part is stored as a collection of chunks, so the upload can be resumed from the last failed chunk.
+// Every uploaded chunk is buffered in memory, so it could be retried on errors.
+const chunkSize = 8 * 1024 * 1024
+
+// maxRetries is the maximum number of retries for the request after temporary errors.
+const maxRetries = 5
+
+// tmpSuffix is the suffix for temporary collections, which are created during the part upload.
+const tmpSuffix = ".tmp"
+
+// FS represents filesystem for backups at WebDAV server.
+//
+// Init must be called before calling other FS methods.
+type FS struct {
+	// URL is the URL of WebDAV server. For example, https://webdav-server:8080/
+	URL string
+
+	// Dir is the directory at WebDAV server to write to.
+	Dir string
+
+	// Username is an optional username for basic auth at WebDAV server.
+	Username string
+
+	// PasswordFile is an optional path to file with the password for basic auth at WebDAV server.
+	PasswordFile string
+
+	// TLSCAFile is an optional path to file with CA certificates for verifying WebDAV server certificate.
+	TLSCAFile string
+
+	// TLSInsecureSkipVerify disables verification of WebDAV server certificate.
+	TLSInsecureSkipVerify bool
+
+	baseURL *url.URL
+	tr      *http.Transport
+	c       *gowebdav.Client
+
+	// createdDirs contains directories, which are known to exist at WebDAV server.
+	createdDirs sync.Map
+}
+
+// Init initializes fs.
+//
+// The returned fs must be stopped when no long needed with MustStop call.
+func (fs *FS) Init() error {
+	if fs.c != nil {
+		logger.Panicf("BUG: Init is already called")
+	}
+	u, err := url.Parse(fs.URL)
+	if err != nil {
+		return fmt.Errorf("cannot parse WebDAV server url %q: %w", fs.URL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme in WebDAV server url %q; supported schemes: http, https", fs.URL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	fs.baseURL = u
+	fs.Dir = path.Clean("/" + fs.Dir)
+
+	var password string
+	if fs.PasswordFile != "" {
+		data, err := os.ReadFile(fs.PasswordFile)
+		if err != nil {
+			return fmt.Errorf("cannot read password: %w", err)
+		}
+		password = strings.TrimRight(string(data), "\r\n")
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: fs.TLSInsecureSkipVerify,
+	}
+	if fs.TLSCAFile != "" {
+		data, err := os.ReadFile(fs.TLSCAFile)
+		if err != nil {
+			return fmt.Errorf("cannot read CA file: %w", err)
+		}
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(data) {
+			return fmt.Errorf("cannot parse CA certificates from %q", fs.TLSCAFile)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	fs.tr = &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		TLSClientConfig:       tlsConfig,
+		ResponseHeaderTimeout: time.Minute,
+		MaxIdleConnsPerHost:   100,
+	}
+	auth := &basicAuth{
+		username: fs.Username,
+		password: password,
+	}
+	fs.c = gowebdav.NewAuthClient(u.String(), gowebdav.NewPreemptiveAuth(auth))
+	fs.c.SetTransport(fs.tr)
+
+	// Verify the connection and credentials.
+	if _, err := fs.stat(fs.Dir); err != nil && !isNotFound(err) {
+		return fmt.Errorf("cannot access %s: %w", fs, err)
+	}
+	return nil
+}
+
+// MustStop stops fs.
+func (fs *FS) MustStop() {
+	fs.tr.CloseIdleConnections()
+}
+
+// String returns human-readable description for fs.
+func (fs *FS) String() string {
+	return fmt.Sprintf("WebDAV{url: %q, dir: %q}", fs.URL, fs.Dir)
+}
+
+// basicAuth sets basic auth credentials for every request to WebDAV server.
+//
+// It avoids the additional round trip and buffering of request bodies needed for negotiating the auth method.
+type basicAuth struct {
+	username string
+	password string
+}
+
+func (ba *basicAuth) Authorize(_ *http.Client, req *http.Request, _ string) error {
+	if ba.username != "" || ba.password != "" {
+		req.SetBasicAuth(ba.username, ba.password)
+	}
+	return nil
+}
+
+func (ba *basicAuth) Verify(_ *http.Client, resp *http.Response, p string) (bool, error) {
+	if resp.StatusCode == http.StatusUnauthorized {
+		return false, gowebdav.NewPathError("Authorize", p, resp.StatusCode)
+	}
+	return false, nil
+}
+
+func (ba *basicAuth) Clone() gowebdav.Authenticator {
+	return ba
+}
+
+func (ba *basicAuth) Close() error {
+	return nil
+}
+
+// isNotFound returns true if err is returned for missing file at WebDAV server.
+func isNotFound(err error) bool {
+	return statusCode(err) == http.StatusNotFound
+}
+
+// statusCode returns response status code for err returned from WebDAV server.
+//
+// It returns 0 if err isn't caused by unexpected response status code.
+func statusCode(err error) int {
+	var se gowebdav.StatusError
+	if errors.As(err, &se) {
+		return se.Status
+	}
+	return 0
+}
+
+// isTemporaryError returns true if the request may be retried after err.
+func isTemporaryError(err error) bool {
+	if code := statusCode(err); code != 0 {
+		switch code {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		default:
+			return code >= 500 && code != http.StatusNotImplemented
+		}
+	}
+	var ue *url.Error
+	return errors.As(err, &ue) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// withRetries calls f and retries it on temporary errors.
+func withRetries(f func() error) error {
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		err := f()
+		if err == nil || !isTemporaryError(err) || attempt >= maxRetries {
+			return err
+		}
+		logger.Warnf("%s; retrying in %s", err, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// stat returns information about p at WebDAV server.
+func (fs *FS) stat(p string) (os.FileInfo, error) {
+	var fi os.FileInfo
+	err := withRetries(func() error {
+		var err error
+		fi, err = fs.c.Stat(p)
+		return err
+	})
+	return fi, err
+}
+
+// davEntry is an entry at WebDAV server.
+type davEntry struct {
+	// path is the entry path at WebDAV server.
+	path  string
+	isDir bool
+	size  uint64
+}
+
+// readDir returns entries in dir at WebDAV server.
+func (fs *FS) readDir(dir string) ([]davEntry, error) {
+	var fis []os.FileInfo
+	err := withRetries(func() error {
+		var err error
+		fis, err = fs.c.ReadDir(dir)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]davEntry, 0, len(fis))
+	for _, fi := range fis {
+		entries = append(entries, davEntry{
+			path:  path.Join(dir, fi.Name()),
+			isDir: fi.IsDir(),
+			size:  uint64(fi.Size()),
+		})
+	}
+	return entries, nil
+}
+
+// listAll returns all the entries under dir at WebDAV server.
+func (fs *FS) listAll(dir string) ([]davEntry, error) {
+	entries, err := fs.readDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	result := entries
+	for _, e := range entries {
+		if !e.isDir {
+			continue
+		}
+		subEntries, err := fs.listAll(e.path)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, subEntries...)
+	}
+	return result, nil
+}
+
+// removeAll removes p with all its contents from WebDAV server.
+//
+// It returns nil if p doesn't exist.
+func (fs *FS) removeAll(p string) error {
+	return withRetries(func() error {
+		return fs.c.RemoveAll(p)
+	})
+}
+
+// remoteFile is a file or a part stored at WebDAV server.
+type remoteFile struct {
+	// path is the file path relative to fs.Dir.
+	path string
+	size uint64
+
+	// isPart is set if the file is a part stored as a collection of chunks.
+	isPart bool
+}
+
+// listFiles returns all the files and parts under fs.Dir.
+func (fs *FS) listFiles() ([]remoteFile, error) {
+	entries, err := fs.listAll(fs.Dir)
+	if err != nil {
+		if isNotFound(err) {
+			// Return empty list for non-existing directory.
+			// The directory will be created later.
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot list files at %s: %w", fs, err)
+	}
+	parts := make(map[string]*remoteFile)
+	var files []remoteFile
+	for _, e := range entries {
+		if !fs.isUnderDir(e.path) {
+			continue
+		}
+		relPath := fs.relPath(e.path)
+		if e.isDir {
+			var p common.Part
+			if p.ParseFromRemotePath(relPath) && parts[relPath] == nil {
+				parts[relPath] = &remoteFile{
+					path:   relPath,
+					isPart: true,
+				}
+			}
+			continue
+		}
+		dir, name := path.Split(relPath)
+		dir = strings.TrimSuffix(dir, "/")
+		if strings.HasSuffix(dir, tmpSuffix) {
+			continue
+		}
+		var p common.Part
+		if _, err := parseChunkName(name); err == nil && p.ParseFromRemotePath(dir) {
+			rf := parts[dir]
+			if rf == nil {
+				rf = &remoteFile{
+					path:   dir,
+					isPart: true,
+				}
+				parts[dir] = rf
+			}
+			rf.size += e.size
+			continue
+		}
+		files = append(files, remoteFile{
+			path: relPath,
+			size: e.size,
+		})
+	}
+	for _, rf := range parts {
+		files = append(files, *rf)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].path < files[j].path
+	})
+	return files, nil
+}
+
+// ListParts returns all the parts for fs.
+func (fs *FS) ListParts() ([]common.Part, error) {
+	files, err := fs.listFiles()
+	if err != nil {
+		return nil, err
+	}
+	var parts []common.Part
+	for _, rf := range files {
+		if fscommon.IgnorePath(rf.path) {
+			continue
+		}
+		var p common.Part
+		if !rf.isPart || !p.ParseFromRemotePath(rf.path) {
+			logger.Infof("skipping unknown file %s", rf.path)
+			continue
+		}
+		p.ActualSize = rf.size
+		parts = append(parts, p)
+	}
+	return parts, nil
+}
+
+// DeletePart deletes part p from fs.
+func (fs *FS) DeletePart(p common.Part) error {
+	partPath := fs.path(p)
+	if err := fs.removeAll(partPath); err != nil {
+		return fmt.Errorf("cannot delete %q at %s: %w", partPath, fs, err)
+	}
+	return nil
+}
+
+// RemoveEmptyDirs recursively removes empty directories in fs.
+//
+// It also removes temporary collections left after interrupted uploads.
+func (fs *FS) RemoveEmptyDirs() error {
+	entries, err := fs.listAll(fs.Dir)
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("cannot list files at %s: %w", fs, err)
+	}
+	fs.createdDirs.Range(func(k, _ interface{}) bool {
+		fs.createdDirs.Delete(k)
+		return true
+	})
+
+	nonEmptyDirs := make(map[string]bool)
+	// The listing doesn't include fs.Dir itself.
+	dirs := []string{fs.Dir}
+	for _, e := range entries {
+		if e.path != fs.Dir && !fs.isUnderDir(e.path) {
+			continue
+		}
+		if e.isDir {
+			if strings.HasSuffix(e.path, tmpSuffix) {
+				logger.Infof("removing temporary collection %q left after interrupted upload", e.path)
+				if err := fs.removeAll(e.path); err != nil {
+					return fmt.Errorf("cannot delete %q at %s: %w", e.path, fs, err)
+				}
+				continue
+			}
+			dirs = append(dirs, e.path)
+			continue
+		}
+		if strings.HasSuffix(path.Dir(e.path), tmpSuffix) {
+			continue
+		}
+		for dir := path.Dir(e.path); (dir == fs.Dir || fs.isUnderDir(dir)) && !nonEmptyDirs[dir]; dir = path.Dir(dir) {
+			nonEmptyDirs[dir] = true
+		}
+	}
+	// Remove nested directories before their parents.
+	sort.Slice(dirs, func(i, j int) bool {
+		return len(dirs[i]) > len(dirs[j])
+	})
+	for _, dir := range dirs {
+		if dir == "/" || nonEmptyDirs[dir] || strings.HasSuffix(path.Dir(dir), tmpSuffix) {
+			continue
+		}
+		if err := fs.removeAll(dir); err != nil {
+			return fmt.Errorf("cannot delete empty directory %q at %s: %w", dir, fs, err)
+		}
+	}
+	return nil
+}
+
+// CopyPart copies p from srcFS to fs.
+//
+// srcFS must have *FS type. Server-side copying is performed if both fs and srcFS are located at the same WebDAV server.
+// Otherwise p is downloaded from srcFS and uploaded to fs.
+func (fs *FS) CopyPart(srcFS common.OriginFS, p common.Part) error {
+	src, ok := srcFS.(*FS)
+	if !ok {
+		return fmt.Errorf("cannot perform server-side copying from %s to %s: both of them must be WebDAV", srcFS, fs)
+	}
+	if src.baseURL.String() == fs.baseURL.String() {
+		dstPath := fs.path(p)
+		if err := fs.mkdirAll(path.Dir(dstPath)); err != nil {
+			return err
+		}
+		err := withRetries(func() error {
+			return src.c.Copy(src.path(p)+"/", dstPath+"/", true)
+		})
+		if err == nil {
+			return nil
+		}
+		if code := statusCode(err); code != http.StatusNotImplemented && code != http.StatusBadGateway {
+			return fmt.Errorf("cannot copy %s from %s to %s: %w", &p, src, fs, err)
+		}
+		// The server doesn't support COPY requests. Copy the part via the client.
+	}
+
+	pr, pw := io.Pipe()
+	doneCh := make(chan error, 1)
+	go func() {
+		err := src.DownloadPart(p, pw)
+		pw.CloseWithError(err)
+		doneCh <- err
+	}()
+	err := fs.UploadPart(p, pr)
+	pr.CloseWithError(io.ErrClosedPipe)
+	if errDownload := <-doneCh; errDownload != nil && err == nil {
+		err = errDownload
+	}
+	if err != nil {
+		return fmt.Errorf("cannot copy %s from %s to %s: %w", &p, src, fs, err)
+	}
+	return nil
+}
+
+// DownloadPart downloads part p from fs to w.
+func (fs *FS) DownloadPart(p common.Part, w io.Writer) error {
+	partPath := fs.path(p)
+	chunks, err := fs.listChunks(partPath)
+	if err != nil {
+		return err
+	}
+	offset := uint64(0)
+	for _, chunk := range chunks {
+		if chunk.offset != offset {
+			return fmt.Errorf("missing chunk at offset %d for %q at %s", offset, partPath, fs)
+		}
+		if err := fs.downloadChunk(chunk, w); err != nil {
+			return err
+		}
+		offset += chunk.size
+	}
+	if offset != p.RemoteSize() {
+		return fmt.Errorf("wrong data size downloaded from %q at %s; got %d bytes; want %d bytes", partPath, fs, offset, p.RemoteSize())
+	}
+	return nil
+}
+
+// chunk is a chunk of the part stored at WebDAV server.
+type chunk struct {
+	path   string
+	offset uint64
+	size   uint64
+}
+
+func chunkName(offset uint64) string {
+	return fmt.Sprintf("%016X", offset)
+}
+
+func parseChunkName(name string) (uint64, error) {
+	if len(name) != 16 {
+		return 0, fmt.Errorf("unexpected chunk name length; got %d; want 16", len(name))
+	}
+	return strconv.ParseUint(name, 16, 64)
+}
+
+// listChunks returns chunks for the part at partPath sorted by offset.
+func (fs *FS) listChunks(partPath string) ([]chunk, error) {
+	entries, err := fs.readDir(partPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list chunks for %q at %s: %w", partPath, fs, err)
+	}
+	var chunks []chunk
+	for _, e := range entries {
+		if e.isDir {
+			continue
+		}
+		offset, err := parseChunkName(path.Base(e.path))
+		if err != nil {
+			return nil, fmt.Errorf("unexpected file %q in %q at %s: %w", e.path, partPath, fs, err)
+		}
+		chunks = append(chunks, chunk{
+			path:   e.path,
+			offset: offset,
+			size:   e.size,
+		})
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		return chunks[i].offset < chunks[j].offset
+	})
+	return chunks, nil
+}
+
+// downloadChunk downloads c to w.
+//
+// The download is resumed from the last downloaded byte after temporary errors.
+func (fs *FS) downloadChunk(c chunk, w io.Writer) error {
+	written := uint64(0)
+	err := withRetries(func() error {
+		rc, err := fs.c.ReadStreamRange(c.path, int64(written), 0)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = rc.Close() }()
+		sw := &statWriter{
+			w: w,
+		}
+		_, err = io.Copy(sw, rc)
+		written += sw.bytesWritten
+		if sw.err != nil {
+			return sw.err
+		}
+		if err != nil {
+			// Wrap the error into url.Error, so it is retried.
+			return &url.Error{
+				Op:  "GET",
+				URL: c.path,
+				Err: err,
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cannot download %q from %s: %w", c.path, fs, err)
+	}
+	if written != c.size {
+		return fmt.Errorf("wrong data size downloaded from %q at %s; got %d bytes; want %d bytes", c.path, fs, written, c.size)
+	}
+	return nil
+}
+
+// UploadPart uploads part p from r to fs.
+//
+// The part is uploaded in chunks. Every chunk upload is retried on temporary errors.
+func (fs *FS) UploadPart(p common.Part, r io.Reader) error {
+	partPath := fs.path(p)
+	tmpPath := partPath + tmpSuffix
+	if err := fs.mkdirAll(path.Dir(partPath)); err != nil {
+		return err
+	}
+	// Remove the temporary collection left after the previous interrupted upload.
+	if err := fs.removeAll(tmpPath); err != nil {
+		return fmt.Errorf("cannot delete %q at %s: %w", tmpPath, fs, err)
+	}
+	if err := fs.mkcol(tmpPath); err != nil {
+		return err
+	}
+
+	buf := make([]byte, chunkSize)
+	offset := uint64(0)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			fs.removeTmp(tmpPath)
+			return fmt.Errorf("cannot read data for uploading to %q at %s: %w", partPath, fs, err)
+		}
+		if n > 0 || offset == 0 {
+			chunkPath := tmpPath + "/" + chunkName(offset)
+			chunk := buf[:n]
+			if err := withRetries(func() error { return fs.c.Write(chunkPath, chunk, 0600) }); err != nil {
+				fs.removeTmp(tmpPath)
+				return fmt.Errorf("cannot upload chunk at offset %d for %q at %s: %w", offset, partPath, fs, err)
+			}
+			offset += uint64(n)
+		}
+		if err != nil {
+			break
+		}
+	}
+	if offset != p.RemoteSize() {
+		fs.removeTmp(tmpPath)
+		return fmt.Errorf("wrong data size uploaded to %q at %s; got %d bytes; want %d bytes", partPath, fs, offset, p.RemoteSize())
+	}
+	err := withRetries(func() error {
+		return fs.c.Rename(tmpPath+"/", partPath+"/", true)
+	})
+	if err != nil {
+		fs.removeTmp(tmpPath)
+		return fmt.Errorf("cannot move %q to %q at %s: %w", tmpPath, partPath, fs, err)
+	}
+	return nil
+}
+
+func (fs *FS) removeTmp(tmpPath string) {
+	_ = fs.removeAll(tmpPath)
+}
+
+// mkcol creates the collection at dir.
+//
+// It returns nil if the collection already exists.
+func (fs *FS) mkcol(dir string) error {
+	// gowebdav.Client.Mkdir returns nil if the collection already exists.
+	err := withRetries(func() error {
+		return fs.c.Mkdir(dir, 0700)
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create directory %q at %s: %w", dir, fs, err)
+	}
+	return nil
+}
+
+// mkdirAll creates dir with all the parent directories at WebDAV server.
+func (fs *FS) mkdirAll(dir string) error {
+	if dir == "/" || dir == "." {
+		return nil
+	}
+	if _, ok := fs.createdDirs.Load(dir); ok {
+		return nil
+	}
+	if err := fs.mkdirAll(path.Dir(dir)); err != nil {
+		return err
+	}
+	if err := fs.mkcol(dir); err != nil {
+		return err
+	}
+	fs.createdDirs.Store(dir, struct{}{})
+	return nil
+}
+
+func (fs *FS) path(p common.Part) string {
+	return p.RemotePath(fs.Dir)
+}
+
+func (fs *FS) filePath(filePath string) string {
+	return path.Join(fs.Dir, filePath)
+}
+
+// isUnderDir returns true if p is located under fs.Dir.
+func (fs *FS) isUnderDir(p string) bool {
+	if fs.Dir == "/" {
+		return p != "/"
+	}
+	return strings.HasPrefix(p, fs.Dir+"/")
+}
+
+// relPath returns filePath relative to fs.Dir.
+func (fs *FS) relPath(filePath string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(filePath, fs.Dir), "/")
+}
+
+// DeleteFile deletes filePath from fs if it exists.
+func (fs *FS) DeleteFile(filePath string) error {
+	p := fs.filePath(filePath)
+	if err := fs.removeAll(p); err != nil {
+		return fmt.Errorf("cannot delete %q at %s: %w", p, fs, err)
+	}
+	return nil
+}
+
+// CreateFile creates filePath at fs and puts data into it.
+//
+// The file is overwritten if it already exists.
+func (fs *FS) CreateFile(filePath string, data []byte) error {
+	p := fs.filePath(filePath)
+	if err := fs.mkdirAll(path.Dir(p)); err != nil {
+		return err
+	}
+	if err := withRetries(func() error { return fs.c.Write(p, data, 0600) }); err != nil {
+		return fmt.Errorf("cannot create %q at %s: %w", p, fs, err)
+	}
+	return nil
+}
+
+// HasFile returns true if filePath exists at fs.
+func (fs *FS) HasFile(filePath string) (bool, error) {
+	p := fs.filePath(filePath)
+	fi, err := fs.stat(p)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("cannot check for %q at %s: %w", p, fs, err)
+	}
+	if fi.IsDir() {
+		return false, fmt.Errorf("%q at %s is directory, while file is needed", p, fs)
+	}
+	return true, nil
+}
+
+// ListFiles returns all the files at fs with paths starting with prefix.
+//
+// Parts are returned as a single file despite they are stored as a collection of chunks.
+func (fs *FS) ListFiles(prefix string) ([]common.FileInfo, error) {
+	files, err := fs.listFiles()
+	if err != nil {
+		return nil, err
+	}
+	var fis []common.FileInfo
+	for _, rf := range files {
+		if strings.HasPrefix(rf.path, prefix) {
+			fis = append(fis, common.FileInfo{
+				Path: rf.path,
+				Size: rf.size,
+			})
+		}
+	}
+	return fis, nil
+}
+
+// ReadFileRange reads size bytes from filePath at fs starting from the given offset.
+func (fs *FS) ReadFileRange(filePath string, offset, size uint64) ([]byte, error) {
+	p := fs.filePath(filePath)
+	if size == 0 {
+		return nil, nil
+	}
+	var data []byte
+	err := withRetries(func() error {
+		rc, err := fs.c.ReadStreamRange(p, int64(offset), int64(size))
+		if err != nil {
+			return err
+		}
+		defer func() { _ = rc.Close() }()
+		data, err = io.ReadAll(io.LimitReader(rc, int64(size)))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %d bytes at offset %d from %q at %s: %w", size, offset, p, fs, err)
+	}
+	if uint64(len(data)) != size {
+		return nil, fmt.Errorf("cannot read %d bytes at offset %d from %q at %s: got only %d bytes", size, offset, p, fs, len(data))
+	}
+	return data, nil
+}
+
+// statWriter tracks the number of bytes written to w and the error returned from w.
+type statWriter struct {
+	w            io.Writer
+	bytesWritten uint64
+	err          error
+}
+
+func (sw *statWriter) Write(p []byte) (int, error) {
+	n, err := sw.w.Write(p)
+	sw.bytesWritten += uint64(n)
+	if err != nil {
+		sw.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,267 @@
+package webdavremote
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/net/webdav"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/backup/common"
+)
+
+// testServer is in-process WebDAV server for tests.
+type testServer struct {
+	root string
+	s    *httptest.Server
+
+	// failPuts is the number of PUT requests, which must fail with temporary error.
+	failPuts int64
+
+	// rejectCopy makes the server to reject COPY requests.
+	rejectCopy bool
+
+	copies int64
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	root := t.TempDir()
+	ts := &testServer{
+		root: root,
+	}
+	h := &webdav.Handler{
+		FileSystem: webdav.Dir(root),
+		LockSystem: webdav.NewMemLS(),
+	}
+	ts.s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "test" || password != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case "PUT":
+			if atomic.AddInt64(&ts.failPuts, -1) >= 0 {
+				http.Error(w, "temporary error", http.StatusServiceUnavailable)
+				return
+			}
+		case "COPY":
+			if ts.rejectCopy {
+				http.Error(w, "COPY isn't supported", http.StatusNotImplemented)
+				return
+			}
+			atomic.AddInt64(&ts.copies, 1)
+		}
+		h.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.s.Close)
+	return ts
+}
+
+func newTestFS(t *testing.T, ts *testServer, dir string) *FS {
+	t.Helper()
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatalf("cannot write password file: %s", err)
+	}
+	fs := &FS{
+		URL:          ts.s.URL,
+		Dir:          dir,
+		Username:     "test",
+		PasswordFile: passwordFile,
+	}
+	if err := fs.Init(); err != nil {
+		t.Fatalf("cannot initialize fs: %s", err)
+	}
+	t.Cleanup(fs.MustStop)
+	return fs
+}
+
+func newTestPart(path string, size int) (common.Part, []byte) {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	p := common.Part{
+		Path:     path,
+		FileSize: uint64(size),
+		Size:     uint64(size),
+	}
+	return p, data
+}
+
+func TestFSAuth(t *testing.T) {
+	ts := newTestServer(t)
+	fs := &FS{
+		URL:      ts.s.URL,
+		Dir:      "/backup",
+		Username: "test",
+	}
+	if err := fs.Init(); err == nil {
+		t.Fatalf("expecting non-nil error for missing password")
+	}
+}
+
+func TestFSParts(t *testing.T) {
+	ts := newTestServer(t)
+	fs := newTestFS(t, ts, "/backup")
+
+	parts, err := fs.ListParts()
+	if err != nil {
+		t.Fatalf("cannot list parts: %s", err)
+	}
+	if len(parts) != 0 {
+		t.Fatalf("unexpected parts in empty fs: %v", parts)
+	}
+
+	p1, data1 := newTestPart("data/small/part/values.bin", 2*chunkSize+123)
+	if err := fs.UploadPart(p1, bytes.NewReader(data1)); err != nil {
+		t.Fatalf("cannot upload part: %s", err)
+	}
+	p2, data2 := newTestPart("data/small/part/index.bin", 0)
+	if err := fs.UploadPart(p2, bytes.NewReader(data2)); err != nil {
+		t.Fatalf("cannot upload empty part: %s", err)
+	}
+	if err := fs.CreateFile("backup_complete.ignore", nil); err != nil {
+		t.Fatalf("cannot create file: %s", err)
+	}
+
+	parts, err = fs.ListParts()
+	if err != nil {
+		t.Fatalf("cannot list parts: %s", err)
+	}
+	common.SortParts(parts)
+	if len(parts) != 2 || parts[0] != p2 || parts[1].Path != p1.Path || parts[1].ActualSize != p1.Size {
+		t.Fatalf("unexpected parts: %v", parts)
+	}
+
+	fis, err := fs.ListFiles("data/")
+	if err != nil {
+		t.Fatalf("cannot list files: %s", err)
+	}
+	if len(fis) != 2 || fis[0].Size != 0 || fis[1].Size != p1.Size {
+		t.Fatalf("unexpected files: %v", fis)
+	}
+
+	var bb bytes.Buffer
+	if err := fs.DownloadPart(p1, &bb); err != nil {
+		t.Fatalf("cannot download part: %s", err)
+	}
+	if !bytes.Equal(bb.Bytes(), data1) {
+		t.Fatalf("unexpected data downloaded")
+	}
+
+	// Upload the part with invalid size
+	pInvalid, _ := newTestPart("data/small/part/items.bin", 10)
+	if err := fs.UploadPart(pInvalid, bytes.NewReader(data1[:20])); err == nil {
+		t.Fatalf("expecting non-nil error for part with invalid size")
+	}
+
+	for _, p := range []common.Part{p1, p2} {
+		if err := fs.DeletePart(p); err != nil {
+			t.Fatalf("cannot delete part: %s", err)
+		}
+	}
+	if err := fs.DeleteFile("backup_complete.ignore"); err != nil {
+		t.Fatalf("cannot delete file: %s", err)
+	}
+	if err := fs.RemoveEmptyDirs(); err != nil {
+		t.Fatalf("cannot remove empty dirs: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(ts.root, "backup")); !os.IsNotExist(err) {
+		t.Fatalf("expecting empty dirs to be removed; got %v", err)
+	}
+}
+
+func TestFSFiles(t *testing.T) {
+	ts := newTestServer(t)
+	fs := newTestFS(t, ts, "/backup")
+
+	ok, err := fs.HasFile("backup_complete.ignore")
+	if err != nil {
+		t.Fatalf("cannot check for file: %s", err)
+	}
+	if ok {
+		t.Fatalf("unexpected file in empty fs")
+	}
+	if err := fs.CreateFile("backup_complete.ignore", []byte("foobar")); err != nil {
+		t.Fatalf("cannot create file: %s", err)
+	}
+	ok, err = fs.HasFile("backup_complete.ignore")
+	if err != nil {
+		t.Fatalf("cannot check for file: %s", err)
+	}
+	if !ok {
+		t.Fatalf("missing created file")
+	}
+	data, err := fs.ReadFileRange("backup_complete.ignore", 1, 2)
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err)
+	}
+	if string(data) != "oo" {
+		t.Fatalf("unexpected data read; got %q; want %q", data, "oo")
+	}
+	if err := fs.DeleteFile("backup_complete.ignore"); err != nil {
+		t.Fatalf("cannot delete file: %s", err)
+	}
+	if err := fs.DeleteFile("backup_complete.ignore"); err != nil {
+		t.Fatalf("cannot delete missing file: %s", err)
+	}
+}
+
+func TestFSUploadRetry(t *testing.T) {
+	ts := newTestServer(t)
+	fs := newTestFS(t, ts, "/backup")
+
+	atomic.StoreInt64(&ts.failPuts, 1)
+	p, data := newTestPart("data/big/part/values.bin", chunkSize+1)
+	if err := fs.UploadPart(p, bytes.NewReader(data)); err != nil {
+		t.Fatalf("cannot upload part: %s", err)
+	}
+	var bb bytes.Buffer
+	if err := fs.DownloadPart(p, &bb); err != nil {
+		t.Fatalf("cannot download part: %s", err)
+	}
+	if !bytes.Equal(bb.Bytes(), data) {
+		t.Fatalf("unexpected data downloaded after retried upload")
+	}
+}
+
+func TestFSCopyPart(t *testing.T) {
+	f := func(rejectCopy bool, copiesExpected int64) {
+		t.Helper()
+		ts := newTestServer(t)
+		ts.rejectCopy = rejectCopy
+		src := newTestFS(t, ts, "/backup/src")
+		dst := newTestFS(t, ts, "/backup/dst")
+
+		p, data := newTestPart("data/small/part/values.bin", 1000)
+		if err := src.UploadPart(p, bytes.NewReader(data)); err != nil {
+			t.Fatalf("cannot upload part: %s", err)
+		}
+		if err := dst.CopyPart(src, p); err != nil {
+			t.Fatalf("cannot copy part: %s", err)
+		}
+		if n := atomic.LoadInt64(&ts.copies); n != copiesExpected {
+			t.Fatalf("unexpected number of COPY requests; got %d; want %d", n, copiesExpected)
+		}
+		var bb bytes.Buffer
+		if err := dst.DownloadPart(p, &bb); err != nil {
+			t.Fatalf("cannot download part: %s", err)
+		}
+		if !bytes.Equal(bb.Bytes(), data) {
+			t.Fatalf("unexpected data for the copied part")
+		}
+	}
+
+	// Server-side copy
+	f(false, 1)
+
+	// Copy via the client
+	f(true, 0)
+}
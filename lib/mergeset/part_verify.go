@@ -0,0 +1,61 @@
+package mergeset
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// VerifyPartHeaders verifies headers for the part with the given files.
+//
+// fileSizes must contain sizes for all the files in the part directory.
+// readFile must return the contents of the file with the given name from the part directory.
+// Only small files with part headers are read via readFile.
+func VerifyPartHeaders(fileSizes map[string]uint64, readFile func(name string) ([]byte, error)) error {
+	for _, name := range []string{metaindexFilename, indexFilename, itemsFilename, lensFilename, metadataFilename} {
+		if _, ok := fileSizes[name]; !ok {
+			return fmt.Errorf("missing %q file", name)
+		}
+	}
+
+	metadata, err := readFile(metadataFilename)
+	if err != nil {
+		return fmt.Errorf("cannot read %q: %w", metadataFilename, err)
+	}
+	var phj partHeaderJSON
+	if err := json.Unmarshal(metadata, &phj); err != nil {
+		return fmt.Errorf("cannot parse %q: %w", metadataFilename, err)
+	}
+	if phj.ItemsCount <= 0 {
+		return fmt.Errorf("the part cannot contain zero items")
+	}
+	if phj.BlocksCount <= 0 {
+		return fmt.Errorf("the part cannot contain zero blocks")
+	}
+	if phj.BlocksCount > phj.ItemsCount {
+		return fmt.Errorf("the number of blocks cannot exceed the number of items in the part; got blocksCount=%d, itemsCount=%d", phj.BlocksCount, phj.ItemsCount)
+	}
+
+	metaindex, err := readFile(metaindexFilename)
+	if err != nil {
+		return fmt.Errorf("cannot read %q: %w", metaindexFilename, err)
+	}
+	mrs, err := unmarshalMetaindexRows(nil, bytes.NewReader(metaindex))
+	if err != nil {
+		return fmt.Errorf("cannot parse %q: %w", metaindexFilename, err)
+	}
+	indexSize := fileSizes[indexFilename]
+	blocksCount := uint64(0)
+	for i := range mrs {
+		mr := &mrs[i]
+		if mr.indexBlockOffset+uint64(mr.indexBlockSize) > indexSize {
+			return fmt.Errorf("index block #%d at offset %d with size %d is out of %q file with size %d",
+				i, mr.indexBlockOffset, mr.indexBlockSize, indexFilename, indexSize)
+		}
+		blocksCount += uint64(mr.blockHeadersCount)
+	}
+	if blocksCount != phj.BlocksCount {
+		return fmt.Errorf("the number of blocks in %q doesn't match %q; got %d vs %d", metaindexFilename, metadataFilename, blocksCount, phj.BlocksCount)
+	}
+	return nil
+}
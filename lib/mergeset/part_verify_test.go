@@ -0,0 +1,90 @@
+package mergeset
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestVerifyPartHeaders(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	bsrs, items := newTestInmemoryBlockStreamReaders(r, 10, 4000)
+	var itemsMerged uint64
+	var ip inmemoryPart
+	var bsw blockStreamWriter
+	bsw.MustInitFromInmemoryPart(&ip, -3)
+	if err := mergeBlockStreams(&ip.ph, &bsw, bsrs, nil, nil, &itemsMerged); err != nil {
+		t.Fatalf("cannot merge blocks: %s", err)
+	}
+	if itemsMerged != uint64(len(items)) {
+		t.Fatalf("unexpected itemsMerged; got %d; want %d", itemsMerged, len(items))
+	}
+	newMetadata := func(ph *partHeader) []byte {
+		t.Helper()
+		data, err := json.Marshal(&partHeaderJSON{
+			ItemsCount:  ph.itemsCount,
+			BlocksCount: ph.blocksCount,
+			FirstItem:   ph.firstItem,
+			LastItem:    ph.lastItem,
+		})
+		if err != nil {
+			t.Fatalf("cannot marshal part header: %s", err)
+		}
+		return data
+	}
+	metadata := newMetadata(&ip.ph)
+	newFiles := func() map[string][]byte {
+		return map[string][]byte{
+			metaindexFilename: append([]byte{}, ip.metaindexData.B...),
+			indexFilename:     append([]byte{}, ip.indexData.B...),
+			itemsFilename:     append([]byte{}, ip.itemsData.B...),
+			lensFilename:      append([]byte{}, ip.lensData.B...),
+			metadataFilename:  append([]byte{}, metadata...),
+		}
+	}
+	verify := func(files map[string][]byte) error {
+		fileSizes := make(map[string]uint64, len(files))
+		for name, data := range files {
+			fileSizes[name] = uint64(len(data))
+		}
+		return VerifyPartHeaders(fileSizes, func(name string) ([]byte, error) {
+			data, ok := files[name]
+			if !ok {
+				return nil, fmt.Errorf("missing file %q", name)
+			}
+			return data, nil
+		})
+	}
+	f := func(name string, modify func(files map[string][]byte), resultExpected bool) {
+		t.Helper()
+		files := newFiles()
+		modify(files)
+		err := verify(files)
+		if resultExpected && err != nil {
+			t.Fatalf("%s: unexpected error: %s", name, err)
+		}
+		if !resultExpected && err == nil {
+			t.Fatalf("%s: expecting non-nil error", name)
+		}
+	}
+
+	f("valid part", func(files map[string][]byte) {}, true)
+	f("missing metadata", func(files map[string][]byte) {
+		delete(files, metadataFilename)
+	}, false)
+	f("missing lens", func(files map[string][]byte) {
+		delete(files, lensFilename)
+	}, false)
+	f("truncated index", func(files map[string][]byte) {
+		files[indexFilename] = files[indexFilename][:len(files[indexFilename])-1]
+	}, false)
+	f("corrupted metaindex", func(files map[string][]byte) {
+		files[metaindexFilename] = []byte("foobar")
+	}, false)
+	f("blocks count mismatch", func(files map[string][]byte) {
+		ph := ip.ph
+		ph.blocksCount++
+		files[metadataFilename] = newMetadata(&ph)
+	}, false)
+}
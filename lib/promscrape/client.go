@@ -56,6 +56,10 @@ type client struct {
 	denyRedirects           bool
 	disableCompression      bool
 	disableKeepAlive        bool
+
+	// maxScrapeSizeOption is the name of the option, which limits the response size for the given client.
+	// It is used in error messages.
+	maxScrapeSizeOption string
 }
 
 func addMissingPort(addr string, isTLS bool) string {
@@ -119,6 +123,12 @@ func newClient(ctx context.Context, sw *ScrapeWork) *client {
 	if err != nil {
 		logger.Fatalf("cannot create dial func: %s", err)
 	}
+	maxBodySize := maxScrapeSize.IntN()
+	maxScrapeSizeOption := "-promscrape.maxScrapeSize"
+	if sw.BodySizeLimit > 0 {
+		maxBodySize = int(sw.BodySizeLimit)
+		maxScrapeSizeOption = "body_size_limit"
+	}
 	hc := &fasthttp.HostClient{
 		Addr:                         dialAddr,
 		Name:                         "vm_promscrape",
@@ -128,7 +138,7 @@ func newClient(ctx context.Context, sw *ScrapeWork) *client {
 		MaxIdleConnDuration:          2 * sw.ScrapeInterval,
 		ReadTimeout:                  sw.ScrapeTimeout,
 		WriteTimeout:                 10 * time.Second,
-		MaxResponseBodySize:          maxScrapeSize.IntN(),
+		MaxResponseBodySize:          maxBodySize,
 		MaxIdempotentRequestAttempts: 1,
 		ReadBufferSize:               maxResponseHeadersSize.IntN(),
 	}
@@ -180,6 +190,7 @@ func newClient(ctx context.Context, sw *ScrapeWork) *client {
 		denyRedirects:           sw.DenyRedirects,
 		disableCompression:      sw.DisableCompression,
 		disableKeepAlive:        sw.DisableKeepAlive,
+		maxScrapeSizeOption:     maxScrapeSizeOption,
 	}
 }
 
@@ -218,10 +229,11 @@ func (c *client) GetStreamReader() (*streamReader, error) {
 	}
 	scrapesOK.Inc()
 	return &streamReader{
-		r:           resp.Body,
-		cancel:      cancel,
-		scrapeURL:   c.scrapeURL,
-		maxBodySize: int64(c.hc.MaxResponseBodySize),
+		r:                 resp.Body,
+		cancel:            cancel,
+		scrapeURL:         c.scrapeURL,
+		maxBodySize:       int64(c.hc.MaxResponseBodySize),
+		maxBodySizeOption: c.maxScrapeSizeOption,
 	}, nil
 }
 
@@ -303,8 +315,8 @@ func (c *client) ReadData(dst []byte) ([]byte, error) {
 		}
 		if err == fasthttp.ErrBodyTooLarge {
 			maxScrapeSizeExceeded.Inc()
-			return dst, fmt.Errorf("the response from %q exceeds -promscrape.maxScrapeSize=%d; "+
-				"either reduce the response size for the target or increase -promscrape.maxScrapeSize", c.scrapeURL, maxScrapeSize.N)
+			return dst, fmt.Errorf("the response from %q exceeds %s=%d; "+
+				"either reduce the response size for the target or increase %s", c.scrapeURL, c.maxScrapeSizeOption, c.hc.MaxResponseBodySize, c.maxScrapeSizeOption)
 		}
 		return dst, fmt.Errorf("error when scraping %q: %w", c.scrapeURL, err)
 	}
@@ -330,8 +342,8 @@ func (c *client) ReadData(dst []byte) ([]byte, error) {
 	fasthttp.ReleaseResponse(resp)
 	if len(dst) > c.hc.MaxResponseBodySize {
 		maxScrapeSizeExceeded.Inc()
-		return dst, fmt.Errorf("the response from %q exceeds -promscrape.maxScrapeSize=%d (the actual response size is %d bytes); "+
-			"either reduce the response size for the target or increase -promscrape.maxScrapeSize", c.scrapeURL, maxScrapeSize.N, len(dst))
+		return dst, fmt.Errorf("the response from %q exceeds %s=%d (the actual response size is %d bytes); "+
+			"either reduce the response size for the target or increase %s", c.scrapeURL, c.maxScrapeSizeOption, c.hc.MaxResponseBodySize, len(dst), c.maxScrapeSizeOption)
 	}
 	if statusCode != fasthttp.StatusOK {
 		metrics.GetOrCreateCounter(fmt.Sprintf(`vm_promscrape_scrapes_total{status_code="%d"}`, statusCode)).Inc()
@@ -402,6 +414,9 @@ type streamReader struct {
 	bytesRead   int64
 	scrapeURL   string
 	maxBodySize int64
+
+	// maxBodySizeOption is the name of the option, which sets maxBodySize. It is used in error messages.
+	maxBodySizeOption string
 }
 
 func (sr *streamReader) Read(p []byte) (int, error) {
@@ -409,8 +424,8 @@ func (sr *streamReader) Read(p []byte) (int, error) {
 	sr.bytesRead += int64(n)
 	if err == nil && sr.bytesRead > sr.maxBodySize {
 		maxScrapeSizeExceeded.Inc()
-		err = fmt.Errorf("the response from %q exceeds -promscrape.maxScrapeSize=%d; "+
-			"either reduce the response size for the target or increase -promscrape.maxScrapeSize", sr.scrapeURL, sr.maxBodySize)
+		err = fmt.Errorf("the response from %q exceeds %s=%d; "+
+			"either reduce the response size for the target or increase %s", sr.scrapeURL, sr.maxBodySizeOption, sr.maxBodySize, sr.maxBodySizeOption)
 	}
 	return n, err
 }
@@ -1212,6 +1212,10 @@ scrape_configs:
 scrape_configs:
   - job_name: 'snmp'
     sample_limit: 100
+    label_limit: 30
+    label_name_length_limit: 40
+    label_value_length_limit: 50
+    body_size_limit: 10MiB
     disable_keepalive: true
     disable_compression: true
     headers:
@@ -1247,16 +1251,20 @@ scrape_configs:
 				"instance": "192.168.1.2",
 				"job":      "snmp",
 			}),
-			AuthConfig:          ac,
-			ProxyAuthConfig:     proxyAC,
-			SampleLimit:         100,
-			DisableKeepAlive:    true,
-			DisableCompression:  true,
-			StreamParse:         true,
-			ScrapeAlignInterval: time.Second,
-			ScrapeOffset:        500 * time.Millisecond,
-			SeriesLimit:         1234,
-			jobNameOriginal:     "snmp",
+			AuthConfig:            ac,
+			ProxyAuthConfig:       proxyAC,
+			SampleLimit:           100,
+			LabelLimit:            30,
+			LabelNameLengthLimit:  40,
+			LabelValueLengthLimit: 50,
+			BodySizeLimit:         10 * 1024 * 1024,
+			DisableKeepAlive:      true,
+			DisableCompression:    true,
+			StreamParse:           true,
+			ScrapeAlignInterval:   time.Second,
+			ScrapeOffset:          500 * time.Millisecond,
+			SeriesLimit:           1234,
+			jobNameOriginal:       "snmp",
 		},
 	})
 	f(`
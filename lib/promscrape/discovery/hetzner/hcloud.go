@@ -0,0 +1,193 @@
+package hetzner
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+// hcloudServer represents the server object returned by Hetzner Cloud API.
+//
+// See https://docs.hetzner.cloud/#servers-get-a-server
+type hcloudServer struct {
+	ID         int                `json:"id"`
+	Name       string             `json:"name"`
+	Status     string             `json:"status"`
+	PublicNet  hcloudPublicNet    `json:"public_net"`
+	PrivateNet []hcloudPrivateNet `json:"private_net"`
+	ServerType hcloudServerType   `json:"server_type"`
+	Datacenter hcloudDatacenter   `json:"datacenter"`
+	Image      *hcloudImage       `json:"image"`
+	Labels     map[string]string  `json:"labels"`
+}
+
+// hcloudPublicNet represents public network of Hetzner Cloud server.
+type hcloudPublicNet struct {
+	IPv4 hcloudIPv4 `json:"ipv4"`
+	IPv6 hcloudIPv6 `json:"ipv6"`
+}
+
+// hcloudIPv4 represents public IPv4 address of Hetzner Cloud server.
+type hcloudIPv4 struct {
+	IP string `json:"ip"`
+}
+
+// hcloudIPv6 represents public IPv6 network of Hetzner Cloud server.
+type hcloudIPv6 struct {
+	IP string `json:"ip"`
+}
+
+// hcloudPrivateNet represents private network of Hetzner Cloud server.
+type hcloudPrivateNet struct {
+	ID int    `json:"network"`
+	IP string `json:"ip"`
+}
+
+// hcloudServerType represents server type of Hetzner Cloud server.
+type hcloudServerType struct {
+	Name    string  `json:"name"`
+	Cores   int     `json:"cores"`
+	CPUType string  `json:"cpu_type"`
+	Memory  float64 `json:"memory"`
+	Disk    int     `json:"disk"`
+}
+
+// hcloudDatacenter represents datacenter of Hetzner Cloud server.
+type hcloudDatacenter struct {
+	Name     string                   `json:"name"`
+	Location hcloudDatacenterLocation `json:"location"`
+}
+
+// hcloudDatacenterLocation represents datacenter location of Hetzner Cloud server.
+type hcloudDatacenterLocation struct {
+	Name        string `json:"name"`
+	NetworkZone string `json:"network_zone"`
+}
+
+// hcloudImage represents image of Hetzner Cloud server.
+type hcloudImage struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	OSFlavor    string `json:"os_flavor"`
+	OSVersion   string `json:"os_version"`
+}
+
+// hcloudNetwork represents Hetzner Cloud network.
+//
+// See https://docs.hetzner.cloud/#networks-get-all-networks
+type hcloudNetwork struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type hcloudMeta struct {
+	Pagination struct {
+		NextPage int `json:"next_page"`
+	} `json:"pagination"`
+}
+
+type hcloudServersList struct {
+	Servers []hcloudServer `json:"servers"`
+	Meta    hcloudMeta     `json:"meta"`
+}
+
+type hcloudNetworksList struct {
+	Networks []hcloudNetwork `json:"networks"`
+	Meta     hcloudMeta      `json:"meta"`
+}
+
+func getHCloudServerLabels(cfg *apiConfig) ([]*promutils.Labels, error) {
+	servers, err := getHCloudServers(cfg)
+	if err != nil {
+		return nil, err
+	}
+	networks, err := getHCloudNetworks(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var ms []*promutils.Labels
+	for i := range servers {
+		ms = appendHCloudTargetLabels(ms, &servers[i], networks, cfg.port)
+	}
+	return ms, nil
+}
+
+func getHCloudServers(cfg *apiConfig) ([]hcloudServer, error) {
+	var servers []hcloudServer
+	page := 1
+	for page > 0 {
+		path := fmt.Sprintf("/v1/servers?page=%d&per_page=50", page)
+		data, err := cfg.client.GetAPIResponse(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot query hcloud api for servers: %w", err)
+		}
+		var resp hcloudServersList
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal hcloud servers list %q: %w", data, err)
+		}
+		servers = append(servers, resp.Servers...)
+		page = resp.Meta.Pagination.NextPage
+	}
+	return servers, nil
+}
+
+func getHCloudNetworks(cfg *apiConfig) ([]hcloudNetwork, error) {
+	var networks []hcloudNetwork
+	page := 1
+	for page > 0 {
+		path := fmt.Sprintf("/v1/networks?page=%d&per_page=50", page)
+		data, err := cfg.client.GetAPIResponse(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot query hcloud api for networks: %w", err)
+		}
+		var resp hcloudNetworksList
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal hcloud networks list %q: %w", data, err)
+		}
+		networks = append(networks, resp.Networks...)
+		page = resp.Meta.Pagination.NextPage
+	}
+	return networks, nil
+}
+
+func appendHCloudTargetLabels(ms []*promutils.Labels, server *hcloudServer, networks []hcloudNetwork, port int) []*promutils.Labels {
+	addr := discoveryutils.JoinHostPort(server.PublicNet.IPv4.IP, port)
+	m := promutils.NewLabels(24)
+	m.Add("__address__", addr)
+	m.Add("__meta_hetzner_role", "hcloud")
+	m.Add("__meta_hetzner_server_id", strconv.Itoa(server.ID))
+	m.Add("__meta_hetzner_server_name", server.Name)
+	m.Add("__meta_hetzner_server_status", server.Status)
+	m.Add("__meta_hetzner_public_ipv4", server.PublicNet.IPv4.IP)
+	m.Add("__meta_hetzner_public_ipv6_network", server.PublicNet.IPv6.IP)
+	m.Add("__meta_hetzner_datacenter", server.Datacenter.Name)
+	m.Add("__meta_hetzner_hcloud_datacenter_location", server.Datacenter.Location.Name)
+	m.Add("__meta_hetzner_hcloud_datacenter_location_network_zone", server.Datacenter.Location.NetworkZone)
+	m.Add("__meta_hetzner_hcloud_server_type", server.ServerType.Name)
+	m.Add("__meta_hetzner_hcloud_cpu_cores", strconv.Itoa(server.ServerType.Cores))
+	m.Add("__meta_hetzner_hcloud_cpu_type", server.ServerType.CPUType)
+	m.Add("__meta_hetzner_hcloud_memory_size_gb", strconv.Itoa(int(server.ServerType.Memory)))
+	m.Add("__meta_hetzner_hcloud_disk_size_gb", strconv.Itoa(server.ServerType.Disk))
+	if server.Image != nil {
+		m.Add("__meta_hetzner_hcloud_image_name", server.Image.Name)
+		m.Add("__meta_hetzner_hcloud_image_description", server.Image.Description)
+		m.Add("__meta_hetzner_hcloud_image_os_version", server.Image.OSVersion)
+		m.Add("__meta_hetzner_hcloud_image_os_flavor", server.Image.OSFlavor)
+	}
+	for _, privateNet := range server.PrivateNet {
+		for _, network := range networks {
+			if privateNet.ID == network.ID {
+				m.Add(discoveryutils.SanitizeLabelName("__meta_hetzner_hcloud_private_ipv4_"+network.Name), privateNet.IP)
+			}
+		}
+	}
+	for k, v := range server.Labels {
+		m.Add(discoveryutils.SanitizeLabelName("__meta_hetzner_hcloud_label_"+k), v)
+		m.Add(discoveryutils.SanitizeLabelName("__meta_hetzner_hcloud_labelpresent_"+k), "true")
+	}
+	ms = append(ms, m)
+	return ms
+}
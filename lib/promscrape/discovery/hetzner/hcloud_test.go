@@ -0,0 +1,165 @@
+package hetzner
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+func newTestAPIConfig(t *testing.T, handler http.HandlerFunc) (*apiConfig, func()) {
+	t.Helper()
+	s := httptest.NewServer(handler)
+	c, err := discoveryutils.NewClient(s.URL, nil, nil, nil)
+	if err != nil {
+		s.Close()
+		t.Fatalf("unexpected error when creating client: %s", err)
+	}
+	cfg := &apiConfig{
+		client: c,
+		port:   9100,
+	}
+	return cfg, func() {
+		c.Stop()
+		s.Close()
+	}
+}
+
+func TestGetHCloudServerLabels(t *testing.T) {
+	cfg, stop := newTestAPIConfig(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/servers":
+			switch r.URL.Query().Get("page") {
+			case "1":
+				fmt.Fprint(w, `{
+  "servers": [
+    {
+      "id": 42,
+      "name": "my-resource",
+      "status": "running",
+      "public_net": {
+        "ipv4": {"ip": "1.2.3.4"},
+        "ipv6": {"ip": "2001:db8::/64"}
+      },
+      "private_net": [
+        {"network": 4711, "ip": "10.0.0.2"}
+      ],
+      "server_type": {
+        "name": "cx11",
+        "cores": 1,
+        "cpu_type": "shared",
+        "memory": 1.5,
+        "disk": 25
+      },
+      "datacenter": {
+        "name": "fsn1-dc8",
+        "location": {"name": "fsn1", "network_zone": "eu-central"}
+      },
+      "image": {
+        "name": "ubuntu-20.04",
+        "description": "Ubuntu 20.04 Standard 64 bit",
+        "os_flavor": "ubuntu",
+        "os_version": "20.04"
+      },
+      "labels": {"my-label": "my-value"}
+    }
+  ],
+  "meta": {"pagination": {"page": 1, "per_page": 1, "next_page": 2, "last_page": 2}}
+}`)
+			case "2":
+				fmt.Fprint(w, `{
+  "servers": [
+    {
+      "id": 44,
+      "name": "another-server",
+      "status": "stopped",
+      "public_net": {
+        "ipv4": {"ip": "1.2.3.6"},
+        "ipv6": {"ip": "2001:db7::/64"}
+      },
+      "private_net": [],
+      "server_type": {
+        "name": "cpx11",
+        "cores": 2,
+        "cpu_type": "dedicated",
+        "memory": 2,
+        "disk": 40
+      },
+      "datacenter": {
+        "name": "hel1-dc2",
+        "location": {"name": "hel1", "network_zone": "eu-central"}
+      },
+      "image": null,
+      "labels": {}
+    }
+  ],
+  "meta": {"pagination": {"page": 2, "per_page": 1, "next_page": null, "last_page": 2}}
+}`)
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		case "/v1/networks":
+			fmt.Fprint(w, `{
+  "networks": [
+    {"id": 4711, "name": "mynet"}
+  ],
+  "meta": {"pagination": {"page": 1, "per_page": 25, "next_page": null, "last_page": 1}}
+}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer stop()
+
+	labelss, err := getHCloudServerLabels(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expectedLabelss := []*promutils.Labels{
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                                            "1.2.3.4:9100",
+			"__meta_hetzner_role":                                    "hcloud",
+			"__meta_hetzner_server_id":                               "42",
+			"__meta_hetzner_server_name":                             "my-resource",
+			"__meta_hetzner_server_status":                           "running",
+			"__meta_hetzner_public_ipv4":                             "1.2.3.4",
+			"__meta_hetzner_public_ipv6_network":                     "2001:db8::/64",
+			"__meta_hetzner_datacenter":                              "fsn1-dc8",
+			"__meta_hetzner_hcloud_datacenter_location":              "fsn1",
+			"__meta_hetzner_hcloud_datacenter_location_network_zone": "eu-central",
+			"__meta_hetzner_hcloud_server_type":                      "cx11",
+			"__meta_hetzner_hcloud_cpu_cores":                        "1",
+			"__meta_hetzner_hcloud_cpu_type":                         "shared",
+			"__meta_hetzner_hcloud_memory_size_gb":                   "1",
+			"__meta_hetzner_hcloud_disk_size_gb":                     "25",
+			"__meta_hetzner_hcloud_image_name":                       "ubuntu-20.04",
+			"__meta_hetzner_hcloud_image_description":                "Ubuntu 20.04 Standard 64 bit",
+			"__meta_hetzner_hcloud_image_os_flavor":                  "ubuntu",
+			"__meta_hetzner_hcloud_image_os_version":                 "20.04",
+			"__meta_hetzner_hcloud_private_ipv4_mynet":               "10.0.0.2",
+			"__meta_hetzner_hcloud_label_my_label":                   "my-value",
+			"__meta_hetzner_hcloud_labelpresent_my_label":            "true",
+		}),
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                                            "1.2.3.6:9100",
+			"__meta_hetzner_role":                                    "hcloud",
+			"__meta_hetzner_server_id":                               "44",
+			"__meta_hetzner_server_name":                             "another-server",
+			"__meta_hetzner_server_status":                           "stopped",
+			"__meta_hetzner_public_ipv4":                             "1.2.3.6",
+			"__meta_hetzner_public_ipv6_network":                     "2001:db7::/64",
+			"__meta_hetzner_datacenter":                              "hel1-dc2",
+			"__meta_hetzner_hcloud_datacenter_location":              "hel1",
+			"__meta_hetzner_hcloud_datacenter_location_network_zone": "eu-central",
+			"__meta_hetzner_hcloud_server_type":                      "cpx11",
+			"__meta_hetzner_hcloud_cpu_cores":                        "2",
+			"__meta_hetzner_hcloud_cpu_type":                         "dedicated",
+			"__meta_hetzner_hcloud_memory_size_gb":                   "2",
+			"__meta_hetzner_hcloud_disk_size_gb":                     "40",
+		}),
+	}
+	discoveryutils.TestEqualLabelss(t, labelss, expectedLabelss)
+}
@@ -0,0 +1,50 @@
+package hetzner
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/proxy"
+)
+
+// SDCheckInterval defines interval for targets refresh.
+var SDCheckInterval = flag.Duration("promscrape.hetznerSDCheckInterval", time.Minute, "Interval for checking for changes in Hetzner API. "+
+	"This works only if hetzner_sd_configs is configured in '-promscrape.config' file. "+
+	"See https://docs.victoriametrics.com/sd_configs.html#hetzner_sd_configs for details")
+
+// SDConfig represents service discovery config for Hetzner Cloud and Hetzner Robot.
+//
+// See https://prometheus.io/docs/prometheus/latest/configuration/configuration/#hetzner_sd_config
+type SDConfig struct {
+	// Role must be either `hcloud` or `robot`.
+	Role              string                     `yaml:"role"`
+	Port              int                        `yaml:"port,omitempty"`
+	HTTPClientConfig  promauth.HTTPClientConfig  `yaml:",inline"`
+	ProxyURL          *proxy.URL                 `yaml:"proxy_url,omitempty"`
+	ProxyClientConfig promauth.ProxyClientConfig `yaml:",inline"`
+	// refresh_interval is obtained from `-promscrape.hetznerSDCheckInterval` command-line option.
+}
+
+// GetLabels returns Hetzner labels according to sdc.
+func (sdc *SDConfig) GetLabels(baseDir string) ([]*promutils.Labels, error) {
+	cfg, err := getAPIConfig(sdc, baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get API config: %w", err)
+	}
+	switch sdc.Role {
+	case "hcloud":
+		return getHCloudServerLabels(cfg)
+	case "robot":
+		return getRobotServerLabels(cfg)
+	default:
+		return nil, fmt.Errorf("skipping unexpected role=%q; must be one of `hcloud` or `robot`", sdc.Role)
+	}
+}
+
+// MustStop stops further usage for sdc.
+func (sdc *SDConfig) MustStop() {
+	configMap.Delete(sdc)
+}
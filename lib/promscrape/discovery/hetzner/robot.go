@@ -0,0 +1,91 @@
+package hetzner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+// robotServer represents the server object returned by Hetzner Robot API.
+//
+// See https://robot.your-server.de/doc/webservice/en.html#server
+type robotServer struct {
+	ServerIP     string        `json:"server_ip"`
+	ServerNumber int           `json:"server_number"`
+	ServerName   string        `json:"server_name"`
+	Product      string        `json:"product"`
+	DC           string        `json:"dc"`
+	Status       string        `json:"status"`
+	Cancelled    bool          `json:"cancelled"`
+	Subnet       []robotSubnet `json:"subnet"`
+}
+
+// robotSubnet represents subnet of Hetzner Robot server.
+type robotSubnet struct {
+	IP   string `json:"ip"`
+	Mask string `json:"mask"`
+}
+
+type robotServersListItem struct {
+	Server robotServer `json:"server"`
+}
+
+func getRobotServerLabels(cfg *apiConfig) ([]*promutils.Labels, error) {
+	servers, err := getRobotServers(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var ms []*promutils.Labels
+	for i := range servers {
+		ms = appendRobotTargetLabels(ms, &servers[i], cfg.port)
+	}
+	return ms, nil
+}
+
+func getRobotServers(cfg *apiConfig) ([]robotServer, error) {
+	data, err := cfg.client.GetAPIResponse("/server")
+	if err != nil {
+		return nil, fmt.Errorf("cannot query hetzner robot api for servers: %w", err)
+	}
+	return parseRobotServers(data)
+}
+
+func parseRobotServers(data []byte) ([]robotServer, error) {
+	var items []robotServersListItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal hetzner robot servers list %q: %w", data, err)
+	}
+	servers := make([]robotServer, 0, len(items))
+	for _, item := range items {
+		servers = append(servers, item.Server)
+	}
+	return servers, nil
+}
+
+func appendRobotTargetLabels(ms []*promutils.Labels, server *robotServer, port int) []*promutils.Labels {
+	addr := discoveryutils.JoinHostPort(server.ServerIP, port)
+	m := promutils.NewLabels(16)
+	m.Add("__address__", addr)
+	m.Add("__meta_hetzner_role", "robot")
+	m.Add("__meta_hetzner_server_id", strconv.Itoa(server.ServerNumber))
+	m.Add("__meta_hetzner_server_name", server.ServerName)
+	m.Add("__meta_hetzner_server_status", server.Status)
+	m.Add("__meta_hetzner_public_ipv4", server.ServerIP)
+	m.Add("__meta_hetzner_datacenter", strings.ToLower(server.DC))
+	m.Add("__meta_hetzner_robot_product", server.Product)
+	m.Add("__meta_hetzner_robot_cancelled", strconv.FormatBool(server.Cancelled))
+	for _, subnet := range server.Subnet {
+		ip := net.ParseIP(subnet.IP)
+		if ip != nil && ip.To4() == nil {
+			m.Add("__meta_hetzner_public_ipv6_network", fmt.Sprintf("%s/%s", subnet.IP, subnet.Mask))
+			break
+		}
+	}
+	ms = append(ms, m)
+	return ms
+}
@@ -0,0 +1,83 @@
+package hetzner
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+func TestGetRobotServerLabels(t *testing.T) {
+	cfg, stop := newTestAPIConfig(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/server" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `[
+  {
+    "server": {
+      "server_ip": "123.123.123.123",
+      "server_ipv6_net": "2a01:f48:111:4221::",
+      "server_number": 321,
+      "server_name": "server1",
+      "product": "DS 3000",
+      "dc": "NBG1-DC1",
+      "traffic": "5 TB",
+      "status": "ready",
+      "cancelled": false,
+      "paid_until": "2010-09-02",
+      "ip": ["123.123.123.123"],
+      "subnet": [
+        {"ip": "2a01:4f8:111:4221::", "mask": "64"}
+      ]
+    }
+  },
+  {
+    "server": {
+      "server_ip": "123.123.123.124",
+      "server_number": 421,
+      "server_name": "server2",
+      "product": "X5",
+      "dc": "FSN1-DC10",
+      "status": "in process",
+      "cancelled": true,
+      "subnet": null
+    }
+  }
+]`)
+	})
+	defer stop()
+
+	labelss, err := getRobotServerLabels(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expectedLabelss := []*promutils.Labels{
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                        "123.123.123.123:9100",
+			"__meta_hetzner_role":                "robot",
+			"__meta_hetzner_server_id":           "321",
+			"__meta_hetzner_server_name":         "server1",
+			"__meta_hetzner_server_status":       "ready",
+			"__meta_hetzner_public_ipv4":         "123.123.123.123",
+			"__meta_hetzner_public_ipv6_network": "2a01:4f8:111:4221::/64",
+			"__meta_hetzner_datacenter":          "nbg1-dc1",
+			"__meta_hetzner_robot_product":       "DS 3000",
+			"__meta_hetzner_robot_cancelled":     "false",
+		}),
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                    "123.123.123.124:9100",
+			"__meta_hetzner_role":            "robot",
+			"__meta_hetzner_server_id":       "421",
+			"__meta_hetzner_server_name":     "server2",
+			"__meta_hetzner_server_status":   "in process",
+			"__meta_hetzner_public_ipv4":     "123.123.123.124",
+			"__meta_hetzner_datacenter":      "fsn1-dc10",
+			"__meta_hetzner_robot_product":   "X5",
+			"__meta_hetzner_robot_cancelled": "true",
+		}),
+	}
+	discoveryutils.TestEqualLabelss(t, labelss, expectedLabelss)
+}
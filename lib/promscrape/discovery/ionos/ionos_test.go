@@ -0,0 +1,107 @@
+package ionos
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+func TestGetLabels(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/datacenters/8feda53f-15f0-447f-badf-ebe32dad2fc0/servers" || r.URL.Query().Get("depth") != "3" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{
+  "id": "8feda53f-15f0-447f-badf-ebe32dad2fc0/servers",
+  "type": "collection",
+  "items": [
+    {
+      "id": "d6bf44ee-f7e8-4e19-8716-96fdd18cc697",
+      "type": "server",
+      "metadata": {"state": "AVAILABLE"},
+      "properties": {
+        "name": "prometheus-2",
+        "cores": 2,
+        "ram": 4096,
+        "availabilityZone": "ZONE_1",
+        "vmState": "RUNNING",
+        "bootCdrom": null,
+        "bootVolume": {"id": "6e3d6f87-1dcf-4a4a-9b24-22d3e4e6b4f5"},
+        "cpuFamily": "INTEL_SKYLAKE",
+        "type": "ENTERPRISE"
+      },
+      "entities": {
+        "volumes": {
+          "items": [
+            {"id": "6e3d6f87-1dcf-4a4a-9b24-22d3e4e6b4f5", "properties": {"image": "0e4d57f9-cd78-11e9-b88c-525400f64d8d"}}
+          ]
+        },
+        "nics": {
+          "items": [
+            {"id": "1", "properties": {"name": "metrics", "ips": ["85.215.243.177", "85.215.243.178"]}},
+            {"id": "2", "properties": {"name": "Private", "ips": ["10.7.222.5"]}}
+          ]
+        }
+      }
+    },
+    {
+      "id": "b501942c-4e08-43e6-8ec1-00e59c64e0e4",
+      "type": "server",
+      "metadata": {"state": "BUSY"},
+      "properties": {
+        "name": "no-ips",
+        "availabilityZone": "AUTO",
+        "vmState": "SHUTOFF",
+        "type": "CUBE"
+      },
+      "entities": {
+        "nics": {"items": []}
+      }
+    }
+  ]
+}`)
+	}))
+	defer s.Close()
+
+	sdc := &SDConfig{
+		DatacenterID: "8feda53f-15f0-447f-badf-ebe32dad2fc0",
+		Port:         9100,
+		Server:       s.URL,
+	}
+	labelss, err := sdc.GetLabels("")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer sdc.MustStop()
+	expectedLabelss := []*promutils.Labels{
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                           "10.7.222.5:9100",
+			"__meta_ionos_server_availability_zone": "ZONE_1",
+			"__meta_ionos_server_boot_image_id":     "0e4d57f9-cd78-11e9-b88c-525400f64d8d",
+			"__meta_ionos_server_boot_volume_id":    "6e3d6f87-1dcf-4a4a-9b24-22d3e4e6b4f5",
+			"__meta_ionos_server_cpu_family":        "INTEL_SKYLAKE",
+			"__meta_ionos_server_id":                "d6bf44ee-f7e8-4e19-8716-96fdd18cc697",
+			"__meta_ionos_server_ip":                ",10.7.222.5,85.215.243.177,85.215.243.178,",
+			"__meta_ionos_server_lifecycle":         "RUNNING",
+			"__meta_ionos_server_name":              "prometheus-2",
+			"__meta_ionos_server_nic_ip_metrics":    "85.215.243.177,85.215.243.178",
+			"__meta_ionos_server_nic_ip_private":    "10.7.222.5",
+			"__meta_ionos_server_servers_id":        "8feda53f-15f0-447f-badf-ebe32dad2fc0",
+			"__meta_ionos_server_state":             "AVAILABLE",
+			"__meta_ionos_server_type":              "ENTERPRISE",
+		}),
+	}
+	discoveryutils.TestEqualLabelss(t, labelss, expectedLabelss)
+}
+
+func TestNewAPIConfigFailure(t *testing.T) {
+	sdc := &SDConfig{}
+	if _, err := newAPIConfig(sdc, ""); err == nil {
+		t.Fatalf("expecting non-nil error for missing datacenter_id")
+	}
+}
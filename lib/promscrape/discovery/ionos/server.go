@@ -0,0 +1,146 @@
+package ionos
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+// serverList represents the list of servers returned by IONOS Cloud API.
+//
+// See https://api.ionos.com/docs/cloud/v6/#tag/Servers/operation/datacentersServersGet
+type serverList struct {
+	Items []server `json:"items"`
+}
+
+// server represents IONOS Cloud server.
+type server struct {
+	ID         string           `json:"id"`
+	Properties serverProperties `json:"properties"`
+	Metadata   serverMetadata   `json:"metadata"`
+	Entities   serverEntities   `json:"entities"`
+}
+
+type serverProperties struct {
+	Name             string      `json:"name"`
+	Type             string      `json:"type"`
+	AvailabilityZone string      `json:"availabilityZone"`
+	VMState          string      `json:"vmState"`
+	CPUFamily        string      `json:"cpuFamily"`
+	BootCdrom        *resourceID `json:"bootCdrom"`
+	BootVolume       *resourceID `json:"bootVolume"`
+}
+
+type resourceID struct {
+	ID string `json:"id"`
+}
+
+type serverMetadata struct {
+	State string `json:"state"`
+}
+
+type serverEntities struct {
+	NICs    nicList    `json:"nics"`
+	Volumes volumeList `json:"volumes"`
+}
+
+type nicList struct {
+	Items []nic `json:"items"`
+}
+
+type nic struct {
+	Properties nicProperties `json:"properties"`
+}
+
+type nicProperties struct {
+	Name string   `json:"name"`
+	IPs  []string `json:"ips"`
+}
+
+type volumeList struct {
+	Items []volume `json:"items"`
+}
+
+type volume struct {
+	ID         string           `json:"id"`
+	Properties volumeProperties `json:"properties"`
+}
+
+type volumeProperties struct {
+	Image string `json:"image"`
+}
+
+func getServers(cfg *apiConfig) ([]server, error) {
+	path := fmt.Sprintf("/datacenters/%s/servers?depth=3", url.PathEscape(cfg.datacenterID))
+	data, err := cfg.client.GetAPIResponse(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot query ionos api for servers in datacenter %q: %w", cfg.datacenterID, err)
+	}
+	return parseServerList(data)
+}
+
+func parseServerList(data []byte) ([]server, error) {
+	var sl serverList
+	if err := json.Unmarshal(data, &sl); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal ionos servers list %q: %w", data, err)
+	}
+	return sl.Items, nil
+}
+
+func getServersLabels(servers []server, datacenterID string, port int) []*promutils.Labels {
+	var ms []*promutils.Labels
+	for i := range servers {
+		ms = appendServerLabels(ms, &servers[i], datacenterID, port)
+	}
+	return ms
+}
+
+func appendServerLabels(ms []*promutils.Labels, s *server, datacenterID string, port int) []*promutils.Labels {
+	var ips []string
+	nicIPs := make(map[string][]string)
+	for _, n := range s.Entities.NICs.Items {
+		// IPs of the later NICs go first in order to be consistent with Prometheus.
+		ips = append(append([]string{}, n.Properties.IPs...), ips...)
+		if len(n.Properties.IPs) > 0 {
+			name := n.Properties.Name
+			nicIPs[name] = append(nicIPs[name], n.Properties.IPs...)
+		}
+	}
+	if len(ips) == 0 {
+		// Skip servers without IP addresses, since they cannot be scraped.
+		return ms
+	}
+	m := promutils.NewLabels(16)
+	m.Add("__address__", discoveryutils.JoinHostPort(ips[0], port))
+	m.Add("__meta_ionos_server_availability_zone", s.Properties.AvailabilityZone)
+	m.Add("__meta_ionos_server_cpu_family", s.Properties.CPUFamily)
+	m.Add("__meta_ionos_server_id", s.ID)
+	m.Add("__meta_ionos_server_ip", ","+strings.Join(ips, ",")+",")
+	m.Add("__meta_ionos_server_lifecycle", s.Properties.VMState)
+	m.Add("__meta_ionos_server_name", s.Properties.Name)
+	m.Add("__meta_ionos_server_servers_id", datacenterID)
+	m.Add("__meta_ionos_server_state", s.Metadata.State)
+	m.Add("__meta_ionos_server_type", s.Properties.Type)
+	if s.Properties.BootCdrom != nil {
+		m.Add("__meta_ionos_server_boot_cdrom_id", s.Properties.BootCdrom.ID)
+	}
+	if s.Properties.BootVolume != nil {
+		bootVolumeID := s.Properties.BootVolume.ID
+		m.Add("__meta_ionos_server_boot_volume_id", bootVolumeID)
+		for _, v := range s.Entities.Volumes.Items {
+			if v.ID == bootVolumeID && v.Properties.Image != "" {
+				m.Add("__meta_ionos_server_boot_image_id", v.Properties.Image)
+			}
+		}
+	}
+	for name, nips := range nicIPs {
+		labelName := discoveryutils.SanitizeLabelName("__meta_ionos_server_nic_ip_" + strings.ToLower(name))
+		m.Add(labelName, strings.Join(nips, ","))
+	}
+	ms = append(ms, m)
+	return ms
+}
@@ -0,0 +1,96 @@
+package linode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+)
+
+var configMap = discoveryutils.NewConfigMap()
+
+type apiConfig struct {
+	client       *discoveryutils.Client
+	port         int
+	tagSeparator string
+}
+
+func getAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	v, err := configMap.Get(sdc, func() (interface{}, error) { return newAPIConfig(sdc, baseDir) })
+	if err != nil {
+		return nil, err
+	}
+	return v.(*apiConfig), nil
+}
+
+func newAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	ac, err := sdc.HTTPClientConfig.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse auth config: %w", err)
+	}
+	apiServer := sdc.Server
+	if apiServer == "" {
+		apiServer = "https://api.linode.com"
+	}
+	if !strings.Contains(apiServer, "://") {
+		scheme := "http"
+		if sdc.HTTPClientConfig.TLSConfig != nil {
+			scheme = "https"
+		}
+		apiServer = scheme + "://" + apiServer
+	}
+	proxyAC, err := sdc.ProxyClientConfig.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse proxy auth config: %w", err)
+	}
+	client, err := discoveryutils.NewClient(apiServer, ac, sdc.ProxyURL, proxyAC)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create HTTP client for %q: %w", apiServer, err)
+	}
+	port := sdc.Port
+	if port == 0 {
+		port = 80
+	}
+	tagSeparator := ","
+	if sdc.TagSeparator != nil {
+		tagSeparator = *sdc.TagSeparator
+	}
+	cfg := &apiConfig{
+		client:       client,
+		port:         port,
+		tagSeparator: tagSeparator,
+	}
+	return cfg, nil
+}
+
+// listResponse is a generic paginated response from Linode API.
+//
+// See https://www.linode.com/docs/api/#pagination
+type listResponse struct {
+	Data  json.RawMessage `json:"data"`
+	Page  int             `json:"page"`
+	Pages int             `json:"pages"`
+}
+
+// getAllPages calls f for the data from every page returned by Linode API at the given path.
+func getAllPages(cfg *apiConfig, path string, f func(data []byte) error) error {
+	page := 1
+	for {
+		data, err := cfg.client.GetAPIResponse(fmt.Sprintf("%s?page=%d&page_size=500", path, page))
+		if err != nil {
+			return fmt.Errorf("cannot query linode api at %q: %w", path, err)
+		}
+		var resp listResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return fmt.Errorf("cannot unmarshal linode api response from %q: %w; response: %q", path, err, data)
+		}
+		if err := f(resp.Data); err != nil {
+			return fmt.Errorf("cannot parse linode api response from %q: %w", path, err)
+		}
+		if resp.Page >= resp.Pages {
+			return nil
+		}
+		page++
+	}
+}
@@ -0,0 +1,201 @@
+package linode
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+// instance represents Linode instance.
+//
+// See https://www.linode.com/docs/api/linode-instances/#linodes-list
+type instance struct {
+	ID         int      `json:"id"`
+	Label      string   `json:"label"`
+	Image      string   `json:"image"`
+	Region     string   `json:"region"`
+	Type       string   `json:"type"`
+	Status     string   `json:"status"`
+	Group      string   `json:"group"`
+	Hypervisor string   `json:"hypervisor"`
+	Tags       []string `json:"tags"`
+	IPv4       []string `json:"ipv4"`
+	IPv6       string   `json:"ipv6"`
+	Backups    struct {
+		Enabled bool `json:"enabled"`
+	} `json:"backups"`
+	Specs struct {
+		Disk     int `json:"disk"`
+		Memory   int `json:"memory"`
+		VCPUs    int `json:"vcpus"`
+		GPUs     int `json:"gpus"`
+		Transfer int `json:"transfer"`
+	} `json:"specs"`
+}
+
+// ipAddress represents Linode IP address.
+//
+// See https://www.linode.com/docs/api/networking/#ip-addresses-list
+type ipAddress struct {
+	Address string `json:"address"`
+	Public  bool   `json:"public"`
+	RDNS    string `json:"rdns"`
+}
+
+// ipv6Range represents Linode IPv6 range.
+//
+// See https://www.linode.com/docs/api/networking/#ipv6-ranges-list
+type ipv6Range struct {
+	Range       string `json:"range"`
+	Prefix      int    `json:"prefix"`
+	RouteTarget string `json:"route_target"`
+}
+
+func getInstances(cfg *apiConfig) ([]instance, error) {
+	var instances []instance
+	err := getAllPages(cfg, "/v4/linode/instances", func(data []byte) error {
+		var page []instance
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		instances = append(instances, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return instances, nil
+}
+
+func getIPAddresses(cfg *apiConfig) ([]ipAddress, error) {
+	var ips []ipAddress
+	err := getAllPages(cfg, "/v4/networking/ips", func(data []byte) error {
+		var page []ipAddress
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		ips = append(ips, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ips, nil
+}
+
+func getIPv6Ranges(cfg *apiConfig) ([]ipv6Range, error) {
+	var ranges []ipv6Range
+	err := getAllPages(cfg, "/v4/networking/ipv6/ranges", func(data []byte) error {
+		var page []ipv6Range
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		ranges = append(ranges, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ranges, nil
+}
+
+func addInstanceLabels(instances []instance, ips []ipAddress, ranges []ipv6Range, port int, tagSeparator string) []*promutils.Labels {
+	ipsByAddr := make(map[string]*ipAddress, len(ips))
+	for i := range ips {
+		ipsByAddr[ips[i].Address] = &ips[i]
+	}
+	// IPv6 ranges are routed to the SLAAC address of the instance.
+	rangesByTarget := make(map[string][]string)
+	for _, r := range ranges {
+		rangesByTarget[r.RouteTarget] = append(rangesByTarget[r.RouteTarget], r.Range+"/"+strconv.Itoa(r.Prefix))
+	}
+	var ms []*promutils.Labels
+	for i := range instances {
+		inst := &instances[i]
+		if len(inst.IPv4) == 0 {
+			continue
+		}
+		var privateIPv4, publicIPv4, publicIPv6 string
+		var privateIPv4RDNS, publicIPv4RDNS, publicIPv6RDNS string
+		var extraIPs []string
+		for _, addr := range inst.IPv4 {
+			ip := ipsByAddr[addr]
+			if ip == nil {
+				continue
+			}
+			switch {
+			case ip.Public && publicIPv4 == "":
+				publicIPv4 = ip.Address
+				publicIPv4RDNS = getRDNS(ip)
+			case !ip.Public && privateIPv4 == "":
+				privateIPv4 = ip.Address
+				privateIPv4RDNS = getRDNS(ip)
+			default:
+				extraIPs = append(extraIPs, ip.Address)
+			}
+		}
+		var ipv6Ranges []string
+		if inst.IPv6 != "" {
+			addr := inst.IPv6
+			if n := strings.IndexByte(addr, '/'); n >= 0 {
+				addr = addr[:n]
+			}
+			if ip := ipsByAddr[addr]; ip != nil {
+				publicIPv6 = ip.Address
+				publicIPv6RDNS = getRDNS(ip)
+			}
+			ipv6Ranges = rangesByTarget[addr]
+		}
+		backups := "disabled"
+		if inst.Backups.Enabled {
+			backups = "enabled"
+		}
+
+		m := promutils.NewLabels(24)
+		// Use the first IPv4 address of the instance in the same way as Prometheus does,
+		// so the address is set even if the IP is missing in /v4/networking/ips response.
+		m.Add("__address__", discoveryutils.JoinHostPort(inst.IPv4[0], port))
+		m.Add("__meta_linode_instance_id", strconv.Itoa(inst.ID))
+		m.Add("__meta_linode_instance_label", inst.Label)
+		m.Add("__meta_linode_image", inst.Image)
+		m.Add("__meta_linode_private_ipv4", privateIPv4)
+		m.Add("__meta_linode_public_ipv4", publicIPv4)
+		m.Add("__meta_linode_public_ipv6", publicIPv6)
+		m.Add("__meta_linode_private_ipv4_rdns", privateIPv4RDNS)
+		m.Add("__meta_linode_public_ipv4_rdns", publicIPv4RDNS)
+		m.Add("__meta_linode_public_ipv6_rdns", publicIPv6RDNS)
+		m.Add("__meta_linode_region", inst.Region)
+		m.Add("__meta_linode_type", inst.Type)
+		m.Add("__meta_linode_status", inst.Status)
+		m.Add("__meta_linode_group", inst.Group)
+		m.Add("__meta_linode_hypervisor", inst.Hypervisor)
+		m.Add("__meta_linode_backups", backups)
+		// Linode API returns specs in megabytes, while Prometheus exposes them in bytes.
+		m.Add("__meta_linode_specs_disk_bytes", strconv.FormatInt(int64(inst.Specs.Disk)<<20, 10))
+		m.Add("__meta_linode_specs_memory_bytes", strconv.FormatInt(int64(inst.Specs.Memory)<<20, 10))
+		m.Add("__meta_linode_specs_vcpus", strconv.Itoa(inst.Specs.VCPUs))
+		m.Add("__meta_linode_gpus", strconv.Itoa(inst.Specs.GPUs))
+		m.Add("__meta_linode_specs_transfer_bytes", strconv.FormatInt(int64(inst.Specs.Transfer)<<20, 10))
+		if len(inst.Tags) > 0 {
+			m.Add("__meta_linode_tags", tagSeparator+strings.Join(inst.Tags, tagSeparator)+tagSeparator)
+		}
+		if len(extraIPs) > 0 {
+			m.Add("__meta_linode_extra_ips", tagSeparator+strings.Join(extraIPs, tagSeparator)+tagSeparator)
+		}
+		if len(ipv6Ranges) > 0 {
+			m.Add("__meta_linode_ipv6_ranges", tagSeparator+strings.Join(ipv6Ranges, tagSeparator)+tagSeparator)
+		}
+		ms = append(ms, m)
+	}
+	return ms
+}
+
+func getRDNS(ip *ipAddress) string {
+	if ip.RDNS == "null" {
+		return ""
+	}
+	return ip.RDNS
+}
@@ -0,0 +1,202 @@
+package linode
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+func TestGetLabels(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer some-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v4/linode/instances":
+			switch r.URL.Query().Get("page") {
+			case "1":
+				fmt.Fprint(w, `{
+  "data": [
+    {
+      "id": 26838044,
+      "label": "prometheus-linode-sd-exporter-1",
+      "group": "",
+      "status": "running",
+      "type": "g6-standard-2",
+      "ipv4": ["45.33.82.151", "96.126.108.376", "192.168.170.51", "192.168.201.25"],
+      "ipv6": "2600:3c03::f03c:92ff:fe1a:1382/128",
+      "image": "linode/arch",
+      "region": "us-east",
+      "specs": {"disk": 81920, "memory": 4096, "vcpus": 2, "gpus": 1, "transfer": 4000},
+      "hypervisor": "kvm",
+      "backups": {"enabled": false},
+      "tags": ["monitoring"]
+    }
+  ],
+  "page": 1,
+  "pages": 2,
+  "results": 2
+}`)
+			case "2":
+				fmt.Fprint(w, `{
+  "data": [
+    {
+      "id": 26837938,
+      "label": "prometheus-linode-sd-exporter-2",
+      "group": "ungrouped",
+      "status": "offline",
+      "type": "g6-nanode-1",
+      "ipv4": ["45.33.80.31"],
+      "ipv6": "",
+      "image": "linode/ubuntu20.04",
+      "region": "ca-central",
+      "specs": {"disk": 25600, "memory": 1024, "vcpus": 1, "gpus": 0, "transfer": 1000},
+      "hypervisor": "kvm",
+      "backups": {"enabled": true},
+      "tags": []
+    },
+    {
+      "id": 2,
+      "label": "unknown-ip",
+      "status": "running",
+      "ipv4": ["45.33.80.99"],
+      "region": "ca-central",
+      "specs": {"disk": 25600, "memory": 1024, "vcpus": 1, "gpus": 0, "transfer": 1000}
+    },
+    {
+      "id": 1,
+      "label": "no-ipv4",
+      "ipv4": []
+    }
+  ],
+  "page": 2,
+  "pages": 2,
+  "results": 2
+}`)
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		case "/v4/networking/ips":
+			fmt.Fprint(w, `{
+  "data": [
+    {"address": "45.33.82.151", "type": "ipv4", "public": true, "rdns": "li1028-151.members.linode.com", "linode_id": 26838044},
+    {"address": "96.126.108.376", "type": "ipv4", "public": true, "rdns": "null", "linode_id": 26838044},
+    {"address": "192.168.170.51", "type": "ipv4", "public": false, "rdns": null, "linode_id": 26838044},
+    {"address": "192.168.201.25", "type": "ipv4", "public": false, "rdns": null, "linode_id": 26838044},
+    {"address": "2600:3c03::f03c:92ff:fe1a:1382", "type": "ipv6", "public": true, "rdns": "", "linode_id": 26838044},
+    {"address": "45.33.80.31", "type": "ipv4", "public": true, "rdns": "li1132-31.members.linode.com", "linode_id": 26837938}
+  ],
+  "page": 1,
+  "pages": 1,
+  "results": 6
+}`)
+		case "/v4/networking/ipv6/ranges":
+			fmt.Fprint(w, `{
+  "data": [
+    {"range": "2600:3c03:e000:123::", "prefix": 64, "region": "us-east", "route_target": "2600:3c03::f03c:92ff:fe1a:1382"},
+    {"range": "2600:3c03:e000:456::", "prefix": 56, "region": "us-east", "route_target": "2600:3c03::f03c:92ff:fe1a:1382"},
+    {"range": "2600:3c03:e000:789::", "prefix": 64, "region": "us-east", "route_target": "2600:3c03::f03c:92ff:fe1a:9999"}
+  ],
+  "page": 1,
+  "pages": 1,
+  "results": 3
+}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+
+	sdc := &SDConfig{
+		Server: s.URL,
+		Port:   9100,
+	}
+	sdc.HTTPClientConfig.BearerToken = promauth.NewSecret("some-token")
+	labelss, err := sdc.GetLabels("")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer sdc.MustStop()
+
+	expectedLabelss := []*promutils.Labels{
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                        "45.33.82.151:9100",
+			"__meta_linode_instance_id":          "26838044",
+			"__meta_linode_instance_label":       "prometheus-linode-sd-exporter-1",
+			"__meta_linode_image":                "linode/arch",
+			"__meta_linode_private_ipv4":         "192.168.170.51",
+			"__meta_linode_public_ipv4":          "45.33.82.151",
+			"__meta_linode_public_ipv6":          "2600:3c03::f03c:92ff:fe1a:1382",
+			"__meta_linode_private_ipv4_rdns":    "",
+			"__meta_linode_public_ipv4_rdns":     "li1028-151.members.linode.com",
+			"__meta_linode_public_ipv6_rdns":     "",
+			"__meta_linode_region":               "us-east",
+			"__meta_linode_type":                 "g6-standard-2",
+			"__meta_linode_status":               "running",
+			"__meta_linode_group":                "",
+			"__meta_linode_hypervisor":           "kvm",
+			"__meta_linode_backups":              "disabled",
+			"__meta_linode_specs_disk_bytes":     "85899345920",
+			"__meta_linode_specs_memory_bytes":   "4294967296",
+			"__meta_linode_specs_vcpus":          "2",
+			"__meta_linode_gpus":                 "1",
+			"__meta_linode_ipv6_ranges":          ",2600:3c03:e000:123::/64,2600:3c03:e000:456::/56,",
+			"__meta_linode_specs_transfer_bytes": "4194304000",
+			"__meta_linode_tags":                 ",monitoring,",
+			"__meta_linode_extra_ips":            ",96.126.108.376,192.168.201.25,",
+		}),
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                        "45.33.80.31:9100",
+			"__meta_linode_instance_id":          "26837938",
+			"__meta_linode_instance_label":       "prometheus-linode-sd-exporter-2",
+			"__meta_linode_image":                "linode/ubuntu20.04",
+			"__meta_linode_private_ipv4":         "",
+			"__meta_linode_public_ipv4":          "45.33.80.31",
+			"__meta_linode_public_ipv6":          "",
+			"__meta_linode_private_ipv4_rdns":    "",
+			"__meta_linode_public_ipv4_rdns":     "li1132-31.members.linode.com",
+			"__meta_linode_public_ipv6_rdns":     "",
+			"__meta_linode_region":               "ca-central",
+			"__meta_linode_type":                 "g6-nanode-1",
+			"__meta_linode_status":               "offline",
+			"__meta_linode_group":                "ungrouped",
+			"__meta_linode_hypervisor":           "kvm",
+			"__meta_linode_backups":              "enabled",
+			"__meta_linode_specs_disk_bytes":     "26843545600",
+			"__meta_linode_specs_memory_bytes":   "1073741824",
+			"__meta_linode_specs_vcpus":          "1",
+			"__meta_linode_gpus":                 "0",
+			"__meta_linode_specs_transfer_bytes": "1048576000",
+		}),
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                        "45.33.80.99:9100",
+			"__meta_linode_instance_id":          "2",
+			"__meta_linode_instance_label":       "unknown-ip",
+			"__meta_linode_image":                "",
+			"__meta_linode_private_ipv4":         "",
+			"__meta_linode_public_ipv4":          "",
+			"__meta_linode_public_ipv6":          "",
+			"__meta_linode_private_ipv4_rdns":    "",
+			"__meta_linode_public_ipv4_rdns":     "",
+			"__meta_linode_public_ipv6_rdns":     "",
+			"__meta_linode_region":               "ca-central",
+			"__meta_linode_type":                 "",
+			"__meta_linode_status":               "running",
+			"__meta_linode_group":                "",
+			"__meta_linode_hypervisor":           "",
+			"__meta_linode_backups":              "disabled",
+			"__meta_linode_specs_disk_bytes":     "26843545600",
+			"__meta_linode_specs_memory_bytes":   "1073741824",
+			"__meta_linode_specs_vcpus":          "1",
+			"__meta_linode_gpus":                 "0",
+			"__meta_linode_specs_transfer_bytes": "1048576000",
+		}),
+	}
+	discoveryutils.TestEqualLabelss(t, labelss, expectedLabelss)
+}
@@ -0,0 +1,175 @@
+package marathon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/valyala/fastrand"
+)
+
+var configMap = discoveryutils.NewConfigMap()
+
+type apiConfig struct {
+	clients   []*discoveryutils.Client
+	authToken string
+}
+
+func (cfg *apiConfig) mustStop() {
+	for _, c := range cfg.clients {
+		c.Stop()
+	}
+}
+
+func getAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	v, err := configMap.Get(sdc, func() (interface{}, error) { return newAPIConfig(sdc, baseDir) })
+	if err != nil {
+		return nil, err
+	}
+	return v.(*apiConfig), nil
+}
+
+func newAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	if len(sdc.Servers) == 0 {
+		return nil, fmt.Errorf("`servers` cannot be empty")
+	}
+	var authToken string
+	if sdc.AuthToken != nil {
+		if sdc.AuthTokenFile != "" {
+			return nil, fmt.Errorf("cannot set both `auth_token` and `auth_token_file`")
+		}
+		authToken = sdc.AuthToken.String()
+	} else if sdc.AuthTokenFile != "" {
+		path := fs.GetFilepath(baseDir, sdc.AuthTokenFile)
+		data, err := fs.ReadFileOrHTTP(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read `auth_token_file` %q: %w", sdc.AuthTokenFile, err)
+		}
+		authToken = strings.TrimSpace(string(data))
+	}
+	hcc := &sdc.HTTPClientConfig
+	if authToken != "" && (hcc.Authorization != nil || hcc.BasicAuth != nil || hcc.BearerToken != nil || hcc.BearerTokenFile != "") {
+		return nil, fmt.Errorf("`auth_token` and `auth_token_file` cannot be set together with other auth options")
+	}
+	ac, err := hcc.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse auth config: %w", err)
+	}
+	proxyAC, err := sdc.ProxyClientConfig.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse proxy auth config: %w", err)
+	}
+	cfg := &apiConfig{
+		authToken: authToken,
+	}
+	for _, apiServer := range sdc.Servers {
+		apiServer = strings.TrimSuffix(apiServer, "/")
+		if !strings.Contains(apiServer, "://") {
+			scheme := "http"
+			if hcc.TLSConfig != nil {
+				scheme = "https"
+			}
+			apiServer = scheme + "://" + apiServer
+		}
+		client, err := discoveryutils.NewClient(apiServer, ac, sdc.ProxyURL, proxyAC)
+		if err != nil {
+			cfg.mustStop()
+			return nil, fmt.Errorf("cannot create HTTP client for %q: %w", apiServer, err)
+		}
+		cfg.clients = append(cfg.clients, client)
+	}
+	return cfg, nil
+}
+
+// getAPIResponse returns the response for the given path from randomly chosen Marathon server.
+//
+// Other servers are tried on error.
+func (cfg *apiConfig) getAPIResponse(path string) ([]byte, error) {
+	var modifyRequest func(req *http.Request)
+	if cfg.authToken != "" {
+		modifyRequest = func(req *http.Request) {
+			req.Header.Set("Authorization", "token="+cfg.authToken)
+		}
+	}
+	n := int(fastrand.Uint32n(uint32(len(cfg.clients))))
+	var errs []string
+	for i := range cfg.clients {
+		c := cfg.clients[(n+i)%len(cfg.clients)]
+		data, err := c.GetAPIResponseWithReqParams(path, modifyRequest)
+		if err == nil {
+			return data, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return nil, fmt.Errorf("cannot obtain response from any of Marathon servers: %s", strings.Join(errs, "; "))
+}
+
+// See https://mesosphere.github.io/marathon/api-console/index.html#!/apps/get_v2_apps
+type appList struct {
+	Apps []app `json:"apps"`
+}
+
+type app struct {
+	ID              string            `json:"id"`
+	Tasks           []task            `json:"tasks"`
+	Labels          map[string]string `json:"labels"`
+	Container       container         `json:"container"`
+	PortDefinitions []portDefinition  `json:"portDefinitions"`
+	Networks        []network         `json:"networks"`
+	RequirePorts    bool              `json:"requirePorts"`
+}
+
+func (a *app) isContainerNet() bool {
+	return len(a.Networks) > 0 && a.Networks[0].Mode == "container"
+}
+
+type task struct {
+	ID          string      `json:"id"`
+	Host        string      `json:"host"`
+	Ports       []int       `json:"ports"`
+	IPAddresses []ipAddress `json:"ipAddresses"`
+}
+
+type ipAddress struct {
+	Address string `json:"ipAddress"`
+}
+
+type container struct {
+	Docker       dockerContainer `json:"docker"`
+	PortMappings []portMapping   `json:"portMappings"`
+}
+
+type dockerContainer struct {
+	Image        string        `json:"image"`
+	PortMappings []portMapping `json:"portMappings"`
+}
+
+type portMapping struct {
+	Labels        map[string]string `json:"labels"`
+	ContainerPort int               `json:"containerPort"`
+	HostPort      int               `json:"hostPort"`
+}
+
+type portDefinition struct {
+	Labels map[string]string `json:"labels"`
+	Port   int               `json:"port"`
+}
+
+type network struct {
+	Mode string `json:"mode"`
+}
+
+func getApps(cfg *apiConfig) ([]app, error) {
+	data, err := cfg.getAPIResponse("/v2/apps/?embed=apps.tasks")
+	if err != nil {
+		return nil, fmt.Errorf("cannot obtain Marathon apps: %w", err)
+	}
+	var al appList
+	if err := json.Unmarshal(data, &al); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal Marathon apps response %q: %w", data, err)
+	}
+	return al.Apps, nil
+}
@@ -0,0 +1,96 @@
+package marathon
+
+import (
+	"strconv"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+func addAppsLabels(apps []app) []*promutils.Labels {
+	var ms []*promutils.Labels
+	for i := range apps {
+		ms = appendAppLabels(ms, &apps[i])
+	}
+	return ms
+}
+
+// appendAppLabels appends labels for every port of every task in the given app to ms.
+//
+// The logic follows https://github.com/prometheus/prometheus/blob/main/discovery/marathon/marathon.go
+func appendAppLabels(ms []*promutils.Labels, a *app) []*promutils.Labels {
+	var ports []int
+	var portLabels []map[string]string
+	var prefix string
+	switch {
+	case len(a.Container.PortMappings) > 0:
+		// Marathon 1.5.x moved `container.docker.portMappings` to `container.portMappings`.
+		ports, portLabels = extractPortMappings(a.Container.PortMappings, a.isContainerNet())
+		prefix = "__meta_marathon_port_mapping_label_"
+	case len(a.Container.Docker.PortMappings) > 0:
+		ports, portLabels = extractPortMappings(a.Container.Docker.PortMappings, a.isContainerNet())
+		prefix = "__meta_marathon_port_mapping_label_"
+	case len(a.PortDefinitions) > 0:
+		ports = make([]int, len(a.PortDefinitions))
+		portLabels = make([]map[string]string, len(a.PortDefinitions))
+		for i, pd := range a.PortDefinitions {
+			portLabels[i] = pd.Labels
+			// When requirePorts is false, the port becomes the service port instead of the listen port.
+			// In this case the port must be taken from the task.
+			if a.RequirePorts {
+				ports[i] = pd.Port
+			}
+		}
+		prefix = "__meta_marathon_port_definition_label_"
+	}
+
+	for _, t := range a.Tasks {
+		// Ports can be obtained only from the task if they aren't defined at app level (e.g. for host networking).
+		// They are guaranteed to be the same across all the tasks.
+		if len(ports) == 0 && len(t.Ports) > 0 {
+			ports = t.Ports
+		}
+		for i, port := range ports {
+			// Zero port means it is auto-generated by Mesos, so it must be obtained from the task.
+			if port == 0 && len(t.Ports) == len(ports) {
+				port = t.Ports[i]
+			}
+			host := t.Host
+			if a.isContainerNet() && len(t.IPAddresses) > 0 {
+				host = t.IPAddresses[0].Address
+			}
+			m := promutils.NewLabels(8)
+			m.Add("__address__", discoveryutils.JoinHostPort(host, port))
+			m.Add("__meta_marathon_app", a.ID)
+			m.Add("__meta_marathon_image", a.Container.Docker.Image)
+			m.Add("__meta_marathon_task", t.ID)
+			m.Add("__meta_marathon_port_index", strconv.Itoa(i))
+			for k, v := range a.Labels {
+				m.Add(discoveryutils.SanitizeLabelName("__meta_marathon_app_label_"+k), v)
+			}
+			if len(portLabels) > 0 {
+				for k, v := range portLabels[i] {
+					m.Add(discoveryutils.SanitizeLabelName(prefix+k), v)
+				}
+			}
+			ms = append(ms, m)
+		}
+	}
+	return ms
+}
+
+func extractPortMappings(pms []portMapping, containerNet bool) ([]int, []map[string]string) {
+	ports := make([]int, len(pms))
+	labels := make([]map[string]string, len(pms))
+	for i, pm := range pms {
+		labels[i] = pm.Labels
+		if containerNet {
+			// Connect directly to the container port if the app is in a container network.
+			ports[i] = pm.ContainerPort
+		} else {
+			// Otherwise connect to the allocated host port. It may be zero, which means it must be obtained from the task.
+			ports[i] = pm.HostPort
+		}
+	}
+	return ports, labels
+}
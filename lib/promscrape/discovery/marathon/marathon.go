@@ -0,0 +1,52 @@
+package marathon
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/proxy"
+)
+
+// SDCheckInterval defines interval for targets refresh.
+var SDCheckInterval = flag.Duration("promscrape.marathonSDCheckInterval", 30*time.Second, "Interval for checking for changes in Marathon REST API. "+
+	"This works only if marathon_sd_configs is configured in '-promscrape.config' file. "+
+	"See https://docs.victoriametrics.com/sd_configs.html#marathon_sd_configs for details")
+
+// SDConfig represents service discovery config for Marathon.
+//
+// See https://prometheus.io/docs/prometheus/latest/configuration/configuration/#marathon_sd_config
+type SDConfig struct {
+	Servers           []string                   `yaml:"servers"`
+	AuthToken         *promauth.Secret           `yaml:"auth_token,omitempty"`
+	AuthTokenFile     string                     `yaml:"auth_token_file,omitempty"`
+	HTTPClientConfig  promauth.HTTPClientConfig  `yaml:",inline"`
+	ProxyURL          *proxy.URL                 `yaml:"proxy_url,omitempty"`
+	ProxyClientConfig promauth.ProxyClientConfig `yaml:",inline"`
+	// refresh_interval is obtained from `-promscrape.marathonSDCheckInterval` command-line option.
+}
+
+// GetLabels returns Marathon task labels according to sdc.
+func (sdc *SDConfig) GetLabels(baseDir string) ([]*promutils.Labels, error) {
+	cfg, err := getAPIConfig(sdc, baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get API config: %w", err)
+	}
+	apps, err := getApps(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return addAppsLabels(apps), nil
+}
+
+// MustStop stops further usage for sdc.
+func (sdc *SDConfig) MustStop() {
+	v := configMap.Delete(sdc)
+	if v != nil {
+		// v can be nil if GetLabels wasn't called yet.
+		cfg := v.(*apiConfig)
+		cfg.mustStop()
+	}
+}
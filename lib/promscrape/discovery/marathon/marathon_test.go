@@ -0,0 +1,153 @@
+package marathon
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+func TestGetLabels(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/apps/" || r.URL.Query().Get("embed") != "apps.tasks" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "token=secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{
+  "apps": [
+    {
+      "id": "test-service",
+      "labels": {"prometheus": "yes"},
+      "container": {
+        "docker": {"image": "repo/image:tag"},
+        "portMappings": [
+          {"containerPort": 8080, "hostPort": 0, "labels": {"prometheus": "yes"}},
+          {"containerPort": 9090, "hostPort": 31001, "labels": {}}
+        ]
+      },
+      "networks": [{"mode": "container/bridge"}],
+      "tasks": [
+        {"id": "test-task-1", "host": "mesos-slave1", "ports": [31000, 31001], "ipAddresses": [{"ipAddress": "1.2.3.4"}]}
+      ]
+    },
+    {
+      "id": "container-net",
+      "container": {
+        "docker": {
+          "image": "repo/image:tag",
+          "portMappings": [{"containerPort": 1234, "hostPort": 0, "labels": {"port-label": "x"}}]
+        }
+      },
+      "networks": [{"mode": "container", "name": "test-network"}],
+      "tasks": [
+        {"id": "test-task-2", "host": "mesos-slave1", "ports": [31002], "ipAddresses": [{"ipAddress": "10.0.0.3"}]}
+      ]
+    },
+    {
+      "id": "port-definitions",
+      "container": {"docker": {"image": "repo/image:tag"}},
+      "requirePorts": false,
+      "portDefinitions": [{"port": 10000, "labels": {"prometheus": "yes"}}],
+      "tasks": [
+        {"id": "test-task-3", "host": "mesos-slave2", "ports": [31003]}
+      ]
+    },
+    {
+      "id": "host-ports",
+      "container": {"docker": {"image": "repo/image:tag"}},
+      "tasks": [
+        {"id": "test-task-4", "host": "mesos-slave3", "ports": [31004]}
+      ]
+    }
+  ]
+}`)
+	}))
+	defer s.Close()
+
+	sdc := &SDConfig{
+		Servers:   []string{s.URL},
+		AuthToken: promauth.NewSecret("secret"),
+	}
+	labelss, err := sdc.GetLabels("")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer sdc.MustStop()
+
+	expectedLabelss := []*promutils.Labels{
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                                   "mesos-slave1:31000",
+			"__meta_marathon_app":                           "test-service",
+			"__meta_marathon_image":                         "repo/image:tag",
+			"__meta_marathon_task":                          "test-task-1",
+			"__meta_marathon_port_index":                    "0",
+			"__meta_marathon_app_label_prometheus":          "yes",
+			"__meta_marathon_port_mapping_label_prometheus": "yes",
+		}),
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                          "mesos-slave1:31001",
+			"__meta_marathon_app":                  "test-service",
+			"__meta_marathon_image":                "repo/image:tag",
+			"__meta_marathon_task":                 "test-task-1",
+			"__meta_marathon_port_index":           "1",
+			"__meta_marathon_app_label_prometheus": "yes",
+		}),
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                                   "10.0.0.3:1234",
+			"__meta_marathon_app":                           "container-net",
+			"__meta_marathon_image":                         "repo/image:tag",
+			"__meta_marathon_task":                          "test-task-2",
+			"__meta_marathon_port_index":                    "0",
+			"__meta_marathon_port_mapping_label_port_label": "x",
+		}),
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                                      "mesos-slave2:31003",
+			"__meta_marathon_app":                              "port-definitions",
+			"__meta_marathon_image":                            "repo/image:tag",
+			"__meta_marathon_task":                             "test-task-3",
+			"__meta_marathon_port_index":                       "0",
+			"__meta_marathon_port_definition_label_prometheus": "yes",
+		}),
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                "mesos-slave3:31004",
+			"__meta_marathon_app":        "host-ports",
+			"__meta_marathon_image":      "repo/image:tag",
+			"__meta_marathon_task":       "test-task-4",
+			"__meta_marathon_port_index": "0",
+		}),
+	}
+	discoveryutils.TestEqualLabelss(t, labelss, expectedLabelss)
+}
+
+func TestNewAPIConfigFailure(t *testing.T) {
+	f := func(sdc *SDConfig) {
+		t.Helper()
+		if _, err := newAPIConfig(sdc, ""); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+	// missing servers
+	f(&SDConfig{})
+	// auth_token and auth_token_file at the same time
+	f(&SDConfig{
+		Servers:       []string{"http://marathon:8080"},
+		AuthToken:     promauth.NewSecret("foo"),
+		AuthTokenFile: "/path/to/file",
+	})
+	// auth_token with basic_auth
+	f(&SDConfig{
+		Servers:   []string{"http://marathon:8080"},
+		AuthToken: promauth.NewSecret("foo"),
+		HTTPClientConfig: promauth.HTTPClientConfig{
+			BasicAuth: &promauth.BasicAuthConfig{Username: "user"},
+		},
+	})
+}
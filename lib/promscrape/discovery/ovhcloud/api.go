@@ -0,0 +1,173 @@
+package ovhcloud
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+)
+
+var configMap = discoveryutils.NewConfigMap()
+
+// endpoints contains known OVHcloud API endpoints.
+//
+// See https://github.com/ovh/go-ovh/blob/master/ovh/ovh.go
+var endpoints = map[string]string{
+	"ovh-eu":        "https://eu.api.ovh.com/1.0",
+	"ovh-ca":        "https://ca.api.ovh.com/1.0",
+	"ovh-us":        "https://api.us.ovhcloud.com/1.0",
+	"kimsufi-eu":    "https://eu.api.kimsufi.com/1.0",
+	"kimsufi-ca":    "https://ca.api.kimsufi.com/1.0",
+	"soyoustart-eu": "https://eu.api.soyoustart.com/1.0",
+	"soyoustart-ca": "https://ca.api.soyoustart.com/1.0",
+}
+
+type apiConfig struct {
+	client            *discoveryutils.Client
+	applicationKey    string
+	applicationSecret string
+	consumerKey       string
+
+	// timeDeltaLock protects timeDelta and timeDeltaObtained.
+	timeDeltaLock     sync.Mutex
+	timeDelta         time.Duration
+	timeDeltaObtained bool
+}
+
+func getAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	v, err := configMap.Get(sdc, func() (interface{}, error) { return newAPIConfig(sdc, baseDir) })
+	if err != nil {
+		return nil, err
+	}
+	return v.(*apiConfig), nil
+}
+
+func newAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	switch sdc.Service {
+	case "vps", "dedicated_server":
+	default:
+		return nil, fmt.Errorf("unexpected `service`: %q; must be one of `vps` or `dedicated_server`", sdc.Service)
+	}
+	if sdc.ApplicationKey == "" {
+		return nil, fmt.Errorf("missing `application_key` option")
+	}
+	if sdc.ApplicationSecret == nil || sdc.ApplicationSecret.String() == "" {
+		return nil, fmt.Errorf("missing `application_secret` option")
+	}
+	if sdc.ConsumerKey == nil || sdc.ConsumerKey.String() == "" {
+		return nil, fmt.Errorf("missing `consumer_key` option")
+	}
+	apiServer, err := getAPIServer(sdc.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	ac, err := sdc.HTTPClientConfig.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse auth config: %w", err)
+	}
+	proxyAC, err := sdc.ProxyClientConfig.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse proxy auth config: %w", err)
+	}
+	client, err := discoveryutils.NewClient(apiServer, ac, sdc.ProxyURL, proxyAC)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create HTTP client for %q: %w", apiServer, err)
+	}
+	cfg := &apiConfig{
+		client:            client,
+		applicationKey:    sdc.ApplicationKey,
+		applicationSecret: sdc.ApplicationSecret.String(),
+		consumerKey:       sdc.ConsumerKey.String(),
+	}
+	return cfg, nil
+}
+
+func getAPIServer(endpoint string) (string, error) {
+	if endpoint == "" {
+		endpoint = "ovh-eu"
+	}
+	if apiServer, ok := endpoints[endpoint]; ok {
+		return apiServer, nil
+	}
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return "", fmt.Errorf("unexpected `endpoint`: %q; must be either OVHcloud endpoint name such as `ovh-eu` or an URL", endpoint)
+	}
+	return strings.TrimSuffix(endpoint, "/"), nil
+}
+
+// getAPIResponse returns signed response for the given path from OVHcloud API.
+//
+// See https://help.ovhcloud.com/csm/en-gb-api-getting-started-ovhcloud-api?id=kb_article_view&sysparm_article=KB0042784
+func (cfg *apiConfig) getAPIResponse(path string) ([]byte, error) {
+	timeDelta, err := cfg.getTimeDelta()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.client.GetAPIResponseWithReqParams(path, func(req *http.Request) {
+		timestamp := strconv.FormatInt(time.Now().Add(-timeDelta).Unix(), 10)
+		req.Header.Set("X-Ovh-Application", cfg.applicationKey)
+		req.Header.Set("X-Ovh-Consumer", cfg.consumerKey)
+		req.Header.Set("X-Ovh-Timestamp", timestamp)
+		req.Header.Set("X-Ovh-Signature", cfg.getSignature(req.Method, req.URL.String(), "", timestamp))
+	})
+}
+
+func (cfg *apiConfig) getSignature(method, url, body, timestamp string) string {
+	s := strings.Join([]string{cfg.applicationSecret, cfg.consumerKey, method, url, body, timestamp}, "+")
+	h := sha1.Sum([]byte(s))
+	return "$1$" + hex.EncodeToString(h[:])
+}
+
+// getTimeDelta returns the difference between local time and OVHcloud API server time.
+//
+// Requests with timestamps too far from the server time are rejected by OVHcloud API.
+func (cfg *apiConfig) getTimeDelta() (time.Duration, error) {
+	cfg.timeDeltaLock.Lock()
+	defer cfg.timeDeltaLock.Unlock()
+
+	if cfg.timeDeltaObtained {
+		return cfg.timeDelta, nil
+	}
+	data, err := cfg.client.GetAPIResponse("/auth/time")
+	if err != nil {
+		return 0, fmt.Errorf("cannot obtain server time from ovhcloud api: %w", err)
+	}
+	serverTime, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse server time %q returned by ovhcloud api: %w", data, err)
+	}
+	cfg.timeDelta = time.Since(time.Unix(serverTime, 0))
+	cfg.timeDeltaObtained = true
+	return cfg.timeDelta, nil
+}
+
+// parseIPs returns the first IPv4 and IPv6 addresses from ips.
+//
+// ips may contain either addresses or CIDR prefixes such as `1.2.3.4/32`.
+func parseIPs(ips []string) (string, string) {
+	var ipv4, ipv6 string
+	for _, s := range ips {
+		if n := strings.IndexByte(s, '/'); n >= 0 {
+			s = s[:n]
+		}
+		ip := net.ParseIP(s)
+		if ip == nil {
+			continue
+		}
+		if ip.To4() != nil {
+			if ipv4 == "" {
+				ipv4 = ip.String()
+			}
+		} else if ipv6 == "" {
+			ipv6 = ip.String()
+		}
+	}
+	return ipv4, ipv6
+}
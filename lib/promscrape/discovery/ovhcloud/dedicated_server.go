@@ -0,0 +1,105 @@
+package ovhcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+// dedicatedServer represents OVHcloud dedicated server.
+//
+// See https://eu.api.ovh.com/console/#/dedicated/server/%7BserviceName%7D~GET
+type dedicatedServer struct {
+	State           string `json:"state"`
+	CommercialRange string `json:"commercialRange"`
+	LinkSpeed       int    `json:"linkSpeed"`
+	Rack            string `json:"rack"`
+	NoIntervention  bool   `json:"noIntervention"`
+	OS              string `json:"os"`
+	SupportLevel    string `json:"supportLevel"`
+	ServerID        int64  `json:"serverId"`
+	Reverse         string `json:"reverse"`
+	Datacenter      string `json:"datacenter"`
+	Name            string `json:"name"`
+}
+
+func getDedicatedServerLabels(cfg *apiConfig) ([]*promutils.Labels, error) {
+	data, err := cfg.getAPIResponse("/dedicated/server")
+	if err != nil {
+		return nil, fmt.Errorf("cannot query ovhcloud api for dedicated servers: %w", err)
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal ovhcloud dedicated servers list %q: %w", data, err)
+	}
+	var ms []*promutils.Labels
+	for _, name := range names {
+		server, err := getDedicatedServer(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		ips, err := getIPs(cfg, "/dedicated/server/"+url.PathEscape(name)+"/ips")
+		if err != nil {
+			return nil, fmt.Errorf("cannot obtain ips for dedicated server %q: %w", name, err)
+		}
+		ms = appendDedicatedServerLabels(ms, server, ips)
+	}
+	return ms, nil
+}
+
+func getDedicatedServer(cfg *apiConfig, name string) (*dedicatedServer, error) {
+	data, err := cfg.getAPIResponse("/dedicated/server/" + url.PathEscape(name))
+	if err != nil {
+		return nil, fmt.Errorf("cannot query ovhcloud api for dedicated server %q: %w", name, err)
+	}
+	var server dedicatedServer
+	if err := json.Unmarshal(data, &server); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal ovhcloud dedicated server %q: %w", data, err)
+	}
+	return &server, nil
+}
+
+func getIPs(cfg *apiConfig, path string) ([]string, error) {
+	data, err := cfg.getAPIResponse(path)
+	if err != nil {
+		return nil, err
+	}
+	var ips []string
+	if err := json.Unmarshal(data, &ips); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal ips list %q: %w", data, err)
+	}
+	return ips, nil
+}
+
+func appendDedicatedServerLabels(ms []*promutils.Labels, server *dedicatedServer, ips []string) []*promutils.Labels {
+	ipv4, ipv6 := parseIPs(ips)
+	addr := ipv4
+	if addr == "" {
+		addr = ipv6
+	}
+	if addr == "" {
+		// Skip servers without IP addresses, since they cannot be scraped.
+		return ms
+	}
+	m := promutils.NewLabels(16)
+	m.Add("__address__", addr)
+	m.Add("instance", server.Name)
+	m.Add("__meta_ovhcloud_dedicated_server_commercial_range", server.CommercialRange)
+	m.Add("__meta_ovhcloud_dedicated_server_datacenter", server.Datacenter)
+	m.Add("__meta_ovhcloud_dedicated_server_ipv4", ipv4)
+	m.Add("__meta_ovhcloud_dedicated_server_ipv6", ipv6)
+	m.Add("__meta_ovhcloud_dedicated_server_link_speed", strconv.Itoa(server.LinkSpeed))
+	m.Add("__meta_ovhcloud_dedicated_server_name", server.Name)
+	m.Add("__meta_ovhcloud_dedicated_server_no_intervention", strconv.FormatBool(server.NoIntervention))
+	m.Add("__meta_ovhcloud_dedicated_server_os", server.OS)
+	m.Add("__meta_ovhcloud_dedicated_server_rack", server.Rack)
+	m.Add("__meta_ovhcloud_dedicated_server_reverse", server.Reverse)
+	m.Add("__meta_ovhcloud_dedicated_server_server_id", strconv.FormatInt(server.ServerID, 10))
+	m.Add("__meta_ovhcloud_dedicated_server_state", server.State)
+	m.Add("__meta_ovhcloud_dedicated_server_support_level", server.SupportLevel)
+	ms = append(ms, m)
+	return ms
+}
@@ -0,0 +1,58 @@
+package ovhcloud
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/proxy"
+)
+
+// SDCheckInterval defines interval for targets refresh.
+var SDCheckInterval = flag.Duration("promscrape.ovhcloudSDCheckInterval", time.Minute, "Interval for checking for changes in OVHcloud API. "+
+	"This works only if ovhcloud_sd_configs is configured in '-promscrape.config' file. "+
+	"See https://docs.victoriametrics.com/sd_configs.html#ovhcloud_sd_configs for details")
+
+// SDConfig represents service discovery config for OVHcloud.
+//
+// See https://prometheus.io/docs/prometheus/latest/configuration/configuration/#ovhcloud_sd_config
+type SDConfig struct {
+	ApplicationKey    string           `yaml:"application_key"`
+	ApplicationSecret *promauth.Secret `yaml:"application_secret"`
+	ConsumerKey       *promauth.Secret `yaml:"consumer_key"`
+
+	// Service must be either `vps` or `dedicated_server`.
+	Service string `yaml:"service"`
+
+	// Endpoint may contain either OVHcloud endpoint name such as `ovh-eu` or an URL for OVHcloud API.
+	// It is set to `ovh-eu` by default.
+	Endpoint string `yaml:"endpoint,omitempty"`
+
+	HTTPClientConfig  promauth.HTTPClientConfig  `yaml:",inline"`
+	ProxyURL          *proxy.URL                 `yaml:"proxy_url,omitempty"`
+	ProxyClientConfig promauth.ProxyClientConfig `yaml:",inline"`
+	// refresh_interval is obtained from `-promscrape.ovhcloudSDCheckInterval` command-line option.
+}
+
+// GetLabels returns OVHcloud labels according to sdc.
+func (sdc *SDConfig) GetLabels(baseDir string) ([]*promutils.Labels, error) {
+	cfg, err := getAPIConfig(sdc, baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get API config: %w", err)
+	}
+	switch sdc.Service {
+	case "dedicated_server":
+		return getDedicatedServerLabels(cfg)
+	case "vps":
+		return getVPSLabels(cfg)
+	default:
+		return nil, fmt.Errorf("skipping unexpected service=%q; must be one of `vps` or `dedicated_server`", sdc.Service)
+	}
+}
+
+// MustStop stops further usage for sdc.
+func (sdc *SDConfig) MustStop() {
+	configMap.Delete(sdc)
+}
@@ -0,0 +1,186 @@
+package ovhcloud
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	var s *httptest.Server
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/time" {
+			fmt.Fprint(w, "1700000000")
+			return
+		}
+		if r.Header.Get("X-Ovh-Application") != "app-key" || r.Header.Get("X-Ovh-Consumer") != "consumer-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		ts := r.Header.Get("X-Ovh-Timestamp")
+		h := sha1.Sum([]byte("app-secret+consumer-key+GET+" + s.URL + r.URL.RequestURI() + "++" + ts))
+		if r.Header.Get("X-Ovh-Signature") != "$1$"+hex.EncodeToString(h[:]) {
+			t.Errorf("unexpected signature for %q", r.URL.Path)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/dedicated/server":
+			fmt.Fprint(w, `["abcde", "no-ips"]`)
+		case "/dedicated/server/abcde":
+			fmt.Fprint(w, `{
+  "ip": "1.2.3.4",
+  "newUpgradeSystem": true,
+  "commercialRange": "Advance-1 Gen 2",
+  "rack": "TESTRACK",
+  "rescueMail": null,
+  "supportLevel": "pro",
+  "bootId": 1,
+  "linkSpeed": 123,
+  "professionalUse": false,
+  "monitoring": true,
+  "noIntervention": false,
+  "name": "abcde",
+  "rootDevice": null,
+  "state": "test",
+  "datacenter": "gra3",
+  "os": "debian11_64",
+  "reverse": "abcde-rev",
+  "serverId": 1234
+}`)
+		case "/dedicated/server/abcde/ips":
+			fmt.Fprint(w, `["1.2.3.4/32", "2001:0db8:0000:0000:0000:0000:0000:0001/64"]`)
+		case "/dedicated/server/no-ips":
+			fmt.Fprint(w, `{"name": "no-ips", "state": "ok"}`)
+		case "/dedicated/server/no-ips/ips":
+			fmt.Fprint(w, `[]`)
+		case "/vps":
+			fmt.Fprint(w, `["abc"]`)
+		case "/vps/abc":
+			fmt.Fprint(w, `{
+  "offerType": "ssd",
+  "monitoringIpBlocks": [],
+  "displayName": "abc",
+  "zone": "zone",
+  "cluster": "cluster_test",
+  "slaMonitoring": false,
+  "name": "abc",
+  "vcore": 1,
+  "state": "running",
+  "keymap": null,
+  "netbootMode": "local",
+  "model": {
+    "name": "vps-value-1-2-40",
+    "availableOptions": [],
+    "maximumAdditionnalIp": 16,
+    "offer": "VPS abc",
+    "disk": 40,
+    "version": "2019v1",
+    "vcore": 1,
+    "datacenter": [],
+    "memory": 2048
+  },
+  "memoryLimit": 2048
+}`)
+		case "/vps/abc/ips":
+			fmt.Fprint(w, `["2001:0db1:0000:0000:0000:0000:0000:0001"]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return s
+}
+
+func TestGetLabels(t *testing.T) {
+	s := newTestServer(t)
+	defer s.Close()
+
+	f := func(service string, expectedLabelss []*promutils.Labels) {
+		t.Helper()
+		sdc := &SDConfig{
+			ApplicationKey:    "app-key",
+			ApplicationSecret: promauth.NewSecret("app-secret"),
+			ConsumerKey:       promauth.NewSecret("consumer-key"),
+			Service:           service,
+			Endpoint:          s.URL,
+		}
+		labelss, err := sdc.GetLabels("")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		defer sdc.MustStop()
+		discoveryutils.TestEqualLabelss(t, labelss, expectedLabelss)
+	}
+	f("dedicated_server", []*promutils.Labels{
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__": "1.2.3.4",
+			"instance":    "abcde",
+			"__meta_ovhcloud_dedicated_server_commercial_range": "Advance-1 Gen 2",
+			"__meta_ovhcloud_dedicated_server_datacenter":       "gra3",
+			"__meta_ovhcloud_dedicated_server_ipv4":             "1.2.3.4",
+			"__meta_ovhcloud_dedicated_server_ipv6":             "2001:db8::1",
+			"__meta_ovhcloud_dedicated_server_link_speed":       "123",
+			"__meta_ovhcloud_dedicated_server_name":             "abcde",
+			"__meta_ovhcloud_dedicated_server_no_intervention":  "false",
+			"__meta_ovhcloud_dedicated_server_os":               "debian11_64",
+			"__meta_ovhcloud_dedicated_server_rack":             "TESTRACK",
+			"__meta_ovhcloud_dedicated_server_reverse":          "abcde-rev",
+			"__meta_ovhcloud_dedicated_server_server_id":        "1234",
+			"__meta_ovhcloud_dedicated_server_state":            "test",
+			"__meta_ovhcloud_dedicated_server_support_level":    "pro",
+		}),
+	})
+	f("vps", []*promutils.Labels{
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                               "2001:db1::1",
+			"instance":                                  "abc",
+			"__meta_ovhcloud_vps_cluster":               "cluster_test",
+			"__meta_ovhcloud_vps_datacenter":            "[]",
+			"__meta_ovhcloud_vps_disk":                  "40",
+			"__meta_ovhcloud_vps_display_name":          "abc",
+			"__meta_ovhcloud_vps_ipv4":                  "",
+			"__meta_ovhcloud_vps_ipv6":                  "2001:db1::1",
+			"__meta_ovhcloud_vps_keymap":                "",
+			"__meta_ovhcloud_vps_maximum_additional_ip": "16",
+			"__meta_ovhcloud_vps_memory":                "2048",
+			"__meta_ovhcloud_vps_memory_limit":          "2048",
+			"__meta_ovhcloud_vps_model_name":            "vps-value-1-2-40",
+			"__meta_ovhcloud_vps_model_vcore":           "1",
+			"__meta_ovhcloud_vps_name":                  "abc",
+			"__meta_ovhcloud_vps_netboot_mode":          "local",
+			"__meta_ovhcloud_vps_offer":                 "VPS abc",
+			"__meta_ovhcloud_vps_offer_type":            "ssd",
+			"__meta_ovhcloud_vps_state":                 "running",
+			"__meta_ovhcloud_vps_vcore":                 "1",
+			"__meta_ovhcloud_vps_version":               "2019v1",
+			"__meta_ovhcloud_vps_zone":                  "zone",
+		}),
+	})
+}
+
+func TestGetAPIServer(t *testing.T) {
+	f := func(endpoint, expected string) {
+		t.Helper()
+		apiServer, err := getAPIServer(endpoint)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if apiServer != expected {
+			t.Fatalf("unexpected api server; got %q; want %q", apiServer, expected)
+		}
+	}
+	f("", "https://eu.api.ovh.com/1.0")
+	f("ovh-ca", "https://ca.api.ovh.com/1.0")
+	f("https://example.com/1.0/", "https://example.com/1.0")
+
+	if _, err := getAPIServer("foobar"); err == nil {
+		t.Fatalf("expecting non-nil error for unknown endpoint")
+	}
+}
@@ -0,0 +1,113 @@
+package ovhcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+// vps represents OVHcloud virtual private server.
+//
+// See https://eu.api.ovh.com/console/#/vps/%7BserviceName%7D~GET
+type vps struct {
+	Model       vpsModel `json:"model"`
+	Zone        string   `json:"zone"`
+	DisplayName string   `json:"displayName"`
+	Cluster     string   `json:"cluster"`
+	State       string   `json:"state"`
+	Name        string   `json:"name"`
+	NetbootMode string   `json:"netbootMode"`
+	MemoryLimit int      `json:"memoryLimit"`
+	OfferType   string   `json:"offerType"`
+	Vcore       int      `json:"vcore"`
+	Keymap      string   `json:"keymap"`
+}
+
+type vpsModel struct {
+	Name    string `json:"name"`
+	Offer   string `json:"offer"`
+	Memory  int    `json:"memory"`
+	Vcore   int    `json:"vcore"`
+	Version string `json:"version"`
+	Disk    int    `json:"disk"`
+
+	// MaximumAdditionalIP has the same misspelled name as in OVHcloud API.
+	MaximumAdditionalIP int      `json:"maximumAdditionnalIp"`
+	Datacenter          []string `json:"datacenter"`
+}
+
+func getVPSLabels(cfg *apiConfig) ([]*promutils.Labels, error) {
+	data, err := cfg.getAPIResponse("/vps")
+	if err != nil {
+		return nil, fmt.Errorf("cannot query ovhcloud api for vps list: %w", err)
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal ovhcloud vps list %q: %w", data, err)
+	}
+	var ms []*promutils.Labels
+	for _, name := range names {
+		server, err := getVPS(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		ips, err := getIPs(cfg, "/vps/"+url.PathEscape(name)+"/ips")
+		if err != nil {
+			return nil, fmt.Errorf("cannot obtain ips for vps %q: %w", name, err)
+		}
+		ms = appendVPSLabels(ms, server, ips)
+	}
+	return ms, nil
+}
+
+func getVPS(cfg *apiConfig, name string) (*vps, error) {
+	data, err := cfg.getAPIResponse("/vps/" + url.PathEscape(name))
+	if err != nil {
+		return nil, fmt.Errorf("cannot query ovhcloud api for vps %q: %w", name, err)
+	}
+	var server vps
+	if err := json.Unmarshal(data, &server); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal ovhcloud vps %q: %w", data, err)
+	}
+	return &server, nil
+}
+
+func appendVPSLabels(ms []*promutils.Labels, server *vps, ips []string) []*promutils.Labels {
+	ipv4, ipv6 := parseIPs(ips)
+	addr := ipv4
+	if addr == "" {
+		addr = ipv6
+	}
+	if addr == "" {
+		// Skip servers without IP addresses, since they cannot be scraped.
+		return ms
+	}
+	m := promutils.NewLabels(24)
+	m.Add("__address__", addr)
+	m.Add("instance", server.Name)
+	m.Add("__meta_ovhcloud_vps_cluster", server.Cluster)
+	m.Add("__meta_ovhcloud_vps_datacenter", fmt.Sprintf("%+v", server.Model.Datacenter))
+	m.Add("__meta_ovhcloud_vps_disk", strconv.Itoa(server.Model.Disk))
+	m.Add("__meta_ovhcloud_vps_display_name", server.DisplayName)
+	m.Add("__meta_ovhcloud_vps_ipv4", ipv4)
+	m.Add("__meta_ovhcloud_vps_ipv6", ipv6)
+	m.Add("__meta_ovhcloud_vps_keymap", server.Keymap)
+	m.Add("__meta_ovhcloud_vps_maximum_additional_ip", strconv.Itoa(server.Model.MaximumAdditionalIP))
+	m.Add("__meta_ovhcloud_vps_memory", strconv.Itoa(server.Model.Memory))
+	m.Add("__meta_ovhcloud_vps_memory_limit", strconv.Itoa(server.MemoryLimit))
+	m.Add("__meta_ovhcloud_vps_model_name", server.Model.Name)
+	m.Add("__meta_ovhcloud_vps_model_vcore", strconv.Itoa(server.Model.Vcore))
+	m.Add("__meta_ovhcloud_vps_name", server.Name)
+	m.Add("__meta_ovhcloud_vps_netboot_mode", server.NetbootMode)
+	m.Add("__meta_ovhcloud_vps_offer", server.Model.Offer)
+	m.Add("__meta_ovhcloud_vps_offer_type", server.OfferType)
+	m.Add("__meta_ovhcloud_vps_state", server.State)
+	m.Add("__meta_ovhcloud_vps_vcore", strconv.Itoa(server.Vcore))
+	m.Add("__meta_ovhcloud_vps_version", server.Model.Version)
+	m.Add("__meta_ovhcloud_vps_zone", server.Zone)
+	ms = append(ms, m)
+	return ms
+}
@@ -0,0 +1,126 @@
+package puppetdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+)
+
+var configMap = discoveryutils.NewConfigMap()
+
+type apiConfig struct {
+	client  *discoveryutils.Client
+	apiPath string
+
+	query             string
+	includeParameters bool
+	port              int
+}
+
+func getAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	v, err := configMap.Get(sdc, func() (interface{}, error) { return newAPIConfig(sdc, baseDir) })
+	if err != nil {
+		return nil, err
+	}
+	return v.(*apiConfig), nil
+}
+
+func newAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	if sdc.Query == "" {
+		return nil, fmt.Errorf("missing `query` option")
+	}
+	apiServer, apiPath, err := getAPIServerPath(sdc.URL)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse `url` %q: %w", sdc.URL, err)
+	}
+	ac, err := sdc.HTTPClientConfig.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse auth config: %w", err)
+	}
+	proxyAC, err := sdc.ProxyClientConfig.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse proxy auth config: %w", err)
+	}
+	client, err := discoveryutils.NewClient(apiServer, ac, sdc.ProxyURL, proxyAC)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create HTTP client for %q: %w", apiServer, err)
+	}
+	port := sdc.Port
+	if port == 0 {
+		port = 80
+	}
+	cfg := &apiConfig{
+		client:  client,
+		apiPath: apiPath,
+
+		query:             sdc.Query,
+		includeParameters: sdc.IncludeParameters,
+		port:              port,
+	}
+	return cfg, nil
+}
+
+func getAPIServerPath(serverURL string) (string, string, error) {
+	if serverURL == "" {
+		return "", "", fmt.Errorf("missing `url` option")
+	}
+	psu, err := url.Parse(serverURL)
+	if err != nil {
+		return "", "", err
+	}
+	if psu.Scheme != "http" && psu.Scheme != "https" {
+		return "", "", fmt.Errorf("unsupported scheme %q; supported schemes: http, https", psu.Scheme)
+	}
+	if psu.Host == "" {
+		return "", "", fmt.Errorf("missing host")
+	}
+	apiServer := fmt.Sprintf("%s://%s", psu.Scheme, psu.Host)
+	apiPath := strings.TrimSuffix(psu.Path, "/") + "/pdb/query/v4"
+	return apiServer, apiPath, nil
+}
+
+// resource represents PuppetDB resource.
+//
+// See https://www.puppet.com/docs/puppetdb/7/api/query/v4/resources.html#response-format
+type resource struct {
+	Certname    string                 `json:"certname"`
+	Resource    string                 `json:"resource"`
+	Type        string                 `json:"type"`
+	Title       string                 `json:"title"`
+	Exported    bool                   `json:"exported"`
+	Tags        []string               `json:"tags"`
+	File        string                 `json:"file"`
+	Environment string                 `json:"environment"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+func getResources(cfg *apiConfig) ([]resource, error) {
+	requestBody, err := json.Marshal(map[string]string{
+		"query": cfg.query,
+	})
+	if err != nil {
+		logger.Panicf("BUG: cannot marshal PuppetDB query: %s", err)
+	}
+	modifyRequest := func(req *http.Request) {
+		req.Method = http.MethodPost
+		req.Body = io.NopCloser(bytes.NewReader(requestBody))
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Content-Type", "application/json")
+	}
+	data, err := cfg.client.GetAPIResponseWithReqParams(cfg.apiPath, modifyRequest)
+	if err != nil {
+		return nil, fmt.Errorf("cannot query PuppetDB: %w", err)
+	}
+	var resources []resource
+	if err := json.Unmarshal(data, &resources); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal PuppetDB response %q: %w", data, err)
+	}
+	return resources, nil
+}
@@ -0,0 +1,112 @@
+package puppetdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+func TestGetLabels(t *testing.T) {
+	const query = `resources { type = "Class" and title = "Prometheus::Node_exporter" }`
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/puppetdb/pdb/query/v4" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var req struct {
+			Query string `json:"query"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Query != query {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `[
+  {
+    "certname": "edinburgh.example.com",
+    "environment": "prod",
+    "exported": false,
+    "file": "/etc/puppetlabs/code/environments/prod/modules/upstream/apache/manifests/init.pp",
+    "parameters": {
+      "access_log": true,
+      "access_log_file": "ssl_access_log",
+      "buffer_size": 16384,
+      "load_factor": 0.75,
+      "ports": [80, 443],
+      "docroot": "/var/www/html",
+      "labels": {"alias": "edinburgh"},
+      "options": ["Indexes", "FollowSymLinks", "MultiViews"],
+      "empty_list": [],
+      "nested": {"a": {"b": "c"}}
+    },
+    "resource": "49af83866dc5a1518968b68e58a25319107afe11",
+    "tags": ["roles::hypervisor", "apache", "apache::vhost", "class", "default-ssl", "profile_hypervisor", "vhost", "profile_apache", "hypervisor", "__node_regexp__edinburgh", "roles", "node"],
+    "title": "default-ssl",
+    "type": "Apache::Vhost"
+  }
+]`)
+	}))
+	defer s.Close()
+
+	f := func(includeParameters bool, expectedLabels map[string]string) {
+		t.Helper()
+		sdc := &SDConfig{
+			URL:               s.URL + "/puppetdb",
+			Query:             query,
+			IncludeParameters: includeParameters,
+			Port:              9100,
+		}
+		labelss, err := sdc.GetLabels("")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		defer sdc.MustStop()
+		discoveryutils.TestEqualLabelss(t, labelss, []*promutils.Labels{promutils.NewLabelsFromMap(expectedLabels)})
+	}
+	commonLabels := map[string]string{
+		"__address__":                 "edinburgh.example.com:9100",
+		"__meta_puppetdb_query":       query,
+		"__meta_puppetdb_certname":    "edinburgh.example.com",
+		"__meta_puppetdb_environment": "prod",
+		"__meta_puppetdb_exported":    "false",
+		"__meta_puppetdb_file":        "/etc/puppetlabs/code/environments/prod/modules/upstream/apache/manifests/init.pp",
+		"__meta_puppetdb_resource":    "49af83866dc5a1518968b68e58a25319107afe11",
+		"__meta_puppetdb_tags":        ",roles::hypervisor,apache,apache::vhost,class,default-ssl,profile_hypervisor,vhost,profile_apache,hypervisor,__node_regexp__edinburgh,roles,node,",
+		"__meta_puppetdb_title":       "default-ssl",
+		"__meta_puppetdb_type":        "Apache::Vhost",
+	}
+	f(false, commonLabels)
+
+	labelsWithParameters := map[string]string{
+		"__meta_puppetdb_parameter_access_log":      "true",
+		"__meta_puppetdb_parameter_access_log_file": "ssl_access_log",
+		"__meta_puppetdb_parameter_buffer_size":     "16384",
+		"__meta_puppetdb_parameter_load_factor":     "0.75",
+		"__meta_puppetdb_parameter_ports":           "80,443",
+		"__meta_puppetdb_parameter_docroot":         "/var/www/html",
+		"__meta_puppetdb_parameter_labels_alias":    "edinburgh",
+		"__meta_puppetdb_parameter_options":         "Indexes,FollowSymLinks,MultiViews",
+		"__meta_puppetdb_parameter_nested_a_b":      "c",
+	}
+	for k, v := range commonLabels {
+		labelsWithParameters[k] = v
+	}
+	f(true, labelsWithParameters)
+}
+
+func TestNewAPIConfigFailure(t *testing.T) {
+	f := func(sdc *SDConfig) {
+		t.Helper()
+		if _, err := newAPIConfig(sdc, ""); err == nil {
+			t.Fatalf("expecting non-nil error for %#v", sdc)
+		}
+	}
+	f(&SDConfig{Query: "resources {}"})
+	f(&SDConfig{URL: "http://puppetdb"})
+	f(&SDConfig{URL: "ftp://puppetdb", Query: "resources {}"})
+	f(&SDConfig{URL: "http://", Query: "resources {}"})
+}
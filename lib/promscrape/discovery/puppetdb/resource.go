@@ -0,0 +1,79 @@
+package puppetdb
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+const separator = ","
+
+func addResourceLabels(resources []resource, cfg *apiConfig) []*promutils.Labels {
+	ms := make([]*promutils.Labels, 0, len(resources))
+	for i := range resources {
+		r := &resources[i]
+		m := promutils.NewLabels(16)
+		m.Add("__address__", discoveryutils.JoinHostPort(r.Certname, cfg.port))
+		m.Add("__meta_puppetdb_query", cfg.query)
+		m.Add("__meta_puppetdb_certname", r.Certname)
+		m.Add("__meta_puppetdb_resource", r.Resource)
+		m.Add("__meta_puppetdb_type", r.Type)
+		m.Add("__meta_puppetdb_title", r.Title)
+		m.Add("__meta_puppetdb_exported", strconv.FormatBool(r.Exported))
+		m.Add("__meta_puppetdb_file", r.File)
+		m.Add("__meta_puppetdb_environment", r.Environment)
+		if len(r.Tags) > 0 {
+			m.Add("__meta_puppetdb_tags", separator+strings.Join(r.Tags, separator)+separator)
+		}
+		if cfg.includeParameters {
+			addParameterLabels(m, "__meta_puppetdb_parameter_", r.Parameters)
+		}
+		ms = append(ms, m)
+	}
+	return ms
+}
+
+// addParameterLabels adds parameters with the given prefix to m in the same way as Prometheus does.
+//
+// Only string, bool, number and lists of these types are exposed. Nested objects are flattened
+// by joining the parent and the child keys with underscore.
+func addParameterLabels(m *promutils.Labels, prefix string, parameters map[string]interface{}) {
+	for k, v := range parameters {
+		var labelValue string
+		switch t := v.(type) {
+		case string:
+			labelValue = t
+		case bool:
+			labelValue = strconv.FormatBool(t)
+		case float64:
+			labelValue = strconv.FormatFloat(t, 'g', -1, 64)
+		case []interface{}:
+			if len(t) == 0 {
+				continue
+			}
+			values := make([]string, len(t))
+			for i, item := range t {
+				switch item := item.(type) {
+				case string:
+					values[i] = item
+				case bool:
+					values[i] = strconv.FormatBool(item)
+				case float64:
+					values[i] = strconv.FormatFloat(item, 'g', -1, 64)
+				}
+			}
+			labelValue = strings.Join(values, separator)
+		case map[string]interface{}:
+			addParameterLabels(m, prefix+discoveryutils.SanitizeLabelName(k+"_"), t)
+			continue
+		default:
+			continue
+		}
+		if labelValue == "" {
+			continue
+		}
+		m.Add(prefix+discoveryutils.SanitizeLabelName(k), labelValue)
+	}
+}
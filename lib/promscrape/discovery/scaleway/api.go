@@ -0,0 +1,133 @@
+package scaleway
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+)
+
+var configMap = discoveryutils.NewConfigMap()
+
+type apiConfig struct {
+	client    *discoveryutils.Client
+	secretKey string
+
+	projectID  string
+	zone       string
+	port       int
+	nameFilter string
+	tagsFilter []string
+}
+
+func getAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	v, err := configMap.Get(sdc, func() (interface{}, error) { return newAPIConfig(sdc, baseDir) })
+	if err != nil {
+		return nil, err
+	}
+	return v.(*apiConfig), nil
+}
+
+func newAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	switch sdc.Role {
+	case "instance", "baremetal":
+	default:
+		return nil, fmt.Errorf("unexpected `role`: %q; must be one of `instance` or `baremetal`", sdc.Role)
+	}
+	if sdc.ProjectID == "" {
+		return nil, fmt.Errorf("missing `project_id` option")
+	}
+	if sdc.AccessKey == "" {
+		return nil, fmt.Errorf("missing `access_key` option")
+	}
+	var secretKey string
+	if sdc.SecretKey != nil {
+		if sdc.SecretKeyFile != "" {
+			return nil, fmt.Errorf("cannot set both `secret_key` and `secret_key_file`")
+		}
+		secretKey = sdc.SecretKey.String()
+	} else if sdc.SecretKeyFile != "" {
+		path := fs.GetFilepath(baseDir, sdc.SecretKeyFile)
+		data, err := fs.ReadFileOrHTTP(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read `secret_key_file` %q: %w", sdc.SecretKeyFile, err)
+		}
+		secretKey = strings.TrimSpace(string(data))
+	}
+	if secretKey == "" {
+		return nil, fmt.Errorf("missing `secret_key` or `secret_key_file` option")
+	}
+	ac, err := sdc.HTTPClientConfig.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse auth config: %w", err)
+	}
+	apiServer := sdc.APIURL
+	if apiServer == "" {
+		apiServer = "https://api.scaleway.com"
+	}
+	apiServer = strings.TrimSuffix(apiServer, "/")
+	proxyAC, err := sdc.ProxyClientConfig.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse proxy auth config: %w", err)
+	}
+	client, err := discoveryutils.NewClient(apiServer, ac, sdc.ProxyURL, proxyAC)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create HTTP client for %q: %w", apiServer, err)
+	}
+	zone := sdc.Zone
+	if zone == "" {
+		zone = "fr-par-1"
+	}
+	port := sdc.Port
+	if port == 0 {
+		port = 80
+	}
+	cfg := &apiConfig{
+		client:    client,
+		secretKey: secretKey,
+
+		projectID:  sdc.ProjectID,
+		zone:       zone,
+		port:       port,
+		nameFilter: sdc.NameFilter,
+		tagsFilter: sdc.TagsFilter,
+	}
+	return cfg, nil
+}
+
+// getAPIResponse returns the response for the given path and query args from Scaleway API.
+func (cfg *apiConfig) getAPIResponse(path string, args url.Values) ([]byte, error) {
+	if len(args) > 0 {
+		path += "?" + args.Encode()
+	}
+	return cfg.client.GetAPIResponseWithReqParams(path, func(req *http.Request) {
+		req.Header.Set("X-Auth-Token", cfg.secretKey)
+	})
+}
+
+// getFilterArgs returns query args for filtering servers according to cfg.
+//
+// projectArg is the name of the query arg with project id, since it differs between instance and baremetal APIs.
+func (cfg *apiConfig) getFilterArgs(projectArg string) url.Values {
+	args := url.Values{}
+	args.Set(projectArg, cfg.projectID)
+	if cfg.nameFilter != "" {
+		args.Set("name", cfg.nameFilter)
+	}
+	if len(cfg.tagsFilter) > 0 {
+		args.Set("tags", strings.Join(cfg.tagsFilter, ","))
+	}
+	return args
+}
+
+// getRegion returns region for the given zone. For example, it returns `fr-par` for `fr-par-1` zone.
+func getRegion(zone string) string {
+	n := strings.LastIndexByte(zone, '-')
+	if n < 0 {
+		return zone
+	}
+	return zone[:n]
+}
@@ -0,0 +1,154 @@
+package scaleway
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+const baremetalServersPerPage = 100
+
+// baremetalServer represents Scaleway Elastic Metal server.
+//
+// See https://www.scaleway.com/en/developers/api/elastic-metal/#path-servers-list-elastic-metal-servers-for-an-organization
+type baremetalServer struct {
+	ID        string   `json:"id"`
+	ProjectID string   `json:"project_id"`
+	Name      string   `json:"name"`
+	Status    string   `json:"status"`
+	OfferName string   `json:"offer_name"`
+	Tags      []string `json:"tags"`
+	Zone      string   `json:"zone"`
+	IPs       []struct {
+		Address string `json:"address"`
+		Version string `json:"version"`
+	} `json:"ips"`
+	Install *struct {
+		OSID string `json:"os_id"`
+	} `json:"install"`
+}
+
+type listBaremetalServersResponse struct {
+	Servers    []baremetalServer `json:"servers"`
+	TotalCount int               `json:"total_count"`
+}
+
+// baremetalOS represents Scaleway Elastic Metal OS.
+//
+// See https://www.scaleway.com/en/developers/api/elastic-metal/#path-os-get-an-os-with-an-id
+type baremetalOS struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+func getBaremetalLabels(cfg *apiConfig) ([]*promutils.Labels, error) {
+	servers, err := getBaremetalServers(cfg)
+	if err != nil {
+		return nil, err
+	}
+	osCache := make(map[string]*baremetalOS)
+	var ms []*promutils.Labels
+	for i := range servers {
+		server := &servers[i]
+		var os *baremetalOS
+		if server.Install != nil && server.Install.OSID != "" {
+			os = osCache[server.Install.OSID]
+			if os == nil {
+				os, err = getBaremetalOS(cfg, server.Install.OSID)
+				if err != nil {
+					return nil, err
+				}
+				osCache[server.Install.OSID] = os
+			}
+		}
+		ms = appendBaremetalLabels(ms, server, os, cfg.port)
+	}
+	return ms, nil
+}
+
+func getBaremetalServers(cfg *apiConfig) ([]baremetalServer, error) {
+	path := fmt.Sprintf("/baremetal/v1/zones/%s/servers", cfg.zone)
+	args := cfg.getFilterArgs("project_id")
+	args.Set("page_size", strconv.Itoa(baremetalServersPerPage))
+	var servers []baremetalServer
+	for page := 1; ; page++ {
+		args.Set("page", strconv.Itoa(page))
+		data, err := cfg.getAPIResponse(path, args)
+		if err != nil {
+			return nil, fmt.Errorf("cannot query scaleway api for baremetal servers: %w", err)
+		}
+		var resp listBaremetalServersResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal scaleway baremetal servers list %q: %w", data, err)
+		}
+		servers = append(servers, resp.Servers...)
+		if len(resp.Servers) == 0 || len(servers) >= resp.TotalCount {
+			return servers, nil
+		}
+	}
+}
+
+func getBaremetalOS(cfg *apiConfig, osID string) (*baremetalOS, error) {
+	path := fmt.Sprintf("/baremetal/v1/zones/%s/os/%s", cfg.zone, osID)
+	data, err := cfg.getAPIResponse(path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot query scaleway api for baremetal os %q: %w", osID, err)
+	}
+	var os baremetalOS
+	if err := json.Unmarshal(data, &os); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal scaleway baremetal os %q: %w", data, err)
+	}
+	return &os, nil
+}
+
+func appendBaremetalLabels(ms []*promutils.Labels, server *baremetalServer, os *baremetalOS, port int) []*promutils.Labels {
+	var ipv4, ipv6 string
+	for _, ip := range server.IPs {
+		switch ip.Version {
+		case "IPv4":
+			if ipv4 == "" {
+				ipv4 = ip.Address
+			}
+		case "IPv6":
+			if ipv6 == "" {
+				ipv6 = ip.Address
+			}
+		}
+	}
+	// IPv4 address is preferred over IPv6 address.
+	addr := ipv4
+	if addr == "" {
+		addr = ipv6
+	}
+	if addr == "" {
+		// The server has no IP addresses, so it cannot be scraped.
+		return ms
+	}
+	m := promutils.NewLabels(16)
+	m.Add("__address__", discoveryutils.JoinHostPort(addr, port))
+	m.Add("__meta_scaleway_baremetal_id", server.ID)
+	m.Add("__meta_scaleway_baremetal_name", server.Name)
+	m.Add("__meta_scaleway_baremetal_project_id", server.ProjectID)
+	m.Add("__meta_scaleway_baremetal_status", server.Status)
+	m.Add("__meta_scaleway_baremetal_type", server.OfferName)
+	m.Add("__meta_scaleway_baremetal_zone", server.Zone)
+	if ipv4 != "" {
+		m.Add("__meta_scaleway_baremetal_public_ipv4", ipv4)
+	}
+	if ipv6 != "" {
+		m.Add("__meta_scaleway_baremetal_public_ipv6", ipv6)
+	}
+	if os != nil {
+		m.Add("__meta_scaleway_baremetal_os_name", os.Name)
+		m.Add("__meta_scaleway_baremetal_os_version", os.Version)
+	}
+	if len(server.Tags) > 0 {
+		m.Add("__meta_scaleway_baremetal_tags", ","+strings.Join(server.Tags, ",")+",")
+	}
+	ms = append(ms, m)
+	return ms
+}
@@ -0,0 +1,143 @@
+package scaleway
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+const instancesPerPage = 100
+
+// instance represents Scaleway instance.
+//
+// See https://www.scaleway.com/en/developers/api/instance/#path-instances-list-all-instances
+type instance struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	Organization   string   `json:"organization"`
+	Project        string   `json:"project"`
+	CommercialType string   `json:"commercial_type"`
+	Hostname       string   `json:"hostname"`
+	Tags           []string `json:"tags"`
+	State          string   `json:"state"`
+	BootType       string   `json:"boot_type"`
+	Zone           string   `json:"zone"`
+	PrivateIP      *string  `json:"private_ip"`
+	PublicIP       *struct {
+		Address string `json:"address"`
+	} `json:"public_ip"`
+	IPv6 *struct {
+		Address string `json:"address"`
+	} `json:"ipv6"`
+	Image *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Arch string `json:"arch"`
+	} `json:"image"`
+	Location *struct {
+		ClusterID    string `json:"cluster_id"`
+		HypervisorID string `json:"hypervisor_id"`
+		NodeID       string `json:"node_id"`
+	} `json:"location"`
+	SecurityGroup *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"security_group"`
+}
+
+type listInstancesResponse struct {
+	Servers []instance `json:"servers"`
+}
+
+func getInstanceLabels(cfg *apiConfig) ([]*promutils.Labels, error) {
+	instances, err := getInstances(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var ms []*promutils.Labels
+	for i := range instances {
+		ms = appendInstanceLabels(ms, &instances[i], cfg.port)
+	}
+	return ms, nil
+}
+
+func getInstances(cfg *apiConfig) ([]instance, error) {
+	path := fmt.Sprintf("/instance/v1/zones/%s/servers", cfg.zone)
+	args := cfg.getFilterArgs("project")
+	args.Set("per_page", strconv.Itoa(instancesPerPage))
+	var instances []instance
+	for page := 1; ; page++ {
+		args.Set("page", strconv.Itoa(page))
+		data, err := cfg.getAPIResponse(path, args)
+		if err != nil {
+			return nil, fmt.Errorf("cannot query scaleway api for instances: %w", err)
+		}
+		var resp listInstancesResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal scaleway instances list %q: %w", data, err)
+		}
+		instances = append(instances, resp.Servers...)
+		if len(resp.Servers) < instancesPerPage {
+			return instances, nil
+		}
+	}
+}
+
+func appendInstanceLabels(ms []*promutils.Labels, inst *instance, port int) []*promutils.Labels {
+	var addr string
+	switch {
+	case inst.PrivateIP != nil && *inst.PrivateIP != "":
+		addr = *inst.PrivateIP
+	case inst.PublicIP != nil:
+		addr = inst.PublicIP.Address
+	case inst.IPv6 != nil:
+		addr = inst.IPv6.Address
+	default:
+		// The instance has no IP addresses, so it cannot be scraped.
+		return ms
+	}
+	m := promutils.NewLabels(24)
+	m.Add("__address__", discoveryutils.JoinHostPort(addr, port))
+	m.Add("__meta_scaleway_instance_boot_type", inst.BootType)
+	m.Add("__meta_scaleway_instance_hostname", inst.Hostname)
+	m.Add("__meta_scaleway_instance_id", inst.ID)
+	m.Add("__meta_scaleway_instance_name", inst.Name)
+	m.Add("__meta_scaleway_instance_organization_id", inst.Organization)
+	m.Add("__meta_scaleway_instance_project_id", inst.Project)
+	m.Add("__meta_scaleway_instance_status", inst.State)
+	m.Add("__meta_scaleway_instance_type", inst.CommercialType)
+	m.Add("__meta_scaleway_instance_zone", inst.Zone)
+	m.Add("__meta_scaleway_instance_region", getRegion(inst.Zone))
+	if inst.Image != nil {
+		m.Add("__meta_scaleway_instance_image_arch", inst.Image.Arch)
+		m.Add("__meta_scaleway_instance_image_id", inst.Image.ID)
+		m.Add("__meta_scaleway_instance_image_name", inst.Image.Name)
+	}
+	if inst.Location != nil {
+		m.Add("__meta_scaleway_instance_location_cluster_id", inst.Location.ClusterID)
+		m.Add("__meta_scaleway_instance_location_hypervisor_id", inst.Location.HypervisorID)
+		m.Add("__meta_scaleway_instance_location_node_id", inst.Location.NodeID)
+	}
+	if inst.SecurityGroup != nil {
+		m.Add("__meta_scaleway_instance_security_group_id", inst.SecurityGroup.ID)
+		m.Add("__meta_scaleway_instance_security_group_name", inst.SecurityGroup.Name)
+	}
+	if inst.PrivateIP != nil && *inst.PrivateIP != "" {
+		m.Add("__meta_scaleway_instance_private_ipv4", *inst.PrivateIP)
+	}
+	if inst.PublicIP != nil {
+		m.Add("__meta_scaleway_instance_public_ipv4", inst.PublicIP.Address)
+	}
+	if inst.IPv6 != nil {
+		m.Add("__meta_scaleway_instance_public_ipv6", inst.IPv6.Address)
+	}
+	if len(inst.Tags) > 0 {
+		m.Add("__meta_scaleway_instance_tags", ","+strings.Join(inst.Tags, ",")+",")
+	}
+	ms = append(ms, m)
+	return ms
+}
@@ -0,0 +1,59 @@
+package scaleway
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/proxy"
+)
+
+// SDCheckInterval defines interval for targets refresh.
+var SDCheckInterval = flag.Duration("promscrape.scalewaySDCheckInterval", time.Minute, "Interval for checking for changes in Scaleway API. "+
+	"This works only if scaleway_sd_configs is configured in '-promscrape.config' file. "+
+	"See https://docs.victoriametrics.com/sd_configs.html#scaleway_sd_configs for details")
+
+// SDConfig represents service discovery config for Scaleway.
+//
+// See https://prometheus.io/docs/prometheus/latest/configuration/configuration/#scaleway_sd_config
+type SDConfig struct {
+	// Role must be either `instance` or `baremetal`.
+	Role          string           `yaml:"role"`
+	ProjectID     string           `yaml:"project_id"`
+	AccessKey     string           `yaml:"access_key"`
+	SecretKey     *promauth.Secret `yaml:"secret_key,omitempty"`
+	SecretKeyFile string           `yaml:"secret_key_file,omitempty"`
+	Zone          string           `yaml:"zone,omitempty"`
+	APIURL        string           `yaml:"api_url,omitempty"`
+	Port          int              `yaml:"port,omitempty"`
+	NameFilter    string           `yaml:"name_filter,omitempty"`
+	TagsFilter    []string         `yaml:"tags_filter,omitempty"`
+
+	HTTPClientConfig  promauth.HTTPClientConfig  `yaml:",inline"`
+	ProxyURL          *proxy.URL                 `yaml:"proxy_url,omitempty"`
+	ProxyClientConfig promauth.ProxyClientConfig `yaml:",inline"`
+	// refresh_interval is obtained from `-promscrape.scalewaySDCheckInterval` command-line option.
+}
+
+// GetLabels returns Scaleway labels according to sdc.
+func (sdc *SDConfig) GetLabels(baseDir string) ([]*promutils.Labels, error) {
+	cfg, err := getAPIConfig(sdc, baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get API config: %w", err)
+	}
+	switch sdc.Role {
+	case "instance":
+		return getInstanceLabels(cfg)
+	case "baremetal":
+		return getBaremetalLabels(cfg)
+	default:
+		return nil, fmt.Errorf("skipping unexpected role=%q; must be one of `instance` or `baremetal`", sdc.Role)
+	}
+}
+
+// MustStop stops further usage for sdc.
+func (sdc *SDConfig) MustStop() {
+	configMap.Delete(sdc)
+}
@@ -0,0 +1,206 @@
+package scaleway
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promauth"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+func newTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Auth-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/instance/v1/zones/fr-par-1/servers":
+			if q.Get("project") != "project-1" || q.Get("tags") != "foo,bar" || q.Get("page") != "1" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{
+  "servers": [
+    {
+      "id": "93c18a61-b681-49d0-a1cc-62b43883ae89",
+      "name": "scw-nervous-shirley",
+      "organization": "20b3d507-96ac-454c-a795-bc731b46b12f",
+      "project": "project-1",
+      "commercial_type": "DEV1-S",
+      "hostname": "scw-nervous-shirley",
+      "image": {"id": "45a86b35-eca6-4055-9b34-ca69845da146", "name": "Ubuntu 20.04 Focal Fossa", "arch": "x86_64"},
+      "tags": ["foo", "bar"],
+      "state": "running",
+      "private_ip": "10.70.60.57",
+      "public_ip": {"id": "1", "address": "51.158.183.115", "dynamic": false},
+      "ipv6": {"address": "2001:bc8:630:1e1c::1", "gateway": "2001:bc8:630:1e1c::", "netmask": "64"},
+      "location": {"cluster_id": "40", "hypervisor_id": "1601", "node_id": "29", "platform_id": "14", "zone_id": "fr-par-1"},
+      "boot_type": "local",
+      "security_group": {"id": "984414da-9fc2-49c0-a925-fed6266fe092", "name": "Default security group"},
+      "zone": "fr-par-1"
+    },
+    {
+      "id": "5b6198b4-c677-41b5-9c05-04557264ae1f",
+      "name": "scw-quizzical-feistel",
+      "organization": "20b3d507-96ac-454c-a795-bc731b46b12f",
+      "project": "project-1",
+      "commercial_type": "DEV1-S",
+      "hostname": "scw-quizzical-feistel",
+      "image": null,
+      "tags": [],
+      "state": "stopped",
+      "private_ip": null,
+      "public_ip": {"id": "2", "address": "151.115.45.127", "dynamic": false},
+      "ipv6": null,
+      "location": null,
+      "boot_type": "local",
+      "security_group": null,
+      "zone": "fr-par-1"
+    },
+    {
+      "id": "no-ips",
+      "name": "no-ips",
+      "private_ip": null,
+      "public_ip": null,
+      "ipv6": null,
+      "zone": "fr-par-1"
+    }
+  ]
+}`)
+		case "/baremetal/v1/zones/fr-par-1/servers":
+			if q.Get("project_id") != "project-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{
+  "total_count": 1,
+  "servers": [
+    {
+      "id": "5ab0de6c-7b8d-4ba4-81f8-5c3d2f69a5e1",
+      "organization_id": "20b3d507-96ac-454c-a795-bc731b46b12f",
+      "project_id": "project-1",
+      "name": "scw-sleepy-lamarr",
+      "status": "ready",
+      "offer_name": "EM-B112X-SSD",
+      "tags": ["foo", "bar"],
+      "ips": [
+        {"id": "1", "address": "2001:bc8:1640:1568:dc00:ff:fe21:91b", "reverse": "", "version": "IPv6"},
+        {"id": "2", "address": "51.158.69.171", "reverse": "", "version": "IPv4"}
+      ],
+      "install": {"os_id": "7d1914e1-f4ab-4500-8f69-0b0f6b2a5f1b", "hostname": "scw-sleepy-lamarr"},
+      "zone": "fr-par-1"
+    }
+  ]
+}`)
+		case "/baremetal/v1/zones/fr-par-1/os/7d1914e1-f4ab-4500-8f69-0b0f6b2a5f1b":
+			fmt.Fprint(w, `{"id": "7d1914e1-f4ab-4500-8f69-0b0f6b2a5f1b", "name": "Ubuntu", "version": "20.04 LTS (Focal Fossa)"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestGetLabels(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	f := func(role string, expectedLabelss []*promutils.Labels) {
+		t.Helper()
+		sdc := &SDConfig{
+			Role:       role,
+			ProjectID:  "project-1",
+			AccessKey:  "access",
+			SecretKey:  promauth.NewSecret("secret"),
+			APIURL:     s.URL,
+			Port:       9100,
+			TagsFilter: []string{"foo", "bar"},
+		}
+		labelss, err := sdc.GetLabels("")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		defer sdc.MustStop()
+		discoveryutils.TestEqualLabelss(t, labelss, expectedLabelss)
+	}
+	f("instance", []*promutils.Labels{
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                                     "10.70.60.57:9100",
+			"__meta_scaleway_instance_boot_type":              "local",
+			"__meta_scaleway_instance_hostname":               "scw-nervous-shirley",
+			"__meta_scaleway_instance_id":                     "93c18a61-b681-49d0-a1cc-62b43883ae89",
+			"__meta_scaleway_instance_image_arch":             "x86_64",
+			"__meta_scaleway_instance_image_id":               "45a86b35-eca6-4055-9b34-ca69845da146",
+			"__meta_scaleway_instance_image_name":             "Ubuntu 20.04 Focal Fossa",
+			"__meta_scaleway_instance_location_cluster_id":    "40",
+			"__meta_scaleway_instance_location_hypervisor_id": "1601",
+			"__meta_scaleway_instance_location_node_id":       "29",
+			"__meta_scaleway_instance_name":                   "scw-nervous-shirley",
+			"__meta_scaleway_instance_organization_id":        "20b3d507-96ac-454c-a795-bc731b46b12f",
+			"__meta_scaleway_instance_private_ipv4":           "10.70.60.57",
+			"__meta_scaleway_instance_project_id":             "project-1",
+			"__meta_scaleway_instance_public_ipv4":            "51.158.183.115",
+			"__meta_scaleway_instance_public_ipv6":            "2001:bc8:630:1e1c::1",
+			"__meta_scaleway_instance_region":                 "fr-par",
+			"__meta_scaleway_instance_security_group_id":      "984414da-9fc2-49c0-a925-fed6266fe092",
+			"__meta_scaleway_instance_security_group_name":    "Default security group",
+			"__meta_scaleway_instance_status":                 "running",
+			"__meta_scaleway_instance_tags":                   ",foo,bar,",
+			"__meta_scaleway_instance_type":                   "DEV1-S",
+			"__meta_scaleway_instance_zone":                   "fr-par-1",
+		}),
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                              "151.115.45.127:9100",
+			"__meta_scaleway_instance_boot_type":       "local",
+			"__meta_scaleway_instance_hostname":        "scw-quizzical-feistel",
+			"__meta_scaleway_instance_id":              "5b6198b4-c677-41b5-9c05-04557264ae1f",
+			"__meta_scaleway_instance_name":            "scw-quizzical-feistel",
+			"__meta_scaleway_instance_organization_id": "20b3d507-96ac-454c-a795-bc731b46b12f",
+			"__meta_scaleway_instance_project_id":      "project-1",
+			"__meta_scaleway_instance_public_ipv4":     "151.115.45.127",
+			"__meta_scaleway_instance_region":          "fr-par",
+			"__meta_scaleway_instance_status":          "stopped",
+			"__meta_scaleway_instance_type":            "DEV1-S",
+			"__meta_scaleway_instance_zone":            "fr-par-1",
+		}),
+	})
+	f("baremetal", []*promutils.Labels{
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                           "51.158.69.171:9100",
+			"__meta_scaleway_baremetal_id":          "5ab0de6c-7b8d-4ba4-81f8-5c3d2f69a5e1",
+			"__meta_scaleway_baremetal_name":        "scw-sleepy-lamarr",
+			"__meta_scaleway_baremetal_os_name":     "Ubuntu",
+			"__meta_scaleway_baremetal_os_version":  "20.04 LTS (Focal Fossa)",
+			"__meta_scaleway_baremetal_project_id":  "project-1",
+			"__meta_scaleway_baremetal_public_ipv4": "51.158.69.171",
+			"__meta_scaleway_baremetal_public_ipv6": "2001:bc8:1640:1568:dc00:ff:fe21:91b",
+			"__meta_scaleway_baremetal_status":      "ready",
+			"__meta_scaleway_baremetal_tags":        ",foo,bar,",
+			"__meta_scaleway_baremetal_type":        "EM-B112X-SSD",
+			"__meta_scaleway_baremetal_zone":        "fr-par-1",
+		}),
+	})
+}
+
+func TestNewAPIConfigFailure(t *testing.T) {
+	f := func(sdc *SDConfig) {
+		t.Helper()
+		if _, err := newAPIConfig(sdc, ""); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+	}
+	// unsupported role
+	f(&SDConfig{Role: "foo", ProjectID: "a", AccessKey: "b", SecretKey: promauth.NewSecret("c")})
+	// missing project_id
+	f(&SDConfig{Role: "instance", AccessKey: "b", SecretKey: promauth.NewSecret("c")})
+	// missing access_key
+	f(&SDConfig{Role: "instance", ProjectID: "a", SecretKey: promauth.NewSecret("c")})
+	// missing secret_key
+	f(&SDConfig{Role: "instance", ProjectID: "a", AccessKey: "b"})
+	// both secret_key and secret_key_file
+	f(&SDConfig{Role: "instance", ProjectID: "a", AccessKey: "b", SecretKey: promauth.NewSecret("c"), SecretKeyFile: "/path/to/file"})
+}
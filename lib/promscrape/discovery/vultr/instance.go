@@ -0,0 +1,64 @@
+package vultr
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+// instance represents Vultr instance.
+//
+// See https://www.vultr.com/api/#tag/instances/operation/get-instance
+type instance struct {
+	ID               string   `json:"id"`
+	OS               string   `json:"os"`
+	RAM              int      `json:"ram"`
+	Disk             int      `json:"disk"`
+	MainIP           string   `json:"main_ip"`
+	VCPUCount        int      `json:"vcpu_count"`
+	Region           string   `json:"region"`
+	Plan             string   `json:"plan"`
+	AllowedBandwidth int      `json:"allowed_bandwidth"`
+	ServerStatus     string   `json:"server_status"`
+	V6MainIP         string   `json:"v6_main_ip"`
+	Label            string   `json:"label"`
+	InternalIP       string   `json:"internal_ip"`
+	Hostname         string   `json:"hostname"`
+	Tags             []string `json:"tags"`
+	OSID             int      `json:"os_id"`
+	Features         []string `json:"features"`
+}
+
+func addInstanceLabels(instances []instance, port int) []*promutils.Labels {
+	var ms []*promutils.Labels
+	for i := range instances {
+		inst := &instances[i]
+		m := promutils.NewLabels(18)
+		m.Add("__address__", discoveryutils.JoinHostPort(inst.MainIP, port))
+		m.Add("__meta_vultr_instance_id", inst.ID)
+		m.Add("__meta_vultr_instance_label", inst.Label)
+		m.Add("__meta_vultr_instance_os", inst.OS)
+		m.Add("__meta_vultr_instance_os_id", strconv.Itoa(inst.OSID))
+		m.Add("__meta_vultr_instance_region", inst.Region)
+		m.Add("__meta_vultr_instance_plan", inst.Plan)
+		m.Add("__meta_vultr_instance_main_ip", inst.MainIP)
+		m.Add("__meta_vultr_instance_internal_ip", inst.InternalIP)
+		m.Add("__meta_vultr_instance_main_ipv6", inst.V6MainIP)
+		m.Add("__meta_vultr_instance_hostname", inst.Hostname)
+		m.Add("__meta_vultr_instance_server_status", inst.ServerStatus)
+		m.Add("__meta_vultr_instance_vcpu_count", strconv.Itoa(inst.VCPUCount))
+		m.Add("__meta_vultr_instance_ram_mb", strconv.Itoa(inst.RAM))
+		m.Add("__meta_vultr_instance_disk_gb", strconv.Itoa(inst.Disk))
+		m.Add("__meta_vultr_instance_allowed_bandwidth_gb", strconv.Itoa(inst.AllowedBandwidth))
+		if len(inst.Features) > 0 {
+			m.Add("__meta_vultr_instance_features", ","+strings.Join(inst.Features, ",")+",")
+		}
+		if len(inst.Tags) > 0 {
+			m.Add("__meta_vultr_instance_tags", ","+strings.Join(inst.Tags, ",")+",")
+		}
+		ms = append(ms, m)
+	}
+	return ms
+}
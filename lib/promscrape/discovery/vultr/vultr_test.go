@@ -0,0 +1,128 @@
+package vultr
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promscrape/discoveryutils"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/promutils"
+)
+
+func TestGetLabels(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/instances" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			fmt.Fprint(w, `{
+  "instances": [
+    {
+      "id": "dbdbd38c-9884-4c92-95fe-899e50dee717",
+      "os": "Marketplace",
+      "ram": 4096,
+      "disk": 128,
+      "main_ip": "149.28.234.27",
+      "vcpu_count": 2,
+      "region": "ewr",
+      "plan": "vhf-2c-4gb",
+      "allowed_bandwidth": 3000,
+      "server_status": "ok",
+      "v6_main_ip": "",
+      "label": "np-2-eae38a19b0f3",
+      "internal_ip": "10.1.96.5",
+      "hostname": "np-2-eae38a19b0f3",
+      "tags": ["tag1", "tag2"],
+      "os_id": 426,
+      "features": ["backups"]
+    }
+  ],
+  "meta": {"total": 2, "links": {"next": "bmV4dF9fQU1T", "prev": ""}}
+}`)
+		case "bmV4dF9fQU1T":
+			fmt.Fprint(w, `{
+  "instances": [
+    {
+      "id": "fccb117c-62f7-4b17-995d-a8e56dd30b33",
+      "os": "Ubuntu 22.04 x64",
+      "ram": 1024,
+      "disk": 25,
+      "main_ip": "45.63.1.222",
+      "vcpu_count": 1,
+      "region": "ord",
+      "plan": "vc2-1c-1gb",
+      "allowed_bandwidth": 1000,
+      "server_status": "installingbooting",
+      "v6_main_ip": "2001:19f0:5c01:10b:5400:4ff:fe6d:e5ea",
+      "label": "web-1",
+      "internal_ip": "",
+      "hostname": "web-1",
+      "tags": [],
+      "os_id": 1743,
+      "features": ["ipv6", "auto_backups"]
+    }
+  ],
+  "meta": {"total": 2, "links": {"next": "", "prev": "cHJldl9fQU1T"}}
+}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer s.Close()
+
+	sdc := &SDConfig{
+		Server: s.URL,
+		Port:   8080,
+	}
+	labelss, err := sdc.GetLabels("")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer sdc.MustStop()
+
+	expectedLabelss := []*promutils.Labels{
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                                "149.28.234.27:8080",
+			"__meta_vultr_instance_id":                   "dbdbd38c-9884-4c92-95fe-899e50dee717",
+			"__meta_vultr_instance_label":                "np-2-eae38a19b0f3",
+			"__meta_vultr_instance_os":                   "Marketplace",
+			"__meta_vultr_instance_os_id":                "426",
+			"__meta_vultr_instance_region":               "ewr",
+			"__meta_vultr_instance_plan":                 "vhf-2c-4gb",
+			"__meta_vultr_instance_main_ip":              "149.28.234.27",
+			"__meta_vultr_instance_internal_ip":          "10.1.96.5",
+			"__meta_vultr_instance_main_ipv6":            "",
+			"__meta_vultr_instance_hostname":             "np-2-eae38a19b0f3",
+			"__meta_vultr_instance_server_status":        "ok",
+			"__meta_vultr_instance_vcpu_count":           "2",
+			"__meta_vultr_instance_ram_mb":               "4096",
+			"__meta_vultr_instance_disk_gb":              "128",
+			"__meta_vultr_instance_allowed_bandwidth_gb": "3000",
+			"__meta_vultr_instance_features":             ",backups,",
+			"__meta_vultr_instance_tags":                 ",tag1,tag2,",
+		}),
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                                "45.63.1.222:8080",
+			"__meta_vultr_instance_id":                   "fccb117c-62f7-4b17-995d-a8e56dd30b33",
+			"__meta_vultr_instance_label":                "web-1",
+			"__meta_vultr_instance_os":                   "Ubuntu 22.04 x64",
+			"__meta_vultr_instance_os_id":                "1743",
+			"__meta_vultr_instance_region":               "ord",
+			"__meta_vultr_instance_plan":                 "vc2-1c-1gb",
+			"__meta_vultr_instance_main_ip":              "45.63.1.222",
+			"__meta_vultr_instance_internal_ip":          "",
+			"__meta_vultr_instance_main_ipv6":            "2001:19f0:5c01:10b:5400:4ff:fe6d:e5ea",
+			"__meta_vultr_instance_hostname":             "web-1",
+			"__meta_vultr_instance_server_status":        "installingbooting",
+			"__meta_vultr_instance_vcpu_count":           "1",
+			"__meta_vultr_instance_ram_mb":               "1024",
+			"__meta_vultr_instance_disk_gb":              "25",
+			"__meta_vultr_instance_allowed_bandwidth_gb": "1000",
+			"__meta_vultr_instance_features":             ",ipv6,auto_backups,",
+		}),
+	}
+	discoveryutils.TestEqualLabelss(t, labelss, expectedLabelss)
+}
@@ -112,6 +112,20 @@ type ScrapeWork struct {
 	// The maximum number of metrics to scrape after relabeling.
 	SampleLimit int
 
+	// The maximum number of labels per scraped metric after relabeling.
+	LabelLimit int
+
+	// The maximum length of label name per scraped metric after relabeling.
+	LabelNameLengthLimit int
+
+	// The maximum length of label value per scraped metric after relabeling.
+	LabelValueLengthLimit int
+
+	// The maximum size of scrape response in bytes.
+	//
+	// -promscrape.maxScrapeSize is used if it isn't set.
+	BodySizeLimit int64
+
 	// Whether to disable response compression when querying ScrapeURL.
 	DisableCompression bool
 
@@ -142,9 +156,13 @@ type ScrapeWork struct {
 }
 
 func (sw *ScrapeWork) canSwitchToStreamParseMode() bool {
-	// Deny switching to stream parse mode if `sample_limit` or `series_limit` options are set,
+	// Deny switching to stream parse mode if `sample_limit`, `series_limit` or label limits options are set,
 	// since these limits cannot be applied in stream parsing mode.
-	return sw.SampleLimit <= 0 && sw.SeriesLimit <= 0
+	return sw.SampleLimit <= 0 && sw.SeriesLimit <= 0 && !sw.hasLabelLimits()
+}
+
+func (sw *ScrapeWork) hasLabelLimits() bool {
+	return sw.LabelLimit > 0 || sw.LabelNameLengthLimit > 0 || sw.LabelValueLengthLimit > 0
 }
 
 // key returns unique identifier for the given sw.
@@ -157,12 +175,14 @@ func (sw *ScrapeWork) key() string {
 	key := fmt.Sprintf("JobNameOriginal=%s, ScrapeURL=%s, ScrapeInterval=%s, ScrapeTimeout=%s, HonorLabels=%v, HonorTimestamps=%v, DenyRedirects=%v, Labels=%s, "+
 		"ExternalLabels=%s, "+
 		"ProxyURL=%s, ProxyAuthConfig=%s, AuthConfig=%s, MetricRelabelConfigs=%q, "+
-		"SampleLimit=%d, DisableCompression=%v, DisableKeepAlive=%v, StreamParse=%v, "+
+		"SampleLimit=%d, LabelLimit=%d, LabelNameLengthLimit=%d, LabelValueLengthLimit=%d, BodySizeLimit=%d, "+
+		"DisableCompression=%v, DisableKeepAlive=%v, StreamParse=%v, "+
 		"ScrapeAlignInterval=%s, ScrapeOffset=%s, SeriesLimit=%d, NoStaleMarkers=%v",
 		sw.jobNameOriginal, sw.ScrapeURL, sw.ScrapeInterval, sw.ScrapeTimeout, sw.HonorLabels, sw.HonorTimestamps, sw.DenyRedirects, sw.Labels.String(),
 		sw.ExternalLabels.String(),
 		sw.ProxyURL.String(), sw.ProxyAuthConfig.String(), sw.AuthConfig.String(), sw.MetricRelabelConfigs.String(),
-		sw.SampleLimit, sw.DisableCompression, sw.DisableKeepAlive, sw.StreamParse,
+		sw.SampleLimit, sw.LabelLimit, sw.LabelNameLengthLimit, sw.LabelValueLengthLimit, sw.BodySizeLimit,
+		sw.DisableCompression, sw.DisableKeepAlive, sw.StreamParse,
 		sw.ScrapeAlignInterval, sw.ScrapeOffset, sw.SeriesLimit, sw.NoStaleMarkers)
 	return key
 }
@@ -386,6 +406,7 @@ var (
 	scrapeResponseSize          = metrics.NewHistogram("vm_promscrape_scrape_response_size_bytes")
 	scrapedSamples              = metrics.NewHistogram("vm_promscrape_scraped_samples")
 	scrapesSkippedBySampleLimit = metrics.NewCounter("vm_promscrape_scrapes_skipped_by_sample_limit_total")
+	scrapesSkippedByLabelLimit  = metrics.NewCounter("vm_promscrape_scrapes_skipped_by_label_limit_total")
 	scrapesFailed               = metrics.NewCounter("vm_promscrape_scrapes_failed_total")
 	pushDataDuration            = metrics.NewHistogram("vm_promscrape_push_data_duration_seconds")
 )
@@ -413,6 +434,50 @@ func (sw *scrapeWork) getTargetResponse() ([]byte, error) {
 	return sw.ReadData(nil)
 }
 
+// writeDebugScrape scrapes sw target on demand and writes the parsed samples after metric relabeling to w.
+//
+// The scraped samples aren't sent to remote storage and the scrape isn't registered in the target status.
+// This is needed for troubleshooting flaky scrape targets.
+func (sw *scrapeWork) writeDebugScrape(w io.Writer) {
+	startTime := time.Now()
+	data, err := sw.getTargetResponse()
+	duration := time.Since(startTime)
+	fmt.Fprintf(w, "# scrape_url: %s\n", sw.Config.ScrapeURL)
+	fmt.Fprintf(w, "# scrape_duration_seconds: %.3f\n", duration.Seconds())
+	fmt.Fprintf(w, "# response_size_bytes: %d\n", len(data))
+	if err != nil {
+		fmt.Fprintf(w, "# error: %s\n", err)
+		return
+	}
+	var parseErrors []string
+	var wc writeRequestCtx
+	wc.rows.UnmarshalWithErrLogger(bytesutil.ToUnsafeString(data), func(s string) {
+		parseErrors = append(parseErrors, s)
+	})
+	scrapeTimestamp := startTime.UnixNano() / 1e6
+	srcRows := wc.rows.Rows
+	for i := range srcRows {
+		sw.addRowToTimeseries(&wc, &srcRows[i], scrapeTimestamp, true)
+	}
+	tss := wc.writeRequest.Timeseries
+	fmt.Fprintf(w, "# samples_scraped: %d\n", len(srcRows))
+	fmt.Fprintf(w, "# samples_post_metric_relabeling: %d\n", len(tss))
+	for _, s := range parseErrors {
+		fmt.Fprintf(w, "# parse error: %s\n", s)
+	}
+	if sw.Config.SampleLimit > 0 && len(tss) > sw.Config.SampleLimit {
+		fmt.Fprintf(w, "# error: the number of samples exceeds sample_limit=%d\n", sw.Config.SampleLimit)
+	}
+	if wc.labelLimitErr != nil {
+		fmt.Fprintf(w, "# error: %s\n", wc.labelLimitErr)
+	}
+	for _, ts := range tss {
+		for _, sample := range ts.Samples {
+			fmt.Fprintf(w, "%s %g %d\n", promrelabel.LabelsToString(ts.Labels), sample.Value, sample.Timestamp)
+		}
+	}
+}
+
 func (sw *scrapeWork) scrapeInternal(scrapeTimestamp, realTimestamp int64) error {
 	if *streamParse || sw.Config.StreamParse || sw.mustSwitchToStreamParseMode(sw.prevBodyLen) {
 		// Read data from scrape targets in streaming manner.
@@ -472,6 +537,11 @@ func (sw *scrapeWork) processScrapedData(scrapeTimestamp, realTimestamp int64, b
 		scrapesSkippedBySampleLimit.Inc()
 		err = fmt.Errorf("the response from %q exceeds sample_limit=%d; "+
 			"either reduce the sample count for the target or increase sample_limit", sw.Config.ScrapeURL, sw.Config.SampleLimit)
+	} else if wc.labelLimitErr != nil {
+		err = wc.labelLimitErr
+		wc.resetNoRows()
+		up = 0
+		scrapesSkippedByLabelLimit.Inc()
 	}
 	if up == 0 {
 		bodyString = ""
@@ -514,7 +584,7 @@ func (sw *scrapeWork) processScrapedData(scrapeTimestamp, realTimestamp int64, b
 		sw.storeLastScrape(body.B)
 	}
 	sw.finalizeLastScrape()
-	tsmGlobal.Update(sw, up == 1, realTimestamp, int64(duration*1000), samplesScraped, err)
+	tsmGlobal.Update(sw, up == 1, realTimestamp, int64(duration*1000), samplesScraped, seriesAdded, len(body.B), err)
 	return !mustSwitchToStreamParse, err
 }
 
@@ -590,6 +660,11 @@ func (sw *scrapeWork) scrapeStream(scrapeTimestamp, realTimestamp int64) error {
 					return fmt.Errorf("the response from %q exceeds sample_limit=%d; "+
 						"either reduce the sample count for the target or increase sample_limit", sw.Config.ScrapeURL, sw.Config.SampleLimit)
 				}
+				if err := wc.labelLimitErr; err != nil {
+					wc.resetNoRows()
+					scrapesSkippedByLabelLimit.Inc()
+					return err
+				}
 				if sw.seriesLimitExceeded || !areIdenticalSeries {
 					samplesDropped += sw.applySeriesLimit(wc)
 				}
@@ -644,7 +719,7 @@ func (sw *scrapeWork) scrapeStream(scrapeTimestamp, realTimestamp int64) error {
 		sw.storeLastScrape(sbr.body)
 	}
 	sw.finalizeLastScrape()
-	tsmGlobal.Update(sw, up == 1, realTimestamp, int64(duration*1000), samplesScraped, err)
+	tsmGlobal.Update(sw, up == 1, realTimestamp, int64(duration*1000), samplesScraped, seriesAdded, sbr.bodyLen, err)
 	// Do not track active series in streaming mode, since this may need too big amounts of memory
 	// when the target exports too big number of metrics.
 	return err
@@ -710,6 +785,9 @@ type writeRequestCtx struct {
 	writeRequest prompbmarshal.WriteRequest
 	labels       []prompbmarshal.Label
 	samples      []prompbmarshal.Sample
+
+	// labelLimitErr contains the first error for label_limit, label_name_length_limit or label_value_length_limit violation.
+	labelLimitErr error
 }
 
 func (wc *writeRequestCtx) reset() {
@@ -729,6 +807,8 @@ func (wc *writeRequestCtx) resetNoRows() {
 	wc.labels = wc.labels[:0]
 
 	wc.samples = wc.samples[:0]
+
+	wc.labelLimitErr = nil
 }
 
 var writeRequestCtxPool leveledWriteRequestCtxPool
@@ -741,6 +821,29 @@ func (sw *scrapeWork) getSeriesAdded(lastScrape, currScrape string) int {
 	return strings.Count(bodyString, "\n")
 }
 
+// checkLabelLimits returns an error if the given labels violate label_limit, label_name_length_limit or label_value_length_limit.
+func (sw *scrapeWork) checkLabelLimits(labels []prompbmarshal.Label) error {
+	cfg := sw.Config
+	if cfg.LabelLimit > 0 && len(labels) > cfg.LabelLimit {
+		return fmt.Errorf("the response from %q contains metric %s with %d labels, which exceeds label_limit=%d; "+
+			"either reduce the number of labels for the metric or increase label_limit",
+			cfg.ScrapeURL, promrelabel.LabelsToString(labels), len(labels), cfg.LabelLimit)
+	}
+	for _, label := range labels {
+		if cfg.LabelNameLengthLimit > 0 && len(label.Name) > cfg.LabelNameLengthLimit {
+			return fmt.Errorf("the response from %q contains metric %s with label name %q, which exceeds label_name_length_limit=%d; "+
+				"either reduce the label name length or increase label_name_length_limit",
+				cfg.ScrapeURL, promrelabel.LabelsToString(labels), label.Name, cfg.LabelNameLengthLimit)
+		}
+		if cfg.LabelValueLengthLimit > 0 && len(label.Value) > cfg.LabelValueLengthLimit {
+			return fmt.Errorf("the response from %q contains metric %s with label %q value, which exceeds label_value_length_limit=%d; "+
+				"either reduce the label value length or increase label_value_length_limit",
+				cfg.ScrapeURL, promrelabel.LabelsToString(labels), label.Name, cfg.LabelValueLengthLimit)
+		}
+	}
+	return nil
+}
+
 func (sw *scrapeWork) applySeriesLimit(wc *writeRequestCtx) int {
 	if sw.Config.SeriesLimit <= 0 {
 		return 0
@@ -935,6 +1038,10 @@ func (sw *scrapeWork) addRowToTimeseries(wc *writeRequestCtx, r *parser.Row, tim
 		// Skip row without labels.
 		return
 	}
+	if needRelabel && wc.labelLimitErr == nil && sw.Config.hasLabelLimits() {
+		// Check label limits before adding external labels like Prometheus does.
+		wc.labelLimitErr = sw.checkLabelLimits(wc.labels[labelsLen:])
+	}
 	// Add labels from `global->external_labels` section after the relabeling like Prometheus does.
 	// See https://github.com/VictoriaMetrics/VictoriaMetrics/issues/3137
 	externalLabels := sw.Config.ExternalLabels.GetLabels()
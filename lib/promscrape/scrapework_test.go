@@ -467,6 +467,127 @@ func TestScrapeWorkScrapeInternalSuccess(t *testing.T) {
 		scrape_series_limit_samples_dropped 1 123
 		scrape_timeout_seconds 42 123
 	`)
+	// Scrape success with the given label limits.
+	f(`
+		foo{bar="baz"} 34.44
+		bar{a="b",c="d"} -3e4
+	`, &ScrapeWork{
+		ScrapeTimeout:         time.Second * 42,
+		LabelLimit:            3,
+		LabelNameLengthLimit:  8,
+		LabelValueLengthLimit: 3,
+	}, `
+		foo{bar="baz"} 34.44 123
+		bar{a="b",c="d"} -3e4 123
+		up 1 123
+		scrape_samples_scraped 2 123
+		scrape_duration_seconds 0 123
+		scrape_samples_post_metric_relabeling 2 123
+		scrape_series_added 2 123
+		scrape_timeout_seconds 42 123
+	`)
+}
+
+func TestScrapeWorkScrapeInternalLabelLimits(t *testing.T) {
+	f := func(data string, cfg *ScrapeWork, dataExpected, errExpected string) {
+		t.Helper()
+
+		timeseriesExpected := parseData(dataExpected)
+
+		var sw scrapeWork
+		sw.Config = cfg
+
+		readDataCalls := 0
+		sw.ReadData = func(dst []byte) ([]byte, error) {
+			readDataCalls++
+			dst = append(dst, data...)
+			return dst, nil
+		}
+
+		pushDataCalls := 0
+		var pushDataErr error
+		sw.PushData = func(at *auth.Token, wr *prompbmarshal.WriteRequest) {
+			pushDataCalls++
+			if len(wr.Timeseries) > len(timeseriesExpected) {
+				pushDataErr = fmt.Errorf("too many time series obtained; got %d; want %d\ngot\n%+v\nwant\n%+v",
+					len(wr.Timeseries), len(timeseriesExpected), wr.Timeseries, timeseriesExpected)
+				return
+			}
+			tsExpected := timeseriesExpected[:len(wr.Timeseries)]
+			timeseriesExpected = timeseriesExpected[len(tsExpected):]
+			if err := expectEqualTimeseries(wr.Timeseries, tsExpected); err != nil {
+				pushDataErr = fmt.Errorf("unexpected data pushed: %w\ngot\n%v\nwant\n%v", err, wr.Timeseries, tsExpected)
+				return
+			}
+		}
+
+		timestamp := int64(123000)
+		err := sw.scrapeInternal(timestamp, timestamp)
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+		if !strings.Contains(err.Error(), errExpected) {
+			t.Fatalf("unexpected error; got %q; want it to contain %q", err, errExpected)
+		}
+		if pushDataErr != nil {
+			t.Fatalf("unexpected error: %s", pushDataErr)
+		}
+		if readDataCalls != 1 {
+			t.Fatalf("unexpected number of readData calls; got %d; want %d", readDataCalls, 1)
+		}
+		if pushDataCalls == 0 {
+			t.Fatalf("missing pushData calls")
+		}
+		if len(timeseriesExpected) != 0 {
+			t.Fatalf("%d series weren't pushed", len(timeseriesExpected))
+		}
+	}
+
+	// Scrape failure because of the exceeded LabelLimit
+	f(`
+		foo{bar="baz"} 34.44
+		bar{a="b",c="d"} -3e4
+	`, &ScrapeWork{
+		ScrapeTimeout: time.Second * 42,
+		LabelLimit:    2,
+	}, `
+		up 0 123
+		scrape_samples_scraped 2 123
+		scrape_duration_seconds 0 123
+		scrape_samples_post_metric_relabeling 2 123
+		scrape_series_added 0 123
+		scrape_timeout_seconds 42 123
+	`, "label_limit=2")
+	// Scrape failure because of the exceeded LabelNameLengthLimit
+	f(`
+		foo{bar="baz"} 34.44
+		bar{a="b",c="d"} -3e4
+	`, &ScrapeWork{
+		ScrapeTimeout:        time.Second * 42,
+		LabelNameLengthLimit: 2,
+	}, `
+		up 0 123
+		scrape_samples_scraped 2 123
+		scrape_duration_seconds 0 123
+		scrape_samples_post_metric_relabeling 2 123
+		scrape_series_added 0 123
+		scrape_timeout_seconds 42 123
+	`, "label_name_length_limit=2")
+	// Scrape failure because of the exceeded LabelValueLengthLimit
+	f(`
+		foo{bar="baz"} 34.44
+		bar{a="b",c="d"} -3e4
+	`, &ScrapeWork{
+		ScrapeTimeout:         time.Second * 42,
+		LabelValueLengthLimit: 2,
+	}, `
+		up 0 123
+		scrape_samples_scraped 2 123
+		scrape_duration_seconds 0 123
+		scrape_samples_post_metric_relabeling 2 123
+		scrape_series_added 0 123
+		scrape_timeout_seconds 42 123
+	`, "label_value_length_limit=2")
 }
 
 func TestAddRowToTimeseriesNoRelabeling(t *testing.T) {
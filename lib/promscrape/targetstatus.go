@@ -19,9 +19,14 @@ import (
 	"github.com/cespare/xxhash/v2"
 )
 
-var maxDroppedTargets = flag.Int("promscrape.maxDroppedTargets", 1000, "The maximum number of droppedTargets to show at /api/v1/targets page. "+
-	"Increase this value if your setup drops more scrape targets during relabeling and you need investigating labels for all the dropped targets. "+
-	"Note that the increased number of tracked dropped targets may result in increased memory usage")
+var (
+	maxDroppedTargets = flag.Int("promscrape.maxDroppedTargets", 1000, "The maximum number of droppedTargets to show at /api/v1/targets page. "+
+		"Increase this value if your setup drops more scrape targets during relabeling and you need investigating labels for all the dropped targets. "+
+		"Note that the increased number of tracked dropped targets may result in increased memory usage")
+	maxScrapeHistory = flag.Int("promscrape.maxScrapeHistory", 10, "The maximum number of recent scrape results to keep per each scrape target. "+
+		"The scrape history is shown at /targets page and at /api/v1/targets?scrape_history=1 . "+
+		"Set it to zero in order to disable tracking the scrape history")
+)
 
 var tsmGlobal = newTargetStatusMap()
 
@@ -43,6 +48,21 @@ func WriteTargetResponse(w http.ResponseWriter, r *http.Request) error {
 	return err
 }
 
+// WriteTargetDebug serves requests to /target_debug?id=<id>
+//
+// It scrapes the given target id on demand and writes the parsed samples after metric relabeling to w.
+// The scraped samples aren't sent to remote storage.
+func WriteTargetDebug(w http.ResponseWriter, r *http.Request) error {
+	targetID := r.FormValue("id")
+	sw := tsmGlobal.getScrapeWorkByTargetID(targetID)
+	if sw == nil {
+		return fmt.Errorf("cannot find target for id=%s", targetID)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	sw.writeDebugScrape(w)
+	return nil
+}
+
 // WriteHumanReadableTargetsStatus writes human-readable status for all the scrape targets to w according to r.
 func WriteHumanReadableTargetsStatus(w http.ResponseWriter, r *http.Request) {
 	filter := getRequestFilter(r)
@@ -65,13 +85,15 @@ func WriteServiceDiscovery(w http.ResponseWriter, r *http.Request) {
 }
 
 // WriteAPIV1Targets writes /api/v1/targets to w according to https://prometheus.io/docs/prometheus/latest/querying/api/#targets
-func WriteAPIV1Targets(w io.Writer, state string) {
+//
+// If showScrapeHistory is set, then the recent scrape results are written in `scrapeHistory` field for every active target.
+func WriteAPIV1Targets(w io.Writer, state string, showScrapeHistory bool) {
 	if state == "" {
 		state = "any"
 	}
 	fmt.Fprintf(w, `{"status":"success","data":{"activeTargets":`)
 	if state == "active" || state == "any" {
-		tsmGlobal.WriteActiveTargetsJSON(w)
+		tsmGlobal.WriteActiveTargetsJSON(w, showScrapeHistory)
 	} else {
 		fmt.Fprintf(w, `[]`)
 	}
@@ -122,7 +144,7 @@ func (tsm *targetStatusMap) Unregister(sw *scrapeWork) {
 	tsm.mu.Unlock()
 }
 
-func (tsm *targetStatusMap) Update(sw *scrapeWork, up bool, scrapeTime, scrapeDuration int64, samplesScraped int, err error) {
+func (tsm *targetStatusMap) Update(sw *scrapeWork, up bool, scrapeTime, scrapeDuration int64, samplesScraped, seriesAdded, responseSize int, err error) {
 	tsm.mu.Lock()
 	ts := tsm.m[sw]
 	if ts == nil {
@@ -140,6 +162,15 @@ func (tsm *targetStatusMap) Update(sw *scrapeWork, up bool, scrapeTime, scrapeDu
 		ts.scrapesFailed++
 	}
 	ts.err = err
+	ts.addScrapeResult(scrapeResult{
+		up:             up,
+		scrapeTime:     scrapeTime,
+		scrapeDuration: scrapeDuration,
+		samplesScraped: samplesScraped,
+		seriesAdded:    seriesAdded,
+		responseSize:   responseSize,
+		err:            err,
+	}, *maxScrapeHistory)
 	tsm.mu.Unlock()
 }
 
@@ -177,7 +208,7 @@ func (tsm *targetStatusMap) getActiveTargetStatuses() []targetStatus {
 	tsm.mu.Lock()
 	tss := make([]targetStatus, 0, len(tsm.m))
 	for _, ts := range tsm.m {
-		tss = append(tss, *ts)
+		tss = append(tss, ts.clone())
 	}
 	tsm.mu.Unlock()
 	// Sort discovered targets by __address__ label, so they stay in consistent order across calls
@@ -190,7 +221,9 @@ func (tsm *targetStatusMap) getActiveTargetStatuses() []targetStatus {
 }
 
 // WriteActiveTargetsJSON writes `activeTargets` contents to w according to https://prometheus.io/docs/prometheus/latest/querying/api/#targets
-func (tsm *targetStatusMap) WriteActiveTargetsJSON(w io.Writer) {
+//
+// If showScrapeHistory is set, then the recent scrape results are written in `scrapeHistory` field for every target.
+func (tsm *targetStatusMap) WriteActiveTargetsJSON(w io.Writer, showScrapeHistory bool) {
 	tss := tsm.getActiveTargetStatuses()
 	fmt.Fprintf(w, `[`)
 	for i, ts := range tss {
@@ -208,11 +241,11 @@ func (tsm *targetStatusMap) WriteActiveTargetsJSON(w io.Writer) {
 		fmt.Fprintf(w, `,"lastScrape":%q`, time.Unix(ts.scrapeTime/1000, (ts.scrapeTime%1000)*1e6).Format(time.RFC3339Nano))
 		fmt.Fprintf(w, `,"lastScrapeDuration":%g`, (time.Millisecond * time.Duration(ts.scrapeDuration)).Seconds())
 		fmt.Fprintf(w, `,"lastSamplesScraped":%d`, ts.samplesScraped)
-		state := "up"
-		if !ts.up {
-			state = "down"
+		if showScrapeHistory {
+			fmt.Fprintf(w, `,"scrapeHistory":`)
+			writeScrapeHistoryJSON(w, ts.history)
 		}
-		fmt.Fprintf(w, `,"health":%q}`, state)
+		fmt.Fprintf(w, `,"health":%q}`, getHealth(ts.up))
 		if i+1 < len(tss) {
 			fmt.Fprintf(w, `,`)
 		}
@@ -232,6 +265,32 @@ func writeLabelsJSON(w io.Writer, labels *promutils.Labels) {
 	fmt.Fprintf(w, `}`)
 }
 
+func writeScrapeHistoryJSON(w io.Writer, history []scrapeResult) {
+	fmt.Fprintf(w, `[`)
+	// Write the most recent scrape results first.
+	for i := len(history) - 1; i >= 0; i-- {
+		sr := &history[i]
+		fmt.Fprintf(w, `{"timestamp":%q`, sr.getScrapeTime().Format(time.RFC3339Nano))
+		fmt.Fprintf(w, `,"duration":%g`, (time.Millisecond * time.Duration(sr.scrapeDuration)).Seconds())
+		fmt.Fprintf(w, `,"samplesScraped":%d`, sr.samplesScraped)
+		fmt.Fprintf(w, `,"seriesAdded":%d`, sr.seriesAdded)
+		fmt.Fprintf(w, `,"responseSize":%d`, sr.responseSize)
+		fmt.Fprintf(w, `,"error":%q`, sr.getError())
+		fmt.Fprintf(w, `,"health":%q}`, getHealth(sr.up))
+		if i > 0 {
+			fmt.Fprintf(w, `,`)
+		}
+	}
+	fmt.Fprintf(w, `]`)
+}
+
+func getHealth(up bool) string {
+	if up {
+		return "up"
+	}
+	return "down"
+}
+
 type targetStatus struct {
 	sw             *scrapeWork
 	up             bool
@@ -241,6 +300,69 @@ type targetStatus struct {
 	scrapesTotal   int
 	scrapesFailed  int
 	err            error
+
+	// history contains up to -promscrape.maxScrapeHistory recent scrape results ordered by scrape time.
+	history []scrapeResult
+}
+
+// scrapeResult contains the outcome of a single scrape for the given target.
+type scrapeResult struct {
+	up bool
+
+	// scrapeTime is the scrape timestamp in milliseconds.
+	scrapeTime int64
+
+	// scrapeDuration is the scrape duration in milliseconds.
+	scrapeDuration int64
+
+	samplesScraped int
+	seriesAdded    int
+	responseSize   int
+	err            error
+}
+
+func (sr *scrapeResult) getScrapeTime() time.Time {
+	return time.Unix(sr.scrapeTime/1000, (sr.scrapeTime%1000)*1e6)
+}
+
+// getSummary returns human-readable summary for sr.
+func (sr *scrapeResult) getSummary() string {
+	summary := fmt.Sprintf("time: %s, duration: %dms, samples: %d, series added: %d, response size: %d bytes",
+		sr.getScrapeTime().Format(time.RFC3339), sr.scrapeDuration, sr.samplesScraped, sr.seriesAdded, sr.responseSize)
+	if sr.err != nil {
+		summary += ", error: " + sr.err.Error()
+	}
+	return summary
+}
+
+func (sr *scrapeResult) getError() string {
+	if sr.err == nil {
+		return ""
+	}
+	return sr.err.Error()
+}
+
+// addScrapeResult adds sr to ts.history, while keeping up to maxHistory recent scrape results there.
+func (ts *targetStatus) addScrapeResult(sr scrapeResult, maxHistory int) {
+	if maxHistory <= 0 {
+		ts.history = nil
+		return
+	}
+	if len(ts.history) < maxHistory {
+		ts.history = append(ts.history, sr)
+		return
+	}
+	// Drop the oldest scrape results. The history is updated in place, so the previously returned
+	// copies of ts must have their own copy of history - see clone().
+	n := copy(ts.history, ts.history[len(ts.history)-maxHistory+1:])
+	ts.history = append(ts.history[:n], sr)
+}
+
+// clone returns a copy of ts, which can be safely used without holding the lock on targetStatusMap.
+func (ts *targetStatus) clone() targetStatus {
+	tsCopy := *ts
+	tsCopy.history = append([]scrapeResult{}, ts.history...)
+	return tsCopy
 }
 
 func (ts *targetStatus) getDurationFromLastScrape() time.Duration {
@@ -352,7 +474,7 @@ func (tsm *targetStatusMap) getTargetsStatusByJob(filter *requestFilter) *target
 	tsm.mu.Lock()
 	for _, ts := range tsm.m {
 		jobName := ts.sw.Config.jobNameOriginal
-		byJob[jobName] = append(byJob[jobName], *ts)
+		byJob[jobName] = append(byJob[jobName], ts.clone())
 	}
 	jobNames := append([]string{}, tsm.jobNames...)
 	tsm.mu.Unlock()
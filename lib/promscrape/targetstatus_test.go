@@ -0,0 +1,78 @@
+package promscrape
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTargetStatusAddScrapeResult(t *testing.T) {
+	f := func(n, maxHistory int, scrapeTimesExpected []int64) {
+		t.Helper()
+		var ts targetStatus
+		for i := 0; i < n; i++ {
+			ts.addScrapeResult(scrapeResult{
+				scrapeTime: int64(i),
+			}, maxHistory)
+		}
+		var scrapeTimes []int64
+		for _, sr := range ts.history {
+			scrapeTimes = append(scrapeTimes, sr.scrapeTime)
+		}
+		if fmt.Sprintf("%v", scrapeTimes) != fmt.Sprintf("%v", scrapeTimesExpected) {
+			t.Fatalf("unexpected history; got %v; want %v", scrapeTimes, scrapeTimesExpected)
+		}
+	}
+	f(0, 3, nil)
+	f(2, 3, []int64{0, 1})
+	f(3, 3, []int64{0, 1, 2})
+	f(7, 3, []int64{4, 5, 6})
+	f(5, 1, []int64{4})
+	f(5, 0, nil)
+}
+
+func TestTargetStatusClone(t *testing.T) {
+	var ts targetStatus
+	for i := 0; i < 3; i++ {
+		ts.addScrapeResult(scrapeResult{
+			scrapeTime: int64(i),
+		}, 3)
+	}
+	tsCopy := ts.clone()
+	ts.addScrapeResult(scrapeResult{
+		scrapeTime: 3,
+	}, 3)
+	for i, sr := range tsCopy.history {
+		if sr.scrapeTime != int64(i) {
+			t.Fatalf("unexpected scrapeTime at position %d in the cloned history; got %d; want %d", i, sr.scrapeTime, i)
+		}
+	}
+}
+
+func TestWriteScrapeHistoryJSON(t *testing.T) {
+	history := []scrapeResult{
+		{
+			up:             true,
+			scrapeTime:     1000,
+			scrapeDuration: 123,
+			samplesScraped: 10,
+			seriesAdded:    2,
+			responseSize:   345,
+		},
+		{
+			up:             false,
+			scrapeTime:     2000,
+			scrapeDuration: 5000,
+			err:            fmt.Errorf("cannot read data: timeout"),
+		},
+	}
+	var bb bytes.Buffer
+	writeScrapeHistoryJSON(&bb, history)
+	resultExpected := fmt.Sprintf(`[{"timestamp":%q,"duration":5,"samplesScraped":0,"seriesAdded":0,"responseSize":0,"error":"cannot read data: timeout","health":"down"},`+
+		`{"timestamp":%q,"duration":0.123,"samplesScraped":10,"seriesAdded":2,"responseSize":345,"error":"","health":"up"}]`,
+		time.Unix(2, 0).Format(time.RFC3339Nano), time.Unix(1, 0).Format(time.RFC3339Nano))
+	if result := bb.String(); result != resultExpected {
+		t.Fatalf("unexpected result;\ngot\n%s\nwant\n%s", result, resultExpected)
+	}
+}
@@ -0,0 +1,92 @@
+package promutils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Bytes is size in bytes, which can be used in Prometheus-compatible yaml configs.
+//
+// It supports the following optional suffixes for values: B, KB, MB, GB, TB, KiB, MiB, GiB, TiB.
+// All the suffixes are base-2 in the same way as Prometheus does, e.g. both 10MB and 10MiB mean 10*1024*1024 bytes.
+type Bytes struct {
+	N int64
+
+	valueString string
+}
+
+// NewBytes returns Bytes for the given n.
+func NewBytes(n int64) *Bytes {
+	return &Bytes{
+		N: n,
+	}
+}
+
+// MarshalYAML implements yaml.Marshaler interface.
+func (b Bytes) MarshalYAML() (interface{}, error) {
+	if b.valueString != "" {
+		return b.valueString, nil
+	}
+	return b.N, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler interface.
+func (b *Bytes) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	n, err := parseBase2Bytes(s)
+	if err != nil {
+		return err
+	}
+	b.N = n
+	b.valueString = s
+	return nil
+}
+
+var base2Suffixes = []struct {
+	suffix     string
+	multiplier float64
+}{
+	{"KiB", 1 << 10},
+	{"MiB", 1 << 20},
+	{"GiB", 1 << 30},
+	{"TiB", 1 << 40},
+	{"KB", 1 << 10},
+	{"MB", 1 << 20},
+	{"GB", 1 << 30},
+	{"TB", 1 << 40},
+	{"B", 1},
+}
+
+func parseBase2Bytes(s string) (int64, error) {
+	value := strings.TrimSpace(s)
+	multiplier := float64(1)
+	for _, bs := range base2Suffixes {
+		if strings.HasSuffix(value, bs.suffix) {
+			value = value[:len(value)-len(bs.suffix)]
+			multiplier = bs.multiplier
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse size %q: %w", s, err)
+	}
+	if f < 0 {
+		return 0, fmt.Errorf("size cannot be negative; got %q", s)
+	}
+	return int64(f * multiplier), nil
+}
+
+// Size returns the size in bytes for b.
+//
+// It returns 0 if b is nil.
+func (b *Bytes) Size() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.N
+}
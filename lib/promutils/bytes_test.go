@@ -0,0 +1,68 @@
+package promutils
+
+import (
+	"testing"
+)
+
+func TestBytes(t *testing.T) {
+	var b *Bytes
+	if n := b.Size(); n != 0 {
+		t.Fatalf("unexpected size for nil Bytes; got %d; want 0", n)
+	}
+	b = NewBytes(1024)
+	if n := b.Size(); n != 1024 {
+		t.Fatalf("unexpected size; got %d; want %d", n, 1024)
+	}
+	v, err := b.MarshalYAML()
+	if err != nil {
+		t.Fatalf("unexpected error in MarshalYAML(): %s", err)
+	}
+	if n := v.(int64); n != 1024 {
+		t.Fatalf("unexpected value from MarshalYAML(); got %d; want %d", n, 1024)
+	}
+
+	f := func(s string, nExpected int64) {
+		t.Helper()
+		var b Bytes
+		if err := b.UnmarshalYAML(func(v interface{}) error {
+			sp := v.(*string)
+			*sp = s
+			return nil
+		}); err != nil {
+			t.Fatalf("unexpected error in UnmarshalYAML(%q): %s", s, err)
+		}
+		if b.Size() != nExpected {
+			t.Fatalf("unexpected size for %q; got %d; want %d", s, b.Size(), nExpected)
+		}
+		v, err := b.MarshalYAML()
+		if err != nil {
+			t.Fatalf("unexpected error in MarshalYAML(): %s", err)
+		}
+		if v.(string) != s {
+			t.Fatalf("unexpected value from MarshalYAML(); got %q; want %q", v, s)
+		}
+	}
+	f("123", 123)
+	f("123B", 123)
+	f("10KB", 10*1024)
+	f("10KiB", 10*1024)
+	f("10MB", 10*1024*1024)
+	f("1.5MiB", 1024*1024*3/2)
+	f("2GB", 2*1024*1024*1024)
+	f("1TiB", 1024*1024*1024*1024)
+
+	fError := func(s string) {
+		t.Helper()
+		var b Bytes
+		if err := b.UnmarshalYAML(func(v interface{}) error {
+			sp := v.(*string)
+			*sp = s
+			return nil
+		}); err == nil {
+			t.Fatalf("expecting non-nil error for invalid size %q", s)
+		}
+	}
+	fError("foobar")
+	fError("10XB")
+	fError("-1KB")
+}
@@ -14,7 +14,7 @@ import (
 // It also extracts Pushgateways-compatible extra labels from req.URL.Path
 // according to https://github.com/prometheus/pushgateway#url .
 func GetExtraLabels(req *http.Request) ([]prompbmarshal.Label, error) {
-	labels, err := getPushgatewayLabels(req.URL.Path)
+	labels, err := GetPushgatewayLabels(req.URL.Path)
 	if err != nil {
 		return nil, fmt.Errorf("cannot parse pushgateway-style labels from %q: %w", req.URL.Path, err)
 	}
@@ -32,7 +32,9 @@ func GetExtraLabels(req *http.Request) ([]prompbmarshal.Label, error) {
 	return labels, nil
 }
 
-func getPushgatewayLabels(path string) ([]prompbmarshal.Label, error) {
+// GetPushgatewayLabels extracts Pushgateway-compatible labels from the given path
+// according to https://github.com/prometheus/pushgateway#url .
+func GetPushgatewayLabels(path string) ([]prompbmarshal.Label, error) {
 	n := strings.Index(path, "/metrics/job")
 	if n < 0 {
 		return nil, nil
@@ -41,13 +41,13 @@ func TestGetExtraLabelsSuccess(t *testing.T) {
 func TestGetPushgatewayLabelsSuccess(t *testing.T) {
 	f := func(path, expectedLabels string) {
 		t.Helper()
-		labels, err := getPushgatewayLabels(path)
+		labels, err := GetPushgatewayLabels(path)
 		if err != nil {
-			t.Fatalf("unexpected error in getPushgatewayLabels(%q): %s", path, err)
+			t.Fatalf("unexpected error in GetPushgatewayLabels(%q): %s", path, err)
 		}
 		labelsStr := getLabelsString(labels)
 		if labelsStr != expectedLabels {
-			t.Fatalf("unexpected labels returned from getPushgatewayLabels(%q);\ngot\n%s\nwant\n%s", path, labelsStr, expectedLabels)
+			t.Fatalf("unexpected labels returned from GetPushgatewayLabels(%q);\ngot\n%s\nwant\n%s", path, labelsStr, expectedLabels)
 		}
 	}
 	f("", "{}")
@@ -67,10 +67,10 @@ func TestGetPushgatewayLabelsSuccess(t *testing.T) {
 func TestGetPushgatewayLabelsFailure(t *testing.T) {
 	f := func(path string) {
 		t.Helper()
-		labels, err := getPushgatewayLabels(path)
+		labels, err := GetPushgatewayLabels(path)
 		if err == nil {
 			labelsStr := getLabelsString(labels)
-			t.Fatalf("expecting non-nil error for getPushgatewayLabels(%q); got labels %s", path, labelsStr)
+			t.Fatalf("expecting non-nil error for GetPushgatewayLabels(%q); got labels %s", path, labelsStr)
 		}
 	}
 	// missing bar value
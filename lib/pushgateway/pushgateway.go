@@ -0,0 +1,508 @@
+package pushgateway
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/decimal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/flagutil"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/fs"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/logger"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/common"
+	parser "github.com/VictoriaMetrics/VictoriaMetrics/lib/protoparser/prometheus"
+	"github.com/VictoriaMetrics/metrics"
+)
+
+var (
+	reemitInterval = flag.Duration("pushgateway.reemitInterval", 30*time.Second, "Interval for re-emitting the last pushed metrics for every group "+
+		"registered via Pushgateway-compatible API at /api/v1/pushgateway/metrics/job/... . "+
+		"See https://docs.victoriametrics.com/#how-to-use-pushgateway-compatible-api")
+	maxRequestSize = flagutil.NewBytes("pushgateway.maxRequestSize", 16*1024*1024, "The maximum size in bytes of a single request "+
+		"to Pushgateway-compatible API at /api/v1/pushgateway/metrics/job/...")
+)
+
+const stateFilename = "state.json"
+
+// Init initializes Pushgateway-compatible API.
+//
+// The last pushed metrics for every group are persisted at stateDir and are passed to pushData
+// every -pushgateway.reemitInterval.
+//
+// MustStop must be called when the API is no longer needed.
+func Init(pushData func(wr *prompbmarshal.WriteRequest), stateDir string) {
+	fs.MustMkdirIfNotExist(stateDir)
+	path := filepath.Join(stateDir, stateFilename)
+	s := newStorage(pushData)
+	if err := s.loadState(path); err != nil {
+		logger.Fatalf("cannot load Pushgateway state from %q: %s", path, err)
+	}
+	storageGlobal.Store(s)
+	stopCh = make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.run(path, stopCh)
+	}()
+}
+
+// MustStop stops Pushgateway-compatible API and persists its state.
+//
+// RequestHandler returns an error for requests received after MustStop call.
+func MustStop() {
+	close(stopCh)
+	wg.Wait()
+	storageGlobal.Store(nil)
+}
+
+var (
+	storageGlobal atomic.Pointer[storage]
+	stopCh        chan struct{}
+	wg            sync.WaitGroup
+)
+
+// RequestHandler processes Pushgateway-compatible requests at /metrics/job/... path.
+//
+// PUT requests replace all the metrics in the group, POST requests replace only the metrics with the same names
+// as the pushed metrics, while DELETE requests delete the group.
+//
+// See https://github.com/prometheus/pushgateway#api
+func RequestHandler(w http.ResponseWriter, r *http.Request, path string) error {
+	if !strings.HasPrefix(path, "/metrics/job/") && !strings.HasPrefix(path, "/metrics/job@base64/") {
+		return fmt.Errorf("unsupported path %q; it must have the form /metrics/job/<job_name>{/<label_name>/<label_value>}", path)
+	}
+	groupLabels, err := common.GetPushgatewayLabels(path)
+	if err != nil {
+		return fmt.Errorf("cannot parse group labels from %q: %w", path, err)
+	}
+	groupLabels = normalizeGroupLabels(groupLabels)
+	if getLabelValue(groupLabels, "job") == "" {
+		return fmt.Errorf("job name cannot be empty at %q", path)
+	}
+	s := storageGlobal.Load()
+	if s == nil {
+		return fmt.Errorf("Pushgateway-compatible API is stopped")
+	}
+	switch r.Method {
+	case http.MethodDelete:
+		deleteRequests.Inc()
+		s.deleteGroup(groupLabels)
+		w.WriteHeader(http.StatusAccepted)
+		return nil
+	case http.MethodPut, http.MethodPost:
+		rows, err := readRows(r)
+		if err != nil {
+			return err
+		}
+		if r.Method == http.MethodPut {
+			putRequests.Inc()
+		} else {
+			postRequests.Inc()
+		}
+		s.push(groupLabels, rows, r.Method == http.MethodPut)
+		w.WriteHeader(http.StatusOK)
+		return nil
+	default:
+		return fmt.Errorf("unsupported HTTP method %q; Pushgateway-compatible API supports only PUT, POST and DELETE methods", r.Method)
+	}
+}
+
+func readRows(r *http.Request) ([]parser.Row, error) {
+	reader := io.Reader(r.Body)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := common.GetGzipReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read gzipped Pushgateway request: %w", err)
+		}
+		defer common.PutGzipReader(zr)
+		reader = zr
+	}
+	data, err := io.ReadAll(io.LimitReader(reader, maxRequestSize.N+1))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read Pushgateway request: %w", err)
+	}
+	if int64(len(data)) > maxRequestSize.N {
+		return nil, fmt.Errorf("too big request; mustn't exceed -pushgateway.maxRequestSize=%d bytes", maxRequestSize.N)
+	}
+	var rs parser.Rows
+	var parseErr error
+	rs.UnmarshalWithErrLogger(string(data), func(s string) {
+		if parseErr == nil {
+			parseErr = fmt.Errorf("%s", s)
+		}
+	})
+	if parseErr != nil {
+		return nil, fmt.Errorf("cannot parse pushed metrics: %w", parseErr)
+	}
+	for i := range rs.Rows {
+		row := &rs.Rows[i]
+		if row.Timestamp != 0 {
+			return nil, fmt.Errorf("pushed metrics mustn't contain timestamps; got %q with timestamp %d", row.Metric, row.Timestamp)
+		}
+	}
+	return rs.Rows, nil
+}
+
+// normalizeGroupLabels removes duplicate labels from labels and sorts them by name.
+//
+// The last label wins in case of duplicates.
+func normalizeGroupLabels(labels []prompbmarshal.Label) []prompbmarshal.Label {
+	return labelsFromMap(labelsToMap(labels))
+}
+
+func labelsFromMap(m map[string]string) []prompbmarshal.Label {
+	labels := make([]prompbmarshal.Label, 0, len(m))
+	for name, value := range m {
+		labels = append(labels, prompbmarshal.Label{
+			Name:  name,
+			Value: value,
+		})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+	return labels
+}
+
+func labelsToMap(labels []prompbmarshal.Label) map[string]string {
+	m := make(map[string]string, len(labels))
+	for _, label := range labels {
+		m[label.Name] = label.Value
+	}
+	return m
+}
+
+func getLabelValue(labels []prompbmarshal.Label, name string) string {
+	for _, label := range labels {
+		if label.Name == name {
+			return label.Value
+		}
+	}
+	return ""
+}
+
+func marshalLabels(dst []byte, labels []prompbmarshal.Label) []byte {
+	for _, label := range labels {
+		dst = append(dst, label.Name...)
+		dst = append(dst, '=')
+		dst = strconv.AppendQuote(dst, label.Value)
+		dst = append(dst, ',')
+	}
+	return dst
+}
+
+type storage struct {
+	pushData func(wr *prompbmarshal.WriteRequest)
+
+	mu     sync.Mutex
+	groups map[string]*group
+
+	// dirty is set to true when groups must be persisted to disk.
+	dirty bool
+}
+
+// group contains the last pushed metrics for the given grouping labels.
+type group struct {
+	labels []prompbmarshal.Label
+
+	// pushTime is the last push time in milliseconds.
+	pushTime int64
+
+	series []series
+}
+
+type series struct {
+	key    string
+	name   string
+	labels []prompbmarshal.Label
+	value  float64
+}
+
+func newStorage(pushData func(wr *prompbmarshal.WriteRequest)) *storage {
+	return &storage{
+		pushData: pushData,
+		groups:   make(map[string]*group),
+	}
+}
+
+func (s *storage) groupsCount() int {
+	s.mu.Lock()
+	n := len(s.groups)
+	s.mu.Unlock()
+	return n
+}
+
+func newSeries(groupLabels []prompbmarshal.Label, row *parser.Row) series {
+	m := make(map[string]string, len(row.Tags)+len(groupLabels)+1)
+	for _, tag := range row.Tags {
+		m[tag.Key] = tag.Value
+	}
+	// Grouping labels take precedence over the pushed labels with the same names.
+	for _, label := range groupLabels {
+		m[label.Name] = label.Value
+	}
+	m["__name__"] = row.Metric
+	labels := labelsFromMap(m)
+	return series{
+		key:    string(marshalLabels(nil, labels)),
+		name:   row.Metric,
+		labels: labels,
+		value:  row.Value,
+	}
+}
+
+func (s *storage) push(groupLabels []prompbmarshal.Label, rows []parser.Row, replaceAll bool) {
+	ss := make([]series, 0, len(rows))
+	seen := make(map[string]int, len(rows))
+	names := make(map[string]struct{})
+	for i := range rows {
+		sr := newSeries(groupLabels, &rows[i])
+		names[sr.name] = struct{}{}
+		if n, ok := seen[sr.key]; ok {
+			// The last pushed value wins for duplicate series.
+			ss[n] = sr
+			continue
+		}
+		seen[sr.key] = len(ss)
+		ss = append(ss, sr)
+	}
+	pushTime := time.Now().UnixNano() / 1e6
+	groupKey := string(marshalLabels(nil, groupLabels))
+
+	s.mu.Lock()
+	g := s.groups[groupKey]
+	if g == nil {
+		g = &group{
+			labels: groupLabels,
+		}
+		s.groups[groupKey] = g
+	}
+	var removed []series
+	for _, sr := range g.series {
+		_, replaced := names[sr.name]
+		if replaceAll || replaced {
+			if _, ok := seen[sr.key]; !ok {
+				removed = append(removed, sr)
+			}
+			continue
+		}
+		// POST keeps the previously pushed metrics with names missing in the pushed data.
+		ss = append(ss, sr)
+	}
+	g.series = ss
+	g.pushTime = pushTime
+	s.dirty = true
+	var wr prompbmarshal.WriteRequest
+	wr.Timeseries = appendStaleSeries(wr.Timeseries, removed, pushTime)
+	wr.Timeseries = g.appendTimeSeries(wr.Timeseries, pushTime)
+	s.mu.Unlock()
+
+	seriesPushed.Add(len(rows))
+	s.pushData(&wr)
+}
+
+func (s *storage) deleteGroup(groupLabels []prompbmarshal.Label) {
+	groupKey := string(marshalLabels(nil, groupLabels))
+	timestamp := time.Now().UnixNano() / 1e6
+
+	s.mu.Lock()
+	g := s.groups[groupKey]
+	if g == nil {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.groups, groupKey)
+	s.dirty = true
+	var wr prompbmarshal.WriteRequest
+	wr.Timeseries = appendStaleSeries(wr.Timeseries, g.series, timestamp)
+	wr.Timeseries = appendStaleSeries(wr.Timeseries, []series{g.newPushTimeSeries()}, timestamp)
+	s.mu.Unlock()
+
+	// Send staleness markers for the deleted series, so they disappear from query results immediately.
+	s.pushData(&wr)
+}
+
+// reemit pushes the last pushed metrics for all the groups with the given timestamp.
+func (s *storage) reemit(timestamp int64) {
+	var wr prompbmarshal.WriteRequest
+	s.mu.Lock()
+	for _, g := range s.groups {
+		wr.Timeseries = g.appendTimeSeries(wr.Timeseries, timestamp)
+	}
+	s.mu.Unlock()
+
+	if len(wr.Timeseries) == 0 {
+		return
+	}
+	seriesReemitted.Add(len(wr.Timeseries))
+	s.pushData(&wr)
+}
+
+func (s *storage) run(path string, stopCh <-chan struct{}) {
+	t := time.NewTicker(*reemitInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stopCh:
+			s.mustSaveStateIfNeeded(path)
+			return
+		case <-t.C:
+			s.reemit(time.Now().UnixNano() / 1e6)
+			s.mustSaveStateIfNeeded(path)
+		}
+	}
+}
+
+// appendTimeSeries appends the last pushed metrics for g with the given timestamp to dst
+// together with push_time_seconds metric.
+func (g *group) appendTimeSeries(dst []prompbmarshal.TimeSeries, timestamp int64) []prompbmarshal.TimeSeries {
+	for _, sr := range g.series {
+		dst = sr.appendTimeSeries(dst, sr.value, timestamp)
+	}
+	pts := g.newPushTimeSeries()
+	return pts.appendTimeSeries(dst, pts.value, timestamp)
+}
+
+func (g *group) newPushTimeSeries() series {
+	labels := make([]prompbmarshal.Label, 0, len(g.labels)+1)
+	labels = append(labels, prompbmarshal.Label{
+		Name:  "__name__",
+		Value: "push_time_seconds",
+	})
+	labels = append(labels, g.labels...)
+	return series{
+		name:   "push_time_seconds",
+		labels: labels,
+		value:  float64(g.pushTime) / 1e3,
+	}
+}
+
+func appendStaleSeries(dst []prompbmarshal.TimeSeries, ss []series, timestamp int64) []prompbmarshal.TimeSeries {
+	for _, sr := range ss {
+		dst = sr.appendTimeSeries(dst, decimal.StaleNaN, timestamp)
+	}
+	return dst
+}
+
+func (sr *series) appendTimeSeries(dst []prompbmarshal.TimeSeries, value float64, timestamp int64) []prompbmarshal.TimeSeries {
+	return append(dst, prompbmarshal.TimeSeries{
+		Labels: sr.labels,
+		Samples: []prompbmarshal.Sample{{
+			Value:     value,
+			Timestamp: timestamp,
+		}},
+	})
+}
+
+// groupState is the persisted state of a group.
+type groupState struct {
+	Labels   map[string]string `json:"labels"`
+	PushTime int64             `json:"pushTime"`
+	Series   []seriesState     `json:"series"`
+}
+
+type seriesState struct {
+	Labels map[string]string `json:"labels"`
+
+	// Value is stored as a string, since JSON doesn't support NaN and Inf values.
+	Value string `json:"value"`
+}
+
+func (s *storage) mustSaveStateIfNeeded(path string) {
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return
+	}
+	gss := make([]groupState, 0, len(s.groups))
+	for _, g := range s.groups {
+		gs := groupState{
+			Labels:   labelsToMap(g.labels),
+			PushTime: g.pushTime,
+			Series:   make([]seriesState, 0, len(g.series)),
+		}
+		for _, sr := range g.series {
+			gs.Series = append(gs.Series, seriesState{
+				Labels: labelsToMap(sr.labels),
+				Value:  strconv.FormatFloat(sr.value, 'g', -1, 64),
+			})
+		}
+		gss = append(gss, gs)
+	}
+	s.dirty = false
+	s.mu.Unlock()
+
+	sort.Slice(gss, func(i, j int) bool {
+		return gss[i].PushTime < gss[j].PushTime
+	})
+	data, err := json.Marshal(gss)
+	if err != nil {
+		logger.Panicf("BUG: cannot marshal Pushgateway state: %s", err)
+	}
+	fs.MustWriteAtomic(path, data, true)
+}
+
+func (s *storage) loadState(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var gss []groupState
+	if err := json.Unmarshal(data, &gss); err != nil {
+		return fmt.Errorf("cannot parse JSON: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, gs := range gss {
+		g := &group{
+			labels:   labelsFromMap(gs.Labels),
+			pushTime: gs.PushTime,
+			series:   make([]series, 0, len(gs.Series)),
+		}
+		for _, ss := range gs.Series {
+			v, err := strconv.ParseFloat(ss.Value, 64)
+			if err != nil {
+				return fmt.Errorf("cannot parse value for series %s: %w", ss.Labels, err)
+			}
+			labels := labelsFromMap(ss.Labels)
+			g.series = append(g.series, series{
+				key:    string(marshalLabels(nil, labels)),
+				name:   ss.Labels["__name__"],
+				labels: labels,
+				value:  v,
+			})
+		}
+		s.groups[string(marshalLabels(nil, g.labels))] = g
+	}
+	return nil
+}
+
+var (
+	putRequests     = metrics.NewCounter(`vm_pushgateway_requests_total{method="PUT"}`)
+	postRequests    = metrics.NewCounter(`vm_pushgateway_requests_total{method="POST"}`)
+	deleteRequests  = metrics.NewCounter(`vm_pushgateway_requests_total{method="DELETE"}`)
+	seriesPushed    = metrics.NewCounter(`vm_pushgateway_series_pushed_total`)
+	seriesReemitted = metrics.NewCounter(`vm_pushgateway_series_reemitted_total`)
+
+	_ = metrics.NewGauge(`vm_pushgateway_groups`, func() float64 {
+		s := storageGlobal.Load()
+		if s == nil {
+			return 0
+		}
+		return float64(s.groupsCount())
+	})
+)
@@ -0,0 +1,203 @@
+package pushgateway
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/decimal"
+	"github.com/VictoriaMetrics/VictoriaMetrics/lib/prompbmarshal"
+)
+
+type pushCollector struct {
+	result []string
+}
+
+func (pc *pushCollector) push(wr *prompbmarshal.WriteRequest) {
+	for _, ts := range wr.Timeseries {
+		labels := make([]string, 0, len(ts.Labels))
+		for _, label := range ts.Labels {
+			labels = append(labels, fmt.Sprintf("%s=%q", label.Name, label.Value))
+		}
+		value := ts.Samples[0].Value
+		valueStr := fmt.Sprintf("%g", value)
+		if decimal.IsStaleNaN(value) {
+			valueStr = "stale"
+		} else if strings.HasPrefix(labels[0], `__name__="push_time_seconds"`) {
+			valueStr = "<push_time>"
+		}
+		pc.result = append(pc.result, fmt.Sprintf("{%s} %s", strings.Join(labels, ","), valueStr))
+	}
+}
+
+func (pc *pushCollector) getResult() string {
+	sort.Strings(pc.result)
+	s := strings.Join(pc.result, "\n")
+	pc.result = pc.result[:0]
+	return s
+}
+
+func TestRequestHandlerSuccess(t *testing.T) {
+	var pc pushCollector
+	storageGlobal.Store(newStorage(pc.push))
+	defer func() {
+		storageGlobal.Store(nil)
+	}()
+
+	f := func(method, path, body string, statusCodeExpected int, resultExpected string) {
+		t.Helper()
+		r := httptest.NewRequest(method, "http://localhost"+path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		if err := RequestHandler(w, r, path); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if w.Code != statusCodeExpected {
+			t.Fatalf("unexpected status code; got %d; want %d", w.Code, statusCodeExpected)
+		}
+		if result := pc.getResult(); result != resultExpected {
+			t.Fatalf("unexpected result;\ngot\n%s\nwant\n%s", result, resultExpected)
+		}
+	}
+
+	// PUT creates a new group
+	f("PUT", "/metrics/job/foo/instance/bar", "a 1\nb{x=\"y\"} 2\n", http.StatusOK, `{__name__="a",instance="bar",job="foo"} 1
+{__name__="b",instance="bar",job="foo",x="y"} 2
+{__name__="push_time_seconds",instance="bar",job="foo"} <push_time>`)
+
+	// POST replaces only metrics with the same names
+	f("POST", "/metrics/job/foo/instance/bar", "b{x=\"z\"} 3\n", http.StatusOK, `{__name__="a",instance="bar",job="foo"} 1
+{__name__="b",instance="bar",job="foo",x="y"} stale
+{__name__="b",instance="bar",job="foo",x="z"} 3
+{__name__="push_time_seconds",instance="bar",job="foo"} <push_time>`)
+
+	// Grouping labels override pushed labels
+	f("POST", "/metrics/job@base64/Zm9v/instance/bar", "a{job=\"qwe\"} 4\n", http.StatusOK, `{__name__="a",instance="bar",job="foo"} 4
+{__name__="b",instance="bar",job="foo",x="z"} 3
+{__name__="push_time_seconds",instance="bar",job="foo"} <push_time>`)
+
+	// PUT replaces all the metrics in the group
+	f("PUT", "/metrics/job/foo/instance/bar", "c 5\n", http.StatusOK, `{__name__="a",instance="bar",job="foo"} stale
+{__name__="b",instance="bar",job="foo",x="z"} stale
+{__name__="c",instance="bar",job="foo"} 5
+{__name__="push_time_seconds",instance="bar",job="foo"} <push_time>`)
+
+	// Another group
+	f("PUT", "/metrics/job/foo", "c 6\n", http.StatusOK, `{__name__="c",job="foo"} 6
+{__name__="push_time_seconds",job="foo"} <push_time>`)
+
+	// Re-emit all the groups
+	storageGlobal.Load().reemit(123)
+	if result := pc.getResult(); result != `{__name__="c",instance="bar",job="foo"} 5
+{__name__="c",job="foo"} 6
+{__name__="push_time_seconds",instance="bar",job="foo"} <push_time>
+{__name__="push_time_seconds",job="foo"} <push_time>` {
+		t.Fatalf("unexpected result after reemit:\n%s", result)
+	}
+
+	// DELETE the group
+	f("DELETE", "/metrics/job/foo/instance/bar", "", http.StatusAccepted, `{__name__="c",instance="bar",job="foo"} stale
+{__name__="push_time_seconds",instance="bar",job="foo"} stale`)
+
+	// DELETE missing group
+	f("DELETE", "/metrics/job/foo/instance/baz", "", http.StatusAccepted, ``)
+
+	if n := storageGlobal.Load().groupsCount(); n != 1 {
+		t.Fatalf("unexpected number of groups; got %d; want 1", n)
+	}
+}
+
+func TestRequestHandlerFailure(t *testing.T) {
+	var pc pushCollector
+	storageGlobal.Store(newStorage(pc.push))
+	defer func() {
+		storageGlobal.Store(nil)
+	}()
+
+	f := func(method, path, body string) {
+		t.Helper()
+		r := httptest.NewRequest(method, "http://localhost"+path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		if err := RequestHandler(w, r, path); err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+		if len(pc.result) > 0 {
+			t.Fatalf("unexpected data pushed: %s", pc.result)
+		}
+	}
+
+	// Unsupported method
+	f("GET", "/metrics/job/foo", "")
+
+	// Missing job
+	f("PUT", "/metrics", "a 1")
+	f("PUT", "/metrics/instance/foo", "a 1")
+	f("PUT", "/metrics/job/", "a 1")
+
+	// Missing label value
+	f("PUT", "/metrics/job/foo/instance", "a 1")
+
+	// Invalid data
+	f("PUT", "/metrics/job/foo", "a{ 1")
+
+	// Timestamps aren't allowed
+	f("PUT", "/metrics/job/foo", "a 1 123")
+
+	// Requests after MustStop
+	storageGlobal.Store(nil)
+	f("PUT", "/metrics/job/foo", "a 1")
+	f("DELETE", "/metrics/job/foo", "")
+}
+
+func TestStorageSaveLoadState(t *testing.T) {
+	var pc pushCollector
+	s := newStorage(pc.push)
+	storageGlobal.Store(s)
+	defer func() {
+		storageGlobal.Store(nil)
+	}()
+	r := httptest.NewRequest("PUT", "http://localhost/metrics/job/foo", strings.NewReader("a{x=\"y\"} 1\nb +Inf\nc NaN\n"))
+	if err := RequestHandler(httptest.NewRecorder(), r, "/metrics/job/foo"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	path := filepath.Join(t.TempDir(), stateFilename)
+	s.mustSaveStateIfNeeded(path)
+
+	s2 := newStorage(pc.push)
+	if err := s2.loadState(path); err != nil {
+		t.Fatalf("cannot load state: %s", err)
+	}
+	g := s2.groups[string(marshalLabels(nil, []prompbmarshal.Label{{Name: "job", Value: "foo"}}))]
+	if g == nil {
+		t.Fatalf("missing group after loading the state")
+	}
+	gOrig := s.groups[string(marshalLabels(nil, []prompbmarshal.Label{{Name: "job", Value: "foo"}}))]
+	if g.pushTime != gOrig.pushTime {
+		t.Fatalf("unexpected push time; got %d; want %d", g.pushTime, gOrig.pushTime)
+	}
+	if len(g.series) != 3 {
+		t.Fatalf("unexpected number of series; got %d; want 3", len(g.series))
+	}
+	values := make(map[string]float64)
+	for _, sr := range g.series {
+		values[sr.key] = sr.value
+	}
+	for _, sr := range gOrig.series {
+		v, ok := values[sr.key]
+		if !ok {
+			t.Fatalf("missing series %s", sr.key)
+		}
+		if v != sr.value && !(math.IsNaN(v) && math.IsNaN(sr.value)) {
+			t.Fatalf("unexpected value for series %s; got %v; want %v", sr.key, v, sr.value)
+		}
+	}
+
+	// Loading missing state must succeed
+	if err := newStorage(pc.push).loadState(filepath.Join(t.TempDir(), "missing")); err != nil {
+		t.Fatalf("unexpected error when loading missing state: %s", err)
+	}
+}
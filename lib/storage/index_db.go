@@ -1771,7 +1771,7 @@ func (db *indexDB) searchMetricIDs(qt *querytracer.Tracer, tfss []*TagFilters, t
 	localMetricIDs, err := is.searchMetricIDs(qtChild, tfss, tr, maxMetrics)
 	db.putIndexSearch(is)
 	if err != nil {
-		return nil, fmt.Errorf("error when searching for metricIDs in the current indexdb: %s", err)
+		return nil, fmt.Errorf("error when searching for metricIDs in the current indexdb: %w", err)
 	}
 	qtChild.Done()
 
@@ -1796,7 +1796,7 @@ func (db *indexDB) searchMetricIDs(qt *querytracer.Tracer, tfss []*TagFilters, t
 		extDB.putMetricIDsToTagFiltersCache(qtChild, extMetricIDs, tfKeyExtBuf.B)
 	})
 	if err != nil {
-		return nil, fmt.Errorf("error when searching for metricIDs in the previous indexdb: %s", err)
+		return nil, fmt.Errorf("error when searching for metricIDs in the previous indexdb: %w", err)
 	}
 
 	// Merge localMetricIDs with extMetricIDs.
@@ -2341,8 +2341,8 @@ func (is *indexSearch) searchMetricIDsInternal(qt *querytracer.Tracer, tfss []*T
 			return nil, err
 		}
 		if metricIDs.Len() > maxMetrics {
-			return nil, fmt.Errorf("the number of matching timeseries exceeds %d; either narrow down the search "+
-				"or increase -search.max* command-line flag values at vmselect; see https://docs.victoriametrics.com/#resource-usage-limits", maxMetrics)
+			return nil, newTooManyTimeseriesError(fmt.Sprintf("the number of matching timeseries exceeds %d; either narrow down the search "+
+				"or increase -search.max* command-line flag values at vmselect; see https://docs.victoriametrics.com/#resource-usage-limits", maxMetrics))
 		}
 	}
 	return metricIDs, nil
@@ -2364,8 +2364,8 @@ func (is *indexSearch) updateMetricIDsForTagFilters(qt *querytracer.Tracer, metr
 	m, err := is.getMetricIDsForDateAndFilters(qt, 0, tfs, maxMetrics)
 	if err != nil {
 		if errors.Is(err, errFallbackToGlobalSearch) {
-			return fmt.Errorf("the number of matching timeseries exceeds %d; either narrow down the search "+
-				"or increase -search.max* command-line flag values at vmselect", maxMetrics)
+			return newTooManyTimeseriesError(fmt.Sprintf("the number of matching timeseries exceeds %d; either narrow down the search "+
+				"or increase -search.max* command-line flag values at vmselect", maxMetrics))
 		}
 		return err
 	}
@@ -2399,6 +2399,29 @@ func (is *indexSearch) getMetricIDsForTagFilter(qt *querytracer.Tracer, tf *tagF
 
 var errTooManyLoops = fmt.Errorf("too many loops is needed for applying this filter")
 
+// ErrTooManyTimeseries is returned when the number of timeseries matching the search exceeds the given limit.
+//
+// Use errors.Is for checking whether the returned error is caused by this limit.
+var ErrTooManyTimeseries = errors.New("too many matching timeseries")
+
+type tooManyTimeseriesError struct {
+	msg string
+}
+
+func newTooManyTimeseriesError(msg string) error {
+	return &tooManyTimeseriesError{
+		msg: msg,
+	}
+}
+
+func (e *tooManyTimeseriesError) Error() string {
+	return e.msg
+}
+
+func (e *tooManyTimeseriesError) Is(target error) bool {
+	return target == ErrTooManyTimeseries
+}
+
 func (is *indexSearch) getMetricIDsForTagFilterSlow(tf *tagFilter, f func(metricID uint64), maxLoopsCount int64) (int64, error) {
 	if len(tf.orSuffixes) > 0 {
 		logger.Panicf("BUG: the getMetricIDsForTagFilterSlow must be called only for empty tf.orSuffixes; got %s", tf.orSuffixes)
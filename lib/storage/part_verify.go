@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// VerifyPartHeaders verifies headers for the part with the given files.
+//
+// fileSizes must contain sizes for all the files in the part directory.
+// readFile must return the contents of the file with the given name from the part directory.
+// Only small files with part headers are read via readFile.
+func VerifyPartHeaders(fileSizes map[string]uint64, readFile func(name string) ([]byte, error)) error {
+	for _, name := range []string{metaindexFilename, indexFilename, timestampsFilename, valuesFilename} {
+		if _, ok := fileSizes[name]; !ok {
+			return fmt.Errorf("missing %q file", name)
+		}
+	}
+
+	var ph partHeader
+	hasMetadata := false
+	if _, ok := fileSizes[metadataFilename]; ok {
+		// Parts created before v1.90.0 have no metadata file.
+		metadata, err := readFile(metadataFilename)
+		if err != nil {
+			return fmt.Errorf("cannot read %q: %w", metadataFilename, err)
+		}
+		ph.Reset()
+		if err := json.Unmarshal(metadata, &ph); err != nil {
+			return fmt.Errorf("cannot parse %q: %w", metadataFilename, err)
+		}
+		if ph.MinTimestamp > ph.MaxTimestamp {
+			return fmt.Errorf("minTimestamp cannot exceed maxTimestamp in %q; got %d vs %d", metadataFilename, ph.MinTimestamp, ph.MaxTimestamp)
+		}
+		if ph.RowsCount <= 0 {
+			return fmt.Errorf("rowsCount must be greater than 0 in %q; got %d", metadataFilename, ph.RowsCount)
+		}
+		if ph.BlocksCount <= 0 {
+			return fmt.Errorf("blocksCount must be greater than 0 in %q; got %d", metadataFilename, ph.BlocksCount)
+		}
+		if ph.BlocksCount > ph.RowsCount {
+			return fmt.Errorf("blocksCount cannot be bigger than rowsCount in %q; got blocksCount=%d, rowsCount=%d", metadataFilename, ph.BlocksCount, ph.RowsCount)
+		}
+		hasMetadata = true
+	}
+
+	metaindex, err := readFile(metaindexFilename)
+	if err != nil {
+		return fmt.Errorf("cannot read %q: %w", metaindexFilename, err)
+	}
+	mrs, err := unmarshalMetaindexRows(nil, bytes.NewReader(metaindex))
+	if err != nil {
+		return fmt.Errorf("cannot parse %q: %w", metaindexFilename, err)
+	}
+	indexSize := fileSizes[indexFilename]
+	blocksCount := uint64(0)
+	for i := range mrs {
+		mr := &mrs[i]
+		if mr.IndexBlockOffset+uint64(mr.IndexBlockSize) > indexSize {
+			return fmt.Errorf("index block #%d at offset %d with size %d is out of %q file with size %d",
+				i, mr.IndexBlockOffset, mr.IndexBlockSize, indexFilename, indexSize)
+		}
+		blocksCount += uint64(mr.BlockHeadersCount)
+	}
+	if hasMetadata && blocksCount != ph.BlocksCount {
+		return fmt.Errorf("the number of blocks in %q doesn't match %q; got %d vs %d", metaindexFilename, metadataFilename, blocksCount, ph.BlocksCount)
+	}
+	return nil
+}
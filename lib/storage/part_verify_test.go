@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestVerifyPartHeaders(t *testing.T) {
+	var rows []rawRow
+	for i := 0; i < 10000; i++ {
+		rows = append(rows, rawRow{
+			TSID: TSID{
+				MetricID: uint64(i % 100),
+			},
+			Timestamp:     int64(i),
+			Value:         float64(i),
+			PrecisionBits: defaultPrecisionBits,
+		})
+	}
+	mp := newTestInmemoryPart(rows)
+	metadata, err := json.Marshal(&mp.ph)
+	if err != nil {
+		t.Fatalf("cannot marshal part header: %s", err)
+	}
+	newFiles := func() map[string][]byte {
+		return map[string][]byte{
+			metaindexFilename:  append([]byte{}, mp.metaindexData.B...),
+			indexFilename:      append([]byte{}, mp.indexData.B...),
+			timestampsFilename: append([]byte{}, mp.timestampsData.B...),
+			valuesFilename:     append([]byte{}, mp.valuesData.B...),
+			metadataFilename:   append([]byte{}, metadata...),
+		}
+	}
+	verify := func(files map[string][]byte) error {
+		fileSizes := make(map[string]uint64, len(files))
+		for name, data := range files {
+			fileSizes[name] = uint64(len(data))
+		}
+		return VerifyPartHeaders(fileSizes, func(name string) ([]byte, error) {
+			data, ok := files[name]
+			if !ok {
+				return nil, fmt.Errorf("missing file %q", name)
+			}
+			return data, nil
+		})
+	}
+	f := func(name string, modify func(files map[string][]byte), resultExpected bool) {
+		t.Helper()
+		files := newFiles()
+		modify(files)
+		err := verify(files)
+		if resultExpected && err != nil {
+			t.Fatalf("%s: unexpected error: %s", name, err)
+		}
+		if !resultExpected && err == nil {
+			t.Fatalf("%s: expecting non-nil error", name)
+		}
+	}
+
+	f("valid part", func(files map[string][]byte) {}, true)
+	f("part without metadata", func(files map[string][]byte) {
+		delete(files, metadataFilename)
+	}, true)
+	f("missing values", func(files map[string][]byte) {
+		delete(files, valuesFilename)
+	}, false)
+	f("truncated index", func(files map[string][]byte) {
+		files[indexFilename] = files[indexFilename][:len(files[indexFilename])-1]
+	}, false)
+	f("corrupted metaindex", func(files map[string][]byte) {
+		files[metaindexFilename] = []byte("foobar")
+	}, false)
+	f("invalid metadata", func(files map[string][]byte) {
+		files[metadataFilename] = []byte("{")
+	}, false)
+	f("blocks count mismatch", func(files map[string][]byte) {
+		ph := mp.ph
+		ph.BlocksCount++
+		data, err := json.Marshal(&ph)
+		if err != nil {
+			t.Fatalf("cannot marshal part header: %s", err)
+		}
+		files[metadataFilename] = data
+	}, false)
+}
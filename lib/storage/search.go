@@ -54,6 +54,11 @@ func (br *BlockRef) RowsCount() int {
 	return int(br.bh.RowsCount)
 }
 
+// SizeBytes returns the size of compressed data for br.
+func (br *BlockRef) SizeBytes() int {
+	return int(br.bh.TimestampsBlockSize) + int(br.bh.ValuesBlockSize)
+}
+
 // PartRef returns PartRef from br.
 func (br *BlockRef) PartRef() PartRef {
 	return PartRef{
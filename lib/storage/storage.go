@@ -52,6 +52,11 @@ type Storage struct {
 	hourlySeriesLimitRowsDropped uint64
 	dailySeriesLimitRowsDropped  uint64
 
+	// sampleDensityBits holds float64 bits for the estimated number of samples per series per second.
+	//
+	// It is updated by currHourMetricIDsUpdater.
+	sampleDensityBits uint64
+
 	path           string
 	cachePath      string
 	retentionMsecs int64
@@ -671,6 +676,7 @@ var currHourMetricIDsUpdateInterval = time.Second * 10
 func (s *Storage) currHourMetricIDsUpdater() {
 	ticker := time.NewTicker(currHourMetricIDsUpdateInterval)
 	defer ticker.Stop()
+	var sdu sampleDensityUpdater
 	for {
 		select {
 		case <-s.stop:
@@ -680,10 +686,54 @@ func (s *Storage) currHourMetricIDsUpdater() {
 		case <-ticker.C:
 			hour := fasttime.UnixHour()
 			s.updateCurrHourMetricIDs(hour)
+			sdu.update(s)
 		}
 	}
 }
 
+// sampleDensityUpdater estimates the number of samples per series per second
+// from the ingestion rate and the number of active series.
+type sampleDensityUpdater struct {
+	prevRowsAdded  uint64
+	prevUpdateTime time.Time
+}
+
+func (sdu *sampleDensityUpdater) update(s *Storage) {
+	rowsAdded := atomic.LoadUint64(&rowsAddedTotal)
+	currentTime := time.Now()
+	prevRowsAdded := sdu.prevRowsAdded
+	prevUpdateTime := sdu.prevUpdateTime
+	sdu.prevRowsAdded = rowsAdded
+	sdu.prevUpdateTime = currentTime
+	if prevUpdateTime.IsZero() || rowsAdded <= prevRowsAdded {
+		return
+	}
+	// Use the maximum number of series for the current and the previous hour,
+	// since the current hour may contain only a small part of active series just after its start.
+	activeSeries := s.currHourMetricIDs.Load().(*hourMetricIDs).m.Len()
+	if n := s.prevHourMetricIDs.Load().(*hourMetricIDs).m.Len(); n > activeSeries {
+		activeSeries = n
+	}
+	secs := currentTime.Sub(prevUpdateTime).Seconds()
+	if activeSeries == 0 || secs <= 0 {
+		return
+	}
+	density := float64(rowsAdded-prevRowsAdded) / secs / float64(activeSeries)
+	if prevDensity := s.GetSampleDensity(); prevDensity > 0 {
+		// Smooth out ingestion spikes.
+		density = 0.8*prevDensity + 0.2*density
+	}
+	atomic.StoreUint64(&s.sampleDensityBits, math.Float64bits(density))
+}
+
+// GetSampleDensity returns the estimated number of samples per series per second.
+//
+// The estimation is based on the ingestion rate for active series.
+// Zero is returned if there is no enough data for the estimation.
+func (s *Storage) GetSampleDensity() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&s.sampleDensityBits))
+}
+
 var nextDayMetricIDsUpdateInterval = time.Second * 11
 
 func (s *Storage) nextDayMetricIDsUpdater() {
@@ -1393,6 +1443,40 @@ func (s *Storage) GetSeriesCount(deadline uint64) (uint64, error) {
 	return s.idb().GetSeriesCount(deadline)
 }
 
+// GetSeriesCountPerDay returns the number of series matching tfss per each day on the given tr.
+//
+// The i-th item in the returned slice contains the number of series for the day tr.MinTimestamp/msecPerDay+i.
+// The number of series per day is limited by maxMetrics.
+// Series are searched in both the current and the previous indexdb.
+func (s *Storage) GetSeriesCountPerDay(qt *querytracer.Tracer, tfss []*TagFilters, tr TimeRange, maxMetrics int, deadline uint64) ([]int, error) {
+	qt = qt.NewChild("get the number of series per day: filters=%s, timeRange=%s", tfss, &tr)
+	defer qt.Done()
+	if tr.MinTimestamp < 0 {
+		tr.MinTimestamp = 0
+	}
+	if tr.MaxTimestamp < tr.MinTimestamp {
+		return nil, nil
+	}
+	minDate := uint64(tr.MinTimestamp) / msecPerDay
+	maxDate := uint64(tr.MaxTimestamp) / msecPerDay
+	counts := make([]int, 0, maxDate-minDate+1)
+	idb := s.idb()
+	for date := minDate; date <= maxDate; date++ {
+		trDay := TimeRange{
+			MinTimestamp: int64(date) * msecPerDay,
+			MaxTimestamp: int64(date+1)*msecPerDay - 1,
+		}
+		// searchMetricIDs merges metricIDs from the current indexdb with metricIDs from extDB,
+		// so series registered before the indexdb rotation are counted too.
+		metricIDs, err := idb.searchMetricIDs(qt, tfss, trDay, maxMetrics, deadline)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, len(metricIDs))
+	}
+	return counts, nil
+}
+
 // GetTSDBStatus returns TSDB status data for /api/v1/status/tsdb
 func (s *Storage) GetTSDBStatus(qt *querytracer.Tracer, tfss []*TagFilters, date uint64, focusLabel string, topN, maxMetrics int, deadline uint64) (*TSDBStatus, error) {
 	return s.idb().GetTSDBStatus(qt, tfss, date, focusLabel, topN, maxMetrics, deadline)
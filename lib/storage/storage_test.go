@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
@@ -1206,3 +1207,79 @@ func containsString(a []string, s string) bool {
 	}
 	return false
 }
+
+func TestStorageGetSeriesCountPerDay(t *testing.T) {
+	path := "TestStorageGetSeriesCountPerDay"
+	s := MustOpenStorage(path, 0, 0, 0)
+
+	currentDate := uint64(timestampFromTime(time.Now())) / msecPerDay
+	day1 := int64(currentDate-2) * msecPerDay
+	day2 := int64(currentDate-1) * msecPerDay
+	var mrs []MetricRow
+	addRow := func(metricName string, timestamp int64) {
+		var mn MetricName
+		mn.MetricGroup = []byte(metricName)
+		mn.AddTag("job", "test")
+		mrs = append(mrs, MetricRow{
+			MetricNameRaw: mn.marshalRaw(nil),
+			Timestamp:     timestamp,
+			Value:         1,
+		})
+	}
+	addRow("metric_1", day1+1000)
+	addRow("metric_2", day1+2000)
+	addRow("metric_3", day1+3000)
+	addRow("metric_1", day2+1000)
+	addRow("metric_2", day2+2000)
+	if err := s.AddRows(mrs, defaultPrecisionBits); err != nil {
+		t.Fatalf("unexpected error when adding rows: %s", err)
+	}
+	s.DebugFlush()
+
+	tfs := NewTagFilters()
+	if err := tfs.Add(nil, []byte("metric_.*"), false, true); err != nil {
+		t.Fatalf("cannot add tag filter: %s", err)
+	}
+	tr := TimeRange{
+		MinTimestamp: day1 - msecPerDay,
+		MaxTimestamp: day2 + msecPerDay - 1,
+	}
+	// The limit on the number of series must result in ErrTooManyTimeseries
+	_, err := s.GetSeriesCountPerDay(nil, []*TagFilters{tfs}, tr, 1, noDeadline)
+	if !errors.Is(err, ErrTooManyTimeseries) {
+		t.Fatalf("expecting ErrTooManyTimeseries; got %v", err)
+	}
+
+	counts, err := s.GetSeriesCountPerDay(nil, []*TagFilters{tfs}, tr, 1e5, noDeadline)
+	if err != nil {
+		t.Fatalf("unexpected error in GetSeriesCountPerDay: %s", err)
+	}
+	countsExpected := []int{0, 3, 2}
+	if !reflect.DeepEqual(counts, countsExpected) {
+		t.Fatalf("unexpected series counts per day; got %v; want %v", counts, countsExpected)
+	}
+
+	// Empty time range
+	counts, err = s.GetSeriesCountPerDay(nil, []*TagFilters{tfs}, TimeRange{MinTimestamp: day2, MaxTimestamp: day1}, 1e5, noDeadline)
+	if err != nil {
+		t.Fatalf("unexpected error in GetSeriesCountPerDay for empty time range: %s", err)
+	}
+	if len(counts) != 0 {
+		t.Fatalf("expecting empty counts for empty time range; got %v", counts)
+	}
+
+	// Series from the previous indexdb must be counted too
+	s.mustRotateIndexDB()
+	counts, err = s.GetSeriesCountPerDay(nil, []*TagFilters{tfs}, tr, 1e5, noDeadline)
+	if err != nil {
+		t.Fatalf("unexpected error in GetSeriesCountPerDay after indexdb rotation: %s", err)
+	}
+	if !reflect.DeepEqual(counts, countsExpected) {
+		t.Fatalf("unexpected series counts per day after indexdb rotation; got %v; want %v", counts, countsExpected)
+	}
+
+	s.MustClose()
+	if err := os.RemoveAll(path); err != nil {
+		t.Fatalf("cannot remove %q: %s", path, err)
+	}
+}